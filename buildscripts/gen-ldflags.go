@@ -0,0 +1,110 @@
+// +build ignore
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"os"
+	"os/exec"
+	"strings"
+)
+
+// vendored libraries reported by ‘minio-xl version’
+var keyDependencies = []string{
+	"github.com/minio/cli",
+	"github.com/gorilla/mux",
+	"github.com/gorilla/rpc/v2",
+	"github.com/facebookgo/httpdown",
+	"github.com/dustin/go-humanize",
+}
+
+// vendorRevisions returns revisions of all packages listed in ‘vendor/vendor.json’.
+func vendorRevisions() map[string]string {
+	vendorFile, e := os.Open("vendor/vendor.json")
+	if e != nil {
+		fmt.Fprintln(os.Stderr, "gen-ldflags: Unable to open ‘vendor/vendor.json’. Error: ", e)
+		os.Exit(1)
+	}
+	defer vendorFile.Close()
+
+	var manifest struct {
+		Package []struct {
+			Path     string `json:"path"`
+			Revision string `json:"revision"`
+		} `json:"package"`
+	}
+	if e = json.NewDecoder(vendorFile).Decode(&manifest); e != nil {
+		fmt.Fprintln(os.Stderr, "gen-ldflags: Unable to parse ‘vendor/vendor.json’. Error: ", e)
+		os.Exit(1)
+	}
+	revisions := make(map[string]string)
+	for _, pkg := range manifest.Package {
+		revisions[pkg.Path] = pkg.Revision
+	}
+	return revisions
+}
+
+// erasureVersion returns version of the Intel ISA-L release bundled in ‘pkg/erasure’.
+func erasureVersion() string {
+	notesFile, e := os.Open("pkg/erasure/RELEASE-NOTES.INTEL")
+	if e != nil {
+		fmt.Fprintln(os.Stderr, "gen-ldflags: Unable to open erasure release notes. Error: ", e)
+		os.Exit(1)
+	}
+	defer notesFile.Close()
+
+	// first line after the banner reads ‘v2.10 Intel Intelligent Storage Acceleration Library Release Notes’
+	scanner := bufio.NewScanner(notesFile)
+	for scanner.Scan() {
+		if line := strings.TrimSpace(scanner.Text()); strings.HasPrefix(line, "v") {
+			return "isa-l-" + strings.Fields(line)[0]
+		}
+	}
+	return "isa-l-unknown"
+}
+
+// shortCommitID returns the abbreviated commit-id hash of the last commit, in-tree packages carry no version of their own.
+func shortCommitID() string {
+	commit, e := exec.Command("git", "log", "--format=%h", "-n1").Output()
+	if e != nil {
+		fmt.Fprintln(os.Stderr, "Error generating git commit-id: ", e)
+		os.Exit(1)
+	}
+	return strings.TrimSpace(string(commit))
+}
+
+func main() {
+	revisions := vendorRevisions()
+	var dependencies []string
+	for _, path := range keyDependencies {
+		revision, ok := revisions[path]
+		if !ok {
+			fmt.Fprintln(os.Stderr, "gen-ldflags: ‘"+path+"’ is not vendored.")
+			os.Exit(1)
+		}
+		dependencies = append(dependencies, path+"="+revision)
+	}
+	dependencies = append(dependencies, "github.com/minio/minio-xl/pkg/erasure="+erasureVersion())
+	dependencies = append(dependencies, "github.com/minio/minio-xl/pkg/probe="+shortCommitID())
+
+	// value of ‘minioXLDependencies’ in version-main.go
+	fmt.Println("-X main.minioXLDependencies=" + strings.Join(dependencies, ","))
+}
@@ -17,9 +17,12 @@
 package main
 
 import (
+	"io/ioutil"
 	"net/http"
+	"os"
 	"time"
 
+	"github.com/minio/minio-xl/pkg/erasure"
 	. "gopkg.in/check.v1"
 )
 
@@ -31,3 +34,57 @@ func (s *ConfigSuite) TestVersion(c *C) {
 	_, err := time.Parse(minioXLVersion, http.TimeFormat)
 	c.Assert(err, NotNil)
 }
+
+func (s *ConfigSuite) TestVersionDependencies(c *C) {
+	dependencies := getDependencies("github.com/minio/cli=be072a0, github.com/minio/minio-xl/pkg/erasure=isa-l-v2.10,invalid")
+	c.Assert(dependencies, DeepEquals, map[string]string{
+		"github.com/minio/cli":                  "be072a0",
+		"github.com/minio/minio-xl/pkg/erasure": "isa-l-v2.10",
+	})
+	c.Assert(len(getDependencies("")), Equals, 0)
+
+	message := versionMessage{Version: "v", ReleaseTag: "r", CommitID: "c", Dependencies: dependencies}
+	c.Assert(message.String(), Equals, "Version: v\nRelease-Tag: r\nCommit-ID: c\nDependencies:\n"+
+		"  github.com/minio/cli: be072a0\n  github.com/minio/minio-xl/pkg/erasure: isa-l-v2.10")
+}
+
+func (s *ConfigSuite) TestSelftest(c *C) {
+	defer erasure.SetCodec(erasure.CodecISAL)
+	for _, codec := range []string{erasure.CodecISAL, erasure.CodecGo} {
+		c.Assert(erasure.SetCodec(codec), IsNil)
+		message, err := selftest(4, 4, 100000, 2)
+		c.Assert(err, IsNil)
+		c.Assert(message.Codec, Equals, erasure.GetCodec())
+		c.Assert(message.EncodeSpeed > 0 && message.DecodeSpeed > 0, Equals, true)
+	}
+
+	message := versionMessage{Version: "v", ReleaseTag: "r", CommitID: "c", Codec: "isal/avx2"}
+	c.Assert(message.String(), Equals, "Version: v\nRelease-Tag: r\nCommit-ID: c\nErasure-Codec: isal/avx2")
+}
+
+func (s *ConfigSuite) TestResumeMetadataExport(c *C) {
+	file, e := ioutil.TempFile(os.TempDir(), "export-")
+	c.Assert(e, IsNil)
+	defer os.Remove(file.Name())
+	defer file.Close()
+
+	// nothing written yet
+	startAfter, err := resumeMetadataExport(file)
+	c.Assert(err, IsNil)
+	c.Assert(startAfter, Equals, "")
+
+	// export interrupted in the middle of a record
+	complete := `{"bucket":"foo","bucketMetadata":{}}` + "\n" + `{"bucket":"foo","object":"a/b","objectMetadata":{}}` + "\n"
+	_, e = file.WriteString(complete + `{"bucket":"foo","obj`)
+	c.Assert(e, IsNil)
+	_, e = file.Seek(0, os.SEEK_SET)
+	c.Assert(e, IsNil)
+	startAfter, err = resumeMetadataExport(file)
+	c.Assert(err, IsNil)
+	c.Assert(startAfter, Equals, "foo/a/b")
+	_, e = file.WriteString(`{"bucket":"foo","object":"c"}` + "\n")
+	c.Assert(e, IsNil)
+	data, e := ioutil.ReadFile(file.Name())
+	c.Assert(e, IsNil)
+	c.Assert(string(data), Equals, complete+`{"bucket":"foo","object":"c"}`+"\n")
+}
@@ -17,11 +17,14 @@
 package main
 
 import (
+	"encoding/json"
 	"fmt"
 	"os"
 	"sync"
 
 	"path/filepath"
+
+	"github.com/minio/minio-xl/pkg/probe"
 )
 
 type logLevel int
@@ -169,3 +172,57 @@ func ProgramName() string {
 	_, progName := filepath.Split(os.Args[0])
 	return progName
 }
+
+// message - output of a command, every line a command prints is a message so that with
+// --json each line is a single json object
+type message interface {
+	String() string
+	JSON() string
+}
+
+// printMessage - print msg as json with --json, as text otherwise
+func printMessage(msg message) {
+	if globalJSONFlag {
+		Println(msg.JSON())
+		return
+	}
+	Println(msg.String())
+}
+
+// jsonMessage - json formatted msg, shared by the JSON methods of all messages
+func jsonMessage(msg interface{}) string {
+	b, err := json.Marshal(msg)
+	errorIf(probe.NewError(err), "Unable to marshal json", nil)
+	return string(b)
+}
+
+// infoMessage - free form informational message
+type infoMessage struct {
+	Message string `json:"message"`
+}
+
+func (i infoMessage) String() string {
+	return i.Message
+}
+
+// JSON - json formatted output
+func (i infoMessage) JSON() string {
+	return jsonMessage(i)
+}
+
+// startMessage - a service started listening
+type startMessage struct {
+	// Service is one of ‘server’, ‘website’, ‘metrics’ or ‘controller’
+	Service string `json:"service"`
+	Address string `json:"address"`
+	PID     int    `json:"pid"`
+}
+
+func (s startMessage) String() string {
+	return fmt.Sprintf("Starting minio %s on: %s, PID: %d", s.Service, s.Address, s.PID)
+}
+
+// JSON - json formatted output
+func (s startMessage) JSON() string {
+	return jsonMessage(s)
+}
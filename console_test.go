@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+
+	"github.com/minio/minio-xl/pkg/xl"
+
+	. "gopkg.in/check.v1"
+)
+
+type MessageSuite struct{}
+
+var _ = Suite(&MessageSuite{})
+
+// assertFields - json of msg is a single object carrying all the fields
+func assertFields(c *C, msg message, fields ...string) {
+	var object map[string]interface{}
+	c.Assert(json.Unmarshal([]byte(msg.JSON()), &object), IsNil)
+	for _, field := range fields {
+		_, ok := object[field]
+		c.Assert(ok, Equals, true, Commentf("%s is missing in %s", field, msg.JSON()))
+	}
+}
+
+func (s *MessageSuite) TestMessageSchema(c *C) {
+	assertFields(c, infoMessage{"Success!"}, "message")
+	assertFields(c, startMessage{Service: "server", Address: "http://localhost:9000", PID: 1}, "service", "address", "pid")
+	assertFields(c, defaultBucketMessage{Bucket: "bucket"}, "bucket", "created")
+	assertFields(c, versionMessage{Version: "1", ReleaseTag: "tag", CommitID: "id"}, "version", "releaseTag", "commitID", "dependencies", "codec")
+	assertFields(c, accessKeys{&AuthUser{Name: "admin"}}, "name", "accessKeyId", "secretAccessKey")
+	assertFields(c, clusterStatus{ClusterStatusRep{Servers: []ServerStatusEntry{{Host: "localhost"}}}},
+		"servers", "totalServers", "onlineServers", "healthyServers", "disks", "total", "free", "objects",
+		"multipartUploads")
+	assertFields(c, rebalanceProgress{RebalanceProgress: xl.RebalanceProgress{Bucket: "bucket", Object: "object"}},
+		"bucket", "object", "size", "moved", "movedBytes")
+	assertFields(c, fsckProblem{FsckProblem: xl.FsckProblem{Kind: xl.FsckOrphan, Path: "/mnt/export1/object"}},
+		"kind", "path", "recoverable", "fixed")
+	assertFields(c, fsckProblem{FsckProblem: xl.FsckProblem{Kind: xl.FsckDangling, Bucket: "bucket", Object: "object", MissingBlocks: 1}},
+		"kind", "bucket", "object", "missingBlocks", "recoverable", "fixed")
+	assertFields(c, bucketStatsMessage{BucketStats: xl.BucketStats{Bucket: "bucket"}}, "bucket", "objects", "size",
+		"rescanned", "objectsDiff", "sizeDiff")
+	assertFields(c, fsckSummary{FsckSummary: xl.FsckSummary{}}, "buckets", "objects", "orphans", "orphanedBytes", "dangling",
+		"unrecoverable", "fixed", "reclaimedBytes", "corrupt", "damagedMetadata", "repaired")
+	assertFields(c, fsckProblem{FsckProblem: xl.FsckProblem{Kind: xl.FsckCorrupt, Bucket: "bucket", Object: "object", CorruptBlocks: 1}},
+		"kind", "bucket", "object", "corruptBlocks", "recoverable", "fixed")
+	assertFields(c, metadataRepairMessage{MetadataRepair: xl.MetadataRepair{Bucket: "bucket", Object: "object", Reason: "no integrity file"}},
+		"bucket", "object", "damagedCopies", "fromIntegrity", "repaired", "reason")
+	assertFields(c, metadataRepairSummary{}, "objects", "damaged", "repaired", "unrecoverable")
+	assertFields(c, integrityMessage{Bucket: "bucket", Object: "object"}, "bucket", "object", "version", "created", "size",
+		"erasureK", "erasureM", "blockSize", "chunkCount", "md5sum", "sha512sum", "blocks")
+	assertFields(c, contentTypeMessage{}, "bucket", "prefix", "contentType", "objects", "updated")
+	assertFields(c, touchMessage{}, "bucket", "key", "prefix", "touched")
+	assertFields(c, searchMessage{Objects: []string{}}, "bucket", "key", "value", "objects")
+	assertFields(c, deleteByQueryMessage{DeleteByQueryRep{Matched: []string{}, Failures: []DeleteFailure{}}},
+		"bucket", "key", "value", "dryRun", "matched", "deleted", "failures")
+	assertFields(c, lockedMessage{Objects: []lockedObject{}}, "bucket", "prefix", "objects")
+	assertFields(c, duMessage{Prefixes: []prefixUsage{{Prefix: "photos/"}}}, "bucket", "prefix", "size", "objects", "prefixes")
+	assertFields(c, registerMessage{Controller: "localhost:9001", Attempt: 1}, "controller", "attempt", "registered")
+	assertFields(c, dumpMessage{Goroutines: "goroutines.txt", Heap: "heap.pprof"}, "goroutines", "heap")
+	assertFields(c, selftestMessage{Codec: "go"}, "codec", "dataBlocks", "parityBlocks", "encodeBytesPerSecond",
+		"decodeBytesPerSecond")
+}
+
+func (s *MessageSuite) TestMessageString(c *C) {
+	c.Assert(startMessage{Service: "website", Address: "http://localhost:8080", PID: 1}.String(), Equals,
+		"Starting minio website on: http://localhost:8080, PID: 1")
+	c.Assert(defaultBucketMessage{Bucket: "bucket", Created: true}.String(), Equals, "Created default bucket: bucket")
+	c.Assert(defaultBucketMessage{Bucket: "bucket"}.String(), Equals, "Default bucket bucket is already present.")
+
+	// dry runs only read differently
+	orphan := xl.FsckProblem{Kind: xl.FsckOrphan, Path: "/mnt/export1/object", Size: 1024, Fixed: true}
+	c.Assert(fsckProblem{orphan, true}.String(), Equals, "Would remove orphan ‘/mnt/export1/object’ (1.0KiB).")
+	c.Assert(fsckProblem{orphan, true}.JSON(), Equals, fsckProblem{orphan, false}.JSON())
+	progress := xl.RebalanceProgress{Bucket: "bucket", Object: "object", Moved: true}
+	c.Assert(rebalanceProgress{progress, true}.JSON(), Equals, rebalanceProgress{progress, false}.JSON())
+}
@@ -0,0 +1,238 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"errors"
+	"math/rand"
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// controllerLease - makes exactly one of a set of peer controllers the active one. Every controller
+// votes for one holder at a time, a controller holding the votes of a majority of the peers is the
+// leader until its lease expires unless renewed. Every new leader takes a higher term, servers refuse
+// management by a controller with a lower term than they have seen, so a deposed leader which has
+// not noticed yet cannot manage them anymore.
+type controllerLease struct {
+	lock  sync.Mutex
+	id    string
+	peers []string
+	ssl   bool
+	ttl   time.Duration
+
+	// lease held by this controller, expired unless this controller is the leader
+	term    uint64
+	expires time.Time
+	leader  string
+
+	// vote given by this controller, to itself or to a peer
+	vote        string
+	voteTerm    uint64
+	voteExpires time.Time
+}
+
+// newControllerLease - lease of the controller reachable at id shared with peers, port 9001 is used
+// for peers without one
+func newControllerLease(id string, peers []string, ssl bool, ttl time.Duration) *controllerLease {
+	l := &controllerLease{id: id, ssl: ssl, ttl: ttl}
+	for _, peer := range peers {
+		if _, _, err := net.SplitHostPort(peer); err != nil {
+			peer = net.JoinHostPort(peer, "9001")
+		}
+		l.peers = append(l.peers, peer)
+	}
+	return l
+}
+
+// getID - address peers and servers know this controller by, empty without peers
+func (l *controllerLease) getID() string {
+	if l == nil {
+		return ""
+	}
+	return l.id
+}
+
+// isLeader - term of the lease if held by this controller, a controller without peers always leads
+func (l *controllerLease) isLeader() (uint64, bool) {
+	if l == nil {
+		return 0, true
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	return l.term, time.Now().Before(l.expires)
+}
+
+// getLeader - last known holder of the lease
+func (l *controllerLease) getLeader() string {
+	if l == nil {
+		return ""
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if time.Now().Before(l.expires) {
+		return l.id
+	}
+	return l.leader
+}
+
+// errNotLeader - management requests sent to a controller not holding the lease
+func (l *controllerLease) errNotLeader() error {
+	if leader := l.getLeader(); leader != "" {
+		return errors.New("Not the active controller, the lease is held by " + leader)
+	}
+	return errors.New("Not the active controller, no controller holds the lease")
+}
+
+// grant - vote for the candidate unless the vote was given to another candidate whose lease has not
+// expired yet, or the candidate is behind the term of the vote
+func (l *controllerLease) grant(args LeaseArgs, rep *LeaseRep) bool {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	now := time.Now()
+	free := l.vote == "" || !now.Before(l.voteExpires)
+	if args.Term >= l.voteTerm && (free || l.vote == args.Holder) {
+		// never release the vote before the candidate considers its lease expired
+		ttl := args.TTL
+		if ttl < l.ttl {
+			ttl = l.ttl
+		}
+		l.vote, l.voteTerm, l.voteExpires = args.Holder, args.Term, now.Add(ttl)
+		rep.Granted = true
+	}
+	rep.Holder, rep.Term = l.vote, l.voteTerm
+	return rep.Granted
+}
+
+// nextTerm - term of the current lease when renewing it, a new higher term otherwise
+func (l *controllerLease) nextTerm() uint64 {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	if time.Now().Before(l.expires) {
+		return l.term
+	}
+	if l.voteTerm > l.term {
+		return l.voteTerm + 1
+	}
+	return l.term + 1
+}
+
+// update - record the votes of a round started at start, lease is held if granted by a majority
+func (l *controllerLease) update(term uint64, start time.Time, replies []LeaseRep) {
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	var granted int
+	holders := make(map[string]int)
+	for _, rep := range replies {
+		if rep.Granted {
+			granted++
+		} else if rep.Holder != "" {
+			holders[rep.Holder]++
+		}
+		if rep.Term > l.term {
+			l.term = rep.Term
+		}
+	}
+	if granted*2 > len(replies) {
+		l.term, l.expires, l.leader = term, start.Add(l.ttl), l.id
+		return
+	}
+	l.expires = time.Time{}
+	l.leader = ""
+	for holder, votes := range holders {
+		if votes > holders[l.leader] {
+			l.leader = holder
+		}
+	}
+	// give up the own vote, candidates splitting the votes would otherwise never make progress
+	if l.vote == l.id {
+		l.vote = ""
+	}
+}
+
+// Lease vote for a peer asking for the lease, servers managed by the leader are taken over
+// so they are still managed after a failover
+func (s *controllerRPCService) Lease(r *http.Request, args *LeaseArgs, rep *LeaseRep) error {
+	if s.lease == nil {
+		return errors.New("Controller has no peers")
+	}
+	if s.lease.grant(*args, rep) && args.Holder != s.lease.id {
+		s.lock.Lock()
+		s.serverList = args.Servers
+		s.lock.Unlock()
+	}
+	return nil
+}
+
+// renewLease - one round of acquiring or renewing the lease, votes of all peers are asked for in
+// parallel. Unreachable peers count as not granting their vote.
+func (s *controllerRPCService) renewLease() {
+	l := s.lease
+	args := LeaseArgs{Holder: l.id, Term: l.nextTerm(), TTL: l.ttl}
+	s.lock.Lock()
+	args.Servers = append([]ServerRep{}, s.serverList...)
+	s.lock.Unlock()
+	// lease starts before any vote is given, it always expires before the votes do
+	start := time.Now()
+	replies := make([]LeaseRep, len(l.peers)+1)
+	l.grant(args, &replies[0])
+	var wg sync.WaitGroup
+	for i, peer := range l.peers {
+		wg.Add(1)
+		go func(rep *LeaseRep, peer string) {
+			defer wg.Done()
+			if err := callRPC("Controller.Lease", peer, l.ssl, args, rep); err != nil {
+				*rep = LeaseRep{}
+			}
+		}(&replies[i+1], peer)
+	}
+	wg.Wait()
+	l.update(args.Term, start, replies)
+}
+
+// maintainLease - renew the lease three times per ttl, controllers not holding it retry at
+// random intervals so they do not keep splitting the votes
+func (s *controllerRPCService) maintainLease() {
+	for {
+		s.renewLease()
+		interval := s.lease.ttl / 3
+		if _, ok := s.lease.isLeader(); !ok {
+			interval += time.Duration(rand.Int63n(int64(interval) + 1))
+		}
+		time.Sleep(interval)
+	}
+}
+
+// controllerFence - controller with the highest lease term which managed this server
+type controllerFence struct {
+	lock       sync.Mutex
+	controller string
+	term       uint64
+}
+
+// accept - management by a controller is accepted unless another controller took over since
+func (f *controllerFence) accept(arg *ServerArg) error {
+	f.lock.Lock()
+	defer f.lock.Unlock()
+	if arg.Term < f.term {
+		return errors.New("Controller " + arg.Controller + " lost its lease to " + f.controller)
+	}
+	f.term, f.controller = arg.Term, arg.Controller
+	return nil
+}
@@ -17,8 +17,6 @@
 package main
 
 import (
-	"crypto/tls"
-	"encoding/json"
 	"fmt"
 	"net"
 	"net/http"
@@ -46,6 +44,15 @@ EXAMPLES:
 
   2. Fetch stored access keys
       $ minio-xl {{.Name}} keys
+
+  3. Fetch status of all the servers registered with a running controller
+      $ minio-xl {{.Name}} status
+
+  4. Fetch status of all the servers in json
+      $ minio-xl --json {{.Name}} status
+
+  5. Start one of three controllers, exactly one of them manages the servers at a time
+      $ minio-xl --controller-peers ctrl2:9001,ctrl3:9001 --lease-ttl 10s {{.Name}}
 `,
 }
 
@@ -58,12 +65,10 @@ func configureControllerRPC(conf minioConfig, rpcHandler http.Handler) (*http.Se
 		MaxHeaderBytes: 1 << 20,
 	}
 	if conf.TLS {
-		var err error
-		rpcServer.TLSConfig = &tls.Config{}
-		rpcServer.TLSConfig.Certificates = make([]tls.Certificate, 1)
-		rpcServer.TLSConfig.Certificates[0], err = tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		var err *probe.Error
+		rpcServer.TLSConfig, err = getTLSConfig(conf)
 		if err != nil {
-			return nil, probe.NewError(err)
+			return nil, err.Trace()
 		}
 	}
 	host, port, err := net.SplitHostPort(conf.ControllerAddress)
@@ -89,21 +94,49 @@ func configureControllerRPC(conf minioConfig, rpcHandler http.Handler) (*http.Se
 		}
 	}
 	for _, host := range hosts {
+		scheme := "http://"
 		if conf.TLS {
-			Printf("Starting minio controller on: https://%s:%s, PID: %d\n", host, port, os.Getpid())
-		} else {
-			Printf("Starting minio controller on: http://%s:%s, PID: %d\n", host, port, os.Getpid())
+			scheme = "https://"
 		}
+		printMessage(startMessage{Service: "controller", Address: scheme + net.JoinHostPort(host, port), PID: os.Getpid()})
 	}
 	return rpcServer, nil
 }
 
+// getAdvertisedAddress address peers reach a controller or server listening on address at, the
+// hostname is used if it listens on all interfaces
+func getAdvertisedAddress(address string) (string, *probe.Error) {
+	host, port, err := net.SplitHostPort(address)
+	if err != nil {
+		return "", probe.NewError(err)
+	}
+	if host == "" {
+		if host, err = os.Hostname(); err != nil {
+			return "", probe.NewError(err)
+		}
+	}
+	return net.JoinHostPort(host, port), nil
+}
+
 // startController starts a minio controller
 func startController(conf minioConfig) *probe.Error {
-	rpcServer, err := configureControllerRPC(conf, getControllerRPCHandler(conf.Anonymous))
+	controller := new(controllerRPCService)
+	if len(conf.ControllerPeers) > 0 {
+		id, err := getAdvertisedAddress(conf.ControllerAddress)
+		if err != nil {
+			return err.Trace()
+		}
+		controller.lease = newControllerLease(id, conf.ControllerPeers, conf.TLS, conf.LeaseTTL)
+	}
+	rpcServer, err := configureControllerRPC(conf, getControllerRPCHandler(conf.Anonymous, controller))
 	if err != nil {
 		return err.Trace()
 	}
+	trackRPCServer(rpcServer, conf.RPCIdleTimeout)
+	globalRPCTransport = newRPCTransport(conf.RPCIdleTimeout)
+	if controller.lease != nil {
+		go controller.maintainLease()
+	}
 	// Setting rate limit to 'zero' no ratelimiting implemented
 	if err := minhttp.ListenAndServeLimited(0, rpcServer); err != nil {
 		return err.Trace()
@@ -157,9 +190,7 @@ func (a accessKeys) String() string {
 
 // JSON - json formatted output
 func (a accessKeys) JSON() string {
-	b, err := json.Marshal(a)
-	errorIf(probe.NewError(err), "Unable to marshal json", nil)
-	return string(b)
+	return jsonMessage(a)
 }
 
 // firstTimeAuth first time authorization
@@ -169,17 +200,11 @@ func firstTimeAuth() *probe.Error {
 		return err.Trace()
 	}
 	if conf != nil {
-		Println("Running for first time, generating access keys.")
+		printMessage(infoMessage{"Running for first time, generating access keys."})
 		for _, user := range conf.Users {
-			if globalJSONFlag {
-				Println(accessKeys{user}.JSON())
-			} else {
-				Println(accessKeys{user})
-			}
-
+			printMessage(accessKeys{user})
 		}
-		Println("To fetch your keys again.")
-		Println("  $ minio-xl controller keys")
+		printMessage(infoMessage{"To fetch your keys again.\n  $ minio-xl controller keys"})
 	}
 	return nil
 }
@@ -191,18 +216,32 @@ func getControllerConfig(c *cli.Context) minioConfig {
 		Fatalln("Both certificate and key are required to enable https.")
 	}
 	tls := (certFile != "" && keyFile != "")
+	var peers []string
+	for _, peer := range strings.Split(c.GlobalString("controller-peers"), ",") {
+		if peer = strings.TrimSpace(peer); peer != "" {
+			peers = append(peers, peer)
+		}
+	}
+	if len(peers) > 0 && c.GlobalDuration("lease-ttl") <= 0 {
+		Fatalln("Lease ttl must be positive for controllers with peers.")
+	}
 	return minioConfig{
 		ControllerAddress: c.GlobalString("address-controller"),
 		TLS:               tls,
+		TLSMinVersion:     c.GlobalString("tls-min-version"),
+		TLSCiphers:        c.GlobalString("tls-ciphers"),
 		CertFile:          certFile,
 		KeyFile:           keyFile,
 		RateLimit:         c.GlobalInt("ratelimit"),
 		Anonymous:         c.GlobalBool("anonymous"),
+		RPCIdleTimeout:    c.GlobalDuration("rpc-idle-timeout"),
+		ControllerPeers:   peers,
+		LeaseTTL:          c.GlobalDuration("lease-ttl"),
 	}
 }
 
 func controllerMain(c *cli.Context) {
-	if c.Args().Present() && c.Args().First() != "keys" {
+	if c.Args().Present() && c.Args().First() != "keys" && c.Args().First() != "status" {
 		cli.ShowCommandHelpAndExit(c, "controller", 1)
 	}
 
@@ -211,16 +250,19 @@ func controllerMain(c *cli.Context) {
 		fatalIf(err.Trace(), "Failed to fetch keys for minio controller.", nil)
 		if conf != nil {
 			for _, user := range conf.Users {
-				if globalJSONFlag {
-					Println(accessKeys{user}.JSON())
-				} else {
-					Println(accessKeys{user})
-				}
+				printMessage(accessKeys{user})
 			}
 		}
 		return
 	}
 
+	if c.Args().First() == "status" {
+		status, err := getClusterStatus(getControllerConfig(c))
+		fatalIf(err.Trace(), "Failed to fetch status from minio controller.", nil)
+		printMessage(clusterStatus{status})
+		return
+	}
+
 	err := firstTimeAuth()
 	fatalIf(err.Trace(), "Failed to generate keys for minio.", nil)
 
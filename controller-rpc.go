@@ -24,13 +24,17 @@ import (
 	"os"
 	"runtime"
 	"strings"
+	"sync"
 
 	"github.com/gorilla/rpc/v2/json"
 	"github.com/minio/minio-xl/pkg/probe"
 )
 
 type controllerRPCService struct {
+	lock       sync.Mutex
 	serverList []ServerRep
+	// lease shared with peer controllers, nil if this is the only controller
+	lease *controllerLease
 }
 
 // generateAuth generate new auth keys for a user
@@ -158,7 +162,22 @@ func readAuthConfig() (*AuthConfig, *probe.Error) {
 	return authConfig, nil
 }
 
+// proxyRequest request on behalf of no controller, as sent by the controller commands
 func proxyRequest(method, host string, ssl bool, res interface{}) *probe.Error {
+	return callRPC(method, host, ssl, ServerArg{}, res)
+}
+
+// proxyRequest management request to a server, refused unless this controller holds the lease
+func (s *controllerRPCService) proxyRequest(method, host string, ssl bool, res interface{}) *probe.Error {
+	term, ok := s.lease.isLeader()
+	if !ok {
+		return probe.NewError(s.lease.errNotLeader())
+	}
+	return callRPC(method, host, ssl, ServerArg{Controller: s.lease.getID(), Term: term}, res)
+}
+
+// callRPC send a signed rpc request, port 9002 of servers is used if host has none
+func callRPC(method, host string, ssl bool, arg, res interface{}) *probe.Error {
 	u := &url.URL{}
 	if ssl {
 		u.Scheme = "https"
@@ -175,13 +194,13 @@ func proxyRequest(method, host string, ssl bool, res interface{}) *probe.Error {
 
 	op := rpcOperation{
 		Method:  method,
-		Request: ServerArg{},
+		Request: arg,
 	}
 	authConfig, err := readAuthConfig()
 	if err != nil {
 		return err.Trace()
 	}
-	request, err := newRPCRequest(authConfig, u.String(), op, nil)
+	request, err := newRPCRequest(authConfig, u.String(), op, globalRPCTransport)
 	if err != nil {
 		return err.Trace()
 	}
@@ -190,6 +209,7 @@ func proxyRequest(method, host string, ssl bool, res interface{}) *probe.Error {
 	if err != nil {
 		return err.Trace()
 	}
+	defer resp.Body.Close()
 	if err := json.DecodeClientResponse(resp.Body, res); err != nil {
 		return probe.NewError(err)
 	}
@@ -198,7 +218,7 @@ func proxyRequest(method, host string, ssl bool, res interface{}) *probe.Error {
 
 // StorageStats returns dummy storage stats
 func (s *controllerRPCService) StorageStats(r *http.Request, args *ControllerArgs, reply *StorageStatsRep) error {
-	err := proxyRequest("XL.StorageStats", args.Host, args.SSL, reply)
+	err := s.proxyRequest("XL.StorageStats", args.Host, args.SSL, reply)
 	if err != nil {
 		return probe.WrapError(err)
 	}
@@ -207,7 +227,7 @@ func (s *controllerRPCService) StorageStats(r *http.Request, args *ControllerArg
 
 // RebalaceStats returns dummy rebalance stats
 func (s *controllerRPCService) RebalanceStats(r *http.Request, args *ControllerArgs, reply *RebalanceStatsRep) error {
-	err := proxyRequest("XL.RebalanceStats", args.Host, args.SSL, reply)
+	err := s.proxyRequest("XL.RebalanceStats", args.Host, args.SSL, reply)
 	if err != nil {
 		return probe.WrapError(err)
 	}
@@ -215,10 +235,22 @@ func (s *controllerRPCService) RebalanceStats(r *http.Request, args *ControllerA
 }
 
 func (s *controllerRPCService) AddServer(r *http.Request, args *ControllerArgs, res *ServerRep) error {
-	err := proxyRequest("Server.Add", args.Host, args.SSL, res)
+	err := s.proxyRequest("Server.Add", args.Host, args.SSL, res)
 	if err != nil {
 		return probe.WrapError(err)
 	}
+	// remember where the server was reached, status requests are sent there
+	res.Host = args.Host
+	res.SSL = args.SSL
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	// servers retrying their registration may be added more than once
+	for i, server := range s.serverList {
+		if server.Host == res.Host {
+			s.serverList[i] = *res
+			return nil
+		}
+	}
 	s.serverList = append(s.serverList, *res)
 	return nil
 }
@@ -228,7 +260,7 @@ func (s *controllerRPCService) DiscoverServers(r *http.Request, args *DiscoverAr
 	defer close(c)
 	for _, host := range args.Hosts {
 		go func(c chan DiscoverRepEntry, host string) {
-			err := proxyRequest("Server.Version", host, args.SSL, rep)
+			err := s.proxyRequest("Server.Version", host, args.SSL, rep)
 			if err != nil {
 				c <- DiscoverRepEntry{host, err.ToGoError().Error()}
 				return
@@ -255,7 +287,7 @@ func (s *controllerRPCService) GetControllerNetInfo(r *http.Request, args *Serve
 }
 
 func (s *controllerRPCService) GetServerMemStats(r *http.Request, args *ControllerArgs, res *MemStatsRep) error {
-	err := proxyRequest("Server.MemStats", args.Host, args.SSL, res)
+	err := s.proxyRequest("Server.MemStats", args.Host, args.SSL, res)
 	if err != nil {
 		return probe.WrapError(err)
 	}
@@ -263,7 +295,7 @@ func (s *controllerRPCService) GetServerMemStats(r *http.Request, args *Controll
 }
 
 func (s *controllerRPCService) GetServerDiskStats(r *http.Request, args *ControllerArgs, res *DiskStatsRep) error {
-	err := proxyRequest("Server.DiskStats", args.Host, args.SSL, res)
+	err := s.proxyRequest("Server.DiskStats", args.Host, args.SSL, res)
 	if err != nil {
 		return probe.WrapError(err)
 	}
@@ -271,7 +303,7 @@ func (s *controllerRPCService) GetServerDiskStats(r *http.Request, args *Control
 }
 
 func (s *controllerRPCService) GetServerSysInfo(r *http.Request, args *ControllerArgs, res *SysInfoRep) error {
-	err := proxyRequest("Server.SysInfo", args.Host, args.SSL, res)
+	err := s.proxyRequest("Server.SysInfo", args.Host, args.SSL, res)
 	if err != nil {
 		return probe.WrapError(err)
 	}
@@ -279,12 +311,39 @@ func (s *controllerRPCService) GetServerSysInfo(r *http.Request, args *Controlle
 }
 
 func (s *controllerRPCService) ListServers(r *http.Request, args *ControllerArgs, res *ListRep) error {
+	s.lock.Lock()
+	defer s.lock.Unlock()
 	res.List = s.serverList
 	return nil
 }
 
+// ClusterStatus fetches status of all registered servers in parallel, unreachable servers
+// are reported with an error instead of failing the whole status
+func (s *controllerRPCService) ClusterStatus(r *http.Request, args *ControllerArgs, res *ClusterStatusRep) error {
+	s.lock.Lock()
+	servers := make([]ServerRep, len(s.serverList))
+	copy(servers, s.serverList)
+	s.lock.Unlock()
+
+	entries := make([]ServerStatusEntry, len(servers))
+	var wg sync.WaitGroup
+	for i, server := range servers {
+		wg.Add(1)
+		go func(i int, server ServerRep) {
+			defer wg.Done()
+			entries[i] = ServerStatusEntry{Host: server.Host, ID: server.ID}
+			if err := s.proxyRequest("Server.Status", server.Host, server.SSL, &entries[i].Status); err != nil {
+				entries[i].Error = err.ToGoError().Error()
+			}
+		}(i, server)
+	}
+	wg.Wait()
+	*res = aggregateClusterStatus(entries)
+	return nil
+}
+
 func (s *controllerRPCService) GetServerVersion(r *http.Request, args *ControllerArgs, res *VersionRep) error {
-	err := proxyRequest("Server.Version", args.Host, args.SSL, res)
+	err := s.proxyRequest("Server.Version", args.Host, args.SSL, res)
 	if err != nil {
 		return probe.WrapError(err)
 	}
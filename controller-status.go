@@ -0,0 +1,166 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"sort"
+	"strings"
+
+	"github.com/dustin/go-humanize"
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// aggregateClusterStatus summarizes status of all the servers, unreachable servers are only counted in total
+func aggregateClusterStatus(entries []ServerStatusEntry) ClusterStatusRep {
+	status := ClusterStatusRep{
+		Servers:      entries,
+		TotalServers: len(entries),
+	}
+	for _, entry := range entries {
+		if entry.Error != "" {
+			continue
+		}
+		status.OnlineServers++
+		if entry.Status.Healthy {
+			status.HealthyServers++
+		}
+		if entry.Status.DiskQuorum.Degraded() {
+			status.DegradedServers++
+		}
+		for _, disk := range entry.Status.Disks {
+			status.Disks++
+			status.Total += disk.Total
+			status.Free += disk.Free
+		}
+		status.Objects += entry.Status.Objects
+		status.MultipartUploads += entry.Status.MultipartUploads
+		status.OrphansRemoved += entry.Status.Orphans.Removed
+		status.DiskCacheHits += entry.Status.DiskCache.Hits
+		status.DiskCacheMisses += entry.Status.DiskCache.Misses
+		status.BucketRequests = addBucketRequests(status.BucketRequests, entry.Status.BucketRequests)
+	}
+	return status
+}
+
+// addBucketRequests - add requests served by a server to those of the cluster, sorted by bucket name
+func addBucketRequests(total, stats []BucketRequestStats) []BucketRequestStats {
+	for _, s := range stats {
+		i := sort.Search(len(total), func(i int) bool { return total[i].Bucket >= s.Bucket })
+		if i == len(total) || total[i].Bucket != s.Bucket {
+			total = append(total, BucketRequestStats{})
+			copy(total[i+1:], total[i:])
+			total[i] = BucketRequestStats{Bucket: s.Bucket, Requests: make(map[string]int64)}
+		}
+		for method, count := range s.Requests {
+			total[i].Requests[method] += count
+		}
+		total[i].Errors += s.Errors
+		total[i].BytesReceived += s.BytesReceived
+		total[i].BytesSent += s.BytesSent
+	}
+	return total
+}
+
+// getClusterStatus fetch cluster status from a running controller
+func getClusterStatus(conf minioConfig) (ClusterStatusRep, *probe.Error) {
+	host, port, e := net.SplitHostPort(conf.ControllerAddress)
+	if e != nil {
+		return ClusterStatusRep{}, probe.NewError(e)
+	}
+	if host == "" {
+		host = "localhost"
+	}
+	var status ClusterStatusRep
+	if err := proxyRequest("Controller.ClusterStatus", net.JoinHostPort(host, port), conf.TLS, &status); err != nil {
+		return ClusterStatusRep{}, err.Trace(conf.ControllerAddress)
+	}
+	return status, nil
+}
+
+// clusterStatus - printable cluster status
+type clusterStatus struct {
+	ClusterStatusRep
+}
+
+func (s clusterStatus) String() string {
+	var lines []string
+	line := fmt.Sprintf("Servers: %d, Online: %d, Healthy: %d", s.TotalServers, s.OnlineServers, s.HealthyServers)
+	if s.DegradedServers > 0 {
+		line = line + fmt.Sprintf(", Degraded: %d", s.DegradedServers)
+	}
+	lines = append(lines, line)
+	lines = append(lines, fmt.Sprintf("Disks: %d, Total: %s, Free: %s, Objects: %d, Multipart uploads: %d", s.Disks,
+		humanize.IBytes(uint64(s.Total)), humanize.IBytes(uint64(s.Free)), s.Objects, s.MultipartUploads))
+	for _, entry := range s.Servers {
+		if entry.Error != "" {
+			lines = append(lines, fmt.Sprintf("  %s: Unreachable, %s", entry.Host, entry.Error))
+			continue
+		}
+		health := "Healthy"
+		if !entry.Status.Healthy {
+			health = "Unhealthy"
+		} else if quorum := entry.Status.DiskQuorum; quorum.Degraded() {
+			health = fmt.Sprintf("Degraded, %d of %d disks online", quorum.Online, quorum.Total)
+		}
+		line := fmt.Sprintf("  %s: %s, Disks: %d, Buckets: %d, Objects: %d, Multipart uploads: %d", entry.Host, health,
+			len(entry.Status.Disks), entry.Status.Buckets, entry.Status.Objects, entry.Status.MultipartUploads)
+		if entry.Status.Orphans.Removed > 0 {
+			line = line + fmt.Sprintf(", Orphans removed: %d (%s)", entry.Status.Orphans.Removed,
+				humanize.IBytes(uint64(entry.Status.Orphans.ReclaimedBytes)))
+		}
+		if cache := entry.Status.DiskCache; cache.Hits+cache.Misses > 0 {
+			line = line + fmt.Sprintf(", Cache hits: %d, misses: %d, cached: %d (%s)", cache.Hits, cache.Misses,
+				cache.Objects, humanize.IBytes(uint64(cache.Bytes)))
+		}
+		if entry.Status.Error != "" {
+			line = line + ", " + entry.Status.Error
+		}
+		lines = append(lines, line)
+		for _, disk := range entry.Status.Disks {
+			line := fmt.Sprintf("    %s: Unusable", disk.Path)
+			if disk.Usable {
+				line = fmt.Sprintf("    %s: Total: %s, Free: %s", disk.Path, humanize.IBytes(uint64(disk.Total)), humanize.IBytes(uint64(disk.Free)))
+			}
+			// older servers do not report how disks were probed
+			if disk.Probe != "" {
+				line = line + ", Probe: " + disk.Probe
+			}
+			lines = append(lines, line)
+		}
+	}
+	for _, bucket := range s.BucketRequests {
+		var methods []string
+		for method := range bucket.Requests {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		var requests []string
+		for _, method := range methods {
+			requests = append(requests, fmt.Sprintf("%s %d", method, bucket.Requests[method]))
+		}
+		lines = append(lines, fmt.Sprintf("Bucket %s: Requests: %s, Errors: %d, Received: %s, Sent: %s", bucket.Bucket,
+			strings.Join(requests, ", "), bucket.Errors, humanize.IBytes(uint64(bucket.BytesReceived)), humanize.IBytes(uint64(bucket.BytesSent))))
+	}
+	return colorizeMessage(strings.Join(lines, "\n"))
+}
+
+// JSON - json formatted output
+func (s clusterStatus) JSON() string {
+	return jsonMessage(s)
+}
@@ -17,14 +17,20 @@
 package main
 
 import (
+	"bytes"
 	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"net/http/httptest"
 	"net/url"
 	"os"
+	"path/filepath"
+	"strings"
+	"time"
 
 	"github.com/gorilla/rpc/v2/json"
+	"github.com/minio/minio-xl/pkg/xl"
 	. "gopkg.in/check.v1"
 )
 
@@ -34,6 +40,7 @@ type ControllerRPCSuite struct {
 	req    *http.Request
 	body   io.ReadSeeker
 	config *AuthConfig
+	api    API
 }
 
 var _ = Suite(&ControllerRPCSuite{})
@@ -65,8 +72,22 @@ func (s *ControllerRPCSuite) SetUpSuite(c *C) {
 	perr = SaveConfig(authConf)
 	c.Assert(perr, IsNil)
 
-	testControllerRPC = httptest.NewServer(getControllerRPCHandler(false))
-	testServerRPC = httptest.NewUnstartedServer(getServerRPCHandler(false))
+	xlConf := &xl.Config{}
+	xlConf.Version = "0.0.1"
+	xlConf.MaxSize = 100000
+	xl.SetXLConfigPath(filepath.Join(root, "xl.json"))
+	perr = xl.SaveConfig(xlConf)
+	c.Assert(perr, IsNil)
+	d, perr := xl.New()
+	c.Assert(perr, IsNil)
+	perr = d.MakeBucket("status", "private", nil, nil)
+	c.Assert(perr, IsNil)
+	_, perr = d.CreateObject("status", "object", "", int64(len("hello world")), bytes.NewReader([]byte("hello world")), nil, nil)
+	c.Assert(perr, IsNil)
+	s.api = API{XL: d}
+
+	testControllerRPC = httptest.NewServer(getControllerRPCHandler(false, new(controllerRPCService)))
+	testServerRPC = httptest.NewUnstartedServer(getServerRPCHandler(false, s.api))
 	testServerRPC.Config.Addr = ":9002"
 	testServerRPC.Start()
 
@@ -255,3 +276,389 @@ func (s *ControllerRPCSuite) TestAuth(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(resp.StatusCode, Equals, http.StatusBadRequest)
 }
+
+func (s *ControllerRPCSuite) TestClusterStatus(c *C) {
+	// new controller, servers registered by other tests are not part of the status
+	controllerRPC := httptest.NewServer(getControllerRPCHandler(false, new(controllerRPCService)))
+	defer controllerRPC.Close()
+	serverRPC := httptest.NewServer(getServerRPCHandler(false, s.api))
+	serverURL, gerr := url.Parse(serverRPC.URL)
+	c.Assert(gerr, IsNil)
+
+	for _, host := range []string{s.url.Host, serverURL.Host} {
+		op := rpcOperation{
+			Method:  "Controller.AddServer",
+			Request: ControllerArgs{Host: host},
+		}
+		req, err := newRPCRequest(s.config, controllerRPC.URL+"/rpc", op, http.DefaultTransport)
+		c.Assert(err, IsNil)
+		resp, err := req.Do()
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		resp.Body.Close()
+	}
+	// second server goes away after registering
+	serverRPC.Close()
+
+	op := rpcOperation{
+		Method:  "Controller.ClusterStatus",
+		Request: ControllerArgs{},
+	}
+	req, err := newRPCRequest(s.config, controllerRPC.URL+"/rpc", op, http.DefaultTransport)
+	c.Assert(err, IsNil)
+	resp, err := req.Do()
+	c.Assert(err, IsNil)
+	c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+	var reply ClusterStatusRep
+	c.Assert(json.DecodeClientResponse(resp.Body, &reply), IsNil)
+	resp.Body.Close()
+	c.Assert(reply.TotalServers, Equals, 2)
+	c.Assert(reply.OnlineServers, Equals, 1)
+	c.Assert(reply.HealthyServers, Equals, 1)
+	c.Assert(reply.Objects, Equals, int64(1))
+	c.Assert(len(reply.Servers), Equals, 2)
+
+	c.Assert(reply.Servers[0].Host, Equals, s.url.Host)
+	c.Assert(reply.Servers[0].Error, Equals, "")
+	c.Assert(reply.Servers[0].Status.Healthy, Equals, true)
+	c.Assert(reply.Servers[0].Status.Buckets, Equals, 1)
+	c.Assert(reply.Servers[0].Status.Objects, Equals, int64(1))
+
+	c.Assert(reply.Servers[1].Host, Equals, serverURL.Host)
+	c.Assert(reply.Servers[1].Error, Not(Equals), "")
+}
+
+func (s *ControllerRPCSuite) TestBucketStats(c *C) {
+	for _, bucket := range []string{"status", ""} {
+		op := rpcOperation{
+			Method:  "Server.BucketStats",
+			Request: BucketStatsArgs{Bucket: bucket},
+		}
+		req, err := newRPCRequest(s.config, testServerRPC.URL+"/rpc", op, http.DefaultTransport)
+		c.Assert(err, IsNil)
+		resp, err := req.Do()
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+
+		var reply BucketStatsRep
+		c.Assert(json.DecodeClientResponse(resp.Body, &reply), IsNil)
+		resp.Body.Close()
+		c.Assert(reply.Buckets, DeepEquals, []xl.BucketStats{{Bucket: "status", Objects: 1, Size: int64(len("hello world"))}})
+	}
+}
+
+func (s *ControllerRPCSuite) TestDeleteByQuery(c *C) {
+	c.Assert(s.api.XL.MakeBucket("purge", "private", nil, nil), IsNil)
+	c.Assert(s.api.XL.SetBucketMetadata("purge", map[string]string{xl.MetadataIndexKey: xl.MetadataIndexEnabled}), IsNil)
+	for object, metadata := range map[string]map[string]string{
+		"a":    {"x-amz-meta-user": "1234"},
+		"b":    {"x-amz-meta-user": "1234"},
+		"c":    {"x-amz-meta-user": "5678"},
+		"held": {"x-amz-meta-user": "1234", "legalHold": xl.LegalHoldOn},
+	} {
+		_, err := s.api.XL.CreateObject("purge", object, "", int64(len(object)), bytes.NewReader([]byte(object)), metadata, nil)
+		c.Assert(err, IsNil)
+	}
+	deleteByQuery := func(dryRun bool) DeleteByQueryRep {
+		op := rpcOperation{
+			Method:  "Server.DeleteByQuery",
+			Request: DeleteByQueryArgs{Bucket: "purge", Key: "user", Value: "1234", DryRun: dryRun},
+		}
+		req, err := newRPCRequest(s.config, testServerRPC.URL+"/rpc", op, http.DefaultTransport)
+		c.Assert(err, IsNil)
+		resp, err := req.Do()
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		defer resp.Body.Close()
+
+		var reply DeleteByQueryRep
+		c.Assert(json.DecodeClientResponse(resp.Body, &reply), IsNil)
+		return reply
+	}
+
+	// dry run only previews matches
+	reply := deleteByQuery(true)
+	c.Assert(reply.Matched, DeepEquals, []string{"a", "b", "held"})
+	c.Assert(reply.Deleted, Equals, 0)
+	c.Assert(len(reply.Failures), Equals, 0)
+	_, err := s.api.XL.GetObjectMetadata("purge", "a")
+	c.Assert(err, IsNil)
+
+	// objects under legal hold are reported and kept
+	reply = deleteByQuery(false)
+	c.Assert(reply.Matched, DeepEquals, []string{"a", "b", "held"})
+	c.Assert(reply.Deleted, Equals, 2)
+	c.Assert(reply.Failures, DeepEquals, []DeleteFailure{{Object: "held", Error: xl.ObjectLocked{Object: "held"}.Error()}})
+	_, err = s.api.XL.GetObjectMetadata("purge", "a")
+	c.Assert(err, Not(IsNil))
+	_, err = s.api.XL.GetObjectMetadata("purge", "c")
+	c.Assert(err, IsNil)
+
+	// admin credentials are required, anonymous servers have none
+	anonymous := &serverRPCService{XL: s.api.XL, anonymous: true}
+	c.Assert(anonymous.DeleteByQuery(nil, &DeleteByQueryArgs{Bucket: "purge", Key: "user", Value: "5678"}, &DeleteByQueryRep{}), Not(IsNil))
+	_, err = s.api.XL.GetObjectMetadata("purge", "c")
+	c.Assert(err, IsNil)
+}
+
+func (s *ControllerRPCSuite) TestSetDefaultContentType(c *C) {
+	c.Assert(s.api.XL.MakeBucket("photos", "private", nil, nil), IsNil)
+	putObject := func(object string) string {
+		metadata, err := s.api.XL.CreateObject("photos", object, "", int64(len(object)), bytes.NewReader([]byte(object)), nil, nil)
+		c.Assert(err, IsNil)
+		return metadata.Metadata["contentType"]
+	}
+	setDefaultContentType := func(contentType string) {
+		op := rpcOperation{
+			Method:  "Server.SetDefaultContentType",
+			Request: DefaultContentTypeArgs{Bucket: "photos", ContentType: contentType},
+		}
+		req, err := newRPCRequest(s.config, testServerRPC.URL+"/rpc", op, http.DefaultTransport)
+		c.Assert(err, IsNil)
+		resp, err := req.Do()
+		c.Assert(err, IsNil)
+		c.Assert(resp.StatusCode, Equals, http.StatusOK)
+		defer resp.Body.Close()
+
+		var reply DefaultContentTypeRep
+		c.Assert(json.DecodeClientResponse(resp.Body, &reply), IsNil)
+		c.Assert(reply, DeepEquals, DefaultContentTypeRep{Bucket: "photos", ContentType: contentType})
+	}
+
+	// applies to uploads of the running server right away
+	setDefaultContentType("image/jpeg")
+	c.Assert(putObject("a"), Equals, "image/jpeg")
+	setDefaultContentType("")
+	c.Assert(putObject("b"), Equals, "application/octet-stream")
+
+	// admin credentials are required, anonymous servers have none
+	anonymous := &serverRPCService{XL: s.api.XL, anonymous: true}
+	c.Assert(anonymous.SetDefaultContentType(nil, &DefaultContentTypeArgs{Bucket: "photos", ContentType: "image/png"}, &DefaultContentTypeRep{}), Not(IsNil))
+	c.Assert(putObject("c"), Equals, "application/octet-stream")
+
+	// content types which cannot be parsed are refused
+	service := &serverRPCService{XL: s.api.XL}
+	c.Assert(service.SetDefaultContentType(nil, &DefaultContentTypeArgs{Bucket: "photos", ContentType: "image/"}, &DefaultContentTypeRep{}), Not(IsNil))
+	c.Assert(putObject("d"), Equals, "application/octet-stream")
+}
+
+func (s *ControllerRPCSuite) TestAggregateClusterStatus(c *C) {
+	status := aggregateClusterStatus([]ServerStatusEntry{
+		{
+			Host: "server1:9002",
+			Status: ServerStatusRep{
+				Disks:            []DiskUsageRep{{Path: "/mnt/disk1", Total: 100, Free: 40, Usable: true}, {Path: "/mnt/disk2", Total: 100, Free: 60, Usable: true}},
+				Objects:          10,
+				MultipartUploads: 2,
+				BucketRequests: []BucketRequestStats{
+					{Bucket: "photos", Requests: map[string]int64{"GET": 3}, BytesSent: 300},
+					{Bucket: "videos", Requests: map[string]int64{"PUT": 1}, BytesReceived: 1024},
+				},
+				DiskQuorum: xl.DiskQuorumStats{Total: 3, Online: 2, Required: 2},
+				Healthy:    true,
+			},
+		},
+		{
+			Host: "server2:9002",
+			Status: ServerStatusRep{
+				Disks:            []DiskUsageRep{{Path: "/mnt/disk1", Total: 50, Free: 50, Usable: true}, {Path: "/mnt/disk2"}},
+				Objects:          5,
+				MultipartUploads: 1,
+				BucketRequests: []BucketRequestStats{
+					{Bucket: "archive", Requests: map[string]int64{"HEAD": 1}, Errors: 1},
+					{Bucket: "photos", Requests: map[string]int64{"GET": 1, "PUT": 2}, BytesReceived: 20, BytesSent: 100},
+				},
+			},
+		},
+		{
+			Host:  "server3:9002",
+			Error: "connection refused",
+		},
+	})
+	c.Assert(status.TotalServers, Equals, 3)
+	c.Assert(status.OnlineServers, Equals, 2)
+	c.Assert(status.HealthyServers, Equals, 1)
+	c.Assert(status.DegradedServers, Equals, 1)
+	c.Assert(status.Disks, Equals, 4)
+	c.Assert(status.Total, Equals, int64(250))
+	c.Assert(status.Free, Equals, int64(150))
+	c.Assert(status.Objects, Equals, int64(15))
+	c.Assert(status.MultipartUploads, Equals, 3)
+	c.Assert(status.BucketRequests, DeepEquals, []BucketRequestStats{
+		{Bucket: "archive", Requests: map[string]int64{"HEAD": 1}, Errors: 1},
+		{Bucket: "photos", Requests: map[string]int64{"GET": 4, "PUT": 2}, BytesReceived: 20, BytesSent: 400},
+		{Bucket: "videos", Requests: map[string]int64{"PUT": 1}, BytesReceived: 1024},
+	})
+
+	status.Servers[0].Status.Disks[0].Probe = diskProbeWrite
+	status.Servers[1].Status.Disks[1].Probe = diskProbeStat
+	output := clusterStatus{status}.String()
+	c.Assert(strings.Contains(output, "/mnt/disk1: Total: 100B, Free: 40B, Probe: write\n"), Equals, true)
+	c.Assert(strings.Contains(output, "/mnt/disk2: Total: 100B, Free: 60B\n"), Equals, true)
+	c.Assert(strings.Contains(output, "/mnt/disk2: Unusable, Probe: stat\n"), Equals, true)
+	c.Assert(strings.HasPrefix(output, "Servers: 3, Online: 2, Healthy: 1, Degraded: 1\n"), Equals, true)
+	c.Assert(strings.Contains(output, "server1:9002: Degraded, 2 of 3 disks online, Disks: 2,"), Equals, true)
+	c.Assert(strings.Contains(output, "server2:9002: Unhealthy, Disks: 2,"), Equals, true)
+	c.Assert(strings.Contains(output, "Bucket photos: Requests: GET 4, PUT 2, Errors: 0, Received: 20B, Sent: 400B\n"), Equals, true)
+}
+
+func (s *ControllerRPCSuite) TestDiskUsage(c *C) {
+	writable := filepath.Join(s.root, "writable")
+	c.Assert(os.Mkdir(writable, 0700), IsNil)
+	unwritable := filepath.Join(s.root, "unwritable")
+	c.Assert(os.Mkdir(unwritable, 0500), IsNil)
+
+	usage := getDiskUsage(writable)
+	c.Assert(usage.Usable, Equals, true)
+	c.Assert(usage.Probe, Equals, diskProbeWrite)
+	c.Assert(usage.Total > 0, Equals, true)
+	// test writes leave nothing behind
+	files, err := ioutil.ReadDir(writable)
+	c.Assert(err, IsNil)
+	c.Assert(len(files), Equals, 0)
+
+	usage = getDiskUsage(unwritable)
+	c.Assert(usage.Usable, Equals, false)
+	c.Assert(usage.Probe, Equals, diskProbeWrite)
+
+	usage = getDiskUsage(filepath.Join(s.root, "missing"))
+	c.Assert(usage.Usable, Equals, false)
+	c.Assert(usage.Probe, Equals, diskProbeStat)
+
+	globalDiskProbe = diskProbeStat
+	defer func() { globalDiskProbe = diskProbeWrite }()
+	usage = getDiskUsage(unwritable)
+	c.Assert(usage.Usable, Equals, true)
+	c.Assert(usage.Probe, Equals, diskProbeStat)
+}
+
+func (s *ControllerRPCSuite) TestControllerLease(c *C) {
+	var controllers []*controllerRPCService
+	var servers []*httptest.Server
+	var ids []string
+	for i := 0; i < 3; i++ {
+		controller := new(controllerRPCService)
+		server := httptest.NewServer(getControllerRPCHandler(false, controller))
+		defer server.Close()
+		serverURL, err := url.Parse(server.URL)
+		c.Assert(err, IsNil)
+		controllers = append(controllers, controller)
+		servers = append(servers, server)
+		ids = append(ids, serverURL.Host)
+	}
+	for i, controller := range controllers {
+		var peers []string
+		for j, id := range ids {
+			if j != i {
+				peers = append(peers, id)
+			}
+		}
+		controller.lease = newControllerLease(ids[i], peers, false, 500*time.Millisecond)
+	}
+	// new server, lease terms seen by it do not affect other tests
+	serverRPC := httptest.NewServer(getServerRPCHandler(false, s.api))
+	defer serverRPC.Close()
+	serverURL, gerr := url.Parse(serverRPC.URL)
+	c.Assert(gerr, IsNil)
+
+	addServer := func(controller int) error {
+		op := rpcOperation{
+			Method:  "Controller.AddServer",
+			Request: ControllerArgs{Host: serverURL.Host},
+		}
+		req, err := newRPCRequest(s.config, servers[controller].URL+"/rpc", op, http.DefaultTransport)
+		c.Assert(err, IsNil)
+		resp, err := req.Do()
+		c.Assert(err, IsNil)
+		defer resp.Body.Close()
+		var reply ServerRep
+		return json.DecodeClientResponse(resp.Body, &reply)
+	}
+
+	controllers[0].renewLease()
+	term, ok := controllers[0].lease.isLeader()
+	c.Assert(ok, Equals, true)
+	c.Assert(term, Equals, uint64(1))
+	// votes are taken, other controllers follow
+	controllers[1].renewLease()
+	_, ok = controllers[1].lease.isLeader()
+	c.Assert(ok, Equals, false)
+	c.Assert(controllers[1].lease.getLeader(), Equals, ids[0])
+
+	c.Assert(addServer(0), IsNil)
+	err := addServer(1)
+	c.Assert(err, Not(IsNil))
+	c.Assert(strings.Contains(err.Error(), "lease is held by "+ids[0]), Equals, true)
+	// renewal hands the servers over to the peers
+	controllers[0].renewLease()
+	_, ok = controllers[0].lease.isLeader()
+	c.Assert(ok, Equals, true)
+
+	// leader stops renewing, a peer takes over once the votes expired
+	servers[0].Close()
+	time.Sleep(600 * time.Millisecond)
+	_, ok = controllers[0].lease.isLeader()
+	c.Assert(ok, Equals, false)
+	controllers[1].renewLease()
+	term, ok = controllers[1].lease.isLeader()
+	c.Assert(ok, Equals, true)
+	c.Assert(term, Equals, uint64(2))
+
+	var status ClusterStatusRep
+	c.Assert(controllers[1].ClusterStatus(nil, &ControllerArgs{}, &status), IsNil)
+	c.Assert(status.TotalServers, Equals, 1)
+	c.Assert(status.OnlineServers, Equals, 1)
+	c.Assert(status.Servers[0].Host, Equals, serverURL.Host)
+
+	// deposed leader is refused by the servers
+	var version VersionRep
+	perr := callRPC("Server.Version", serverURL.Host, false, ServerArg{Controller: ids[0], Term: 1}, &version)
+	c.Assert(perr, Not(IsNil))
+	c.Assert(strings.Contains(perr.ToGoError().Error(), "lost its lease to "+ids[1]), Equals, true)
+}
+
+func (s *ControllerRPCSuite) TestControllerRegistration(c *C) {
+	// controller is not up yet, its address is reserved and released again
+	listener, e := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(e, IsNil)
+	address := listener.Addr().String()
+	c.Assert(listener.Close(), IsNil)
+
+	registration := newControllerRegistration(address, s.url.Host, false, 10*time.Second)
+	registration.backoff, registration.maxBackoff = 50*time.Millisecond, 100*time.Millisecond
+	registered := make(chan error)
+	go func() {
+		registered <- registration.register().ToGoError()
+	}()
+	time.Sleep(300 * time.Millisecond)
+
+	controller := new(controllerRPCService)
+	controllerRPC := httptest.NewUnstartedServer(getControllerRPCHandler(false, controller))
+	controllerRPC.Listener, e = net.Listen("tcp", address)
+	c.Assert(e, IsNil)
+	controllerRPC.Start()
+	defer controllerRPC.Close()
+	select {
+	case err := <-registered:
+		c.Assert(err, IsNil)
+	case <-time.After(5 * time.Second):
+		c.Fatal("server did not register once the controller came up")
+	}
+	var servers ListRep
+	c.Assert(controller.ListServers(nil, &ControllerArgs{}, &servers), IsNil)
+	c.Assert(len(servers.List), Equals, 1)
+	c.Assert(servers.List[0].Host, Equals, s.url.Host)
+
+	// registering again does not add the server twice
+	c.Assert(registration.register(), IsNil)
+	c.Assert(controller.ListServers(nil, &ControllerArgs{}, &servers), IsNil)
+	c.Assert(len(servers.List), Equals, 1)
+
+	// retries give up once the window is over
+	registration = newControllerRegistration("127.0.0.1:1", s.url.Host, false, 200*time.Millisecond)
+	registration.backoff = 50 * time.Millisecond
+	start := time.Now()
+	c.Assert(registration.register(), Not(IsNil))
+	c.Assert(time.Since(start) < time.Second, Equals, true)
+}
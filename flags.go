@@ -16,7 +16,11 @@
 
 package main
 
-import "github.com/minio/cli"
+import (
+	"time"
+
+	"github.com/minio/cli"
+)
 
 // Collection of minio flags currently supported
 var flags = []cli.Flag{}
@@ -25,7 +29,7 @@ var (
 	addressFlag = cli.StringFlag{
 		Name:  "address",
 		Value: ":9000",
-		Usage: "ADDRESS:PORT for cloud storage access.",
+		Usage: "ADDRESS:PORT for cloud storage access, or unix:///PATH to listen on a unix socket.",
 	}
 
 	addressControllerFlag = cli.StringFlag{
@@ -35,6 +39,28 @@ var (
 		Usage: "ADDRESS:PORT for management console access.",
 	}
 
+	controllerPeersFlag = cli.StringFlag{
+		Name:  "controller-peers",
+		Usage: "Comma separated ADDRESS:PORT of other controllers, the one holding the lease manages the servers: [DEFAULT: disabled].",
+	}
+
+	leaseTTLFlag = cli.DurationFlag{
+		Name:  "lease-ttl",
+		Value: 10 * time.Second,
+		Usage: "Controllers not renewing their lease for this long lose it to a peer: [DEFAULT: 10s].",
+	}
+
+	registerControllerFlag = cli.StringFlag{
+		Name:  "register-controller",
+		Usage: "ADDRESS:PORT of the controller servers register with once started, retried until it is reachable: [DEFAULT: disabled].",
+	}
+
+	registerTimeoutFlag = cli.DurationFlag{
+		Name:  "register-timeout",
+		Value: 10 * time.Minute,
+		Usage: "Give up registering with --register-controller after retrying for this long, ‘0’ retries forever: [DEFAULT: 10m].",
+	}
+
 	addressServerRPCFlag = cli.StringFlag{
 		Name:  "address-server-rpc",
 		Hide:  true,
@@ -42,6 +68,16 @@ var (
 		Usage: "ADDRESS:PORT for management console access.",
 	}
 
+	addressWebsiteFlag = cli.StringFlag{
+		Name:  "website-address",
+		Usage: "ADDRESS:PORT for static website access to buckets with a website configuration: [DEFAULT: disabled].",
+	}
+
+	addressMetricsFlag = cli.StringFlag{
+		Name:  "metrics-address",
+		Usage: "ADDRESS:PORT serving request counters of every bucket to Prometheus at /metrics: [DEFAULT: disabled].",
+	}
+
 	ratelimitFlag = cli.IntFlag{
 		Name:  "ratelimit",
 		Hide:  true,
@@ -49,6 +85,12 @@ var (
 		Usage: "Limit for total concurrent requests: [DEFAULT: 16].",
 	}
 
+	anonymousRatelimitFlag = cli.IntFlag{
+		Name:  "anonymous-ratelimit",
+		Value: 0,
+		Usage: "Maximum unsigned requests served concurrently, to --anonymous and website addresses, others get 503 SlowDown: [DEFAULT: 0 (unlimited)].",
+	}
+
 	anonymousFlag = cli.BoolFlag{
 		Name:  "anonymous",
 		Hide:  true,
@@ -65,6 +107,323 @@ var (
 		Usage: "Provide your domain private key.",
 	}
 
+	tlsMinVersionFlag = cli.StringFlag{
+		Name:  "tls-min-version",
+		Value: "1.2",
+		Usage: "Minimum TLS version accepted for https: 1.0, 1.1, 1.2 or 1.3.",
+	}
+
+	tlsCiphersFlag = cli.StringFlag{
+		Name:  "tls-ciphers",
+		Usage: "Comma separated list of TLS cipher suites for https, defaults to ECDHE with AES-GCM and CHACHA20-POLY1305.",
+	}
+
+	ocspStapleFlag = cli.BoolFlag{
+		Name:  "ocsp-staple",
+		Usage: "Staple the OCSP response of the responder named by the certificate, --cert must hold the issuer certificate after it.",
+	}
+
+	proxyProtocolFlag = cli.BoolFlag{
+		Name:  "proxy-protocol",
+		Usage: "Require a PROXY protocol v1 or v2 header on every connection to recover client addresses behind a TCP load balancer.",
+	}
+
+	fileModeFlag = cli.StringFlag{
+		Name:  "file-mode",
+		Value: "0600",
+		Usage: "Octal permissions of block and metadata files created on disks.",
+	}
+
+	dirModeFlag = cli.StringFlag{
+		Name:  "dir-mode",
+		Value: "0700",
+		Usage: "Octal permissions of directories created on disks.",
+	}
+
+	fsyncFlag = cli.StringFlag{
+		Name:  "fsync",
+		Value: "never",
+		Usage: "Sync files written to disks ‘always’ before acknowledging, in a ‘batch’ every second or ‘never’ leaving it to the OS: [DEFAULT: never].",
+	}
+
+	writeOrderFlag = cli.StringFlag{
+		Name:  "write-order",
+		Value: "ordered",
+		Usage: "Sync blocks of objects before their metadata is written, ‘ordered’, or leave both to --fsync, ‘relaxed’: [DEFAULT: ordered].",
+	}
+
+	diskTimeoutFlag = cli.DurationFlag{
+		Name:  "disk-timeout",
+		Value: 0,
+		Usage: "Fail a disk once a block read on it takes longer, reads outstanding on it are reconstructed from parity, '0' only fails disks on I/O errors: [DEFAULT: 0].",
+	}
+
+	readAheadFlag = cli.IntFlag{
+		Name:  "read-ahead",
+		Value: 0,
+		Usage: "Number of stripes prefetched from disk ahead of the client, at most 16, each uses up to 10MB: [DEFAULT: 0 (disabled)].",
+	}
+
+	diskOpenWorkersFlag = cli.IntFlag{
+		Name:  "disk-open-workers",
+		Value: 8,
+		Usage: "Number of disks initialized in parallel on startup: [DEFAULT: 8].",
+	}
+
+	minOnlineDisksFlag = cli.IntFlag{
+		Name:  "min-online-disks",
+		Usage: "Number of disks which must be healthy on startup, the server starts degraded with fewer than all: [DEFAULT: half of the disks].",
+	}
+
+	listWorkersFlag = cli.IntFlag{
+		Name:  "list-workers",
+		Value: 8,
+		Usage: "Number of object metadata read in parallel by a bucket listing, 1 reads them one after the other: [DEFAULT: 8].",
+	}
+
+	dedupFlag = cli.BoolFlag{
+		Name:  "dedup",
+		Usage: "Store identical object data only once, objects with the same content share their blocks on disk.",
+	}
+
+	readRepairFlag = cli.BoolFlag{
+		Name:  "read-repair",
+		Usage: "Rewrite blocks found missing or unreadable by reads which reconstructed the object from parity.",
+	}
+
+	rpcIdleTimeoutFlag = cli.DurationFlag{
+		Name:  "rpc-idle-timeout",
+		Value: 5 * time.Minute,
+		Usage: "Close RPC connections idle for longer than this duration, '0' keeps them open: [DEFAULT: 5m].",
+	}
+
+	shutdownTimeoutFlag = cli.DurationFlag{
+		Name:  "shutdown-timeout",
+		Value: 10 * time.Second,
+		Usage: "Time in-flight requests get to complete on SIGTERM, or once a process restarted by SIGUSR2 or SIGHUP takes over: [DEFAULT: 10s].",
+	}
+
+	maxHeaderBytesFlag = cli.IntFlag{
+		Name:  "max-header-bytes",
+		Value: 64 * 1024,
+		Usage: "Largest request line and headers in bytes, larger requests are rejected with 431 Request Header Fields Too Large: [DEFAULT: 65536].",
+	}
+
+	idempotencyTTLFlag = cli.DurationFlag{
+		Name:  "idempotency-ttl",
+		Value: 10 * time.Minute,
+		Usage: "Answer PUTs retried with the same X-Minio-Idempotency-Key within this duration with the result of the first, '0' disables: [DEFAULT: 10m].",
+	}
+
+	writeRetriesFlag = cli.IntFlag{
+		Name:  "write-retries",
+		Value: 3,
+		Usage: "Retry disk writes failing with transient errors such as EINTR, other errors take the disk offline for the object: [DEFAULT: 3].",
+	}
+
+	writeRetryBackoffFlag = cli.DurationFlag{
+		Name:  "write-retry-backoff",
+		Value: 100 * time.Millisecond,
+		Usage: "Wait before the first retry of a disk write, doubled on every subsequent retry: [DEFAULT: 100ms].",
+	}
+
+	orphanExpiryFlag = cli.DurationFlag{
+		Name:  "orphan-expiry",
+		Usage: "Remove blocks left on disks by failed writes and multipart completions once older than this, '0' disables: [DEFAULT: 0].",
+	}
+
+	multipartTTLFlag = cli.DurationFlag{
+		Name:  "multipart-ttl",
+		Usage: "Abort multipart uploads with no part uploaded for this long, freeing their parts, '0' disables: [DEFAULT: 0].",
+	}
+
+	notFoundCacheTTLFlag = cli.DurationFlag{
+		Name:  "not-found-cache-ttl",
+		Value: time.Second,
+		Usage: "Report objects found missing on disks as missing without looking again for this long, '0' disables: [DEFAULT: 1s].",
+	}
+
+	cacheDirFlag = cli.StringFlag{
+		Name:  "cache-dir",
+		Usage: "Cache recently read objects in this directory, usually on a disk faster than the erasure coded disks: [DEFAULT: disabled].",
+	}
+
+	cacheSizeFlag = cli.StringFlag{
+		Name:  "cache-size",
+		Value: "10GB",
+		Usage: "Evict least recently used objects from --cache-dir once it holds more than this e.g. 100GB: [DEFAULT: 10GB].",
+	}
+
+	cacheWritesFlag = cli.BoolFlag{
+		Name:  "cache-writes",
+		Usage: "Cache objects in --cache-dir as they are uploaded, not only once read.",
+	}
+
+	erasureRatioFlag = cli.StringFlag{
+		Name:  "erasure-ratio",
+		Usage: "Data to parity blocks of objects by content type or key prefix e.g. ‘video/*=12:4,prefix:db/=4:12’, others get as many parity as data blocks: [DEFAULT: disabled].",
+	}
+
+	localDisksFlag = cli.StringFlag{
+		Name:  "local-disks",
+		Usage: "Comma separated disks in the rack of this server e.g. ‘/mnt/disk1,/mnt/disk2’, other disks are only read when blocks on these do not suffice: [DEFAULT: all disks are read].",
+	}
+
+	metadataIndexSizeFlag = cli.IntFlag{
+		Name:  "metadata-index-size",
+		Value: 1000000,
+		Usage: "Number of user metadata values indexed for ‘xl search’ over all buckets, buckets beyond it are searched by reading all their objects: [DEFAULT: 1000000].",
+	}
+
+	thumbnailFlag = cli.StringFlag{
+		Name:  "thumbnail",
+		Usage: "Store a thumbnail of uploaded JPEG, PNG and GIF images as ‘<object>?thumb’, fitting within the given size e.g. 256x256: [DEFAULT: disabled].",
+	}
+
+	compressFlag = cli.BoolFlag{
+		Name:  "compress",
+		Usage: "Serve text, json, xml, javascript and svg objects gzip compressed to clients accepting it, with a weak ETag.",
+	}
+
+	disableXMLCompressionFlag = cli.BoolFlag{
+		Name:  "disable-xml-compression",
+		Usage: "Never gzip compress XML responses of listings, ACLs and errors, sent compressed to clients accepting gzip by default.",
+	}
+
+	sanitizeHeadersFlag = cli.BoolFlag{
+		Name:  "sanitize-headers",
+		Usage: "Do not disclose the server release and platform in the ‘Server’ header of responses.",
+	}
+
+	cacheControlFlag = cli.StringFlag{
+		Name:  "cache-control",
+		Usage: "‘Cache-Control’ header of objects served and their 304 Not Modified responses e.g. ‘public, max-age=300’, for CDNs revalidating objects: [DEFAULT: not sent].",
+	}
+
+	diskProbeFlag = cli.StringFlag{
+		Name:  "disk-probe",
+		Value: "write",
+		Usage: "Probe disk health with ‘stat’ only or ‘write’ a test file as well, disks mounted read-only are only stat'ed: [DEFAULT: write].",
+	}
+
+	accessTimeIntervalFlag = cli.DurationFlag{
+		Name:  "access-time-interval",
+		Value: 0,
+		Usage: "Record the last access time of objects read with GET in their metadata, at most once per interval per object, '0' disables: [DEFAULT: 0].",
+	}
+
+	defaultBucketFlag = cli.StringFlag{
+		Name:  "default-bucket",
+		Usage: "Create this private bucket on startup unless it already exists.",
+	}
+
+	auditLogFlag = cli.StringFlag{
+		Name:  "audit-log",
+		Usage: "Append a json entry for every authentication decision to this file, allowed or denied along with the reason.",
+	}
+
+	maxMemoryFlag = cli.StringFlag{
+		Name:  "max-memory",
+		Usage: "Maximum memory buffered by in-flight requests e.g. 512MB, new uploads get 503 SlowDown above it: [DEFAULT: unlimited].",
+	}
+
+	maxBandwidthFlag = cli.StringFlag{
+		Name:  "max-bandwidth",
+		Usage: "Maximum bytes per second of uploads and downloads together e.g. 100MB, requests are slowed down above it: [DEFAULT: unlimited].",
+	}
+
+	maxIngressBandwidthFlag = cli.StringFlag{
+		Name:  "max-ingress-bandwidth",
+		Usage: "Maximum bytes per second of uploads e.g. 50MB: [DEFAULT: unlimited].",
+	}
+
+	maxEgressBandwidthFlag = cli.StringFlag{
+		Name:  "max-egress-bandwidth",
+		Usage: "Maximum bytes per second of downloads e.g. 50MB: [DEFAULT: unlimited].",
+	}
+
+	disableMD5Flag = cli.BoolFlag{
+		Name:  "disable-md5",
+		Usage: "Skip computing MD5 of uploads without Content-MD5, their ETag is derived from the SHA-512 of the data instead.",
+	}
+
+	etagAlgorithmFlag = cli.StringFlag{
+		Name:  "etag-algorithm",
+		Value: "md5",
+		Usage: "Compute ETags of objects written from now on with ‘md5’ or ‘sha256’, Content-MD5 is verified either way: [DEFAULT: md5].",
+	}
+
+	disableBufferPoolFlag = cli.BoolFlag{
+		Name:  "disable-buffer-pool",
+		Usage: "Allocate erasure coding buffers for every stripe rather than reusing them across requests.",
+	}
+
+	maxPartsInFlightFlag = cli.IntFlag{
+		Name:  "max-parts-in-flight",
+		Value: 0,
+		Usage: "Maximum parts of a multipart upload uploaded concurrently, others wait and eventually get 503 SlowDown: [DEFAULT: 0 (unlimited)].",
+	}
+
+	maxMultipartUploadsFlag = cli.IntFlag{
+		Name:  "max-multipart-uploads",
+		Value: 0,
+		Usage: "Maximum multipart uploads open at once, new uploads get 503 ServiceUnavailable above it: [DEFAULT: 0 (unlimited)].",
+	}
+
+	pathPrefixFlag = cli.StringFlag{
+		Name:  "path-prefix",
+		Usage: "Serve the S3 API under this path e.g. /storage, behind a reverse proxy not rewriting paths.",
+	}
+
+	maxKeyLengthFlag = cli.IntFlag{
+		Name:  "max-key-length",
+		Value: 1024,
+		Usage: "Longest object key in bytes, longer keys are rejected with KeyTooLongError: [DEFAULT: 1024].",
+	}
+
+	maxKeyDepthFlag = cli.IntFlag{
+		Name:  "max-key-depth",
+		Value: 0,
+		Usage: "Most ‘/’ separated segments of an object key, deeper keys are rejected with InvalidObjectName: [DEFAULT: 0 (unlimited)].",
+	}
+
+	otelEndpointFlag = cli.StringFlag{
+		Name:  "otel-endpoint",
+		Usage: "OTLP/HTTP endpoint of an OpenTelemetry collector e.g. http://localhost:4318, spans of requests and their disk operations are exported to it.",
+	}
+
+	dumpDirFlag = cli.StringFlag{
+		Name:  "dump-dir",
+		Usage: "Write a goroutine dump to this directory on --dump-signal, the server keeps running.",
+	}
+
+	dumpSignalFlag = cli.StringFlag{
+		Name:  "dump-signal",
+		Value: "SIGQUIT",
+		Usage: "Signal triggering a dump to --dump-dir, ‘SIGQUIT’ or ‘SIGUSR1’: [DEFAULT: SIGQUIT].",
+	}
+
+	dumpHeapFlag = cli.BoolFlag{
+		Name:  "dump-heap",
+		Usage: "Write a heap profile to --dump-dir along with every goroutine dump.",
+	}
+
+	codecFlag = cli.StringFlag{
+		Name:  "codec",
+		Value: "isal",
+		Usage: "Erasure codec, ‘isal’ using the SIMD instructions of the CPU or portable ‘go’, falls back to ‘go’ on CPUs without SIMD: [DEFAULT: isal].",
+	}
+
+	backgroundIOPriorityFlag = cli.StringFlag{
+		Name:  "background-ioprio",
+		Usage: "I/O priority on linux of fsck, rebalance, read repair and expiry, ‘idle’, ‘best-effort[:0-7]’ or ‘realtime[:0-7]’, client requests keep the priority of the process: [DEFAULT: unchanged].",
+	}
+
+	credentialsCmdFlag = cli.StringFlag{
+		Name:  "credentials-cmd",
+		Usage: "Command printing json {\"accessKey\": ..., \"secretKey\": ...} used for signature verification, re-run on SIGHUP.",
+	}
+
 	jsonFlag = cli.BoolFlag{
 		Name:  "json",
 		Usage: "Enable json formatted output.",
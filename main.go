@@ -22,22 +22,90 @@ import (
 	"os/user"
 	"runtime"
 	"strconv"
+	"time"
 
+	"github.com/Sirupsen/logrus"
 	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/erasure"
 	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+	"github.com/minio/minio-xl/pkg/xl/disk"
 )
 
 // minioConfig - http server config
 type minioConfig struct {
 	Address           string
 	ControllerAddress string
+	ControllerPeers   []string
+	LeaseTTL          time.Duration
+	Controller        string
+	RegisterTimeout   time.Duration
 	RPCAddress        string
+	WebsiteAddress    string
+	MetricsAddress    string
 	Anonymous         bool
 	TLS               bool
+	TLSMinVersion     string
+	TLSCiphers        string
+	OCSPStaple        bool
+	ProxyProtocol     bool
 	CertFile          string
 	KeyFile           string
 	RateLimit         int
+	AnonRateLimit     int
+	CredentialsCmd    string
+	ReadAhead         int
+	MaxMemory         int64
+	MaxBandwidth      int64
+	MaxIngress        int64
+	MaxEgress         int64
+	MaxPartsInFlight  int
+	MaxUploads        int
+	PathPrefix        string
+	MaxKeyLength      int
+	MaxKeyDepth       int
+	DiskOpenWorkers   int
+	MinOnlineDisks    int
+	ListWorkers       int
+	FileMode          os.FileMode
+	DirMode           os.FileMode
+	FsyncPolicy       disk.FsyncPolicy
+	WriteOrder        xl.WriteOrder
+	DiskTimeout       time.Duration
+	Dedup             bool
+	ReadRepair        bool
+	DisableMD5        bool
+	ETagAlgorithm     xl.ETagAlgorithm
+	NoBufferPool      bool
+	RPCIdleTimeout    time.Duration
+	ShutdownTimeout   time.Duration
+	MaxHeaderBytes    int
+	IdempotencyTTL    time.Duration
+	Thumbnail         string
+	Compress          bool
+	NoXMLCompression  bool
+	SanitizeHeaders   bool
+	CacheControl      string
+	WriteRetries      int
+	WriteRetryBackoff time.Duration
+	NotFoundCacheTTL  time.Duration
+	OrphanExpiry      time.Duration
+	MultipartTTL      time.Duration
+	CacheDir          string
+	CacheSize         int64
+	CacheWrites       bool
+	ErasureRatios     []xl.ErasureRatioRule
+	LocalDisks        []string
+	MetadataIndexSize int
+	AccessInterval    time.Duration
+	DiskProbe         string
+	DefaultBucket     string
+	AuditLog          string
+	OTelEndpoint      string
+	DumpDir           string
+	DumpSignal        os.Signal
+	DumpHeap          bool
 }
 
 func init() {
@@ -64,11 +132,12 @@ func getSystemData() map[string]string {
 	}
 	memstats := &runtime.MemStats{}
 	runtime.ReadMemStats(memstats)
-	mem := fmt.Sprintf("Used: %s | Allocated: %s | Used-Heap: %s | Allocated-Heap: %s",
+	mem := fmt.Sprintf("Used: %s | Allocated: %s | Used-Heap: %s | Allocated-Heap: %s | In-Flight: %s",
 		humanize.Bytes(memstats.Alloc),
 		humanize.Bytes(memstats.TotalAlloc),
 		humanize.Bytes(memstats.HeapAlloc),
-		humanize.Bytes(memstats.HeapSys))
+		humanize.Bytes(memstats.HeapSys),
+		humanize.Bytes(uint64(globalMemoryAccountant.getInFlight())))
 	platform := fmt.Sprintf("Host: %s | OS: %s | Arch: %s",
 		host,
 		runtime.GOOS,
@@ -99,11 +168,76 @@ func registerApp() *cli.App {
 	// register all flags
 	registerFlag(addressFlag)
 	registerFlag(addressControllerFlag)
+	registerFlag(controllerPeersFlag)
+	registerFlag(leaseTTLFlag)
+	registerFlag(registerControllerFlag)
+	registerFlag(registerTimeoutFlag)
 	registerFlag(addressServerRPCFlag)
+	registerFlag(addressWebsiteFlag)
+	registerFlag(addressMetricsFlag)
 	registerFlag(ratelimitFlag)
+	registerFlag(anonymousRatelimitFlag)
 	registerFlag(anonymousFlag)
 	registerFlag(certFlag)
 	registerFlag(keyFlag)
+	registerFlag(tlsMinVersionFlag)
+	registerFlag(tlsCiphersFlag)
+	registerFlag(ocspStapleFlag)
+	registerFlag(proxyProtocolFlag)
+	registerFlag(credentialsCmdFlag)
+	registerFlag(readAheadFlag)
+	registerFlag(maxMemoryFlag)
+	registerFlag(maxBandwidthFlag)
+	registerFlag(maxIngressBandwidthFlag)
+	registerFlag(maxEgressBandwidthFlag)
+	registerFlag(maxPartsInFlightFlag)
+	registerFlag(maxMultipartUploadsFlag)
+	registerFlag(pathPrefixFlag)
+	registerFlag(maxKeyLengthFlag)
+	registerFlag(maxKeyDepthFlag)
+	registerFlag(diskOpenWorkersFlag)
+	registerFlag(minOnlineDisksFlag)
+	registerFlag(listWorkersFlag)
+	registerFlag(fileModeFlag)
+	registerFlag(dirModeFlag)
+	registerFlag(fsyncFlag)
+	registerFlag(writeOrderFlag)
+	registerFlag(diskTimeoutFlag)
+	registerFlag(dedupFlag)
+	registerFlag(readRepairFlag)
+	registerFlag(disableMD5Flag)
+	registerFlag(etagAlgorithmFlag)
+	registerFlag(disableBufferPoolFlag)
+	registerFlag(rpcIdleTimeoutFlag)
+	registerFlag(shutdownTimeoutFlag)
+	registerFlag(maxHeaderBytesFlag)
+	registerFlag(idempotencyTTLFlag)
+	registerFlag(thumbnailFlag)
+	registerFlag(compressFlag)
+	registerFlag(disableXMLCompressionFlag)
+	registerFlag(sanitizeHeadersFlag)
+	registerFlag(cacheControlFlag)
+	registerFlag(writeRetriesFlag)
+	registerFlag(writeRetryBackoffFlag)
+	registerFlag(notFoundCacheTTLFlag)
+	registerFlag(orphanExpiryFlag)
+	registerFlag(multipartTTLFlag)
+	registerFlag(cacheDirFlag)
+	registerFlag(cacheSizeFlag)
+	registerFlag(cacheWritesFlag)
+	registerFlag(erasureRatioFlag)
+	registerFlag(localDisksFlag)
+	registerFlag(metadataIndexSizeFlag)
+	registerFlag(diskProbeFlag)
+	registerFlag(accessTimeIntervalFlag)
+	registerFlag(defaultBucketFlag)
+	registerFlag(auditLogFlag)
+	registerFlag(otelEndpointFlag)
+	registerFlag(dumpDirFlag)
+	registerFlag(dumpSignalFlag)
+	registerFlag(dumpHeapFlag)
+	registerFlag(codecFlag)
+	registerFlag(backgroundIOPriorityFlag)
 	registerFlag(jsonFlag)
 
 	// set up app
@@ -164,6 +298,18 @@ func main() {
 	app := registerApp()
 	app.Before = func(c *cli.Context) error {
 		globalJSONFlag = c.GlobalBool("json")
+		if globalJSONFlag {
+			// errors are logged as json too, scripts never have to parse text
+			log.Formatter = &logrus.JSONFormatter{}
+		}
+		// the server as well as xl rebalance and fsck encode with the same codec
+		if e := erasure.SetCodec(c.GlobalString("codec")); e != nil {
+			fatalIf(probe.NewError(e), "Invalid --codec.", nil)
+		}
+		// background work of the server runs at it as well as xl fsck, rebalance and repair-metadata
+		priority, err := disk.ParseIOPriority(c.GlobalString("background-ioprio"))
+		fatalIf(err.Trace(c.GlobalString("background-ioprio")), "Invalid --background-ioprio.", nil)
+		fatalIf(xl.SetBackgroundIOPriority(priority).Trace(priority.String()), "Unable to set --background-ioprio.", nil)
 		return nil
 	}
 	app.ExtraInfo = func() map[string]string {
@@ -30,6 +30,13 @@ import (
 type File struct {
 	*os.File
 	file string
+	// syncOnClose - Close syncs data and the rename to disk
+	syncOnClose bool
+}
+
+// SyncOnClose - make Close sync the file and the directory it is renamed in to disk
+func (f *File) SyncOnClose() {
+	f.syncOnClose = true
 }
 
 // CloseAndSync sync file to disk and close, returns an error if any
@@ -38,14 +45,18 @@ func (f *File) CloseAndSync() error {
 	if err := f.File.Sync(); err != nil {
 		return err
 	}
-	if err := f.Close(); err != nil {
-		return err
-	}
-	return nil
+	return f.close()
 }
 
 // Close the file, returns an error if any
 func (f *File) Close() error {
+	if f.syncOnClose {
+		return f.CloseAndSync()
+	}
+	return f.close()
+}
+
+func (f *File) close() error {
 	// close the embedded fd
 	if err := f.File.Close(); err != nil {
 		return err
@@ -54,9 +65,23 @@ func (f *File) Close() error {
 	if err := os.Rename(f.Name(), f.file); err != nil {
 		return err
 	}
+	if f.syncOnClose {
+		// the rename itself is only durable once its directory is synced
+		return syncDir(filepath.Dir(f.file))
+	}
 	return nil
 }
 
+// syncDir - sync a directory to disk
+func syncDir(dir string) error {
+	d, err := os.Open(dir)
+	if err != nil {
+		return err
+	}
+	defer d.Close()
+	return d.Sync()
+}
+
 // CloseAndPurge removes the temp file, closes the transaction and returns an error if any
 func (f *File) CloseAndPurge() error {
 	// close the embedded fd
@@ -77,6 +102,16 @@ func FileCreate(filePath string) (*File, error) {
 // FileCreateWithPrefix creates a new file at filePath for atomic writes, it also creates parent directories if they don't exist
 // prefix specifies the prefix of the temporary files so that cleaning stale temp files is easy
 func FileCreateWithPrefix(filePath string, prefix string) (*File, error) {
+	return fileCreate(filePath, prefix, 0600)
+}
+
+// FileCreateWithMode creates a new file at filePath for atomic writes with permissions mode, it also creates parent
+// directories if they don't exist
+func FileCreateWithMode(filePath string, mode os.FileMode) (*File, error) {
+	return fileCreate(filePath, "$deleteme.", mode)
+}
+
+func fileCreate(filePath string, prefix string, mode os.FileMode) (*File, error) {
 	// if parent directories do not exist, ioutil.TempFile doesn't create them
 	// handle such a case with os.MkdirAll()
 	if err := os.MkdirAll(filepath.Dir(filePath), 0700); err != nil {
@@ -87,7 +122,7 @@ func FileCreateWithPrefix(filePath string, prefix string) (*File, error) {
 	if err != nil {
 		return nil, err
 	}
-	if err := os.Chmod(f.Name(), 0600); err != nil {
+	if err := os.Chmod(f.Name(), mode); err != nil {
 		if err := os.Remove(f.Name()); err != nil {
 			return nil, err
 		}
@@ -64,3 +64,16 @@ func (s *MySuite) TestAtomicPurge(c *C) {
 	err = f.Close()
 	c.Assert(err, Not(IsNil))
 }
+
+func (s *MySuite) TestAtomicSyncOnClose(c *C) {
+	f, err := FileCreate(filepath.Join(s.root, "syncfile"))
+	c.Assert(err, IsNil)
+	f.SyncOnClose()
+	_, err = f.Write([]byte("sync"))
+	c.Assert(err, IsNil)
+	err = f.Close()
+	c.Assert(err, IsNil)
+	data, err := ioutil.ReadFile(filepath.Join(s.root, "syncfile"))
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "sync")
+}
@@ -0,0 +1,98 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package erasure
+
+import (
+	"fmt"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/cpu"
+)
+
+// Codecs encoders are implemented with, both produce the same blocks
+const (
+	// CodecISAL - Intel ISA-L, using the AVX2, AVX or SSE4.1 instructions of the CPU
+	CodecISAL = "isal"
+	// CodecGo - portable Go, for CPUs without any of those instructions
+	CodecGo = "go"
+)
+
+// defaultCodec - codec of encoders unless SetCodec() is called
+const defaultCodec = CodecISAL
+
+// internal variable only accessed via get/set methods
+var codec atomic.Value
+
+// coder - arithmetic of a codec on blocks of equal length
+type coder interface {
+	// encode - fill the m parity blocks following the k data blocks
+	encode(blockLen int, blocks [][]byte)
+	// reconstruct - recompute the missing blocks from the others, missing blocks are allocated
+	reconstruct(blockLen int, blocks [][]byte, missing []int) error
+}
+
+// SetCodec - set codec of encoders created from now on
+func SetCodec(name string) error {
+	switch name {
+	case CodecISAL, CodecGo:
+		codec.Store(name)
+		return nil
+	}
+	return fmt.Errorf("Unsupported erasure codec ‘%s’, supported codecs are ‘%s’ and ‘%s’", name, CodecISAL, CodecGo)
+}
+
+// getCodec - codec set, ISA-L falls back to Go if the CPU has no SIMD instructions it uses
+func getCodec() string {
+	name, ok := codec.Load().(string)
+	if !ok {
+		name = defaultCodec
+	}
+	if name == CodecISAL && getISALInstructions() == "" {
+		return CodecGo
+	}
+	return name
+}
+
+// getISALInstructions - instructions ISA-L picks on this CPU, empty if none
+func getISALInstructions() string {
+	switch {
+	case cpu.HasAVX2():
+		return "avx2"
+	case cpu.HasAVX():
+		return "avx"
+	case cpu.HasSSE41():
+		return "sse4.1"
+	}
+	return ""
+}
+
+// GetCodec - codec of new encoders along with the instructions it runs on e.g. ‘isal/avx2’
+func GetCodec() string {
+	name := getCodec()
+	if name == CodecISAL {
+		return name + "/" + getISALInstructions()
+	}
+	return name
+}
+
+func newCoder(name string, k, m int) coder {
+	switch name {
+	case CodecGo:
+		return newGoCoder(k, m)
+	}
+	return newISALCoder(k, m)
+}
@@ -0,0 +1,86 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package erasure
+
+import (
+	"bytes"
+	"math/rand"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MySuite) TestSetCodec(c *C) {
+	defer SetCodec(defaultCodec)
+	c.Assert(SetCodec("simd"), Not(IsNil))
+	c.Assert(SetCodec(CodecGo), IsNil)
+	c.Assert(GetCodec(), Equals, CodecGo)
+	c.Assert(NewErasure(&Params{K: 8, M: 8}).coder, FitsTypeOf, &goCoder{})
+	c.Assert(SetCodec(CodecISAL), IsNil)
+	if getISALInstructions() == "" {
+		c.Assert(GetCodec(), Equals, CodecGo)
+	} else {
+		c.Assert(GetCodec(), Equals, CodecISAL+"/"+getISALInstructions())
+	}
+}
+
+func (s *MySuite) TestCodecsCompatible(c *C) {
+	data := make([]byte, 100000)
+	rand.New(rand.NewSource(1)).Read(data)
+	// Vandermonde coefficients below 5 data blocks, Cauchy from there on
+	for _, params := range []Params{{K: 2, M: 2}, {K: 4, M: 4}, {K: 8, M: 8}, {K: k, M: m}} {
+		isal := NewErasure(&Params{K: params.K, M: params.M, Codec: CodecISAL})
+		gocodec := NewErasure(&Params{K: params.K, M: params.M, Codec: CodecGo})
+		isalBlocks, err := isal.Encode(data)
+		c.Assert(err, IsNil)
+		goBlocks, err := gocodec.Encode(data)
+		c.Assert(err, IsNil)
+		c.Assert(goBlocks, DeepEquals, isalBlocks)
+
+		// as many blocks as there is parity are lost, the first data and the last parity blocks
+		lost := make([][]byte, len(goBlocks))
+		copy(lost, goBlocks)
+		for i := 0; i < int(params.M); i++ {
+			if i%2 == 0 {
+				lost[i/2] = nil
+			} else {
+				lost[len(lost)-1-i/2] = nil
+			}
+		}
+		decoded, err := NewErasure(&Params{K: params.K, M: params.M, Codec: CodecGo}).Decode(lost, len(data))
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(decoded, data), Equals, true)
+		c.Assert(lost, DeepEquals, isalBlocks)
+	}
+}
+
+func benchmarkEncode(b *testing.B, codec string) {
+	data := make([]byte, 4*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	e := NewErasure(&Params{K: 8, M: 8, Codec: codec})
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := e.Encode(data); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkEncodeISAL(b *testing.B) { benchmarkEncode(b, CodecISAL) }
+
+func BenchmarkEncodeGo(b *testing.B) { benchmarkEncode(b, CodecGo) }
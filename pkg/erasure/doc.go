@@ -5,6 +5,8 @@
 // For more information on Intel ISA-L, please visit:
 // https://01.org/intel%C2%AE-storage-acceleration-library-open-source-version
 //
+// SetCodec selects a portable Go implementation instead, producing the same blocks.
+//
 // Usage:
 //
 // Encode encodes a block of data. The input is the original data. The output
@@ -16,15 +16,9 @@
 
 package erasure
 
-// #cgo CFLAGS: -O0
-// #include <stdlib.h>
-// #include "ec.h"
-// #include "ec_minio_common.h"
-import "C"
 import (
 	"errors"
 	"fmt"
-	"unsafe"
 )
 
 // Decode decodes erasure coded blocks of data into its original
@@ -34,15 +28,14 @@ import (
 // "encodedDataBlocks" is an array of K data blocks and M parity
 // blocks. Data blocks are position and order dependent. Missing blocks
 // are set to "nil". There must be at least "K" number of data|parity
-// blocks.
+// blocks. Missing parity blocks are only recomputed when data blocks
+// are missing as well.
 //
 // "dataLen" is the length of original source data
 func (e *Erasure) Decode(encodedDataBlocks [][]byte, dataLen int) (decodedData []byte, err error) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
 
-	var source, target **C.uchar
-
 	k := int(e.params.K)
 	m := int(e.params.M)
 	n := k + m
@@ -60,10 +53,12 @@ func (e *Erasure) Decode(encodedDataBlocks [][]byte, dataLen int) (decodedData [
 	var missingEncodedBlocksCount int
 
 	// Check for the missing encoded blocks
+	var missingDataBlocks bool
 	for i := range encodedDataBlocks {
 		if encodedDataBlocks[i] == nil || len(encodedDataBlocks[i]) == 0 {
 			missingEncodedBlocks[missingEncodedBlocksCount] = i
 			missingEncodedBlocksCount++
+			missingDataBlocks = missingDataBlocks || i < k
 		}
 	}
 
@@ -72,8 +67,14 @@ func (e *Erasure) Decode(encodedDataBlocks [][]byte, dataLen int) (decodedData [
 		return nil, fmt.Errorf("Cannot reconstruct original data. Need at least [%d]  data or parity blocks", m)
 	}
 
-	// Convert from Go int slice to C int array
-	missingEncodedBlocksC := intSlice2CIntArray(missingEncodedBlocks[:missingEncodedBlocksCount])
+	// Data blocks are all there, nothing to recompute
+	if !missingDataBlocks {
+		decodedData = make([]byte, 0, encodedBlockLen*int(k))
+		for i := 0; i < int(k); i++ {
+			decodedData = append(decodedData, encodedDataBlocks[i]...)
+		}
+		return decodedData[:dataLen], nil
+	}
 
 	// Allocate buffer for the missing blocks
 	for i := range encodedDataBlocks {
@@ -82,37 +83,11 @@ func (e *Erasure) Decode(encodedDataBlocks [][]byte, dataLen int) (decodedData [
 		}
 	}
 
-	// If not already initialized, recompute and cache
-	if e.decodeMatrix == nil || e.decodeTbls == nil || e.decodeIndex == nil {
-		var decodeMatrix, decodeTbls *C.uchar
-		var decodeIndex *C.uint32_t
-
-		C.minio_init_decoder(missingEncodedBlocksC, C.int(k), C.int(n), C.int(missingEncodedBlocksCount),
-			e.encodeMatrix, &decodeMatrix, &decodeTbls, &decodeIndex)
-
-		// cache this for future needs
-		e.decodeMatrix = decodeMatrix
-		e.decodeTbls = decodeTbls
-		e.decodeIndex = decodeIndex
+	// Recompute the missing blocks from the others
+	if err := e.coder.reconstruct(encodedBlockLen, encodedDataBlocks, missingEncodedBlocks[:missingEncodedBlocksCount]); err != nil {
+		return nil, err
 	}
 
-	// Make a slice of pointers to encoded blocks. Necessary to bridge to the C world.
-	pointers := make([]*byte, n)
-	for i := range encodedDataBlocks {
-		pointers[i] = &encodedDataBlocks[i][0]
-	}
-
-	// Get pointers to source "data" and target "parity" blocks from the output byte array.
-	ret := C.minio_get_source_target(C.int(missingEncodedBlocksCount), C.int(k), C.int(m), missingEncodedBlocksC,
-		e.decodeIndex, (**C.uchar)(unsafe.Pointer(&pointers[0])), &source, &target)
-	if int(ret) == -1 {
-		return nil, errors.New("Unable to decode data")
-	}
-
-	// Decode data
-	C.ec_encode_data(C.int(encodedBlockLen), C.int(k), C.int(missingEncodedBlocksCount), e.decodeTbls,
-		source, target)
-
 	// Allocate buffer to output buffer
 	decodedData = make([]byte, 0, encodedBlockLen*int(k))
 	for i := 0; i < int(k); i++ {
@@ -16,14 +16,9 @@
 
 package erasure
 
-// #include <stdlib.h>
-// #include "ec.h"
-// #include "ec_minio_common.h"
-import "C"
 import (
 	"errors"
 	"sync"
-	"unsafe"
 )
 
 // Block alignment
@@ -35,15 +30,15 @@ const (
 type Params struct {
 	K uint8
 	M uint8
+	// Codec - codec of the encoder, the one set by SetCodec() if empty
+	Codec string
 }
 
 // Erasure is an object used to encode and decode data.
 type Erasure struct {
-	params                   *Params
-	encodeMatrix, encodeTbls *C.uchar
-	decodeMatrix, decodeTbls *C.uchar
-	decodeIndex              *C.uint32_t
-	mutex                    *sync.Mutex
+	params *Params
+	coder  coder
+	mutex  *sync.Mutex
 }
 
 // ValidateParams creates an Params object.
@@ -72,22 +67,14 @@ func ValidateParams(k, m uint8) (*Params, error) {
 
 // NewErasure creates an encoder object with a given set of parameters.
 func NewErasure(ep *Params) *Erasure {
-	var k = C.int(ep.K)
-	var m = C.int(ep.M)
-
-	var encodeMatrix *C.uchar
-	var encodeTbls *C.uchar
-
-	C.minio_init_encoder(k, m, &encodeMatrix, &encodeTbls)
-
+	codec := ep.Codec
+	if codec == "" {
+		codec = getCodec()
+	}
 	return &Erasure{
-		params:       ep,
-		encodeMatrix: encodeMatrix,
-		encodeTbls:   encodeTbls,
-		decodeMatrix: nil,
-		decodeTbls:   nil,
-		decodeIndex:  nil,
-		mutex:        new(sync.Mutex),
+		params: ep,
+		coder:  newCoder(codec, int(ep.K), int(ep.M)),
+		mutex:  new(sync.Mutex),
 	}
 }
 
@@ -111,7 +98,8 @@ func GetEncodedBlockLen(inputLen int, k uint8) (encodedOutputLen int) {
 }
 
 // Encode erasure codes a block of data in "k" data blocks and "m" parity blocks.
-// Output is [k+m][]blocks of data and parity slices.
+// Output is [k+m][]blocks of data and parity slices. Blocks share the memory of
+// inputData if its capacity holds all of them, see GetEncodedBlockLen.
 func (e *Erasure) Encode(inputData []byte) (encodedBlocks [][]byte, err error) {
 	e.mutex.Lock()
 	defer e.mutex.Unlock()
@@ -124,51 +112,34 @@ func (e *Erasure) Encode(inputData []byte) (encodedBlocks [][]byte, err error) {
 	// Total number of encoded chunks = "k" data  + "m" parity blocks
 	encodedBlockLen := GetEncodedBlockLen(len(inputData), uint8(k))
 
-	// Length of total number of "k" data chunks
-	encodedDataBlocksLen := encodedBlockLen * k
-
-	// Length of extra padding required for the data blocks.
-	encodedDataBlocksPadLen := encodedDataBlocksLen - len(inputData)
-
-	// Extend inputData buffer to accommodate coded data blocks if necesssary
-	if encodedDataBlocksPadLen > 0 {
-		padding := make([]byte, encodedDataBlocksPadLen)
-		// Expand with new padded blocks to the byte array
-		inputData = append(inputData, padding...)
+	// Extend inputData buffer to accommodate padding and coded parity blocks, in place
+	// if its capacity allows. Whatever the capacity held before is cleared, padding is
+	// stored along with the data blocks and parity is accumulated.
+	inputLen := len(inputData)
+	encodedLen := encodedBlockLen * n
+	if cap(inputData) < encodedLen {
+		buffer := make([]byte, inputLen, encodedLen)
+		copy(buffer, inputData)
+		inputData = buffer
 	}
-
-	// Extend inputData buffer to accommodate coded parity blocks
-	{ // Local Scope
-		encodedParityBlocksLen := encodedBlockLen * m
-		parityBlocks := make([]byte, encodedParityBlocksLen)
-		inputData = append(inputData, parityBlocks...)
+	inputData = inputData[:encodedLen]
+	padding := inputData[inputLen:]
+	for i := range padding {
+		padding[i] = 0
 	}
 
 	// Allocate memory to the "encoded blocks" return buffer
 	encodedBlocks = make([][]byte, n) // Return buffer
 
-	// Neccessary to bridge Go to the C world. C requires 2D arry of pointers to
-	// byte array. "encodedBlocks" is a 2D slice.
-	pointersToEncodedBlock := make([]*byte, n) // Pointers to encoded blocks.
-
-	// Copy data block slices to encoded block buffer
-	for i := 0; i < k; i++ {
+	// Copy data and erasure block slices to encoded block buffer
+	for i := 0; i < n; i++ {
 		encodedBlocks[i] = inputData[i*encodedBlockLen : (i+1)*encodedBlockLen]
-		pointersToEncodedBlock[i] = &encodedBlocks[i][0]
-	}
-
-	// Copy erasure block slices to encoded block buffer
-	for i := k; i < n; i++ {
-		encodedBlocks[i] = make([]byte, encodedBlockLen)
-		pointersToEncodedBlock[i] = &encodedBlocks[i][0]
 	}
 
 	// Erasure code the data into K data blocks and M parity
 	// blocks. Only the parity blocks are filled. Data blocks remain
 	// intact.
-	C.ec_encode_data(C.int(encodedBlockLen), C.int(k), C.int(m), e.encodeTbls,
-		(**C.uchar)(unsafe.Pointer(&pointersToEncodedBlock[:k][0])), // Pointers to data blocks
-		(**C.uchar)(unsafe.Pointer(&pointersToEncodedBlock[k:][0]))) // Pointers to parity blocks
+	e.coder.encode(encodedBlockLen, encodedBlocks)
 
 	return encodedBlocks, nil
 }
@@ -0,0 +1,201 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package erasure
+
+import "errors"
+
+// gfPolynomial - GF(2^8) is generated by x^8 + x^4 + x^3 + x^2 + 1 as in Intel ISA-L, blocks
+// written by either codec are read by the other
+const gfPolynomial = 0x11d
+
+var (
+	gfExp [255]byte
+	gfLog [256]byte
+	// gfMulTable - gfMulTable[a][b] is a * b
+	gfMulTable [256][256]byte
+)
+
+func init() {
+	x := 1
+	for i := 0; i < 255; i++ {
+		gfExp[i] = byte(x)
+		gfLog[x] = byte(i)
+		x <<= 1
+		if x&0x100 != 0 {
+			x ^= gfPolynomial
+		}
+	}
+	for a := 1; a < 256; a++ {
+		for b := 1; b < 256; b++ {
+			gfMulTable[a][b] = gfExp[(int(gfLog[a])+int(gfLog[b]))%255]
+		}
+	}
+}
+
+func gfMul(a, b byte) byte {
+	return gfMulTable[a][b]
+}
+
+func gfInv(a byte) byte {
+	if a == 0 {
+		return 0
+	}
+	return gfExp[(255-int(gfLog[a]))%255]
+}
+
+// gfMulAdd - dst ^= c * src
+func gfMulAdd(c byte, src, dst []byte) {
+	if c == 0 {
+		return
+	}
+	table := &gfMulTable[c]
+	src = src[:len(dst)]
+	for i, b := range src {
+		dst[i] ^= table[b]
+	}
+}
+
+// gfInvertMatrix - inverse of the n x n matrix in, in is destroyed on the way
+func gfInvertMatrix(in []byte, n int) ([]byte, error) {
+	out := make([]byte, n*n)
+	for i := 0; i < n; i++ {
+		out[i*n+i] = 1
+	}
+	for i := 0; i < n; i++ {
+		// a row with a non zero pivot is swapped in if needed
+		if in[i*n+i] == 0 {
+			j := i + 1
+			for j < n && in[j*n+i] == 0 {
+				j++
+			}
+			if j == n {
+				return nil, errors.New("Matrix is singular")
+			}
+			for l := 0; l < n; l++ {
+				in[i*n+l], in[j*n+l] = in[j*n+l], in[i*n+l]
+				out[i*n+l], out[j*n+l] = out[j*n+l], out[i*n+l]
+			}
+		}
+		inv := gfInv(in[i*n+i])
+		for l := 0; l < n; l++ {
+			in[i*n+l] = gfMul(in[i*n+l], inv)
+			out[i*n+l] = gfMul(out[i*n+l], inv)
+		}
+		for j := 0; j < n; j++ {
+			if j == i {
+				continue
+			}
+			factor := in[j*n+i]
+			for l := 0; l < n; l++ {
+				in[j*n+l] ^= gfMul(factor, in[i*n+l])
+				out[j*n+l] ^= gfMul(factor, out[i*n+l])
+			}
+		}
+	}
+	return out, nil
+}
+
+// goCoder - portable Go, same matrices and field as isalCoder without any SIMD
+type goCoder struct {
+	k, m int
+	// encodeMatrix - (k + m) x k, identity on top of the parity rows
+	encodeMatrix []byte
+}
+
+func newGoCoder(k, m int) *goCoder {
+	n := k + m
+	matrix := make([]byte, n*k)
+	for i := 0; i < k; i++ {
+		matrix[i*k+i] = 1
+	}
+	// same choice of coefficients as minio_init_encoder()
+	if k < 5 {
+		gen := byte(1)
+		for i := k; i < n; i++ {
+			p := byte(1)
+			for j := 0; j < k; j++ {
+				matrix[i*k+j] = p
+				p = gfMul(p, gen)
+			}
+			gen = gfMul(gen, 2)
+		}
+	} else {
+		for i := k; i < n; i++ {
+			for j := 0; j < k; j++ {
+				matrix[i*k+j] = gfInv(byte(i ^ j))
+			}
+		}
+	}
+	return &goCoder{k: k, m: m, encodeMatrix: matrix}
+}
+
+func (c *goCoder) encode(blockLen int, blocks [][]byte) {
+	for i := c.k; i < c.k+c.m; i++ {
+		parity := blocks[i][:blockLen]
+		for j := 0; j < c.k; j++ {
+			gfMulAdd(c.encodeMatrix[i*c.k+j], blocks[j], parity)
+		}
+	}
+}
+
+func (c *goCoder) reconstruct(blockLen int, blocks [][]byte, missing []int) error {
+	if len(missing) == 0 {
+		return nil
+	}
+	isMissing := make(map[int]bool)
+	for _, i := range missing {
+		isMissing[i] = true
+	}
+	// rows of the first k blocks left, as minio_init_decoder() picks them
+	k := c.k
+	sources := make([]int, 0, k)
+	input := make([]byte, 0, k*k)
+	for r := 0; len(sources) < k; r++ {
+		if isMissing[r] {
+			continue
+		}
+		sources = append(sources, r)
+		input = append(input, c.encodeMatrix[r*k:(r+1)*k]...)
+	}
+	inverse, err := gfInvertMatrix(input, k)
+	if err != nil {
+		return err
+	}
+	row := make([]byte, k)
+	for _, target := range missing {
+		if target < k {
+			copy(row, inverse[target*k:(target+1)*k])
+		} else {
+			// parity row of the block applied to the data recovered from the sources
+			for i := 0; i < k; i++ {
+				var s byte
+				for j := 0; j < k; j++ {
+					s ^= gfMul(inverse[j*k+i], c.encodeMatrix[target*k+j])
+				}
+				row[i] = s
+			}
+		}
+		dst := blocks[target][:blockLen]
+		for i := range dst {
+			dst[i] = 0
+		}
+		for i, source := range sources {
+			gfMulAdd(row[i], blocks[source], dst)
+		}
+	}
+	return nil
+}
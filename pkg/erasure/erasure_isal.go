@@ -0,0 +1,100 @@
+/*
+ * Minio Cloud Storage, (C) 2014 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package erasure
+
+// #cgo CFLAGS: -O0
+// #include <stdlib.h>
+// #include "ec.h"
+// #include "ec_minio_common.h"
+import "C"
+import (
+	"errors"
+	"unsafe"
+)
+
+// isalCoder - Intel ISA-L, picks the SIMD instructions of the CPU at runtime
+type isalCoder struct {
+	k, m                     int
+	encodeMatrix, encodeTbls *C.uchar
+	decodeMatrix, decodeTbls *C.uchar
+	decodeIndex              *C.uint32_t
+}
+
+func newISALCoder(k, m int) *isalCoder {
+	var encodeMatrix *C.uchar
+	var encodeTbls *C.uchar
+
+	C.minio_init_encoder(C.int(k), C.int(m), &encodeMatrix, &encodeTbls)
+
+	return &isalCoder{
+		k:            k,
+		m:            m,
+		encodeMatrix: encodeMatrix,
+		encodeTbls:   encodeTbls,
+	}
+}
+
+func (c *isalCoder) encode(blockLen int, blocks [][]byte) {
+	// Neccessary to bridge Go to the C world. C requires 2D arry of pointers to
+	// byte array. "blocks" is a 2D slice.
+	pointers := make([]*byte, c.k+c.m)
+	for i := range blocks {
+		pointers[i] = &blocks[i][0]
+	}
+	C.ec_encode_data(C.int(blockLen), C.int(c.k), C.int(c.m), c.encodeTbls,
+		(**C.uchar)(unsafe.Pointer(&pointers[:c.k][0])), // Pointers to data blocks
+		(**C.uchar)(unsafe.Pointer(&pointers[c.k:][0]))) // Pointers to parity blocks
+}
+
+func (c *isalCoder) reconstruct(blockLen int, blocks [][]byte, missing []int) error {
+	var source, target **C.uchar
+
+	// Convert from Go int slice to C int array
+	missingC := intSlice2CIntArray(missing)
+
+	// If not already initialized, recompute and cache
+	if c.decodeMatrix == nil || c.decodeTbls == nil || c.decodeIndex == nil {
+		var decodeMatrix, decodeTbls *C.uchar
+		var decodeIndex *C.uint32_t
+
+		C.minio_init_decoder(missingC, C.int(c.k), C.int(c.k+c.m), C.int(len(missing)),
+			c.encodeMatrix, &decodeMatrix, &decodeTbls, &decodeIndex)
+
+		// cache this for future needs
+		c.decodeMatrix = decodeMatrix
+		c.decodeTbls = decodeTbls
+		c.decodeIndex = decodeIndex
+	}
+
+	// Make a slice of pointers to encoded blocks. Necessary to bridge to the C world.
+	pointers := make([]*byte, len(blocks))
+	for i := range blocks {
+		pointers[i] = &blocks[i][0]
+	}
+
+	// Get pointers to source "data" and target "parity" blocks from the output byte array.
+	ret := C.minio_get_source_target(C.int(len(missing)), C.int(c.k), C.int(c.m), missingC,
+		c.decodeIndex, (**C.uchar)(unsafe.Pointer(&pointers[0])), &source, &target)
+	if int(ret) == -1 {
+		return errors.New("Unable to decode data")
+	}
+
+	// Decode data
+	C.ec_encode_data(C.int(blockLen), C.int(c.k), C.int(len(missing)), c.decodeTbls,
+		source, target)
+	return nil
+}
@@ -78,3 +78,20 @@ func (s *MySuite) TestEncodeDecodeSuccess(c *C) {
 		c.Fatalf("Recovered data mismatches with original data")
 	}
 }
+
+func (s *MySuite) TestDecodeMissingParity(c *C) {
+	ep, err := ValidateParams(k, m)
+	c.Assert(err, IsNil)
+
+	data := bytes.Repeat([]byte("Lorem Ipsum "), 100)
+	e := NewErasure(ep)
+	chunks, err := e.Encode(data)
+	c.Assert(err, IsNil)
+
+	// data blocks are returned as they are, lost parity is not recomputed
+	chunks = corruptChunks(chunks, []int{10, 12, 14})
+	recoveredData, err := e.Decode(chunks, len(data))
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data, recoveredData), Equals, true)
+	c.Assert(chunks[12], IsNil)
+}
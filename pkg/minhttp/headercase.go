@@ -0,0 +1,179 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minhttp
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"net/http"
+	"net/textproto"
+	"sync"
+)
+
+// maxHeaderCaseBytes - header blocks longer than this are not looked at, their keys keep the
+// canonical case
+const maxHeaderCaseBytes = 1 << 20
+
+// headerCaseConnKey - context key of the connection a request was read from
+type headerCaseConnKey struct{}
+
+// headerCaseServers - servers whose requests keep the case of header keys as sent
+var headerCaseServers = struct {
+	sync.Mutex
+	servers map[*http.Server]bool
+}{servers: make(map[*http.Server]bool)}
+
+// EnableHeaderCase - keys of headers sent over plain text HTTP/1.x connections to s are
+// recorded as sent, GetHeaderCase returns them for the first request of a connection. Header
+// keys sent over TLS connections terminated by s are only known in their canonical form.
+func EnableHeaderCase(s *http.Server) {
+	headerCaseServers.Lock()
+	defer headerCaseServers.Unlock()
+	headerCaseServers.servers[s] = true
+	connContext := s.ConnContext
+	s.ConnContext = func(ctx context.Context, c net.Conn) context.Context {
+		if connContext != nil {
+			ctx = connContext(ctx, c)
+		}
+		if hc, ok := c.(*headerCaseConn); ok {
+			ctx = context.WithValue(ctx, headerCaseConnKey{}, hc)
+		}
+		return ctx
+	}
+	// keys of the first request never leak to later ones on a kept alive connection
+	connState := s.ConnState
+	s.ConnState = func(c net.Conn, state http.ConnState) {
+		if connState != nil {
+			connState(c, state)
+		}
+		if hc, ok := c.(*headerCaseConn); ok && state == http.StateIdle {
+			hc.claimKeys()
+		}
+	}
+}
+
+// isHeaderCase - is header case recorded for s
+func isHeaderCase(s *http.Server) bool {
+	headerCaseServers.Lock()
+	defer headerCaseServers.Unlock()
+	return headerCaseServers.servers[s]
+}
+
+// GetHeaderCase - header keys of req as sent by the client by their canonical form, nil if
+// they are not known. Only the first request of a connection is answered, and only once,
+// later ones fall back to the canonical keys.
+func GetHeaderCase(req *http.Request) map[string]string {
+	hc, ok := req.Context().Value(headerCaseConnKey{}).(*headerCaseConn)
+	if !ok {
+		return nil
+	}
+	return hc.claimKeys()
+}
+
+// headerCaseListener - listener recording header keys of the first request on every
+// connection, must be above any tls listener
+type headerCaseListener struct {
+	net.Listener
+}
+
+// NewHeaderCaseListener - wrap l, servers given to ListenAndServe are wrapped once enabled
+// with EnableHeaderCase
+func NewHeaderCaseListener(l net.Listener) net.Listener {
+	return &headerCaseListener{Listener: l}
+}
+
+func (l *headerCaseListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &headerCaseConn{Conn: c}, nil
+}
+
+type headerCaseConn struct {
+	net.Conn
+	lock    sync.Mutex
+	header  []byte
+	done    bool
+	claimed bool
+	keys    map[string]string
+}
+
+// Read - bytes read are looked at until the end of the first header block
+func (c *headerCaseConn) Read(p []byte) (int, error) {
+	n, err := c.Conn.Read(p)
+	if n > 0 {
+		c.record(p[:n])
+	}
+	return n, err
+}
+
+// record - collect the first header block, keys are parsed once it is complete
+func (c *headerCaseConn) record(p []byte) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.done {
+		return
+	}
+	c.header = append(c.header, p...)
+	end := bytes.Index(c.header, []byte("\r\n\r\n"))
+	if end < 0 {
+		end = bytes.Index(c.header, []byte("\n\n"))
+	}
+	if end < 0 {
+		if len(c.header) > maxHeaderCaseBytes {
+			c.done = true
+			c.header = nil
+		}
+		return
+	}
+	c.keys = parseHeaderKeys(c.header[:end])
+	c.done = true
+	c.header = nil
+}
+
+// claimKeys - keys of the first request, handed out once
+func (c *headerCaseConn) claimKeys() map[string]string {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if c.claimed || !c.done {
+		return nil
+	}
+	c.claimed = true
+	return c.keys
+}
+
+// parseHeaderKeys - keys of a header block by their canonical form, the request line is
+// skipped. Of keys sent in several cases the first one wins.
+func parseHeaderKeys(header []byte) map[string]string {
+	keys := make(map[string]string)
+	lines := bytes.Split(header, []byte("\n"))
+	for _, line := range lines[1:] {
+		i := bytes.IndexByte(line, ':')
+		// continuation lines and lines without a key
+		if i <= 0 || line[0] == ' ' || line[0] == '\t' {
+			continue
+		}
+		key := string(line[:i])
+		canonicalKey := textproto.CanonicalMIMEHeaderKey(key)
+		if _, ok := keys[canonicalKey]; !ok {
+			keys[canonicalKey] = key
+		}
+	}
+	return keys
+}
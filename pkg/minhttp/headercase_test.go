@@ -0,0 +1,72 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minhttp
+
+import (
+	"bufio"
+	"io/ioutil"
+	"net"
+	"net/http"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyHeaderCaseSuite struct{}
+
+var _ = Suite(&MyHeaderCaseSuite{})
+
+func (s *MyHeaderCaseSuite) TestParseHeaderKeys(c *C) {
+	keys := parseHeaderKeys([]byte("PUT /bucket/object HTTP/1.1\r\nHost: localhost\r\nx-amz-meta-MyKey: one\r\n" +
+		" continued\r\nX-AMZ-META-MYKEY: two\r\nx-amz-meta-other:three"))
+	c.Assert(keys, DeepEquals, map[string]string{
+		"Host":             "Host",
+		"X-Amz-Meta-Mykey": "x-amz-meta-MyKey",
+		"X-Amz-Meta-Other": "x-amz-meta-other",
+	})
+}
+
+func (s *MyHeaderCaseSuite) TestHeaderCaseListener(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	hl := NewHeaderCaseListener(l)
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(GetHeaderCase(r)["X-Amz-Meta-Mykey"]))
+	})}
+	EnableHeaderCase(server)
+	c.Assert(isHeaderCase(server), Equals, true)
+	c.Assert(isHeaderCase(&http.Server{}), Equals, false)
+	go server.Serve(hl)
+	defer hl.Close()
+
+	conn, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	reader := bufio.NewReader(conn)
+	request := func() string {
+		_, err := conn.Write([]byte("GET / HTTP/1.1\r\nHost: localhost\r\nx-amz-meta-MyKey: value\r\n\r\n"))
+		c.Assert(err, IsNil)
+		response, err := http.ReadResponse(reader, nil)
+		c.Assert(err, IsNil)
+		defer response.Body.Close()
+		body, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		return string(body)
+	}
+	c.Assert(request(), Equals, "x-amz-meta-MyKey")
+	// later requests on a kept alive connection only get canonical keys
+	c.Assert(request(), Equals, "")
+}
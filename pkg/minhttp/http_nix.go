@@ -49,12 +49,17 @@ type app struct {
 // listen initailize listeners
 func (a *app) listen() *probe.Error {
 	for _, s := range a.servers {
-		l, err := a.net.Listen("tcp", s.Addr)
+		l, err := a.net.Listen(SplitNetworkAddress(s.Addr))
 		if err != nil {
 			return err.Trace()
 		}
+		if isProxyProtocol(s) {
+			l = newProxyProtocolListener(l)
+		}
 		if s.TLSConfig != nil {
 			l = tls.NewListener(l, s.TLSConfig)
+		} else if isHeaderCase(s) {
+			l = NewHeaderCaseListener(l)
 		}
 		a.listeners = append(a.listeners, l)
 	}
@@ -64,7 +69,7 @@ func (a *app) listen() *probe.Error {
 // serve start serving all listeners
 func (a *app) serve() {
 	h := &httpdown.HTTP{
-		StopTimeout: 10 * time.Second,
+		StopTimeout: getStopTimeout(),
 		KillTimeout: 1 * time.Second,
 	}
 	for i, s := range a.servers {
@@ -91,9 +96,18 @@ func (a *app) wait() {
 // trapSignal wait on listed signals for pre-defined behaviors
 func (a *app) trapSignal(wg *sync.WaitGroup) {
 	ch := make(chan os.Signal, 10)
-	signal.Notify(ch, syscall.SIGTERM, syscall.SIGHUP)
+	signal.Notify(ch, syscall.SIGTERM, syscall.SIGHUP, syscall.SIGUSR2)
+	// a restart in progress is not started again, unless the new process exits before taking over
+	var restarting bool
+	exited := make(chan struct{}, 1)
 	for {
-		sig := <-ch
+		var sig os.Signal
+		select {
+		case sig = <-ch:
+		case <-exited:
+			restarting = false
+			continue
+		}
 		switch sig {
 		case syscall.SIGTERM:
 			// this ensures a subsequent TERM will trigger standard go behaviour of terminating
@@ -108,12 +122,22 @@ func (a *app) trapSignal(wg *sync.WaitGroup) {
 				}(s)
 			}
 			return
-		case syscall.SIGHUP:
+		case syscall.SIGHUP, syscall.SIGUSR2:
+			if restarting {
+				continue
+			}
 			// we only return here if there's an error, otherwise the new process
 			// will send us a TERM when it's ready to trigger the actual shutdown.
-			if _, err := a.net.StartProcess(); err != nil {
+			process, err := a.net.StartProcess()
+			if err != nil {
 				a.errors <- err.Trace()
+				continue
 			}
+			restarting = true
+			go func() {
+				process.Wait()
+				exited <- struct{}{}
+			}()
 		}
 	}
 }
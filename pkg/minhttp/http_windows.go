@@ -49,12 +49,17 @@ type app struct {
 // listen initailize listeners
 func (a *app) listen() *probe.Error {
 	for _, s := range a.servers {
-		l, err := a.net.Listen("tcp", s.Addr)
+		l, err := a.net.Listen(SplitNetworkAddress(s.Addr))
 		if err != nil {
 			return err.Trace()
 		}
+		if isProxyProtocol(s) {
+			l = newProxyProtocolListener(l)
+		}
 		if s.TLSConfig != nil {
 			l = tls.NewListener(l, s.TLSConfig)
+		} else if isHeaderCase(s) {
+			l = NewHeaderCaseListener(l)
 		}
 		a.listeners = append(a.listeners, l)
 	}
@@ -64,7 +69,7 @@ func (a *app) listen() *probe.Error {
 // serve start serving all listeners
 func (a *app) serve() {
 	h := &httpdown.HTTP{
-		StopTimeout: 10 * time.Second,
+		StopTimeout: getStopTimeout(),
 		KillTimeout: 1 * time.Second,
 	}
 	for i, s := range a.servers {
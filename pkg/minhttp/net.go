@@ -24,6 +24,8 @@ import (
 	"strconv"
 	"strings"
 	"sync"
+	"sync/atomic"
+	"time"
 
 	"github.com/minio/minio-xl/pkg/probe"
 )
@@ -46,6 +48,27 @@ const (
 	envCountKeyPrefix = envCountKey + "="
 )
 
+// defaultStopTimeout - in-flight requests get this long to complete on stop or restart
+const defaultStopTimeout = 10 * time.Second
+
+// internal variable only accessed via get/set methods
+var stopTimeout = int64(defaultStopTimeout)
+
+// SetStopTimeout - set how long in-flight requests get to complete once the server is
+// stopped, or restarted by a new process taking over its listeners. Values smaller than
+// a second are raised to a second.
+func SetStopTimeout(timeout time.Duration) {
+	if timeout < time.Second {
+		timeout = time.Second
+	}
+	atomic.StoreInt64(&stopTimeout, int64(timeout))
+}
+
+// getStopTimeout - get how long in-flight requests get to complete
+func getStopTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&stopTimeout))
+}
+
 // In order to keep the working directory the same as when we started we record
 // it at startup.
 var originalWD, _ = os.Getwd()
@@ -188,11 +211,18 @@ func (n *minNet) ListenUnix(nett string, laddr *net.UnixAddr) (net.Listener, *pr
 		}
 	}
 
-	// make a fresh listener
+	// make a fresh listener, the socket is removed when the listener is closed
+	if err := removeStaleSocket(laddr.Name); err != nil {
+		return nil, err.Trace(laddr.Name)
+	}
 	l, err := net.ListenUnix(nett, laddr)
 	if err != nil {
 		return nil, probe.NewError(err)
 	}
+	if err := os.Chmod(laddr.Name, unixSocketMode); err != nil {
+		l.Close()
+		return nil, probe.NewError(err)
+	}
 	n.activeListeners = append(n.activeListeners, rateLimitedListener(l, n.connLimit))
 	return l, nil
 }
@@ -211,6 +241,10 @@ func (n1 minAddr) IsEqual(n2 net.Addr) bool {
 	if n1.Network() != n2.Network() {
 		return false
 	}
+	// unix sockets have no host and port, match them by path
+	if strings.HasPrefix(n1.Network(), "unix") {
+		return n1.String() == n2.String()
+	}
 	a1h, a1p, _ := net.SplitHostPort(n1.String())
 	a2h, a2p, _ := net.SplitHostPort(n2.String())
 	// Special cases since Addr() from net.Listener will
@@ -230,17 +264,21 @@ func (n1 minAddr) IsEqual(n2 net.Addr) bool {
 // StartProcess starts a new process passing it the active listeners. It
 // doesn't fork, but starts a new process using the same environment and
 // arguments as when it was originally started. This allows for a newly
-// deployed binary to be started. It returns the pid of the newly started
-// process when successful.
-func (n *minNet) StartProcess() (int, *probe.Error) {
+// deployed binary to be started. It returns the newly started process
+// when successful.
+func (n *minNet) StartProcess() (*os.Process, *probe.Error) {
 	listeners := n.getActiveListeners()
 	// Extract the fds from the listeners.
 	files := make([]*os.File, len(listeners))
 	for i, l := range listeners {
+		// socket is handed over to the new process, it must outlive this one
+		if ul, ok := getUnixListener(l); ok {
+			ul.SetUnlinkOnClose(false)
+		}
 		var err error
 		files[i], err = l.(fileListener).File()
 		if err != nil {
-			return 0, probe.NewError(err)
+			return nil, probe.NewError(err)
 		}
 		defer files[i].Close()
 	}
@@ -249,7 +287,7 @@ func (n *minNet) StartProcess() (int, *probe.Error) {
 	// the file it points to has been changed we will use the updated symlink.
 	argv0, err := exec.LookPath(os.Args[0])
 	if err != nil {
-		return 0, probe.NewError(err)
+		return nil, probe.NewError(err)
 	}
 
 	// Pass on the environment and replace the old count key with the new one.
@@ -268,7 +306,7 @@ func (n *minNet) StartProcess() (int, *probe.Error) {
 		Files: allFiles,
 	})
 	if err != nil {
-		return 0, probe.NewError(err)
+		return nil, probe.NewError(err)
 	}
-	return process.Pid, nil
+	return process, nil
 }
@@ -0,0 +1,206 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minhttp
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	// proxyHeaderTimeout - connections must send their PROXY protocol header within this long
+	proxyHeaderTimeout = 5 * time.Second
+	// maxProxyV1HeaderLength - longest v1 header, ‘PROXY TCP6’ with both addresses at full length
+	maxProxyV1HeaderLength = 107
+)
+
+// proxyV2Signature - v2 headers start with this binary signature
+var proxyV2Signature = []byte("\r\n\r\n\x00\r\nQUIT\n")
+
+var errInvalidProxyHeader = errors.New("Connection did not send a valid PROXY protocol header")
+
+// proxyProtocolServers - servers whose connections must start with a PROXY protocol header
+var proxyProtocolServers = struct {
+	sync.Mutex
+	servers map[*http.Server]bool
+}{servers: make(map[*http.Server]bool)}
+
+// EnableProxyProtocol - connections to s must start with a PROXY protocol v1 or v2 header
+// sent by a load balancer, the client address it carries becomes the remote address of the
+// connection. Connections without a valid header are closed.
+func EnableProxyProtocol(s *http.Server) {
+	proxyProtocolServers.Lock()
+	defer proxyProtocolServers.Unlock()
+	proxyProtocolServers.servers[s] = true
+}
+
+// isProxyProtocol - is PROXY protocol enabled for s
+func isProxyProtocol(s *http.Server) bool {
+	proxyProtocolServers.Lock()
+	defer proxyProtocolServers.Unlock()
+	return proxyProtocolServers.servers[s]
+}
+
+// proxyProtocolListener - listener reading a PROXY protocol header off every connection
+type proxyProtocolListener struct {
+	net.Listener
+	timeout time.Duration
+}
+
+// newProxyProtocolListener - wrap l, must be below any tls listener
+func newProxyProtocolListener(l net.Listener) net.Listener {
+	return &proxyProtocolListener{Listener: l, timeout: proxyHeaderTimeout}
+}
+
+// Accept - header is read on first use of the connection, never by the accepting goroutine
+func (l *proxyProtocolListener) Accept() (net.Conn, error) {
+	c, err := l.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &proxyProtocolConn{Conn: c, reader: bufio.NewReader(c), timeout: l.timeout}, nil
+}
+
+type proxyProtocolConn struct {
+	net.Conn
+	reader     *bufio.Reader
+	timeout    time.Duration
+	once       sync.Once
+	remoteAddr net.Addr
+	err        error
+}
+
+// readHeader - read the header once, connections without a valid one are closed
+func (c *proxyProtocolConn) readHeader() error {
+	c.once.Do(func() {
+		c.Conn.SetReadDeadline(time.Now().Add(c.timeout))
+		c.remoteAddr, c.err = readProxyHeader(c.reader)
+		c.Conn.SetReadDeadline(time.Time{})
+		if c.err != nil {
+			c.Conn.Close()
+		}
+	})
+	return c.err
+}
+
+func (c *proxyProtocolConn) Read(p []byte) (int, error) {
+	if err := c.readHeader(); err != nil {
+		return 0, err
+	}
+	return c.reader.Read(p)
+}
+
+// RemoteAddr - client address sent by the load balancer, own address for health checks
+// of the load balancer itself
+func (c *proxyProtocolConn) RemoteAddr() net.Addr {
+	if c.readHeader() == nil && c.remoteAddr != nil {
+		return c.remoteAddr
+	}
+	return c.Conn.RemoteAddr()
+}
+
+// readProxyHeader - read a v1 or v2 header, returns a nil address for connections the load
+// balancer makes on its own behalf
+func readProxyHeader(reader *bufio.Reader) (net.Addr, error) {
+	signature, err := reader.Peek(len(proxyV2Signature))
+	if err == nil && bytes.Equal(signature, proxyV2Signature) {
+		return readProxyV2Header(reader)
+	}
+	return readProxyV1Header(reader)
+}
+
+// readProxyV1Header - ‘PROXY TCP4|TCP6 <src> <dst> <srcport> <dstport>\r\n’ or ‘PROXY UNKNOWN ...\r\n’
+func readProxyV1Header(reader *bufio.Reader) (net.Addr, error) {
+	var line []byte
+	for len(line) < maxProxyV1HeaderLength {
+		b, err := reader.ReadByte()
+		if err != nil {
+			return nil, errInvalidProxyHeader
+		}
+		line = append(line, b)
+		if b == '\n' {
+			break
+		}
+	}
+	header := string(line)
+	if !strings.HasPrefix(header, "PROXY ") || !strings.HasSuffix(header, "\r\n") {
+		return nil, errInvalidProxyHeader
+	}
+	fields := strings.Split(strings.TrimSuffix(header, "\r\n"), " ")
+	if len(fields) >= 2 && fields[1] == "UNKNOWN" {
+		return nil, nil
+	}
+	if len(fields) != 6 || (fields[1] != "TCP4" && fields[1] != "TCP6") {
+		return nil, errInvalidProxyHeader
+	}
+	ip := net.ParseIP(fields[2])
+	port, err := strconv.ParseUint(fields[4], 10, 16)
+	if ip == nil || err != nil || (fields[1] == "TCP4") != (ip.To4() != nil) {
+		return nil, errInvalidProxyHeader
+	}
+	return &net.TCPAddr{IP: ip, Port: int(port)}, nil
+}
+
+// readProxyV2Header - binary header, only TCP over IPv4 or IPv6 addresses are used
+func readProxyV2Header(reader *bufio.Reader) (net.Addr, error) {
+	header := make([]byte, len(proxyV2Signature)+4)
+	if _, err := io.ReadFull(reader, header); err != nil {
+		return nil, errInvalidProxyHeader
+	}
+	versionCommand, family := header[12], header[13]
+	payload := make([]byte, binary.BigEndian.Uint16(header[14:16]))
+	if _, err := io.ReadFull(reader, payload); err != nil {
+		return nil, errInvalidProxyHeader
+	}
+	if versionCommand>>4 != 2 {
+		return nil, errInvalidProxyHeader
+	}
+	switch versionCommand & 0x0f {
+	case 0:
+		// LOCAL
+		return nil, nil
+	case 1:
+		// PROXY
+	default:
+		return nil, errInvalidProxyHeader
+	}
+	switch family {
+	case 0x11:
+		// TCP over IPv4, source and destination addresses followed by ports
+		if len(payload) < 12 {
+			return nil, errInvalidProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:4]), Port: int(binary.BigEndian.Uint16(payload[8:10]))}, nil
+	case 0x21:
+		// TCP over IPv6
+		if len(payload) < 36 {
+			return nil, errInvalidProxyHeader
+		}
+		return &net.TCPAddr{IP: net.IP(payload[0:16]), Port: int(binary.BigEndian.Uint16(payload[32:34]))}, nil
+	}
+	// unspecified, UDP or unix sockets, keep the address of the load balancer
+	return nil, nil
+}
@@ -0,0 +1,134 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minhttp
+
+import (
+	"bufio"
+	"bytes"
+	"io/ioutil"
+	"net"
+	"net/http"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyProxySuite struct{}
+
+var _ = Suite(&MyProxySuite{})
+
+func (s *MyProxySuite) TestReadProxyHeader(c *C) {
+	testCases := []struct {
+		header string
+		addr   string
+		err    error
+	}{
+		{"PROXY TCP4 192.168.1.10 10.0.0.1 56324 9000\r\nGET", "192.168.1.10:56324", nil},
+		{"PROXY TCP6 2001:db8::1 2001:db8::2 56324 9000\r\nGET", "[2001:db8::1]:56324", nil},
+		// health checks of the load balancer itself
+		{"PROXY UNKNOWN\r\nGET", "", nil},
+		// v2, TCP over IPv4
+		{string(proxyV2Signature) + "\x21\x11\x00\x0c\xc0\xa8\x01\x0a\x0a\x00\x00\x01\xdc\x04\x23\x28GET", "192.168.1.10:56324", nil},
+		// v2, LOCAL
+		{string(proxyV2Signature) + "\x20\x00\x00\x00GET", "", nil},
+		{"GET / HTTP/1.1\r\n", "", errInvalidProxyHeader},
+		{"PROXY TCP4 192.168.1.10 10.0.0.1 56324\r\nGET", "", errInvalidProxyHeader},
+		{"PROXY TCP4 2001:db8::1 2001:db8::2 56324 9000\r\nGET", "", errInvalidProxyHeader},
+		{"PROXY TCP4 192.168.1.10 10.0.0.1 56324 9000\nGET", "", errInvalidProxyHeader},
+		{"PROXY TCP4 " + strings.Repeat("1", 200) + "\r\n", "", errInvalidProxyHeader},
+		{string(proxyV2Signature) + "\x21\x11\x00\x0c\xc0\xa8", "", errInvalidProxyHeader},
+	}
+	for _, testCase := range testCases {
+		reader := bufio.NewReader(strings.NewReader(testCase.header))
+		addr, err := readProxyHeader(reader)
+		c.Assert(err, Equals, testCase.err)
+		if testCase.addr == "" {
+			c.Assert(addr, IsNil)
+		} else {
+			c.Assert(addr.String(), Equals, testCase.addr)
+		}
+		if err == nil {
+			// nothing after the header is consumed
+			rest, e := ioutil.ReadAll(reader)
+			c.Assert(e, IsNil)
+			c.Assert(string(rest), Equals, "GET")
+		}
+	}
+}
+
+func (s *MyProxySuite) TestProxyProtocolListener(c *C) {
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	pl := newProxyProtocolListener(l)
+	pl.(*proxyProtocolListener).timeout = 100 * time.Millisecond
+	server := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Write([]byte(r.RemoteAddr))
+	})}
+	go server.Serve(pl)
+	defer pl.Close()
+
+	request := func(header string) (string, error) {
+		conn, err := net.Dial("tcp", l.Addr().String())
+		if err != nil {
+			return "", err
+		}
+		defer conn.Close()
+		if _, err := conn.Write([]byte(header + "GET / HTTP/1.0\r\n\r\n")); err != nil {
+			return "", err
+		}
+		response, err := http.ReadResponse(bufio.NewReader(conn), nil)
+		if err != nil {
+			return "", err
+		}
+		defer response.Body.Close()
+		body, err := ioutil.ReadAll(response.Body)
+		return string(body), err
+	}
+
+	// client address is the one sent by the load balancer
+	remoteAddr, err := request("PROXY TCP4 192.168.1.10 10.0.0.1 56324 9000\r\n")
+	c.Assert(err, IsNil)
+	c.Assert(remoteAddr, Equals, "192.168.1.10:56324")
+
+	// load balancer health check keeps its own address
+	remoteAddr, err = request("PROXY UNKNOWN\r\n")
+	c.Assert(err, IsNil)
+	c.Assert(strings.HasPrefix(remoteAddr, "127.0.0.1:"), Equals, true)
+
+	// connections without a header are rejected
+	_, err = request("")
+	c.Assert(err, Not(IsNil))
+
+	// connections not sending anything are closed once the header times out
+	conn, err := net.Dial("tcp", l.Addr().String())
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	n, err := conn.Read(make([]byte, 1))
+	c.Assert(n, Equals, 0)
+	c.Assert(err, Not(IsNil))
+	c.Assert(bytes.Contains([]byte(err.Error()), []byte("timeout")), Equals, false)
+}
+
+func (s *MyProxySuite) TestEnableProxyProtocol(c *C) {
+	server := &http.Server{}
+	c.Assert(isProxyProtocol(server), Equals, false)
+	EnableProxyProtocol(server)
+	c.Assert(isProxyProtocol(server), Equals, true)
+	c.Assert(isProxyProtocol(&http.Server{}), Equals, false)
+}
@@ -0,0 +1,180 @@
+// +build !windows
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+
+package minhttp
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"syscall"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// restartHelperEnv - set for the test binary run as a server by TestGracefulRestart
+const restartHelperEnv = "MINHTTP_RESTART_HELPER"
+
+// TestRestartHelper - serve the pid of this process over tls, restarted processes run it too
+func TestRestartHelper(t *testing.T) {
+	if os.Getenv(restartHelperEnv) == "" {
+		return
+	}
+	cert, e := tls.LoadX509KeyPair(os.Getenv(restartHelperEnv+"_CERT"), os.Getenv(restartHelperEnv+"_KEY"))
+	if e != nil {
+		os.Exit(1)
+	}
+	mux := http.NewServeMux()
+	mux.HandleFunc("/pid", func(w http.ResponseWriter, req *http.Request) {
+		fmt.Fprint(w, os.Getpid())
+	})
+	mux.HandleFunc("/slow", func(w http.ResponseWriter, req *http.Request) {
+		time.Sleep(2 * time.Second)
+		fmt.Fprint(w, os.Getpid())
+	})
+	server := &http.Server{
+		Addr:      os.Getenv(restartHelperEnv + "_ADDR"),
+		Handler:   mux,
+		TLSConfig: &tls.Config{Certificates: []tls.Certificate{cert}},
+	}
+	if err := ListenAndServe(server); err != nil {
+		os.Exit(1)
+	}
+	os.Exit(0)
+}
+
+type MyRestartSuite struct {
+	root string
+}
+
+var _ = Suite(&MyRestartSuite{})
+
+func (s *MyRestartSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minhttp-restart-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyRestartSuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+// writeTestCertificate - self signed certificate for localhost
+func (s *MyRestartSuite) writeTestCertificate(c *C) (string, string) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	c.Assert(err, IsNil)
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{Organization: []string{"Minio"}},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+		KeyUsage:     x509.KeyUsageKeyEncipherment | x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	c.Assert(err, IsNil)
+	certFile, keyFile := filepath.Join(s.root, "public.crt"), filepath.Join(s.root, "private.key")
+	c.Assert(ioutil.WriteFile(certFile, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0600), IsNil)
+	c.Assert(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "RSA PRIVATE KEY", Bytes: x509.MarshalPKCS1PrivateKey(key)}), 0600), IsNil)
+	return certFile, keyFile
+}
+
+func (s *MyRestartSuite) TestGracefulRestart(c *C) {
+	certFile, keyFile := s.writeTestCertificate(c)
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	c.Assert(err, IsNil)
+	addr := l.Addr().String()
+	c.Assert(l.Close(), IsNil)
+
+	cmd := exec.Command(os.Args[0], "-test.run=^TestRestartHelper$")
+	cmd.Env = append(os.Environ(), restartHelperEnv+"=1", restartHelperEnv+"_ADDR="+addr,
+		restartHelperEnv+"_CERT="+certFile, restartHelperEnv+"_KEY="+keyFile)
+	c.Assert(cmd.Start(), IsNil)
+	defer cmd.Process.Kill()
+
+	client := &http.Client{
+		Transport: &http.Transport{
+			TLSClientConfig:   &tls.Config{InsecureSkipVerify: true},
+			DisableKeepAlives: true,
+		},
+		Timeout: 10 * time.Second,
+	}
+	getPid := func(path string) (int, error) {
+		response, err := client.Get("https://" + addr + path)
+		if err != nil {
+			return 0, err
+		}
+		defer response.Body.Close()
+		body, err := ioutil.ReadAll(response.Body)
+		if err != nil {
+			return 0, err
+		}
+		return strconv.Atoi(string(body))
+	}
+	// waitPid - pid serving requests once it is not the given one
+	waitPid := func(not int) int {
+		for deadline := time.Now().Add(20 * time.Second); time.Now().Before(deadline); time.Sleep(50 * time.Millisecond) {
+			if pid, err := getPid("/pid"); err == nil && pid != not {
+				return pid
+			}
+		}
+		c.Fatalf("no process other than %d is serving", not)
+		return 0
+	}
+	c.Assert(waitPid(0), Equals, cmd.Process.Pid)
+
+	// request in flight while the new process takes over
+	type slowResult struct {
+		pid int
+		err error
+	}
+	slow := make(chan slowResult, 1)
+	go func() {
+		pid, err := getPid("/slow")
+		slow <- slowResult{pid, err}
+	}()
+	time.Sleep(500 * time.Millisecond)
+	c.Assert(cmd.Process.Signal(syscall.SIGUSR2), IsNil)
+
+	newPid := waitPid(cmd.Process.Pid)
+	defer syscall.Kill(newPid, syscall.SIGTERM)
+	result := <-slow
+	c.Assert(result.err, IsNil)
+	c.Assert(result.pid, Equals, cmd.Process.Pid)
+	// old process exits once drained
+	c.Assert(cmd.Wait(), IsNil)
+	pid, err := getPid("/pid")
+	c.Assert(err, IsNil)
+	c.Assert(pid, Equals, newPid)
+}
@@ -0,0 +1,77 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minhttp
+
+import (
+	"errors"
+	"net"
+	"os"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// UnixAddressPrefix - server addresses of the form ‘unix:///run/minio-xl.sock’ listen on a unix socket
+const UnixAddressPrefix = "unix://"
+
+// unixSocketMode - sockets are accessible only to the owner and group of the server
+const unixSocketMode = 0660
+
+var (
+	errNotSocket   = errors.New("File exists and is not a unix socket")
+	errSocketInUse = errors.New("Unix socket is in use by another server")
+)
+
+// SplitNetworkAddress - network and address a server listens on
+func SplitNetworkAddress(addr string) (string, string) {
+	if strings.HasPrefix(addr, UnixAddressPrefix) {
+		return "unix", strings.TrimPrefix(addr, UnixAddressPrefix)
+	}
+	return "tcp", addr
+}
+
+// removeStaleSocket - remove a socket left behind by a server which did not shut down
+// cleanly, sockets still accepting connections and other files are never removed
+func removeStaleSocket(path string) *probe.Error {
+	st, err := os.Lstat(path)
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return probe.NewError(err)
+	}
+	if st.Mode()&os.ModeSocket == 0 {
+		return probe.NewError(errNotSocket)
+	}
+	if conn, err := net.Dial("unix", path); err == nil {
+		conn.Close()
+		return probe.NewError(errSocketInUse)
+	}
+	if err := os.Remove(path); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// getUnixListener - unix listener underneath a possibly rate limited listener
+func getUnixListener(l net.Listener) (*net.UnixListener, bool) {
+	if rl, ok := l.(*rateLimitListener); ok {
+		l = rl.Listener
+	}
+	ul, ok := l.(*net.UnixListener)
+	return ul, ok
+}
@@ -0,0 +1,91 @@
+// +build !windows
+
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package minhttp
+
+import (
+	"io/ioutil"
+	"net"
+	"os"
+	"path/filepath"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type MyUnixSuite struct {
+	root string
+}
+
+var _ = Suite(&MyUnixSuite{})
+
+func (s *MyUnixSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "minhttp-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyUnixSuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+func (s *MyUnixSuite) TestSplitNetworkAddress(c *C) {
+	network, addr := SplitNetworkAddress("unix:///run/minio-xl.sock")
+	c.Assert(network, Equals, "unix")
+	c.Assert(addr, Equals, "/run/minio-xl.sock")
+
+	network, addr = SplitNetworkAddress(":9000")
+	c.Assert(network, Equals, "tcp")
+	c.Assert(addr, Equals, ":9000")
+}
+
+func (s *MyUnixSuite) TestListenUnix(c *C) {
+	socket := filepath.Join(s.root, "minio-xl.sock")
+	l, err := (&minNet{}).Listen(SplitNetworkAddress(UnixAddressPrefix + socket))
+	c.Assert(err, IsNil)
+	st, e := os.Stat(socket)
+	c.Assert(e, IsNil)
+	c.Assert(st.Mode()&os.ModePerm, Equals, os.FileMode(unixSocketMode))
+
+	// socket in use is not taken over
+	_, err = (&minNet{}).Listen("unix", socket)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), Equals, errSocketInUse)
+
+	// socket is cleaned up on shutdown
+	c.Assert(l.Close(), IsNil)
+	_, e = os.Stat(socket)
+	c.Assert(os.IsNotExist(e), Equals, true)
+
+	// socket left behind by a crashed server is replaced
+	stale, e := net.ListenUnix("unix", &net.UnixAddr{Name: socket, Net: "unix"})
+	c.Assert(e, IsNil)
+	stale.SetUnlinkOnClose(false)
+	stale.Close()
+	l, err = (&minNet{}).Listen("unix", socket)
+	c.Assert(err, IsNil)
+	c.Assert(l.Close(), IsNil)
+
+	// other files are never removed
+	c.Assert(ioutil.WriteFile(socket, []byte("data"), 0600), IsNil)
+	_, err = (&minNet{}).Listen("unix", socket)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), Equals, errNotSocket)
+}
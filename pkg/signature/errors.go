@@ -30,6 +30,13 @@ func (e MissingExpiresQuery) Error() string {
 	return "Missing expires query string"
 }
 
+// InvalidExpiresQuery expires query string is not a number of seconds between 1 and 604800
+type InvalidExpiresQuery struct{}
+
+func (e InvalidExpiresQuery) Error() string {
+	return "X-Amz-Expires must be between 1 and 604800 seconds"
+}
+
 // ExpiredPresignedRequest request already expired
 type ExpiredPresignedRequest struct{}
 
@@ -20,6 +20,7 @@ import (
 	"encoding/json"
 	"fmt"
 	"reflect"
+	"strconv"
 	"time"
 
 	"github.com/minio/minio-xl/pkg/probe"
@@ -39,6 +40,15 @@ func toInteger(val interface{}) int {
 	switch v := val.(type) {
 	case int:
 		return v
+	case float64:
+		// JSON numbers are always decoded as float64.
+		return int(v)
+	case string:
+		i, e := strconv.Atoi(v)
+		if e != nil {
+			return 0
+		}
+		return i
 	}
 	return 0
 }
@@ -50,6 +50,15 @@ const (
 	yyyymmdd         = "20060102"
 )
 
+// presigned requests are valid for at least a second and at most seven days
+const (
+	minPresignedExpiry = 1
+	maxPresignedExpiry = 7 * 24 * 60 * 60
+)
+
+// timeNow - current time, replaced in tests to verify expiry boundaries
+var timeNow = time.Now
+
 // sumHMAC calculate hmac between two input byte array
 func sumHMAC(key []byte, data []byte) []byte {
 	hash := hmac.New(sha256.New, key)
@@ -276,10 +285,11 @@ func (r *Signature) DoesPresignedSignatureMatch() (bool, *probe.Error) {
 		return false, probe.NewError(MissingExpiresQuery{})
 	}
 	expireSeconds, err := strconv.Atoi(r.Request.URL.Query().Get("X-Amz-Expires"))
-	if err != nil {
-		return false, probe.NewError(err)
+	if err != nil || expireSeconds < minPresignedExpiry || expireSeconds > maxPresignedExpiry {
+		return false, probe.NewError(InvalidExpiresQuery{})
 	}
-	if time.Now().UTC().Sub(t) > time.Duration(expireSeconds)*time.Second {
+	// valid up to and including the second it expires at
+	if timeNow().UTC().After(t.Add(time.Duration(expireSeconds) * time.Second)) {
 		return false, probe.NewError(ExpiredPresignedRequest{})
 	}
 	query.Set("X-Amz-Date", t.Format(iso8601Format))
@@ -0,0 +1,35 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+// InvalidEndpoint - endpoint is not an http or https url
+type InvalidEndpoint struct {
+	Endpoint string
+}
+
+func (e InvalidEndpoint) Error() string {
+	return "Invalid OTLP endpoint: " + e.Endpoint
+}
+
+// ExportFailed - collector rejected an export request
+type ExportFailed struct {
+	Status string
+}
+
+func (e ExportFailed) Error() string {
+	return "Export rejected by collector: " + e.Status
+}
@@ -0,0 +1,258 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"log"
+	"net/http"
+	"net/url"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// maxQueuedSpans - spans ended while this many are waiting for export are dropped
+	maxQueuedSpans = 4096
+	// maxBatchSpans - spans sent in a single export request
+	maxBatchSpans = 512
+	// exportInterval - spans are exported at least this often
+	exportInterval = 5 * time.Second
+	// exportTimeout - export requests taking longer are abandoned, spans are dropped
+	exportTimeout = 10 * time.Second
+	// tracesPath - OTLP/HTTP path of trace exports
+	tracesPath = "/v1/traces"
+)
+
+// droppedSpans - number of spans dropped since the exporter queue was full or export failed
+var droppedSpans int64
+
+// exporter - batches ended spans and posts them as OTLP/HTTP json
+type exporter struct {
+	url     string
+	service string
+	client  *http.Client
+	spans   chan *Span
+	done    chan struct{}
+	wg      *sync.WaitGroup
+}
+
+// newExporter - exporter for a collector endpoint, the traces path is added unless given
+func newExporter(endpoint, service string) (*exporter, *probe.Error) {
+	u, err := url.Parse(endpoint)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	if (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, probe.NewError(InvalidEndpoint{Endpoint: endpoint})
+	}
+	if u.Path == "" || u.Path == "/" {
+		u.Path = tracesPath
+	}
+	e := &exporter{
+		url:     u.String(),
+		service: service,
+		client:  &http.Client{Timeout: exportTimeout},
+		spans:   make(chan *Span, maxQueuedSpans),
+		done:    make(chan struct{}),
+		wg:      &sync.WaitGroup{},
+	}
+	e.wg.Add(1)
+	go e.run()
+	return e, nil
+}
+
+// export - queue an ended span, never blocks
+func (e *exporter) export(span *Span) {
+	select {
+	case e.spans <- span:
+	default:
+		atomic.AddInt64(&droppedSpans, 1)
+	}
+}
+
+// run - send batches once full or on every interval, until shut down
+func (e *exporter) run() {
+	defer e.wg.Done()
+	ticker := time.NewTicker(exportInterval)
+	defer ticker.Stop()
+	var batch []*Span
+	for {
+		select {
+		case span := <-e.spans:
+			batch = append(batch, span)
+			if len(batch) >= maxBatchSpans {
+				e.send(batch)
+				batch = nil
+			}
+		case <-ticker.C:
+			e.send(batch)
+			batch = nil
+		case <-e.done:
+			for {
+				select {
+				case span := <-e.spans:
+					batch = append(batch, span)
+				default:
+					e.send(batch)
+					return
+				}
+			}
+		}
+	}
+}
+
+// shutdown - export queued spans and stop
+func (e *exporter) shutdown() {
+	close(e.done)
+	e.wg.Wait()
+}
+
+// send - post a batch, spans of a failed export are dropped
+func (e *exporter) send(batch []*Span) {
+	for len(batch) > 0 {
+		n := len(batch)
+		if n > maxBatchSpans {
+			n = maxBatchSpans
+		}
+		body, err := json.Marshal(e.encode(batch[:n]))
+		if err == nil {
+			err = e.post(body)
+		}
+		if err != nil {
+			atomic.AddInt64(&droppedSpans, int64(n))
+			log.Printf("Unable to export %d spans to %s: %s", n, e.url, err)
+		}
+		batch = batch[n:]
+	}
+}
+
+func (e *exporter) post(body []byte) error {
+	resp, err := e.client.Post(e.url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode/100 != 2 {
+		return ExportFailed{Status: resp.Status}
+	}
+	return nil
+}
+
+// OTLP/HTTP json encoding of a trace export request
+
+type otlpRequest struct {
+	ResourceSpans []otlpResourceSpans `json:"resourceSpans"`
+}
+
+type otlpResourceSpans struct {
+	Resource   otlpResource     `json:"resource"`
+	ScopeSpans []otlpScopeSpans `json:"scopeSpans"`
+}
+
+type otlpResource struct {
+	Attributes []otlpKeyValue `json:"attributes"`
+}
+
+type otlpScopeSpans struct {
+	Scope otlpScope  `json:"scope"`
+	Spans []otlpSpan `json:"spans"`
+}
+
+type otlpScope struct {
+	Name string `json:"name"`
+}
+
+type otlpSpan struct {
+	TraceID           string         `json:"traceId"`
+	SpanID            string         `json:"spanId"`
+	ParentSpanID      string         `json:"parentSpanId,omitempty"`
+	Name              string         `json:"name"`
+	Kind              int            `json:"kind"`
+	StartTimeUnixNano string         `json:"startTimeUnixNano"`
+	EndTimeUnixNano   string         `json:"endTimeUnixNano"`
+	Attributes        []otlpKeyValue `json:"attributes,omitempty"`
+	Status            otlpStatus     `json:"status"`
+}
+
+type otlpKeyValue struct {
+	Key   string       `json:"key"`
+	Value otlpAnyValue `json:"value"`
+}
+
+// otlpAnyValue - 64 bit integers are encoded as strings
+type otlpAnyValue struct {
+	StringValue *string `json:"stringValue,omitempty"`
+	IntValue    *string `json:"intValue,omitempty"`
+	BoolValue   *bool   `json:"boolValue,omitempty"`
+}
+
+type otlpStatus struct {
+	Code    int    `json:"code,omitempty"`
+	Message string `json:"message,omitempty"`
+}
+
+func newKeyValue(key string, value interface{}) otlpKeyValue {
+	kv := otlpKeyValue{Key: key}
+	switch v := value.(type) {
+	case string:
+		kv.Value.StringValue = &v
+	case int64:
+		i := strconv.FormatInt(v, 10)
+		kv.Value.IntValue = &i
+	case bool:
+		kv.Value.BoolValue = &v
+	}
+	return kv
+}
+
+// encode - export request of a batch, all spans are from the same service
+func (e *exporter) encode(batch []*Span) otlpRequest {
+	var spans []otlpSpan
+	for _, span := range batch {
+		span.lock.Lock()
+		s := otlpSpan{
+			TraceID:           hex.EncodeToString(span.traceID[:]),
+			SpanID:            hex.EncodeToString(span.spanID[:]),
+			Name:              span.name,
+			Kind:              span.kind,
+			StartTimeUnixNano: strconv.FormatInt(span.start.UnixNano(), 10),
+			EndTimeUnixNano:   strconv.FormatInt(span.end.UnixNano(), 10),
+			Status:            otlpStatus{Code: span.status, Message: span.message},
+		}
+		if span.parentID != [8]byte{} {
+			s.ParentSpanID = hex.EncodeToString(span.parentID[:])
+		}
+		for _, a := range span.attributes {
+			s.Attributes = append(s.Attributes, newKeyValue(a.key, a.value))
+		}
+		span.lock.Unlock()
+		spans = append(spans, s)
+	}
+	return otlpRequest{
+		ResourceSpans: []otlpResourceSpans{{
+			Resource:   otlpResource{Attributes: []otlpKeyValue{newKeyValue("service.name", e.service)}},
+			ScopeSpans: []otlpScopeSpans{{Scope: otlpScope{Name: e.service}, Spans: spans}},
+		}},
+	}
+}
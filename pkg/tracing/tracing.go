@@ -0,0 +1,232 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+// Package tracing records spans of requests and the disk operations serving them, exported
+// to an OpenTelemetry collector over OTLP/HTTP. Until Enable is called every function is a
+// no-op and spans are nil, methods of a nil span do nothing.
+package tracing
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// Span kinds as defined by OTLP
+const (
+	KindInternal = 1
+	KindServer   = 2
+)
+
+// statusError - OTLP status code of failed spans, others are left unset
+const statusError = 2
+
+// current exporter, holds a nil exporter while tracing is disabled
+var current atomic.Value
+
+// currentExporter - atomic.Value only stores values of a single non nil type
+type currentExporter struct {
+	exporter *exporter
+}
+
+type spanKey struct{}
+
+// Span - a timed operation of a trace
+type Span struct {
+	traceID  [16]byte
+	spanID   [8]byte
+	parentID [8]byte
+	name     string
+	kind     int
+	start    time.Time
+	exporter *exporter
+
+	lock       sync.Mutex
+	end        time.Time
+	attributes []attribute
+	status     int
+	message    string
+	ended      bool
+}
+
+// attribute - key and value of a span attribute, values are strings, integers or booleans
+type attribute struct {
+	key   string
+	value interface{}
+}
+
+// Enable - export spans to the OTLP/HTTP endpoint of a collector e.g. http://localhost:4318,
+// spans are reported as coming from service. Spans of a previous endpoint are flushed.
+func Enable(endpoint, service string) *probe.Error {
+	e, err := newExporter(endpoint, service)
+	if err != nil {
+		return err.Trace(endpoint)
+	}
+	if previous := getExporter(); previous != nil {
+		previous.shutdown()
+	}
+	current.Store(currentExporter{e})
+	return nil
+}
+
+// Disable - stop tracing, spans not yet exported are flushed
+func Disable() {
+	if previous := getExporter(); previous != nil {
+		current.Store(currentExporter{})
+		previous.shutdown()
+	}
+}
+
+// Enabled - spans are recorded
+func Enabled() bool {
+	return getExporter() != nil
+}
+
+func getExporter() *exporter {
+	c, _ := current.Load().(currentExporter)
+	return c.exporter
+}
+
+// FromContext - span of ctx, nil if there is none
+func FromContext(ctx context.Context) *Span {
+	if ctx == nil {
+		return nil
+	}
+	span, _ := ctx.Value(spanKey{}).(*Span)
+	return span
+}
+
+// Start - start an internal span, child of the span of ctx if any
+func Start(ctx context.Context, name string) (context.Context, *Span) {
+	e := getExporter()
+	if e == nil {
+		return ctx, nil
+	}
+	if ctx == nil {
+		ctx = context.Background()
+	}
+	span := newSpan(e, name, KindInternal)
+	if parent := FromContext(ctx); parent != nil {
+		span.traceID = parent.traceID
+		span.parentID = parent.spanID
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// StartServer - start a span of a request received, child of the span sent by the client
+// in a W3C 'traceparent' header if any
+func StartServer(ctx context.Context, name string, header http.Header) (context.Context, *Span) {
+	e := getExporter()
+	if e == nil {
+		return ctx, nil
+	}
+	span := newSpan(e, name, KindServer)
+	if traceID, parentID, ok := parseTraceParent(header.Get("traceparent")); ok {
+		span.traceID = traceID
+		span.parentID = parentID
+	}
+	return context.WithValue(ctx, spanKey{}, span), span
+}
+
+// newSpan - root span with new trace and span ids
+func newSpan(e *exporter, name string, kind int) *Span {
+	span := &Span{name: name, kind: kind, start: time.Now(), exporter: e}
+	rand.Read(span.traceID[:])
+	rand.Read(span.spanID[:])
+	return span
+}
+
+// parseTraceParent - trace and parent span ids of a 'traceparent' header, version 00 as
+// defined by W3C Trace Context. Later versions start with the same fields.
+func parseTraceParent(traceParent string) (traceID [16]byte, parentID [8]byte, ok bool) {
+	fields := strings.Split(strings.TrimSpace(traceParent), "-")
+	if len(fields) < 4 || len(fields[0]) != 2 || fields[0] == "ff" || (fields[0] == "00" && len(fields) != 4) {
+		return traceID, parentID, false
+	}
+	if len(fields[1]) != 32 || len(fields[2]) != 16 {
+		return traceID, parentID, false
+	}
+	if _, err := hex.Decode(traceID[:], []byte(fields[1])); err != nil {
+		return traceID, parentID, false
+	}
+	if _, err := hex.Decode(parentID[:], []byte(fields[2])); err != nil {
+		return traceID, parentID, false
+	}
+	// all zero ids are invalid
+	if traceID == [16]byte{} || parentID == [8]byte{} {
+		return traceID, parentID, false
+	}
+	return traceID, parentID, true
+}
+
+// SetAttribute - set an attribute of the span, values other than strings, integers and
+// booleans are formatted as strings
+func (s *Span) SetAttribute(key string, value interface{}) {
+	if s == nil {
+		return
+	}
+	switch v := value.(type) {
+	case string, bool, int64:
+	case int:
+		value = int64(v)
+	default:
+		value = fmt.Sprint(v)
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	for i := range s.attributes {
+		if s.attributes[i].key == key {
+			s.attributes[i].value = value
+			return
+		}
+	}
+	s.attributes = append(s.attributes, attribute{key: key, value: value})
+}
+
+// SetError - mark the span failed
+func (s *Span) SetError(message string) {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	s.status = statusError
+	s.message = message
+}
+
+// End - end the span and queue it for export, only the first call has any effect
+func (s *Span) End() {
+	if s == nil {
+		return
+	}
+	s.lock.Lock()
+	if s.ended {
+		s.lock.Unlock()
+		return
+	}
+	s.ended = true
+	s.end = time.Now()
+	s.lock.Unlock()
+	s.exporter.export(s)
+}
@@ -0,0 +1,146 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package tracing
+
+import (
+	"context"
+	"encoding/hex"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+func Test(t *testing.T) { TestingT(t) }
+
+type MySuite struct{}
+
+var _ = Suite(&MySuite{})
+
+// collector - OTLP/HTTP endpoint keeping every span exported to it
+type collector struct {
+	lock  sync.Mutex
+	paths []string
+	spans []otlpSpan
+}
+
+func (c *collector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request otlpRequest
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.paths = append(c.paths, r.URL.Path)
+	for _, resourceSpans := range request.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			c.spans = append(c.spans, scopeSpans.Spans...)
+		}
+	}
+}
+
+func (s *MySuite) TestDisabled(c *C) {
+	c.Assert(Enabled(), Equals, false)
+	ctx, span := Start(context.Background(), "operation")
+	c.Assert(span, IsNil)
+	c.Assert(FromContext(ctx), IsNil)
+	c.Assert(FromContext(nil), IsNil)
+	_, span = StartServer(context.Background(), "GET", http.Header{})
+	c.Assert(span, IsNil)
+	// methods of nil spans do nothing
+	span.SetAttribute("key", "value")
+	span.SetError("failed")
+	span.End()
+	Disable()
+}
+
+func (s *MySuite) TestParseTraceParent(c *C) {
+	traceID, parentID, ok := parseTraceParent("00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	c.Assert(ok, Equals, true)
+	c.Assert(hex.EncodeToString(traceID[:]), Equals, "4bf92f3577b34da6a3ce929d0e0e4736")
+	c.Assert(hex.EncodeToString(parentID[:]), Equals, "00f067aa0ba902b7")
+	// later versions may add fields
+	_, _, ok = parseTraceParent("01-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra")
+	c.Assert(ok, Equals, true)
+	for _, traceParent := range []string{
+		"",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01-extra",
+		"ff-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01",
+		"00-00000000000000000000000000000000-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e4736-0000000000000000-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e473-00f067aa0ba902b7-01",
+		"00-4bf92f3577b34da6a3ce929d0e0e47zz-00f067aa0ba902b7-01",
+	} {
+		_, _, ok := parseTraceParent(traceParent)
+		c.Assert(ok, Equals, false, Commentf("%s", traceParent))
+	}
+}
+
+func (s *MySuite) TestExport(c *C) {
+	c.Assert(Enable("localhost:4318", "minio-xl"), Not(IsNil))
+	c.Assert(Enable("ftp://localhost:4318", "minio-xl"), Not(IsNil))
+	c.Assert(Enabled(), Equals, false)
+
+	spans := &collector{}
+	server := httptest.NewServer(spans)
+	defer server.Close()
+	c.Assert(Enable(server.URL, "minio-xl"), IsNil)
+	c.Assert(Enabled(), Equals, true)
+
+	header := http.Header{}
+	header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	ctx, request := StartServer(context.Background(), "PUT", header)
+	request.SetAttribute("http.response.status_code", 200)
+	request.SetAttribute("xl.bucket", "bucket")
+	_, disk := Start(ctx, "disk.write")
+	disk.SetAttribute("xl.bytes", int64(11))
+	disk.SetError("purged")
+	disk.End()
+	request.End()
+	request.End()
+	// spans never end up in an unrelated trace
+	_, root := Start(context.Background(), "operation")
+	root.End()
+	Disable()
+	c.Assert(Enabled(), Equals, false)
+
+	c.Assert(spans.paths, DeepEquals, []string{tracesPath})
+	c.Assert(len(spans.spans), Equals, 3)
+	diskSpan, requestSpan, rootSpan := spans.spans[0], spans.spans[1], spans.spans[2]
+	c.Assert(requestSpan.Name, Equals, "PUT")
+	c.Assert(requestSpan.Kind, Equals, KindServer)
+	c.Assert(requestSpan.TraceID, Equals, "4bf92f3577b34da6a3ce929d0e0e4736")
+	c.Assert(requestSpan.ParentSpanID, Equals, "00f067aa0ba902b7")
+	c.Assert(requestSpan.Status.Code, Equals, 0)
+	c.Assert(len(requestSpan.Attributes), Equals, 2)
+	c.Assert(*requestSpan.Attributes[0].Value.IntValue, Equals, "200")
+	c.Assert(*requestSpan.Attributes[1].Value.StringValue, Equals, "bucket")
+
+	c.Assert(diskSpan.Name, Equals, "disk.write")
+	c.Assert(diskSpan.Kind, Equals, KindInternal)
+	c.Assert(diskSpan.TraceID, Equals, requestSpan.TraceID)
+	c.Assert(diskSpan.ParentSpanID, Equals, requestSpan.SpanID)
+	c.Assert(diskSpan.Status, DeepEquals, otlpStatus{Code: statusError, Message: "purged"})
+	c.Assert(diskSpan.StartTimeUnixNano <= diskSpan.EndTimeUnixNano, Equals, true)
+
+	c.Assert(rootSpan.TraceID, Not(Equals), requestSpan.TraceID)
+	c.Assert(rootSpan.ParentSpanID, Equals, "")
+}
@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// defaultAccessTimeInterval - access times are not recorded by default, every recorded
+	// access rewrites object metadata on all disks
+	defaultAccessTimeInterval = 0
+	// maxAccessTimeEntries - bounds memory used to coalesce access time updates
+	maxAccessTimeEntries = 10000
+)
+
+// internal variable only accessed via get/set methods
+var accessTimeInterval = int64(defaultAccessTimeInterval)
+
+// SetAccessTimeInterval - record the last access time of objects read with GetObject() in
+// their metadata under "lastAccessed", at most once per interval per object, '0' disables
+func SetAccessTimeInterval(interval time.Duration) {
+	if interval < 0 {
+		interval = 0
+	}
+	atomic.StoreInt64(&accessTimeInterval, int64(interval))
+}
+
+// getAccessTimeInterval - get access time interval
+func getAccessTimeInterval() time.Duration {
+	return time.Duration(atomic.LoadInt64(&accessTimeInterval))
+}
+
+// accessTimes - access times recorded recently and when, reads in between are not recorded
+type accessTimes struct {
+	lock     *sync.Mutex
+	recorded map[string]time.Time
+}
+
+func newAccessTimes() *accessTimes {
+	return &accessTimes{
+		lock:     &sync.Mutex{},
+		recorded: make(map[string]time.Time),
+	}
+}
+
+// isRecorded - an access of the object was recorded within the interval
+func (a *accessTimes) isRecorded(objectKey string, interval time.Duration) bool {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	recorded, ok := a.recorded[objectKey]
+	return ok && time.Since(recorded) < interval
+}
+
+// set - an access of the object was recorded at t
+func (a *accessTimes) set(objectKey string, t time.Time, interval time.Duration) {
+	a.lock.Lock()
+	defer a.lock.Unlock()
+	if len(a.recorded) >= maxAccessTimeEntries {
+		for key, recorded := range a.recorded {
+			if time.Since(recorded) >= interval {
+				delete(a.recorded, key)
+			}
+		}
+		// all entries are fresh, start over rather than growing without bounds
+		if len(a.recorded) >= maxAccessTimeEntries {
+			a.recorded = make(map[string]time.Time)
+		}
+	}
+	a.recorded[objectKey] = t
+}
+
+// recordAccess - save the time of a read in object metadata unless one was recorded within
+// the interval, by this server or by another one sharing the disks. GET succeeded already,
+// failing to record it is only logged.
+func (xl API) recordAccess(bucket, object string) {
+	interval := getAccessTimeInterval()
+	if interval == 0 {
+		return
+	}
+	objectKey := bucket + "/" + object
+	if xl.accessTimes.isRecorded(objectKey, interval) {
+		return
+	}
+	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+	objMetadata, cached := storedBucket.objectMetadata[objectKey]
+	if !cached {
+		if len(xl.config.NodeDiskMap) == 0 {
+			return
+		}
+		var err *probe.Error
+		if objMetadata, err = xl.getObjectMetadata(bucket, object); err != nil {
+			log.Printf("Recording access of %s failed: %s", objectKey, err.ToGoError())
+			return
+		}
+	}
+	if lastAccessed, e := time.Parse(time.RFC3339Nano, objMetadata.Metadata["lastAccessed"]); e == nil && time.Since(lastAccessed) < interval {
+		xl.accessTimes.set(objectKey, lastAccessed, interval)
+		return
+	}
+	now := time.Now().UTC()
+	metadata := map[string]string{"lastAccessed": now.Format(time.RFC3339Nano)}
+	if len(xl.config.NodeDiskMap) > 0 {
+		var err *probe.Error
+		if objMetadata, err = xl.setObjectMetadata(bucket, object, metadata); err != nil {
+			log.Printf("Recording access of %s failed: %s", objectKey, err.ToGoError())
+			return
+		}
+	} else {
+		objMetadata = updateObjectMetadata(objMetadata, metadata)
+	}
+	if cached {
+		storedBucket.objectMetadata[objectKey] = objMetadata
+		xl.storedBuckets.Set(bucket, storedBucket)
+	}
+	xl.accessTimes.set(objectKey, now, interval)
+}
@@ -0,0 +1,100 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyAccessTimeSuite struct {
+	root string
+}
+
+var _ = Suite(&MyAccessTimeSuite{})
+
+func (s *MyAccessTimeSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-accesstime-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyAccessTimeSuite) TearDownSuite(c *C) {
+	SetAccessTimeInterval(defaultAccessTimeInterval)
+	os.RemoveAll(s.root)
+}
+
+// lastAccessed - access time saved on disks, read through a new xl
+func (s *MyAccessTimeSuite) lastAccessed(c *C, object string) string {
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	metadata, err := xlAPI.GetObjectMetadata("bucket", object)
+	c.Assert(err, IsNil)
+	return metadata.Metadata["lastAccessed"]
+}
+
+func (s *MyAccessTimeSuite) readObject(c *C, xlAPI Interface, object string) {
+	var buffer bytes.Buffer
+	_, err := xlAPI.GetObject(&buffer, "bucket", object, 0, 0)
+	c.Assert(err, IsNil)
+}
+
+func (s *MyAccessTimeSuite) TestAccessTime(c *C) {
+	xlAPI, e := newBucketCacheTestXL(s.root)
+	c.Assert(e, IsNil)
+
+	// disabled by default
+	s.readObject(c, xlAPI, "object")
+	c.Assert(s.lastAccessed(c, "object"), Equals, "")
+
+	// reads within the interval are coalesced into a single update
+	SetAccessTimeInterval(200 * time.Millisecond)
+	s.readObject(c, xlAPI, "object")
+	first := s.lastAccessed(c, "object")
+	_, e = time.Parse(time.RFC3339Nano, first)
+	c.Assert(e, IsNil)
+	for i := 0; i < 10; i++ {
+		s.readObject(c, xlAPI, "object")
+	}
+	c.Assert(s.lastAccessed(c, "object"), Equals, first)
+	metadata, err := xlAPI.GetObjectMetadata("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Metadata["lastAccessed"], Equals, first)
+
+	// an access recorded by another server sharing the disks counts as well
+	restarted, err := New()
+	c.Assert(err, IsNil)
+	s.readObject(c, restarted, "object")
+	c.Assert(s.lastAccessed(c, "object"), Equals, first)
+
+	// reads after the interval are recorded, the object itself is left alone
+	time.Sleep(getAccessTimeInterval())
+	s.readObject(c, restarted, "object")
+	second := s.lastAccessed(c, "object")
+	firstTime, _ := time.Parse(time.RFC3339Nano, first)
+	secondTime, e := time.Parse(time.RFC3339Nano, second)
+	c.Assert(e, IsNil)
+	c.Assert(secondTime.Sub(firstTime) >= getAccessTimeInterval(), Equals, true)
+	metadata, err = restarted.GetObjectMetadata("bucket", "object")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.Metadata["lastAccessed"], Equals, second)
+	c.Assert(metadata.MD5Sum, Equals, "b10a8db164e0754105b7a99be72e3fe5")
+}
@@ -18,9 +18,12 @@ package xl
 
 import (
 	"bytes"
+	"context"
 	"fmt"
 	"hash"
 	"io"
+	"log"
+	"os"
 	"path/filepath"
 	"sort"
 	"strings"
@@ -50,6 +53,7 @@ type bucket struct {
 	xlName string
 	nodes  map[string]node
 	lock   *sync.Mutex
+	ctx    context.Context
 }
 
 // newBucket - instantiate a new bucket
@@ -102,7 +106,8 @@ func (b bucket) getBucketMetadataReaders() (map[int]io.ReadCloser, *probe.Error)
 		}
 		readers[order] = bucketMetaDataReader
 	}
-	if err != nil {
+	// disks missing bucket metadata, e.g newly added ones, are skipped, fail only if none of them have it
+	if len(readers) == 0 && err != nil {
 		return nil, err.Trace()
 	}
 	return readers, nil
@@ -140,7 +145,7 @@ func (b bucket) GetObjectMetadata(objectName string) (ObjectMetadata, *probe.Err
 }
 
 // ListObjects - list all objects
-func (b bucket) ListObjects(prefix, marker, delimiter string, maxkeys int) (ListObjectsResults, *probe.Error) {
+func (b bucket) ListObjects(prefix, marker, delimiter string, depth, maxkeys int) (ListObjectsResults, *probe.Error) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
 	if maxkeys <= 0 {
@@ -173,9 +178,9 @@ func (b bucket) ListObjects(prefix, marker, delimiter string, maxkeys int) (List
 	var filteredObjects []string
 	filteredObjects = objects
 	if strings.TrimSpace(delimiter) != "" {
-		filteredObjects = HasNoDelimiter(objects, delimiter)
+		filteredObjects = HasNoDelimiterDepth(objects, delimiter, depth)
 		prefixes = HasDelimiter(objects, delimiter)
-		prefixes = SplitDelimiter(prefixes, delimiter)
+		prefixes = SplitDelimiterDepth(prefixes, delimiter, depth)
 		prefixes = SortUnique(prefixes)
 	}
 	var results []string
@@ -202,12 +207,12 @@ func (b bucket) ListObjects(prefix, marker, delimiter string, maxkeys int) (List
 	listObjects.CommonPrefixes = commonPrefixes
 	listObjects.IsTruncated = isTruncated
 
-	for _, objectName := range results {
-		objMetadata, err := b.readObjectMetadata(normalizeObjectName(objectName))
-		if err != nil {
-			return ListObjectsResults{}, err.Trace()
-		}
-		listObjects.Objects[objectName] = objMetadata
+	objectsMetadata, err := b.readObjectsMetadata(results)
+	if err != nil {
+		return ListObjectsResults{}, err.Trace()
+	}
+	for i, objectName := range results {
+		listObjects.Objects[objectName] = objectsMetadata[i]
 	}
 	return listObjects, nil
 }
@@ -230,8 +235,22 @@ func (b bucket) ReadObject(objectName string) (reader io.ReadCloser, size int64,
 	if err != nil {
 		return nil, 0, err.Trace()
 	}
-	// read and reply back to GetObject() request in a go-routine
-	go b.readObjectData(normalizeObjectName(objectName), writer, objMetadata)
+	readers, err := b.getDataReaders(normalizeObjectName(objectName), objMetadata)
+	if err != nil {
+		return nil, 0, err.Trace()
+	}
+	// read and reply back to GetObject() request in a go-routine, blocks lost on the way
+	// are repaired once the client got the whole object
+	go func() {
+		if !b.readObjectData(readers, writer, objMetadata) || !isReadRepair() {
+			return
+		}
+		withBackgroundIOPriority(func() {
+			if err := b.repairObject(normalizeObjectName(objectName), objMetadata, getLostBlocks(readers, objMetadata)); err != nil {
+				log.Printf("Read repair of %s/%s failed: %s", b.getBucketName(), objectName, err.ToGoError())
+			}
+		})
+	}()
 	return reader, objMetadata.Size, nil
 }
 
@@ -239,50 +258,99 @@ func (b bucket) ReadObject(objectName string) (reader io.ReadCloser, size int64,
 func (b bucket) WriteObject(objectName string, objectData io.Reader, size int64, expectedMD5Sum string, metadata map[string]string, signature *signv4.Signature) (ObjectMetadata, *probe.Error) {
 	b.lock.Lock()
 	defer b.lock.Unlock()
+	objMetadata, blocks, err := b.writeObjectBlocks(objectName, objectData, size, expectedMD5Sum, metadata, signature)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if err := b.commitObject(normalizeObjectName(objectName), objMetadata, blocks); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	return objMetadata, nil
+}
+
+// writeObjectBlocks - write the blocks of an object to all the disks and commit them, nothing
+// points at them until commitObject writes the metadata of the object
+func (b bucket) writeObjectBlocks(objectName string, objectData io.Reader, size int64, expectedMD5Sum string, metadata map[string]string, signature *signv4.Signature) (ObjectMetadata, map[int]string, *probe.Error) {
 	if objectName == "" || objectData == nil {
-		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
+		return ObjectMetadata{}, nil, probe.NewError(InvalidArgument{})
+	}
+	// ratio is recorded by the blocks written, not as metadata
+	var ratio ErasureRatio
+	if value, ok := metadata[erasureRatioKey]; ok {
+		ratio, _ = ParseErasureRatio(value)
+		delete(metadata, erasureRatioKey)
 	}
 	writers, err := b.getObjectWriters(normalizeObjectName(objectName), "data")
 	if err != nil {
-		return ObjectMetadata{}, err.Trace()
+		b.removeObjectData(normalizeObjectName(objectName))
+		return ObjectMetadata{}, nil, err.Trace()
 	}
-	sumMD5 := md5.New()
+	writers = newBlockChecksumWriters(writers)
 	sum512 := sha512.New()
-	var sum256 hash.Hash
-	var mwriter io.Writer
-
-	if signature != nil {
+	var sumMD5, sum256 hash.Hash
+	hashWriters := []io.Writer{sum512}
+	if isMD5Required(expectedMD5Sum) {
+		sumMD5 = md5.New()
+		hashWriters = append(hashWriters, sumMD5)
+	}
+	// sha256 ETags reuse the sum of the payload signature
+	algorithm := getETagAlgorithm()
+	if signature != nil || algorithm == ETagSHA256 {
 		sum256 = sha256.New()
-		mwriter = io.MultiWriter(sumMD5, sum256, sum512)
-	} else {
-		mwriter = io.MultiWriter(sumMD5, sum512)
+		hashWriters = append(hashWriters, sum256)
+	}
+	checksum, err := newObjectChecksum(metadata)
+	if err != nil {
+		b.purgeObject(normalizeObjectName(objectName), writers)
+		return ObjectMetadata{}, nil, err.Trace()
+	}
+	if checksum != nil {
+		hashWriters = append(hashWriters, checksum)
+	}
+	mwriter := io.MultiWriter(hashWriters...)
+	// digests are final once the last chunk is hashed, a mismatch fails the write before that
+	// chunk reaches the disks
+	verifyDigests := func() *probe.Error {
+		if sumMD5 != nil && strings.TrimSpace(expectedMD5Sum) != "" {
+			if err := b.isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), hex.EncodeToString(sumMD5.Sum(nil))); err != nil {
+				return err.Trace()
+			}
+		}
+		if checksum != nil {
+			if _, err := checksum.verify(); err != nil {
+				return err.Trace()
+			}
+		}
+		return nil
 	}
 	objMetadata := ObjectMetadata{}
 	objMetadata.Version = objectMetadataVersion
-	objMetadata.Created = time.Now().UTC()
+	objMetadata.Created = getModTime(metadata)
+	var offline map[int]error
 	// if total writers are only '1' do not compute erasure
 	switch len(writers) == 1 {
 	case true:
-		mw := io.MultiWriter(writers[0], mwriter)
+		mw := io.MultiWriter(retryWriter{writers[0]}, mwriter)
 		totalLength, err := io.Copy(mw, objectData)
 		if err != nil {
-			CleanupWritersOnError(writers)
-			return ObjectMetadata{}, probe.NewError(err)
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, probe.NewError(err)
 		}
 		objMetadata.Size = totalLength
 	case false:
-		// calculate data and parity dictated by total number of writers
-		k, m, err := b.getDataAndParity(len(writers))
+		// calculate data and parity dictated by total number of writers and the ratio of the object
+		k, m, err := b.getDataAndParity(len(writers), ratio)
 		if err != nil {
-			CleanupWritersOnError(writers)
-			return ObjectMetadata{}, err.Trace()
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, err.Trace()
 		}
 		// write encoded data with k, m and writers
-		chunkCount, totalLength, err := b.writeObjectData(k, m, writers, objectData, size, mwriter)
+		chunkCount, totalLength, offlineWriters, err := b.writeObjectData(k, m, writers, objectData, size, mwriter, verifyDigests)
 		if err != nil {
-			CleanupWritersOnError(writers)
-			return ObjectMetadata{}, err.Trace()
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, err.Trace()
 		}
+		offline = offlineWriters
 		/// xlMetadata section
 		objMetadata.BlockSize = blockSize
 		objMetadata.ChunkCount = chunkCount
@@ -292,42 +360,211 @@ func (b bucket) WriteObject(objectName string, objectData io.Reader, size int64,
 	}
 	objMetadata.Bucket = b.getBucketName()
 	objMetadata.Object = objectName
-	dataMD5sum := sumMD5.Sum(nil)
 	dataSHA512sum := sum512.Sum(nil)
 	if signature != nil {
 		ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sum256.Sum(nil)))
 		if err != nil {
 			// error occurred while doing signature calculation, we return and also cleanup any temporary writers.
-			CleanupWritersOnError(writers)
-			return ObjectMetadata{}, err.Trace()
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, err.Trace()
 		}
 		if !ok {
 			// purge all writers, when control flow reaches here
 			//
 			// Signature mismatch occurred all temp files to be removed and all data purged.
-			CleanupWritersOnError(writers)
-			return ObjectMetadata{}, probe.NewError(signv4.DoesNotMatch{})
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, probe.NewError(signv4.DoesNotMatch{})
 		}
 	}
-	objMetadata.MD5Sum = hex.EncodeToString(dataMD5sum)
+	switch {
+	case algorithm == ETagSHA256:
+		objMetadata.MD5Sum = hex.EncodeToString(sum256.Sum(nil))
+		objMetadata.ETagAlgorithm = ETagSHA256
+	case sumMD5 != nil:
+		objMetadata.MD5Sum = hex.EncodeToString(sumMD5.Sum(nil))
+	default:
+		objMetadata.MD5Sum = getContentETag(dataSHA512sum)
+	}
 	objMetadata.SHA512Sum = hex.EncodeToString(dataSHA512sum)
 
 	// Verify if the written object is equal to what is expected, only if it is requested as such
 	if strings.TrimSpace(expectedMD5Sum) != "" {
-		if err := b.isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), objMetadata.MD5Sum); err != nil {
-			return ObjectMetadata{}, err.Trace()
+		if err := b.isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), hex.EncodeToString(sumMD5.Sum(nil))); err != nil {
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, err.Trace()
+		}
+	}
+	if checksum != nil {
+		checksumValue, err := checksum.verify()
+		if err != nil {
+			b.purgeObject(normalizeObjectName(objectName), writers)
+			return ObjectMetadata{}, nil, err.Trace()
 		}
+		checksum.setMetadata(metadata, checksumValue)
+	}
+	// multipart objects carry the ETag computed from their parts, data is verified with sha512 on reads
+	if etag, ok := metadata["multipartETag"]; ok {
+		objMetadata.MD5Sum = etag
 	}
 	objMetadata.Metadata = metadata
-	// write object specific metadata
+	blocks := getBlockChecksums(writers, offline)
+	// identical data already stored is referenced instead, writers are committed or purged
+	if isDedup() {
+		objMetadata.DedupKey = getDedupKey(objMetadata)
+		syncOnClose(writers)
+		if err := b.dedupObjectData(normalizeObjectName(objectName), objMetadata.DedupKey, writers); err != nil {
+			return ObjectMetadata{}, nil, err.Trace()
+		}
+		return objMetadata, blocks, nil
+	}
+	// blocks are in place before the metadata pointing at them is written, readers finding the
+	// metadata always find the blocks of the same object
+	if err := commitBlocks(writers, offline); err != nil {
+		b.purgeObject(normalizeObjectName(objectName), writers)
+		return ObjectMetadata{}, nil, err.Trace()
+	}
+	return objMetadata, blocks, nil
+}
+
+// commitObject - write the integrity file and then the metadata of an object whose blocks are
+// committed, the object is readable once its metadata is written. A crash before leaves blocks
+// without metadata, which are not part of any object
+func (b bucket) commitObject(objectName string, objMetadata ObjectMetadata, blocks map[int]string) *probe.Error {
+	// checksums are kept apart from object metadata, a damaged metadata file does not lose them
+	err := b.writeObjectIntegrity(objectName, newObjectIntegrity(objMetadata, blocks))
+	if err == nil {
+		// write object specific metadata
+		err = b.writeObjectMetadata(objectName, objMetadata)
+	}
+	if err != nil {
+		if objMetadata.DedupKey != "" {
+			b.releaseDedupData(objMetadata.DedupKey)
+		} else {
+			// blocks without metadata are not part of any object
+			b.removeObjectData(objectName)
+		}
+		return err.Trace()
+	}
+	return nil
+}
+
+// purgeObject - discard everything written for an object which failed, along with the
+// directories created for it on the disks
+func (b bucket) purgeObject(objectName string, writers []io.WriteCloser) {
+	CleanupWritersOnError(writers)
+	b.removeObjectData(objectName)
+}
+
+// removeObjectData - remove blocks of an object from all the disks, errors are ignored since
+// blocks left behind are orphans found by fsck
+func (b bucket) removeObjectData(objectName string) {
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order)
+			disk.RemoveAll(filepath.Join(b.xlName, bucketSlice, objectName))
+		}
+		nodeSlice = nodeSlice + 1
+	}
+}
+
+// DeleteObject - remove an object from all the disks
+func (b bucket) DeleteObject(objectName string) *probe.Error {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if objectName == "" {
+		return probe.NewError(InvalidArgument{})
+	}
+	// shared data blocks are removed only along with their last reference
+	if objMetadata, err := b.readObjectMetadata(normalizeObjectName(objectName)); err == nil && objMetadata.DedupKey != "" {
+		if err := b.releaseDedupData(objMetadata.DedupKey); err != nil {
+			return err.Trace()
+		}
+	}
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order)
+			if err := disk.RemoveAll(filepath.Join(b.xlName, bucketSlice, normalizeObjectName(objectName))); err != nil {
+				return err.Trace()
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return nil
+}
+
+// UpdateObject - rewrite metadata of an object on all the disks with update applied, data
+// is not rewritten
+func (b bucket) UpdateObject(objectName string, update func(ObjectMetadata) ObjectMetadata) (ObjectMetadata, *probe.Error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if objectName == "" {
+		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
+	}
+	objMetadata, err := b.readObjectMetadata(normalizeObjectName(objectName))
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	objMetadata = update(objMetadata)
 	if err := b.writeObjectMetadata(normalizeObjectName(objectName), objMetadata); err != nil {
-		// purge all writers, when control flow reaches here
-		CleanupWritersOnError(writers)
 		return ObjectMetadata{}, err.Trace()
 	}
-	// close all writers, when control flow reaches here
-	for _, writer := range writers {
-		writer.Close()
+	return objMetadata, nil
+}
+
+// MoveObject - rename an object on all the disks, data blocks are not rewritten
+func (b bucket) MoveObject(sourceObjectName, objectName string) (ObjectMetadata, *probe.Error) {
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	if sourceObjectName == "" || objectName == "" {
+		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
+	}
+	objMetadata, err := b.readObjectMetadata(normalizeObjectName(sourceObjectName))
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	objMetadata.Object = objectName
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order)
+			sourcePath := filepath.Join(b.xlName, bucketSlice, normalizeObjectName(sourceObjectName))
+			objectPath := filepath.Join(b.xlName, bucketSlice, normalizeObjectName(objectName))
+			// leftovers of a failed write under the new name are not part of any object
+			if err := disk.RemoveAll(objectPath); err != nil {
+				return ObjectMetadata{}, err.Trace()
+			}
+			if err := disk.Rename(sourcePath, objectPath); err != nil {
+				// disks missing the object, e.g newly added ones, are skipped
+				if os.IsNotExist(err.ToGoError()) {
+					continue
+				}
+				return ObjectMetadata{}, err.Trace()
+			}
+			objMetadataWriter, err := disk.CreateFile(filepath.Join(objectPath, objectMetadataConfig))
+			if err != nil {
+				return ObjectMetadata{}, err.Trace()
+			}
+			if err := json.NewEncoder(objMetadataWriter).Encode(&objMetadata); err != nil {
+				objMetadataWriter.CloseAndPurge()
+				return ObjectMetadata{}, probe.NewError(err)
+			}
+			objMetadataWriter.Close()
+		}
+		nodeSlice = nodeSlice + 1
 	}
 	return objMetadata, nil
 }
@@ -389,8 +626,8 @@ func (b bucket) readObjectMetadata(objectName string) (ObjectMetadata, *probe.Er
 	}
 	{
 		var err error
-		for _, objMetadataReader := range objMetadataReaders {
-			jdec := json.NewDecoder(objMetadataReader)
+		for _, order := range getReadOrder(objMetadataReaders) {
+			jdec := json.NewDecoder(objMetadataReaders[order])
 			if err = jdec.Decode(&objMetadata); err == nil {
 				return objMetadata, nil
 			}
@@ -412,55 +649,92 @@ func normalizeObjectName(objectName string) string {
 	return strings.Replace(objectName, "/", "-", -1)
 }
 
-// getDataAndParity - calculate k, m (data and parity) values from number of disks
-func (b bucket) getDataAndParity(totalWriters int) (k uint8, m uint8, err *probe.Error) {
+// getDataAndParity - calculate k, m (data and parity) values from number of disks in the given
+// ratio, at least one of each. Without a ratio there are as many parity as data blocks, one more
+// data block for an odd number of disks
+func (b bucket) getDataAndParity(totalWriters int, ratio ErasureRatio) (k uint8, m uint8, err *probe.Error) {
 	if totalWriters <= 1 {
 		return 0, 0, probe.NewError(InvalidArgument{})
 	}
-	quotient := totalWriters / 2 // not using float or abs to let integer round off to lower value
-	// quotient cannot be bigger than (255 / 2) = 127
-	if quotient > 127 {
+	// k + m cannot be bigger than 255
+	if totalWriters > 255 {
 		return 0, 0, probe.NewError(ParityOverflow{})
 	}
-	remainder := totalWriters % 2 // will be 1 for odd and 0 for even numbers
-	k = uint8(quotient + remainder)
-	m = uint8(quotient)
-	return k, m, nil
+	if ratio.Data <= 0 || ratio.Parity <= 0 {
+		ratio = ErasureRatio{Data: 1, Parity: 1}
+	}
+	// parity rounded to the nearest block, halves round towards data
+	total := ratio.Data + ratio.Parity
+	parity := (2*totalWriters*ratio.Parity + total - 1) / (2 * total)
+	if parity < 1 {
+		parity = 1
+	}
+	if parity > totalWriters-1 {
+		parity = totalWriters - 1
+	}
+	return uint8(totalWriters - parity), uint8(parity), nil
 }
 
-// writeObjectData -
-func (b bucket) writeObjectData(k, m uint8, writers []io.WriteCloser, objectData io.Reader, size int64, hashWriter io.Writer) (int, int, *probe.Error) {
+// writeObjectData - data is hashed with hashWriter chunk by chunk as it is encoded. verify, unless
+// nil, checks the digests once the last chunk is hashed, before its blocks are written
+func (b bucket) writeObjectData(k, m uint8, writers []io.WriteCloser, objectData io.Reader, size int64, hashWriter io.Writer, verify func() *probe.Error) (int, int, map[int]error, *probe.Error) {
 	encoder, err := newEncoder(k, m)
 	if err != nil {
-		return 0, 0, err.Trace()
+		return 0, 0, nil, err.Trace()
 	}
 	chunkSize := int64(10 * 1024 * 1024)
 	chunkCount := 0
 	totalLength := 0
+	// disks which failed persistently, their blocks are purged and rebuilt from parity on reads
+	offline := make(map[int]error)
+	// every chunk is encoded in place, into a buffer holding its padding and parity as well
+	encodedBlockLen, err := encoder.GetEncodedBlockLen(int(chunkSize))
+	if err != nil {
+		return 0, 0, nil, err.Trace()
+	}
+	buffer := getBuffer(encodedBlockLen * int(k+m))
+	defer putBuffer(buffer)
 
 	var e error
 	for e == nil {
 		var length int
-		inputData := make([]byte, chunkSize)
-		length, e = objectData.Read(inputData)
+		inputData := buffer[:chunkSize]
+		// every chunk except the last one must be of full chunkSize, since that is
+		// what readObjectData() expects while decoding
+		length, e = io.ReadFull(objectData, inputData)
+		if e == io.ErrUnexpectedEOF {
+			e = io.EOF
+		}
 		if length != 0 {
 			encodedBlocks, err := encoder.Encode(inputData[0:length])
 			if err != nil {
-				return 0, 0, err.Trace()
+				return 0, 0, nil, err.Trace()
 			}
 			if _, err := hashWriter.Write(inputData[0:length]); err != nil {
-				return 0, 0, probe.NewError(err)
+				return 0, 0, nil, probe.NewError(err)
+			}
+			if verify != nil && (e == io.EOF || (size > 0 && int64(totalLength+length) == size)) {
+				if err := verify(); err != nil {
+					return 0, 0, nil, err.Trace()
+				}
 			}
 			for blockIndex, block := range encodedBlocks {
-				errCh := make(chan error, 1)
-				go func(writer io.Writer, reader io.Reader, errCh chan<- error) {
-					defer close(errCh)
-					_, err := io.Copy(writer, reader)
-					errCh <- err
-				}(writers[blockIndex], bytes.NewReader(block), errCh)
-				if err := <-errCh; err != nil {
-					// Returning error is fine here CleanupErrors() would cleanup writers
-					return 0, 0, probe.NewError(err)
+				if _, ok := offline[blockIndex]; ok {
+					continue
+				}
+				if err := writeBlock(writers[blockIndex], block); err != nil {
+					// Returning error is fine here CleanupErrors() would cleanup writers, once
+					// more disks than parity are lost the object could not be read back
+					if len(offline) >= int(m) {
+						return 0, 0, nil, probe.NewError(err)
+					}
+					log.Printf("Disk %d is offline, writing remaining blocks without it: %s", blockIndex, err)
+					offline[blockIndex] = err
+					purgeWriter(writers[blockIndex])
+					// reads outstanding on the disk fail over to parity as well
+					if disk.IsFailure(err) {
+						b.getDisks()[blockIndex].Fail(err)
+					}
 				}
 			}
 			totalLength += length
@@ -468,55 +742,85 @@ func (b bucket) writeObjectData(k, m uint8, writers []io.WriteCloser, objectData
 		}
 	}
 	if e != io.EOF {
-		return 0, 0, probe.NewError(e)
+		return 0, 0, nil, probe.NewError(e)
 	}
-	return chunkCount, totalLength, nil
+	return chunkCount, totalLength, offline, nil
 }
 
-// readObjectData -
-func (b bucket) readObjectData(objectName string, writer *io.PipeWriter, objMetadata ObjectMetadata) {
-	readers, err := b.getObjectReaders(objectName, "data")
-	if err != nil {
-		writer.CloseWithError(probe.WrapError(err))
-		return
+// getDataFile - name of the file holding erasure coded blocks of an object
+func getDataFile(objMetadata ObjectMetadata) string {
+	if objMetadata.DataFile == "" {
+		return "data"
 	}
+	return objMetadata.DataFile
+}
+
+// readObjectData - readers are opened by the caller, an object moved or removed
+// once they are open is still read in full. Readers which fail are dropped from readers,
+// returns true if the object was read in full and matched its checksums.
+func (b bucket) readObjectData(readers map[int]io.ReadCloser, writer *io.PipeWriter, objMetadata ObjectMetadata) bool {
 	for _, reader := range readers {
 		defer reader.Close()
 	}
 	var expected512Sum, expectedMd5sum []byte
 	{
 		var err error
-		expectedMd5sum, err = hex.DecodeString(objMetadata.MD5Sum)
+		if !isMultipartETag(objMetadata.MD5Sum) {
+			expectedMd5sum, err = hex.DecodeString(objMetadata.MD5Sum)
+		}
 		if err != nil {
 			writer.CloseWithError(probe.WrapError(probe.NewError(err)))
-			return
+			return false
 		}
 		expected512Sum, err = hex.DecodeString(objMetadata.SHA512Sum)
 		if err != nil {
 			writer.CloseWithError(probe.WrapError(probe.NewError(err)))
-			return
+			return false
 		}
 	}
-	hasher := md5.New()
-	sum512hasher := sha256.New()
-	mwriter := io.MultiWriter(writer, hasher, sum512hasher)
-	switch len(readers) > 1 {
+	// objects written without MD5 and multipart objects are only verified with their sha512sum
+	var hasher hash.Hash
+	sum512hasher := sha512.New()
+	hashWriter := io.Writer(sum512hasher)
+	if expectedMd5sum != nil {
+		hasher = objMetadata.ETagAlgorithm.newHash()
+		hashWriter = io.MultiWriter(hasher, sum512hasher)
+	}
+	mwriter := io.MultiWriter(writer, hashWriter)
+	// an erasure coded object with a single block left is still decoded, objects written to a single
+	// disk have no data disks recorded
+	switch len(readers) > 1 || objMetadata.DataDisks > 0 {
 	case true:
 		encoder, err := newEncoder(objMetadata.DataDisks, objMetadata.ParityDisks)
 		if err != nil {
 			writer.CloseWithError(probe.WrapError(err))
-			return
+			return false
+		}
+		var chunks <-chan decodedChunk
+		if stripes := getReadAhead(); stripes > 0 {
+			done := make(chan struct{})
+			defer close(done)
+			chunks = b.prefetchObjectData(objMetadata, readers, encoder, stripes, hashWriter, writer, done)
 		}
 		totalLeft := objMetadata.Size
 		for i := 0; i < objMetadata.ChunkCount; i++ {
-			decodedData, err := b.decodeEncodedData(totalLeft, int64(objMetadata.BlockSize), readers, encoder, writer)
+			var decodedData []byte
+			var err *probe.Error
+			dst := mwriter
+			if chunks != nil {
+				// checksums are already updated by the prefetch
+				chunk := <-chunks
+				decodedData, err, dst = chunk.data, chunk.err, writer
+			} else {
+				decodedData, err = b.decodeEncodedData(totalLeft, int64(objMetadata.BlockSize), readers, encoder, writer)
+			}
 			if err != nil {
 				writer.CloseWithError(probe.WrapError(err))
-				return
+				return false
 			}
-			if _, err := io.Copy(mwriter, bytes.NewReader(decodedData)); err != nil {
+			if _, err := io.Copy(dst, bytes.NewReader(decodedData)); err != nil {
 				writer.CloseWithError(probe.WrapError(probe.NewError(err)))
-				return
+				return false
 			}
 			totalLeft = totalLeft - int64(objMetadata.BlockSize)
 		}
@@ -524,20 +828,20 @@ func (b bucket) readObjectData(objectName string, writer *io.PipeWriter, objMeta
 		_, err := io.Copy(writer, readers[0])
 		if err != nil {
 			writer.CloseWithError(probe.WrapError(probe.NewError(err)))
-			return
+			return false
 		}
 	}
 	// check if decodedData md5sum matches
-	if !bytes.Equal(expectedMd5sum, hasher.Sum(nil)) {
+	if hasher != nil && !bytes.Equal(expectedMd5sum, hasher.Sum(nil)) {
 		writer.CloseWithError(probe.WrapError(probe.NewError(ChecksumMismatch{})))
-		return
+		return false
 	}
 	if !bytes.Equal(expected512Sum, sum512hasher.Sum(nil)) {
 		writer.CloseWithError(probe.WrapError(probe.NewError(ChecksumMismatch{})))
-		return
+		return false
 	}
 	writer.Close()
-	return
+	return true
 }
 
 // decodeEncodedData -
@@ -553,30 +857,53 @@ func (b bucket) decodeEncodedData(totalLeft, blockSize int64, readers map[int]io
 		return nil, err.Trace()
 	}
 	encodedBytes := make([][]byte, encoder.k+encoder.m)
-	errCh := make(chan error, len(readers))
+	// blocks of full stripes are read into a buffer reused across requests, decoding copies
+	// the data out of it
+	var buffer []byte
+	var abandoned bool
+	if curBlockSize == blockSize {
+		buffer = getBuffer(curChunkSize * len(encodedBytes))
+		defer func() {
+			// reads abandoned on failed disks may still write to the buffer
+			if !abandoned {
+				putBuffer(buffer)
+			}
+		}()
+	}
+	disks := b.getDisks()
 	var errRet error
 	var readCnt int
 
-	for i, reader := range readers {
-		go func(reader io.Reader, i int) {
-			encodedBytes[i] = make([]byte, curChunkSize)
-			_, err := io.ReadFull(reader, encodedBytes[i])
-			if err != nil {
-				encodedBytes[i] = nil
-				errCh <- err
-				return
-			}
-			errCh <- nil
-		}(reader, i)
-		// read through errCh for any errors
-		err := <-errCh
+	for _, i := range getReadOrder(readers) {
+		reader := readers[i]
+		// blocks on remote disks are skipped as long as the local ones suffice
+		if standby, ok := reader.(*standbyReader); ok && readCnt >= int(encoder.k) {
+			standby.skip(int64(curChunkSize))
+			continue
+		}
+		var block []byte
+		if buffer != nil {
+			block = buffer[i*curChunkSize : (i+1)*curChunkSize]
+		} else {
+			block = make([]byte, curChunkSize)
+		}
+		// a block which failed to read is not aligned with the next stripe anymore and is
+		// never read again
+		blockAbandoned, err := readBlock(disks[i], reader, block)
+		abandoned = abandoned || blockAbandoned
 		if err != nil {
 			errRet = err
-		} else {
-			readCnt++
+			delete(readers, i)
+			continue
 		}
+		encodedBytes[i] = block
+		readCnt++
 	}
 	if readCnt < int(encoder.k) {
+		// blocks missing on disks altogether never fail to read
+		if errRet == nil {
+			errRet = io.ErrUnexpectedEOF
+		}
 		return nil, probe.NewError(errRet)
 	}
 	decodedData, err := encoder.Decode(encodedBytes, int(curBlockSize))
@@ -601,14 +928,15 @@ func (b bucket) getObjectReaders(objectName, objectMeta string) (map[int]io.Read
 			var objectSlice io.ReadCloser
 			bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order)
 			objectPath := filepath.Join(b.xlName, bucketSlice, objectName, objectMeta)
-			objectSlice, err = disk.Open(objectPath)
+			objectSlice, err = b.openFile(disk, objectPath)
 			if err == nil {
 				readers[order] = objectSlice
 			}
 		}
 		nodeSlice = nodeSlice + 1
 	}
-	if err != nil {
+	// disks missing the object, e.g newly added ones, are skipped, fail only if none of them have it
+	if len(readers) == 0 && err != nil {
 		return nil, err.Trace()
 	}
 	return readers, nil
@@ -629,9 +957,15 @@ func (b bucket) getObjectWriters(objectName, objectMeta string) ([]io.WriteClose
 			objectPath := filepath.Join(b.xlName, bucketSlice, objectName, objectMeta)
 			objectSlice, err := disk.CreateFile(objectPath)
 			if err != nil {
+				// files already created on other disks are never committed
+				for _, writer := range writers {
+					if writer != nil {
+						purgeWriter(writer)
+					}
+				}
 				return nil, err.Trace()
 			}
-			writers[order] = objectSlice
+			writers[order] = b.traceWriter(objectSlice, disk.GetPath(), objectPath)
 		}
 		nodeSlice = nodeSlice + 1
 	}
@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// bucketCacheTTL - how long a bucket verified on disk is trusted to exist without looking
+// at the disks again, bounds how long a bucket removed out-of-band is still served
+var bucketCacheTTL = 5 * time.Second
+
+// diskBucketLookups - number of times bucket existence was verified on disks
+var diskBucketLookups int64
+
+// bucketCache - buckets known to exist and when they were last verified on disks
+type bucketCache struct {
+	lock     *sync.Mutex
+	verified map[string]time.Time
+}
+
+func newBucketCache() *bucketCache {
+	return &bucketCache{
+		lock:     &sync.Mutex{},
+		verified: make(map[string]time.Time),
+	}
+}
+
+// isFresh - bucket was verified on disks within bucketCacheTTL
+func (c *bucketCache) isFresh(bucketName string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	verified, ok := c.verified[bucketName]
+	return ok && time.Since(verified) < bucketCacheTTL
+}
+
+// set - bucket was just created or verified on disks
+func (c *bucketCache) set(bucketName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	c.verified[bucketName] = time.Now()
+}
+
+// remove - bucket no longer exists
+func (c *bucketCache) remove(bucketName string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.verified, bucketName)
+}
+
+// isBucketOnDisk - bucket exists if any of the disks has its bucket slice
+func (xl API) isBucketOnDisk(bucketName string) (bool, *probe.Error) {
+	atomic.AddInt64(&diskBucketLookups, 1)
+	var lastErr error
+	nodeSlice := 0
+	for _, node := range xl.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return false, err.Trace()
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", bucketName, nodeSlice, order)
+			st, e := os.Stat(filepath.Join(disk.GetPath(), xl.config.XLName, bucketSlice))
+			if e == nil && st.IsDir() {
+				return true, nil
+			}
+			if e != nil && !os.IsNotExist(e) {
+				lastErr = e
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	// if all disks are failing then return error
+	if lastErr != nil {
+		return false, probe.NewError(lastErr)
+	}
+	return false, nil
+}
+
+// checkBucket - verify bucket exists, disks are consulted only if the bucket is not
+// known or it was last verified more than bucketCacheTTL ago
+func (xl API) checkBucket(bucketName string) *probe.Error {
+	if _, ok := xl.buckets[bucketName]; ok && xl.bucketCache.isFresh(bucketName) {
+		return nil
+	}
+	exists, err := xl.isBucketOnDisk(bucketName)
+	if err != nil {
+		return err.Trace()
+	}
+	if !exists {
+		xl.bucketCache.remove(bucketName)
+		delete(xl.buckets, bucketName)
+		return probe.NewError(BucketNotFound{Bucket: bucketName})
+	}
+	if _, ok := xl.buckets[bucketName]; !ok {
+		bkt, _, err := newBucket(bucketName, "private", xl.config.XLName, xl.nodes)
+		if err != nil {
+			return err.Trace()
+		}
+		xl.buckets[bucketName] = bkt
+	}
+	xl.bucketCache.set(bucketName)
+	return nil
+}
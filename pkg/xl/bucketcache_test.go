@@ -0,0 +1,130 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// newBucketCacheTestXL - disk xl holding a single object 'bucket/object'
+func newBucketCacheTestXL(root string) (API, error) {
+	xlAPI, err := createTestXL(root, "bucketcache", 16)
+	if err != nil {
+		return API{}, err.ToGoError()
+	}
+	if err := xlAPI.MakeBucket("bucket", "private", nil, nil); err != nil {
+		return API{}, err.ToGoError()
+	}
+	data := []byte("Hello World")
+	if _, err := xlAPI.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil); err != nil {
+		return API{}, err.ToGoError()
+	}
+	return xlAPI, nil
+}
+
+func (s *MyXLSuite) TestBucketCache(c *C) {
+	bucketCacheTTL = 100 * time.Millisecond
+	defer func() { bucketCacheTTL = 5 * time.Second }()
+	root := c.MkDir()
+	xlAPI, err := newBucketCacheTestXL(root)
+	c.Assert(err, IsNil)
+
+	// bucket created is known, disks are not consulted
+	lookups := atomic.LoadInt64(&diskBucketLookups)
+	for i := 0; i < 10; i++ {
+		_, perr := xlAPI.getObjectMetadata("bucket", "object")
+		c.Assert(perr, IsNil)
+	}
+	c.Assert(atomic.LoadInt64(&diskBucketLookups), Equals, lookups)
+
+	_, perr := xlAPI.getObjectMetadata("unknown", "object")
+	c.Assert(perr.ToGoError(), DeepEquals, BucketNotFound{Bucket: "unknown"})
+	c.Assert(atomic.LoadInt64(&diskBucketLookups), Equals, lookups+1)
+
+	// bucket removed out-of-band is noticed once its cache entry expires
+	slices, e := filepath.Glob(filepath.Join(root, "*", "bucketcache", "bucket$*"))
+	c.Assert(e, IsNil)
+	c.Assert(len(slices), Equals, 16)
+	for _, slice := range slices {
+		c.Assert(os.RemoveAll(slice), IsNil)
+	}
+	time.Sleep(bucketCacheTTL)
+	_, _, perr = xlAPI.getObject("bucket", "object")
+	c.Assert(perr.ToGoError(), DeepEquals, BucketNotFound{Bucket: "bucket"})
+}
+
+func (s *MyXLSuite) TestGetObjectRange(c *C) {
+	xlAPI, err := newBucketCacheTestXL(c.MkDir())
+	c.Assert(err, IsNil)
+
+	// range read from disks is not cached as the whole object
+	var buffer bytes.Buffer
+	_, perr := xlAPI.GetObject(&buffer, "bucket", "object", 6, 5)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.String(), Equals, "World")
+	buffer.Reset()
+	_, perr = xlAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.String(), Equals, "Hello World")
+	buffer.Reset()
+	_, perr = xlAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.String(), Equals, "Hello World")
+}
+
+func benchmarkBucketCache(b *testing.B, ttl time.Duration) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-bucketcache-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	bucketCacheTTL = ttl
+	defer func() { bucketCacheTTL = 5 * time.Second }()
+	xlAPI, err := newBucketCacheTestXL(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	lookups := atomic.LoadInt64(&diskBucketLookups)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader, _, err := xlAPI.getObject("bucket", "object")
+		if err != nil {
+			b.Fatal(err)
+		}
+		if _, err := ioutil.ReadAll(reader); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&diskBucketLookups)-lookups)/float64(b.N), "lookups/op")
+}
+
+func BenchmarkGetObjectBucketCache(b *testing.B) {
+	benchmarkBucketCache(b, 5*time.Second)
+}
+
+func BenchmarkGetObjectNoBucketCache(b *testing.B) {
+	benchmarkBucketCache(b, 0)
+}
@@ -0,0 +1,152 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// BucketStats - number of objects in a bucket and their total size in bytes
+type BucketStats struct {
+	Bucket  string `json:"bucket"`
+	Objects int64  `json:"objects"`
+	Size    int64  `json:"size"`
+}
+
+// bucketCounters - counters of a bucket, updated atomically so that they can be read
+// without waiting for writers holding the xl lock
+type bucketCounters struct {
+	objects int64
+	size    int64
+}
+
+// bucketStats - counters of all buckets
+type bucketStats struct {
+	lock    *sync.RWMutex
+	buckets map[string]*bucketCounters
+}
+
+func newBucketStats() *bucketStats {
+	return &bucketStats{
+		lock:    &sync.RWMutex{},
+		buckets: make(map[string]*bucketCounters),
+	}
+}
+
+// counters - counters of a bucket, created on first use
+func (s *bucketStats) counters(bucket string) *bucketCounters {
+	s.lock.RLock()
+	c, ok := s.buckets[bucket]
+	s.lock.RUnlock()
+	if ok {
+		return c
+	}
+	s.lock.Lock()
+	defer s.lock.Unlock()
+	if c, ok = s.buckets[bucket]; !ok {
+		c = &bucketCounters{}
+		s.buckets[bucket] = c
+	}
+	return c
+}
+
+// add - objects were created, or removed if negative
+func (s *bucketStats) add(bucket string, objects, size int64) {
+	c := s.counters(bucket)
+	atomic.AddInt64(&c.objects, objects)
+	atomic.AddInt64(&c.size, size)
+}
+
+// set - counters found by a scan of the bucket
+func (s *bucketStats) set(bucket string, objects, size int64) {
+	c := s.counters(bucket)
+	atomic.StoreInt64(&c.objects, objects)
+	atomic.StoreInt64(&c.size, size)
+}
+
+func (s *bucketStats) get(bucket string) BucketStats {
+	c := s.counters(bucket)
+	return BucketStats{
+		Bucket:  bucket,
+		Objects: atomic.LoadInt64(&c.objects),
+		Size:    atomic.LoadInt64(&c.size),
+	}
+}
+
+// GetBucketStats - number of objects in a bucket and their total size, maintained on every
+// write so it is cheap to call
+func (xl API) GetBucketStats(bucket string) (BucketStats, *probe.Error) {
+	if !IsValidBucket(bucket) {
+		return BucketStats{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return BucketStats{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	return xl.bucketStats.get(bucket), nil
+}
+
+// RescanBucketStats - count objects of a bucket and their total size by reading metadata of
+// every object, counters and the size saved in bucket metadata are replaced by the result
+func (xl API) RescanBucketStats(bucket string) (BucketStats, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return BucketStats{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return BucketStats{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	stats := BucketStats{Bucket: bucket}
+	if len(xl.config.NodeDiskMap) > 0 {
+		if err := xl.checkBucket(bucket); err != nil {
+			return BucketStats{}, err.Trace()
+		}
+		bucketMeta, err := xl.getXLBucketMetadata()
+		if err != nil {
+			return BucketStats{}, err.Trace()
+		}
+		bucketMetadata := bucketMeta.Buckets[bucket]
+		for object := range bucketMetadata.BucketObjects {
+			objMetadata, err := xl.buckets[bucket].GetObjectMetadata(object)
+			if err != nil {
+				return BucketStats{}, err.Trace(bucket, object)
+			}
+			stats.Objects++
+			stats.Size += objMetadata.Size
+		}
+		bucketMetadata.ObjectsSize = stats.Size
+		bucketMeta.Buckets[bucket] = bucketMetadata
+		if err := xl.setXLBucketMetadata(bucketMeta); err != nil {
+			return BucketStats{}, err.Trace()
+		}
+	} else {
+		storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+		for objectKey, objMetadata := range storedBucket.objectMetadata {
+			if strings.HasPrefix(objectKey, bucket+"/") {
+				stats.Objects++
+				stats.Size += objMetadata.Size
+			}
+		}
+	}
+	xl.bucketStats.set(bucket, stats.Objects, stats.Size)
+	return stats, nil
+}
@@ -0,0 +1,128 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyBucketStatsSuite struct {
+	root string
+}
+
+var _ = Suite(&MyBucketStatsSuite{})
+
+func (s *MyBucketStatsSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-bucketstats-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyBucketStatsSuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+func (s *MyBucketStatsSuite) TestBucketStats(c *C) {
+	xlAPI, err := newBucketCacheTestXL(filepath.Join(s.root, "disks"))
+	c.Assert(err, IsNil)
+
+	stats, perr := xlAPI.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, BucketStats{Bucket: "bucket", Objects: 1, Size: 11})
+	_, perr = xlAPI.GetBucketStats("missing")
+	c.Assert(perr.ToGoError(), DeepEquals, BucketNotFound{Bucket: "missing"})
+
+	// concurrent writers are all counted
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			data := bytes.Repeat([]byte("a"), 100)
+			_, err := xlAPI.CreateObject("bucket", "object"+strconv.Itoa(i), "", int64(len(data)), bytes.NewReader(data), nil, nil)
+			c.Check(err, IsNil)
+		}(i)
+	}
+	wg.Wait()
+	c.Assert(xlAPI.DeleteObject("bucket", "object0", ""), IsNil)
+	_, perr = xlAPI.MoveObject("bucket", "object1", "moved")
+	c.Assert(perr, IsNil)
+	expected := BucketStats{Bucket: "bucket", Objects: 10, Size: 11 + 9*100}
+	stats, perr = xlAPI.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, expected)
+
+	// counters survive a restart
+	restarted, perr := New()
+	c.Assert(perr, IsNil)
+	stats, perr = restarted.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, expected)
+
+	// rescan corrects counters which are off
+	restarted.(API).bucketStats.set("bucket", 3, 3)
+	stats, perr = restarted.RescanBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, expected)
+	stats, perr = restarted.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, expected)
+}
+
+func (s *MyBucketStatsSuite) TestBucketStatsMemory(c *C) {
+	conf := new(Config)
+	conf.Version = "0.0.1"
+	conf.MaxSize = 1000
+	SetXLConfigPath(filepath.Join(s.root, "memory.json"))
+	c.Assert(SaveConfig(conf), IsNil)
+	xlAPI, perr := New()
+	c.Assert(perr, IsNil)
+	c.Assert(xlAPI.MakeBucket("bucket", "private", nil, nil), IsNil)
+
+	data := bytes.Repeat([]byte("a"), 400)
+	for _, object := range []string{"object1", "object2"} {
+		_, perr = xlAPI.CreateObject("bucket", object, "", int64(len(data)), bytes.NewReader(data), nil, nil)
+		c.Assert(perr, IsNil)
+	}
+	_, perr = xlAPI.MoveObject("bucket", "object2", "moved")
+	c.Assert(perr, IsNil)
+	stats, perr := xlAPI.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, BucketStats{Bucket: "bucket", Objects: 2, Size: 800})
+
+	// objects evicted from memory are gone
+	_, perr = xlAPI.CreateObject("bucket", "object3", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+	stats, perr = xlAPI.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, BucketStats{Bucket: "bucket", Objects: 2, Size: 800})
+
+	c.Assert(xlAPI.DeleteObject("bucket", "object3", ""), IsNil)
+	stats, perr = xlAPI.RescanBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, BucketStats{Bucket: "bucket", Objects: 1, Size: 400})
+	stats, perr = xlAPI.GetBucketStats("bucket")
+	c.Assert(perr, IsNil)
+	c.Assert(stats, DeepEquals, BucketStats{Bucket: "bucket", Objects: 1, Size: 400})
+}
@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"sync"
+	"sync/atomic"
+)
+
+// internal variable only accessed via get/set methods
+var bufferPool int32 = 1
+
+// bufferPools - pools of stripe buffers by their size, only buffers of full stripes are
+// pooled and the objects of an xl share their block size and erasure parameters so there are
+// only ever a few sizes
+var bufferPools = struct {
+	sync.Mutex
+	pools map[int]*sync.Pool
+}{pools: make(map[int]*sync.Pool)}
+
+// SetBufferPool - reuse stripe buffers of erasure encode and decode across requests rather
+// than allocating them for every stripe, enabled by default
+func SetBufferPool(enabled bool) {
+	if enabled {
+		atomic.StoreInt32(&bufferPool, 1)
+		return
+	}
+	atomic.StoreInt32(&bufferPool, 0)
+}
+
+// isBufferPool - are stripe buffers reused
+func isBufferPool() bool {
+	return atomic.LoadInt32(&bufferPool) == 1
+}
+
+// getBuffer - buffer of size bytes, a reused buffer holds data of a previous object and
+// must be overwritten in full before any of it is read or written out
+func getBuffer(size int) []byte {
+	if !isBufferPool() {
+		return make([]byte, size)
+	}
+	bufferPools.Lock()
+	pool, ok := bufferPools.pools[size]
+	if !ok {
+		pool = &sync.Pool{}
+		bufferPools.pools[size] = pool
+	}
+	bufferPools.Unlock()
+	if buffer, ok := pool.Get().(*[]byte); ok {
+		return *buffer
+	}
+	return make([]byte, size)
+}
+
+// putBuffer - return a buffer of getBuffer once nothing refers to it anymore
+func putBuffer(buffer []byte) {
+	if !isBufferPool() {
+		return
+	}
+	buffer = buffer[:cap(buffer)]
+	bufferPools.Lock()
+	pool, ok := bufferPools.pools[len(buffer)]
+	bufferPools.Unlock()
+	if ok {
+		pool.Put(&buffer)
+	}
+}
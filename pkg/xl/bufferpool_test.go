@@ -0,0 +1,134 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyBufferPoolSuite struct {
+	root string
+}
+
+var _ = Suite(&MyBufferPoolSuite{})
+
+func (s *MyBufferPoolSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-bufferpool-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyBufferPoolSuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+func (s *MyBufferPoolSuite) TestBufferPool(c *C) {
+	xlAPI, err := newBucketCacheTestXL(s.root)
+	c.Assert(err, IsNil)
+
+	// stripes of a large object leave their data behind in the pooled buffers
+	large := bytes.Repeat([]byte("LEAK"), blockSize/2)
+	_, perr := xlAPI.CreateObject("bucket", "large", "", int64(len(large)), bytes.NewReader(large), nil, nil)
+	c.Assert(perr, IsNil)
+	c.Assert(s.readObject(c, xlAPI, "large"), DeepEquals, large)
+
+	// none of it ends up in the padding or parity of a small object written next
+	small := []byte("small object")
+	_, perr = xlAPI.CreateObject("bucket", "small", "", int64(len(small)), bytes.NewReader(small), nil, nil)
+	c.Assert(perr, IsNil)
+	for disk := 0; disk < 16; disk++ {
+		block, err := ioutil.ReadFile(filepath.Join(s.root, strconv.Itoa(disk), "bucketcache", "bucket$0$"+strconv.Itoa(disk), "small", "data"))
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Contains(block, []byte("LEAK")), Equals, false)
+	}
+	c.Assert(s.readObject(c, xlAPI, "small"), DeepEquals, small)
+
+	// full stripes read back through reused buffers
+	c.Assert(s.readObject(c, xlAPI, "large"), DeepEquals, large)
+}
+
+// readObject - object read from disks, the cache of the test xl is too small for large objects
+func (s *MyBufferPoolSuite) readObject(c *C, xlAPI API, object string) []byte {
+	reader, _, err := xlAPI.getObject("bucket", object)
+	c.Assert(err, IsNil)
+	defer reader.Close()
+	data, e := ioutil.ReadAll(reader)
+	c.Assert(e, IsNil)
+	return data
+}
+
+func benchmarkPutGetObject(b *testing.B, pool bool) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-bufferpool-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	xlAPI, err := newBucketCacheTestXL(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+	SetBufferPool(pool)
+	defer SetBufferPool(true)
+	data := make([]byte, blockSize)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(data)
+
+	var objects int64
+	var before, after runtime.MemStats
+	runtime.ReadMemStats(&before)
+	b.SetBytes(int64(2 * len(data)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	b.RunParallel(func(pb *testing.PB) {
+		for pb.Next() {
+			object := "object" + strconv.FormatInt(atomic.AddInt64(&objects, 1), 10)
+			if _, err := xlAPI.CreateObject("bucket", object, "", int64(len(data)), bytes.NewReader(data), nil, nil); err != nil {
+				b.Fatal(err)
+			}
+			reader, _, err := xlAPI.getObject("bucket", object)
+			if err != nil {
+				b.Fatal(err)
+			}
+			if _, err := io.Copy(ioutil.Discard, reader); err != nil {
+				b.Fatal(err)
+			}
+			reader.Close()
+		}
+	})
+	b.StopTimer()
+	runtime.ReadMemStats(&after)
+	b.ReportMetric(float64(after.PauseTotalNs-before.PauseTotalNs)/float64(b.N), "gc-pause-ns/op")
+}
+
+func BenchmarkPutGetObjectBufferPool(b *testing.B) {
+	benchmarkPutGetObject(b, true)
+}
+
+func BenchmarkPutGetObjectNoBufferPool(b *testing.B) {
+	benchmarkPutGetObject(b, false)
+}
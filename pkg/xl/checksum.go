@@ -0,0 +1,107 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"hash"
+	"hash/crc32"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// Supported additional checksum algorithms, requested through "checksumAlgorithm" metadata
+const (
+	ChecksumCRC32C = "CRC32C"
+	ChecksumSHA256 = "SHA256"
+)
+
+// NewChecksumHash - hash for the checksum algorithm, nil if the algorithm is not supported
+func NewChecksumHash(algorithm string) hash.Hash {
+	switch algorithm {
+	case ChecksumCRC32C:
+		return crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	case ChecksumSHA256:
+		return sha256.New()
+	}
+	return nil
+}
+
+// IsValidChecksum - verify checksum algorithm is supported and checksum if any is a base64 encoded
+// value of the right length for the algorithm
+func IsValidChecksum(algorithm, checksum string) bool {
+	h := NewChecksumHash(algorithm)
+	if h == nil {
+		return false
+	}
+	if checksum == "" {
+		return true
+	}
+	checksumBytes, err := base64.StdEncoding.DecodeString(checksum)
+	if err != nil {
+		return false
+	}
+	return len(checksumBytes) == h.Size()
+}
+
+// objectChecksum - additional checksum computed over object data
+type objectChecksum struct {
+	algorithm string
+	expected  string
+	hash      hash.Hash
+}
+
+// newObjectChecksum - checksum requested through "checksumAlgorithm" and optionally expected
+// "checksum" metadata, returns nil if no checksum is requested
+func newObjectChecksum(metadata map[string]string) (*objectChecksum, *probe.Error) {
+	algorithm, ok := metadata["checksumAlgorithm"]
+	if !ok {
+		return nil, nil
+	}
+	algorithm = strings.ToUpper(strings.TrimSpace(algorithm))
+	expected := strings.TrimSpace(metadata["checksum"])
+	if !IsValidChecksum(algorithm, expected) {
+		return nil, probe.NewError(InvalidChecksum{Algorithm: algorithm, Checksum: expected})
+	}
+	return &objectChecksum{
+		algorithm: algorithm,
+		expected:  expected,
+		hash:      NewChecksumHash(algorithm),
+	}, nil
+}
+
+// Write - update checksum with object data
+func (c *objectChecksum) Write(p []byte) (int, error) {
+	return c.hash.Write(p)
+}
+
+// verify - verify computed checksum against expected one if any, returns base64 encoded checksum
+func (c *objectChecksum) verify() (string, *probe.Error) {
+	checksum := base64.StdEncoding.EncodeToString(c.hash.Sum(nil))
+	if c.expected != "" && c.expected != checksum {
+		return "", probe.NewError(BadDigest{})
+	}
+	return checksum, nil
+}
+
+// setMetadata - save algorithm and computed checksum in object metadata
+func (c *objectChecksum) setMetadata(metadata map[string]string, checksum string) {
+	metadata["checksumAlgorithm"] = c.algorithm
+	metadata["checksum"] = checksum
+}
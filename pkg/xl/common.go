@@ -19,13 +19,16 @@ package xl
 import (
 	"bufio"
 	"bytes"
+	"encoding/hex"
 	"io"
 	"regexp"
 	"sort"
+	"strconv"
 	"strings"
+	"time"
 	"unicode/utf8"
 
-	"github.com/minio/minio-xl/pkg/atomic"
+	"github.com/minio/minio-xl/pkg/probe"
 )
 
 // IsValidXL - verify xl name is correct
@@ -67,7 +70,7 @@ func IsValidObjectName(object string) bool {
 	if strings.TrimSpace(object) == "" {
 		return false
 	}
-	if len(object) > 1024 || len(object) == 0 {
+	if len(object) > getMaxObjectNameLength() || len(object) == 0 {
 		return false
 	}
 	if !utf8.ValidString(object) {
@@ -168,6 +171,37 @@ func SplitDelimiter(objects []string, delim string) []string {
 	return results
 }
 
+// HasNoDelimiterDepth provides a new slice from an input slice which has elements with fewer than
+// depth delimiters, depth smaller than 1 is treated as 1
+func HasNoDelimiterDepth(objects []string, delim string, depth int) []string {
+	if depth < 1 {
+		depth = 1
+	}
+	var results []string
+	for _, object := range objects {
+		if strings.Count(object, delim) < depth {
+			results = append(results, object)
+		}
+	}
+	return results
+}
+
+// SplitDelimiterDepth provides a new slice from an input slice by splitting at each of the first
+// depth delimiters, depth smaller than 1 is treated as 1
+func SplitDelimiterDepth(objects []string, delim string, depth int) []string {
+	if depth < 1 {
+		depth = 1
+	}
+	var results []string
+	for _, object := range objects {
+		parts := strings.Split(object, delim)
+		for i := 1; i <= depth && i < len(parts); i++ {
+			results = append(results, strings.Join(parts[:i], delim)+delim)
+		}
+	}
+	return results
+}
+
 // SortUnique sort a slice in lexical order, removing duplicate elements
 func SortUnique(objects []string) []string {
 	objectMap := make(map[string]string)
@@ -185,6 +219,49 @@ func SortUnique(objects []string) []string {
 // CleanupWritersOnError purge writers on error
 func CleanupWritersOnError(writers []io.WriteCloser) {
 	for _, writer := range writers {
-		writer.(*atomic.File).CloseAndPurge()
+		writer.(purger).CloseAndPurge()
+	}
+}
+
+// purger - writers discarding everything written on close, files created on disks and
+// their traced wrappers
+type purger interface {
+	CloseAndPurge() error
+}
+
+// purgeWriter - discard everything written to a disk which went offline
+func purgeWriter(writer io.WriteCloser) {
+	if file, ok := writer.(purger); ok {
+		file.CloseAndPurge()
+	}
+}
+
+// getModTime - modification time requested through "mtime" metadata if any, otherwise current time
+func getModTime(metadata map[string]string) time.Time {
+	if mtime, ok := metadata["mtime"]; ok {
+		if t, err := time.Parse(time.RFC3339Nano, mtime); err == nil {
+			return t.UTC()
+		}
+	}
+	return time.Now().UTC()
+}
+
+// getMultipartETag - ETag of a multipart object, md5sum of the concatenated binary md5sums
+// of all its parts suffixed with total number of parts e.g "8a2e0ac3cb6e8e4d4ac9e1e5f5c3e5c1-3",
+// or their sha256sum with sha256 ETags
+func getMultipartETag(partETags []string) (string, *probe.Error) {
+	hasher := getETagAlgorithm().newHash()
+	for _, partETag := range partETags {
+		partMD5Bytes, err := hex.DecodeString(strings.Trim(partETag, "\""))
+		if err != nil {
+			return "", probe.NewError(InvalidDigest{Md5: partETag})
+		}
+		hasher.Write(partMD5Bytes)
 	}
+	return hex.EncodeToString(hasher.Sum(nil)) + "-" + strconv.Itoa(len(partETags)), nil
+}
+
+// isMultipartETag - multipart ETags are not md5sum of the object data
+func isMultipartETag(etag string) bool {
+	return strings.Contains(etag, "-")
 }
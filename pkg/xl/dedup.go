@@ -0,0 +1,258 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// dedupDir - directory inside xl holding content addressed data blocks shared by objects
+//
+// layout: <xlName>/.dedup/<node>$<order>/<dedupKey>/{data,refcount.json}
+const dedupDir = ".dedup"
+
+// dedupRefCountConfig - number of objects referring to a deduplicated data file
+const dedupRefCountConfig = "refcount.json"
+
+// internal variable only accessed via get/set methods
+var dedup int32
+
+// dedupLock serializes reference count updates across all buckets
+var dedupLock = &sync.Mutex{}
+
+// dedupRefCount - reference count saved alongside deduplicated data
+type dedupRefCount struct {
+	RefCount int `json:"refCount"`
+}
+
+// SetDedup - enable content addressed deduplication of object data, objects with
+// identical content share their data blocks on disk
+func SetDedup(enable bool) {
+	var value int32
+	if enable {
+		value = 1
+	}
+	atomic.StoreInt32(&dedup, value)
+}
+
+// isDedup - is deduplication enabled
+func isDedup() bool {
+	return atomic.LoadInt32(&dedup) == 1
+}
+
+// getDedupKey - content address of object data, blocks are only identical for the same erasure layout
+func getDedupKey(objMetadata ObjectMetadata) string {
+	return fmt.Sprintf("%s.%d.%d", objMetadata.SHA512Sum, objMetadata.DataDisks, objMetadata.ParityDisks)
+}
+
+// getDedupPath - path of a deduplicated file on a given disk
+func (b bucket) getDedupPath(nodeSlice, order int, dedupKey, file string) string {
+	return filepath.Join(b.xlName, dedupDir, fmt.Sprintf("%d$%d", nodeSlice, order), dedupKey, file)
+}
+
+// dedupObjectData - commit freshly written data blocks of an object, blocks are moved
+// into the dedup store unless identical blocks are already there, in which case they
+// are purged and the existing blocks are referenced instead
+func (b bucket) dedupObjectData(objectName, dedupKey string, writers []io.WriteCloser) *probe.Error {
+	dedupLock.Lock()
+	defer dedupLock.Unlock()
+
+	refCount, err := b.readDedupRefCount(dedupKey)
+	if err != nil {
+		CleanupWritersOnError(writers)
+		return err.Trace()
+	}
+	if refCount > 0 {
+		CleanupWritersOnError(writers)
+		return b.writeDedupRefCount(dedupKey, refCount+1).Trace()
+	}
+	for _, writer := range writers {
+		if err := writer.Close(); err != nil {
+			return probe.NewError(err)
+		}
+	}
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order)
+			dataPath := b.getDedupPath(nodeSlice, order, dedupKey, "data")
+			if err := disk.MakeDir(filepath.Dir(dataPath)); err != nil {
+				return err.Trace()
+			}
+			// blocks of disks which went offline while writing were purged
+			if err := disk.Rename(filepath.Join(b.xlName, bucketSlice, objectName, "data"), dataPath); err != nil {
+				if os.IsNotExist(err.ToGoError()) {
+					continue
+				}
+				return err.Trace()
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return b.writeDedupRefCount(dedupKey, 1).Trace()
+}
+
+// releaseDedupData - drop a reference to deduplicated data, blocks are removed along
+// with the last reference
+func (b bucket) releaseDedupData(dedupKey string) *probe.Error {
+	dedupLock.Lock()
+	defer dedupLock.Unlock()
+
+	refCount, err := b.readDedupRefCount(dedupKey)
+	if err != nil {
+		return err.Trace()
+	}
+	if refCount > 1 {
+		return b.writeDedupRefCount(dedupKey, refCount-1).Trace()
+	}
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, disk := range disks {
+			if err := disk.RemoveAll(b.getDedupPath(nodeSlice, order, dedupKey, "")); err != nil {
+				return err.Trace()
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return nil
+}
+
+// retireDedupData - drop a reference to deduplicated data, which is kept along with the last
+// reference for servers which may still be reading it. Its reference count is rewritten so
+// orphan expiry only removes it after its expiry
+func (b bucket) retireDedupData(dedupKey string) *probe.Error {
+	dedupLock.Lock()
+	defer dedupLock.Unlock()
+
+	refCount, err := b.readDedupRefCount(dedupKey)
+	if err != nil {
+		return err.Trace()
+	}
+	if refCount > 0 {
+		refCount--
+	}
+	return b.writeDedupRefCount(dedupKey, refCount).Trace()
+}
+
+// readDedupRefCount - reference count of deduplicated data, '0' if it does not exist
+func (b bucket) readDedupRefCount(dedupKey string) (int, *probe.Error) {
+	var lastErr error
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return 0, err.Trace()
+		}
+		for order, disk := range disks {
+			reader, err := disk.Open(b.getDedupPath(nodeSlice, order, dedupKey, dedupRefCountConfig))
+			if err != nil {
+				if !os.IsNotExist(err.ToGoError()) {
+					lastErr = err.ToGoError()
+				}
+				continue
+			}
+			refCount := dedupRefCount{}
+			e := json.NewDecoder(reader).Decode(&refCount)
+			reader.Close()
+			if e == nil {
+				return refCount.RefCount, nil
+			}
+			lastErr = e
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	if lastErr != nil {
+		return 0, probe.NewError(lastErr)
+	}
+	return 0, nil
+}
+
+// writeDedupRefCount - save reference count of deduplicated data on all the disks
+func (b bucket) writeDedupRefCount(dedupKey string, refCount int) *probe.Error {
+	var writers []io.WriteCloser
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, disk := range disks {
+			writer, err := disk.CreateFile(b.getDedupPath(nodeSlice, order, dedupKey, dedupRefCountConfig))
+			if err != nil {
+				CleanupWritersOnError(writers)
+				return err.Trace()
+			}
+			writers = append(writers, writer)
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	for _, writer := range writers {
+		if err := json.NewEncoder(writer).Encode(&dedupRefCount{RefCount: refCount}); err != nil {
+			CleanupWritersOnError(writers)
+			return probe.NewError(err)
+		}
+	}
+	for _, writer := range writers {
+		writer.Close()
+	}
+	return nil
+}
+
+// getDataReaders - open data blocks of an object, from the dedup store if they are shared
+func (b bucket) getDataReaders(objectName string, objMetadata ObjectMetadata) (map[int]io.ReadCloser, *probe.Error) {
+	if objMetadata.DedupKey == "" {
+		return b.getObjectReaders(objectName, getDataFile(objMetadata))
+	}
+	readers := make(map[int]io.ReadCloser)
+	var err *probe.Error
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, perr := node.ListDisks()
+		if perr != nil {
+			return nil, perr.Trace()
+		}
+		for order, disk := range disks {
+			var dataSlice io.ReadCloser
+			dataPath := b.getDedupPath(nodeSlice, order, objMetadata.DedupKey, "data")
+			dataSlice, err = b.openFile(disk, dataPath)
+			if err == nil {
+				readers[order] = dataSlice
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	if len(readers) == 0 && err != nil {
+		return nil, err.Trace()
+	}
+	return readers, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+// getDiskUsage - total size of all files under root
+func getDiskUsage(root string) (int64, error) {
+	var usage int64
+	err := filepath.Walk(root, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if !info.IsDir() {
+			usage += info.Size()
+		}
+		return nil
+	})
+	return usage, err
+}
+
+func (s *MyXLSuite) TestDedup(c *C) {
+	SetDedup(true)
+	defer SetDedup(false)
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "dedup", 16)
+	c.Assert(xlAPI.MakeBucket("bucket1", "private", nil, nil), IsNil)
+	c.Assert(xlAPI.MakeBucket("bucket2", "private", nil, nil), IsNil)
+
+	data := make([]byte, 100*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	baseUsage, err := getDiskUsage(root)
+	c.Assert(err, IsNil)
+
+	objMetadata1, perr := xlAPI.CreateObject("bucket1", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+	firstUsage, err := getDiskUsage(root)
+	c.Assert(err, IsNil)
+	c.Assert(firstUsage-baseUsage > int64(len(data)), Equals, true)
+
+	// second copy adds only its metadata
+	objMetadata2, perr := xlAPI.CreateObject("bucket2", "copy", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+	c.Assert(objMetadata2.MD5Sum, Equals, objMetadata1.MD5Sum)
+	c.Assert(objMetadata2.Size, Equals, objMetadata1.Size)
+	secondUsage, err := getDiskUsage(root)
+	c.Assert(err, IsNil)
+	c.Assert(secondUsage-firstUsage < 1024*1024, Equals, true)
+
+	// shared blocks outlive the first object deleted
+	c.Assert(xlAPI.DeleteObject("bucket1", "object", ""), IsNil)
+	reader, size, perr := xlAPI.getObject("bucket2", "copy")
+	c.Assert(perr, IsNil)
+	c.Assert(size, Equals, int64(len(data)))
+	readData, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(readData, data), Equals, true)
+	dedupEntries, err := filepath.Glob(filepath.Join(root, "*", "dedup", dedupDir, "*", "*"))
+	c.Assert(err, IsNil)
+	c.Assert(len(dedupEntries), Equals, 16)
+
+	c.Assert(xlAPI.DeleteObject("bucket2", "copy", ""), IsNil)
+	dedupEntries, err = filepath.Glob(filepath.Join(root, "*", "dedup", dedupDir, "*", "*"))
+	c.Assert(err, IsNil)
+	c.Assert(len(dedupEntries), Equals, 0)
+
+	// dedup store is not mistaken for a bucket
+	xlAPI = newTestXL(c, root, "dedup", 16)
+	buckets, perr := xlAPI.ListBuckets()
+	c.Assert(perr, IsNil)
+	c.Assert(len(buckets), Equals, 2)
+}
@@ -0,0 +1,36 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+// DefaultContentTypeKey - content type of objects uploaded to a bucket without one, saved in
+// bucket metadata
+const DefaultContentTypeKey = "defaultContentType"
+
+// getContentType - content type an object is stored with, uploads which sent a content type of
+// their own never get the default of the bucket. Multipart uploads only carry it for the erasure
+// ratio, it is not stored
+func getContentType(bucketMetadata BucketMetadata, metadata map[string]string) string {
+	if contentType := metadata["contentType"]; contentType != "" {
+		return contentType
+	}
+	if metadata[ErasureContentTypeKey] == "" {
+		if contentType := bucketMetadata.Metadata[DefaultContentTypeKey]; contentType != "" {
+			return contentType
+		}
+	}
+	return "application/octet-stream"
+}
@@ -0,0 +1,61 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestDefaultContentType(c *C) {
+	root := c.MkDir()
+	for _, withDisks := range []bool{true, false} {
+		var xlAPI API
+		if withDisks {
+			xlAPI = newTestXL(c, root, "defaultcontenttype", 4)
+		} else {
+			xlAPI = newTestMemoryXL(c, root)
+		}
+		c.Assert(xlAPI.MakeBucket("photos", "private", nil, nil), IsNil)
+		putObject := func(object string, metadata map[string]string) string {
+			data := []byte("hello " + object)
+			objMetadata, err := xlAPI.CreateObject("photos", object, "", int64(len(data)), bytes.NewReader(data), metadata, nil)
+			c.Assert(err, IsNil)
+			return objMetadata.Metadata["contentType"]
+		}
+		c.Assert(putObject("a", nil), Equals, "application/octet-stream")
+
+		c.Assert(xlAPI.SetBucketMetadata("photos", map[string]string{DefaultContentTypeKey: "image/jpeg"}), IsNil)
+		c.Assert(putObject("b", nil), Equals, "image/jpeg")
+		// explicit content types win, whether they are stored or not
+		c.Assert(putObject("c", map[string]string{"contentType": "image/png"}), Equals, "image/png")
+		c.Assert(putObject("d", map[string]string{ErasureContentTypeKey: "text/plain"}), Equals, "application/octet-stream")
+		// objects written before are left alone
+		objMetadata, err := xlAPI.GetObjectMetadata("photos", "a")
+		c.Assert(err, IsNil)
+		c.Assert(objMetadata.Metadata["contentType"], Equals, "application/octet-stream")
+
+		if withDisks {
+			// persists with bucket metadata
+			xlAPI = newTestXL(c, root, "defaultcontenttype", 4)
+			c.Assert(putObject("e", nil), Equals, "image/jpeg")
+		}
+		c.Assert(xlAPI.SetBucketMetadata("photos", map[string]string{DefaultContentTypeKey: ""}), IsNil)
+		c.Assert(putObject("f", nil), Equals, "application/octet-stream")
+	}
+}
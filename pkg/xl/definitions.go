@@ -35,9 +35,16 @@ type ObjectMetadata struct {
 	BlockSize   int   `json:"sys.blockSize"`
 	ChunkCount  int   `json:"sys.chunkCount"`
 
-	// checksums
-	MD5Sum    string `json:"sys.md5sum"`
-	SHA512Sum string `json:"sys.sha512sum"`
+	// name of the file holding erasure coded blocks, defaults to "data"
+	DataFile string `json:"sys.dataFile,omitempty"`
+
+	// content address of data blocks shared through the dedup store, empty if not shared
+	DedupKey string `json:"sys.dedupKey,omitempty"`
+
+	// checksums, MD5Sum is the ETag computed with ETagAlgorithm
+	MD5Sum        string        `json:"sys.md5sum"`
+	SHA512Sum     string        `json:"sys.sha512sum"`
+	ETagAlgorithm ETagAlgorithm `json:"sys.etagAlgorithm,omitempty"`
 
 	// metadata
 	Metadata map[string]string `json:"metadata"`
@@ -63,6 +70,8 @@ type BucketMetadata struct {
 	Multiparts    map[string]MultiPartSession `json:"multiparts"`
 	Metadata      map[string]string           `json:"metadata"`
 	BucketObjects map[string]struct{}         `json:"objects"`
+	// ObjectsSize total size of BucketObjects, saved on disk only
+	ObjectsSize int64 `json:"objectsSize"`
 }
 
 // ListObjectsResults container for list objects response
@@ -74,10 +83,11 @@ type ListObjectsResults struct {
 
 // MultiPartSession multipart session
 type MultiPartSession struct {
-	UploadID   string                  `json:"uploadId"`
-	Initiated  time.Time               `json:"initiated"`
-	Parts      map[string]PartMetadata `json:"parts"`
-	TotalParts int                     `json:"total-parts"`
+	UploadID    string                  `json:"uploadId"`
+	ContentType string                  `json:"contentType"`
+	Initiated   time.Time               `json:"initiated"`
+	Parts       map[string]PartMetadata `json:"parts"`
+	TotalParts  int                     `json:"total-parts"`
 }
 
 // PartMetadata - various types of individual part resources
@@ -146,12 +156,15 @@ type BucketMultipartResourcesMetadata struct {
 
 // BucketResourcesMetadata - various types of bucket resources
 type BucketResourcesMetadata struct {
-	Prefix         string
-	Marker         string
-	NextMarker     string
-	Maxkeys        int
-	EncodingType   string
-	Delimiter      string
+	Prefix       string
+	Marker       string
+	NextMarker   string
+	Maxkeys      int
+	EncodingType string
+	Delimiter    string
+	// Depth - minio extension, number of delimiter levels listed in one call,
+	// values smaller than 2 keep the standard single level listing
+	Depth          int
 	IsTruncated    bool
 	CommonPrefixes []string
 }
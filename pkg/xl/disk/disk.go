@@ -31,9 +31,10 @@ import (
 
 // Disk container for disk parameters
 type Disk struct {
-	lock   *sync.Mutex
-	path   string
-	fsInfo map[string]string
+	lock    *sync.Mutex
+	path    string
+	fsInfo  map[string]string
+	failure *failure
 }
 
 // New - instantiate new disk
@@ -43,7 +44,7 @@ func New(diskPath string) (Disk, *probe.Error) {
 	}
 	st, err := os.Stat(diskPath)
 	if err != nil {
-		return Disk{}, probe.NewError(err)
+		return Disk{}, probe.NewError(getPathError(diskPath, err))
 	}
 
 	if !st.IsDir() {
@@ -52,12 +53,13 @@ func New(diskPath string) (Disk, *probe.Error) {
 	s := syscall.Statfs_t{}
 	err = syscall.Statfs(diskPath, &s)
 	if err != nil {
-		return Disk{}, probe.NewError(err)
+		return Disk{}, probe.NewError(getPathError(diskPath, err))
 	}
 	disk := Disk{
-		lock:   &sync.Mutex{},
-		path:   diskPath,
-		fsInfo: make(map[string]string),
+		lock:    &sync.Mutex{},
+		path:    diskPath,
+		fsInfo:  make(map[string]string),
+		failure: newFailure(),
 	}
 	if fsType := getFSType(s.Type); fsType != "UNKNOWN" {
 		disk.fsInfo["FSType"] = fsType
@@ -76,6 +78,15 @@ func (disk Disk) IsUsable() bool {
 	return true
 }
 
+// IsReadOnly - is disk mounted read-only, such disks must never be written to
+func (disk Disk) IsReadOnly() bool {
+	s := syscall.Statfs_t{}
+	if err := syscall.Statfs(disk.path, &s); err != nil {
+		return false
+	}
+	return isReadOnly(s)
+}
+
 // GetPath - get root disk path
 func (disk Disk) GetPath() string {
 	return disk.path
@@ -102,7 +113,32 @@ func (disk Disk) GetFSInfo() map[string]string {
 func (disk Disk) MakeDir(dirname string) *probe.Error {
 	disk.lock.Lock()
 	defer disk.lock.Unlock()
-	if err := os.MkdirAll(filepath.Join(disk.path, dirname), 0700); err != nil {
+	_, dirMode := getPermissions()
+	dirPath := filepath.Join(disk.path, dirname)
+	if err := mkdirAll(dirPath, dirMode); err != nil {
+		return probe.NewError(getPathError(dirPath, err))
+	}
+	return nil
+}
+
+// RemoveAll - remove a directory and all its contents inside disk root path
+func (disk Disk) RemoveAll(dirname string) *probe.Error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	if err := os.RemoveAll(filepath.Join(disk.path, dirname)); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// Rename - rename a file or directory inside disk root path
+func (disk Disk) Rename(oldname, newname string) *probe.Error {
+	disk.lock.Lock()
+	defer disk.lock.Unlock()
+	if oldname == "" || newname == "" {
+		return probe.NewError(InvalidArgument{})
+	}
+	if err := os.Rename(filepath.Join(disk.path, oldname), filepath.Join(disk.path, newname)); err != nil {
 		return probe.NewError(err)
 	}
 	return nil
@@ -165,11 +201,21 @@ func (disk Disk) CreateFile(filename string) (*atomic.File, *probe.Error) {
 		return nil, probe.NewError(InvalidArgument{})
 	}
 
-	f, err := atomic.FileCreate(filepath.Join(disk.path, filename))
+	fileMode, dirMode := getPermissions()
+	filePath := filepath.Join(disk.path, filename)
+	if err := mkdirAll(filepath.Dir(filePath), dirMode); err != nil {
+		return nil, probe.NewError(err)
+	}
+	f, err := atomic.FileCreateWithMode(filePath, fileMode)
 	if err != nil {
 		return nil, probe.NewError(err)
 	}
-
+	switch getFsyncPolicy() {
+	case FsyncAlways:
+		f.SyncOnClose()
+	case FsyncBatch:
+		startBatchSync()
+	}
 	return f, nil
 }
 
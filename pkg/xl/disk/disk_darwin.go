@@ -16,7 +16,10 @@
 
 package disk
 
-import "strconv"
+import (
+	"strconv"
+	"syscall"
+)
 
 // fsType2StrinMap - list of filesystems supported by xl
 var fsType2StringMap = map[string]string{
@@ -32,3 +35,11 @@ func getFSType(fsType uint32) string {
 	}
 	return fsTypeString
 }
+
+// mntReadOnly - MNT_RDONLY of sys/mount.h, not provided by syscall
+const mntReadOnly = 0x1
+
+// isReadOnly - filesystem is mounted read-only
+func isReadOnly(s syscall.Statfs_t) bool {
+	return s.Flags&mntReadOnly != 0
+}
@@ -16,7 +16,10 @@
 
 package disk
 
-import "strconv"
+import (
+	"strconv"
+	"syscall"
+)
 
 // fsType2StringMap - list of filesystems supported by xl on linux
 var fsType2StringMap = map[string]string{
@@ -43,3 +46,8 @@ func getFSType(fsType int64) string {
 	}
 	return fsTypeString
 }
+
+// isReadOnly - filesystem is mounted read-only
+func isReadOnly(s syscall.Statfs_t) bool {
+	return s.Flags&syscall.MS_RDONLY != 0
+}
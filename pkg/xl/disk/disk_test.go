@@ -17,9 +17,13 @@
 package disk
 
 import (
+	"context"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"runtime"
+	"strconv"
+	"syscall"
 	"testing"
 
 	. "gopkg.in/check.v1"
@@ -52,6 +56,19 @@ func (s *MyDiskSuite) TestDiskInfo(c *C) {
 	fsInfo := s.disk.GetFSInfo()
 	c.Assert(fsInfo["MountPoint"], Equals, s.disk.GetPath())
 	c.Assert(fsInfo["FSType"], Not(Equals), "UNKNOWN")
+	c.Assert(s.disk.IsReadOnly(), Equals, false)
+}
+
+func (s *MyDiskSuite) TestDiskErrors(c *C) {
+	missing := filepath.Join(s.path, "missing")
+	_, err := New(missing)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, PathNotFound{Path: missing})
+
+	// tests may run as root, which is never denied access
+	c.Assert(getPathError(missing, syscall.EACCES), DeepEquals, PermissionDenied{Path: missing})
+	c.Assert(getPathError(missing, &os.PathError{Op: "stat", Path: missing, Err: syscall.EACCES}), DeepEquals, PermissionDenied{Path: missing})
+	c.Assert(getPathError(missing, syscall.ENOTDIR), Equals, syscall.ENOTDIR)
 }
 
 func (s *MyDiskSuite) TestDiskCreateDir(c *C) {
@@ -82,3 +99,183 @@ func (s *MyDiskSuite) TestDiskOpen(c *C) {
 	c.Assert(f2.Name(), Equals, filepath.Join(s.path, "hello2"))
 	defer f2.Close()
 }
+
+func (s *MyDiskSuite) TestDiskPermissions(c *C) {
+	// directories created inside the disk get the dir mode, the disk itself is left alone
+	SetPermissions(0640, 0750)
+	defer SetPermissions(DefaultFileMode, DefaultDirMode)
+	c.Assert(s.disk.MakeDir(filepath.Join("perm1", "a", "b")), IsNil)
+	for _, dir := range []string{"perm1", filepath.Join("perm1", "a"), filepath.Join("perm1", "a", "b")} {
+		st, err := os.Stat(filepath.Join(s.path, dir))
+		c.Assert(err, IsNil)
+		c.Assert(st.Mode().Perm(), Equals, os.FileMode(0750))
+	}
+	st, err := os.Stat(s.path)
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm(), Equals, os.FileMode(0700))
+
+	f, perr := s.disk.CreateFile(filepath.Join("perm2", "a", "file"))
+	c.Assert(perr, IsNil)
+	c.Assert(f.Close(), IsNil)
+	st, err = os.Stat(filepath.Join(s.path, "perm2", "a", "file"))
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm(), Equals, os.FileMode(0640))
+	st, err = os.Stat(filepath.Join(s.path, "perm2", "a"))
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm(), Equals, os.FileMode(0750))
+
+	// defaults are restrictive
+	SetPermissions(DefaultFileMode, DefaultDirMode)
+	f, perr = s.disk.CreateFile(filepath.Join("perm3", "file"))
+	c.Assert(perr, IsNil)
+	c.Assert(f.Close(), IsNil)
+	st, err = os.Stat(filepath.Join(s.path, "perm3", "file"))
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm(), Equals, os.FileMode(0600))
+	st, err = os.Stat(filepath.Join(s.path, "perm3"))
+	c.Assert(err, IsNil)
+	c.Assert(st.Mode().Perm(), Equals, os.FileMode(0700))
+}
+
+func (s *MyDiskSuite) TestParseMode(c *C) {
+	mode, err := ParseMode("0640", 0600)
+	c.Assert(err, IsNil)
+	c.Assert(mode, Equals, os.FileMode(0640))
+	mode, err = ParseMode("750", 0700)
+	c.Assert(err, IsNil)
+	c.Assert(mode, Equals, os.FileMode(0750))
+
+	for _, invalid := range []string{"", "0649", "rw-r-----", "01777", "-1"} {
+		_, err = ParseMode(invalid, 0600)
+		c.Assert(err, Not(IsNil))
+	}
+	// server would lock itself out
+	_, err = ParseMode("0400", 0600)
+	c.Assert(err, Not(IsNil))
+	_, err = ParseMode("0600", 0700)
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *MyDiskSuite) TestParseIOPriority(c *C) {
+	for priority, expected := range map[string]IOPriority{
+		"":              0,
+		"idle":          NewIOPriority(IOClassIdle, 0),
+		"best-effort":   NewIOPriority(IOClassBestEffort, 4),
+		"best-effort:7": NewIOPriority(IOClassBestEffort, 7),
+		"realtime:0":    NewIOPriority(IOClassRealtime, 0),
+	} {
+		parsed, err := ParseIOPriority(priority)
+		c.Assert(err, IsNil)
+		c.Assert(parsed, Equals, expected)
+	}
+	c.Assert(NewIOPriority(IOClassBestEffort, 7).String(), Equals, "best-effort:7")
+	c.Assert(NewIOPriority(IOClassIdle, 0).String(), Equals, "idle")
+	c.Assert(NewIOPriority(IOClassIdle, 0), Equals, IOPriority(3<<13))
+
+	for _, invalid := range []string{"low", "idle:7", "best-effort:8", "best-effort:", "realtime:-1"} {
+		_, err := ParseIOPriority(invalid)
+		c.Assert(err, Not(IsNil))
+	}
+}
+
+func (s *MyDiskSuite) TestThreadIOPriority(c *C) {
+	if runtime.GOOS != "linux" {
+		c.Skip("I/O priorities are only set on linux")
+	}
+	idle := NewIOPriority(IOClassIdle, 0)
+	done := make(chan IOPriority)
+	go func() {
+		// thread is thrown away with the goroutine
+		runtime.LockOSThread()
+		c.Check(SetThreadIOPriority(idle), IsNil)
+		done <- GetThreadIOPriority()
+	}()
+	c.Assert(<-done, Equals, idle)
+	c.Assert(GetThreadIOPriority(), Not(Equals), idle)
+}
+
+func (s *MyDiskSuite) TestFsyncPolicy(c *C) {
+	for _, name := range []string{"always", "batch", "never"} {
+		policy, err := ParseFsyncPolicy(name)
+		c.Assert(err, IsNil)
+		c.Assert(policy.String(), Equals, name)
+	}
+	_, err := ParseFsyncPolicy("sometimes")
+	c.Assert(err, Not(IsNil))
+
+	// files are written the same way whatever the policy
+	defer SetFsyncPolicy(FsyncNever)
+	for _, policy := range []FsyncPolicy{FsyncAlways, FsyncBatch, FsyncNever} {
+		SetFsyncPolicy(policy)
+		name := filepath.Join("fsync", policy.String())
+		f, perr := s.disk.CreateFile(name)
+		c.Assert(perr, IsNil)
+		_, e := f.Write([]byte(policy.String()))
+		c.Assert(e, IsNil)
+		c.Assert(f.Close(), IsNil)
+		data, e := ioutil.ReadFile(filepath.Join(s.path, name))
+		c.Assert(e, IsNil)
+		c.Assert(string(data), Equals, policy.String())
+	}
+}
+
+func (s *MyDiskSuite) TestDiskFail(c *C) {
+	ctx := s.disk.Context()
+	c.Assert(ctx.Err(), IsNil)
+	// copies of a disk share its failures
+	disk := s.disk
+	disk.Fail(syscall.EIO)
+	<-ctx.Done()
+	c.Assert(context.Cause(ctx), DeepEquals, Failed{Path: s.path, Err: syscall.EIO})
+	// operations started afterwards use the disk again
+	c.Assert(s.disk.Context().Err(), IsNil)
+
+	c.Assert(IsFailure(&os.PathError{Op: "read", Path: s.path, Err: syscall.EIO}), Equals, true)
+	c.Assert(IsFailure(syscall.ENODEV), Equals, true)
+	c.Assert(IsFailure(syscall.EINTR), Equals, false)
+	c.Assert(IsFailure(nil), Equals, false)
+	Disk{}.Fail(syscall.EIO)
+	c.Assert(Disk{}.Context().Err(), IsNil)
+}
+
+func benchmarkCreateFile(b *testing.B, policy FsyncPolicy) {
+	path, err := ioutil.TempDir(os.TempDir(), "disk-fsync-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(path)
+	d, perr := New(path)
+	if perr != nil {
+		b.Fatal(perr)
+	}
+	SetFsyncPolicy(policy)
+	defer SetFsyncPolicy(FsyncNever)
+	// a block of a 10MiB stripe spread over 16 disks
+	block := make([]byte, 640*1024)
+	b.SetBytes(int64(len(block)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		f, perr := d.CreateFile(filepath.Join("object"+strconv.Itoa(i), "data"))
+		if perr != nil {
+			b.Fatal(perr)
+		}
+		if _, err := f.Write(block); err != nil {
+			b.Fatal(err)
+		}
+		if err := f.Close(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateFileFsyncAlways(b *testing.B) {
+	benchmarkCreateFile(b, FsyncAlways)
+}
+
+func BenchmarkCreateFileFsyncBatch(b *testing.B) {
+	benchmarkCreateFile(b, FsyncBatch)
+}
+
+func BenchmarkCreateFileFsyncNever(b *testing.B) {
+	benchmarkCreateFile(b, FsyncNever)
+}
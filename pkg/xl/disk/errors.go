@@ -16,6 +16,8 @@
 
 package disk
 
+import "os"
+
 // InvalidArgument invalid argument
 type InvalidArgument struct{}
 
@@ -31,3 +33,42 @@ type UnsupportedFilesystem struct {
 func (e UnsupportedFilesystem) Error() string {
 	return "Unsupported filesystem: " + e.Type
 }
+
+// PathNotFound disk path does not exist
+type PathNotFound struct {
+	Path string
+}
+
+func (e PathNotFound) Error() string {
+	return "Disk path not found: " + e.Path + ", create the directory first"
+}
+
+// PermissionDenied disk path exists but cannot be accessed
+type PermissionDenied struct {
+	Path string
+}
+
+func (e PermissionDenied) Error() string {
+	return "Permission denied: " + e.Path + ", fix the permissions of the disk path"
+}
+
+// Failed disk failed, operations outstanding on it were cancelled
+type Failed struct {
+	Path string
+	Err  error
+}
+
+func (e Failed) Error() string {
+	return "Disk failed: " + e.Path + ", " + e.Err.Error()
+}
+
+// getPathError - tell missing paths from inaccessible ones, other errors are returned as is
+func getPathError(path string, err error) error {
+	switch {
+	case os.IsNotExist(err):
+		return PathNotFound{Path: path}
+	case os.IsPermission(err):
+		return PermissionDenied{Path: path}
+	}
+	return err
+}
@@ -0,0 +1,72 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"context"
+	"os"
+	"sync"
+	"syscall"
+)
+
+// failure - context operations on a disk are bound to, replaced once the disk fails
+type failure struct {
+	sync.Mutex
+	ctx    context.Context
+	cancel context.CancelCauseFunc
+}
+
+func newFailure() *failure {
+	f := &failure{}
+	f.ctx, f.cancel = context.WithCancelCause(context.Background())
+	return f
+}
+
+// Context - context of operations on the disk, cancelled with Failed once the disk fails
+func (disk Disk) Context() context.Context {
+	if disk.failure == nil {
+		return context.Background()
+	}
+	disk.failure.Lock()
+	defer disk.failure.Unlock()
+	return disk.failure.ctx
+}
+
+// Fail - cancel every operation outstanding on the disk, rather than waiting for the disk they
+// fail over to other disks. Operations started afterwards use the disk again
+func (disk Disk) Fail(err error) {
+	if disk.failure == nil {
+		return
+	}
+	disk.failure.Lock()
+	defer disk.failure.Unlock()
+	disk.failure.cancel(Failed{Path: disk.path, Err: err})
+	disk.failure.ctx, disk.failure.cancel = context.WithCancelCause(context.Background())
+}
+
+// IsFailure - errors telling the disk failed rather than the operation, I/O errors of the device,
+// devices which are gone and network filesystems which lost their server
+func IsFailure(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	switch err {
+	case syscall.EIO, syscall.ENXIO, syscall.ENODEV, syscall.ESTALE, syscall.ENOTCONN:
+		return true
+	}
+	return false
+}
@@ -0,0 +1,96 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// FsyncPolicy - when files written to disks are synced to stable storage
+type FsyncPolicy uint32
+
+const (
+	// FsyncNever - leave syncing to the operating system, files written within its writeback
+	// delay, typically 30 seconds, are lost on power failure
+	FsyncNever FsyncPolicy = iota
+	// FsyncBatch - sync disks once per batchSyncInterval, files written within the last
+	// interval are lost on power failure
+	FsyncBatch
+	// FsyncAlways - sync every file and its directory before it is acknowledged
+	FsyncAlways
+)
+
+// batchSyncInterval - how often disks are synced with FsyncBatch
+const batchSyncInterval = time.Second
+
+// internal variable only accessed via get/set methods
+var fsyncPolicy = uint32(FsyncNever)
+
+// SetFsyncPolicy - set when files created on disks from now on are synced
+func SetFsyncPolicy(policy FsyncPolicy) {
+	atomic.StoreUint32(&fsyncPolicy, uint32(policy))
+}
+
+// getFsyncPolicy - get fsync policy
+func getFsyncPolicy() FsyncPolicy {
+	return FsyncPolicy(atomic.LoadUint32(&fsyncPolicy))
+}
+
+// ParseFsyncPolicy - parse ‘always’, ‘batch’ or ‘never’
+func ParseFsyncPolicy(policy string) (FsyncPolicy, *probe.Error) {
+	switch policy {
+	case "always":
+		return FsyncAlways, nil
+	case "batch":
+		return FsyncBatch, nil
+	case "never":
+		return FsyncNever, nil
+	}
+	return 0, probe.NewError(fmt.Errorf("%q is not an fsync policy, use ‘always’, ‘batch’ or ‘never’", policy))
+}
+
+func (policy FsyncPolicy) String() string {
+	switch policy {
+	case FsyncAlways:
+		return "always"
+	case FsyncBatch:
+		return "batch"
+	}
+	return "never"
+}
+
+// batchSyncOnce - the batch syncer is started by the first file created with FsyncBatch
+var batchSyncOnce sync.Once
+
+// startBatchSync - start syncing in the background
+func startBatchSync() {
+	batchSyncOnce.Do(func() { go batchSyncLoop() })
+}
+
+// batchSyncLoop - sync all filesystems every interval, which is cheap when nothing was written
+// since the last one. Individual disks can not be synced on their own portably.
+func batchSyncLoop() {
+	for range time.Tick(batchSyncInterval) {
+		syscall.Sync()
+	}
+}
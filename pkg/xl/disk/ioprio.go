@@ -0,0 +1,111 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// IOClass - I/O scheduling class of a thread on linux
+type IOClass int
+
+// I/O scheduling classes, as ionice(1) names them
+const (
+	IOClassNone IOClass = iota
+	IOClassRealtime
+	IOClassBestEffort
+	IOClassIdle
+)
+
+// ioClassNames - classes ParseIOPriority accepts
+var ioClassNames = map[string]IOClass{
+	"realtime":    IOClassRealtime,
+	"best-effort": IOClassBestEffort,
+	"idle":        IOClassIdle,
+}
+
+const (
+	// ioPriorityClassShift - class is kept above the level, as ioprio_set(2) takes it
+	ioPriorityClassShift = 13
+	// defaultIOPriorityLevel - level of the realtime and best-effort classes when none is given
+	defaultIOPriorityLevel = 4
+)
+
+// IOPriority - I/O scheduling class and level of a thread, the zero value is the priority
+// threads start with, derived from their nice value
+type IOPriority uint16
+
+// NewIOPriority - priority of class at level, 0 being the highest and 7 the lowest. Threads
+// of the idle class have no level
+func NewIOPriority(class IOClass, level int) IOPriority {
+	if class == IOClassIdle || class == IOClassNone {
+		level = 0
+	}
+	return IOPriority(int(class)<<ioPriorityClassShift | level)
+}
+
+// Class - scheduling class of p
+func (p IOPriority) Class() IOClass {
+	return IOClass(p >> ioPriorityClassShift)
+}
+
+// Level - level of p within its class
+func (p IOPriority) Level() int {
+	return int(p & (1<<ioPriorityClassShift - 1))
+}
+
+func (p IOPriority) String() string {
+	for name, class := range ioClassNames {
+		if class != p.Class() {
+			continue
+		}
+		if class == IOClassIdle {
+			return name
+		}
+		return name + ":" + strconv.Itoa(p.Level())
+	}
+	return "none"
+}
+
+// ParseIOPriority - parse a priority e.g. ‘idle’, ‘best-effort:7’ or ‘realtime’, level 4 is
+// used when none is given. An empty priority leaves threads as they are
+func ParseIOPriority(priority string) (IOPriority, *probe.Error) {
+	if priority == "" {
+		return 0, nil
+	}
+	name, level := priority, defaultIOPriorityLevel
+	if i := strings.Index(priority, ":"); i >= 0 {
+		name = priority[:i]
+		value, err := strconv.Atoi(priority[i+1:])
+		if err != nil || value < 0 || value > 7 {
+			return 0, probe.NewError(fmt.Errorf("%q has no level between 0 and 7", priority))
+		}
+		level = value
+	}
+	class, ok := ioClassNames[name]
+	if !ok {
+		return 0, probe.NewError(fmt.Errorf("%q is not one of the classes ‘realtime’, ‘best-effort’ or ‘idle’", priority))
+	}
+	if class == IOClassIdle && name != priority {
+		return 0, probe.NewError(fmt.Errorf("%q has a level, the idle class has none", priority))
+	}
+	return NewIOPriority(class, level), nil
+}
@@ -0,0 +1,45 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"syscall"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// ioPriorityWhoProcess - IOPRIO_WHO_PROCESS, with a pid of 0 the calling thread
+const ioPriorityWhoProcess = 1
+
+// SetThreadIOPriority - set the I/O priority of the calling thread, the goroutine must be
+// locked to it
+func SetThreadIOPriority(priority IOPriority) *probe.Error {
+	_, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_SET, ioPriorityWhoProcess, 0, uintptr(priority))
+	if errno != 0 {
+		return probe.NewError(errno)
+	}
+	return nil
+}
+
+// GetThreadIOPriority - I/O priority of the calling thread, zero if it cannot be read
+func GetThreadIOPriority() IOPriority {
+	priority, _, errno := syscall.RawSyscall(syscall.SYS_IOPRIO_GET, ioPriorityWhoProcess, 0, 0)
+	if errno != 0 {
+		return 0
+	}
+	return IOPriority(priority)
+}
@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package disk
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync/atomic"
+	"syscall"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// DefaultFileMode - blocks and metadata are only accessible to the server
+	DefaultFileMode os.FileMode = 0600
+	// DefaultDirMode - directories are only accessible to the server
+	DefaultDirMode os.FileMode = 0700
+)
+
+// internal variables only accessed via get/set methods
+var (
+	fileMode = uint32(DefaultFileMode)
+	dirMode  = uint32(DefaultDirMode)
+)
+
+// SetPermissions - set permissions of files and directories created on disks from now on,
+// existing ones are left alone
+func SetPermissions(file, dir os.FileMode) {
+	atomic.StoreUint32(&fileMode, uint32(file&os.ModePerm))
+	atomic.StoreUint32(&dirMode, uint32(dir&os.ModePerm))
+}
+
+// getPermissions - get permissions of files and directories created on disks
+func getPermissions() (os.FileMode, os.FileMode) {
+	return os.FileMode(atomic.LoadUint32(&fileMode)), os.FileMode(atomic.LoadUint32(&dirMode))
+}
+
+// ParseMode - parse an octal permission string e.g. ‘0640’, the server itself must keep
+// at least the permissions in required
+func ParseMode(mode string, required os.FileMode) (os.FileMode, *probe.Error) {
+	value, err := strconv.ParseUint(mode, 8, 32)
+	if err != nil || value > uint64(os.ModePerm) {
+		return 0, probe.NewError(fmt.Errorf("%q is not an octal permission between 0000 and 0777", mode))
+	}
+	if os.FileMode(value)&required != required {
+		return 0, probe.NewError(fmt.Errorf("%q does not give the server the permissions %04o it needs", mode, required))
+	}
+	return os.FileMode(value), nil
+}
+
+// mkdirAll - like os.MkdirAll, every directory created gets mode regardless of the umask
+func mkdirAll(path string, mode os.FileMode) error {
+	st, err := os.Stat(path)
+	if err == nil {
+		if !st.IsDir() {
+			return &os.PathError{Op: "mkdir", Path: path, Err: syscall.ENOTDIR}
+		}
+		return nil
+	}
+	if parent := filepath.Dir(path); parent != path {
+		if err := mkdirAll(parent, mode); err != nil {
+			return err
+		}
+	}
+	if err := os.Mkdir(path, mode); err != nil {
+		// created concurrently by another writer
+		if os.IsExist(err) {
+			return nil
+		}
+		return err
+	}
+	return os.Chmod(path, mode)
+}
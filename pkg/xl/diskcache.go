@@ -0,0 +1,284 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"container/list"
+	"crypto/sha256"
+	"encoding/hex"
+	"io"
+	"log"
+	"path/filepath"
+	"sync"
+
+	"github.com/minio/minio-xl/pkg/atomic"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// diskCacheDirName - directory inside the cache dir holding cached objects, removed when an
+// xl is created since objects cached by an earlier run may have been deleted since
+const diskCacheDirName = "xl-cache"
+
+// internal variables only accessed via get/set methods
+var (
+	diskCacheDir    string
+	diskCacheSize   int64
+	diskCacheWrites bool
+)
+
+// SetDiskCache - xls created with New keep recently read objects in dir, usually on a disk
+// faster than the erasure coded disks, least recently used objects are evicted once more than
+// size bytes are cached. With writes objects are cached as they are uploaded, otherwise
+// only when read. An empty dir disables the cache.
+func SetDiskCache(dir string, size int64, writes bool) {
+	if size < 0 {
+		size = 0
+	}
+	diskCacheDir = dir
+	diskCacheSize = size
+	diskCacheWrites = writes
+}
+
+// getDiskCache - get disk cache dir, size and whether writes are cached
+func getDiskCache() (string, int64, bool) {
+	return diskCacheDir, diskCacheSize, diskCacheWrites
+}
+
+// DiskCacheStats - reads served by the disk cache since the xl was created and its usage
+type DiskCacheStats struct {
+	Hits    int64 `json:"hits"`
+	Misses  int64 `json:"misses"`
+	Objects int   `json:"objects"`
+	Bytes   int64 `json:"bytes"`
+}
+
+// diskCacheEntry - cached object, hits must match the current ETag of the object
+type diskCacheEntry struct {
+	objectKey string
+	etag      string
+	size      int64
+}
+
+// diskCache - objects cached in files on a local disk, shared by all copies of an xl
+type diskCache struct {
+	lock    *sync.Mutex
+	disk    disk.Disk
+	size    int64
+	writes  bool
+	used    int64
+	entries *list.List
+	index   map[string]*list.Element
+	hits    int64
+	misses  int64
+}
+
+// newDiskCache - disk cache configured with SetDiskCache, nil if not configured
+func newDiskCache() *diskCache {
+	dir, size, writes := getDiskCache()
+	if dir == "" || size == 0 {
+		return nil
+	}
+	cacheDisk, err := disk.New(dir)
+	if err == nil {
+		err = cacheDisk.RemoveAll(diskCacheDirName)
+	}
+	if err != nil {
+		log.Printf("Disk cache %s disabled: %s", dir, err.ToGoError())
+		return nil
+	}
+	return &diskCache{
+		lock:    &sync.Mutex{},
+		disk:    cacheDisk,
+		size:    size,
+		writes:  writes,
+		entries: list.New(),
+		index:   make(map[string]*list.Element),
+	}
+}
+
+// getDiskCacheFile - name of the file caching an object, inside the cache disk
+func getDiskCacheFile(objectKey string) string {
+	sum := sha256.Sum256([]byte(objectKey))
+	return filepath.Join(diskCacheDirName, hex.EncodeToString(sum[:]))
+}
+
+// GetDiskCacheStats - reads served by the disk cache so far, zero without a disk cache
+func (xl API) GetDiskCacheStats() DiskCacheStats {
+	c := xl.diskCache
+	if c == nil {
+		return DiskCacheStats{}
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	return DiskCacheStats{
+		Hits:    c.hits,
+		Misses:  c.misses,
+		Objects: c.entries.Len(),
+		Bytes:   c.used,
+	}
+}
+
+// read - write length bytes of a cached object from start to w, the whole object for '0'. Objects
+// not cached with the given ETag are a miss, nothing has been written to w then
+func (c *diskCache) read(w io.Writer, objectKey, etag string, start, length int64) (int64, bool, *probe.Error) {
+	if c == nil {
+		return 0, false, nil
+	}
+	c.lock.Lock()
+	element, ok := c.index[objectKey]
+	if ok && element.Value.(*diskCacheEntry).etag != etag {
+		c.remove(element)
+		ok = false
+	}
+	if !ok {
+		c.misses++
+		c.lock.Unlock()
+		return 0, false, nil
+	}
+	c.entries.MoveToFront(element)
+	// an open file stays readable when evicted
+	file, err := c.disk.Open(getDiskCacheFile(objectKey))
+	if err != nil {
+		c.remove(element)
+		c.misses++
+		c.lock.Unlock()
+		return 0, false, nil
+	}
+	c.hits++
+	c.lock.Unlock()
+	defer file.Close()
+
+	if _, e := file.Seek(start, 0); e != nil {
+		return 0, true, probe.NewError(e)
+	}
+	var written int64
+	var e error
+	if length > 0 {
+		written, e = io.CopyN(w, file, length)
+	} else {
+		written, e = io.Copy(w, file)
+	}
+	if e != nil {
+		return written, true, probe.NewError(e)
+	}
+	return written, true, nil
+}
+
+// put - cache an object read in full
+func (c *diskCache) put(objectKey, etag string, data []byte) {
+	writer := c.newWriter(objectKey, int64(len(data)))
+	if writer == nil {
+		return
+	}
+	writer.Write(data)
+	writer.commit(etag)
+}
+
+// newWriter - writer caching an object as it is written, nil for objects larger than the cache
+func (c *diskCache) newWriter(objectKey string, size int64) *diskCacheWriter {
+	if c == nil || size < 0 || size > c.size {
+		return nil
+	}
+	file, err := c.disk.CreateFile(getDiskCacheFile(objectKey))
+	if err != nil {
+		log.Printf("Caching %s failed: %s", objectKey, err.ToGoError())
+		return nil
+	}
+	return &diskCacheWriter{cache: c, file: file, objectKey: objectKey, size: size}
+}
+
+// invalidate - object was created, deleted or moved
+func (c *diskCache) invalidate(objectKey string) {
+	if c == nil {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.index[objectKey]; ok {
+		c.remove(element)
+	}
+}
+
+// add - object was written to its cache file, evict least recently used objects to make room
+func (c *diskCache) add(entry *diskCacheEntry) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if element, ok := c.index[entry.objectKey]; ok {
+		// file was replaced already, forget the entry only
+		c.entries.Remove(element)
+		c.used -= element.Value.(*diskCacheEntry).size
+		delete(c.index, entry.objectKey)
+	}
+	for c.used+entry.size > c.size && c.entries.Len() > 0 {
+		c.remove(c.entries.Back())
+	}
+	c.index[entry.objectKey] = c.entries.PushFront(entry)
+	c.used += entry.size
+}
+
+// remove - drop an object and its file, cache must be locked
+func (c *diskCache) remove(element *list.Element) {
+	entry := element.Value.(*diskCacheEntry)
+	c.entries.Remove(element)
+	c.used -= entry.size
+	delete(c.index, entry.objectKey)
+	if err := c.disk.RemoveAll(getDiskCacheFile(entry.objectKey)); err != nil {
+		log.Printf("Removing cached %s failed: %s", entry.objectKey, err.ToGoError())
+	}
+}
+
+// diskCacheWriter - cache file of an object being written, failures to write the cache file are
+// never reported to the writer of the object, the object is just not cached
+type diskCacheWriter struct {
+	cache     *diskCache
+	file      *atomic.File
+	objectKey string
+	size      int64
+	written   int64
+	failed    bool
+}
+
+func (w *diskCacheWriter) Write(p []byte) (int, error) {
+	if w.failed {
+		return len(p), nil
+	}
+	n, err := w.file.Write(p)
+	w.written += int64(n)
+	if err != nil {
+		w.failed = true
+	}
+	return len(p), nil
+}
+
+// commit - object was written in full with the given ETag
+func (w *diskCacheWriter) commit(etag string) {
+	if w.failed || w.written != w.size {
+		w.abort()
+		return
+	}
+	if err := w.file.Close(); err != nil {
+		log.Printf("Caching %s failed: %s", w.objectKey, err)
+		return
+	}
+	w.cache.add(&diskCacheEntry{objectKey: w.objectKey, etag: etag, size: w.size})
+}
+
+// abort - object was not written, nothing is cached
+func (w *diskCacheWriter) abort() {
+	w.file.CloseAndPurge()
+}
@@ -0,0 +1,129 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/base64"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+// removeBlocks - remove blocks of an object from all disks, only a cached copy remains readable
+func removeBlocks(c *C, root, bucket, object string) {
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "diskcache", bucket, disk, object), "data")), IsNil)
+	}
+}
+
+// readObject - read an object from the disks or the disk cache, never from memory
+func readObject(xlAPI API, bucket, object string, start, length int64) (string, error) {
+	xlAPI.objects.Delete(bucket + "/" + object)
+	var buffer bytes.Buffer
+	if _, err := xlAPI.GetObject(&buffer, bucket, object, start, length); err != nil {
+		return "", err.ToGoError()
+	}
+	return buffer.String(), nil
+}
+
+func (s *MyXLSuite) TestDiskCache(c *C) {
+	root := c.MkDir()
+	cacheDir := c.MkDir()
+	SetDiskCache(cacheDir, 20, false)
+	defer SetDiskCache("", 0, false)
+	xlAPI := newTestXL(c, root, "diskcache", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	for _, object := range []string{"one", "two", "three"} {
+		data := []byte("Hello " + object)
+		_, err := xlAPI.CreateObject("foo", object, "", int64(len(data)), bytes.NewReader(data), nil, nil)
+		c.Assert(err, IsNil)
+	}
+
+	// range reads are not cached, full reads are
+	data, err := readObject(xlAPI, "foo", "one", 6, 2)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "on")
+	data, err = readObject(xlAPI, "foo", "one", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "Hello one")
+	c.Assert(xlAPI.GetDiskCacheStats(), DeepEquals, DiskCacheStats{Misses: 2, Objects: 1, Bytes: 9})
+
+	// hits never touch the blocks on disks
+	removeBlocks(c, root, "foo", "one")
+	data, err = readObject(xlAPI, "foo", "one", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "Hello one")
+	data, err = readObject(xlAPI, "foo", "one", 6, 2)
+	c.Assert(err, IsNil)
+	c.Assert(data, Equals, "on")
+	c.Assert(xlAPI.GetDiskCacheStats(), DeepEquals, DiskCacheStats{Hits: 2, Misses: 2, Objects: 1, Bytes: 9})
+
+	// least recently used object is evicted
+	_, err = readObject(xlAPI, "foo", "two", 0, 0)
+	c.Assert(err, IsNil)
+	_, err = readObject(xlAPI, "foo", "one", 0, 0)
+	c.Assert(err, IsNil)
+	_, err = readObject(xlAPI, "foo", "three", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(xlAPI.GetDiskCacheStats(), DeepEquals, DiskCacheStats{Hits: 3, Misses: 4, Objects: 2, Bytes: 20})
+	removeBlocks(c, root, "foo", "two")
+	_, err = readObject(xlAPI, "foo", "two", 0, 0)
+	c.Assert(err, Not(IsNil))
+
+	// deleted objects are removed from the cache
+	c.Assert(xlAPI.DeleteObject("foo", "three", ""), IsNil)
+	_, err = readObject(xlAPI, "foo", "three", 0, 0)
+	c.Assert(err, Not(IsNil))
+	c.Assert(xlAPI.GetDiskCacheStats().Objects, Equals, 1)
+	files, e := ioutil.ReadDir(filepath.Join(cacheDir, diskCacheDirName))
+	c.Assert(e, IsNil)
+	c.Assert(len(files), Equals, 1)
+}
+
+func (s *MyXLSuite) TestDiskCacheWrites(c *C) {
+	root := c.MkDir()
+	SetDiskCache(c.MkDir(), 1024, true)
+	defer SetDiskCache("", 0, false)
+	xlAPI := newTestXL(c, root, "diskcache", 4)
+	c.Assert(xlAPI.GetDiskCacheStats(), DeepEquals, DiskCacheStats{})
+	c.Assert(xlAPI.MakeBucket("bar", "private", nil, nil), IsNil)
+	data := []byte("Hello World")
+	_, err := xlAPI.CreateObject("bar", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(xlAPI.GetDiskCacheStats(), DeepEquals, DiskCacheStats{Objects: 1, Bytes: 11})
+
+	removeBlocks(c, root, "bar", "object")
+	cached, e := readObject(xlAPI, "bar", "object", 0, 0)
+	c.Assert(e, IsNil)
+	c.Assert(cached, Equals, "Hello World")
+	c.Assert(xlAPI.GetDiskCacheStats().Hits, Equals, int64(1))
+
+	// moved objects are cached only once read under their new name
+	_, err = xlAPI.MoveObject("bar", "object", "moved")
+	c.Assert(err, IsNil)
+	c.Assert(xlAPI.GetDiskCacheStats().Objects, Equals, 0)
+
+	// failed uploads are not cached
+	wrongMD5 := md5.Sum([]byte("other"))
+	_, err = xlAPI.CreateObject("bar", "corrupt", base64.StdEncoding.EncodeToString(wrongMD5[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(xlAPI.GetDiskCacheStats().Objects, Equals, 0)
+}
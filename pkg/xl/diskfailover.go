@@ -0,0 +1,90 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"context"
+	"io"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// internal variable only accessed via get/set methods
+var diskTimeout int64
+
+// SetDiskTimeout - set how long a block read may take before its disk is failed, cancelling every
+// read outstanding on the disk. '0' waits for reads however long they take, disks still fail
+// on I/O errors.
+func SetDiskTimeout(timeout time.Duration) {
+	if timeout < 0 {
+		timeout = 0
+	}
+	atomic.StoreInt64(&diskTimeout, int64(timeout))
+}
+
+// getDiskTimeout - get disk timeout
+func getDiskTimeout() time.Duration {
+	return time.Duration(atomic.LoadInt64(&diskTimeout))
+}
+
+// getDisks - disks by their order, as readers and writers of objects are
+func (b bucket) getDisks() map[int]disk.Disk {
+	disks := make(map[int]disk.Disk)
+	for _, node := range b.nodes {
+		nodeDisks, err := node.ListDisks()
+		if err != nil {
+			continue
+		}
+		for order, d := range nodeDisks {
+			disks[order] = d
+		}
+	}
+	return disks
+}
+
+// readBlock - read a block in full from a file on d. The read is abandoned once d fails, or fails d
+// itself when taking longer than the disk timeout, so it is reconstructed from parity instead of
+// waiting for a disk which may never answer. An abandoned read may still write to block.
+func readBlock(d disk.Disk, reader io.Reader, block []byte) (abandoned bool, err error) {
+	ctx := d.Context()
+	done := make(chan error, 1)
+	goIO(func() {
+		_, err := io.ReadFull(reader, block)
+		done <- err
+	})
+	var timeout <-chan time.Time
+	if t := getDiskTimeout(); t > 0 {
+		timer := time.NewTimer(t)
+		defer timer.Stop()
+		timeout = timer.C
+	}
+	select {
+	case err := <-done:
+		if disk.IsFailure(err) {
+			d.Fail(err)
+		}
+		return false, err
+	case <-ctx.Done():
+		return true, context.Cause(ctx)
+	case <-timeout:
+		err := DiskTimeout{Timeout: getDiskTimeout()}
+		d.Fail(err)
+		return true, err
+	}
+}
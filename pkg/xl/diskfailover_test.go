@@ -0,0 +1,119 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"errors"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"syscall"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// hangDataBlocks - replace the blocks of object on disk 0 with a pipe serving its first stripe,
+// reads of the next stripe hang until release is closed
+func hangDataBlocks(c *C, xlAPI API, root, object string, release <-chan struct{}) {
+	objMetadata, err := xlAPI.getBucket("foo").GetObjectMetadata(object)
+	c.Assert(err, IsNil)
+	encoder, err := newEncoder(objMetadata.DataDisks, objMetadata.ParityDisks)
+	c.Assert(err, IsNil)
+	blockLen, err := encoder.GetEncodedBlockLen(int(objMetadata.BlockSize))
+	c.Assert(err, IsNil)
+
+	path := filepath.Join(testObjectPath(root, "failover", "foo", 0, object), "data")
+	blocks, e := ioutil.ReadFile(path)
+	c.Assert(e, IsNil)
+	c.Assert(os.Remove(path), IsNil)
+	c.Assert(syscall.Mkfifo(path, 0600), IsNil)
+	go func() {
+		pipe, e := os.OpenFile(path, os.O_WRONLY, 0)
+		if e != nil {
+			return
+		}
+		defer pipe.Close()
+		pipe.Write(blocks[:blockLen])
+		<-release
+	}()
+}
+
+func (s *MyXLSuite) TestDiskFailover(c *C) {
+	defer SetDiskTimeout(getDiskTimeout())
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "failover", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	// two stripes
+	data := make([]byte, 11*1024*1024)
+	rand.Read(data)
+	for _, object := range []string{"failed", "timeout"} {
+		_, err := xlAPI.CreateObject("foo", object, "", int64(len(data)), bytes.NewReader(data), nil, nil)
+		c.Assert(err, IsNil)
+	}
+	release := make(chan struct{})
+	defer close(release)
+
+	readObject := func(object string) <-chan []byte {
+		read := make(chan []byte, 1)
+		go func() {
+			reader, _, err := xlAPI.getObject("foo", object)
+			if err != nil {
+				read <- nil
+				return
+			}
+			defer reader.Close()
+			readData, _ := ioutil.ReadAll(reader)
+			read <- readData
+		}()
+		return read
+	}
+
+	// disk fails while a read of its block hangs, the stripe is reconstructed from parity
+	hangDataBlocks(c, xlAPI, root, "failed", release)
+	read := readObject("failed")
+	select {
+	case <-read:
+		c.Fatal("read of a hanging disk completed")
+	case <-time.After(200 * time.Millisecond):
+	}
+	failed := time.Now()
+	disks, err := xlAPI.nodes["localhost"].ListDisks()
+	c.Assert(err, IsNil)
+	disks[0].Fail(errors.New("simulated failure"))
+	select {
+	case readData := <-read:
+		c.Assert(bytes.Equal(readData, data), Equals, true)
+		c.Assert(time.Since(failed) < time.Second, Equals, true)
+	case <-time.After(5 * time.Second):
+		c.Fatal("read did not fail over")
+	}
+
+	// reads taking longer than the disk timeout fail the disk
+	SetDiskTimeout(100 * time.Millisecond)
+	hangDataBlocks(c, xlAPI, root, "timeout", release)
+	started := time.Now()
+	select {
+	case readData := <-readObject("timeout"):
+		c.Assert(bytes.Equal(readData, data), Equals, true)
+		c.Assert(time.Since(started) < time.Second, Equals, true)
+	case <-time.After(5 * time.Second):
+		c.Fatal("read did not time out")
+	}
+}
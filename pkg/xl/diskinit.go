@@ -0,0 +1,140 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"log"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// defaultDiskOpenWorkers number of disks initialized in parallel unless configured
+const defaultDiskOpenWorkers = 8
+
+// internal variable only accessed via get/set methods
+var diskOpenWorkers int32 = defaultDiskOpenWorkers
+
+// SetDiskOpenWorkers - set number of disks initialized in parallel on startup, values
+// smaller than 1 are raised to 1.
+func SetDiskOpenWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	atomic.StoreInt32(&diskOpenWorkers, int32(workers))
+}
+
+// getDiskOpenWorkers - get number of disks initialized in parallel
+func getDiskOpenWorkers() int {
+	return int(atomic.LoadInt32(&diskOpenWorkers))
+}
+
+// internal variable only accessed via get/set methods
+var minOnlineDisks int32
+
+// SetMinOnlineDisks - set number of disks which must be healthy on startup, 0 requires just
+// enough disks to read back objects erasure coded across all disks
+func SetMinOnlineDisks(disks int) {
+	if disks < 0 {
+		disks = 0
+	}
+	atomic.StoreInt32(&minOnlineDisks, int32(disks))
+}
+
+// getMinOnlineDisks - get number of disks which must be healthy on startup
+func getMinOnlineDisks() int {
+	return int(atomic.LoadInt32(&minOnlineDisks))
+}
+
+// DiskQuorumStats disks configured and disks healthy when the xl started
+type DiskQuorumStats struct {
+	Total    int `json:"total"`
+	Online   int `json:"online"`
+	Required int `json:"required"`
+}
+
+// Degraded - started with disks missing, objects are read back from parity
+func (s DiskQuorumStats) Degraded() bool {
+	return s.Online < s.Total
+}
+
+// GetDiskQuorumStats - disks healthy on startup, zero without disks
+func (xl API) GetDiskQuorumStats() DiskQuorumStats {
+	return xl.diskQuorum
+}
+
+// openDisk - open a disk and create the xl directory on it
+func (xl API) openDisk(diskPath string) (disk.Disk, *probe.Error) {
+	newDisk, err := disk.New(diskPath)
+	if err != nil {
+		return disk.Disk{}, err.Trace(diskPath)
+	}
+	if err := newDisk.MakeDir(xl.config.XLName); err != nil {
+		return disk.Disk{}, err.Trace(diskPath)
+	}
+	return newDisk, nil
+}
+
+// openDisks - open disks of a node on a bounded pool of workers, disks which fail are
+// reported and left out, returns disks opened by their order
+func (xl API) openDisks(hostname string, disks []string) map[int]disk.Disk {
+	workers := getDiskOpenWorkers()
+	if workers > len(disks) {
+		workers = len(disks)
+	}
+	var lock sync.Mutex
+	opened := make(map[int]disk.Disk)
+	orders := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for order := range orders {
+				newDisk, err := xl.openDisk(disks[order])
+				if err != nil {
+					log.Printf("Disk %s on %s failed to initialize: %s", disks[order], hostname, err.ToGoError())
+					continue
+				}
+				log.Printf("Disk %s on %s initialized, filesystem: %s", disks[order], hostname, newDisk.GetFSInfo()["FSType"])
+				lock.Lock()
+				opened[order] = newDisk
+				lock.Unlock()
+			}
+		}()
+	}
+	for order := range disks {
+		orders <- order
+	}
+	close(orders)
+	wg.Wait()
+	return opened
+}
+
+// getMinHealthyDisks - minimum number of disks needed to start, enough to read back
+// data blocks of objects erasure coded across all disks unless configured otherwise
+func getMinHealthyDisks(totalDisks int) int {
+	if disks := getMinOnlineDisks(); disks > 0 {
+		if disks > totalDisks {
+			return totalDisks
+		}
+		return disks
+	}
+	return totalDisks/2 + totalDisks%2
+}
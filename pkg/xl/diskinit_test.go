@@ -0,0 +1,95 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestDiskInit(c *C) {
+	defer SetDiskOpenWorkers(defaultDiskOpenWorkers)
+	for _, workers := range []int{0, 1, 3, 16} {
+		SetDiskOpenWorkers(workers)
+
+		// missing disks are left out, remaining disks still hold all data blocks
+		root := c.MkDir()
+		newTestXL(c, root, "diskinit", 8)
+		for disk := 4; disk < 8; disk++ {
+			c.Assert(os.RemoveAll(filepath.Join(root, strconv.Itoa(disk))), IsNil)
+		}
+		xlAPI, err := New()
+		c.Assert(err, IsNil)
+		nodeDiskMap, err := xlAPI.Info()
+		c.Assert(err, IsNil)
+		c.Assert(len(nodeDiskMap["localhost"]), Equals, 4)
+		for _, diskPath := range nodeDiskMap["localhost"] {
+			_, e := os.Stat(filepath.Join(diskPath, "diskinit"))
+			c.Assert(e, IsNil)
+		}
+
+		root = c.MkDir()
+		newTestXL(c, root, "diskinit", 9)
+		for disk := 4; disk < 9; disk++ {
+			c.Assert(os.RemoveAll(filepath.Join(root, strconv.Itoa(disk))), IsNil)
+		}
+		_, err = New()
+		c.Assert(err.ToGoError(), DeepEquals, InsufficientDisks{Healthy: 4, Total: 9, Required: 5})
+	}
+}
+
+func (s *MyXLSuite) TestMinOnlineDisks(c *C) {
+	defer SetMinOnlineDisks(0)
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "diskinit", 8)
+	c.Assert(xlAPI.GetDiskQuorumStats(), DeepEquals, DiskQuorumStats{Total: 8, Online: 8, Required: 4})
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	data := bytes.Repeat([]byte("quorum"), 1000)
+	_, err := xlAPI.CreateObject("foo", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+
+	// two disks lost, not in order
+	c.Assert(os.RemoveAll(filepath.Join(root, "1")), IsNil)
+	c.Assert(os.RemoveAll(filepath.Join(root, "6")), IsNil)
+	SetMinOnlineDisks(7)
+	_, err = New()
+	c.Assert(err.ToGoError(), DeepEquals, InsufficientDisks{Healthy: 6, Total: 8, Required: 7})
+
+	// degraded start, reads are served from parity
+	SetMinOnlineDisks(6)
+	degradedAPI, err := New()
+	c.Assert(err, IsNil)
+	quorum := degradedAPI.GetDiskQuorumStats()
+	c.Assert(quorum, DeepEquals, DiskQuorumStats{Total: 8, Online: 6, Required: 6})
+	c.Assert(quorum.Degraded(), Equals, true)
+	nodeDiskMap, err := degradedAPI.Info()
+	c.Assert(err, IsNil)
+	c.Assert(len(nodeDiskMap["localhost"]), Equals, 6)
+	var buffer bytes.Buffer
+	_, err = degradedAPI.GetObject(&buffer, "foo", "object", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+
+	// more disks than configured are all required
+	SetMinOnlineDisks(16)
+	_, err = New()
+	c.Assert(err.ToGoError(), DeepEquals, InsufficientDisks{Healthy: 6, Total: 8, Required: 8})
+}
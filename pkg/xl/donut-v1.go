@@ -30,6 +30,7 @@ import (
 	"sort"
 	"strconv"
 	"strings"
+	"sync/atomic"
 	"time"
 
 	"github.com/minio/minio-xl/pkg/crypto/sha256"
@@ -62,12 +63,9 @@ func (xl API) makeBucket(bucket string, acl BucketACL) *probe.Error {
 
 // getBucketMetadata - get bucket metadata
 func (xl API) getBucketMetadata(bucketName string) (BucketMetadata, *probe.Error) {
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucketName); err != nil {
 		return BucketMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucketName]; !ok {
-		return BucketMetadata{}, probe.NewError(BucketNotFound{Bucket: bucketName})
-	}
 	metadata, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return BucketMetadata{}, err.Trace()
@@ -85,11 +83,10 @@ func (xl API) setBucketMetadata(bucketName string, bucketMetadata map[string]str
 		return err.Trace()
 	}
 	oldBucketMetadata := metadata.Buckets[bucketName]
-	acl, ok := bucketMetadata["acl"]
-	if !ok {
+	if len(bucketMetadata) == 0 {
 		return probe.NewError(InvalidArgument{})
 	}
-	oldBucketMetadata.ACL = BucketACL(acl)
+	updateBucketMetadata(&oldBucketMetadata, bucketMetadata)
 	metadata.Buckets[bucketName] = oldBucketMetadata
 	return xl.setXLBucketMetadata(metadata)
 }
@@ -113,14 +110,11 @@ func (xl API) listBuckets() (map[string]BucketMetadata, *probe.Error) {
 }
 
 // listObjects - return list of objects
-func (xl API) listObjects(bucket, prefix, marker, delimiter string, maxkeys int) (ListObjectsResults, *probe.Error) {
-	if err := xl.listXLBuckets(); err != nil {
+func (xl API) listObjects(bucket, prefix, marker, delimiter string, depth, maxkeys int) (ListObjectsResults, *probe.Error) {
+	if err := xl.checkBucket(bucket); err != nil {
 		return ListObjectsResults{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return ListObjectsResults{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
-	listObjects, err := xl.buckets[bucket].ListObjects(prefix, marker, delimiter, maxkeys)
+	listObjects, err := xl.getBucket(bucket).ListObjects(prefix, marker, delimiter, depth, maxkeys)
 	if err != nil {
 		return ListObjectsResults{}, err.Trace()
 	}
@@ -135,24 +129,28 @@ func (xl API) putObject(bucket, object, expectedMD5Sum string, reader io.Reader,
 	if object == "" || strings.TrimSpace(object) == "" {
 		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
 	}
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	bucketMeta, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
 	if _, ok := bucketMeta.Buckets[bucket].BucketObjects[object]; ok {
+		if objMetadata, err := xl.getBucket(bucket).GetObjectMetadata(object); err == nil && isLegalHold(objMetadata) {
+			return ObjectMetadata{}, probe.NewError(ObjectLocked{Object: object})
+		}
 		return ObjectMetadata{}, probe.NewError(ObjectExists{Object: object})
 	}
-	objMetadata, err := xl.buckets[bucket].WriteObject(object, reader, size, expectedMD5Sum, metadata, signature)
+	objMetadata, err := xl.getBucket(bucket).WriteObject(object, reader, size, expectedMD5Sum, metadata, signature)
 	if err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
-	bucketMeta.Buckets[bucket].BucketObjects[object] = struct{}{}
+	xl.diskCache.invalidate(bucket + "/" + object)
+	bucketMetadata := bucketMeta.Buckets[bucket]
+	bucketMetadata.BucketObjects[object] = struct{}{}
+	bucketMetadata.ObjectsSize += objMetadata.Size
+	bucketMeta.Buckets[bucket] = bucketMetadata
 	if err := xl.setXLBucketMetadata(bucketMeta); err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
@@ -167,12 +165,9 @@ func (xl API) putObjectPart(bucket, object, expectedMD5Sum, uploadID string, par
 	if object == "" || strings.TrimSpace(object) == "" {
 		return PartMetadata{}, probe.NewError(InvalidArgument{})
 	}
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return PartMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return PartMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	bucketMeta, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return PartMetadata{}, err.Trace()
@@ -184,7 +179,7 @@ func (xl API) putObjectPart(bucket, object, expectedMD5Sum, uploadID string, par
 		return PartMetadata{}, probe.NewError(ObjectExists{Object: object})
 	}
 	objectPart := object + "/" + "multipart" + "/" + strconv.Itoa(partID)
-	objmetadata, err := xl.buckets[bucket].WriteObject(objectPart, reader, size, expectedMD5Sum, metadata, signature)
+	objmetadata, err := xl.getBucket(bucket).WriteObject(objectPart, reader, size, expectedMD5Sum, metadata, signature)
 	if err != nil {
 		return PartMetadata{}, err.Trace()
 	}
@@ -211,23 +206,18 @@ func (xl API) getObject(bucket, object string) (reader io.ReadCloser, size int64
 	if object == "" || strings.TrimSpace(object) == "" {
 		return nil, 0, probe.NewError(InvalidArgument{})
 	}
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return nil, 0, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return nil, 0, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
-	return xl.buckets[bucket].ReadObject(object)
+	return xl.getBucket(bucket).ReadObject(object)
 }
 
 // getObjectMetadata - get object metadata
 func (xl API) getObjectMetadata(bucket, object string) (ObjectMetadata, *probe.Error) {
-	if err := xl.listXLBuckets(); err != nil {
+	atomic.AddInt64(&diskObjectLookups, 1)
+	if err := xl.checkBucket(bucket); err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	bucketMeta, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return ObjectMetadata{}, err.Trace()
@@ -235,21 +225,93 @@ func (xl API) getObjectMetadata(bucket, object string) (ObjectMetadata, *probe.E
 	if _, ok := bucketMeta.Buckets[bucket].BucketObjects[object]; !ok {
 		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: object})
 	}
-	objectMetadata, err := xl.buckets[bucket].GetObjectMetadata(object)
+	objectMetadata, err := xl.getBucket(bucket).GetObjectMetadata(object)
 	if err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
 	return objectMetadata, nil
 }
 
+// deleteObject - delete object of given size
+func (xl API) deleteObject(bucket, object string, size int64) *probe.Error {
+	if err := xl.checkBucket(bucket); err != nil {
+		return err.Trace()
+	}
+	bucketMeta, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return err.Trace()
+	}
+	if _, ok := bucketMeta.Buckets[bucket].BucketObjects[object]; !ok {
+		return probe.NewError(ObjectNotFound{Object: object})
+	}
+	// remove the object from bucket metadata first, a partially removed object is never listed
+	bucketMetadata := bucketMeta.Buckets[bucket]
+	delete(bucketMetadata.BucketObjects, object)
+	bucketMetadata.ObjectsSize -= size
+	bucketMeta.Buckets[bucket] = bucketMetadata
+	if err := xl.setXLBucketMetadata(bucketMeta); err != nil {
+		return err.Trace()
+	}
+	xl.diskCache.invalidate(bucket + "/" + object)
+	return xl.getBucket(bucket).DeleteObject(object)
+}
+
+// setObjectMetadata - set metadata of an object
+func (xl API) setObjectMetadata(bucket, object string, metadata map[string]string) (ObjectMetadata, *probe.Error) {
+	return xl.updateObject(bucket, object, func(objMetadata ObjectMetadata) ObjectMetadata {
+		return updateObjectMetadata(objMetadata, metadata)
+	})
+}
+
+// updateObject - rewrite metadata of an object with update applied
+func (xl API) updateObject(bucket, object string, update func(ObjectMetadata) ObjectMetadata) (ObjectMetadata, *probe.Error) {
+	if err := xl.checkBucket(bucket); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	bucketMeta, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if _, ok := bucketMeta.Buckets[bucket].BucketObjects[object]; !ok {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: object})
+	}
+	return xl.getBucket(bucket).UpdateObject(object, update)
+}
+
+// moveObject - move object to a new name in the same bucket
+func (xl API) moveObject(bucket, sourceObject, object string) (ObjectMetadata, *probe.Error) {
+	if err := xl.checkBucket(bucket); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	bucketMeta, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	if _, ok := bucketMeta.Buckets[bucket].BucketObjects[sourceObject]; !ok {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: sourceObject})
+	}
+	if _, ok := bucketMeta.Buckets[bucket].BucketObjects[object]; ok {
+		return ObjectMetadata{}, probe.NewError(ObjectExists{Object: object})
+	}
+	objMetadata, err := xl.getBucket(bucket).MoveObject(sourceObject, object)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	xl.diskCache.invalidate(bucket + "/" + sourceObject)
+	// old and new names are swapped in a single bucket metadata update
+	delete(bucketMeta.Buckets[bucket].BucketObjects, sourceObject)
+	bucketMeta.Buckets[bucket].BucketObjects[object] = struct{}{}
+	if err := xl.setXLBucketMetadata(bucketMeta); err != nil {
+		return ObjectMetadata{}, err.Trace()
+	}
+	return objMetadata, nil
+}
+
 // newMultipartUpload - new multipart upload request
 func (xl API) newMultipartUpload(bucket, object, contentType string) (string, *probe.Error) {
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return "", err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return "", probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	allbuckets, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return "", err.Trace()
@@ -289,12 +351,9 @@ func (xl API) listObjectParts(bucket, object string, resources ObjectResourcesMe
 	if object == "" || strings.TrimSpace(object) == "" {
 		return ObjectResourcesMetadata{}, probe.NewError(InvalidArgument{})
 	}
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return ObjectResourcesMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return ObjectResourcesMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	allBuckets, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return ObjectResourcesMetadata{}, err.Trace()
@@ -344,12 +403,9 @@ func (xl API) completeMultipartUpload(bucket, object, uploadID string, data io.R
 	if object == "" || strings.TrimSpace(object) == "" {
 		return ObjectMetadata{}, probe.NewError(InvalidArgument{})
 	}
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	allBuckets, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return ObjectMetadata{}, err.Trace()
@@ -390,20 +446,19 @@ func (xl API) completeMultipartUpload(bucket, object, uploadID string, data io.R
 			return ObjectMetadata{}, probe.NewError(InvalidPart{})
 		}
 	}
-	var finalETagBytes []byte
+	var partETags []string
 	var finalSize int64
 	totalParts := strconv.Itoa(bucketMetadata.Multiparts[object].TotalParts)
-	for _, part := range bucketMetadata.Multiparts[object].Parts {
-		partETagBytes, err := hex.DecodeString(part.ETag)
-		if err != nil {
-			return ObjectMetadata{}, probe.NewError(err)
-		}
-		finalETagBytes = append(finalETagBytes, partETagBytes...)
-		finalSize += part.Size
+	for _, part := range parts.Part {
+		partETags = append(partETags, part.ETag)
+		finalSize += bucketMetadata.Multiparts[object].Parts[strconv.Itoa(part.PartNumber)].Size
+	}
+	finalETag, err := getMultipartETag(partETags)
+	if err != nil {
+		return ObjectMetadata{}, err.Trace()
 	}
-	finalETag := hex.EncodeToString(finalETagBytes)
 	objMetadata := ObjectMetadata{}
-	objMetadata.MD5Sum = finalETag + "-" + totalParts
+	objMetadata.MD5Sum = finalETag
 	objMetadata.Object = object
 	objMetadata.Bucket = bucket
 	objMetadata.Size = finalSize
@@ -413,12 +468,9 @@ func (xl API) completeMultipartUpload(bucket, object, uploadID string, data io.R
 
 // listMultipartUploads list all multipart uploads
 func (xl API) listMultipartUploads(bucket string, resources BucketMultipartResourcesMetadata) (BucketMultipartResourcesMetadata, *probe.Error) {
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return BucketMultipartResourcesMetadata{}, err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return BucketMultipartResourcesMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	allbuckets, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return BucketMultipartResourcesMetadata{}, err.Trace()
@@ -471,12 +523,9 @@ func (xl API) listMultipartUploads(bucket string, resources BucketMultipartResou
 
 // abortMultipartUpload - abort a incomplete multipart upload
 func (xl API) abortMultipartUpload(bucket, object, uploadID string) *probe.Error {
-	if err := xl.listXLBuckets(); err != nil {
+	if err := xl.checkBucket(bucket); err != nil {
 		return err.Trace()
 	}
-	if _, ok := xl.buckets[bucket]; !ok {
-		return probe.NewError(BucketNotFound{Bucket: bucket})
-	}
 	allbuckets, err := xl.getXLBucketMetadata()
 	if err != nil {
 		return err.Trace()
@@ -543,7 +592,8 @@ func (xl API) getBucketMetadataReaders() (map[int]io.ReadCloser, *probe.Error) {
 		}
 		readers[order] = bucketMetaDataReader
 	}
-	if err != nil {
+	// disks missing bucket metadata, e.g newly added ones, are skipped, fail only if none of them have it
+	if len(readers) == 0 && err != nil {
 		return nil, err.Trace()
 	}
 	return readers, nil
@@ -602,23 +652,11 @@ func (xl API) makeXLBucket(bucketName, acl string) *probe.Error {
 	if err != nil {
 		return err.Trace()
 	}
-	nodeNumber := 0
 	xl.buckets[bucketName] = bkt
-	for _, node := range xl.nodes {
-		disks := make(map[int]disk.Disk)
-		disks, err = node.ListDisks()
-		if err != nil {
-			return err.Trace()
-		}
-		for order, disk := range disks {
-			bucketSlice := fmt.Sprintf("%s$%d$%d", bucketName, nodeNumber, order)
-			err := disk.MakeDir(filepath.Join(xl.config.XLName, bucketSlice))
-			if err != nil {
-				return err.Trace()
-			}
-		}
-		nodeNumber = nodeNumber + 1
+	if err := xl.makeBucketSlices(bucketName); err != nil {
+		return err.Trace()
 	}
+	xl.bucketCache.set(bucketName)
 	var metadata *AllBuckets
 	metadata, err = xl.getXLBucketMetadata()
 	if err != nil {
@@ -642,6 +680,26 @@ func (xl API) makeXLBucket(bucketName, acl string) *probe.Error {
 	return nil
 }
 
+// makeBucketSlices - make the directories of a bucket on every disk, existing ones are left alone
+func (xl API) makeBucketSlices(bucketName string) *probe.Error {
+	nodeNumber := 0
+	for _, node := range xl.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", bucketName, nodeNumber, order)
+			err := disk.MakeDir(filepath.Join(xl.config.XLName, bucketSlice))
+			if err != nil {
+				return err.Trace()
+			}
+		}
+		nodeNumber = nodeNumber + 1
+	}
+	return nil
+}
+
 // listXLBuckets -
 func (xl API) listXLBuckets() *probe.Error {
 	var disks map[int]disk.Disk
@@ -664,6 +722,9 @@ func (xl API) listXLBuckets() *probe.Error {
 		return err.Trace()
 	}
 	for _, dir := range dirs {
+		if dir.Name() == dedupDir {
+			continue
+		}
 		splitDir := strings.Split(dir.Name(), "$")
 		if len(splitDir) < 3 {
 			return probe.NewError(CorruptedBackend{Backend: dir.Name()})
@@ -19,14 +19,20 @@ package xl
 import (
 	"bytes"
 	"crypto/md5"
+	"crypto/sha256"
 	"encoding/base64"
 	"encoding/hex"
+	"encoding/xml"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"strconv"
+	"sync"
+	"sync/atomic"
 	"testing"
+	"time"
 
+	"github.com/minio/minio-xl/pkg/probe"
 	. "gopkg.in/check.v1"
 )
 
@@ -54,6 +60,47 @@ func createTestNodeDiskMap(p string) map[string][]string {
 	return nodes
 }
 
+// createTestXL - xl named xlName with the first n disks of a 16 disk test node under root, tests
+// other than those of dd create it under their own c.MkDir()
+func createTestXL(root, xlName string, n int) (API, *probe.Error) {
+	nodes := createTestNodeDiskMap(root)
+	nodes["localhost"] = nodes["localhost"][:n]
+	conf := new(Config)
+	conf.Version = "0.0.1"
+	conf.XLName = xlName
+	conf.NodeDiskMap = nodes
+	conf.MaxSize = 100 * 1024 * 1024
+	SetXLConfigPath(filepath.Join(root, "xl.json"))
+	if err := SaveConfig(conf); err != nil {
+		return API{}, err.Trace()
+	}
+	xlAPI, err := New()
+	if err != nil {
+		return API{}, err.Trace()
+	}
+	return xlAPI.(API), nil
+}
+
+// newTestXL - createTestXL for tests
+func newTestXL(c *C, root, xlName string, n int) API {
+	xlAPI, err := createTestXL(root, xlName, n)
+	c.Assert(err, IsNil)
+	return xlAPI
+}
+
+// newTestMemoryXL - xl without disks under root, objects are only kept in memory
+func newTestMemoryXL(c *C, root string) API {
+	SetXLConfigPath(filepath.Join(root, "missing.json"))
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	return xlAPI.(API)
+}
+
+// testObjectPath - path of an object directory on a disk of an xl created by newTestXL
+func testObjectPath(root, xlName, bucket string, disk int, object string) string {
+	return filepath.Join(root, strconv.Itoa(disk), xlName, bucket+"$0$"+strconv.Itoa(disk), object)
+}
+
 var dd Interface
 
 func (s *MyXLSuite) SetUpSuite(c *C) {
@@ -79,6 +126,11 @@ func (s *MyXLSuite) SetUpSuite(c *C) {
 	c.Assert(len(buckets), Equals, 0)
 }
 
+// SetUpTest - tests reading dd back through New() find its config, whichever xl the test before created
+func (s *MyXLSuite) SetUpTest(c *C) {
+	SetXLConfigPath(filepath.Join(s.root, "xl.json"))
+}
+
 func (s *MyXLSuite) TearDownSuite(c *C) {
 	os.RemoveAll(s.root)
 }
@@ -177,6 +229,423 @@ func (s *MyXLSuite) TestNewObjectMetadata(c *C) {
 	c.Assert(objectMetadata.Metadata["contentType"], Equals, "application/json")
 }
 
+// test create object preserves modification time
+func (s *MyXLSuite) TestNewObjectModTime(c *C) {
+	data := "Hello World"
+	reader := ioutil.NopCloser(bytes.NewReader([]byte(data)))
+
+	err := dd.MakeBucket("foo7", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	modTime := time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC)
+	objectMetadata, err := dd.CreateObject("foo7", "obj", "", int64(len(data)), reader, map[string]string{"mtime": modTime.Format(time.RFC3339Nano)}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Created.Equal(modTime), Equals, true)
+
+	objectMetadata, err = dd.GetObjectMetadata("foo7", "obj")
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Created.Equal(modTime), Equals, true)
+}
+
+// test conditional delete
+func (s *MyXLSuite) TestNewObjectConditionalDelete(c *C) {
+	data := "Hello World"
+	err := dd.MakeBucket("foo8", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	objectMetadata, err := dd.CreateObject("foo8", "obj", "", int64(len(data)), bytes.NewReader([]byte(data)), nil, nil)
+	c.Assert(err, IsNil)
+
+	err = dd.DeleteObject("foo8", "obj", "d41d8cd98f00b204e9800998ecf8427e")
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, PreconditionFailed{Object: "obj", ETag: "d41d8cd98f00b204e9800998ecf8427e"})
+
+	err = dd.DeleteObject("foo8", "obj", "\""+objectMetadata.MD5Sum+"\"")
+	c.Assert(err, IsNil)
+
+	_, err = dd.GetObjectMetadata("foo8", "obj")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "obj"})
+	err = dd.DeleteObject("foo8", "obj", objectMetadata.MD5Sum)
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "obj"})
+
+	// deleted objects can be written again
+	_, err = dd.CreateObject("foo8", "obj", "", int64(len(data)), bytes.NewReader([]byte(data)), nil, nil)
+	c.Assert(err, IsNil)
+}
+
+// test conditional delete racing with an overwrite never removes the new object
+func (s *MyXLSuite) TestNewObjectConditionalDeleteRace(c *C) {
+	oldData, newData := "Hello World", "Hello New World"
+	err := dd.MakeBucket("foo9", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	for i := 0; i < 5; i++ {
+		key := "obj" + strconv.Itoa(i)
+		oldMetadata, err := dd.CreateObject("foo9", key, "", int64(len(oldData)), bytes.NewReader([]byte(oldData)), nil, nil)
+		c.Assert(err, IsNil)
+
+		var wg sync.WaitGroup
+		var deleted int32
+		var overwriteErr *probe.Error
+		var overwriterDeleted bool
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			// objects are immutable, an overwrite is a delete followed by a create
+			overwriterDeleted = dd.DeleteObject("foo9", key, "") == nil
+			_, overwriteErr = dd.CreateObject("foo9", key, "", int64(len(newData)), bytes.NewReader([]byte(newData)), nil, nil)
+		}()
+		for j := 0; j < 8; j++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				if dd.DeleteObject("foo9", key, oldMetadata.MD5Sum) == nil {
+					atomic.AddInt32(&deleted, 1)
+				}
+			}()
+		}
+		wg.Wait()
+		c.Assert(overwriteErr, IsNil)
+		// old object is removed exactly once, either by the overwrite or by a conditional delete
+		if overwriterDeleted {
+			c.Assert(deleted, Equals, int32(0))
+		} else {
+			c.Assert(deleted, Equals, int32(1))
+		}
+
+		objectMetadata, err := dd.GetObjectMetadata("foo9", key)
+		c.Assert(err, IsNil)
+		c.Assert(objectMetadata.Size, Equals, int64(len(newData)))
+		var buffer bytes.Buffer
+		_, err = dd.GetObject(&buffer, "foo9", key, 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(buffer.String(), Equals, newData)
+	}
+}
+
+func (s *MyXLSuite) TestOverwriteReadAfterWrite(c *C) {
+	err := dd.MakeBucket("foo15", "private", nil, nil)
+	c.Assert(err, IsNil)
+	// another server on the same disks, reads go straight to the disks
+	SetXLConfigPath(filepath.Join(s.root, "xl.json"))
+	other, err := New()
+	c.Assert(err, IsNil)
+
+	done := make(chan struct{})
+	var readErr *probe.Error
+	var readers sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		readers.Add(1)
+		go func() {
+			defer readers.Done()
+			for {
+				select {
+				case <-done:
+					return
+				default:
+				}
+				var buffer bytes.Buffer
+				if _, err := dd.GetObject(&buffer, "foo15", "object", 0, 0); err != nil {
+					if _, ok := err.ToGoError().(ObjectNotFound); !ok {
+						readErr = err
+						return
+					}
+				}
+			}
+		}()
+	}
+	for i := 0; i < 20; i++ {
+		data := "version " + strconv.Itoa(i)
+		if i > 0 {
+			// objects are immutable, an overwrite is a delete followed by a create
+			c.Assert(dd.DeleteObject("foo15", "object", ""), IsNil)
+		}
+		objectMetadata, err := dd.CreateObject("foo15", "object", "", int64(len(data)), bytes.NewReader([]byte(data)), nil, nil)
+		c.Assert(err, IsNil)
+
+		metadata, err := dd.GetObjectMetadata("foo15", "object")
+		c.Assert(err, IsNil)
+		c.Assert(metadata.MD5Sum, Equals, objectMetadata.MD5Sum)
+		var buffer bytes.Buffer
+		_, err = dd.GetObject(&buffer, "foo15", "object", 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(buffer.String(), Equals, data)
+
+		metadata, err = other.(API).getObjectMetadata("foo15", "object")
+		c.Assert(err, IsNil)
+		c.Assert(metadata.MD5Sum, Equals, objectMetadata.MD5Sum)
+		reader, _, err := other.(API).getObject("foo15", "object")
+		c.Assert(err, IsNil)
+		read, e := ioutil.ReadAll(reader)
+		reader.Close()
+		c.Assert(e, IsNil)
+		c.Assert(string(read), Equals, data)
+	}
+	close(done)
+	readers.Wait()
+	c.Assert(readErr, IsNil)
+}
+
+// test multipart object ETag
+func (s *MyXLSuite) TestMultipartObjectETag(c *C) {
+	err := dd.MakeBucket("foo10", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	uploadID, err := dd.NewMultipartUpload("foo10", "obj", "")
+	c.Assert(err, IsNil)
+	var parts []CompletePart
+	var partMD5Sums []byte
+	for i, data := range []string{"Hello", " World"} {
+		etag, err := dd.CreateObjectPart("foo10", "obj", uploadID, i+1, "", "", int64(len(data)), bytes.NewReader([]byte(data)), nil)
+		c.Assert(err, IsNil)
+		parts = append(parts, CompletePart{PartNumber: i + 1, ETag: etag})
+		partMD5Sum := md5.Sum([]byte(data))
+		partMD5Sums = append(partMD5Sums, partMD5Sum[:]...)
+	}
+	completeBytes, e := xml.Marshal(CompleteMultipartUpload{Part: parts})
+	c.Assert(e, IsNil)
+	objectMetadata, err := dd.CompleteMultipartUpload("foo10", "obj", uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, IsNil)
+
+	multipartMD5Sum := md5.Sum(partMD5Sums)
+	expectedETag := hex.EncodeToString(multipartMD5Sum[:]) + "-2"
+	c.Assert(objectMetadata.MD5Sum, Equals, expectedETag)
+
+	// read back from disk through a fresh xl, data is verified with sha512sum only
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	objectMetadata, err = xlAPI.GetObjectMetadata("foo10", "obj")
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.MD5Sum, Equals, expectedETag)
+
+	reader, _, err := xlAPI.(API).getObject("foo10", "obj")
+	c.Assert(err, IsNil)
+	data, e := ioutil.ReadAll(reader)
+	c.Assert(e, IsNil)
+	c.Assert(string(data), Equals, "Hello World")
+}
+
+// test corrupt staged part fails completion and can be uploaded again
+func (s *MyXLSuite) TestMultipartCorruptPart(c *C) {
+	err := dd.MakeBucket("foo17", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	uploadID, err := dd.NewMultipartUpload("foo17", "obj", "")
+	c.Assert(err, IsNil)
+	var parts []CompletePart
+	for i, data := range []string{"Hello", " World"} {
+		etag, err := dd.CreateObjectPart("foo17", "obj", uploadID, i+1, "", "", int64(len(data)), bytes.NewReader([]byte(data)), nil)
+		c.Assert(err, IsNil)
+		parts = append(parts, CompletePart{PartNumber: i + 1, ETag: etag})
+	}
+	staged, ok := dd.(API).multiPartObjects[uploadID].Get(2)
+	c.Assert(ok, Equals, true)
+	staged[0] = 'w'
+
+	completeBytes, e := xml.Marshal(CompleteMultipartUpload{Part: parts})
+	c.Assert(e, IsNil)
+	_, err = dd.CompleteMultipartUpload("foo17", "obj", uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, CorruptPart{UploadID: uploadID, PartNumber: 2})
+	_, err = dd.GetObjectMetadata("foo17", "obj")
+	c.Assert(err, Not(IsNil))
+
+	// only the corrupt part is uploaded again
+	_, err = dd.CreateObjectPart("foo17", "obj", uploadID, 2, "", "", int64(len(" World")), bytes.NewReader([]byte(" World")), nil)
+	c.Assert(err, IsNil)
+	objectMetadata, err := dd.CompleteMultipartUpload("foo17", "obj", uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Size, Equals, int64(len("Hello World")))
+	var buffer bytes.Buffer
+	_, err = dd.GetObject(&buffer, "foo17", "obj", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "Hello World")
+}
+
+// test additional checksum is verified and persisted
+func (s *MyXLSuite) TestObjectChecksum(c *C) {
+	err := dd.MakeBucket("foo11", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	sha256Sum := sha256.Sum256([]byte("Hello World"))
+	checksum := base64.StdEncoding.EncodeToString(sha256Sum[:])
+	metadata := map[string]string{"checksumAlgorithm": ChecksumSHA256, "checksum": checksum}
+	objectMetadata, err := dd.CreateObject("foo11", "obj", "", int64(len("Hello World")), bytes.NewReader([]byte("Hello World")), metadata, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Metadata["checksumAlgorithm"], Equals, ChecksumSHA256)
+	c.Assert(objectMetadata.Metadata["checksum"], Equals, checksum)
+
+	// checksum is persisted on disk
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	objectMetadata, err = xlAPI.GetObjectMetadata("foo11", "obj")
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Metadata["checksum"], Equals, checksum)
+
+	metadata = map[string]string{"checksumAlgorithm": ChecksumSHA256, "checksum": checksum}
+	_, err = dd.CreateObject("foo11", "mismatch", "", int64(len("Hello World!")), bytes.NewReader([]byte("Hello World!")), metadata, nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, BadDigest{})
+	_, err = dd.GetObjectMetadata("foo11", "mismatch")
+	c.Assert(err, Not(IsNil))
+
+	metadata = map[string]string{"checksumAlgorithm": "MD4"}
+	_, err = dd.CreateObject("foo11", "invalid", "", int64(len("Hello World")), bytes.NewReader([]byte("Hello World")), metadata, nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, InvalidChecksum{Algorithm: "MD4"})
+}
+
+func (s *MyXLSuite) TestObjectListDepth(c *C) {
+	err := dd.MakeBucket("foo14", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	data := []byte("Hello World")
+	for _, object := range []string{"a/b/c/d", "a/b/e", "a/f", "g"} {
+		_, err = dd.CreateObject("foo14", object, "", int64(len(data)), bytes.NewReader(data), nil, nil)
+		c.Assert(err, IsNil)
+	}
+
+	testCases := []struct {
+		depth    int
+		objects  []string
+		prefixes []string
+	}{
+		// standard single level listing
+		{0, []string{"g"}, []string{"a/"}},
+		{1, []string{"g"}, []string{"a/"}},
+		{2, []string{"a/f", "g"}, []string{"a/", "a/b/"}},
+		{3, []string{"a/b/e", "a/f", "g"}, []string{"a/", "a/b/", "a/b/c/"}},
+		{10, []string{"a/b/c/d", "a/b/e", "a/f", "g"}, []string{"a/", "a/b/", "a/b/c/"}},
+	}
+	for _, testCase := range testCases {
+		resources := BucketResourcesMetadata{Delimiter: "/", Depth: testCase.depth, Maxkeys: 10}
+		objectsMetadata, resources, err := dd.ListObjects("foo14", resources)
+		c.Assert(err, IsNil)
+		var objects []string
+		for _, objectMetadata := range objectsMetadata {
+			objects = append(objects, objectMetadata.Object)
+		}
+		c.Assert(objects, DeepEquals, testCase.objects)
+		c.Assert(resources.CommonPrefixes, DeepEquals, testCase.prefixes)
+	}
+
+	// depth applies below the requested prefix
+	resources := BucketResourcesMetadata{Prefix: "a/", Delimiter: "/", Depth: 2, Maxkeys: 10}
+	objectsMetadata, resources, err := dd.ListObjects("foo14", resources)
+	c.Assert(err, IsNil)
+	c.Assert(len(objectsMetadata), Equals, 2)
+	c.Assert(objectsMetadata[0].Object, Equals, "a/b/e")
+	c.Assert(objectsMetadata[1].Object, Equals, "a/f")
+	c.Assert(resources.CommonPrefixes, DeepEquals, []string{"a/b/", "a/b/c/"})
+}
+
+func (s *MyXLSuite) TestMoveObject(c *C) {
+	err := dd.MakeBucket("foo12", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	data := []byte("Hello World")
+	objectMetadata, err := dd.CreateObject("foo12", "old", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	_, err = dd.CreateObject("foo12", "other", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+
+	// reader opened before the move still reads the object in full
+	reader, _, err := dd.(API).getObject("foo12", "old")
+	c.Assert(err, IsNil)
+
+	movedMetadata, err := dd.MoveObject("foo12", "old", "dir/new")
+	c.Assert(err, IsNil)
+	c.Assert(movedMetadata.Object, Equals, "dir/new")
+	c.Assert(movedMetadata.MD5Sum, Equals, objectMetadata.MD5Sum)
+
+	readData, e := ioutil.ReadAll(reader)
+	c.Assert(e, IsNil)
+	c.Assert(readData, DeepEquals, data)
+
+	_, err = dd.GetObjectMetadata("foo12", "old")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "old"})
+
+	// moved object is persisted on disk
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo12", "dir/new", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.Bytes(), DeepEquals, data)
+	objects, _, err := xlAPI.ListObjects("foo12", BucketResourcesMetadata{})
+	c.Assert(err, IsNil)
+	c.Assert(len(objects), Equals, 2)
+	c.Assert(objects[0].Object, Equals, "dir/new")
+	c.Assert(objects[1].Object, Equals, "other")
+
+	_, err = dd.MoveObject("foo12", "dir/new", "other")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectExists{Object: "other"})
+	_, err = dd.MoveObject("foo12", "old", "new")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "old"})
+}
+
+func (s *MyXLSuite) TestObjectLegalHold(c *C) {
+	err := dd.MakeBucket("foo13", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	data := []byte("Hello World")
+	_, err = dd.CreateObject("foo13", "held", "", int64(len(data)), bytes.NewReader(data), map[string]string{"legalHold": LegalHoldOn}, nil)
+	c.Assert(err, IsNil)
+
+	// held objects cannot be deleted, overwritten or moved
+	err = dd.DeleteObject("foo13", "held", "")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectLocked{Object: "held"})
+	_, err = dd.CreateObject("foo13", "held", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err.ToGoError(), DeepEquals, ObjectLocked{Object: "held"})
+	_, err = dd.MoveObject("foo13", "held", "new")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectLocked{Object: "held"})
+
+	// released hold is persisted on disk
+	objectMetadata, err := dd.SetObjectMetadata("foo13", "held", map[string]string{"legalHold": ""})
+	c.Assert(err, IsNil)
+	_, ok := objectMetadata.Metadata["legalHold"]
+	c.Assert(ok, Equals, false)
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	objectMetadata, err = xlAPI.GetObjectMetadata("foo13", "held")
+	c.Assert(err, IsNil)
+	_, ok = objectMetadata.Metadata["legalHold"]
+	c.Assert(ok, Equals, false)
+
+	c.Assert(dd.DeleteObject("foo13", "held", ""), IsNil)
+	_, err = dd.SetObjectMetadata("foo13", "held", map[string]string{"legalHold": LegalHoldOn})
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "held"})
+}
+
+func (s *MyXLSuite) TestTouchObject(c *C) {
+	err := dd.MakeBucket("foo16", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	data := []byte("Hello World")
+	created, err := dd.CreateObject("foo16", "touched", "", int64(len(data)), bytes.NewReader(data), map[string]string{"contentType": "text/plain"}, nil)
+	c.Assert(err, IsNil)
+	time.Sleep(10 * time.Millisecond)
+
+	// only the modification time changes, it is persisted on disk
+	touched, err := dd.TouchObject("foo16", "touched")
+	c.Assert(err, IsNil)
+	c.Assert(touched.Created.After(created.Created), Equals, true)
+	c.Assert(touched.MD5Sum, Equals, created.MD5Sum)
+	c.Assert(touched.Metadata["contentType"], Equals, "text/plain")
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	objectMetadata, err := xlAPI.GetObjectMetadata("foo16", "touched")
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Created.Equal(touched.Created), Equals, true)
+	var buffer bytes.Buffer
+	_, err = dd.GetObject(&buffer, "foo16", "touched", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.Bytes(), DeepEquals, data)
+
+	_, err = dd.TouchObject("foo16", "missing")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "missing"})
+}
+
 // test create object fails without name
 func (s *MyXLSuite) TestNewObjectFailsWithEmptyName(c *C) {
 	_, err := dd.CreateObject("foo", "", "", 0, nil, nil, nil)
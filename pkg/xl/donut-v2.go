@@ -18,9 +18,11 @@ package xl
 
 import (
 	"bytes"
+	"context"
 	"crypto/md5"
 	"encoding/base64"
 	"encoding/hex"
+	"hash"
 	"io"
 	"io/ioutil"
 	"log"
@@ -32,6 +34,7 @@ import (
 	"time"
 
 	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/crypto/sha512"
 	"github.com/minio/minio-xl/pkg/probe"
 	"github.com/minio/minio-xl/pkg/quick"
 	signv4 "github.com/minio/minio-xl/pkg/signature"
@@ -61,6 +64,16 @@ type API struct {
 	storedBuckets    *metadata.Cache
 	nodes            map[string]node
 	buckets          map[string]bucket
+	bucketCache      *bucketCache
+	notFoundCache    *notFoundCache
+	accessTimes      *accessTimes
+	bucketStats      *bucketStats
+	orphanStats      *orphanStats
+	diskCache        *diskCache
+	diskQuorum       DiskQuorumStats
+	metadataIndex    *metadataIndex
+	// ctx - context of the request served, disk operations are traced as its children
+	ctx context.Context
 }
 
 // storedBucket saved bucket
@@ -91,12 +104,20 @@ func New() (Interface, *probe.Error) {
 	a.storedBuckets = metadata.NewCache()
 	a.nodes = make(map[string]node)
 	a.buckets = make(map[string]bucket)
+	a.bucketCache = newBucketCache()
+	a.notFoundCache = newNotFoundCache()
+	a.accessTimes = newAccessTimes()
+	a.bucketStats = newBucketStats()
+	a.orphanStats = new(orphanStats)
+	a.metadataIndex = newMetadataIndex()
 	a.objects = data.NewCache(a.config.MaxSize)
 	a.multiPartObjects = make(map[string]*data.Cache)
 	a.objects.OnEvicted = a.evictedObject
 	a.lock = new(sync.Mutex)
 
 	if len(a.config.NodeDiskMap) > 0 {
+		start := time.Now()
+		var totalDisks, healthyDisks int
 		for k, v := range a.config.NodeDiskMap {
 			if len(v) == 0 {
 				return nil, probe.NewError(InvalidDisksArgument{})
@@ -105,6 +126,16 @@ func New() (Interface, *probe.Error) {
 			if err != nil {
 				return nil, err.Trace()
 			}
+			totalDisks += len(v)
+			healthyDisks += len(a.nodes[k].disks)
+		}
+		log.Printf("Initialized %d of %d disks in %s", healthyDisks, totalDisks, time.Since(start))
+		a.diskQuorum = DiskQuorumStats{Total: totalDisks, Online: healthyDisks, Required: getMinHealthyDisks(totalDisks)}
+		if healthyDisks < a.diskQuorum.Required {
+			return nil, probe.NewError(InsufficientDisks{Healthy: healthyDisks, Total: totalDisks, Required: a.diskQuorum.Required})
+		}
+		if a.diskQuorum.Degraded() {
+			log.Printf("Degraded, %d of %d disks are missing, objects are read back from parity", totalDisks-healthyDisks, totalDisks)
 		}
 		/// Initialization, populate all buckets into memory
 		buckets, err := a.listBuckets()
@@ -118,8 +149,16 @@ func New() (Interface, *probe.Error) {
 			newBucket.multiPartSession = make(map[string]MultiPartSession)
 			newBucket.partMetadata = make(map[string]map[int]PartMetadata)
 			a.storedBuckets.Set(k, newBucket)
+			a.bucketStats.set(k, int64(len(v.BucketObjects)), v.ObjectsSize)
 		}
+		a.diskCache = newDiskCache()
 		a.Heal()
+		if getOrphanExpiry() > 0 {
+			go a.expireOrphansLoop()
+		}
+	}
+	if getMultipartTTL() > 0 {
+		go a.expireMultipartUploadsLoop()
 	}
 	return a, nil
 }
@@ -151,6 +190,24 @@ func (xl API) GetObject(w io.Writer, bucket string, object string, start, length
 	var written int64
 	if !ok {
 		if len(xl.config.NodeDiskMap) > 0 {
+			if xl.notFoundCache.isMissing(objectKey) {
+				return 0, probe.NewError(ObjectNotFound{Object: object})
+			}
+			// cache hits are served without reading, let alone decoding, any blocks
+			var etag string
+			if xl.diskCache != nil {
+				if objMetadata, err := xl.getObjectMetadata(bucket, object); err == nil {
+					etag = objMetadata.MD5Sum
+					written, hit, err := xl.diskCache.read(w, objectKey, etag, start, length)
+					if hit {
+						if err != nil {
+							return 0, err.Trace()
+						}
+						xl.recordAccess(bucket, object)
+						return written, nil
+					}
+				}
+			}
 			reader, size, err := xl.getObject(bucket, object)
 			if err != nil {
 				return 0, err.Trace()
@@ -176,13 +233,20 @@ func (xl API) GetObject(w io.Writer, bucket string, object string, start, length
 					}
 				}
 			}
-			/// cache object read from disk
-			ok := xl.objects.Append(objectKey, pw.writtenBytes)
+			/// cache object read from disk, a range read would cache a truncated object
+			if start == 0 && written == size {
+				if etag != "" {
+					xl.diskCache.put(objectKey, etag, pw.writtenBytes)
+				}
+				ok := xl.objects.Append(objectKey, pw.writtenBytes)
+				if !ok {
+					pw.writtenBytes = nil
+					return 0, probe.NewError(InternalError{})
+				}
+			}
 			pw.writtenBytes = nil
 			go debug.FreeOSMemory()
-			if !ok {
-				return 0, probe.NewError(InternalError{})
-			}
+			xl.recordAccess(bucket, object)
 			return written, nil
 		}
 		return 0, probe.NewError(ObjectNotFound{Object: object})
@@ -193,12 +257,14 @@ func (xl API) GetObject(w io.Writer, bucket string, object string, start, length
 		if err != nil {
 			return 0, probe.NewError(err)
 		}
+		xl.recordAccess(bucket, object)
 		return written, nil
 	}
 	written, err = io.CopyN(w, bytes.NewBuffer(data[start:]), length)
 	if err != nil {
 		return 0, probe.NewError(err)
 	}
+	xl.recordAccess(bucket, object)
 	return written, nil
 }
 
@@ -242,11 +308,54 @@ func (xl API) SetBucketMetadata(bucket string, metadata map[string]string) *prob
 		}
 	}
 	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
-	storedBucket.bucketMetadata.ACL = BucketACL(metadata["acl"])
+	updateBucketMetadata(&storedBucket.bucketMetadata, metadata)
 	xl.storedBuckets.Set(bucket, storedBucket)
+	if !isMetadataIndexed(storedBucket.bucketMetadata) {
+		xl.metadataIndex.drop(bucket)
+	}
 	return nil
 }
 
+// updateObjectMetadata - metadata of an object with the given keys set, an empty value removes
+// the key, user metadata keys match in any case. Metadata of the original object is left untouched
+func updateObjectMetadata(objMetadata ObjectMetadata, metadata map[string]string) ObjectMetadata {
+	newMetadata := make(map[string]string)
+	for key, value := range objMetadata.Metadata {
+		newMetadata[key] = value
+	}
+	for key, value := range metadata {
+		if IsUserMetadata(key) {
+			deleteUserMetadata(newMetadata, key)
+		}
+		if value == "" {
+			delete(newMetadata, key)
+			continue
+		}
+		newMetadata[key] = value
+	}
+	objMetadata.Metadata = newMetadata
+	return objMetadata
+}
+
+// updateBucketMetadata - 'acl' sets the bucket ACL, all other keys are saved as
+// bucket metadata, an empty value removes the key
+func updateBucketMetadata(bucketMetadata *BucketMetadata, metadata map[string]string) {
+	for key, value := range metadata {
+		if key == "acl" {
+			bucketMetadata.ACL = BucketACL(value)
+			continue
+		}
+		if value == "" {
+			delete(bucketMetadata.Metadata, key)
+			continue
+		}
+		if bucketMetadata.Metadata == nil {
+			bucketMetadata.Metadata = make(map[string]string)
+		}
+		bucketMetadata.Metadata[key] = value
+	}
+}
+
 // isMD5SumEqual - returns error if md5sum mismatches, success its `nil`
 func isMD5SumEqual(expectedMD5Sum, actualMD5Sum string) *probe.Error {
 	if strings.TrimSpace(expectedMD5Sum) != "" && strings.TrimSpace(actualMD5Sum) != "" {
@@ -271,8 +380,7 @@ func (xl API) CreateObject(bucket, key, expectedMD5Sum string, size int64, data
 	xl.lock.Lock()
 	defer xl.lock.Unlock()
 
-	contentType := metadata["contentType"]
-	objectMetadata, err := xl.createObject(bucket, key, contentType, expectedMD5Sum, size, data, signature)
+	objectMetadata, err := xl.createObject(bucket, key, expectedMD5Sum, size, data, metadata, signature)
 	// free
 	debug.FreeOSMemory()
 
@@ -280,7 +388,7 @@ func (xl API) CreateObject(bucket, key, expectedMD5Sum string, size int64, data
 }
 
 // createObject - PUT object to cache buffer
-func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size int64, data io.Reader, signature *signv4.Signature) (ObjectMetadata, *probe.Error) {
+func (xl API) createObject(bucket, key, expectedMD5Sum string, size int64, data io.Reader, metadata map[string]string, signature *signv4.Signature) (ObjectMetadata, *probe.Error) {
 	if len(xl.config.NodeDiskMap) == 0 {
 		if size > int64(xl.config.MaxSize) {
 			generic := GenericObjectError{Bucket: bucket, Object: key}
@@ -303,14 +411,14 @@ func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size
 	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
 	// get object key
 	objectKey := bucket + "/" + key
-	if _, ok := storedBucket.objectMetadata[objectKey]; ok == true {
+	if objMetadata, ok := storedBucket.objectMetadata[objectKey]; ok == true {
+		if isLegalHold(objMetadata) {
+			return ObjectMetadata{}, probe.NewError(ObjectLocked{Object: key})
+		}
 		return ObjectMetadata{}, probe.NewError(ObjectExists{Object: key})
 	}
 
-	if contentType == "" {
-		contentType = "application/octet-stream"
-	}
-	contentType = strings.TrimSpace(contentType)
+	contentType := strings.TrimSpace(getContentType(storedBucket.bucketMetadata, metadata))
 	if strings.TrimSpace(expectedMD5Sum) != "" {
 		expectedMD5SumBytes, err := base64.StdEncoding.DecodeString(strings.TrimSpace(expectedMD5Sum))
 		if err != nil {
@@ -319,30 +427,79 @@ func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size
 		}
 		expectedMD5Sum = hex.EncodeToString(expectedMD5SumBytes)
 	}
+	checksum, perr := newObjectChecksum(metadata)
+	if perr != nil {
+		return ObjectMetadata{}, perr.Trace()
+	}
 
 	if len(xl.config.NodeDiskMap) > 0 {
+		diskMetadata := map[string]string{
+			"contentType":   contentType,
+			"contentLength": strconv.FormatInt(size, 10),
+		}
+		if mtime, ok := metadata["mtime"]; ok {
+			diskMetadata["mtime"] = mtime
+		}
+		if etag, ok := metadata["multipartETag"]; ok {
+			diskMetadata["multipartETag"] = etag
+		}
+		if legalHold, ok := metadata["legalHold"]; ok {
+			diskMetadata["legalHold"] = legalHold
+		}
+		if location, ok := metadata["websiteRedirectLocation"]; ok {
+			diskMetadata["websiteRedirectLocation"] = location
+		}
+		setUserMetadata(diskMetadata, metadata)
+		erasureContentType := metadata[ErasureContentTypeKey]
+		if erasureContentType == "" {
+			erasureContentType = contentType
+		}
+		if ratio := getErasureRatio(key, erasureContentType); ratio.Data > 0 {
+			diskMetadata[erasureRatioKey] = ratio.String()
+		}
+		if checksum != nil {
+			diskMetadata["checksumAlgorithm"] = metadata["checksumAlgorithm"]
+			diskMetadata["checksum"] = metadata["checksum"]
+		}
+		var cacheWriter *diskCacheWriter
+		if xl.diskCache != nil && xl.diskCache.writes {
+			if cacheWriter = xl.diskCache.newWriter(objectKey, size); cacheWriter != nil {
+				data = io.TeeReader(data, cacheWriter)
+			}
+		}
 		objMetadata, err := xl.putObject(
 			bucket,
 			key,
 			expectedMD5Sum,
 			data,
 			size,
-			map[string]string{
-				"contentType":   contentType,
-				"contentLength": strconv.FormatInt(size, 10),
-			},
+			diskMetadata,
 			signature,
 		)
 		if err != nil {
+			if cacheWriter != nil {
+				cacheWriter.abort()
+			}
 			return ObjectMetadata{}, err.Trace()
 		}
+		if cacheWriter != nil {
+			cacheWriter.commit(objMetadata.MD5Sum)
+		}
+		xl.notFoundCache.remove(objectKey)
+		xl.bucketStats.add(bucket, 1, objMetadata.Size)
+		xl.metadataIndex.add(bucket, key, objMetadata.Metadata)
 		storedBucket.objectMetadata[objectKey] = objMetadata
 		xl.storedBuckets.Set(bucket, storedBucket)
 		return objMetadata, nil
 	}
 
-	// calculate md5
-	hash := md5.New()
+	// calculate md5, or just the content hash if md5 is not required
+	var hash hash.Hash
+	if isMD5Required(expectedMD5Sum) {
+		hash = md5.New()
+	} else {
+		hash = sha512.New()
+	}
 	sha256hash := sha256.New()
 
 	var err error
@@ -354,6 +511,9 @@ func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size
 		if length != 0 {
 			hash.Write(byteBuffer[0:length])
 			sha256hash.Write(byteBuffer[0:length])
+			if checksum != nil {
+				checksum.Write(byteBuffer[0:length])
+			}
 			ok := xl.objects.Append(objectKey, byteBuffer[0:length])
 			if !ok {
 				return ObjectMetadata{}, probe.NewError(InternalError{})
@@ -370,10 +530,14 @@ func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size
 		}
 	}
 	if err != io.EOF {
+		// data read so far, e.g. parts merged before a failing part of a multipart upload
+		xl.objects.Delete(objectKey)
 		return ObjectMetadata{}, probe.NewError(err)
 	}
-	md5SumBytes := hash.Sum(nil)
-	md5Sum := hex.EncodeToString(md5SumBytes)
+	md5Sum := hex.EncodeToString(hash.Sum(nil))
+	if !isMD5Required(expectedMD5Sum) {
+		md5Sum = getContentETag(hash.Sum(nil))
+	}
 	// Verify if the written object is equal to what is expected, only if it is requested as such
 	if strings.TrimSpace(expectedMD5Sum) != "" {
 		if err := isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), md5Sum); err != nil {
@@ -382,6 +546,23 @@ func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size
 			return ObjectMetadata{}, probe.NewError(BadDigest{})
 		}
 	}
+	algorithm := getETagAlgorithm()
+	if algorithm == ETagSHA256 {
+		md5Sum = hex.EncodeToString(sha256hash.Sum(nil))
+	}
+	var checksumValue string
+	if checksum != nil {
+		checksumValue, perr = checksum.verify()
+		if perr != nil {
+			// Delete perhaps the object is already saved, due to the nature of append()
+			xl.objects.Delete(objectKey)
+			return ObjectMetadata{}, perr.Trace()
+		}
+	}
+	// multipart objects carry the ETag computed from their parts
+	if etag, ok := metadata["multipartETag"]; ok {
+		md5Sum = etag
+	}
 	if signature != nil {
 		ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sha256hash.Sum(nil)))
 		if err != nil {
@@ -398,16 +579,33 @@ func (xl API) createObject(bucket, key, contentType, expectedMD5Sum string, size
 
 	m := make(map[string]string)
 	m["contentType"] = contentType
+	if mtime, ok := metadata["mtime"]; ok {
+		m["mtime"] = mtime
+	}
+	if legalHold, ok := metadata["legalHold"]; ok {
+		m["legalHold"] = legalHold
+	}
+	if location, ok := metadata["websiteRedirectLocation"]; ok {
+		m["websiteRedirectLocation"] = location
+	}
+	setUserMetadata(m, metadata)
+	if checksum != nil {
+		checksum.setMetadata(m, checksumValue)
+	}
 	newObject := ObjectMetadata{
 		Bucket: bucket,
 		Object: key,
 
 		Metadata: m,
-		Created:  time.Now().UTC(),
+		Created:  getModTime(metadata),
 		MD5Sum:   md5Sum,
 		Size:     int64(totalLength),
+
+		ETagAlgorithm: algorithm,
 	}
 
+	xl.bucketStats.add(bucket, 1, newObject.Size)
+	xl.metadataIndex.add(bucket, key, newObject.Metadata)
 	storedBucket.objectMetadata[objectKey] = newObject
 	xl.storedBuckets.Set(bucket, storedBucket)
 	return newObject, nil
@@ -494,6 +692,7 @@ func (xl API) ListObjects(bucket string, resources BucketResourcesMetadata) ([]O
 			resources.Prefix,
 			resources.Marker,
 			resources.Delimiter,
+			resources.Depth,
 			resources.Maxkeys,
 		)
 		if err != nil {
@@ -531,9 +730,9 @@ func (xl API) ListObjects(bucket string, resources BucketResourcesMetadata) ([]O
 	var filteredKeys []string
 	filteredKeys = keys
 	if strings.TrimSpace(resources.Delimiter) != "" {
-		filteredKeys = HasNoDelimiter(keys, resources.Delimiter)
+		filteredKeys = HasNoDelimiterDepth(keys, resources.Delimiter, resources.Depth)
 		prefixes = HasDelimiter(keys, resources.Delimiter)
-		prefixes = SplitDelimiter(prefixes, resources.Delimiter)
+		prefixes = SplitDelimiterDepth(prefixes, resources.Delimiter, resources.Depth)
 		prefixes = SortUnique(prefixes)
 	}
 	for _, commonPrefix := range prefixes {
@@ -610,7 +809,7 @@ func (xl API) GetObjectMetadata(bucket, key string) (ObjectMetadata, *probe.Erro
 		return objMetadata, nil
 	}
 	if len(xl.config.NodeDiskMap) > 0 {
-		objMetadata, err := xl.getObjectMetadata(bucket, key)
+		objMetadata, err := xl.getCachedObjectMetadata(bucket, key)
 		if err != nil {
 			return ObjectMetadata{}, err.Trace()
 		}
@@ -622,6 +821,179 @@ func (xl API) GetObjectMetadata(bucket, key string) (ObjectMetadata, *probe.Erro
 	return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: key})
 }
 
+// DeleteObject - delete object, if expectedETag is set the object is deleted only if its
+// current ETag matches, check and delete happen atomically with respect to other writers
+func (xl API) DeleteObject(bucket, key, expectedETag string) *probe.Error {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(key) {
+		return probe.NewError(ObjectNameInvalid{Object: key})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+	objectKey := bucket + "/" + key
+	objMetadata, ok := storedBucket.objectMetadata[objectKey]
+	if !ok {
+		if len(xl.config.NodeDiskMap) == 0 {
+			return probe.NewError(ObjectNotFound{Object: key})
+		}
+		var err *probe.Error
+		objMetadata, err = xl.getObjectMetadata(bucket, key)
+		if err != nil {
+			return err.Trace()
+		}
+	}
+	if isLegalHold(objMetadata) {
+		return probe.NewError(ObjectLocked{Object: key})
+	}
+	expectedETag = strings.Trim(strings.TrimSpace(expectedETag), "\"")
+	if expectedETag != "" && expectedETag != objMetadata.MD5Sum {
+		return probe.NewError(PreconditionFailed{Object: key, ETag: expectedETag})
+	}
+	if len(xl.config.NodeDiskMap) > 0 {
+		if err := xl.deleteObject(bucket, key, objMetadata.Size); err != nil {
+			return err.Trace()
+		}
+		xl.bucketStats.add(bucket, -1, -objMetadata.Size)
+	}
+	xl.metadataIndex.remove(bucket, key)
+	// without disks the object is gone once its data is, counters are updated on eviction
+	xl.objects.Delete(objectKey)
+	delete(storedBucket.objectMetadata, objectKey)
+	xl.storedBuckets.Set(bucket, storedBucket)
+	return nil
+}
+
+// SetObjectMetadata - set metadata keys of an object, an empty value removes the key
+func (xl API) SetObjectMetadata(bucket, key string, metadata map[string]string) (ObjectMetadata, *probe.Error) {
+	return xl.updateStoredObject(bucket, key, func(objMetadata ObjectMetadata) ObjectMetadata {
+		return updateObjectMetadata(objMetadata, metadata)
+	})
+}
+
+// TouchObject - set the modification time of an object to now, data, ETag and metadata
+// stay the same
+func (xl API) TouchObject(bucket, key string) (ObjectMetadata, *probe.Error) {
+	now := time.Now().UTC()
+	return xl.updateStoredObject(bucket, key, func(objMetadata ObjectMetadata) ObjectMetadata {
+		objMetadata.Created = now
+		return objMetadata
+	})
+}
+
+// updateStoredObject - apply update to the metadata of an object, only metadata is rewritten
+func (xl API) updateStoredObject(bucket, key string, update func(ObjectMetadata) ObjectMetadata) (ObjectMetadata, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(key) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Object: key})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+	objectKey := bucket + "/" + key
+	objMetadata, ok := storedBucket.objectMetadata[objectKey]
+	if len(xl.config.NodeDiskMap) > 0 {
+		var err *probe.Error
+		objMetadata, err = xl.updateObject(bucket, key, update)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+	} else {
+		if !ok {
+			return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: key})
+		}
+		objMetadata = update(objMetadata)
+	}
+	xl.metadataIndex.add(bucket, key, objMetadata.Metadata)
+	if ok {
+		storedBucket.objectMetadata[objectKey] = objMetadata
+		xl.storedBuckets.Set(bucket, storedBucket)
+	}
+	return objMetadata, nil
+}
+
+// MoveObject - move an object to a new name in the same bucket, readers see either the
+// old or the new name, never both
+func (xl API) MoveObject(bucket, sourceKey, key string) (ObjectMetadata, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(sourceKey) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Object: sourceKey})
+	}
+	if !IsValidObjectName(key) {
+		return ObjectMetadata{}, probe.NewError(ObjectNameInvalid{Object: key})
+	}
+	if sourceKey == key {
+		return ObjectMetadata{}, probe.NewError(ObjectExists{Object: key})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return ObjectMetadata{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+	sourceObjectKey := bucket + "/" + sourceKey
+	objectKey := bucket + "/" + key
+	if _, ok := storedBucket.objectMetadata[objectKey]; ok {
+		return ObjectMetadata{}, probe.NewError(ObjectExists{Object: key})
+	}
+	objMetadata, ok := storedBucket.objectMetadata[sourceObjectKey]
+	if !ok {
+		if len(xl.config.NodeDiskMap) == 0 {
+			return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: sourceKey})
+		}
+		var err *probe.Error
+		objMetadata, err = xl.getObjectMetadata(bucket, sourceKey)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+	}
+	// object is removed from its old name
+	if isLegalHold(objMetadata) {
+		return ObjectMetadata{}, probe.NewError(ObjectLocked{Object: sourceKey})
+	}
+	if len(xl.config.NodeDiskMap) > 0 {
+		var err *probe.Error
+		objMetadata, err = xl.moveObject(bucket, sourceKey, key)
+		if err != nil {
+			return ObjectMetadata{}, err.Trace()
+		}
+		xl.notFoundCache.remove(objectKey)
+	} else {
+		objMetadata.Object = key
+	}
+	// cached data follows the object to its new name
+	if data, ok := xl.objects.Get(sourceObjectKey); ok {
+		xl.objects.Delete(sourceObjectKey)
+		if xl.objects.Set(objectKey, data) {
+			storedBucket.objectMetadata[objectKey] = objMetadata
+			if len(xl.config.NodeDiskMap) == 0 {
+				// counted as removed when evicted from its old name
+				xl.bucketStats.add(bucket, 1, objMetadata.Size)
+			}
+		}
+	}
+	delete(storedBucket.objectMetadata, sourceObjectKey)
+	xl.storedBuckets.Set(bucket, storedBucket)
+	xl.metadataIndex.remove(bucket, sourceKey)
+	xl.metadataIndex.add(bucket, key, objMetadata.Metadata)
+	return objMetadata, nil
+}
+
 // evictedObject callback function called when an item is evicted from memory
 func (xl API) evictedObject(a ...interface{}) {
 	cacheStats := xl.objects.Stats()
@@ -630,6 +1002,12 @@ func (xl API) evictedObject(a ...interface{}) {
 	key := a[0].(string)
 	// loop through all buckets
 	for _, bucket := range xl.storedBuckets.GetAll() {
+		objMetadata, ok := bucket.(storedBucket).objectMetadata[key]
+		if ok && len(xl.config.NodeDiskMap) == 0 {
+			// only copy of the object is gone
+			xl.bucketStats.add(objMetadata.Bucket, -1, -objMetadata.Size)
+			xl.metadataIndex.remove(objMetadata.Bucket, objMetadata.Object)
+		}
 		delete(bucket.(storedBucket).objectMetadata, key)
 	}
 	debug.FreeOSMemory()
@@ -25,6 +25,7 @@ import (
 	"os"
 	"path/filepath"
 	"testing"
+	"time"
 
 	. "gopkg.in/check.v1"
 )
@@ -152,6 +153,47 @@ func (s *MyCacheSuite) TestNewObjectMetadata(c *C) {
 	c.Assert(objectMetadata.Metadata["contentType"], Equals, "application/json")
 }
 
+// test create object preserves modification time
+func (s *MyCacheSuite) TestNewObjectModTime(c *C) {
+	data := "Hello World"
+	reader := ioutil.NopCloser(bytes.NewReader([]byte(data)))
+
+	err := dc.MakeBucket("foo7", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	modTime := time.Date(2015, time.October, 21, 7, 28, 0, 0, time.UTC)
+	objectMetadata, err := dc.CreateObject("foo7", "obj", "", int64(len(data)), reader, map[string]string{"mtime": modTime.Format(time.RFC3339Nano)}, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Created.Equal(modTime), Equals, true)
+
+	objectMetadata, err = dc.GetObjectMetadata("foo7", "obj")
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.Created.Equal(modTime), Equals, true)
+}
+
+// test conditional delete
+func (s *MyCacheSuite) TestNewObjectConditionalDelete(c *C) {
+	data := "Hello World"
+	err := dc.MakeBucket("foo8", "private", nil, nil)
+	c.Assert(err, IsNil)
+
+	objectMetadata, err := dc.CreateObject("foo8", "obj", "", int64(len(data)), bytes.NewReader([]byte(data)), nil, nil)
+	c.Assert(err, IsNil)
+
+	err = dc.DeleteObject("foo8", "obj", "d41d8cd98f00b204e9800998ecf8427e")
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, PreconditionFailed{Object: "obj", ETag: "d41d8cd98f00b204e9800998ecf8427e"})
+
+	err = dc.DeleteObject("foo8", "obj", objectMetadata.MD5Sum)
+	c.Assert(err, IsNil)
+
+	_, err = dc.GetObjectMetadata("foo8", "obj")
+	c.Assert(err.ToGoError(), DeepEquals, ObjectNotFound{Object: "obj"})
+	var buffer bytes.Buffer
+	_, err = dc.GetObject(&buffer, "foo8", "obj", 0, 0)
+	c.Assert(err, Not(IsNil))
+}
+
 // test create object fails without name
 func (s *MyCacheSuite) TestNewObjectFailsWithEmptyName(c *C) {
 	_, err := dc.CreateObject("foo", "", "", 0, nil, nil, nil)
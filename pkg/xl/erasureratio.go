@@ -0,0 +1,132 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// ErasureContentTypeKey - metadata key of CreateObject carrying the content type an object was
+// uploaded with, it only selects the erasure ratio and is not stored
+const ErasureContentTypeKey = "erasureContentType"
+
+// erasureRatioKey - metadata key of WriteObject carrying the ratio of the object, not stored
+const erasureRatioKey = "erasureRatio"
+
+// ErasureRatio - data to parity blocks of an object, scaled to the number of disks it is written to
+type ErasureRatio struct {
+	Data   int
+	Parity int
+}
+
+func (r ErasureRatio) String() string {
+	return strconv.Itoa(r.Data) + ":" + strconv.Itoa(r.Parity)
+}
+
+// ErasureRatioRule - objects of a content type or under a key prefix get the ratio
+type ErasureRatioRule struct {
+	ContentType string
+	Prefix      string
+	Ratio       ErasureRatio
+}
+
+// matches - rule applies to an object, ‘type/*’ content types match all subtypes
+func (rule ErasureRatioRule) matches(object, contentType string) bool {
+	if rule.ContentType == "" {
+		return strings.HasPrefix(object, rule.Prefix)
+	}
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.ToLower(strings.TrimSpace(contentType))
+	if strings.HasSuffix(rule.ContentType, "/*") {
+		return strings.HasPrefix(contentType, strings.TrimSuffix(rule.ContentType, "*"))
+	}
+	return contentType == rule.ContentType
+}
+
+// internal variable only accessed via get/set methods
+var erasureRatioRules = struct {
+	sync.RWMutex
+	rules []ErasureRatioRule
+}{}
+
+// SetErasureRatioRules - objects written from now on get the ratio of the first rule they match,
+// others as many data as parity blocks
+func SetErasureRatioRules(rules []ErasureRatioRule) {
+	erasureRatioRules.Lock()
+	defer erasureRatioRules.Unlock()
+	erasureRatioRules.rules = rules
+}
+
+// getErasureRatio - ratio of the first rule matching an object, '0:0' for none
+func getErasureRatio(object, contentType string) ErasureRatio {
+	erasureRatioRules.RLock()
+	defer erasureRatioRules.RUnlock()
+	for _, rule := range erasureRatioRules.rules {
+		if rule.matches(object, contentType) {
+			return rule.Ratio
+		}
+	}
+	return ErasureRatio{}
+}
+
+// ParseErasureRatio - parse ‘DATA:PARITY’, both at least 1
+func ParseErasureRatio(ratio string) (ErasureRatio, *probe.Error) {
+	fields := strings.Split(ratio, ":")
+	if len(fields) == 2 {
+		data, dataErr := strconv.Atoi(strings.TrimSpace(fields[0]))
+		parity, parityErr := strconv.Atoi(strings.TrimSpace(fields[1]))
+		if dataErr == nil && parityErr == nil && data >= 1 && parity >= 1 && data+parity <= 255 {
+			return ErasureRatio{Data: data, Parity: parity}, nil
+		}
+	}
+	return ErasureRatio{}, probe.NewError(fmt.Errorf("%q is not an erasure ratio, use ‘DATA:PARITY’ e.g. ‘12:4’", ratio))
+}
+
+// ParseErasureRatioRules - parse comma separated ‘CONTENT-TYPE=DATA:PARITY’ or
+// ‘prefix:PREFIX=DATA:PARITY’ rules e.g. ‘video/*=12:4,prefix:db/=4:12’
+func ParseErasureRatioRules(rules string) ([]ErasureRatioRule, *probe.Error) {
+	var parsed []ErasureRatioRule
+	for _, field := range strings.Split(rules, ",") {
+		field = strings.TrimSpace(field)
+		if field == "" {
+			continue
+		}
+		i := strings.LastIndex(field, "=")
+		if i <= 0 {
+			return nil, probe.NewError(fmt.Errorf("%q is not an erasure ratio rule, use ‘CONTENT-TYPE=DATA:PARITY’ or ‘prefix:PREFIX=DATA:PARITY’", field))
+		}
+		ratio, err := ParseErasureRatio(field[i+1:])
+		if err != nil {
+			return nil, err.Trace(field)
+		}
+		rule := ErasureRatioRule{Ratio: ratio}
+		if match := field[:i]; strings.HasPrefix(match, "prefix:") {
+			rule.Prefix = strings.TrimPrefix(match, "prefix:")
+		} else {
+			rule.ContentType = strings.ToLower(match)
+		}
+		parsed = append(parsed, rule)
+	}
+	return parsed, nil
+}
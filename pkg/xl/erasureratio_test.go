@@ -0,0 +1,135 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestParseErasureRatioRules(c *C) {
+	rules, err := ParseErasureRatioRules("video/*=12:4, prefix:db/=4:12,Application/JSON=2:1")
+	c.Assert(err, IsNil)
+	c.Assert(rules, DeepEquals, []ErasureRatioRule{
+		{ContentType: "video/*", Ratio: ErasureRatio{Data: 12, Parity: 4}},
+		{Prefix: "db/", Ratio: ErasureRatio{Data: 4, Parity: 12}},
+		{ContentType: "application/json", Ratio: ErasureRatio{Data: 2, Parity: 1}},
+	})
+	empty, err := ParseErasureRatioRules("")
+	c.Assert(err, IsNil)
+	c.Assert(len(empty), Equals, 0)
+	for _, invalid := range []string{"video/*", "=1:1", "video/*=12", "video/*=0:4", "video/*=4:0", "video/*=a:b", "video/*=200:100"} {
+		_, err = ParseErasureRatioRules(invalid)
+		c.Assert(err, Not(IsNil), Commentf("%s", invalid))
+	}
+
+	SetErasureRatioRules(rules)
+	defer SetErasureRatioRules(nil)
+	c.Assert(getErasureRatio("movie.mp4", "video/mp4"), Equals, ErasureRatio{Data: 12, Parity: 4})
+	c.Assert(getErasureRatio("db/movie.mp4", "video/mp4"), Equals, ErasureRatio{Data: 12, Parity: 4})
+	c.Assert(getErasureRatio("db/table", "application/octet-stream"), Equals, ErasureRatio{Data: 4, Parity: 12})
+	c.Assert(getErasureRatio("data.json", "application/json; charset=utf-8"), Equals, ErasureRatio{Data: 2, Parity: 1})
+	c.Assert(getErasureRatio("videos/clip", "videos/mp4"), Equals, ErasureRatio{})
+}
+
+func (s *MyXLSuite) TestGetDataAndParity(c *C) {
+	var b bucket
+	for _, test := range []struct {
+		writers int
+		ratio   ErasureRatio
+		k, m    uint8
+	}{
+		{16, ErasureRatio{}, 8, 8},
+		{5, ErasureRatio{}, 3, 2},
+		{2, ErasureRatio{}, 1, 1},
+		{16, ErasureRatio{Data: 12, Parity: 4}, 12, 4},
+		{16, ErasureRatio{Data: 4, Parity: 12}, 4, 12},
+		{4, ErasureRatio{Data: 12, Parity: 4}, 3, 1},
+		{3, ErasureRatio{Data: 1, Parity: 1}, 2, 1},
+		{16, ErasureRatio{Data: 100, Parity: 1}, 15, 1},
+		{16, ErasureRatio{Data: 1, Parity: 100}, 1, 15},
+	} {
+		k, m, err := b.getDataAndParity(test.writers, test.ratio)
+		c.Assert(err, IsNil)
+		c.Assert([]uint8{k, m}, DeepEquals, []uint8{test.k, test.m}, Commentf("%d %s", test.writers, test.ratio))
+	}
+	_, _, err := b.getDataAndParity(1, ErasureRatio{})
+	c.Assert(err, Not(IsNil))
+	_, _, err = b.getDataAndParity(256, ErasureRatio{})
+	c.Assert(err, Not(IsNil))
+}
+
+func (s *MyXLSuite) TestErasureRatio(c *C) {
+	rules, err := ParseErasureRatioRules("video/*=3:1,prefix:db/=1:3")
+	c.Assert(err, IsNil)
+	SetErasureRatioRules(rules)
+	defer SetErasureRatioRules(nil)
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "ratio", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	data := bytes.Repeat([]byte("ratio"), 1000)
+	for _, test := range []struct {
+		object, contentType string
+		k, m                uint8
+	}{
+		{"clip", "video/mp4", 3, 1},
+		{"db/table", "", 1, 3},
+		{"other", "text/plain", 2, 2},
+	} {
+		metadata := map[string]string{ErasureContentTypeKey: test.contentType}
+		_, err := xlAPI.CreateObject("foo", test.object, "", int64(len(data)), bytes.NewReader(data), metadata, nil)
+		c.Assert(err, IsNil)
+		objMetadata, err := xlAPI.getObjectMetadata("foo", test.object)
+		c.Assert(err, IsNil)
+		c.Assert([]uint8{objMetadata.DataDisks, objMetadata.ParityDisks}, DeepEquals, []uint8{test.k, test.m}, Commentf("%s", test.object))
+		// content type of the upload is not stored
+		c.Assert(objMetadata.Metadata["contentType"], Equals, "application/octet-stream")
+		_, ok := objMetadata.Metadata[ErasureContentTypeKey]
+		c.Assert(ok, Equals, false)
+		_, ok = objMetadata.Metadata[erasureRatioKey]
+		c.Assert(ok, Equals, false)
+	}
+
+	// reads use the ratio of the object, db/table survives three lost blocks
+	for disk := 0; disk < 3; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "ratio", "foo", disk, "db-table"), "data")), IsNil)
+	}
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo", "db/table", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+
+	// multipart uploads get the ratio of the content type they were initiated with
+	uploadID, err := xlAPI.NewMultipartUpload("foo", "movie", "video/webm")
+	c.Assert(err, IsNil)
+	_, err = xlAPI.CreateObjectPart("foo", "movie", uploadID, 1, "", "", int64(len(data)), bytes.NewReader(data), nil)
+	c.Assert(err, IsNil)
+	sum := md5.Sum(data)
+	complete, e := xml.Marshal(CompleteMultipartUpload{Part: []CompletePart{{PartNumber: 1, ETag: hex.EncodeToString(sum[:])}}})
+	c.Assert(e, IsNil)
+	_, err = xlAPI.CompleteMultipartUpload("foo", "movie", uploadID, bytes.NewReader(complete), nil)
+	c.Assert(err, IsNil)
+	objMetadata, err := xlAPI.getObjectMetadata("foo", "movie")
+	c.Assert(err, IsNil)
+	c.Assert([]uint8{objMetadata.DataDisks, objMetadata.ParityDisks}, DeepEquals, []uint8{3, 1})
+}
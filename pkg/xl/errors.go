@@ -16,7 +16,10 @@
 
 package xl
 
-import "fmt"
+import (
+	"fmt"
+	"time"
+)
 
 // InvalidArgument invalid argument
 type InvalidArgument struct{}
@@ -61,6 +64,45 @@ func (e ObjectNotFound) Error() string {
 	return "Object not found: " + e.Object
 }
 
+// PreconditionFailed object does not match the requested precondition
+type PreconditionFailed struct {
+	Object string
+	ETag   string
+}
+
+func (e PreconditionFailed) Error() string {
+	return "Precondition failed, object " + e.Object + " does not match ETag: " + e.ETag
+}
+
+// InsufficientDisks not enough healthy disks to serve objects
+type InsufficientDisks struct {
+	Healthy  int
+	Total    int
+	Required int
+}
+
+func (e InsufficientDisks) Error() string {
+	return fmt.Sprintf("Insufficient disks, only %d of %d disks are healthy, %d required", e.Healthy, e.Total, e.Required)
+}
+
+// ObjectLocked object is under legal hold, cannot be deleted or overwritten
+type ObjectLocked struct {
+	Object string
+}
+
+func (e ObjectLocked) Error() string {
+	return "Object is under legal hold: " + e.Object
+}
+
+// MetadataIndexDisabled metadata of objects in the bucket is not indexed for search
+type MetadataIndexDisabled struct {
+	Bucket string
+}
+
+func (e MetadataIndexDisabled) Error() string {
+	return "Metadata search is not enabled for bucket: " + e.Bucket
+}
+
 // ObjectCorrupted object found to be corrupted
 type ObjectCorrupted struct {
 	Object string
@@ -118,6 +160,15 @@ func (e ParityOverflow) Error() string {
 	return "Parity overflow"
 }
 
+// TooManyMultipartUploads maximum number of open multipart uploads reached
+type TooManyMultipartUploads struct {
+	Max int
+}
+
+func (e TooManyMultipartUploads) Error() string {
+	return fmt.Sprintf("Too many multipart uploads in progress, at most %d are allowed", e.Max)
+}
+
 // ChecksumMismatch checksum mismatch
 type ChecksumMismatch struct{}
 
@@ -278,6 +329,16 @@ func (e InvalidDigest) Error() string {
 	return "Md5 provided " + e.Md5 + " is invalid"
 }
 
+// InvalidChecksum - checksum algorithm not supported or checksum provided is malformed
+type InvalidChecksum struct {
+	Algorithm string
+	Checksum  string
+}
+
+func (e InvalidChecksum) Error() string {
+	return "Checksum provided " + e.Checksum + " for algorithm " + e.Algorithm + " is invalid"
+}
+
 // OperationNotPermitted - operation not permitted
 type OperationNotPermitted struct {
 	Op     string
@@ -298,6 +359,15 @@ func (e InvalidRange) Error() string {
 	return fmt.Sprintf("Invalid range start:%d length:%d", e.Start, e.Length)
 }
 
+// DiskTimeout - a block read on a disk did not complete in time
+type DiskTimeout struct {
+	Timeout time.Duration
+}
+
+func (e DiskTimeout) Error() string {
+	return fmt.Sprintf("Block read did not complete within %s", e.Timeout)
+}
+
 /// Multipart related errors
 
 // InvalidUploadID invalid upload id
@@ -316,6 +386,16 @@ func (e InvalidPart) Error() string {
 	return "One or more of the specified parts could not be found"
 }
 
+// CorruptPart staged part no longer matches the md5sum it was uploaded with
+type CorruptPart struct {
+	UploadID   string
+	PartNumber int
+}
+
+func (e CorruptPart) Error() string {
+	return fmt.Sprintf("Part %d of upload %s is corrupt, upload it again", e.PartNumber, e.UploadID)
+}
+
 // InvalidPartOrder parts are not ordered as Requested
 type InvalidPartOrder struct {
 	UploadID string
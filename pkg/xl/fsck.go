@@ -0,0 +1,535 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// kinds of problems found by fsck
+const (
+	// FsckOrphan - blocks or metadata on disk not referenced by any object
+	FsckOrphan = "orphan"
+	// FsckDangling - object whose blocks are missing on some or all disks
+	FsckDangling = "dangling"
+	// FsckCorrupt - object whose blocks do not match their checksums in its integrity file
+	FsckCorrupt = "corrupt"
+	// FsckMetadata - object whose metadata is unreadable on some or all disks, or does not
+	// match its integrity file
+	FsckMetadata = "metadata"
+)
+
+// FsckConfig - fsck parameters
+type FsckConfig struct {
+	// Fix removes orphans, by default nothing is modified
+	Fix bool
+	// DryRun reports orphans as removed when fixing, without removing them
+	DryRun bool
+	// Progress if set is called for every problem found
+	Progress func(FsckProblem)
+	// MinAge skips orphans modified more recently, they may belong to writes still in progress
+	MinAge time.Duration
+	// Verify reads every block and compares it with its checksum in the integrity file of its object
+	Verify bool
+	// OrphansOnly looks for orphans alone, objects are neither checked nor repaired. Objects may
+	// be written and deleted while it runs, orphans younger than MinAge are left alone
+	OrphansOnly bool
+}
+
+// FsckProblem - an orphan or a dangling object found by fsck
+type FsckProblem struct {
+	Kind   string `json:"kind"`
+	Bucket string `json:"bucket,omitempty"`
+	Object string `json:"object,omitempty"`
+	// Path of an orphan on disk
+	Path string `json:"path,omitempty"`
+	Size int64  `json:"size,omitempty"`
+	// MissingBlocks of a dangling object, unrecoverable once fewer than its data disks are left
+	MissingBlocks int `json:"missingBlocks,omitempty"`
+	// CorruptBlocks of a corrupt object, found only when verifying
+	CorruptBlocks int `json:"corruptBlocks,omitempty"`
+	// DamagedCopies of object metadata, Mismatch if readable metadata does not match the integrity file
+	DamagedCopies int  `json:"damagedCopies,omitempty"`
+	Mismatch      bool `json:"mismatch,omitempty"`
+	Recoverable   bool `json:"recoverable"`
+	Fixed         bool `json:"fixed"`
+}
+
+// FsckSummary - totals of an fsck run
+type FsckSummary struct {
+	Buckets        int   `json:"buckets"`
+	Objects        int   `json:"objects"`
+	Orphans        int   `json:"orphans"`
+	OrphanedBytes  int64 `json:"orphanedBytes"`
+	Dangling       int   `json:"dangling"`
+	Unrecoverable  int   `json:"unrecoverable"`
+	Fixed          int   `json:"fixed"`
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+	// Corrupt and DamagedMetadata objects, Repaired is how many of them were fixed
+	Corrupt         int `json:"corrupt"`
+	DamagedMetadata int `json:"damagedMetadata"`
+	Repaired        int `json:"repaired"`
+}
+
+// fsckState - state shared by all the checks of an fsck run
+type fsckState struct {
+	config  FsckConfig
+	summary FsckSummary
+	// dedupKeys referenced by the objects checked so far
+	dedupKeys map[string]struct{}
+}
+
+// orphan - count and report an orphan at path on disk, it is removed when fixing unless this
+// is a dry run
+func (f *fsckState) orphan(d disk.Disk, problem FsckProblem) *probe.Error {
+	if f.config.MinAge > 0 {
+		// checked right before removing, writes refresh the modification time of their object
+		modTime := getPathModTime(filepath.Join(d.GetPath(), problem.Path))
+		if modTime.IsZero() || time.Since(modTime) < f.config.MinAge {
+			return nil
+		}
+	}
+	f.summary.Orphans++
+	f.summary.OrphanedBytes += problem.Size
+	problem.Kind = FsckOrphan
+	problem.Recoverable = true
+	if f.config.Fix {
+		if !f.config.DryRun {
+			if err := d.RemoveAll(problem.Path); err != nil {
+				return err.Trace(problem.Path)
+			}
+		}
+		problem.Fixed = true
+		f.summary.Fixed++
+		f.summary.ReclaimedBytes += problem.Size
+	}
+	problem.Path = filepath.Join(d.GetPath(), problem.Path)
+	f.progress(problem)
+	return nil
+}
+
+// dangling - count and report a dangling object
+func (f *fsckState) dangling(problem FsckProblem) {
+	f.summary.Dangling++
+	if !problem.Recoverable {
+		f.summary.Unrecoverable++
+	}
+	problem.Kind = FsckDangling
+	f.progress(problem)
+}
+
+// repairable - count and report a corrupt object or damaged metadata, repair is called when
+// fixing unless this is a dry run
+func (f *fsckState) repairable(problem FsckProblem, repair func() *probe.Error) *probe.Error {
+	if problem.Kind == FsckCorrupt {
+		f.summary.Corrupt++
+		if !problem.Recoverable {
+			f.summary.Unrecoverable++
+		}
+	} else {
+		f.summary.DamagedMetadata++
+	}
+	if f.config.Fix && problem.Recoverable {
+		if !f.config.DryRun {
+			if err := repair(); err != nil {
+				return err.Trace(problem.Bucket, problem.Object)
+			}
+		}
+		problem.Fixed = true
+		f.summary.Repaired++
+	}
+	f.progress(problem)
+	return nil
+}
+
+func (f *fsckState) progress(problem FsckProblem) {
+	if f.config.Progress != nil {
+		f.config.Progress(problem)
+	}
+}
+
+// getPathSize - bytes used by a file or all the files below a directory
+func getPathSize(path string) int64 {
+	var size int64
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.Mode().IsRegular() {
+			size += info.Size()
+		}
+		return nil
+	})
+	return size
+}
+
+// getPathModTime - latest modification of a file or of any file or directory below a directory
+func getPathModTime(path string) time.Time {
+	var modTime time.Time
+	filepath.Walk(path, func(_ string, info os.FileInfo, err error) error {
+		if err == nil && info.ModTime().After(modTime) {
+			modTime = info.ModTime()
+		}
+		return nil
+	})
+	return modTime
+}
+
+// fsck - cross check blocks on all disks against object metadata. Orphans are blocks and metadata
+// of objects not listed in their bucket, such as left behind by a crashed upload, and blocks no
+// longer referenced by their object, such as left behind by a crashed rebalance. Dangling objects
+// are listed but have blocks missing, they are reported as unrecoverable if fewer blocks than
+// their data disks are left. Unrecoverable objects are never removed, disks which are offline
+// may still hold their blocks.
+//
+// Object metadata unreadable on some disks is rewritten when fixing from a readable copy, or
+// from the integrity file of the object once no copy is left. Verifying reads every block, blocks
+// not matching their checksums are rebuilt from parity when fixing.
+//
+// Objects written while fsck is running look orphaned, run it while the server is stopped.
+func (xl API) fsck(config FsckConfig) (FsckSummary, *probe.Error) {
+	allBuckets, buckets, err := xl.getFsckBuckets()
+	if err != nil {
+		return FsckSummary{}, err.Trace()
+	}
+	return xl.fsckBuckets(config, allBuckets, buckets)
+}
+
+// getFsckBuckets - bucket listings and buckets an fsck run checks, the only part of it which
+// needs the xl locked when objects are written while it runs
+func (xl API) getFsckBuckets() (*AllBuckets, map[string]bucket, *probe.Error) {
+	if err := xl.listXLBuckets(); err != nil {
+		return nil, nil, err.Trace()
+	}
+	allBuckets, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return nil, nil, err.Trace()
+	}
+	buckets := make(map[string]bucket)
+	for bucketName := range allBuckets.Buckets {
+		bkt, ok := xl.buckets[bucketName]
+		if !ok {
+			return nil, nil, probe.NewError(BucketNotFound{Bucket: bucketName})
+		}
+		buckets[bucketName] = bkt
+	}
+	return allBuckets, buckets, nil
+}
+
+// fsckBuckets - check buckets against their listings in allBuckets
+func (xl API) fsckBuckets(config FsckConfig, allBuckets *AllBuckets, buckets map[string]bucket) (FsckSummary, *probe.Error) {
+	f := &fsckState{config: config, dedupKeys: make(map[string]struct{})}
+	var bucketNames []string
+	for bucketName := range allBuckets.Buckets {
+		bucketNames = append(bucketNames, bucketName)
+	}
+	sort.Strings(bucketNames)
+	for _, bucketName := range bucketNames {
+		if err := buckets[bucketName].fsck(f, allBuckets.Buckets[bucketName]); err != nil {
+			return FsckSummary{}, err.Trace(bucketName)
+		}
+		f.summary.Buckets++
+	}
+	if err := xl.fsckXLDir(f, allBuckets); err != nil {
+		return FsckSummary{}, err.Trace()
+	}
+	return f.summary, nil
+}
+
+// getReferencedObjects - objects of a bucket by their name on disk, parts of ongoing multipart
+// uploads are stored as objects of their own
+func getReferencedObjects(bucketMetadata BucketMetadata) map[string]string {
+	objects := make(map[string]string)
+	for objectName := range bucketMetadata.BucketObjects {
+		objects[normalizeObjectName(objectName)] = objectName
+	}
+	for objectName, session := range bucketMetadata.Multiparts {
+		for partNumber := range session.Parts {
+			partName := objectName + "/" + "multipart" + "/" + partNumber
+			objects[normalizeObjectName(partName)] = partName
+		}
+	}
+	return objects
+}
+
+// fsck - check all objects of a bucket and its slices on every disk
+func (b bucket) fsck(f *fsckState, bucketMetadata BucketMetadata) *probe.Error {
+	objects := getReferencedObjects(bucketMetadata)
+	var objectNames []string
+	for normalizedObjectName := range objects {
+		objectNames = append(objectNames, normalizedObjectName)
+	}
+	sort.Strings(objectNames)
+	objectsMetadata := make(map[string]ObjectMetadata)
+	for _, normalizedObjectName := range objectNames {
+		f.summary.Objects++
+		objectName := objects[normalizedObjectName]
+		// objects written before integrity files were introduced have none
+		integrity, err := b.readObjectIntegrity(normalizedObjectName)
+		hasIntegrity := err == nil
+		objMetadata, err := b.readObjectMetadata(normalizedObjectName)
+		if f.config.OrphansOnly {
+			// enough to tell which blocks and dedup data the object refers to, objects deleted
+			// since they were listed are left alone
+			if err != nil && hasIntegrity {
+				objMetadata, err = integrity.objectMetadata(b.getBucketName(), objectName), nil
+			}
+			if err == nil {
+				objectsMetadata[normalizedObjectName] = objMetadata
+				if objMetadata.DedupKey != "" {
+					f.dedupKeys[objMetadata.DedupKey] = struct{}{}
+				}
+			}
+			continue
+		}
+		if err != nil {
+			if !hasIntegrity {
+				// listed without metadata on any disk
+				f.dangling(FsckProblem{Bucket: b.name, Object: objectName})
+				continue
+			}
+			// user metadata is lost along with the last copy
+			objMetadata = integrity.objectMetadata(b.getBucketName(), objectName)
+		}
+		if err := b.fsckObjectMetadata(f, normalizedObjectName, objectName, objMetadata); err != nil {
+			return err.Trace(normalizedObjectName)
+		}
+		objectsMetadata[normalizedObjectName] = objMetadata
+		if objMetadata.DedupKey != "" {
+			f.dedupKeys[objMetadata.DedupKey] = struct{}{}
+		}
+		if hasIntegrity && !integrity.matches(objMetadata) {
+			// either of them may be the damaged one, blocks are not verified against either
+			if err := f.repairable(FsckProblem{Kind: FsckMetadata, Bucket: b.name, Object: objectName, Mismatch: true}, nil); err != nil {
+				return err.Trace()
+			}
+			hasIntegrity = false
+		}
+		var blocks int
+		totalBlocks := int(objMetadata.DataDisks) + int(objMetadata.ParityDisks)
+		if totalBlocks > 0 {
+			blocks, err = b.countDataBlocks(normalizedObjectName, objMetadata)
+			if err != nil {
+				return err.Trace(normalizedObjectName)
+			}
+			if blocks < totalBlocks {
+				f.dangling(FsckProblem{
+					Bucket:        b.name,
+					Object:        objectName,
+					MissingBlocks: totalBlocks - blocks,
+					Recoverable:   blocks >= int(objMetadata.DataDisks),
+				})
+			}
+		}
+		if f.config.Verify && hasIntegrity {
+			corrupt, err := b.getCorruptBlocks(normalizedObjectName, integrity)
+			if err != nil {
+				return err.Trace(normalizedObjectName)
+			}
+			if len(corrupt) == 0 {
+				continue
+			}
+			// shared blocks of deduplicated objects are never rewritten
+			problem := FsckProblem{
+				Kind:          FsckCorrupt,
+				Bucket:        b.name,
+				Object:        objectName,
+				CorruptBlocks: len(corrupt),
+				Recoverable:   objMetadata.DedupKey == "" && totalBlocks > 0 && blocks-len(corrupt) >= int(objMetadata.DataDisks),
+			}
+			repair := func() *probe.Error {
+				return b.repairObject(normalizedObjectName, objMetadata, corrupt)
+			}
+			if err := f.repairable(problem, repair); err != nil {
+				return err.Trace()
+			}
+		}
+	}
+
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, d := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order)
+			objectDirs, err := d.ListDir(filepath.Join(b.xlName, bucketSlice))
+			if err != nil {
+				// disk was added after the bucket was made
+				continue
+			}
+			for _, objectDir := range objectDirs {
+				objectPath := filepath.Join(b.xlName, bucketSlice, objectDir.Name())
+				objMetadata, ok := objectsMetadata[objectDir.Name()]
+				if _, listed := objects[objectDir.Name()]; !listed {
+					problem := FsckProblem{Bucket: b.name, Path: objectPath, Size: getPathSize(filepath.Join(d.GetPath(), objectPath))}
+					if err := f.orphan(d, problem); err != nil {
+						return err.Trace()
+					}
+					continue
+				}
+				if !ok {
+					continue
+				}
+				if err := f.fsckObjectDir(d, b.name, objects[objectDir.Name()], objectPath, objMetadata); err != nil {
+					return err.Trace()
+				}
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return nil
+}
+
+// fsckObjectDir - blocks in an object directory other than the ones its metadata refers to are
+// orphans, as are unfinished atomic writes
+func (f *fsckState) fsckObjectDir(d disk.Disk, bucketName, objectName, objectPath string, objMetadata ObjectMetadata) *probe.Error {
+	files, err := d.ListFiles(objectPath)
+	if err != nil {
+		if f.config.OrphansOnly && os.IsNotExist(err.ToGoError()) {
+			// deleted since it was listed
+			return nil
+		}
+		return err.Trace(objectPath)
+	}
+	for _, file := range files {
+		if file.Name() == objectMetadataConfig || file.Name() == objectIntegrityConfig {
+			continue
+		}
+		// blocks shared through the dedup store are not kept with the object
+		if objMetadata.DedupKey == "" && file.Name() == getDataFile(objMetadata) {
+			continue
+		}
+		problem := FsckProblem{
+			Bucket: bucketName,
+			Object: objectName,
+			Path:   filepath.Join(objectPath, file.Name()),
+			Size:   file.Size(),
+		}
+		if err := f.orphan(d, problem); err != nil {
+			return err.Trace()
+		}
+	}
+	return nil
+}
+
+// fsckObjectMetadata - report copies of object metadata which are missing or unreadable on
+// disks holding the object, they are rewritten from objMetadata when fixing
+func (b bucket) fsckObjectMetadata(f *fsckState, normalizedObjectName, objectName string, objMetadata ObjectMetadata) *probe.Error {
+	damaged, err := b.getDamagedMetadata(normalizedObjectName)
+	if err != nil {
+		return err.Trace()
+	}
+	if damaged == 0 {
+		return nil
+	}
+	problem := FsckProblem{
+		Kind:          FsckMetadata,
+		Bucket:        b.name,
+		Object:        objectName,
+		DamagedCopies: damaged,
+		Recoverable:   true,
+	}
+	return f.repairable(problem, func() *probe.Error {
+		return b.writeObjectMetadata(normalizedObjectName, objMetadata)
+	})
+}
+
+// countDataBlocks - number of disks holding blocks of an object
+func (b bucket) countDataBlocks(objectName string, objMetadata ObjectMetadata) (int, *probe.Error) {
+	var blocks int
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return 0, err.Trace()
+		}
+		for order, d := range disks {
+			dataPath := filepath.Join(b.xlName, fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order), objectName, getDataFile(objMetadata))
+			if objMetadata.DedupKey != "" {
+				dataPath = b.getDedupPath(nodeSlice, order, objMetadata.DedupKey, "data")
+			}
+			if _, e := os.Stat(filepath.Join(d.GetPath(), dataPath)); e == nil {
+				blocks++
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return blocks, nil
+}
+
+// fsckXLDir - slices of buckets which are not listed and unreferenced dedup data are orphans
+func (xl API) fsckXLDir(f *fsckState, allBuckets *AllBuckets) *probe.Error {
+	nodeSlice := 0
+	for _, node := range xl.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, d := range disks {
+			dirs, err := d.ListDir(xl.config.XLName)
+			if err != nil {
+				continue
+			}
+			for _, dir := range dirs {
+				if dir.Name() == dedupDir {
+					continue
+				}
+				slice := strings.Split(dir.Name(), "$")
+				if len(slice) != 3 {
+					continue
+				}
+				if _, ok := allBuckets.Buckets[slice[0]]; ok {
+					continue
+				}
+				slicePath := filepath.Join(xl.config.XLName, dir.Name())
+				problem := FsckProblem{Bucket: slice[0], Path: slicePath, Size: getPathSize(filepath.Join(d.GetPath(), slicePath))}
+				if err := f.orphan(d, problem); err != nil {
+					return err.Trace()
+				}
+			}
+			dedupPath := filepath.Join(xl.config.XLName, dedupDir, fmt.Sprintf("%d$%d", nodeSlice, order))
+			dedupEntries, err := d.ListDir(dedupPath)
+			if err != nil {
+				continue
+			}
+			for _, dedupEntry := range dedupEntries {
+				if _, ok := f.dedupKeys[dedupEntry.Name()]; ok {
+					continue
+				}
+				entryPath := filepath.Join(dedupPath, dedupEntry.Name())
+				problem := FsckProblem{Path: entryPath, Size: getPathSize(filepath.Join(d.GetPath(), entryPath))}
+				// objects referring to dedup data again rewrite its reference count under the
+				// same lock, which keeps it from expiring
+				dedupLock.Lock()
+				err := f.orphan(d, problem)
+				dedupLock.Unlock()
+				if err != nil {
+					return err.Trace()
+				}
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return nil
+}
@@ -0,0 +1,123 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestFsck(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "fsck", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	for i := 0; i < 3; i++ {
+		key := "obj" + strconv.Itoa(i)
+		data := bytes.Repeat([]byte(key), 1000)
+		_, err := xlAPI.CreateObject("foo", key, "", int64(len(data)), bytes.NewReader(data), nil, nil)
+		c.Assert(err, IsNil)
+	}
+
+	var problems []FsckProblem
+	progress := func(p FsckProblem) { problems = append(problems, p) }
+	summary, err := xlAPI.Fsck(FsckConfig{Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 0)
+	c.Assert(summary, DeepEquals, FsckSummary{Buckets: 1, Objects: 3})
+
+	// blocks of an upload which never made it to the bucket
+	c.Assert(os.MkdirAll(testObjectPath(root, "fsck", "foo", 0, "crashed"), 0700), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(testObjectPath(root, "fsck", "foo", 0, "crashed"), "data"), []byte("crashed"), 0600), IsNil)
+	// blocks left behind by an interrupted rebalance
+	c.Assert(ioutil.WriteFile(filepath.Join(testObjectPath(root, "fsck", "foo", 1, "obj0"), "data.8"), []byte("stale"), 0600), IsNil)
+	// one block lost, object is still readable
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "fsck", "foo", 2, "obj1"), "data")), IsNil)
+	// three blocks lost, more than the parity
+	for disk := 0; disk < 3; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "fsck", "foo", disk, "obj2"), "data")), IsNil)
+	}
+
+	// read only by default
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 4)
+	c.Assert(problems[0].Kind, Equals, FsckDangling)
+	c.Assert(problems[0].Object, Equals, "obj1")
+	c.Assert(problems[0].MissingBlocks, Equals, 1)
+	c.Assert(problems[0].Recoverable, Equals, true)
+	c.Assert(problems[1].Kind, Equals, FsckDangling)
+	c.Assert(problems[1].Object, Equals, "obj2")
+	c.Assert(problems[1].MissingBlocks, Equals, 3)
+	c.Assert(problems[1].Recoverable, Equals, false)
+	// disks are not checked in any particular order
+	orphans := make(map[string]FsckProblem)
+	for _, problem := range problems[2:] {
+		c.Assert(problem.Kind, Equals, FsckOrphan)
+		c.Assert(problem.Fixed, Equals, false)
+		orphans[problem.Path] = problem
+	}
+	c.Assert(orphans[testObjectPath(root, "fsck", "foo", 0, "crashed")].Size, Equals, int64(7))
+	c.Assert(orphans[filepath.Join(testObjectPath(root, "fsck", "foo", 1, "obj0"), "data.8")].Object, Equals, "obj0")
+	c.Assert(summary, DeepEquals, FsckSummary{Buckets: 1, Objects: 3, Orphans: 2, OrphanedBytes: 12, Dangling: 2, Unrecoverable: 1})
+	_, e := os.Stat(testObjectPath(root, "fsck", "foo", 0, "crashed"))
+	c.Assert(e, IsNil)
+
+	// dry run reports orphans exactly as fixing does, nothing is removed
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Fix: true, DryRun: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 4)
+	c.Assert(problems[2].Fixed && problems[3].Fixed, Equals, true)
+	c.Assert(summary, DeepEquals, FsckSummary{Buckets: 1, Objects: 3, Orphans: 2, OrphanedBytes: 12, Dangling: 2, Unrecoverable: 1,
+		Fixed: 2, ReclaimedBytes: 12})
+	_, e = os.Stat(testObjectPath(root, "fsck", "foo", 0, "crashed"))
+	c.Assert(e, IsNil)
+	_, e = os.Stat(filepath.Join(testObjectPath(root, "fsck", "foo", 1, "obj0"), "data.8"))
+	c.Assert(e, IsNil)
+
+	// orphans are removed, dangling objects are left alone
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Fix: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 4)
+	c.Assert(problems[2].Fixed && problems[3].Fixed, Equals, true)
+	c.Assert(summary.Fixed, Equals, 2)
+	c.Assert(summary.ReclaimedBytes, Equals, int64(12))
+	_, e = os.Stat(testObjectPath(root, "fsck", "foo", 0, "crashed"))
+	c.Assert(os.IsNotExist(e), Equals, true)
+	_, e = os.Stat(filepath.Join(testObjectPath(root, "fsck", "foo", 1, "obj0"), "data.8"))
+	c.Assert(os.IsNotExist(e), Equals, true)
+	_, e = os.Stat(filepath.Join(testObjectPath(root, "fsck", "foo", 3, "obj2"), "data"))
+	c.Assert(e, IsNil)
+
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo", "obj0", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), bytes.Repeat([]byte("obj0"), 1000)), Equals, true)
+
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 2)
+	c.Assert(summary.Orphans, Equals, 0)
+}
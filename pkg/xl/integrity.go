@@ -0,0 +1,309 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"hash"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	objectIntegrityConfig  = "integrity.json"
+	objectIntegrityVersion = "1.0.0"
+)
+
+// ObjectIntegrity - checksums of an object kept in a file of its own next to object metadata,
+// object metadata damaged on every disk is restored from it along with its erasure parameters
+type ObjectIntegrity struct {
+	Version string    `json:"version"`
+	Created time.Time `json:"created"`
+	Size    int64     `json:"size"`
+
+	// erasure
+	DataDisks   uint8  `json:"erasureK"`
+	ParityDisks uint8  `json:"erasureM"`
+	BlockSize   int    `json:"blockSize"`
+	ChunkCount  int    `json:"chunkCount"`
+	DataFile    string `json:"dataFile,omitempty"`
+	DedupKey    string `json:"dedupKey,omitempty"`
+
+	// checksums of the whole object
+	MD5Sum        string        `json:"md5sum"`
+	SHA512Sum     string        `json:"sha512sum"`
+	ETagAlgorithm ETagAlgorithm `json:"etagAlgorithm,omitempty"`
+
+	// Blocks - sha512sum of the data file on every disk by its order, disks which went offline
+	// while the object was written are missing
+	Blocks map[int]string `json:"blocks"`
+}
+
+// newObjectIntegrity - integrity of an object written with the given block checksums
+func newObjectIntegrity(objMetadata ObjectMetadata, blocks map[int]string) ObjectIntegrity {
+	return ObjectIntegrity{
+		Version:     objectIntegrityVersion,
+		Created:     objMetadata.Created,
+		Size:        objMetadata.Size,
+		DataDisks:   objMetadata.DataDisks,
+		ParityDisks: objMetadata.ParityDisks,
+		BlockSize:   objMetadata.BlockSize,
+		ChunkCount:  objMetadata.ChunkCount,
+		DataFile:    objMetadata.DataFile,
+		DedupKey:    objMetadata.DedupKey,
+		MD5Sum:      objMetadata.MD5Sum,
+		SHA512Sum:   objMetadata.SHA512Sum,
+		Blocks:      blocks,
+
+		ETagAlgorithm: objMetadata.ETagAlgorithm,
+	}
+}
+
+// matches - does integrity describe the data objMetadata refers to
+func (i ObjectIntegrity) matches(objMetadata ObjectMetadata) bool {
+	return i.Size == objMetadata.Size && i.SHA512Sum == objMetadata.SHA512Sum &&
+		i.DataFile == objMetadata.DataFile && i.DedupKey == objMetadata.DedupKey
+}
+
+// objectMetadata - object metadata restored from integrity, user metadata is lost
+func (i ObjectIntegrity) objectMetadata(bucket, object string) ObjectMetadata {
+	return ObjectMetadata{
+		Version:     objectMetadataVersion,
+		Created:     i.Created,
+		Bucket:      bucket,
+		Object:      object,
+		Size:        i.Size,
+		DataDisks:   i.DataDisks,
+		ParityDisks: i.ParityDisks,
+		BlockSize:   i.BlockSize,
+		ChunkCount:  i.ChunkCount,
+		DataFile:    i.DataFile,
+		DedupKey:    i.DedupKey,
+		MD5Sum:      i.MD5Sum,
+		SHA512Sum:   i.SHA512Sum,
+		Metadata:    make(map[string]string),
+
+		ETagAlgorithm: i.ETagAlgorithm,
+	}
+}
+
+// blockChecksumWriter - writer of a data file computing the checksum of everything written
+type blockChecksumWriter struct {
+	io.WriteCloser
+	hash hash.Hash
+}
+
+func (w *blockChecksumWriter) Write(p []byte) (int, error) {
+	n, err := w.WriteCloser.Write(p)
+	w.hash.Write(p[:n])
+	return n, err
+}
+
+// CloseAndPurge - purge the underlying file
+func (w *blockChecksumWriter) CloseAndPurge() error {
+	if file, ok := w.WriteCloser.(purger); ok {
+		return file.CloseAndPurge()
+	}
+	return w.WriteCloser.Close()
+}
+
+// SyncOnClose - sync the underlying file on close
+func (w *blockChecksumWriter) SyncOnClose() {
+	if file, ok := w.WriteCloser.(syncer); ok {
+		file.SyncOnClose()
+	}
+}
+
+// newBlockChecksumWriters - wrap data file writers, checksums are read with getBlockChecksums
+func newBlockChecksumWriters(writers []io.WriteCloser) []io.WriteCloser {
+	checksumWriters := make([]io.WriteCloser, len(writers))
+	for order, writer := range writers {
+		checksumWriters[order] = &blockChecksumWriter{WriteCloser: writer, hash: sha512.New()}
+	}
+	return checksumWriters
+}
+
+// getBlockChecksums - checksums of the data files written, disks which went offline are skipped
+func getBlockChecksums(writers []io.WriteCloser, offline map[int]error) map[int]string {
+	blocks := make(map[int]string)
+	for order, writer := range writers {
+		if _, ok := offline[order]; ok {
+			continue
+		}
+		blocks[order] = hex.EncodeToString(writer.(*blockChecksumWriter).hash.Sum(nil))
+	}
+	return blocks
+}
+
+// writeObjectIntegrity - write integrity of an object to all the disks, every copy is read back
+// and compared with what was written
+func (b bucket) writeObjectIntegrity(objectName string, integrity ObjectIntegrity) *probe.Error {
+	integrityBytes, e := json.Marshal(&integrity)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	writers, err := b.getObjectWriters(objectName, objectIntegrityConfig)
+	if err != nil {
+		return err.Trace()
+	}
+	for _, writer := range writers {
+		if _, e := writer.Write(integrityBytes); e != nil {
+			CleanupWritersOnError(writers)
+			return probe.NewError(e)
+		}
+	}
+	for _, writer := range writers {
+		writer.Close()
+	}
+	readers, err := b.getObjectReaders(objectName, objectIntegrityConfig)
+	if err != nil {
+		return err.Trace()
+	}
+	for _, reader := range readers {
+		defer reader.Close()
+	}
+	if len(readers) != len(writers) {
+		return probe.NewError(ChecksumMismatch{})
+	}
+	for _, reader := range readers {
+		written, e := ioutil.ReadAll(reader)
+		if e != nil {
+			return probe.NewError(e)
+		}
+		if !bytes.Equal(written, integrityBytes) {
+			return probe.NewError(ChecksumMismatch{})
+		}
+	}
+	return nil
+}
+
+// readObjectIntegrity - read integrity of an object from the first disk holding a readable copy,
+// objects written before integrity files were introduced have none
+func (b bucket) readObjectIntegrity(objectName string) (ObjectIntegrity, *probe.Error) {
+	readers, err := b.getObjectReaders(objectName, objectIntegrityConfig)
+	if err != nil {
+		return ObjectIntegrity{}, err.Trace()
+	}
+	for _, reader := range readers {
+		defer reader.Close()
+	}
+	var e error = os.ErrNotExist
+	for _, reader := range readers {
+		var integrity ObjectIntegrity
+		if e = json.NewDecoder(reader).Decode(&integrity); e == nil {
+			return integrity, nil
+		}
+	}
+	return ObjectIntegrity{}, probe.NewError(e)
+}
+
+// getCorruptBlocks - disks whose data file does not match its checksum in integrity, data files
+// missing on a disk are left to be counted as missing blocks
+func (b bucket) getCorruptBlocks(objectName string, integrity ObjectIntegrity) ([]int, *probe.Error) {
+	readers, err := b.getDataReaders(objectName, integrity.objectMetadata(b.name, objectName))
+	if err != nil {
+		return nil, err.Trace()
+	}
+	for _, reader := range readers {
+		defer reader.Close()
+	}
+	var corrupt []int
+	for order, checksum := range integrity.Blocks {
+		reader, ok := readers[order]
+		if !ok {
+			continue
+		}
+		hasher := sha512.New()
+		if _, e := io.Copy(hasher, reader); e != nil || hex.EncodeToString(hasher.Sum(nil)) != checksum {
+			corrupt = append(corrupt, order)
+		}
+	}
+	sort.Ints(corrupt)
+	return corrupt, nil
+}
+
+// getDamagedMetadata - number of disks holding an object directory without readable object
+// metadata in it
+func (b bucket) getDamagedMetadata(objectName string) (int, *probe.Error) {
+	var damaged int
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return 0, err.Trace()
+		}
+		for order, d := range disks {
+			objectPath := filepath.Join(b.xlName, fmt.Sprintf("%s$%d$%d", b.name, nodeSlice, order), objectName)
+			if _, e := os.Stat(filepath.Join(d.GetPath(), objectPath)); e != nil {
+				continue
+			}
+			reader, err := d.Open(filepath.Join(objectPath, objectMetadataConfig))
+			if err != nil {
+				damaged++
+				continue
+			}
+			var objMetadata ObjectMetadata
+			if e := json.NewDecoder(reader).Decode(&objMetadata); e != nil {
+				damaged++
+			}
+			reader.Close()
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return damaged, nil
+}
+
+// GetObjectIntegrity - read the integrity file of an object
+func (xl API) GetObjectIntegrity(bucket, object string) (ObjectIntegrity, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return ObjectIntegrity{}, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !IsValidObjectName(object) {
+		return ObjectIntegrity{}, probe.NewError(ObjectNameInvalid{Object: object})
+	}
+	if len(xl.config.NodeDiskMap) == 0 {
+		return ObjectIntegrity{}, probe.NewError(InvalidDisksArgument{})
+	}
+	if err := xl.listXLBuckets(); err != nil {
+		return ObjectIntegrity{}, err.Trace()
+	}
+	bkt, ok := xl.buckets[bucket]
+	if !ok {
+		return ObjectIntegrity{}, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	integrity, err := bkt.readObjectIntegrity(normalizeObjectName(object))
+	if err != nil {
+		if os.IsNotExist(err.ToGoError()) {
+			return ObjectIntegrity{}, probe.NewError(ObjectNotFound{Object: object})
+		}
+		return ObjectIntegrity{}, err.Trace()
+	}
+	return integrity, nil
+}
@@ -0,0 +1,162 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"crypto/sha512"
+	"encoding/hex"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestObjectIntegrity(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "integrity", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	data := bytes.Repeat([]byte("integrity"), 1000)
+	objMetadata, err := xlAPI.CreateObject("foo", "dir/object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+
+	integrity, err := xlAPI.GetObjectIntegrity("foo", "dir/object")
+	c.Assert(err, IsNil)
+	c.Assert(integrity.SHA512Sum, Equals, objMetadata.SHA512Sum)
+	c.Assert(integrity.Size, Equals, int64(len(data)))
+	c.Assert(len(integrity.Blocks), Equals, 4)
+	for disk := 0; disk < 4; disk++ {
+		block, e := ioutil.ReadFile(filepath.Join(testObjectPath(root, "integrity", "foo", disk, "dir-object"), "data"))
+		c.Assert(e, IsNil)
+		sum := sha512.Sum512(block)
+		c.Assert(integrity.Blocks[disk], Equals, hex.EncodeToString(sum[:]))
+	}
+	_, err = xlAPI.GetObjectIntegrity("foo", "missing")
+	c.Assert(err, Not(IsNil))
+
+	// a block damaged in place, same size, is only found by verifying
+	dataPath := filepath.Join(testObjectPath(root, "integrity", "foo", 1, "dir-object"), "data")
+	block, e := ioutil.ReadFile(dataPath)
+	c.Assert(e, IsNil)
+	c.Assert(ioutil.WriteFile(dataPath, bytes.Repeat([]byte{0}, len(block)), 0600), IsNil)
+
+	var problems []FsckProblem
+	progress := func(p FsckProblem) { problems = append(problems, p) }
+	summary, err := xlAPI.Fsck(FsckConfig{Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 0)
+
+	summary, err = xlAPI.Fsck(FsckConfig{Verify: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 1)
+	c.Assert(problems[0].Kind, Equals, FsckCorrupt)
+	c.Assert(problems[0].Object, Equals, "dir/object")
+	c.Assert(problems[0].CorruptBlocks, Equals, 1)
+	c.Assert(problems[0].Recoverable, Equals, true)
+	c.Assert(problems[0].Fixed, Equals, false)
+	c.Assert(summary, DeepEquals, FsckSummary{Buckets: 1, Objects: 1, Corrupt: 1})
+
+	// block is rebuilt from the others
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Verify: true, Fix: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 1)
+	c.Assert(problems[0].Fixed, Equals, true)
+	c.Assert(summary.Repaired, Equals, 1)
+	repaired, e := ioutil.ReadFile(dataPath)
+	c.Assert(e, IsNil)
+	c.Assert(bytes.Equal(repaired, block), Equals, true)
+
+	problems = nil
+	_, err = xlAPI.Fsck(FsckConfig{Verify: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 0)
+}
+
+func (s *MyXLSuite) TestDamagedMetadata(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "integrity", 4)
+	c.Assert(xlAPI.MakeBucket("bar", "private", nil, nil), IsNil)
+	data := bytes.Repeat([]byte("metadata"), 1000)
+	_, err := xlAPI.CreateObject("bar", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+
+	// one copy damaged, rewritten from the others
+	metadataPath := filepath.Join(testObjectPath(root, "integrity", "bar", 0, "object"), objectMetadataConfig)
+	c.Assert(ioutil.WriteFile(metadataPath, []byte("{"), 0600), IsNil)
+	var problems []FsckProblem
+	progress := func(p FsckProblem) { problems = append(problems, p) }
+	summary, err := xlAPI.Fsck(FsckConfig{Fix: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 1)
+	c.Assert(problems[0].Kind, Equals, FsckMetadata)
+	c.Assert(problems[0].DamagedCopies, Equals, 1)
+	c.Assert(problems[0].Fixed, Equals, true)
+	c.Assert(summary, DeepEquals, FsckSummary{Buckets: 1, Objects: 1, DamagedMetadata: 1, Repaired: 1})
+
+	// every copy lost, without an integrity file the object is only reported
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "integrity", "bar", disk, "object"), objectMetadataConfig)), IsNil)
+	}
+	integrityBytes, e := ioutil.ReadFile(filepath.Join(testObjectPath(root, "integrity", "bar", 0, "object"), objectIntegrityConfig))
+	c.Assert(e, IsNil)
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Rename(filepath.Join(testObjectPath(root, "integrity", "bar", disk, "object"), objectIntegrityConfig), filepath.Join(root, "saved"+strconv.Itoa(disk))), IsNil)
+	}
+	problems = nil
+	_, err = xlAPI.Fsck(FsckConfig{Fix: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 1)
+	c.Assert(problems[0].Kind, Equals, FsckDangling)
+
+	// restored from the integrity file
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Rename(filepath.Join(root, "saved"+strconv.Itoa(disk)), filepath.Join(testObjectPath(root, "integrity", "bar", disk, "object"), objectIntegrityConfig)), IsNil)
+	}
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Fix: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 1)
+	c.Assert(problems[0].Kind, Equals, FsckMetadata)
+	c.Assert(problems[0].DamagedCopies, Equals, 4)
+	c.Assert(problems[0].Fixed, Equals, true)
+	c.Assert(summary.Repaired, Equals, 1)
+	integrityAfter, e := ioutil.ReadFile(filepath.Join(testObjectPath(root, "integrity", "bar", 0, "object"), objectIntegrityConfig))
+	c.Assert(e, IsNil)
+	c.Assert(bytes.Equal(integrityAfter, integrityBytes), Equals, true)
+
+	xlAPI = newTestXL(c, root, "integrity", 4)
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "bar", "object", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+
+	// metadata no longer describing the blocks of the integrity file
+	objMetadata, err := xlAPI.GetObjectMetadata("bar", "object")
+	c.Assert(err, IsNil)
+	objMetadata.SHA512Sum = hex.EncodeToString(make([]byte, sha512.Size))
+	c.Assert(xlAPI.buckets["bar"].writeObjectMetadata("object", objMetadata), IsNil)
+	problems = nil
+	summary, err = xlAPI.Fsck(FsckConfig{Verify: true, Fix: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(len(problems), Equals, 1)
+	c.Assert(problems[0].Mismatch, Equals, true)
+	c.Assert(problems[0].Fixed, Equals, false)
+	c.Assert(summary, DeepEquals, FsckSummary{Buckets: 1, Objects: 1, DamagedMetadata: 1})
+}
@@ -17,6 +17,7 @@
 package xl
 
 import (
+	"context"
 	"io"
 
 	"github.com/minio/minio-xl/pkg/probe"
@@ -29,6 +30,9 @@ import (
 type Interface interface {
 	CloudStorage
 	Management
+
+	// WithContext - same storage, disk operations are traced as part of the request of ctx
+	WithContext(ctx context.Context) Interface
 }
 
 // CloudStorage is a xl cloud storage interface
@@ -47,6 +51,14 @@ type CloudStorage interface {
 	GetObjectMetadata(bucket, object string) (ObjectMetadata, *probe.Error)
 	// bucket, object, expectedMD5Sum, size, reader, metadata, signature
 	CreateObject(string, string, string, int64, io.Reader, map[string]string, *signv4.Signature) (ObjectMetadata, *probe.Error)
+	// bucket, object, expectedETag
+	DeleteObject(string, string, string) *probe.Error
+	// bucket, sourceObject, object
+	MoveObject(string, string, string) (ObjectMetadata, *probe.Error)
+	// bucket, object, metadata
+	SetObjectMetadata(string, string, map[string]string) (ObjectMetadata, *probe.Error)
+	// bucket, object
+	TouchObject(string, string) (ObjectMetadata, *probe.Error)
 
 	Multipart
 }
@@ -56,16 +68,30 @@ type Multipart interface {
 	NewMultipartUpload(bucket, key, contentType string) (string, *probe.Error)
 	AbortMultipartUpload(bucket, key, uploadID string) *probe.Error
 	CreateObjectPart(string, string, string, int, string, string, int64, io.Reader, *signv4.Signature) (string, *probe.Error)
+	CopyObjectPart(bucket, key, uploadID string, partID int, sourceBucket, sourceObject string, start, length int64) (string, *probe.Error)
 	CompleteMultipartUpload(bucket, key, uploadID string, data io.Reader, signature *signv4.Signature) (ObjectMetadata, *probe.Error)
 	ListMultipartUploads(string, BucketMultipartResourcesMetadata) (BucketMultipartResourcesMetadata, *probe.Error)
 	ListObjectParts(string, string, ObjectResourcesMetadata) (ObjectResourcesMetadata, *probe.Error)
+	CountMultipartUploads() int
 }
 
 // Management is a xl management system interface
 type Management interface {
 	Heal() *probe.Error
-	Rebalance() *probe.Error
+	Rebalance(RebalanceConfig) *probe.Error
+	Fsck(FsckConfig) (FsckSummary, *probe.Error)
+	RepairMetadata(RepairMetadataConfig) (RepairMetadataSummary, *probe.Error)
+	GetObjectIntegrity(bucket, object string) (ObjectIntegrity, *probe.Error)
 	Info() (map[string][]string, *probe.Error)
+	GetBucketStats(bucket string) (BucketStats, *probe.Error)
+	RescanBucketStats(bucket string) (BucketStats, *probe.Error)
+	GetOrphanStats() OrphanStats
+	GetDiskCacheStats() DiskCacheStats
+	GetDiskQuorumStats() DiskQuorumStats
+	SearchMetadata(bucket, key, value string) ([]string, *probe.Error)
+	RebuildMetadataIndex(bucket string) *probe.Error
+	ExportMetadata(startAfter string, record func(MetadataRecord) *probe.Error) *probe.Error
+	ImportMetadata(records []MetadataRecord) ([]MetadataImportResult, *probe.Error)
 
 	AttachNode(hostname string, disks []string) *probe.Error
 	DetachNode(hostname string) *probe.Error
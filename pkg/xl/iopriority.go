@@ -0,0 +1,99 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"log"
+	"runtime"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// internal variables only accessed via get/set methods
+var backgroundIOPriority uint32
+
+// SetBackgroundIOPriority - I/O priority of background work on disks, fsck, rebalance, metadata
+// repair, read repair and expiry of orphans and multipart uploads, e.g. idle so it only gets
+// the disks while client requests leave them alone. Client requests keep the priority of the
+// process. Fails when the priority cannot be set, realtime needs CAP_SYS_ADMIN
+func SetBackgroundIOPriority(priority disk.IOPriority) *probe.Error {
+	if priority != 0 {
+		errCh := make(chan *probe.Error)
+		go func() {
+			// thread is thrown away, never unlocked
+			runtime.LockOSThread()
+			errCh <- disk.SetThreadIOPriority(priority)
+		}()
+		if err := <-errCh; err != nil {
+			return err.Trace(priority.String())
+		}
+		// threads already have it, background work needs no threads of its own
+		if priority == disk.GetThreadIOPriority() {
+			priority = 0
+		}
+	}
+	atomic.StoreUint32(&backgroundIOPriority, uint32(priority))
+	return nil
+}
+
+// getBackgroundIOPriority - zero when background work runs like any other
+func getBackgroundIOPriority() disk.IOPriority {
+	return disk.IOPriority(atomic.LoadUint32(&backgroundIOPriority))
+}
+
+// runAtIOPriority - run fn on a thread of its own at priority. The thread is never unlocked,
+// the runtime ends it along with the goroutine instead of running other goroutines at priority
+func runAtIOPriority(priority disk.IOPriority, fn func()) {
+	runtime.LockOSThread()
+	if err := disk.SetThreadIOPriority(priority); err != nil {
+		log.Printf("Unable to set I/O priority %s: %s", priority, err.ToGoError())
+	}
+	fn()
+}
+
+// isBackgroundThread - is the calling goroutine doing background work, only those run on
+// threads at the background priority
+func isBackgroundThread(priority disk.IOPriority) bool {
+	return priority != 0 && disk.GetThreadIOPriority() == priority
+}
+
+// withBackgroundIOPriority - run fn as background work, returns once it is done
+func withBackgroundIOPriority(fn func()) {
+	priority := getBackgroundIOPriority()
+	if priority == 0 || isBackgroundThread(priority) {
+		fn()
+		return
+	}
+	done := make(chan struct{})
+	go runAtIOPriority(priority, func() {
+		defer close(done)
+		fn()
+	})
+	<-done
+}
+
+// goIO - start fn doing disk I/O on behalf of the calling goroutine, at the background
+// priority when the caller does background work
+func goIO(fn func()) {
+	if priority := getBackgroundIOPriority(); isBackgroundThread(priority) {
+		go runAtIOPriority(priority, fn)
+		return
+	}
+	go fn()
+}
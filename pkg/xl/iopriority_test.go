@@ -0,0 +1,73 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"runtime"
+
+	"github.com/minio/minio-xl/pkg/xl/disk"
+	. "gopkg.in/check.v1"
+)
+
+type MyIOPrioritySuite struct{}
+
+var _ = Suite(&MyIOPrioritySuite{})
+
+func (s *MyIOPrioritySuite) TestBackgroundIOPriority(c *C) {
+	if runtime.GOOS != "linux" {
+		c.Skip("I/O priorities are only set on linux")
+	}
+	idle := disk.NewIOPriority(disk.IOClassIdle, 0)
+	foreground := disk.GetThreadIOPriority()
+	// priority of a goroutine started with goIO, from the calling goroutine
+	ioPriority := func() disk.IOPriority {
+		done := make(chan disk.IOPriority)
+		goIO(func() {
+			runtime.LockOSThread()
+			defer runtime.UnlockOSThread()
+			done <- disk.GetThreadIOPriority()
+		})
+		return <-done
+	}
+
+	// unset, background work runs like any other
+	c.Assert(SetBackgroundIOPriority(0), IsNil)
+	withBackgroundIOPriority(func() {
+		c.Check(disk.GetThreadIOPriority(), Equals, foreground)
+	})
+
+	c.Assert(SetBackgroundIOPriority(idle), IsNil)
+	defer SetBackgroundIOPriority(0)
+	c.Assert(getBackgroundIOPriority(), Equals, idle)
+	var priorities []disk.IOPriority
+	withBackgroundIOPriority(func() {
+		priorities = append(priorities, disk.GetThreadIOPriority())
+		// nested background work and disk I/O on its behalf keep the priority
+		withBackgroundIOPriority(func() {
+			priorities = append(priorities, disk.GetThreadIOPriority())
+		})
+		priorities = append(priorities, ioPriority())
+	})
+	c.Assert(priorities, DeepEquals, []disk.IOPriority{idle, idle, idle})
+	// client requests keep the priority of the process
+	c.Assert(ioPriority(), Equals, foreground)
+	c.Assert(disk.GetThreadIOPriority(), Equals, foreground)
+
+	// priority threads already have needs no threads of their own
+	c.Assert(SetBackgroundIOPriority(foreground), IsNil)
+	c.Assert(getBackgroundIOPriority(), Equals, disk.IOPriority(0))
+}
@@ -0,0 +1,33 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+// Legal hold status saved in object metadata under "legalHold"
+const (
+	LegalHoldOn  = "ON"
+	LegalHoldOff = "OFF"
+)
+
+// IsValidLegalHold - verify legal hold status
+func IsValidLegalHold(status string) bool {
+	return status == LegalHoldOn || status == LegalHoldOff
+}
+
+// isLegalHold - object is under legal hold, it cannot be deleted or overwritten until released
+func isLegalHold(objMetadata ObjectMetadata) bool {
+	return objMetadata.Metadata["legalHold"] == LegalHoldOn
+}
@@ -0,0 +1,101 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// defaultListWorkers number of object metadata read in parallel by a listing unless configured
+const defaultListWorkers = 8
+
+// internal variable only accessed via get/set methods
+var listWorkers int32 = defaultListWorkers
+
+// SetListWorkers - set number of object metadata read in parallel while listing a bucket,
+// values smaller than 1 are raised to 1 which reads them one after the other.
+func SetListWorkers(workers int) {
+	if workers < 1 {
+		workers = 1
+	}
+	atomic.StoreInt32(&listWorkers, int32(workers))
+}
+
+// getListWorkers - get number of object metadata read in parallel
+func getListWorkers() int {
+	return int(atomic.LoadInt32(&listWorkers))
+}
+
+// readObjectsMetadata - read metadata of objects on a bounded pool of workers, results are
+// merged back in the order of objectNames. The first error stops the walk.
+func (b bucket) readObjectsMetadata(objectNames []string) ([]ObjectMetadata, *probe.Error) {
+	objectsMetadata := make([]ObjectMetadata, len(objectNames))
+	workers := getListWorkers()
+	if workers > len(objectNames) {
+		workers = len(objectNames)
+	}
+	if workers <= 1 {
+		for i, objectName := range objectNames {
+			objMetadata, err := b.readObjectMetadata(normalizeObjectName(objectName))
+			if err != nil {
+				return nil, err.Trace(objectName)
+			}
+			objectsMetadata[i] = objMetadata
+		}
+		return objectsMetadata, nil
+	}
+	var once sync.Once
+	var walkErr *probe.Error
+	done := make(chan struct{})
+	indexes := make(chan int)
+	var wg sync.WaitGroup
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		goIO(func() {
+			defer wg.Done()
+			for i := range indexes {
+				objMetadata, err := b.readObjectMetadata(normalizeObjectName(objectNames[i]))
+				if err != nil {
+					once.Do(func() {
+						walkErr = err.Trace(objectNames[i])
+						close(done)
+					})
+					continue
+				}
+				// every worker writes its own indexes, no lock needed
+				objectsMetadata[i] = objMetadata
+			}
+		})
+	}
+walk:
+	for i := range objectNames {
+		select {
+		case indexes <- i:
+		case <-done:
+			break walk
+		}
+	}
+	close(indexes)
+	wg.Wait()
+	if walkErr != nil {
+		return nil, walkErr
+	}
+	return objectsMetadata, nil
+}
@@ -0,0 +1,157 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+
+	. "gopkg.in/check.v1"
+)
+
+var listBenchmarkObjects = flag.Int("xl.list-objects", 500000, "number of objects in the bucket listed by BenchmarkListObjects")
+
+// newListTestXL - xl with 4 disks holding bucket 'bucket' with the given number of objects,
+// all of them sharing the metadata of a single object written the regular way
+func newListTestXL(root string, objects int) (API, error) {
+	api, err := createTestXL(root, "list", 4)
+	if err != nil {
+		return API{}, err.ToGoError()
+	}
+	if err := api.MakeBucket("bucket", "private", nil, nil); err != nil {
+		return API{}, err.ToGoError()
+	}
+	data := []byte("Hello World")
+	objMetadata, err := api.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	if err != nil {
+		return API{}, err.ToGoError()
+	}
+	allBuckets, err := api.getXLBucketMetadata()
+	if err != nil {
+		return API{}, err.ToGoError()
+	}
+	for i := 0; i < objects; i++ {
+		objMetadata.Object = fmt.Sprintf("objects/%07d", i)
+		if err := api.buckets["bucket"].writeObjectMetadata(normalizeObjectName(objMetadata.Object), objMetadata); err != nil {
+			return API{}, err.ToGoError()
+		}
+		allBuckets.Buckets["bucket"].BucketObjects[objMetadata.Object] = struct{}{}
+	}
+	if err := api.setXLBucketMetadata(allBuckets); err != nil {
+		return API{}, err.ToGoError()
+	}
+	return api, nil
+}
+
+// listAllObjects - names and sizes of all objects in 'bucket', page by page
+func listAllObjects(xlAPI API, maxkeys int) ([]string, error) {
+	var objects []string
+	resources := BucketResourcesMetadata{Maxkeys: maxkeys}
+	for {
+		results, nextResources, err := xlAPI.ListObjects("bucket", resources)
+		if err != nil {
+			return nil, err.ToGoError()
+		}
+		for _, objMetadata := range results {
+			objects = append(objects, objMetadata.Object+"/"+strconv.FormatInt(objMetadata.Size, 10))
+		}
+		if !nextResources.IsTruncated || len(results) == 0 {
+			return objects, nil
+		}
+		resources.Marker = results[len(results)-1].Object
+	}
+}
+
+func (s *MyXLSuite) TestListWorkers(c *C) {
+	defer SetListWorkers(defaultListWorkers)
+	root := c.MkDir()
+	xlAPI, err := newListTestXL(root, 250)
+	c.Assert(err, IsNil)
+
+	SetListWorkers(1)
+	serial, err := listAllObjects(xlAPI, 100)
+	c.Assert(err, IsNil)
+	c.Assert(len(serial), Equals, 251)
+	c.Assert(serial[0], Equals, "object/11")
+	c.Assert(serial[1], Equals, "objects/0000000/11")
+
+	// same objects in the same order, whatever the number of workers
+	for _, workers := range []int{2, 8, 300} {
+		SetListWorkers(workers)
+		parallel, err := listAllObjects(xlAPI, 100)
+		c.Assert(err, IsNil)
+		c.Assert(parallel, DeepEquals, serial)
+	}
+
+	// metadata lost on all disks fails the listing
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "list", "bucket", disk, "objects-0000123"), objectMetadataConfig)), IsNil)
+	}
+	SetListWorkers(8)
+	_, err = listAllObjects(xlAPI, 1000)
+	c.Assert(err, Not(IsNil))
+
+	SetListWorkers(0)
+	c.Assert(getListWorkers(), Equals, 1)
+}
+
+// BenchmarkListObjects - list a bucket of -xl.list-objects objects one page after the other,
+// reading object metadata one by one and in parallel. Listings must match the one by one listing.
+func BenchmarkListObjects(b *testing.B) {
+	root, e := ioutil.TempDir(os.TempDir(), "xl-listbench-")
+	if e != nil {
+		b.Fatal(e)
+	}
+	defer os.RemoveAll(root)
+	defer SetListWorkers(defaultListWorkers)
+	xlAPI, e := newListTestXL(root, *listBenchmarkObjects)
+	if e != nil {
+		b.Fatal(e)
+	}
+	SetListWorkers(1)
+	serial, e := listAllObjects(xlAPI, 1000)
+	if e != nil {
+		b.Fatal(e)
+	}
+	for _, workers := range []int{1, 4, 8, 16} {
+		b.Run(fmt.Sprintf("workers-%d", workers), func(b *testing.B) {
+			SetListWorkers(workers)
+			for i := 0; i < b.N; i++ {
+				objects, e := listAllObjects(xlAPI, 1000)
+				if e != nil {
+					b.Fatal(e)
+				}
+				b.StopTimer()
+				if len(objects) != len(serial) {
+					b.Fatalf("listed %d objects, %d listed one by one", len(objects), len(serial))
+				}
+				for j := range objects {
+					if objects[j] != serial[j] {
+						b.Fatalf("object %d is %s, %s listed one by one", j, objects[j], serial[j])
+					}
+				}
+				b.StartTimer()
+			}
+		})
+	}
+}
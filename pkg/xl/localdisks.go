@@ -0,0 +1,137 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// localDisks - disks in the rack of this server, nil if all disks are read alike
+var localDisks = struct {
+	sync.RWMutex
+	paths map[string]bool
+}{}
+
+// SetLocalDisks - blocks and metadata are read from the disks at paths, other disks are only
+// read once the blocks on these do not suffice to decode an object. No paths reads every disk.
+func SetLocalDisks(paths []string) {
+	localDisks.Lock()
+	defer localDisks.Unlock()
+	localDisks.paths = nil
+	for _, path := range paths {
+		if localDisks.paths == nil {
+			localDisks.paths = make(map[string]bool)
+		}
+		localDisks.paths[filepath.Clean(path)] = true
+	}
+}
+
+// isRemoteDisk - is path a disk outside of the local disks, false for all disks unless
+// local disks are set
+func isRemoteDisk(path string) bool {
+	localDisks.RLock()
+	defer localDisks.RUnlock()
+	return localDisks.paths != nil && !localDisks.paths[filepath.Clean(path)]
+}
+
+// standbyReader - file on a remote disk, opened only once a read needs it. Stripes decoded
+// from local blocks alone are skipped without touching the disk.
+type standbyReader struct {
+	// open - open the file at offset
+	open   func(offset int64) (io.ReadCloser, *probe.Error)
+	reader io.ReadCloser
+	offset int64
+	err    error
+}
+
+func (r *standbyReader) Read(p []byte) (int, error) {
+	if r.err != nil {
+		return 0, r.err
+	}
+	if r.reader == nil {
+		reader, err := r.open(r.offset)
+		if err != nil {
+			r.err = err.ToGoError()
+			return 0, r.err
+		}
+		r.reader = reader
+	}
+	n, err := r.reader.Read(p)
+	r.offset += int64(n)
+	return n, err
+}
+
+// skip - skip n bytes, an open file is closed and reopened at its new offset when needed again
+func (r *standbyReader) skip(n int64) {
+	if r.reader != nil {
+		r.reader.Close()
+		r.reader = nil
+	}
+	r.offset += n
+}
+
+// Close - close the file if it was opened
+func (r *standbyReader) Close() error {
+	if r.reader == nil {
+		return nil
+	}
+	return r.reader.Close()
+}
+
+// openFile - open a file of an object on disk, files on remote disks are opened once read
+func (b bucket) openFile(disk disk.Disk, path string) (io.ReadCloser, *probe.Error) {
+	open := func(offset int64) (io.ReadCloser, *probe.Error) {
+		file, err := disk.Open(path)
+		if err != nil {
+			return nil, err.Trace(path)
+		}
+		if offset > 0 {
+			if _, e := file.Seek(offset, os.SEEK_SET); e != nil {
+				file.Close()
+				return nil, probe.NewError(e)
+			}
+		}
+		return b.traceReader(file, disk.GetPath(), path), nil
+	}
+	if isRemoteDisk(disk.GetPath()) {
+		return &standbyReader{open: open}, nil
+	}
+	return open(0)
+}
+
+// getReadOrder - orders of readers to read from, readers of local disks come first and data
+// blocks before parity blocks
+func getReadOrder(readers map[int]io.ReadCloser) []int {
+	var local, remote []int
+	for order, reader := range readers {
+		if _, ok := reader.(*standbyReader); ok {
+			remote = append(remote, order)
+			continue
+		}
+		local = append(local, order)
+	}
+	sort.Ints(local)
+	sort.Ints(remote)
+	return append(local, remote...)
+}
@@ -0,0 +1,195 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	. "gopkg.in/check.v1"
+)
+
+// newLocalDisksTestXL - xl with 4 disks holding an object of two stripes, disks 0 and 1 local
+func newLocalDisksTestXL(root string) (API, []byte, error) {
+	xlAPI, err := createTestXL(root, "local", 4)
+	if err != nil {
+		return API{}, nil, err.ToGoError()
+	}
+	if err := xlAPI.MakeBucket("bucket", "private", nil, nil); err != nil {
+		return API{}, nil, err.ToGoError()
+	}
+	data := bytes.Repeat([]byte("0123456789abcdef"), (blockSize+blockSize/4)/16)
+	if _, err := xlAPI.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil); err != nil {
+		return API{}, nil, err.ToGoError()
+	}
+	SetLocalDisks([]string{filepath.Join(root, "0"), filepath.Join(root, "1")})
+	return xlAPI, data, nil
+}
+
+// readLocalDisksObject - open the blocks of the object, wrap lets callers replace the readers
+// before the object is decoded from them
+func readLocalDisksObject(xlAPI API, wrap func(map[int]io.ReadCloser)) ([]byte, map[int]io.ReadCloser, error) {
+	b := xlAPI.buckets["bucket"]
+	objMetadata, err := b.readObjectMetadata("object")
+	if err != nil {
+		return nil, nil, err.ToGoError()
+	}
+	readers, err := b.getDataReaders("object", objMetadata)
+	if err != nil {
+		return nil, nil, err.ToGoError()
+	}
+	if wrap != nil {
+		wrap(readers)
+	}
+	reader, writer := io.Pipe()
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		b.readObjectData(readers, writer, objMetadata)
+	}()
+	data, e := ioutil.ReadAll(reader)
+	<-done
+	return data, readers, e
+}
+
+func (s *MyXLSuite) TestLocalDisks(c *C) {
+	defer SetLocalDisks(nil)
+	root := c.MkDir()
+	xlAPI, data, err := newLocalDisksTestXL(root)
+	c.Assert(err, IsNil)
+
+	// remote disks are never opened while the local blocks suffice
+	read, readers, err := readLocalDisksObject(xlAPI, nil)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(read, data), Equals, true)
+	c.Assert(getReadOrder(readers), DeepEquals, []int{0, 1, 2, 3})
+	for order := 2; order < 4; order++ {
+		standby, ok := readers[order].(*standbyReader)
+		c.Assert(ok, Equals, true)
+		c.Assert(standby.reader, IsNil)
+	}
+
+	// a local block ending early is replaced by a remote one in the middle of the object
+	dataPath := filepath.Join(testObjectPath(root, "local", "bucket", 0, "object"), "data")
+	fi, e := os.Stat(dataPath)
+	c.Assert(e, IsNil)
+	c.Assert(os.Truncate(dataPath, fi.Size()-1), IsNil)
+	read, readers, err = readLocalDisksObject(xlAPI, nil)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(read, data), Equals, true)
+	_, ok := readers[0]
+	c.Assert(ok, Equals, false)
+	c.Assert(readers[2].(*standbyReader).reader, NotNil)
+	c.Assert(readers[3].(*standbyReader).reader, IsNil)
+
+	// a lost local block reads remote blocks from the start
+	c.Assert(os.Remove(dataPath), IsNil)
+	var buffer bytes.Buffer
+	_, perr := xlAPI.GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+
+	// every disk is read alike without local disks
+	SetLocalDisks(nil)
+	_, readers, err = readLocalDisksObject(xlAPI, nil)
+	c.Assert(err, IsNil)
+	for _, reader := range readers {
+		_, ok := reader.(*standbyReader)
+		c.Assert(ok, Equals, false)
+	}
+}
+
+// remoteDiskReader - reader of a remote disk, data arrives in packets of remotePacketSize
+// each taking remoteDiskLatency
+type remoteDiskReader struct {
+	io.ReadCloser
+}
+
+const (
+	remoteDiskLatency = 500 * time.Microsecond
+	remotePacketSize  = 128 * 1024
+)
+
+func (r remoteDiskReader) Read(p []byte) (int, error) {
+	time.Sleep(remoteDiskLatency)
+	if len(p) > remotePacketSize {
+		p = p[:remotePacketSize]
+	}
+	return r.ReadCloser.Read(p)
+}
+
+// BenchmarkReadLocalDisks - read an object with half its disks behind remote latency, once
+// reading all disks and once preferring local disks
+func BenchmarkReadLocalDisks(b *testing.B) {
+	root, e := ioutil.TempDir(os.TempDir(), "xl-localbench-")
+	if e != nil {
+		b.Fatal(e)
+	}
+	defer os.RemoveAll(root)
+	defer SetLocalDisks(nil)
+	xlAPI, data, e := newLocalDisksTestXL(root)
+	if e != nil {
+		b.Fatal(e)
+	}
+	remote := func(readers map[int]io.ReadCloser) {
+		for order := 2; order < 4; order++ {
+			reader, ok := readers[order]
+			if !ok {
+				continue
+			}
+			if standby, ok := reader.(*standbyReader); ok {
+				open := standby.open
+				standby.open = func(offset int64) (io.ReadCloser, *probe.Error) {
+					reader, err := open(offset)
+					if err != nil {
+						return nil, err
+					}
+					return remoteDiskReader{reader}, nil
+				}
+				continue
+			}
+			readers[order] = remoteDiskReader{reader}
+		}
+	}
+	for _, local := range []bool{false, true} {
+		name := "all-disks"
+		if local {
+			name = "local-disks"
+			SetLocalDisks([]string{filepath.Join(root, "0"), filepath.Join(root, "1")})
+		} else {
+			SetLocalDisks(nil)
+		}
+		b.Run(name, func(b *testing.B) {
+			b.SetBytes(int64(len(data)))
+			for i := 0; i < b.N; i++ {
+				read, _, e := readLocalDisksObject(xlAPI, remote)
+				if e != nil {
+					b.Fatal(e)
+				}
+				if len(read) != len(data) {
+					b.Fatalf("read %d bytes, object has %d", len(read), len(data))
+				}
+			}
+		})
+	}
+}
@@ -17,8 +17,9 @@
 package xl
 
 import (
+	"sort"
+
 	"github.com/minio/minio-xl/pkg/probe"
-	"github.com/minio/minio-xl/pkg/xl/disk"
 )
 
 // Info - return info about xl configuration
@@ -29,9 +30,15 @@ func (xl API) Info() (nodeDiskMap map[string][]string, err *probe.Error) {
 		if err != nil {
 			return nil, err.Trace()
 		}
-		diskList := make([]string, len(disks))
-		for diskOrder, disk := range disks {
-			diskList[diskOrder] = disk.GetPath()
+		// disks missing since startup leave gaps in the order
+		var orders []int
+		for diskOrder := range disks {
+			orders = append(orders, diskOrder)
+		}
+		sort.Ints(orders)
+		diskList := make([]string, 0, len(disks))
+		for _, diskOrder := range orders {
+			diskList = append(diskList, disks[diskOrder].GetPath())
 		}
 		nodeDiskMap[nodeName] = diskList
 	}
@@ -48,15 +55,8 @@ func (xl API) AttachNode(hostname string, disks []string) *probe.Error {
 		return err.Trace()
 	}
 	xl.nodes[hostname] = n
-	for i, d := range disks {
-		newDisk, err := disk.New(d)
-		if err != nil {
-			continue
-		}
-		if err := newDisk.MakeDir(xl.config.XLName); err != nil {
-			return err.Trace()
-		}
-		if err := n.AttachDisk(newDisk, i); err != nil {
+	for order, newDisk := range xl.openDisks(hostname, disks) {
+		if err := n.AttachDisk(newDisk, order); err != nil {
 			return err.Trace()
 		}
 	}
@@ -70,8 +70,37 @@ func (xl API) DetachNode(hostname string) *probe.Error {
 }
 
 // Rebalance - rebalance an existing xl with new disks and nodes
-func (xl API) Rebalance() *probe.Error {
-	return probe.NewError(APINotImplemented{API: "management.Rebalance"})
+func (xl API) Rebalance(config RebalanceConfig) *probe.Error {
+	if len(xl.config.NodeDiskMap) == 0 {
+		return probe.NewError(InvalidDisksArgument{})
+	}
+	var err *probe.Error
+	withBackgroundIOPriority(func() {
+		// bucket slices on new disks are created only by a real run
+		if !config.DryRun {
+			if err = xl.healBuckets(); err != nil {
+				return
+			}
+		}
+		err = xl.rebalance(config)
+	})
+	if err != nil {
+		return err.Trace()
+	}
+	return nil
+}
+
+// Fsck - check an existing xl for orphaned blocks and dangling objects
+func (xl API) Fsck(config FsckConfig) (FsckSummary, *probe.Error) {
+	if len(xl.config.NodeDiskMap) == 0 {
+		return FsckSummary{}, probe.NewError(InvalidDisksArgument{})
+	}
+	var summary FsckSummary
+	var err *probe.Error
+	withBackgroundIOPriority(func() {
+		summary, err = xl.fsck(config)
+	})
+	return summary, err
 }
 
 // Heal - heal your xls
@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/hex"
+	"fmt"
+	"hash"
+	"strings"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// ETagAlgorithm - hash ETags of objects are computed with, kept in the metadata of objects
+type ETagAlgorithm string
+
+const (
+	// ETagMD5 - md5sum of the data, assumed for objects which have no algorithm recorded
+	ETagMD5 ETagAlgorithm = "md5"
+	// ETagSHA256 - sha256sum of the data
+	ETagSHA256 ETagAlgorithm = "sha256"
+)
+
+// internal variables only accessed via get/set methods
+var (
+	md5Disabled   int32
+	etagAlgorithm atomic.Value
+)
+
+// SetETagAlgorithm - set the hash ETags of objects written from now on are computed with, ETags
+// of multipart objects hash the ETags of their parts with it
+func SetETagAlgorithm(algorithm ETagAlgorithm) {
+	etagAlgorithm.Store(algorithm)
+}
+
+// getETagAlgorithm - get ETag algorithm
+func getETagAlgorithm() ETagAlgorithm {
+	if algorithm, ok := etagAlgorithm.Load().(ETagAlgorithm); ok {
+		return algorithm
+	}
+	return ETagMD5
+}
+
+// ParseETagAlgorithm - parse ‘md5’ or ‘sha256’
+func ParseETagAlgorithm(algorithm string) (ETagAlgorithm, *probe.Error) {
+	switch ETagAlgorithm(algorithm) {
+	case ETagMD5, ETagSHA256:
+		return ETagAlgorithm(algorithm), nil
+	}
+	return "", probe.NewError(fmt.Errorf("%q is not an ETag algorithm, use ‘md5’ or ‘sha256’", algorithm))
+}
+
+// newHash - hash of the algorithm, md5 unless recorded otherwise
+func (algorithm ETagAlgorithm) newHash() hash.Hash {
+	if algorithm == ETagSHA256 {
+		return sha256.New()
+	}
+	return md5.New()
+}
+
+// SetDisableMD5 - skip computing MD5 of objects uploaded without Content-MD5, their ETag
+// is derived from the content hash instead
+func SetDisableMD5(disable bool) {
+	var value int32
+	if disable {
+		value = 1
+	}
+	atomic.StoreInt32(&md5Disabled, value)
+}
+
+// isMD5Disabled - is MD5 computation disabled
+func isMD5Disabled() bool {
+	return atomic.LoadInt32(&md5Disabled) == 1
+}
+
+// isMD5Required - MD5 of an upload is needed to verify Content-MD5 sent by the client,
+// or for its ETag unless MD5 is disabled or ETags are computed with another algorithm
+func isMD5Required(expectedMD5Sum string) bool {
+	return (!isMD5Disabled() && getETagAlgorithm() == ETagMD5) || strings.TrimSpace(expectedMD5Sum) != ""
+}
+
+// getContentETag - ETag of an object written without MD5, the first 128 bits of its content
+// hash suffixed with '-1'. Clients treat ETags with a dash like multipart ETags and never
+// compare them with the MD5 of the data, reads only verify the content hash.
+func getContentETag(sum []byte) string {
+	return hex.EncodeToString(sum[:16]) + "-1"
+}
@@ -0,0 +1,242 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	. "gopkg.in/check.v1"
+)
+
+type MyMD5Suite struct {
+	root string
+}
+
+var _ = Suite(&MyMD5Suite{})
+
+func (s *MyMD5Suite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-md5-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyMD5Suite) TearDownSuite(c *C) {
+	SetDisableMD5(false)
+	SetETagAlgorithm(ETagMD5)
+	os.RemoveAll(s.root)
+}
+
+func (s *MyMD5Suite) testDisableMD5(c *C, xlAPI Interface) {
+	SetDisableMD5(true)
+	defer SetDisableMD5(false)
+
+	// no Content-MD5, ETag is derived from the content hash
+	data := []byte("Hello World")
+	objMetadata, err := xlAPI.CreateObject("bucket", "nomd5", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	sum512 := sha512.Sum512(data)
+	c.Assert(objMetadata.MD5Sum, Equals, hex.EncodeToString(sum512[:16])+"-1")
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "bucket", "nomd5", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.Bytes(), DeepEquals, data)
+
+	// Content-MD5 is still verified and used as ETag
+	sum := md5.Sum(data)
+	objMetadata, err = xlAPI.CreateObject("bucket", "md5", base64.StdEncoding.EncodeToString(sum[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objMetadata.MD5Sum, Equals, hex.EncodeToString(sum[:]))
+	badSum := md5.Sum([]byte("Hello Moon"))
+	_, err = xlAPI.CreateObject("bucket", "badmd5", base64.StdEncoding.EncodeToString(badSum[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, BadDigest{})
+}
+
+func (s *MyMD5Suite) TestDisableMD5(c *C) {
+	xlAPI, err := newBucketCacheTestXL(filepath.Join(s.root, "disk"))
+	c.Assert(err, IsNil)
+	s.testDisableMD5(c, xlAPI)
+}
+
+func (s *MyMD5Suite) TestDisableMD5Memory(c *C) {
+	SetXLConfigPath(filepath.Join(s.root, "memory", "xl.json"))
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	c.Assert(xlAPI.MakeBucket("bucket", "private", nil, nil), IsNil)
+	s.testDisableMD5(c, xlAPI)
+}
+
+func (s *MyMD5Suite) testETagAlgorithm(c *C, xlAPI Interface) {
+	data := []byte("Hello World")
+	sum := md5.Sum(data)
+	_, err := xlAPI.CreateObject("bucket", "md5etag", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+
+	SetETagAlgorithm(ETagSHA256)
+	defer SetETagAlgorithm(ETagMD5)
+	sum256 := sha256.Sum256(data)
+	objMetadata, err := xlAPI.CreateObject("bucket", "sha256etag", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objMetadata.MD5Sum, Equals, hex.EncodeToString(sum256[:]))
+	c.Assert(objMetadata.ETagAlgorithm, Equals, ETagSHA256)
+
+	// Content-MD5 is still verified
+	objMetadata, err = xlAPI.CreateObject("bucket", "contentmd5", base64.StdEncoding.EncodeToString(sum[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objMetadata.MD5Sum, Equals, hex.EncodeToString(sum256[:]))
+	badSum := md5.Sum([]byte("Hello Moon"))
+	_, err = xlAPI.CreateObject("bucket", "badmd5", base64.StdEncoding.EncodeToString(badSum[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, BadDigest{})
+
+	// objects written before the change keep their ETag and are verified with it
+	for object, etag := range map[string]string{"md5etag": hex.EncodeToString(sum[:]), "sha256etag": hex.EncodeToString(sum256[:])} {
+		objMetadata, err = xlAPI.GetObjectMetadata("bucket", object)
+		c.Assert(err, IsNil)
+		c.Assert(objMetadata.MD5Sum, Equals, etag)
+		var buffer bytes.Buffer
+		_, err = xlAPI.GetObject(&buffer, "bucket", object, 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(buffer.Bytes(), DeepEquals, data)
+	}
+
+	// multipart ETags keep their composite form
+	uploadID, err := xlAPI.NewMultipartUpload("bucket", "multipart", "")
+	c.Assert(err, IsNil)
+	partETag, err := xlAPI.CreateObjectPart("bucket", "multipart", uploadID, 1, "", base64.StdEncoding.EncodeToString(sum[:]), int64(len(data)), bytes.NewReader(data), nil)
+	c.Assert(err, IsNil)
+	c.Assert(partETag, Equals, hex.EncodeToString(sum256[:]))
+	complete := CompleteMultipartUpload{Part: []CompletePart{{PartNumber: 1, ETag: partETag}}}
+	completeBytes, e := xml.Marshal(complete)
+	c.Assert(e, IsNil)
+	objMetadata, err = xlAPI.CompleteMultipartUpload("bucket", "multipart", uploadID, bytes.NewReader(completeBytes), nil)
+	c.Assert(err, IsNil)
+	multipartSum := sha256.Sum256(sum256[:])
+	c.Assert(objMetadata.MD5Sum, Equals, hex.EncodeToString(multipartSum[:])+"-1")
+}
+
+func (s *MyMD5Suite) TestETagAlgorithm(c *C) {
+	xlAPI, e := newBucketCacheTestXL(filepath.Join(s.root, "etag"))
+	c.Assert(e, IsNil)
+	s.testETagAlgorithm(c, xlAPI)
+
+	// read from the disks, verified with the sha256sum
+	reader, _, err := xlAPI.getObject("bucket", "sha256etag")
+	c.Assert(err, IsNil)
+	read, e := ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(e, IsNil)
+	c.Assert(string(read), Equals, "Hello World")
+}
+
+func (s *MyMD5Suite) TestETagAlgorithmMemory(c *C) {
+	SetXLConfigPath(filepath.Join(s.root, "etagmemory", "xl.json"))
+	xlAPI, err := New()
+	c.Assert(err, IsNil)
+	c.Assert(xlAPI.MakeBucket("bucket", "private", nil, nil), IsNil)
+	s.testETagAlgorithm(c, xlAPI)
+}
+
+func (s *MyMD5Suite) TestStreamingDigest(c *C) {
+	// two chunks, the digest is final once the second one is read
+	data := make([]byte, 11*1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+	firstChunk, writers := newFaultyWriters(4)
+	_, _, _, err := bucket{}.writeObjectData(2, 2, writers, bytes.NewReader(data[:10*1024*1024]), 10*1024*1024, ioutil.Discard, nil)
+	c.Assert(err, IsNil)
+	faultyWriters, writers := newFaultyWriters(4)
+	var verified int
+	verify := func() *probe.Error {
+		verified++
+		return probe.NewError(BadDigest{})
+	}
+	_, _, _, err = bucket{}.writeObjectData(2, 2, writers, bytes.NewReader(data), int64(len(data)), ioutil.Discard, verify)
+	c.Assert(err.ToGoError(), DeepEquals, BadDigest{})
+	c.Assert(verified, Equals, 1)
+	// blocks of the last chunk are never written
+	for i, writer := range faultyWriters {
+		c.Assert(writer.Len(), Equals, firstChunk[i].Len())
+	}
+
+	xlAPI, e := newBucketCacheTestXL(filepath.Join(s.root, "streaming"))
+	c.Assert(e, IsNil)
+	sum := md5.Sum(data)
+	objMetadata, err := xlAPI.CreateObject("bucket", "md5", base64.StdEncoding.EncodeToString(sum[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	c.Assert(objMetadata.MD5Sum, Equals, hex.EncodeToString(sum[:]))
+	reader, _, err := xlAPI.getObject("bucket", "md5")
+	c.Assert(err, IsNil)
+	read, e := ioutil.ReadAll(reader)
+	reader.Close()
+	c.Assert(e, IsNil)
+	c.Assert(bytes.Equal(read, data), Equals, true)
+
+	badSum := md5.Sum(data[1:])
+	_, err = xlAPI.CreateObject("bucket", "badmd5", base64.StdEncoding.EncodeToString(badSum[:]), int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, Not(IsNil))
+	c.Assert(err.ToGoError(), DeepEquals, BadDigest{})
+	_, err = xlAPI.GetObjectMetadata("bucket", "badmd5")
+	c.Assert(err, Not(IsNil))
+	_, e = os.Stat(filepath.Join(s.root, "streaming", "0", "bucketcache", "bucket$0$0", "badmd5"))
+	c.Assert(os.IsNotExist(e), Equals, true)
+}
+
+func benchmarkCreateObject(b *testing.B, disableMD5 bool) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-md5-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	xlAPI, err := newBucketCacheTestXL(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+	SetDisableMD5(disableMD5)
+	defer SetDisableMD5(false)
+	data := make([]byte, blockSize)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(data)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := xlAPI.CreateObject("bucket", "object"+strconv.Itoa(i), "", int64(len(data)), bytes.NewReader(data), nil, nil); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkCreateObjectMD5(b *testing.B) {
+	benchmarkCreateObject(b, false)
+}
+
+func BenchmarkCreateObjectNoMD5(b *testing.B) {
+	benchmarkCreateObject(b, true)
+}
@@ -0,0 +1,246 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"fmt"
+	"log"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// MetadataRecord - metadata of a bucket, or of one of its objects if Object is set. An export
+// is a stream of records, every bucket followed by its objects in lexical order.
+type MetadataRecord struct {
+	Bucket         string          `json:"bucket"`
+	Object         string          `json:"object,omitempty"`
+	BucketMetadata *BucketMetadata `json:"bucketMetadata,omitempty"`
+	ObjectMetadata *ObjectMetadata `json:"objectMetadata,omitempty"`
+}
+
+// Key - position of a record in an export, ‘BUCKET’ or ‘BUCKET/OBJECT’
+func (r MetadataRecord) Key() string {
+	if r.Object == "" {
+		return r.Bucket
+	}
+	return r.Bucket + "/" + r.Object
+}
+
+// MetadataImportResult - outcome of importing a record, records which are not imported say why
+type MetadataImportResult struct {
+	Bucket   string `json:"bucket"`
+	Object   string `json:"object,omitempty"`
+	Imported bool   `json:"imported"`
+	Reason   string `json:"reason,omitempty"`
+}
+
+// isAfterKey - bucket and object come after key in an export, bucket names never contain ‘/’
+func isAfterKey(bucket, object, key string) bool {
+	keyBucket, keyObject := key, ""
+	if i := strings.Index(key, "/"); i >= 0 {
+		keyBucket, keyObject = key[:i], key[i+1:]
+	}
+	if bucket != keyBucket {
+		return bucket > keyBucket
+	}
+	return object > keyObject
+}
+
+// ExportMetadata - call record for every bucket and object after startAfter, an empty startAfter
+// exports everything. Objects listed without readable metadata are logged and skipped, ongoing
+// multipart uploads are not exported.
+func (xl API) ExportMetadata(startAfter string, record func(MetadataRecord) *probe.Error) *probe.Error {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if len(xl.config.NodeDiskMap) == 0 {
+		return probe.NewError(InvalidDisksArgument{})
+	}
+	if err := xl.listXLBuckets(); err != nil {
+		return err.Trace()
+	}
+	allBuckets, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return err.Trace()
+	}
+	var bucketNames []string
+	for bucketName := range allBuckets.Buckets {
+		bucketNames = append(bucketNames, bucketName)
+	}
+	sort.Strings(bucketNames)
+	for _, bucketName := range bucketNames {
+		if !isAfterKey(bucketName, "\xff", startAfter) {
+			continue
+		}
+		bucketMetadata := allBuckets.Buckets[bucketName]
+		if isAfterKey(bucketName, "", startAfter) {
+			// objects are records of their own
+			exported := bucketMetadata
+			exported.Multiparts = nil
+			exported.BucketObjects = nil
+			exported.ObjectsSize = 0
+			if err := record(MetadataRecord{Bucket: bucketName, BucketMetadata: &exported}); err != nil {
+				return err.Trace(bucketName)
+			}
+		}
+		bkt, ok := xl.buckets[bucketName]
+		if !ok {
+			return probe.NewError(BucketNotFound{Bucket: bucketName})
+		}
+		var objectNames []string
+		for objectName := range bucketMetadata.BucketObjects {
+			if isAfterKey(bucketName, objectName, startAfter) {
+				objectNames = append(objectNames, objectName)
+			}
+		}
+		sort.Strings(objectNames)
+		for _, objectName := range objectNames {
+			objMetadata, err := bkt.readObjectMetadata(normalizeObjectName(objectName))
+			if err != nil {
+				log.Printf("Skipping %s/%s without readable metadata: %s", bucketName, objectName, err.ToGoError())
+				continue
+			}
+			if err := record(MetadataRecord{Bucket: bucketName, Object: objectName, ObjectMetadata: &objMetadata}); err != nil {
+				return err.Trace(bucketName, objectName)
+			}
+		}
+	}
+	return nil
+}
+
+// ImportMetadata - restore buckets and objects of an export. Buckets and objects which already
+// exist are left alone, objects are only imported if enough of their data blocks are on disks
+// to read them. Records are imported in order, a bucket before its objects.
+func (xl API) ImportMetadata(records []MetadataRecord) ([]MetadataImportResult, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if len(xl.config.NodeDiskMap) == 0 {
+		return nil, probe.NewError(InvalidDisksArgument{})
+	}
+	if err := xl.listXLBuckets(); err != nil {
+		return nil, err.Trace()
+	}
+	allBuckets, err := xl.getXLBucketMetadata()
+	if err != nil {
+		// all bucket metadata was lost
+		allBuckets = &AllBuckets{Version: bucketMetadataVersion}
+	}
+	if allBuckets.Buckets == nil {
+		allBuckets.Buckets = make(map[string]BucketMetadata)
+	}
+	var results []MetadataImportResult
+	var modified bool
+	for _, record := range records {
+		var reason string
+		var err *probe.Error
+		if record.Object == "" {
+			reason, err = xl.importBucketMetadata(allBuckets, record)
+		} else {
+			reason, err = xl.importObjectMetadata(allBuckets, record)
+		}
+		if err != nil {
+			return results, err.Trace(record.Key())
+		}
+		if reason == "" {
+			modified = true
+		}
+		results = append(results, MetadataImportResult{Bucket: record.Bucket, Object: record.Object, Imported: reason == "", Reason: reason})
+	}
+	if modified {
+		if err := xl.setXLBucketMetadata(allBuckets); err != nil {
+			return results, err.Trace()
+		}
+	}
+	return results, nil
+}
+
+// importBucketMetadata - add a bucket of an export to allBuckets, making its directories on disks
+// if they are missing. Returns why the bucket was not imported.
+func (xl API) importBucketMetadata(allBuckets *AllBuckets, record MetadataRecord) (string, *probe.Error) {
+	if record.BucketMetadata == nil || !IsValidBucket(record.Bucket) {
+		return "invalid bucket record", nil
+	}
+	if _, ok := allBuckets.Buckets[record.Bucket]; ok {
+		return "bucket exists", nil
+	}
+	bkt, bucketMetadata, err := newBucket(record.Bucket, record.BucketMetadata.ACL.String(), xl.config.XLName, xl.nodes)
+	if err != nil {
+		return "", err.Trace()
+	}
+	if err := xl.makeBucketSlices(record.Bucket); err != nil {
+		return "", err.Trace()
+	}
+	bucketMetadata.Created = record.BucketMetadata.Created
+	for key, value := range record.BucketMetadata.Metadata {
+		bucketMetadata.Metadata[key] = value
+	}
+	allBuckets.Buckets[record.Bucket] = bucketMetadata
+	xl.buckets[record.Bucket] = bkt
+	xl.bucketCache.set(record.Bucket)
+	var newBucket = storedBucket{}
+	newBucket.bucketMetadata = bucketMetadata
+	newBucket.objectMetadata = make(map[string]ObjectMetadata)
+	newBucket.multiPartSession = make(map[string]MultiPartSession)
+	newBucket.partMetadata = make(map[string]map[int]PartMetadata)
+	xl.storedBuckets.Set(record.Bucket, newBucket)
+	return "", nil
+}
+
+// importObjectMetadata - write metadata of an object of an export to disks and list it in its
+// bucket. Returns why the object was not imported.
+func (xl API) importObjectMetadata(allBuckets *AllBuckets, record MetadataRecord) (string, *probe.Error) {
+	objMetadata := record.ObjectMetadata
+	if objMetadata == nil || objMetadata.Bucket != record.Bucket || objMetadata.Object != record.Object || !IsValidObjectName(record.Object) {
+		return "invalid object record", nil
+	}
+	bucketMetadata, ok := allBuckets.Buckets[record.Bucket]
+	if !ok {
+		return "bucket not found", nil
+	}
+	if _, ok := bucketMetadata.BucketObjects[record.Object]; ok {
+		return "object exists", nil
+	}
+	bkt, ok := xl.buckets[record.Bucket]
+	if !ok {
+		return "bucket not found", nil
+	}
+	normalizedObjectName := normalizeObjectName(record.Object)
+	if totalBlocks := int(objMetadata.DataDisks) + int(objMetadata.ParityDisks); totalBlocks > 0 {
+		blocks, err := bkt.countDataBlocks(normalizedObjectName, *objMetadata)
+		if err != nil {
+			return "", err.Trace()
+		}
+		if blocks < int(objMetadata.DataDisks) {
+			return fmt.Sprintf("%d of %d data blocks found, %d needed", blocks, totalBlocks, objMetadata.DataDisks), nil
+		}
+	}
+	if err := bkt.writeObjectMetadata(normalizedObjectName, *objMetadata); err != nil {
+		return "", err.Trace()
+	}
+	if bucketMetadata.BucketObjects == nil {
+		bucketMetadata.BucketObjects = make(map[string]struct{})
+	}
+	bucketMetadata.BucketObjects[record.Object] = struct{}{}
+	bucketMetadata.ObjectsSize += objMetadata.Size
+	allBuckets.Buckets[record.Bucket] = bucketMetadata
+	xl.bucketStats.add(record.Bucket, 1, objMetadata.Size)
+	xl.metadataIndex.add(record.Bucket, record.Object, objMetadata.Metadata)
+	return "", nil
+}
@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	. "gopkg.in/check.v1"
+)
+
+// exportKeys - keys of all records exported after startAfter
+func exportKeys(c *C, xlAPI Interface, startAfter string) ([]MetadataRecord, []string) {
+	var records []MetadataRecord
+	var keys []string
+	err := xlAPI.ExportMetadata(startAfter, func(record MetadataRecord) *probe.Error {
+		records = append(records, record)
+		keys = append(keys, record.Key())
+		return nil
+	})
+	c.Assert(err, IsNil)
+	return records, keys
+}
+
+func (s *MyXLSuite) TestExportImportMetadata(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "export", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "public-read", nil, nil), IsNil)
+	c.Assert(xlAPI.SetBucketMetadata("foo", map[string]string{"acl": "public-read", "owner": "backup"}), IsNil)
+	c.Assert(xlAPI.MakeBucket("bar", "private", nil, nil), IsNil)
+	for i := 0; i < 3; i++ {
+		key := "obj" + strconv.Itoa(i)
+		data := bytes.Repeat([]byte(key), 1000)
+		_, err := xlAPI.CreateObject("foo", key, "", int64(len(data)), bytes.NewReader(data), map[string]string{"contentType": "text/plain"}, nil)
+		c.Assert(err, IsNil)
+	}
+
+	records, keys := exportKeys(c, xlAPI, "")
+	c.Assert(keys, DeepEquals, []string{"bar", "foo", "foo/obj0", "foo/obj1", "foo/obj2"})
+	c.Assert(records[1].BucketMetadata.BucketObjects, IsNil)
+	c.Assert(records[2].ObjectMetadata.Metadata["contentType"], Equals, "text/plain")
+
+	// an interrupted export continues after the last record written
+	_, keys = exportKeys(c, xlAPI, "foo/obj0")
+	c.Assert(keys, DeepEquals, []string{"foo/obj1", "foo/obj2"})
+	_, keys = exportKeys(c, xlAPI, "bar")
+	c.Assert(keys, DeepEquals, []string{"foo", "foo/obj0", "foo/obj1", "foo/obj2"})
+
+	// existing buckets and objects are left alone
+	results, err := xlAPI.ImportMetadata(records)
+	c.Assert(err, IsNil)
+	for _, result := range results {
+		c.Assert(result.Imported, Equals, false)
+	}
+
+	// bucket metadata lost, one object lost more blocks than its parity
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Remove(filepath.Join(root, strconv.Itoa(disk), "export", bucketMetadataConfig)), IsNil)
+	}
+	for disk := 0; disk < 3; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "export", "foo", disk, "obj2"), "data")), IsNil)
+	}
+	xlAPI = newTestXL(c, root, "export", 4)
+	results, err = xlAPI.ImportMetadata(records)
+	c.Assert(err, IsNil)
+	c.Assert(len(results), Equals, 5)
+	for _, result := range results[:4] {
+		c.Assert(result.Imported, Equals, true)
+	}
+	c.Assert(results[4].Imported, Equals, false)
+	c.Assert(results[4].Reason, Equals, "1 of 4 data blocks found, 2 needed")
+
+	metadata, err := xlAPI.GetBucketMetadata("foo")
+	c.Assert(err, IsNil)
+	c.Assert(metadata.ACL.String(), Equals, "public-read")
+	c.Assert(metadata.Metadata["owner"], Equals, "backup")
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo", "obj1", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), bytes.Repeat([]byte("obj1"), 1000)), Equals, true)
+	_, err = xlAPI.GetObjectMetadata("foo", "obj2")
+	c.Assert(err, Not(IsNil))
+	stats, err := xlAPI.GetBucketStats("foo")
+	c.Assert(err, IsNil)
+	c.Assert(stats.Objects, Equals, int64(2))
+
+	// objects of unknown buckets are not imported
+	results, err = xlAPI.ImportMetadata([]MetadataRecord{{Bucket: "baz", Object: "obj0", ObjectMetadata: records[2].ObjectMetadata}})
+	c.Assert(err, IsNil)
+	c.Assert(results[0].Reason, Equals, "invalid object record")
+}
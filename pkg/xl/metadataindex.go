@@ -0,0 +1,306 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// Search index of user metadata, enabled per bucket by saving "metadataIndex" in bucket metadata
+const (
+	MetadataIndexKey     = "metadataIndex"
+	MetadataIndexEnabled = "enabled"
+)
+
+// defaultMetadataIndexSize number of metadata values indexed over all buckets unless configured
+const defaultMetadataIndexSize = 1000000
+
+// internal variable only accessed via get/set methods
+var metadataIndexSize int64 = defaultMetadataIndexSize
+
+// SetMetadataIndexSize - set number of metadata values indexed over all buckets, buckets whose
+// index would not fit are searched by reading metadata of every object instead
+func SetMetadataIndexSize(size int64) {
+	if size < 0 {
+		size = 0
+	}
+	atomic.StoreInt64(&metadataIndexSize, size)
+}
+
+// getMetadataIndexSize - get number of metadata values indexed over all buckets
+func getMetadataIndexSize() int64 {
+	return atomic.LoadInt64(&metadataIndexSize)
+}
+
+// isMetadataIndexed - search index is enabled for the bucket
+func isMetadataIndexed(bucketMetadata BucketMetadata) bool {
+	return bucketMetadata.Metadata[MetadataIndexKey] == MetadataIndexEnabled
+}
+
+// bucketIndex - objects of a bucket by user metadata key and value, keys are lower case
+type bucketIndex struct {
+	values  map[string]map[string]map[string]struct{}
+	objects map[string]map[string]string
+	entries int64
+}
+
+func newBucketIndex() *bucketIndex {
+	return &bucketIndex{
+		values:  make(map[string]map[string]map[string]struct{}),
+		objects: make(map[string]map[string]string),
+	}
+}
+
+// metadataIndex - search indexes of buckets, built by a scan on first search and kept up to
+// date by writes from then on. A bucket whose index grew beyond the size is left unindexed
+type metadataIndex struct {
+	lock     *sync.Mutex
+	entries  int64
+	buckets  map[string]*bucketIndex
+	overflow map[string]bool
+}
+
+func newMetadataIndex() *metadataIndex {
+	return &metadataIndex{
+		lock:     &sync.Mutex{},
+		buckets:  make(map[string]*bucketIndex),
+		overflow: make(map[string]bool),
+	}
+}
+
+// getIndexedMetadata - user metadata of an object as indexed, keys in lower case
+func getIndexedMetadata(metadata map[string]string) map[string]string {
+	indexed := make(map[string]string)
+	for key, value := range metadata {
+		if IsUserMetadata(key) {
+			indexed[strings.ToLower(key)] = value
+		}
+	}
+	return indexed
+}
+
+// removeLocked - remove an object from the bucket index
+func (m *metadataIndex) removeLocked(b *bucketIndex, object string) {
+	for key, value := range b.objects[object] {
+		delete(b.values[key][value], object)
+		if len(b.values[key][value]) == 0 {
+			delete(b.values[key], value)
+		}
+		if len(b.values[key]) == 0 {
+			delete(b.values, key)
+		}
+		b.entries--
+		m.entries--
+	}
+	delete(b.objects, object)
+}
+
+// addLocked - index an object, replacing any previous metadata of it. Returns false once the
+// bucket index does not fit, it is then dropped
+func (m *metadataIndex) addLocked(bucket string, b *bucketIndex, object string, metadata map[string]string) bool {
+	m.removeLocked(b, object)
+	indexed := getIndexedMetadata(metadata)
+	if len(indexed) == 0 {
+		return true
+	}
+	if m.entries+int64(len(indexed)) > getMetadataIndexSize() {
+		m.entries -= b.entries
+		delete(m.buckets, bucket)
+		m.overflow[bucket] = true
+		return false
+	}
+	for key, value := range indexed {
+		if b.values[key] == nil {
+			b.values[key] = make(map[string]map[string]struct{})
+		}
+		if b.values[key][value] == nil {
+			b.values[key][value] = make(map[string]struct{})
+		}
+		b.values[key][value][object] = struct{}{}
+	}
+	b.objects[object] = indexed
+	b.entries += int64(len(indexed))
+	m.entries += int64(len(indexed))
+	return true
+}
+
+// add - index a written object, nothing is done for buckets not indexed yet
+func (m *metadataIndex) add(bucket, object string, metadata map[string]string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if b, ok := m.buckets[bucket]; ok {
+		m.addLocked(bucket, b, object, metadata)
+	}
+}
+
+// remove - remove a deleted object from the index
+func (m *metadataIndex) remove(bucket, object string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if b, ok := m.buckets[bucket]; ok {
+		m.removeLocked(b, object)
+	}
+}
+
+// drop - forget the index of a bucket, it is built anew on the next search
+func (m *metadataIndex) drop(bucket string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if b, ok := m.buckets[bucket]; ok {
+		m.entries -= b.entries
+		delete(m.buckets, bucket)
+	}
+	delete(m.overflow, bucket)
+}
+
+// search - objects whose metadata key has value, false if the bucket is not indexed
+func (m *metadataIndex) search(bucket, key, value string) ([]string, bool) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	b, ok := m.buckets[bucket]
+	if !ok {
+		return nil, false
+	}
+	var objects []string
+	for object := range b.values[strings.ToLower(key)][value] {
+		objects = append(objects, object)
+	}
+	sort.Strings(objects)
+	return objects, true
+}
+
+// build - index the metadata of every object, false if it does not fit
+func (m *metadataIndex) build(bucket string, objects map[string]map[string]string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if b, ok := m.buckets[bucket]; ok {
+		m.entries -= b.entries
+	}
+	delete(m.overflow, bucket)
+	b := newBucketIndex()
+	m.buckets[bucket] = b
+	for object, metadata := range objects {
+		if !m.addLocked(bucket, b, object, metadata) {
+			return false
+		}
+	}
+	return true
+}
+
+// isOverflow - index of the bucket did not fit, searches scan the bucket
+func (m *metadataIndex) isOverflow(bucket string) bool {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	return m.overflow[bucket]
+}
+
+// scanMetadata - metadata of every object of a bucket, read from disks
+func (xl API) scanMetadata(bucket string) (map[string]map[string]string, *probe.Error) {
+	objects := make(map[string]map[string]string)
+	if len(xl.config.NodeDiskMap) == 0 {
+		storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+		for objectKey, objMetadata := range storedBucket.objectMetadata {
+			if strings.HasPrefix(objectKey, bucket+"/") {
+				objects[objMetadata.Object] = objMetadata.Metadata
+			}
+		}
+		return objects, nil
+	}
+	if err := xl.checkBucket(bucket); err != nil {
+		return nil, err.Trace()
+	}
+	bucketMeta, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	for object := range bucketMeta.Buckets[bucket].BucketObjects {
+		objMetadata, err := xl.buckets[bucket].GetObjectMetadata(object)
+		if err != nil {
+			return nil, err.Trace(bucket, object)
+		}
+		objects[object] = objMetadata.Metadata
+	}
+	return objects, nil
+}
+
+// SearchMetadata - objects of a bucket with user metadata key set to value, key with or without
+// the ‘x-amz-meta-’ prefix in any case. The index of the bucket is built by reading metadata of
+// every object on the first search, buckets whose index does not fit are read on every search
+func (xl API) SearchMetadata(bucket, key, value string) ([]string, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return nil, probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return nil, probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	if !isMetadataIndexed(xl.storedBuckets.Get(bucket).(storedBucket).bucketMetadata) {
+		return nil, probe.NewError(MetadataIndexDisabled{Bucket: bucket})
+	}
+	if !IsUserMetadata(key) {
+		key = userMetadataPrefix + key
+	}
+	if objects, ok := xl.metadataIndex.search(bucket, key, value); ok {
+		return objects, nil
+	}
+	scanned, err := xl.scanMetadata(bucket)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	if !xl.metadataIndex.isOverflow(bucket) && xl.metadataIndex.build(bucket, scanned) {
+		objects, _ := xl.metadataIndex.search(bucket, key, value)
+		return objects, nil
+	}
+	var objects []string
+	for object, metadata := range scanned {
+		if getIndexedMetadata(metadata)[strings.ToLower(key)] == value {
+			objects = append(objects, object)
+		}
+	}
+	sort.Strings(objects)
+	return objects, nil
+}
+
+// RebuildMetadataIndex - index the bucket anew by reading metadata of every object, also for
+// buckets whose index did not fit before
+func (xl API) RebuildMetadataIndex(bucket string) *probe.Error {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if !IsValidBucket(bucket) {
+		return probe.NewError(BucketNameInvalid{Bucket: bucket})
+	}
+	if !xl.storedBuckets.Exists(bucket) {
+		return probe.NewError(BucketNotFound{Bucket: bucket})
+	}
+	if !isMetadataIndexed(xl.storedBuckets.Get(bucket).(storedBucket).bucketMetadata) {
+		return probe.NewError(MetadataIndexDisabled{Bucket: bucket})
+	}
+	scanned, err := xl.scanMetadata(bucket)
+	if err != nil {
+		return err.Trace()
+	}
+	xl.metadataIndex.build(bucket, scanned)
+	return nil
+}
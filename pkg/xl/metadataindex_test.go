@@ -0,0 +1,95 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestSearchMetadata(c *C) {
+	defer SetMetadataIndexSize(defaultMetadataIndexSize)
+	root := c.MkDir()
+	for _, withDisks := range []bool{true, false} {
+		var xlAPI API
+		if withDisks {
+			xlAPI = newTestXL(c, root, "metadataindex", 4)
+		} else {
+			xlAPI = newTestMemoryXL(c, root)
+		}
+		c.Assert(xlAPI.MakeBucket("search", "private", nil, nil), IsNil)
+		putObject := func(object string, metadata map[string]string) {
+			data := []byte("hello " + object)
+			_, err := xlAPI.CreateObject("search", object, "", int64(len(data)), bytes.NewReader(data), metadata, nil)
+			c.Assert(err, IsNil)
+		}
+		putObject("a", map[string]string{"x-amz-meta-project": "foo"})
+		putObject("b", map[string]string{"X-Amz-Meta-Project": "foo", "x-amz-meta-owner": "alice"})
+		putObject("c", map[string]string{"x-amz-meta-project": "bar"})
+
+		// opt-in per bucket
+		_, err := xlAPI.SearchMetadata("search", "project", "foo")
+		c.Assert(err.ToGoError(), DeepEquals, MetadataIndexDisabled{Bucket: "search"})
+		c.Assert(xlAPI.SetBucketMetadata("search", map[string]string{MetadataIndexKey: MetadataIndexEnabled}), IsNil)
+
+		// first search builds the index, keys match in any case with or without prefix
+		objects, err := xlAPI.SearchMetadata("search", "project", "foo")
+		c.Assert(err, IsNil)
+		c.Assert(objects, DeepEquals, []string{"a", "b"})
+		_, ok := xlAPI.metadataIndex.search("search", "x-amz-meta-project", "foo")
+		c.Assert(ok, Equals, true)
+		objects, err = xlAPI.SearchMetadata("search", "X-AMZ-META-PROJECT", "foo")
+		c.Assert(err, IsNil)
+		c.Assert(objects, DeepEquals, []string{"a", "b"})
+		objects, err = xlAPI.SearchMetadata("search", "project", "Foo")
+		c.Assert(err, IsNil)
+		c.Assert(len(objects), Equals, 0)
+
+		// writes keep the index up to date
+		putObject("d", map[string]string{"x-amz-meta-project": "foo"})
+		c.Assert(xlAPI.DeleteObject("search", "a", ""), IsNil)
+		_, err = xlAPI.SetObjectMetadata("search", "c", map[string]string{"x-amz-meta-project": "foo"})
+		c.Assert(err, IsNil)
+		_, err = xlAPI.MoveObject("search", "b", "e")
+		c.Assert(err, IsNil)
+		objects, err = xlAPI.SearchMetadata("search", "project", "foo")
+		c.Assert(err, IsNil)
+		c.Assert(objects, DeepEquals, []string{"c", "d", "e"})
+		objects, err = xlAPI.SearchMetadata("search", "owner", "alice")
+		c.Assert(err, IsNil)
+		c.Assert(objects, DeepEquals, []string{"e"})
+
+		// buckets whose index does not fit are searched by reading all objects
+		SetMetadataIndexSize(2)
+		c.Assert(xlAPI.RebuildMetadataIndex("search"), IsNil)
+		c.Assert(xlAPI.metadataIndex.isOverflow("search"), Equals, true)
+		c.Assert(xlAPI.metadataIndex.entries, Equals, int64(0))
+		objects, err = xlAPI.SearchMetadata("search", "project", "foo")
+		c.Assert(err, IsNil)
+		c.Assert(objects, DeepEquals, []string{"c", "d", "e"})
+		SetMetadataIndexSize(defaultMetadataIndexSize)
+		c.Assert(xlAPI.RebuildMetadataIndex("search"), IsNil)
+		c.Assert(xlAPI.metadataIndex.isOverflow("search"), Equals, false)
+		c.Assert(xlAPI.metadataIndex.entries, Equals, int64(4))
+
+		c.Assert(xlAPI.SetBucketMetadata("search", map[string]string{MetadataIndexKey: ""}), IsNil)
+		c.Assert(xlAPI.metadataIndex.entries, Equals, int64(0))
+		_, err = xlAPI.SearchMetadata("search", "project", "foo")
+		c.Assert(err.ToGoError(), DeepEquals, MetadataIndexDisabled{Bucket: "search"})
+	}
+}
@@ -63,14 +63,21 @@ func (xl API) NewMultipartUpload(bucket, key, contentType string) (string, *prob
 	if _, ok := storedBucket.objectMetadata[objectKey]; ok == true {
 		return "", probe.NewError(ObjectExists{Object: key})
 	}
+	// a new upload of the same key replaces the previous one
+	if _, ok := storedBucket.multiPartSession[key]; !ok {
+		if max := getMaxMultipartUploads(); max > 0 && xl.countMultipartUploads() >= max {
+			return "", probe.NewError(TooManyMultipartUploads{Max: max})
+		}
+	}
 	id := []byte(strconv.Itoa(rand.Int()) + bucket + key + time.Now().UTC().String())
 	uploadIDSum := sha512.Sum512(id)
 	uploadID := base64.URLEncoding.EncodeToString(uploadIDSum[:])[:47]
 
 	storedBucket.multiPartSession[key] = MultiPartSession{
-		UploadID:   uploadID,
-		Initiated:  time.Now().UTC(),
-		TotalParts: 0,
+		UploadID:    uploadID,
+		ContentType: contentType,
+		Initiated:   time.Now().UTC(),
+		TotalParts:  0,
 	}
 	storedBucket.partMetadata[key] = make(map[int]PartMetadata)
 	multiPartCache := data.NewCache(0)
@@ -119,6 +126,33 @@ func (xl API) CreateObjectPart(bucket, key, uploadID string, partID int, content
 	return etag, err.Trace()
 }
 
+// CopyObjectPart - create a part in a multipart session from length bytes of an existing object
+// starting at start, '0' length copies up to the end of the object
+func (xl API) CopyObjectPart(bucket, key, uploadID string, partID int, sourceBucket, sourceObject string, start, length int64) (string, *probe.Error) {
+	objMetadata, err := xl.GetObjectMetadata(sourceBucket, sourceObject)
+	if err != nil {
+		return "", err.Trace()
+	}
+	if length == 0 {
+		length = objMetadata.Size - start
+	}
+	if start < 0 || length < 0 || start+length > objMetadata.Size || (length == 0 && start > 0) {
+		return "", probe.NewError(InvalidRange{Start: start, Length: length})
+	}
+	// both calls take the lock, the range is buffered rather than streamed from one to the other
+	var buffer bytes.Buffer
+	if length > 0 {
+		if _, err := xl.GetObject(&buffer, sourceBucket, sourceObject, start, length); err != nil {
+			return "", err.Trace()
+		}
+	}
+	etag, err := xl.CreateObjectPart(bucket, key, uploadID, partID, "", "", length, &buffer, nil)
+	if err != nil {
+		return "", err.Trace()
+	}
+	return etag, nil
+}
+
 // createObject - internal wrapper function called by CreateObjectPart
 func (xl API) createObjectPart(bucket, key, uploadID string, partID int, contentType, expectedMD5Sum string, size int64, data io.Reader, signature *signv4.Signature) (string, *probe.Error) {
 	if !IsValidBucket(bucket) {
@@ -181,9 +215,13 @@ func (xl API) createObjectPart(bucket, key, uploadID string, partID int, content
 		expectedMD5Sum = hex.EncodeToString(expectedMD5SumBytes)
 	}
 
-	// calculate md5
+	// calculate md5, the etag is the sha256sum instead if configured so
 	hash := md5.New()
 	sha256hash := sha256.New()
+	etagHash := hash
+	if getETagAlgorithm() == ETagSHA256 {
+		etagHash = sha256hash
+	}
 
 	var totalLength int64
 	var err error
@@ -210,11 +248,10 @@ func (xl API) createObjectPart(bucket, key, uploadID string, partID int, content
 		return "", probe.NewError(err)
 	}
 
-	md5SumBytes := hash.Sum(nil)
-	md5Sum := hex.EncodeToString(md5SumBytes)
+	md5Sum := hex.EncodeToString(etagHash.Sum(nil))
 	// Verify if the written object is equal to what is expected, only if it is requested as such
 	if strings.TrimSpace(expectedMD5Sum) != "" {
-		if err := isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), md5Sum); err != nil {
+		if err := isMD5SumEqual(strings.TrimSpace(expectedMD5Sum), hex.EncodeToString(hash.Sum(nil))); err != nil {
 			return "", err.Trace()
 		}
 	}
@@ -260,24 +297,11 @@ func (xl API) cleanupMultipartSession(bucket, key, uploadID string) {
 
 func (xl API) mergeMultipart(parts *CompleteMultipartUpload, uploadID string, fullObjectWriter *io.PipeWriter) {
 	for _, part := range parts.Part {
-		recvMD5 := part.ETag
 		object, ok := xl.multiPartObjects[uploadID].Get(part.PartNumber)
 		if ok == false {
 			fullObjectWriter.CloseWithError(probe.WrapError(probe.NewError(InvalidPart{})))
 			return
 		}
-		calcMD5Bytes := md5.Sum(object)
-		// complete multi part request header md5sum per part is hex encoded
-		recvMD5Bytes, err := hex.DecodeString(strings.Trim(recvMD5, "\""))
-		if err != nil {
-			fullObjectWriter.CloseWithError(probe.WrapError(probe.NewError(InvalidDigest{Md5: recvMD5})))
-			return
-		}
-		if !bytes.Equal(recvMD5Bytes, calcMD5Bytes[:]) {
-			fullObjectWriter.CloseWithError(probe.WrapError(probe.NewError(BadDigest{})))
-			return
-		}
-
 		if _, err := io.Copy(fullObjectWriter, bytes.NewReader(object)); err != nil {
 			fullObjectWriter.CloseWithError(probe.WrapError(probe.NewError(err)))
 			return
@@ -288,16 +312,57 @@ func (xl API) mergeMultipart(parts *CompleteMultipartUpload, uploadID string, fu
 	return
 }
 
+// verifyStagedParts - verify every part against the etag recorded when it was staged and the etag
+// it is completed with, before anything is written. A corrupt part is discarded so that the client
+// can upload just that part again and retry the completion
+func (xl API) verifyStagedParts(bucket, key, uploadID string, parts *CompleteMultipartUpload) *probe.Error {
+	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
+	for _, part := range parts.Part {
+		object, ok := xl.multiPartObjects[uploadID].Get(part.PartNumber)
+		partMetadata, staged := storedBucket.partMetadata[key][part.PartNumber]
+		if !ok || !staged {
+			return probe.NewError(InvalidPart{})
+		}
+		// complete multi part request header md5sum per part is hex encoded
+		recvMD5Bytes, err := hex.DecodeString(strings.Trim(part.ETag, "\""))
+		if err != nil {
+			return probe.NewError(InvalidDigest{Md5: part.ETag})
+		}
+		// uploads do not outlive the server, parts were staged with the current etag algorithm
+		etagHash := getETagAlgorithm().newHash()
+		etagHash.Write(object)
+		calcMD5Bytes := etagHash.Sum(nil)
+		if int64(len(object)) != partMetadata.Size || hex.EncodeToString(calcMD5Bytes) != partMetadata.ETag {
+			xl.multiPartObjects[uploadID].Delete(part.PartNumber)
+			delete(storedBucket.partMetadata[key], part.PartNumber)
+			multiPartSession := storedBucket.multiPartSession[key]
+			multiPartSession.TotalParts--
+			storedBucket.multiPartSession[key] = multiPartSession
+			xl.storedBuckets.Set(bucket, storedBucket)
+			return probe.NewError(CorruptPart{UploadID: uploadID, PartNumber: part.PartNumber})
+		}
+		if !bytes.Equal(recvMD5Bytes, calcMD5Bytes) {
+			return probe.NewError(BadDigest{})
+		}
+	}
+	return nil
+}
+
 // CompleteMultipartUpload - complete a multipart upload and persist the data
 func (xl API) CompleteMultipartUpload(bucket, key, uploadID string, data io.Reader, signature *signv4.Signature) (ObjectMetadata, *probe.Error) {
 	xl.lock.Lock()
 	defer xl.lock.Unlock()
 	size := int64(xl.multiPartObjects[uploadID].Stats().Bytes)
-	fullObjectReader, err := xl.completeMultipartUploadV2(bucket, key, uploadID, data, signature)
+	fullObjectReader, etag, err := xl.completeMultipartUploadV2(bucket, key, uploadID, data, signature)
 	if err != nil {
 		return ObjectMetadata{}, err.Trace()
 	}
-	objectMetadata, err := xl.createObject(bucket, key, "", "", size, fullObjectReader, nil)
+	metadata := map[string]string{"multipartETag": etag}
+	if xl.storedBuckets.Exists(bucket) {
+		// content type the upload was initiated with selects the erasure ratio
+		metadata[ErasureContentTypeKey] = xl.storedBuckets.Get(bucket).(storedBucket).multiPartSession[key].ContentType
+	}
+	objectMetadata, err := xl.createObject(bucket, key, "", size, fullObjectReader, metadata, nil)
 	if err != nil {
 		// No need to call internal cleanup functions here, caller should call AbortMultipartUpload()
 		// which would in-turn cleanup properly in accordance with S3 Spec
@@ -307,12 +372,12 @@ func (xl API) CompleteMultipartUpload(bucket, key, uploadID string, data io.Read
 	return objectMetadata, nil
 }
 
-func (xl API) completeMultipartUploadV2(bucket, key, uploadID string, data io.Reader, signature *signv4.Signature) (io.Reader, *probe.Error) {
+func (xl API) completeMultipartUploadV2(bucket, key, uploadID string, data io.Reader, signature *signv4.Signature) (io.Reader, string, *probe.Error) {
 	if !IsValidBucket(bucket) {
-		return nil, probe.NewError(BucketNameInvalid{Bucket: bucket})
+		return nil, "", probe.NewError(BucketNameInvalid{Bucket: bucket})
 	}
 	if !IsValidObjectName(key) {
-		return nil, probe.NewError(ObjectNameInvalid{Object: key})
+		return nil, "", probe.NewError(ObjectNameInvalid{Object: key})
 	}
 
 	// TODO: multipart support for xl is broken, since we haven't finalized the format in which
@@ -324,38 +389,50 @@ func (xl API) completeMultipartUploadV2(bucket, key, uploadID string, data io.Re
 	//	}
 
 	if !xl.storedBuckets.Exists(bucket) {
-		return nil, probe.NewError(BucketNotFound{Bucket: bucket})
+		return nil, "", probe.NewError(BucketNotFound{Bucket: bucket})
 	}
 	storedBucket := xl.storedBuckets.Get(bucket).(storedBucket)
 	// Verify upload id
 	if storedBucket.multiPartSession[key].UploadID != uploadID {
-		return nil, probe.NewError(InvalidUploadID{UploadID: uploadID})
+		return nil, "", probe.NewError(InvalidUploadID{UploadID: uploadID})
 	}
 	partBytes, err := ioutil.ReadAll(data)
 	if err != nil {
-		return nil, probe.NewError(err)
+		return nil, "", probe.NewError(err)
 	}
 	if signature != nil {
 		ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sha256.Sum256(partBytes)[:]))
 		if err != nil {
-			return nil, err.Trace()
+			return nil, "", err.Trace()
 		}
 		if !ok {
-			return nil, probe.NewError(signv4.DoesNotMatch{})
+			return nil, "", probe.NewError(signv4.DoesNotMatch{})
 		}
 	}
 	parts := &CompleteMultipartUpload{}
 	if err := xml.Unmarshal(partBytes, parts); err != nil {
-		return nil, probe.NewError(MalformedXML{})
+		return nil, "", probe.NewError(MalformedXML{})
 	}
 	if !sort.IsSorted(completedParts(parts.Part)) {
-		return nil, probe.NewError(InvalidPartOrder{})
+		return nil, "", probe.NewError(InvalidPartOrder{})
+	}
+	if err := xl.verifyStagedParts(bucket, key, uploadID, parts); err != nil {
+		return nil, "", err.Trace()
+	}
+
+	var partETags []string
+	for _, part := range parts.Part {
+		partETags = append(partETags, part.ETag)
+	}
+	etag, perr := getMultipartETag(partETags)
+	if perr != nil {
+		return nil, "", perr.Trace()
 	}
 
 	fullObjectReader, fullObjectWriter := io.Pipe()
 	go xl.mergeMultipart(parts, uploadID, fullObjectWriter)
 
-	return fullObjectReader, nil
+	return fullObjectReader, etag, nil
 }
 
 // byKey is a sortable interface for UploadMetadata slice
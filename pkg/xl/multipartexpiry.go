@@ -0,0 +1,96 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"log"
+	"sort"
+	"sync/atomic"
+	"time"
+)
+
+// internal variable only accessed via get/set methods
+var multipartTTL int64
+
+// SetMultipartTTL - multipart uploads with no part uploaded for ttl are aborted by a background
+// task of xls created with New, freeing their staged parts. '0' disables the task.
+func SetMultipartTTL(ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	atomic.StoreInt64(&multipartTTL, int64(ttl))
+}
+
+// getMultipartTTL - get multipart ttl
+func getMultipartTTL() time.Duration {
+	return time.Duration(atomic.LoadInt64(&multipartTTL))
+}
+
+// getLastPartActivity - when the last part of an upload was uploaded, when it was initiated
+// as long as it has no parts
+func getLastPartActivity(session MultiPartSession, parts map[int]PartMetadata) time.Time {
+	lastActivity := session.Initiated
+	for _, part := range parts {
+		if part.LastModified.After(lastActivity) {
+			lastActivity = part.LastModified
+		}
+	}
+	return lastActivity
+}
+
+// expireMultipartUploads - abort uploads with no part uploaded within ttl before now, returns
+// the ids of the uploads aborted
+func (xl API) expireMultipartUploads(ttl time.Duration, now time.Time) []string {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	var bucketNames []string
+	buckets := xl.storedBuckets.GetAll()
+	for bucketName := range buckets {
+		bucketNames = append(bucketNames, bucketName)
+	}
+	sort.Strings(bucketNames)
+	var aborted []string
+	for _, bucketName := range bucketNames {
+		storedBucket := buckets[bucketName].(storedBucket)
+		for key, session := range storedBucket.multiPartSession {
+			if now.Sub(getLastPartActivity(session, storedBucket.partMetadata[key])) < ttl {
+				continue
+			}
+			xl.cleanupMultipartSession(bucketName, key, session.UploadID)
+			delete(xl.multiPartObjects, session.UploadID)
+			log.Printf("Aborted multipart upload %s of %s/%s, no part uploaded for %s", session.UploadID, bucketName, key, ttl)
+			aborted = append(aborted, session.UploadID)
+		}
+	}
+	return aborted
+}
+
+// expireMultipartUploadsLoop - look for stale uploads twice per ttl, uploads are aborted between
+// one and one and a half ttl after their last part
+func (xl API) expireMultipartUploadsLoop() {
+	for {
+		ttl := getMultipartTTL()
+		if ttl == 0 {
+			return
+		}
+		time.Sleep(ttl / 2)
+		withBackgroundIOPriority(func() {
+			xl.expireMultipartUploads(ttl, time.Now().UTC())
+		})
+	}
+}
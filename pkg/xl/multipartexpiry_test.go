@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyMultipartExpirySuite struct {
+	root string
+}
+
+var _ = Suite(&MyMultipartExpirySuite{})
+
+func (s *MyMultipartExpirySuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-multipart-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyMultipartExpirySuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+func (s *MyMultipartExpirySuite) TestExpireMultipartUploads(c *C) {
+	SetXLConfigPath(filepath.Join(s.root, "missing.json"))
+	xl, err := New()
+	c.Assert(err, IsNil)
+	xlAPI := xl.(API)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	abandoned, err := xlAPI.NewMultipartUpload("foo", "abandoned", "")
+	c.Assert(err, IsNil)
+	active, err := xlAPI.NewMultipartUpload("foo", "active", "")
+	c.Assert(err, IsNil)
+
+	// both initiated two hours ago, a part of active was uploaded since
+	storedBucket := xlAPI.storedBuckets.Get("foo").(storedBucket)
+	for _, key := range []string{"abandoned", "active"} {
+		session := storedBucket.multiPartSession[key]
+		session.Initiated = time.Now().UTC().Add(-2 * time.Hour)
+		storedBucket.multiPartSession[key] = session
+	}
+	data := bytes.Repeat([]byte("part"), 1024)
+	_, err = xlAPI.CreateObjectPart("foo", "active", active, 1, "", "", int64(len(data)), bytes.NewReader(data), nil)
+	c.Assert(err, IsNil)
+
+	now := time.Now().UTC()
+	c.Assert(xlAPI.expireMultipartUploads(time.Hour, now), DeepEquals, []string{abandoned})
+	c.Assert(xlAPI.CountMultipartUploads(), Equals, 1)
+	_, ok := xlAPI.multiPartObjects[abandoned]
+	c.Assert(ok, Equals, false)
+	_, err = xlAPI.CreateObjectPart("foo", "abandoned", abandoned, 1, "", "", int64(len(data)), bytes.NewReader(data), nil)
+	c.Assert(err, Not(IsNil))
+
+	// timer of the active upload runs from its last part
+	c.Assert(xlAPI.expireMultipartUploads(time.Hour, now.Add(59*time.Minute)), IsNil)
+	c.Assert(xlAPI.expireMultipartUploads(time.Hour, now.Add(61*time.Minute)), DeepEquals, []string{active})
+	c.Assert(xlAPI.CountMultipartUploads(), Equals, 0)
+	c.Assert(xlAPI.AbortMultipartUpload("foo", "active", active), Not(IsNil))
+}
@@ -0,0 +1,52 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import "sync/atomic"
+
+// internal variable only accessed via get/set methods
+var maxMultipartUploads int32
+
+// SetMaxMultipartUploads - set number of multipart uploads open at once across all buckets,
+// new uploads are rejected above it. '0' means unlimited.
+func SetMaxMultipartUploads(max int) {
+	if max < 0 {
+		max = 0
+	}
+	atomic.StoreInt32(&maxMultipartUploads, int32(max))
+}
+
+// getMaxMultipartUploads - get maximum number of open multipart uploads
+func getMaxMultipartUploads() int {
+	return int(atomic.LoadInt32(&maxMultipartUploads))
+}
+
+// CountMultipartUploads - number of multipart uploads initiated and neither completed nor aborted
+func (xl API) CountMultipartUploads() int {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+	return xl.countMultipartUploads()
+}
+
+// countMultipartUploads - callers hold the xl lock
+func (xl API) countMultipartUploads() int {
+	var uploads int
+	for _, bucket := range xl.storedBuckets.GetAll() {
+		uploads += len(bucket.(storedBucket).multiPartSession)
+	}
+	return uploads
+}
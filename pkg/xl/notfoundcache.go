@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// defaultNotFoundCacheTTL - objects created by this server are never masked since they are
+	// dropped from the cache right away, the ttl only bounds how long an object created by
+	// another server on the same disks is reported missing
+	defaultNotFoundCacheTTL = time.Second
+	// maxNotFoundCacheEntries - bounds memory used by requests for random keys
+	maxNotFoundCacheEntries = 10000
+)
+
+// internal variable only accessed via get/set methods
+var notFoundCacheTTL = int64(defaultNotFoundCacheTTL)
+
+// diskObjectLookups - number of times object metadata was looked up on disks
+var diskObjectLookups int64
+
+// SetNotFoundCacheTTL - set how long an object found missing on disks is reported missing
+// without looking at the disks again, '0' disables caching
+func SetNotFoundCacheTTL(ttl time.Duration) {
+	if ttl < 0 {
+		ttl = 0
+	}
+	atomic.StoreInt64(&notFoundCacheTTL, int64(ttl))
+}
+
+// getNotFoundCacheTTL - get not found cache ttl
+func getNotFoundCacheTTL() time.Duration {
+	return time.Duration(atomic.LoadInt64(&notFoundCacheTTL))
+}
+
+// notFoundCache - objects recently found missing on disks and when
+type notFoundCache struct {
+	lock    *sync.Mutex
+	missing map[string]time.Time
+}
+
+func newNotFoundCache() *notFoundCache {
+	return &notFoundCache{
+		lock:    &sync.Mutex{},
+		missing: make(map[string]time.Time),
+	}
+}
+
+// isMissing - object was found missing on disks within the ttl
+func (c *notFoundCache) isMissing(objectKey string) bool {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	checked, ok := c.missing[objectKey]
+	if ok && time.Since(checked) >= getNotFoundCacheTTL() {
+		delete(c.missing, objectKey)
+		return false
+	}
+	return ok
+}
+
+// set - object was just found missing on disks
+func (c *notFoundCache) set(objectKey string) {
+	ttl := getNotFoundCacheTTL()
+	if ttl == 0 {
+		return
+	}
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	if len(c.missing) >= maxNotFoundCacheEntries {
+		for key, checked := range c.missing {
+			if time.Since(checked) >= ttl {
+				delete(c.missing, key)
+			}
+		}
+		// all entries are fresh, start over rather than growing without bounds
+		if len(c.missing) >= maxNotFoundCacheEntries {
+			c.missing = make(map[string]time.Time)
+		}
+	}
+	c.missing[objectKey] = time.Now()
+}
+
+// remove - object was created
+func (c *notFoundCache) remove(objectKey string) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	delete(c.missing, objectKey)
+}
+
+// getCachedObjectMetadata - object metadata from disks, objects recently found missing are
+// reported missing without looking at the disks
+func (xl API) getCachedObjectMetadata(bucket, object string) (ObjectMetadata, *probe.Error) {
+	objectKey := bucket + "/" + object
+	if xl.notFoundCache.isMissing(objectKey) {
+		return ObjectMetadata{}, probe.NewError(ObjectNotFound{Object: object})
+	}
+	objMetadata, err := xl.getObjectMetadata(bucket, object)
+	if err != nil {
+		if _, ok := err.ToGoError().(ObjectNotFound); ok {
+			xl.notFoundCache.set(objectKey)
+		}
+		return ObjectMetadata{}, err.Trace()
+	}
+	return objMetadata, nil
+}
@@ -0,0 +1,110 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyNotFoundCacheSuite struct {
+	root string
+}
+
+var _ = Suite(&MyNotFoundCacheSuite{})
+
+func (s *MyNotFoundCacheSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-notfoundcache-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *MyNotFoundCacheSuite) TearDownSuite(c *C) {
+	SetNotFoundCacheTTL(defaultNotFoundCacheTTL)
+	os.RemoveAll(s.root)
+}
+
+func (s *MyNotFoundCacheSuite) TestNotFoundCache(c *C) {
+	SetNotFoundCacheTTL(100 * time.Millisecond)
+	xlAPI, err := newBucketCacheTestXL(s.root)
+	c.Assert(err, IsNil)
+
+	// object found missing is not looked up again
+	lookups := atomic.LoadInt64(&diskObjectLookups)
+	for i := 0; i < 10; i++ {
+		_, perr := xlAPI.GetObjectMetadata("bucket", "missing")
+		c.Assert(perr.ToGoError(), DeepEquals, ObjectNotFound{Object: "missing"})
+		var buffer bytes.Buffer
+		_, perr = xlAPI.GetObject(&buffer, "bucket", "missing", 0, 0)
+		c.Assert(perr.ToGoError(), DeepEquals, ObjectNotFound{Object: "missing"})
+	}
+	c.Assert(atomic.LoadInt64(&diskObjectLookups), Equals, lookups+1)
+
+	// object created is visible right away
+	data := []byte("Hello World")
+	_, perr := xlAPI.CreateObject("bucket", "missing", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+	metadata, perr := xlAPI.GetObjectMetadata("bucket", "missing")
+	c.Assert(perr, IsNil)
+	c.Assert(metadata.Size, Equals, int64(len(data)))
+
+	// disks are consulted again once the entry expires
+	_, perr = xlAPI.GetObjectMetadata("bucket", "other")
+	c.Assert(perr.ToGoError(), DeepEquals, ObjectNotFound{Object: "other"})
+	lookups = atomic.LoadInt64(&diskObjectLookups)
+	time.Sleep(getNotFoundCacheTTL())
+	_, perr = xlAPI.GetObjectMetadata("bucket", "other")
+	c.Assert(perr.ToGoError(), DeepEquals, ObjectNotFound{Object: "other"})
+	c.Assert(atomic.LoadInt64(&diskObjectLookups), Equals, lookups+1)
+}
+
+func benchmarkNotFoundCache(b *testing.B, ttl time.Duration) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-notfoundcache-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	SetNotFoundCacheTTL(ttl)
+	defer SetNotFoundCacheTTL(defaultNotFoundCacheTTL)
+	xlAPI, err := newBucketCacheTestXL(root)
+	if err != nil {
+		b.Fatal(err)
+	}
+
+	lookups := atomic.LoadInt64(&diskObjectLookups)
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		if _, err := xlAPI.GetObjectMetadata("bucket", "missing"); err == nil {
+			b.Fatal("object should be missing")
+		}
+	}
+	b.ReportMetric(float64(atomic.LoadInt64(&diskObjectLookups)-lookups)/float64(b.N), "lookups/op")
+}
+
+func BenchmarkGetMissingObjectNotFoundCache(b *testing.B) {
+	benchmarkNotFoundCache(b, defaultNotFoundCacheTTL)
+}
+
+func BenchmarkGetMissingObjectNoNotFoundCache(b *testing.B) {
+	benchmarkNotFoundCache(b, 0)
+}
@@ -0,0 +1,39 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import "sync/atomic"
+
+// defaultMaxObjectNameLength - longest object name in bytes allowed by S3
+const defaultMaxObjectNameLength = 1024
+
+// internal variable only accessed via get/set methods
+var maxObjectNameLength int32 = defaultMaxObjectNameLength
+
+// SetMaxObjectNameLength - set longest object name in bytes accepted, values smaller than 1
+// restore the default of 1024.
+func SetMaxObjectNameLength(length int) {
+	if length < 1 {
+		length = defaultMaxObjectNameLength
+	}
+	atomic.StoreInt32(&maxObjectNameLength, int32(length))
+}
+
+// getMaxObjectNameLength - get longest object name accepted
+func getMaxObjectNameLength() int {
+	return int(atomic.LoadInt32(&maxObjectNameLength))
+}
@@ -0,0 +1,98 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"log"
+	"sync/atomic"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// internal variable only accessed via get/set methods
+var orphanExpiry int64
+
+// SetOrphanExpiry - blocks and unfinished writes on disks not referenced by any object, such as
+// left behind by failed writes and multipart completions, are removed by a background task of xls
+// created with New once older than expiry. '0' disables the task.
+func SetOrphanExpiry(expiry time.Duration) {
+	if expiry < 0 {
+		expiry = 0
+	}
+	atomic.StoreInt64(&orphanExpiry, int64(expiry))
+}
+
+// getOrphanExpiry - get orphan expiry
+func getOrphanExpiry() time.Duration {
+	return time.Duration(atomic.LoadInt64(&orphanExpiry))
+}
+
+// OrphanStats - orphans removed by the background task since the xl was created
+type OrphanStats struct {
+	Removed        int64 `json:"removed"`
+	ReclaimedBytes int64 `json:"reclaimedBytes"`
+}
+
+// orphanStats - counters shared by all copies of an xl
+type orphanStats struct {
+	removed        int64
+	reclaimedBytes int64
+}
+
+// GetOrphanStats - orphans removed by the background task so far
+func (xl API) GetOrphanStats() OrphanStats {
+	return OrphanStats{
+		Removed:        atomic.LoadInt64(&xl.orphanStats.removed),
+		ReclaimedBytes: atomic.LoadInt64(&xl.orphanStats.reclaimedBytes),
+	}
+}
+
+// expireOrphans - remove orphans older than the expiry from all disks. The xl is only locked
+// while its buckets are listed, requests are served while disks are walked. Writes in progress
+// are never mistaken for orphans, they keep refreshing the modification time of their object
+func (xl API) expireOrphans(expiry time.Duration) *probe.Error {
+	xl.lock.Lock()
+	allBuckets, buckets, err := xl.getFsckBuckets()
+	xl.lock.Unlock()
+	if err != nil {
+		return err.Trace()
+	}
+	summary, err := xl.fsckBuckets(FsckConfig{Fix: true, OrphansOnly: true, MinAge: expiry}, allBuckets, buckets)
+	if err != nil {
+		return err.Trace()
+	}
+	atomic.AddInt64(&xl.orphanStats.removed, int64(summary.Fixed))
+	atomic.AddInt64(&xl.orphanStats.reclaimedBytes, summary.ReclaimedBytes)
+	return nil
+}
+
+// expireOrphansLoop - look for expired orphans once per expiry
+func (xl API) expireOrphansLoop() {
+	for {
+		expiry := getOrphanExpiry()
+		if expiry == 0 {
+			return
+		}
+		time.Sleep(expiry)
+		withBackgroundIOPriority(func() {
+			if err := xl.expireOrphans(expiry); err != nil {
+				log.Printf("Removing expired orphans failed: %s", err.ToGoError())
+			}
+		})
+	}
+}
@@ -0,0 +1,128 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"crypto/md5"
+	"encoding/hex"
+	"encoding/xml"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// completeUpload - complete an upload of a single part with etag
+func completeUpload(xlAPI API, uploadID, etag string) error {
+	complete := CompleteMultipartUpload{Part: []CompletePart{{PartNumber: 1, ETag: etag}}}
+	completeBytes, e := xml.Marshal(complete)
+	if e != nil {
+		return e
+	}
+	if _, err := xlAPI.CompleteMultipartUpload("rollback", "object", uploadID, bytes.NewReader(completeBytes), nil); err != nil {
+		return err.ToGoError()
+	}
+	return nil
+}
+
+func (s *MyXLSuite) TestFailedCompletionRollback(c *C) {
+	root := c.MkDir()
+	for _, withDisks := range []bool{true, false} {
+		var xlAPI API
+		if withDisks {
+			xlAPI = newTestXL(c, root, "orphans", 4)
+		} else {
+			xlAPI = newTestMemoryXL(c, root)
+		}
+		c.Assert(xlAPI.MakeBucket("rollback", "private", nil, nil), IsNil)
+		uploadID, err := xlAPI.NewMultipartUpload("rollback", "object", "")
+		c.Assert(err, IsNil)
+		data := bytes.Repeat([]byte("part"), 1024)
+		etag, err := xlAPI.CreateObjectPart("rollback", "object", uploadID, 1, "", "", int64(len(data)), bytes.NewReader(data), nil)
+		c.Assert(err, IsNil)
+
+		// part fails verification, nothing reaches the disks
+		wrongETag := md5.Sum([]byte("other"))
+		c.Assert(completeUpload(xlAPI, uploadID, hex.EncodeToString(wrongETag[:])), Not(IsNil))
+		var buffer bytes.Buffer
+		_, err = xlAPI.GetObject(&buffer, "rollback", "object", 0, 0)
+		c.Assert(err, Not(IsNil))
+		if withDisks {
+			for disk := 0; disk < 4; disk++ {
+				_, e := os.Stat(testObjectPath(root, "orphans", "rollback", disk, "object"))
+				c.Assert(os.IsNotExist(e), Equals, true)
+			}
+		}
+
+		// upload is still valid and completes
+		c.Assert(completeUpload(xlAPI, uploadID, etag), IsNil)
+		buffer.Reset()
+		_, err = xlAPI.GetObject(&buffer, "rollback", "object", 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+		if withDisks {
+			c.Assert(xlAPI.DeleteObject("rollback", "object", ""), IsNil)
+		}
+	}
+}
+
+func (s *MyXLSuite) TestExpireOrphans(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "orphans", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	data := []byte("Hello World")
+	_, err := xlAPI.CreateObject("foo", "valid", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+
+	// completion which never made it to the bucket, unfinished write of a listed object
+	c.Assert(os.MkdirAll(testObjectPath(root, "orphans", "foo", 0, "crashed"), 0700), IsNil)
+	c.Assert(ioutil.WriteFile(filepath.Join(testObjectPath(root, "orphans", "foo", 0, "crashed"), "data"), []byte("crashed"), 0600), IsNil)
+	unfinished := filepath.Join(testObjectPath(root, "orphans", "foo", 1, "valid"), "$deleteme.data")
+	c.Assert(ioutil.WriteFile(unfinished, []byte("unfinished"), 0600), IsNil)
+
+	// recent orphans may belong to writes still in progress
+	c.Assert(xlAPI.expireOrphans(time.Hour), IsNil)
+	c.Assert(xlAPI.GetOrphanStats(), DeepEquals, OrphanStats{})
+	_, e := os.Stat(testObjectPath(root, "orphans", "foo", 0, "crashed"))
+	c.Assert(e, IsNil)
+
+	old := time.Now().Add(-2 * time.Hour)
+	for _, path := range []string{filepath.Join(testObjectPath(root, "orphans", "foo", 0, "crashed"), "data"), testObjectPath(root, "orphans", "foo", 0, "crashed"), unfinished} {
+		c.Assert(os.Chtimes(path, old, old), IsNil)
+	}
+	// objects are neither expired, however old, nor repaired
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "orphans", "foo", 2, "valid"), objectMetadataConfig)), IsNil)
+	for disk := 0; disk < 4; disk++ {
+		c.Assert(os.Chtimes(testObjectPath(root, "orphans", "foo", disk, "valid"), old, old), IsNil)
+	}
+	c.Assert(xlAPI.expireOrphans(time.Hour), IsNil)
+	c.Assert(xlAPI.GetOrphanStats(), DeepEquals, OrphanStats{Removed: 2, ReclaimedBytes: 17})
+	_, e = os.Stat(testObjectPath(root, "orphans", "foo", 0, "crashed"))
+	c.Assert(os.IsNotExist(e), Equals, true)
+	_, e = os.Stat(unfinished)
+	c.Assert(os.IsNotExist(e), Equals, true)
+	_, e = os.Stat(filepath.Join(testObjectPath(root, "orphans", "foo", 2, "valid"), objectMetadataConfig))
+	c.Assert(os.IsNotExist(e), Equals, true)
+
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo", "valid", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(buffer.String(), Equals, "Hello World")
+}
@@ -0,0 +1,81 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"io"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// maxReadAhead limits memory used for prefetching, every stripe buffers up to blockSize of decoded data
+const maxReadAhead = 16
+
+// internal variable only accessed via get/set methods
+var readAhead int32
+
+// SetReadAhead - set number of stripes decoded ahead of the client on object reads from disk,
+// '0' disables read-ahead. Values larger than 16 are capped at 16.
+func SetReadAhead(stripes int) {
+	if stripes < 0 {
+		stripes = 0
+	}
+	if stripes > maxReadAhead {
+		stripes = maxReadAhead
+	}
+	atomic.StoreInt32(&readAhead, int32(stripes))
+}
+
+// getReadAhead - get number of stripes to read ahead
+func getReadAhead() int {
+	return int(atomic.LoadInt32(&readAhead))
+}
+
+// decodedChunk - a decoded stripe or the error which stopped the prefetch
+type decodedChunk struct {
+	data []byte
+	err  *probe.Error
+}
+
+// prefetchObjectData - decode stripes of an object in background, at most 'stripes' decoded
+// stripes are buffered while the client is still consuming the current one. Checksums are
+// updated here as well, keeping them off the path of the client. Prefetch stops at the first
+// error or when done is closed.
+func (b bucket) prefetchObjectData(objMetadata ObjectMetadata, readers map[int]io.ReadCloser, encoder encoder, stripes int, hashWriter io.Writer, writer *io.PipeWriter, done <-chan struct{}) <-chan decodedChunk {
+	chunks := make(chan decodedChunk, stripes)
+	go func() {
+		defer close(chunks)
+		totalLeft := objMetadata.Size
+		for i := 0; i < objMetadata.ChunkCount; i++ {
+			decodedData, err := b.decodeEncodedData(totalLeft, int64(objMetadata.BlockSize), readers, encoder, writer)
+			if err == nil {
+				hashWriter.Write(decodedData)
+			}
+			select {
+			case chunks <- decodedChunk{data: decodedData, err: err}:
+			case <-done:
+				return
+			}
+			if err != nil {
+				return
+			}
+			totalLeft = totalLeft - int64(objMetadata.BlockSize)
+		}
+	}()
+	return chunks
+}
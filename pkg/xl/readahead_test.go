@@ -0,0 +1,125 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"testing"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestReadAhead(c *C) {
+	defer SetReadAhead(0)
+	// two and a half stripes
+	data := make([]byte, blockSize*2+blockSize/2)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(data)
+	xlAPI := newTestXL(c, c.MkDir(), "readahead", 8)
+	c.Assert(xlAPI.MakeBucket("bucket", "private", nil, nil), IsNil)
+	_, perr := xlAPI.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+	for _, stripes := range []int{0, 1, 4, 100} {
+		SetReadAhead(stripes)
+		reader, size, perr := xlAPI.getObject("bucket", "object")
+		c.Assert(perr, IsNil)
+		c.Assert(size, Equals, int64(len(data)))
+		readData, err := ioutil.ReadAll(reader)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(readData, data), Equals, true)
+	}
+	c.Assert(getReadAhead(), Equals, maxReadAhead)
+
+	// client going away in the middle of a read must not block the prefetch
+	SetReadAhead(2)
+	reader, _, perr := xlAPI.getObject("bucket", "object")
+	c.Assert(perr, IsNil)
+	_, err := io.CopyN(ioutil.Discard, reader, blockSize/2)
+	c.Assert(err, IsNil)
+	c.Assert(reader.Close(), IsNil)
+}
+
+// throttledReader - slow client, every read takes time proportional to the bytes read
+type throttledReader struct {
+	reader         io.Reader
+	bytesPerSecond int64
+}
+
+func (t throttledReader) Read(p []byte) (int, error) {
+	n, err := t.reader.Read(p)
+	time.Sleep(time.Duration(int64(n) * int64(time.Second) / t.bytesPerSecond))
+	return n, err
+}
+
+func benchmarkReadAhead(b *testing.B, stripes int) {
+	root, err := ioutil.TempDir(os.TempDir(), "xl-readahead-")
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer os.RemoveAll(root)
+	data := make([]byte, blockSize*4)
+	rand.New(rand.NewSource(time.Now().UnixNano())).Read(data)
+	xlAPI, perr := createTestXL(root, "readahead", 8)
+	if perr != nil {
+		b.Fatal(perr)
+	}
+	if perr := xlAPI.MakeBucket("bucket", "private", nil, nil); perr != nil {
+		b.Fatal(perr)
+	}
+	if _, perr := xlAPI.CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil); perr != nil {
+		b.Fatal(perr)
+	}
+	SetReadAhead(stripes)
+	defer SetReadAhead(0)
+
+	b.SetBytes(int64(len(data)))
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		reader, _, err := xlAPI.getObject("bucket", "object")
+		if err != nil {
+			b.Fatal(err)
+		}
+		// client consuming at 40MB/s in 1MB reads
+		client := throttledReader{reader, 40 * 1024 * 1024}
+		buffer := make([]byte, 1024*1024)
+		for {
+			_, err := client.Read(buffer)
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				b.Fatal(err)
+			}
+		}
+	}
+}
+
+func BenchmarkReadAheadDisabled(b *testing.B) {
+	benchmarkReadAhead(b, 0)
+}
+
+func BenchmarkReadAhead2(b *testing.B) {
+	benchmarkReadAhead(b, 2)
+}
+
+func BenchmarkReadAhead4(b *testing.B) {
+	benchmarkReadAhead(b, 4)
+}
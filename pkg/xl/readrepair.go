@@ -0,0 +1,169 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"log"
+	"path/filepath"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl/disk"
+)
+
+// internal variable only accessed via get/set methods
+var readRepair int32
+
+// SetReadRepair - enable rewriting blocks found missing or unreadable by a read which still
+// reconstructed the object from parity, costs an extra write of the lost blocks
+func SetReadRepair(enable bool) {
+	var value int32
+	if enable {
+		value = 1
+	}
+	atomic.StoreInt32(&readRepair, value)
+}
+
+// isReadRepair - is read repair enabled
+func isReadRepair() bool {
+	return atomic.LoadInt32(&readRepair) == 1
+}
+
+// getLostBlocks - blocks of an object with no reader left after reading it, either missing
+// when the object was opened or dropped once they failed to read
+func getLostBlocks(readers map[int]io.ReadCloser, objMetadata ObjectMetadata) []int {
+	var lost []int
+	for order := 0; order < int(objMetadata.DataDisks)+int(objMetadata.ParityDisks); order++ {
+		if _, ok := readers[order]; !ok {
+			lost = append(lost, order)
+		}
+	}
+	return lost
+}
+
+// repairObject - rewrite the lost blocks of an object from the blocks still readable. Objects
+// changed since they were read and deduplicated objects, whose blocks are shared, are skipped.
+// Nothing is written unless the reconstructed object matches its checksum.
+func (b bucket) repairObject(objectName string, objMetadata ObjectMetadata, lost []int) *probe.Error {
+	if objMetadata.DedupKey != "" || len(lost) == 0 {
+		return nil
+	}
+	b.lock.Lock()
+	defer b.lock.Unlock()
+
+	current, err := b.readObjectMetadata(objectName)
+	if err != nil {
+		return err.Trace()
+	}
+	if current.SHA512Sum != objMetadata.SHA512Sum || getDataFile(current) != getDataFile(objMetadata) {
+		return nil
+	}
+	readers, err := b.getDataReaders(objectName, objMetadata)
+	if err != nil {
+		return err.Trace()
+	}
+	for _, reader := range readers {
+		defer reader.Close()
+	}
+	// lost blocks which are still present are truncated or unreadable, never decode from them
+	for _, order := range lost {
+		delete(readers, order)
+	}
+	lost, disks, writers, err := b.getRepairWriters(objectName, getDataFile(objMetadata), lost)
+	if err != nil {
+		return err.Trace()
+	}
+	if len(writers) == 0 {
+		return nil
+	}
+	encoder, err := newEncoder(objMetadata.DataDisks, objMetadata.ParityDisks)
+	if err != nil {
+		CleanupWritersOnError(writers)
+		return err.Trace()
+	}
+	sum512hasher := sha512.New()
+	totalLeft := objMetadata.Size
+	for i := 0; i < objMetadata.ChunkCount; i++ {
+		decodedData, err := b.decodeEncodedData(totalLeft, int64(objMetadata.BlockSize), readers, encoder, nil)
+		if err != nil {
+			CleanupWritersOnError(writers)
+			return err.Trace()
+		}
+		sum512hasher.Write(decodedData)
+		encodedBlocks, err := encoder.Encode(decodedData)
+		if err != nil {
+			CleanupWritersOnError(writers)
+			return err.Trace()
+		}
+		for i, order := range lost {
+			if err := writeBlock(writers[i], encodedBlocks[order]); err != nil {
+				CleanupWritersOnError(writers)
+				return probe.NewError(err)
+			}
+		}
+		totalLeft = totalLeft - int64(objMetadata.BlockSize)
+	}
+	if hex.EncodeToString(sum512hasher.Sum(nil)) != objMetadata.SHA512Sum {
+		CleanupWritersOnError(writers)
+		return probe.NewError(ChecksumMismatch{})
+	}
+	for i, writer := range writers {
+		if err := writer.Close(); err != nil {
+			CleanupWritersOnError(writers[i:])
+			return probe.NewError(err)
+		}
+		log.Printf("Read repair of %s/%s rewrote block %d on disk %s", b.getBucketName(), objMetadata.Object, lost[i], disks[i].GetPath())
+	}
+	return nil
+}
+
+// getRepairWriters - writers replacing the data file of an object on the disks of the given
+// orders, returns the orders of the disks still attached along with them
+func (b bucket) getRepairWriters(objectName, dataFile string, orders []int) ([]int, []disk.Disk, []io.WriteCloser, *probe.Error) {
+	// TODO handle node slices, blocks of an object are only ever written to the first node
+	var nodeDisks map[int]disk.Disk
+	for _, node := range b.nodes {
+		var err *probe.Error
+		if nodeDisks, err = node.ListDisks(); err != nil {
+			return nil, nil, nil, err.Trace()
+		}
+		break
+	}
+	var attached []int
+	var disks []disk.Disk
+	var writers []io.WriteCloser
+	for _, order := range orders {
+		d, ok := nodeDisks[order]
+		if !ok {
+			continue
+		}
+		bucketSlice := fmt.Sprintf("%s$%d$%d", b.name, 0, order)
+		writer, err := d.CreateFile(filepath.Join(b.xlName, bucketSlice, objectName, dataFile))
+		if err != nil {
+			CleanupWritersOnError(writers)
+			return nil, nil, nil, err.Trace()
+		}
+		attached = append(attached, order)
+		disks = append(disks, d)
+		writers = append(writers, writer)
+	}
+	return attached, disks, writers, nil
+}
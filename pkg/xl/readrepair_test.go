@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+// waitForRepair - wait for read repair to write the block at dataPath in the background
+func waitForRepair(c *C, dataPath string, size int64) {
+	for i := 0; i < 100; i++ {
+		if fi, err := os.Stat(dataPath); err == nil && fi.Size() == size {
+			return
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Fatalf("block %s was not repaired", dataPath)
+}
+
+// readRepairObject - read an object back through a fresh xl, nothing is served from cache
+func readRepairObject(c *C, root, object string, data []byte) {
+	var buffer bytes.Buffer
+	size, err := newTestXL(c, root, "readrepair", 4).GetObject(&buffer, "foo", object, 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(size, Equals, int64(len(data)))
+	c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+}
+
+func (s *MyXLSuite) TestReadRepair(c *C) {
+	defer SetReadRepair(false)
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "readrepair", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	// spans two stripes
+	data := bytes.Repeat([]byte("readrepair"), 1500000)
+	_, err := xlAPI.CreateObject("foo", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	fi, e := os.Stat(filepath.Join(testObjectPath(root, "readrepair", "foo", 0, "object"), "data"))
+	c.Assert(e, IsNil)
+	blockSize := fi.Size()
+
+	// disabled by default, lost blocks stay lost
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "readrepair", "foo", 1, "object"), "data")), IsNil)
+	readRepairObject(c, root, "object", data)
+	time.Sleep(50 * time.Millisecond)
+	_, e = os.Stat(filepath.Join(testObjectPath(root, "readrepair", "foo", 1, "object"), "data"))
+	c.Assert(os.IsNotExist(e), Equals, true)
+
+	// a missing block and a truncated one are rewritten
+	SetReadRepair(true)
+	c.Assert(os.Truncate(filepath.Join(testObjectPath(root, "readrepair", "foo", 2, "object"), "data"), blockSize/3), IsNil)
+	readRepairObject(c, root, "object", data)
+	waitForRepair(c, filepath.Join(testObjectPath(root, "readrepair", "foo", 1, "object"), "data"), blockSize)
+	waitForRepair(c, filepath.Join(testObjectPath(root, "readrepair", "foo", 2, "object"), "data"), blockSize)
+
+	// repaired blocks alone are enough to read the object
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "readrepair", "foo", 0, "object"), "data")), IsNil)
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "readrepair", "foo", 3, "object"), "data")), IsNil)
+	readRepairObject(c, root, "object", data)
+	waitForRepair(c, filepath.Join(testObjectPath(root, "readrepair", "foo", 0, "object"), "data"), blockSize)
+	waitForRepair(c, filepath.Join(testObjectPath(root, "readrepair", "foo", 3, "object"), "data"), blockSize)
+
+	// objects with more blocks lost than parity can not be read nor repaired
+	for disk := 0; disk < 3; disk++ {
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "readrepair", "foo", disk, "object"), "data")), IsNil)
+	}
+	var buffer bytes.Buffer
+	_, err = newTestXL(c, root, "readrepair", 4).GetObject(&buffer, "foo", "object", 0, 0)
+	c.Assert(err, Not(IsNil))
+	time.Sleep(50 * time.Millisecond)
+	_, e = os.Stat(filepath.Join(testObjectPath(root, "readrepair", "foo", 0, "object"), "data"))
+	c.Assert(os.IsNotExist(e), Equals, true)
+}
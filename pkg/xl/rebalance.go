@@ -0,0 +1,262 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// RebalanceConfig - rebalance parameters
+type RebalanceConfig struct {
+	// RateLimit maximum number of bytes moved per second, '0' means unlimited
+	RateLimit int64
+	// DryRun reports objects which would be moved, nothing is written
+	DryRun bool
+	// Progress if set is called after every object is processed
+	Progress func(RebalanceProgress)
+}
+
+// RebalanceProgress - reported after every object is processed
+type RebalanceProgress struct {
+	Bucket     string `json:"bucket"`
+	Object     string `json:"object"`
+	Size       int64  `json:"size"`
+	Moved      bool   `json:"moved"`
+	MovedBytes int64  `json:"movedBytes"`
+}
+
+// rateLimiter shared by all the readers of a rebalance, limits total bytes read per second
+type rateLimiter struct {
+	rate  int64
+	start time.Time
+	total int64
+}
+
+// rateLimitedReader - reader throttled by a rateLimiter
+type rateLimitedReader struct {
+	reader  io.Reader
+	limiter *rateLimiter
+}
+
+func (r rateLimitedReader) Read(p []byte) (int, error) {
+	if r.limiter == nil || r.limiter.rate <= 0 {
+		return r.reader.Read(p)
+	}
+	if int64(len(p)) > r.limiter.rate {
+		p = p[:r.limiter.rate]
+	}
+	n, err := r.reader.Read(p)
+	r.limiter.total += int64(n)
+	// computed in float64, total * time.Second overflows int64 past ~9.2GB
+	expected := time.Duration(float64(r.limiter.total) / float64(r.limiter.rate) * float64(time.Second))
+	if elapsed := time.Since(r.limiter.start); expected > elapsed {
+		time.Sleep(expected - elapsed)
+	}
+	return n, err
+}
+
+// rebalance - redistribute all objects across all the currently attached disks
+func (xl API) rebalance(config RebalanceConfig) *probe.Error {
+	if err := xl.listXLBuckets(); err != nil {
+		return err.Trace()
+	}
+	allBuckets, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return err.Trace()
+	}
+	limiter := &rateLimiter{rate: config.RateLimit, start: time.Now()}
+	var movedBytes int64
+	// process buckets and objects in a fixed order, progress reported is easier to follow
+	var bucketNames []string
+	for bucketName := range allBuckets.Buckets {
+		bucketNames = append(bucketNames, bucketName)
+	}
+	sort.Strings(bucketNames)
+	for _, bucketName := range bucketNames {
+		bkt, ok := xl.buckets[bucketName]
+		if !ok {
+			return probe.NewError(BucketNotFound{Bucket: bucketName})
+		}
+		var objectNames []string
+		for objectName := range allBuckets.Buckets[bucketName].BucketObjects {
+			objectNames = append(objectNames, objectName)
+		}
+		sort.Strings(objectNames)
+		for _, objectName := range objectNames {
+			moved, size, err := bkt.rebalanceObject(objectName, limiter, config.DryRun)
+			if err != nil {
+				return err.Trace(bucketName, objectName)
+			}
+			if moved {
+				movedBytes += size
+			}
+			if config.Progress != nil {
+				config.Progress(RebalanceProgress{
+					Bucket:     bucketName,
+					Object:     objectName,
+					Size:       size,
+					Moved:      moved,
+					MovedBytes: movedBytes,
+				})
+			}
+		}
+	}
+	return nil
+}
+
+// getTotalDisks - total number of disks an object is written to
+func (b bucket) getTotalDisks() (int, *probe.Error) {
+	var totalDisks int
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return 0, err.Trace()
+		}
+		totalDisks += len(disks)
+	}
+	return totalDisks, nil
+}
+
+// rebalanceObject - re-encode an object across all the disks, objects already spread across
+// all the disks are left untouched which makes an interrupted rebalance resumable.
+//
+// New blocks are written to a new data file, object metadata is switched to it only after
+// all the blocks are written, readers hence always find blocks matching the metadata they read.
+// A dry run stops short of writing and reports the object as moved.
+func (b bucket) rebalanceObject(objectName string, limiter *rateLimiter, dryRun bool) (bool, int64, *probe.Error) {
+	normalizedObjectName := normalizeObjectName(objectName)
+	objMetadata, err := b.readObjectMetadata(normalizedObjectName)
+	if err != nil {
+		return false, 0, err.Trace()
+	}
+	totalDisks, err := b.getTotalDisks()
+	if err != nil {
+		return false, 0, err.Trace()
+	}
+	if totalDisks <= 1 || int(objMetadata.DataDisks)+int(objMetadata.ParityDisks) == totalDisks {
+		return false, objMetadata.Size, nil
+	}
+	if dryRun {
+		return true, objMetadata.Size, nil
+	}
+	dataFile := "data." + strconv.Itoa(totalDisks)
+	writers, err := b.getObjectWriters(normalizedObjectName, dataFile)
+	if err != nil {
+		return false, 0, err.Trace()
+	}
+	writers = newBlockChecksumWriters(writers)
+	// objects keep their ratio of data to parity blocks
+	k, m, err := b.getDataAndParity(len(writers), ErasureRatio{Data: int(objMetadata.DataDisks), Parity: int(objMetadata.ParityDisks)})
+	if err != nil {
+		CleanupWritersOnError(writers)
+		return false, 0, err.Trace()
+	}
+	readers, err := b.getDataReaders(normalizedObjectName, objMetadata)
+	if err != nil {
+		CleanupWritersOnError(writers)
+		return false, 0, err.Trace()
+	}
+	reader, writer := io.Pipe()
+	goIO(func() { b.readObjectData(readers, writer, objMetadata) })
+	chunkCount, totalLength, offline, err := b.writeObjectData(k, m, writers, rateLimitedReader{reader, limiter}, objMetadata.Size, ioutil.Discard, nil)
+	reader.Close()
+	if err != nil {
+		CleanupWritersOnError(writers)
+		return false, 0, err.Trace()
+	}
+	if int64(totalLength) != objMetadata.Size {
+		CleanupWritersOnError(writers)
+		return false, 0, probe.NewError(IncompleteBody{Bucket: b.getBucketName(), Object: objectName})
+	}
+	for _, writer := range writers {
+		if err := writer.Close(); err != nil {
+			return false, 0, probe.NewError(err)
+		}
+	}
+
+	b.lock.Lock()
+	defer b.lock.Unlock()
+	objMetadata.BlockSize = blockSize
+	objMetadata.ChunkCount = chunkCount
+	objMetadata.DataDisks = k
+	objMetadata.ParityDisks = m
+	objMetadata.DataFile = dataFile
+	// re-encoded blocks are no longer shared with other objects
+	dedupKey := objMetadata.DedupKey
+	objMetadata.DedupKey = ""
+	// an interrupted rebalance leaves integrity of the new blocks behind, the object is still
+	// not spread across all the disks and is re-encoded again
+	if err := b.writeObjectIntegrity(normalizedObjectName, newObjectIntegrity(objMetadata, getBlockChecksums(writers, offline))); err != nil {
+		return false, 0, err.Trace()
+	}
+	if err := b.writeObjectMetadata(normalizedObjectName, objMetadata); err != nil {
+		return false, 0, err.Trace()
+	}
+	// servers may still be reading the old blocks, they are left for fsck and orphan expiry
+	if dedupKey != "" {
+		if err := b.retireDedupData(dedupKey); err != nil {
+			return false, 0, err.Trace()
+		}
+	}
+	if err := b.touchStaleDataFiles(normalizedObjectName, dataFile); err != nil {
+		return false, 0, err.Trace()
+	}
+	return true, objMetadata.Size, nil
+}
+
+// touchStaleDataFiles - data files of an object on all disks other than the current one are
+// orphans from now on, rebalance runs apart from the server which may still be reading them.
+// They are marked as modified now so orphan expiry only removes them after its expiry
+func (b bucket) touchStaleDataFiles(objectName, dataFile string) *probe.Error {
+	nodeSlice := 0
+	for _, node := range b.nodes {
+		disks, err := node.ListDisks()
+		if err != nil {
+			return err.Trace()
+		}
+		for order, disk := range disks {
+			bucketSlice := fmt.Sprintf("%s$%d$%d", b.getBucketName(), nodeSlice, order)
+			objectPath := filepath.Join(b.xlName, bucketSlice, objectName)
+			files, err := disk.ListFiles(objectPath)
+			if err != nil {
+				// object was never written to this disk
+				continue
+			}
+			for _, file := range files {
+				if file.Name() == dataFile || !strings.HasPrefix(file.Name(), "data") {
+					continue
+				}
+				now := time.Now()
+				if err := os.Chtimes(filepath.Join(disk.GetPath(), objectPath, file.Name()), now, now); err != nil {
+					return probe.NewError(err)
+				}
+			}
+		}
+		nodeSlice = nodeSlice + 1
+	}
+	return nil
+}
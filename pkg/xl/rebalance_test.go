@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"os"
+	"path/filepath"
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestRebalance(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "rebalance", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	objects := make(map[string][]byte)
+	for i := 0; i < 3; i++ {
+		key := "obj" + strconv.Itoa(i)
+		data := bytes.Repeat([]byte(key), 100000*(i+1))
+		objects[key] = data
+		_, err := xlAPI.CreateObject("foo", key, "", int64(len(data)), bytes.NewReader(data), nil, nil)
+		c.Assert(err, IsNil)
+	}
+
+	// add disks, a dry run reports what a rebalance moves without writing anything
+	xlAPI = newTestXL(c, root, "rebalance", 8)
+	var progress []RebalanceProgress
+	err := xlAPI.Rebalance(RebalanceConfig{
+		DryRun:   true,
+		Progress: func(p RebalanceProgress) { progress = append(progress, p) },
+	})
+	c.Assert(err, IsNil)
+	dryRun := progress
+	for disk := 0; disk < 8; disk++ {
+		_, e := os.Stat(filepath.Join(testObjectPath(root, "rebalance", "foo", disk, "obj0"), "data.8"))
+		c.Assert(os.IsNotExist(e), Equals, true)
+	}
+	objectMetadata, err := xlAPI.GetObjectMetadata("foo", "obj0")
+	c.Assert(err, IsNil)
+	c.Assert(objectMetadata.DataDisks+objectMetadata.ParityDisks, Equals, uint8(4))
+
+	progress = nil
+	err = xlAPI.Rebalance(RebalanceConfig{
+		Progress: func(p RebalanceProgress) { progress = append(progress, p) },
+	})
+	c.Assert(err, IsNil)
+	c.Assert(len(progress), Equals, 3)
+	for _, p := range progress {
+		c.Assert(p.Moved, Equals, true)
+	}
+	c.Assert(progress[2].MovedBytes, Equals, int64(100000*4*6))
+	c.Assert(dryRun, DeepEquals, progress)
+
+	// old blocks may still be read by a server, they are only expired as orphans later on
+	stale := filepath.Join(testObjectPath(root, "rebalance", "foo", 0, "obj0"), "data")
+	_, e := os.Stat(stale)
+	c.Assert(e, IsNil)
+	c.Assert(xlAPI.expireOrphans(time.Hour), IsNil)
+	_, e = os.Stat(stale)
+	c.Assert(e, IsNil)
+	summary, err := xlAPI.Fsck(FsckConfig{Fix: true})
+	c.Assert(err, IsNil)
+	c.Assert(summary.Fixed, Equals, 3*4)
+	_, e = os.Stat(stale)
+	c.Assert(os.IsNotExist(e), Equals, true)
+
+	// read back through a fresh xl, nothing is served from cache
+	xlAPI = newTestXL(c, root, "rebalance", 8)
+	for key, data := range objects {
+		objectMetadata, err := xlAPI.GetObjectMetadata("foo", key)
+		c.Assert(err, IsNil)
+		c.Assert(objectMetadata.DataDisks+objectMetadata.ParityDisks, Equals, uint8(8))
+
+		var buffer bytes.Buffer
+		size, err := xlAPI.GetObject(&buffer, "foo", key, 0, 0)
+		c.Assert(err, IsNil)
+		c.Assert(size, Equals, int64(len(data)))
+		c.Assert(bytes.Equal(buffer.Bytes(), data), Equals, true)
+	}
+
+	// running again moves nothing
+	progress = nil
+	err = xlAPI.Rebalance(RebalanceConfig{
+		Progress: func(p RebalanceProgress) { progress = append(progress, p) },
+	})
+	c.Assert(err, IsNil)
+	c.Assert(len(progress), Equals, 3)
+	for _, p := range progress {
+		c.Assert(p.Moved, Equals, false)
+	}
+	c.Assert(progress[2].MovedBytes, Equals, int64(0))
+}
+
+func (s *MyXLSuite) TestRateLimitedReaderLargeTotal(c *C) {
+	// 10GB already read at 1GB/s, reading 100MB more must still be throttled
+	limiter := &rateLimiter{rate: 1 << 30, start: time.Now().Add(-10 * time.Second), total: 10 << 30}
+	reader := rateLimitedReader{reader: bytes.NewReader(make([]byte, 100<<20)), limiter: limiter}
+	start := time.Now()
+	n, err := reader.Read(make([]byte, 100<<20))
+	c.Assert(err, IsNil)
+	c.Assert(n, Equals, 100<<20)
+	c.Assert(time.Since(start) >= 50*time.Millisecond, Equals, true)
+}
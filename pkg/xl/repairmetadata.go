@@ -0,0 +1,221 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"crypto/sha512"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"sort"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// RepairMetadataConfig - parameters of a metadata repair, all buckets are repaired unless Bucket
+// is set and all its objects unless Object is set
+type RepairMetadataConfig struct {
+	Bucket string
+	Object string
+	// DryRun reports objects as repaired, nothing is written
+	DryRun bool
+	// Progress if set is called for every object with damaged metadata
+	Progress func(MetadataRepair)
+}
+
+// MetadataRepair - an object with metadata unreadable on some or all disks
+type MetadataRepair struct {
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+	// DamagedCopies of object metadata, all of them unless a copy was left to restore from
+	DamagedCopies int `json:"damagedCopies"`
+	// FromIntegrity if no copy was left, metadata is rebuilt from the integrity file once
+	// VerifiedBlocks matching their checksums suffice to decode the object
+	FromIntegrity  bool `json:"fromIntegrity"`
+	VerifiedBlocks int  `json:"verifiedBlocks,omitempty"`
+	Repaired       bool `json:"repaired"`
+	// Reason metadata could not be rebuilt
+	Reason string `json:"reason,omitempty"`
+}
+
+// RepairMetadataSummary - totals of a metadata repair
+type RepairMetadataSummary struct {
+	Objects       int `json:"objects"`
+	Damaged       int `json:"damaged"`
+	Repaired      int `json:"repaired"`
+	Unrecoverable int `json:"unrecoverable"`
+}
+
+// metadataRepairState - state shared by all the objects of a metadata repair
+type metadataRepairState struct {
+	config  RepairMetadataConfig
+	summary RepairMetadataSummary
+}
+
+// report - count and report an object with damaged metadata
+func (r *metadataRepairState) report(repair MetadataRepair) {
+	r.summary.Damaged++
+	if repair.Repaired {
+		r.summary.Repaired++
+	} else {
+		r.summary.Unrecoverable++
+	}
+	if r.config.Progress != nil {
+		r.config.Progress(repair)
+	}
+}
+
+// RepairMetadata - rebuild object metadata damaged on disks from a copy still readable, or from
+// the integrity file of the object and the blocks on disks once no copy is left
+func (xl API) RepairMetadata(config RepairMetadataConfig) (RepairMetadataSummary, *probe.Error) {
+	xl.lock.Lock()
+	defer xl.lock.Unlock()
+
+	if len(xl.config.NodeDiskMap) == 0 {
+		return RepairMetadataSummary{}, probe.NewError(InvalidDisksArgument{})
+	}
+	if config.Bucket == "" && config.Object != "" {
+		return RepairMetadataSummary{}, probe.NewError(InvalidArgument{})
+	}
+	var summary RepairMetadataSummary
+	var err *probe.Error
+	withBackgroundIOPriority(func() {
+		summary, err = xl.repairMetadata(config)
+	})
+	return summary, err
+}
+
+// repairMetadata - repair metadata of objects listed in their bucket. Objects not listed are
+// never brought back, they may as well have been deleted, fsck reports them as orphans
+func (xl API) repairMetadata(config RepairMetadataConfig) (RepairMetadataSummary, *probe.Error) {
+	if err := xl.listXLBuckets(); err != nil {
+		return RepairMetadataSummary{}, err.Trace()
+	}
+	allBuckets, err := xl.getXLBucketMetadata()
+	if err != nil {
+		return RepairMetadataSummary{}, err.Trace()
+	}
+	var bucketNames []string
+	if config.Bucket != "" {
+		bucketNames = append(bucketNames, config.Bucket)
+	} else {
+		for bucketName := range allBuckets.Buckets {
+			bucketNames = append(bucketNames, bucketName)
+		}
+		sort.Strings(bucketNames)
+	}
+	r := &metadataRepairState{config: config}
+	for _, bucketName := range bucketNames {
+		bucketMetadata, ok := allBuckets.Buckets[bucketName]
+		bkt, found := xl.buckets[bucketName]
+		if !ok || !found {
+			return RepairMetadataSummary{}, probe.NewError(BucketNotFound{Bucket: bucketName})
+		}
+		objects := getReferencedObjects(bucketMetadata)
+		if config.Object != "" {
+			if _, ok := objects[normalizeObjectName(config.Object)]; !ok {
+				return RepairMetadataSummary{}, probe.NewError(ObjectNotFound{Object: config.Object})
+			}
+			objects = map[string]string{normalizeObjectName(config.Object): config.Object}
+		}
+		var objectNames []string
+		for normalizedObjectName := range objects {
+			objectNames = append(objectNames, normalizedObjectName)
+		}
+		sort.Strings(objectNames)
+		for _, normalizedObjectName := range objectNames {
+			r.summary.Objects++
+			if err := bkt.repairObjectMetadata(r, normalizedObjectName, objects[normalizedObjectName]); err != nil {
+				return RepairMetadataSummary{}, err.Trace(bucketName, objects[normalizedObjectName])
+			}
+		}
+	}
+	return r.summary, nil
+}
+
+// repairObjectMetadata - rewrite metadata of an object on all disks if it is damaged on any.
+// Metadata rebuilt from the integrity file has no user metadata, objects without an integrity
+// file or with too few blocks matching their checksums are only reported
+func (b bucket) repairObjectMetadata(r *metadataRepairState, normalizedObjectName, objectName string) *probe.Error {
+	damaged, err := b.getDamagedMetadata(normalizedObjectName)
+	if err != nil {
+		return err.Trace()
+	}
+	repair := MetadataRepair{Bucket: b.name, Object: objectName, DamagedCopies: damaged}
+	objMetadata, err := b.readObjectMetadata(normalizedObjectName)
+	if err == nil && damaged == 0 {
+		return nil
+	}
+	if err != nil {
+		repair.FromIntegrity = true
+		integrity, err := b.readObjectIntegrity(normalizedObjectName)
+		if err != nil {
+			repair.Reason = "no integrity file"
+			r.report(repair)
+			return nil
+		}
+		verified, err := b.countVerifiedBlocks(normalizedObjectName, integrity)
+		if err != nil {
+			return err.Trace()
+		}
+		repair.VerifiedBlocks = verified
+		// objects on a single disk are not erasure coded, their only block must match
+		needed := int(integrity.DataDisks)
+		if needed == 0 {
+			needed = 1
+		}
+		if verified < needed {
+			repair.Reason = fmt.Sprintf("%d of %d blocks match their checksums, %d needed", verified, len(integrity.Blocks), needed)
+			r.report(repair)
+			return nil
+		}
+		objMetadata = integrity.objectMetadata(b.getBucketName(), objectName)
+	}
+	if !r.config.DryRun {
+		if err := b.writeObjectMetadata(normalizedObjectName, objMetadata); err != nil {
+			return err.Trace()
+		}
+	}
+	repair.Repaired = true
+	r.report(repair)
+	return nil
+}
+
+// countVerifiedBlocks - number of disks holding a block of an object which matches its checksum
+// in integrity
+func (b bucket) countVerifiedBlocks(objectName string, integrity ObjectIntegrity) (int, *probe.Error) {
+	readers, err := b.getDataReaders(objectName, integrity.objectMetadata(b.name, objectName))
+	if err != nil {
+		// no disk holds a block
+		return 0, nil
+	}
+	for _, reader := range readers {
+		defer reader.Close()
+	}
+	var verified int
+	for order, checksum := range integrity.Blocks {
+		reader, ok := readers[order]
+		if !ok {
+			continue
+		}
+		hasher := sha512.New()
+		if _, e := io.Copy(hasher, reader); e == nil && hex.EncodeToString(hasher.Sum(nil)) == checksum {
+			verified++
+		}
+	}
+	return verified, nil
+}
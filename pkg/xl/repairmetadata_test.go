@@ -0,0 +1,100 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestRepairMetadata(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "repair", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	for i := 0; i < 5; i++ {
+		key := "obj" + strconv.Itoa(i)
+		data := bytes.Repeat([]byte(key), 1000)
+		_, err := xlAPI.CreateObject("foo", key, "", int64(len(data)), bytes.NewReader(data), map[string]string{"contentType": "text/plain"}, nil)
+		c.Assert(err, IsNil)
+	}
+	// obj0 lost one copy, obj1 all copies and a block
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "repair", "foo", 1, "obj0"), objectMetadataConfig)), IsNil)
+	for disk := 0; disk < 4; disk++ {
+		for _, object := range []string{"obj1", "obj2", "obj3"} {
+			c.Assert(os.Remove(filepath.Join(testObjectPath(root, "repair", "foo", disk, object), objectMetadataConfig)), IsNil)
+		}
+		// obj2 has no integrity file left, blocks of obj3 are corrupt on all but one disk
+		c.Assert(os.Remove(filepath.Join(testObjectPath(root, "repair", "foo", disk, "obj2"), objectIntegrityConfig)), IsNil)
+		if disk > 0 {
+			c.Assert(ioutil.WriteFile(filepath.Join(testObjectPath(root, "repair", "foo", disk, "obj3"), "data"), []byte("corrupt"), 0600), IsNil)
+		}
+	}
+	c.Assert(os.Remove(filepath.Join(testObjectPath(root, "repair", "foo", 0, "obj1"), "data")), IsNil)
+
+	var repairs []MetadataRepair
+	progress := func(repair MetadataRepair) { repairs = append(repairs, repair) }
+	summary, err := xlAPI.RepairMetadata(RepairMetadataConfig{DryRun: true, Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(summary, DeepEquals, RepairMetadataSummary{Objects: 5, Damaged: 4, Repaired: 2, Unrecoverable: 2})
+	c.Assert(repairs, DeepEquals, []MetadataRepair{
+		{Bucket: "foo", Object: "obj0", DamagedCopies: 1, Repaired: true},
+		{Bucket: "foo", Object: "obj1", DamagedCopies: 4, FromIntegrity: true, VerifiedBlocks: 3, Repaired: true},
+		{Bucket: "foo", Object: "obj2", DamagedCopies: 4, FromIntegrity: true, Reason: "no integrity file"},
+		{Bucket: "foo", Object: "obj3", DamagedCopies: 4, FromIntegrity: true, VerifiedBlocks: 1, Reason: "1 of 4 blocks match their checksums, 2 needed"},
+	})
+	_, e := os.Stat(filepath.Join(testObjectPath(root, "repair", "foo", 1, "obj0"), objectMetadataConfig))
+	c.Assert(os.IsNotExist(e), Equals, true)
+
+	// a single object
+	repairs = nil
+	summary, err = xlAPI.RepairMetadata(RepairMetadataConfig{Bucket: "foo", Object: "obj1", Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(summary, DeepEquals, RepairMetadataSummary{Objects: 1, Damaged: 1, Repaired: 1})
+	_, err = xlAPI.RepairMetadata(RepairMetadataConfig{Bucket: "foo", Object: "missing"})
+	c.Assert(err, Not(IsNil))
+	_, err = xlAPI.RepairMetadata(RepairMetadataConfig{Bucket: "missing"})
+	c.Assert(err, Not(IsNil))
+
+	repairs = nil
+	summary, err = xlAPI.RepairMetadata(RepairMetadataConfig{Progress: progress})
+	c.Assert(err, IsNil)
+	c.Assert(summary, DeepEquals, RepairMetadataSummary{Objects: 5, Damaged: 3, Repaired: 1, Unrecoverable: 2})
+	c.Assert(repairs[0].Object, Equals, "obj0")
+	c.Assert(repairs[0].Repaired, Equals, true)
+
+	// user metadata survives with a copy, is lost when rebuilt from the integrity file
+	xlAPI = newTestXL(c, root, "repair", 4)
+	objMetadata, err := xlAPI.GetObjectMetadata("foo", "obj0")
+	c.Assert(err, IsNil)
+	c.Assert(objMetadata.Metadata["contentType"], Equals, "text/plain")
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo", "obj1", 0, 0)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(buffer.Bytes(), bytes.Repeat([]byte("obj1"), 1000)), Equals, true)
+	objMetadata, err = xlAPI.GetObjectMetadata("foo", "obj1")
+	c.Assert(err, IsNil)
+	c.Assert(objMetadata.Metadata["contentType"], Equals, "")
+
+	summary, err = xlAPI.RepairMetadata(RepairMetadataConfig{})
+	c.Assert(err, IsNil)
+	c.Assert(summary, DeepEquals, RepairMetadataSummary{Objects: 5, Damaged: 2, Unrecoverable: 2})
+}
@@ -0,0 +1,150 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"context"
+	"io"
+	"path/filepath"
+
+	"github.com/minio/minio-xl/pkg/tracing"
+)
+
+// WithContext - xl whose disk reads and writes are traced as children of the span of ctx,
+// all state is shared with xl
+func (xl API) WithContext(ctx context.Context) Interface {
+	xl.ctx = ctx
+	return xl
+}
+
+// getBucket - bucket carrying the context of xl
+func (xl API) getBucket(bucketName string) bucket {
+	b := xl.buckets[bucketName]
+	b.ctx = xl.ctx
+	return b
+}
+
+// newDiskSpan - span of a disk operation on a file, nil unless the bucket serves a traced request
+func (b bucket) newDiskSpan(name, diskPath, filePath string) *tracing.Span {
+	if tracing.FromContext(b.ctx) == nil {
+		return nil
+	}
+	_, span := tracing.Start(b.ctx, name)
+	span.SetAttribute("xl.disk", diskPath)
+	span.SetAttribute("xl.file", filepath.Join(diskPath, filePath))
+	span.SetAttribute("xl.bucket", b.name)
+	return span
+}
+
+// traceReader - reads of a file recorded as a span, ended once the file is closed
+func (b bucket) traceReader(reader io.ReadCloser, diskPath, filePath string) io.ReadCloser {
+	span := b.newDiskSpan("disk.read", diskPath, filePath)
+	if span == nil {
+		return reader
+	}
+	return &tracedReader{ReadCloser: reader, span: span}
+}
+
+// traceWriter - writes of a file recorded as a span, ended once the file is closed or purged
+func (b bucket) traceWriter(writer io.WriteCloser, diskPath, filePath string) io.WriteCloser {
+	span := b.newDiskSpan("disk.write", diskPath, filePath)
+	if span == nil {
+		return writer
+	}
+	return &tracedWriter{WriteCloser: writer, span: span}
+}
+
+type tracedReader struct {
+	io.ReadCloser
+	span  *tracing.Span
+	bytes int64
+	err   error
+}
+
+func (t *tracedReader) Read(p []byte) (int, error) {
+	n, err := t.ReadCloser.Read(p)
+	t.bytes += int64(n)
+	if err != nil && err != io.EOF {
+		t.err = err
+	}
+	return n, err
+}
+
+func (t *tracedReader) Close() error {
+	err := t.ReadCloser.Close()
+	t.span.SetAttribute("xl.bytes", t.bytes)
+	if t.err != nil {
+		t.span.SetError(t.err.Error())
+	}
+	t.span.End()
+	return err
+}
+
+type tracedWriter struct {
+	io.WriteCloser
+	span  *tracing.Span
+	bytes int64
+	err   error
+}
+
+func (t *tracedWriter) Write(p []byte) (int, error) {
+	n, err := t.WriteCloser.Write(p)
+	t.bytes += int64(n)
+	if err != nil {
+		t.err = err
+	}
+	return n, err
+}
+
+func (t *tracedWriter) Close() error {
+	err := t.WriteCloser.Close()
+	if err != nil && t.err == nil {
+		t.err = err
+	}
+	t.end(false)
+	return err
+}
+
+// CloseAndPurge - discard everything written, the span is ended as failed
+func (t *tracedWriter) CloseAndPurge() error {
+	var err error
+	if p, ok := t.WriteCloser.(purger); ok {
+		err = p.CloseAndPurge()
+	} else {
+		err = t.WriteCloser.Close()
+	}
+	t.end(true)
+	return err
+}
+
+// SyncOnClose - sync the underlying file on close
+func (t *tracedWriter) SyncOnClose() {
+	if s, ok := t.WriteCloser.(syncer); ok {
+		s.SyncOnClose()
+	}
+}
+
+func (t *tracedWriter) end(purged bool) {
+	t.span.SetAttribute("xl.bytes", t.bytes)
+	switch {
+	case t.err != nil:
+		t.span.SetError(t.err.Error())
+	case purged:
+		t.span.SetError("purged")
+	}
+	t.span.End()
+}
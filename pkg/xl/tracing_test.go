@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/tracing"
+	. "gopkg.in/check.v1"
+)
+
+// testSpan - fields of exported spans looked at by the tests
+type testSpan struct {
+	TraceID      string `json:"traceId"`
+	SpanID       string `json:"spanId"`
+	ParentSpanID string `json:"parentSpanId"`
+	Name         string `json:"name"`
+}
+
+// testCollector - OTLP/HTTP endpoint keeping names and ids of exported spans
+type testCollector struct {
+	lock  sync.Mutex
+	spans []testSpan
+}
+
+func (t *testCollector) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ResourceSpans []struct {
+			ScopeSpans []struct {
+				Spans []testSpan `json:"spans"`
+			} `json:"scopeSpans"`
+		} `json:"resourceSpans"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		w.WriteHeader(http.StatusBadRequest)
+		return
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	for _, resourceSpans := range request.ResourceSpans {
+		for _, scopeSpans := range resourceSpans.ScopeSpans {
+			t.spans = append(t.spans, scopeSpans.Spans...)
+		}
+	}
+}
+
+func (s *MyXLSuite) TestDiskSpans(c *C) {
+	defer tracing.Disable()
+	xlAPI := newTestXL(c, c.MkDir(), "tracing", 4)
+	c.Assert(xlAPI.MakeBucket("bucket", "private", nil, nil), IsNil)
+
+	collector := &testCollector{}
+	server := httptest.NewServer(collector)
+	defer server.Close()
+	c.Assert(tracing.Enable(server.URL, "minio-xl"), IsNil)
+
+	// operations without a request are not traced
+	data := []byte("Hello World")
+	_, perr := xlAPI.CreateObject("bucket", "untraced", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+
+	ctx, request := tracing.Start(context.Background(), "PUT")
+	_, perr = xlAPI.WithContext(ctx).CreateObject("bucket", "object", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(perr, IsNil)
+	request.End()
+
+	// reads from disk, the memory cache of xlAPI already holds the object
+	restarted, perr := New()
+	c.Assert(perr, IsNil)
+	ctx, request = tracing.Start(context.Background(), "GET")
+	var buffer bytes.Buffer
+	_, perr = restarted.WithContext(ctx).GetObject(&buffer, "bucket", "object", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.Bytes(), DeepEquals, data)
+	request.End()
+	// data readers are closed once the object was verified, after GetObject returned
+	time.Sleep(50 * time.Millisecond)
+	tracing.Disable()
+
+	requests := make(map[string]testSpan)
+	for _, span := range collector.spans {
+		if span.ParentSpanID == "" {
+			requests[span.SpanID] = span
+		}
+	}
+	c.Assert(len(requests), Equals, 2)
+	operations := make(map[string]int)
+	for _, span := range collector.spans {
+		if span.ParentSpanID == "" {
+			continue
+		}
+		parent, ok := requests[span.ParentSpanID]
+		c.Assert(ok, Equals, true)
+		c.Assert(span.TraceID, Equals, parent.TraceID)
+		operations[parent.Name+" "+span.Name]++
+	}
+	// data, integrity and metadata on each of the 4 disks
+	c.Assert(operations["PUT disk.write"], Equals, 12)
+	c.Assert(operations["GET disk.read"], Equals, 8)
+}
@@ -0,0 +1,47 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import "strings"
+
+// userMetadataPrefix - user metadata is sent as x-amz-meta-* headers, their keys are stored
+// in the case they were sent
+const userMetadataPrefix = "x-amz-meta-"
+
+// IsUserMetadata - key is user metadata, matched case-insensitively
+func IsUserMetadata(key string) bool {
+	return len(key) > len(userMetadataPrefix) && strings.EqualFold(key[:len(userMetadataPrefix)], userMetadataPrefix)
+}
+
+// setUserMetadata - copy user metadata keys of metadata to objMetadata
+func setUserMetadata(objMetadata, metadata map[string]string) {
+	for key, value := range metadata {
+		if IsUserMetadata(key) {
+			objMetadata[key] = value
+		}
+	}
+}
+
+// deleteUserMetadata - remove user metadata matching key case-insensitively, keys differing
+// only in case name the same metadata
+func deleteUserMetadata(metadata map[string]string, key string) {
+	for existingKey := range metadata {
+		if IsUserMetadata(existingKey) && strings.EqualFold(existingKey, key) {
+			delete(metadata, existingKey)
+		}
+	}
+}
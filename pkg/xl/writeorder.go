@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"fmt"
+	"io"
+	"sync/atomic"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// WriteOrder - how blocks of an object reach stable storage relative to the metadata committing it
+type WriteOrder uint32
+
+const (
+	// WriteOrderOrdered - blocks are synced before the metadata of their object is written, a crash
+	// in between leaves blocks no metadata points at, which are orphans, never metadata pointing at
+	// blocks which were lost
+	WriteOrderOrdered WriteOrder = iota
+	// WriteOrderRelaxed - blocks and metadata reach stable storage whenever the fsync policy of the
+	// disks has them synced, in any order
+	WriteOrderRelaxed
+)
+
+// internal variable only accessed via get/set methods
+var writeOrder = uint32(WriteOrderOrdered)
+
+// SetWriteOrder - set how blocks of objects written from now on are committed
+func SetWriteOrder(order WriteOrder) {
+	atomic.StoreUint32(&writeOrder, uint32(order))
+}
+
+// getWriteOrder - get write order
+func getWriteOrder() WriteOrder {
+	return WriteOrder(atomic.LoadUint32(&writeOrder))
+}
+
+// ParseWriteOrder - parse ‘ordered’ or ‘relaxed’
+func ParseWriteOrder(order string) (WriteOrder, *probe.Error) {
+	switch order {
+	case "ordered":
+		return WriteOrderOrdered, nil
+	case "relaxed":
+		return WriteOrderRelaxed, nil
+	}
+	return 0, probe.NewError(fmt.Errorf("%q is not a write order, use ‘ordered’ or ‘relaxed’", order))
+}
+
+func (order WriteOrder) String() string {
+	if order == WriteOrderRelaxed {
+		return "relaxed"
+	}
+	return "ordered"
+}
+
+// syncer - writers syncing what was written along with its rename on close, files created on disks
+// and their wrappers
+type syncer interface {
+	SyncOnClose()
+}
+
+// syncOnClose - unless relaxed, closing writers syncs their blocks along with the rename
+func syncOnClose(writers []io.WriteCloser) {
+	if getWriteOrder() != WriteOrderOrdered {
+		return
+	}
+	for _, writer := range writers {
+		if file, ok := writer.(syncer); ok {
+			file.SyncOnClose()
+		}
+	}
+}
+
+// commitBlocks - close the block writers of disks still online, their blocks are renamed into
+// place and, unless relaxed, synced. A block which fails to commit fails the write, metadata
+// must never point at it
+func commitBlocks(writers []io.WriteCloser, offline map[int]error) *probe.Error {
+	syncOnClose(writers)
+	for order, writer := range writers {
+		if _, ok := offline[order]; ok {
+			// purged when its disk went offline
+			continue
+		}
+		if err := writer.Close(); err != nil {
+			return probe.NewError(err)
+		}
+	}
+	return nil
+}
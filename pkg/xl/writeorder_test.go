@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"errors"
+	"io"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+func (s *MyXLSuite) TestCrashBeforeCommit(c *C) {
+	root := c.MkDir()
+	xlAPI := newTestXL(c, root, "writeorder", 4)
+	c.Assert(xlAPI.MakeBucket("foo", "private", nil, nil), IsNil)
+	data := bytes.Repeat([]byte("crash"), 1000)
+
+	// crash after the blocks are written, before the metadata committing them
+	_, _, err := xlAPI.getBucket("foo").writeObjectBlocks("crashed", bytes.NewReader(data), int64(len(data)), "", map[string]string{}, nil)
+	c.Assert(err, IsNil)
+	for disk := 0; disk < 4; disk++ {
+		// blocks are in place rather than left as temporary files
+		_, e := os.Stat(filepath.Join(testObjectPath(root, "writeorder", "foo", disk, "crashed"), "data"))
+		c.Assert(e, IsNil)
+		_, e = os.Stat(filepath.Join(testObjectPath(root, "writeorder", "foo", disk, "crashed"), objectIntegrityConfig))
+		c.Assert(os.IsNotExist(e), Equals, true)
+		_, e = os.Stat(filepath.Join(testObjectPath(root, "writeorder", "foo", disk, "crashed"), objectMetadataConfig))
+		c.Assert(os.IsNotExist(e), Equals, true)
+	}
+
+	// object does not appear, not even partially
+	var buffer bytes.Buffer
+	_, err = xlAPI.GetObject(&buffer, "foo", "crashed", 0, 0)
+	c.Assert(err, Not(IsNil))
+	c.Assert(buffer.Len(), Equals, 0)
+	_, err = xlAPI.GetObjectMetadata("foo", "crashed")
+	c.Assert(err, Not(IsNil))
+	objects, _, err := xlAPI.ListObjects("foo", BucketResourcesMetadata{Maxkeys: 1000})
+	c.Assert(err, IsNil)
+	c.Assert(len(objects), Equals, 0)
+
+	// blocks are orphans
+	summary, err := xlAPI.Fsck(FsckConfig{})
+	c.Assert(err, IsNil)
+	c.Assert(summary.Objects, Equals, 0)
+	c.Assert(summary.Orphans, Equals, 4)
+
+	// written again from scratch
+	_, err = xlAPI.CreateObject("foo", "crashed", "", int64(len(data)), bytes.NewReader(data), nil, nil)
+	c.Assert(err, IsNil)
+	reader, _, err := xlAPI.getObject("foo", "crashed")
+	c.Assert(err, IsNil)
+	written, e := ioutil.ReadAll(reader)
+	c.Assert(e, IsNil)
+	reader.Close()
+	c.Assert(bytes.Equal(written, data), Equals, true)
+}
+
+// commitTestWriter - block writer recording whether it was synced
+type commitTestWriter struct {
+	synced   bool
+	closeErr error
+}
+
+func (w *commitTestWriter) Write(p []byte) (int, error) { return len(p), nil }
+func (w *commitTestWriter) Close() error                { return w.closeErr }
+func (w *commitTestWriter) SyncOnClose()                { w.synced = true }
+
+func (s *MyXLSuite) TestCommitBlocks(c *C) {
+	defer SetWriteOrder(getWriteOrder())
+	for _, order := range []string{"ordered", "relaxed"} {
+		writeOrder, err := ParseWriteOrder(order)
+		c.Assert(err, IsNil)
+		c.Assert(writeOrder.String(), Equals, order)
+		SetWriteOrder(writeOrder)
+		writers := []io.WriteCloser{&commitTestWriter{}, &commitTestWriter{}}
+		c.Assert(commitBlocks(newBlockChecksumWriters(writers), nil), IsNil)
+		for _, writer := range writers {
+			c.Assert(writer.(*commitTestWriter).synced, Equals, order == "ordered")
+		}
+	}
+	_, err := ParseWriteOrder("any")
+	c.Assert(err, Not(IsNil))
+
+	// blocks of offline disks were purged already, any other failing fails the write
+	offline := map[int]error{1: errors.New("offline")}
+	writers := []io.WriteCloser{&commitTestWriter{}, &commitTestWriter{closeErr: errors.New("closed")}}
+	c.Assert(commitBlocks(writers, offline), IsNil)
+	c.Assert(commitBlocks(writers, nil), Not(IsNil))
+}
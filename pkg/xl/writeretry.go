@@ -0,0 +1,104 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"io"
+	"os"
+	"sync/atomic"
+	"syscall"
+	"time"
+)
+
+const (
+	// defaultWriteRetries number of times a block write failing transiently is retried unless configured
+	defaultWriteRetries = 3
+	// defaultWriteRetryBackoff wait before the first retry, doubled on every subsequent one
+	defaultWriteRetryBackoff = 100 * time.Millisecond
+)
+
+// internal variables only accessed via get/set methods
+var (
+	writeRetries      int32 = defaultWriteRetries
+	writeRetryBackoff int64 = int64(defaultWriteRetryBackoff)
+)
+
+// SetWriteRetry - set number of retries for block writes failing with transient errors
+// such as EINTR, and the backoff before the first retry which doubles on every retry.
+// '0' retries fails the write at the first error.
+func SetWriteRetry(retries int, backoff time.Duration) {
+	if retries < 0 {
+		retries = 0
+	}
+	if backoff < 0 {
+		backoff = 0
+	}
+	atomic.StoreInt32(&writeRetries, int32(retries))
+	atomic.StoreInt64(&writeRetryBackoff, int64(backoff))
+}
+
+// getWriteRetry - get number of retries and initial backoff
+func getWriteRetry() (int, time.Duration) {
+	return int(atomic.LoadInt32(&writeRetries)), time.Duration(atomic.LoadInt64(&writeRetryBackoff))
+}
+
+// isTransientError - errors which are expected to go away when the write is retried,
+// anything else is a disk failure
+func isTransientError(err error) bool {
+	if pathErr, ok := err.(*os.PathError); ok {
+		err = pathErr.Err
+	}
+	switch err {
+	case syscall.EINTR, syscall.EAGAIN:
+		return true
+	}
+	if tempErr, ok := err.(interface {
+		Temporary() bool
+	}); ok {
+		return tempErr.Temporary()
+	}
+	return false
+}
+
+// writeBlock - write a block in full, resuming where a transient error left off
+func writeBlock(writer io.Writer, block []byte) error {
+	retries, backoff := getWriteRetry()
+	var written int
+	for retry := 0; ; retry++ {
+		n, err := writer.Write(block[written:])
+		written += n
+		if err == nil {
+			return nil
+		}
+		if retry >= retries || !isTransientError(err) {
+			return err
+		}
+		time.Sleep(backoff << uint(retry))
+	}
+}
+
+// retryWriter - writer retrying transient errors
+type retryWriter struct {
+	io.Writer
+}
+
+func (r retryWriter) Write(p []byte) (int, error) {
+	if err := writeBlock(r.Writer, p); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
@@ -0,0 +1,136 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package xl
+
+import (
+	"bytes"
+	"io"
+	"io/ioutil"
+	"math/rand"
+	"os"
+	"syscall"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MyWriteRetrySuite struct{}
+
+var _ = Suite(&MyWriteRetrySuite{})
+
+func (s *MyWriteRetrySuite) SetUpSuite(c *C) {
+	SetWriteRetry(3, time.Millisecond)
+}
+
+func (s *MyWriteRetrySuite) TearDownSuite(c *C) {
+	SetWriteRetry(defaultWriteRetries, defaultWriteRetryBackoff)
+}
+
+// faultyWriter - fails the first 'failures' writes with err, after writing half of the data
+type faultyWriter struct {
+	bytes.Buffer
+	failures int
+	err      error
+}
+
+func (f *faultyWriter) Write(p []byte) (int, error) {
+	if f.failures > 0 {
+		f.failures--
+		n, _ := f.Buffer.Write(p[:len(p)/2])
+		return n, f.err
+	}
+	return f.Buffer.Write(p)
+}
+
+func (f *faultyWriter) Close() error {
+	return nil
+}
+
+func newFaultyWriters(count int) ([]*faultyWriter, []io.WriteCloser) {
+	faultyWriters := make([]*faultyWriter, count)
+	writers := make([]io.WriteCloser, count)
+	for i := range writers {
+		faultyWriters[i] = &faultyWriter{}
+		writers[i] = faultyWriters[i]
+	}
+	return faultyWriters, writers
+}
+
+func (s *MyWriteRetrySuite) TestTransientError(c *C) {
+	c.Assert(isTransientError(syscall.EINTR), Equals, true)
+	c.Assert(isTransientError(&os.PathError{Op: "write", Path: "data", Err: syscall.EAGAIN}), Equals, true)
+	c.Assert(isTransientError(&os.PathError{Op: "write", Path: "data", Err: syscall.EIO}), Equals, false)
+	c.Assert(isTransientError(io.ErrShortWrite), Equals, false)
+}
+
+func (s *MyWriteRetrySuite) TestWriteRetry(c *C) {
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	faultyWriters, writers := newFaultyWriters(4)
+	faultyWriters[1].failures = 2
+	faultyWriters[1].err = &os.PathError{Op: "write", Path: "data", Err: syscall.EINTR}
+	chunkCount, totalLength, offline, err := bucket{}.writeObjectData(2, 2, writers, bytes.NewReader(data), int64(len(data)), ioutil.Discard, nil)
+	c.Assert(err, IsNil)
+	c.Assert(chunkCount, Equals, 1)
+	c.Assert(totalLength, Equals, len(data))
+	c.Assert(len(offline), Equals, 0)
+	for _, writer := range faultyWriters {
+		c.Assert(writer.Len(), Equals, faultyWriters[0].Len())
+	}
+
+	// retries are bounded
+	faultyWriters[1].Reset()
+	faultyWriters[1].failures = 4
+	c.Assert(writeBlock(faultyWriters[1], data), Not(IsNil))
+}
+
+func (s *MyWriteRetrySuite) TestWriteDiskOffline(c *C) {
+	data := make([]byte, 1024*1024)
+	rand.New(rand.NewSource(1)).Read(data)
+
+	faultyWriters, writers := newFaultyWriters(4)
+	faultyWriters[1].failures = 1
+	faultyWriters[1].err = &os.PathError{Op: "write", Path: "data", Err: syscall.EIO}
+	_, totalLength, offline, err := bucket{}.writeObjectData(2, 2, writers, bytes.NewReader(data), int64(len(data)), ioutil.Discard, nil)
+	c.Assert(err, IsNil)
+	c.Assert(totalLength, Equals, len(data))
+	c.Assert(len(offline), Equals, 1)
+	c.Assert(offline[1], DeepEquals, faultyWriters[1].err)
+
+	// object is still readable from the remaining disks
+	encoder, err := newEncoder(2, 2)
+	c.Assert(err, IsNil)
+	encodedBlocks := make([][]byte, 4)
+	for i, writer := range faultyWriters {
+		if i != 1 {
+			encodedBlocks[i] = writer.Bytes()
+		}
+	}
+	decodedData, err := encoder.Decode(encodedBlocks, len(data))
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(decodedData, data), Equals, true)
+
+	// losing more disks than parity fails the write
+	faultyWriters, writers = newFaultyWriters(4)
+	for _, writer := range faultyWriters[:3] {
+		writer.failures = 1
+		writer.err = syscall.EIO
+	}
+	_, _, _, err = bucket{}.writeObjectData(2, 2, writers, bytes.NewReader(data), int64(len(data)), ioutil.Discard, nil)
+	c.Assert(err, Not(IsNil))
+}
@@ -34,12 +34,17 @@ func registerAPI(mux *router.Router, a API) {
 
 	// Object operations
 	bucket.Methods("HEAD").Path("/{object:.+}").HandlerFunc(a.HeadObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(a.PutObjectLegalHoldHandler).Queries("legal-hold", "")
+	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.GetObjectLegalHoldHandler).Queries("legal-hold", "")
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".+").HandlerFunc(a.CopyObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(a.PutObjectPartHandler).Queries("partNumber", "{partNumber:[0-9]+}", "uploadId", "{uploadId:.*}")
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.ListObjectPartsHandler).Queries("uploadId", "{uploadId:.*}")
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(a.CompleteMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
 	bucket.Methods("POST").Path("/{object:.+}").HandlerFunc(a.NewMultipartUploadHandler).Queries("uploads", "")
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(a.AbortMultipartUploadHandler).Queries("uploadId", "{uploadId:.*}")
 	bucket.Methods("GET").Path("/{object:.+}").HandlerFunc(a.GetObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Minio-Move-Source", ".+").HandlerFunc(a.MoveObjectHandler)
+	bucket.Methods("PUT").Path("/{object:.+}").HeadersRegexp("X-Amz-Copy-Source", ".+", "X-Minio-Touch", "^(?i)true$").HandlerFunc(a.TouchObjectHandler)
 	bucket.Methods("PUT").Path("/{object:.+}").HandlerFunc(a.PutObjectHandler)
 	// Not supported
 	bucket.Methods("DELETE").Path("/{object:.+}").HandlerFunc(a.DeleteObjectHandler)
@@ -47,11 +52,20 @@ func registerAPI(mux *router.Router, a API) {
 	// Bucket operations
 	bucket.Methods("GET").HandlerFunc(a.GetBucketACLHandler).Queries("acl", "")
 	bucket.Methods("GET").HandlerFunc(a.ListMultipartUploadsHandler).Queries("uploads", "")
+	bucket.Methods("GET").HandlerFunc(a.GetBucketWebsiteHandler).Queries("website", "")
+	bucket.Methods("GET").HandlerFunc(a.GetBucketEncryptionHandler).Queries("encryption", "")
+	bucket.Methods("GET").HandlerFunc(a.GetBucketReplicationHandler).Queries("replication", "")
+	bucket.Methods("GET").HandlerFunc(a.ListObjectsV2Handler).Queries("list-type", "2")
 	bucket.Methods("GET").HandlerFunc(a.ListObjectsHandler)
 	bucket.Methods("PUT").HandlerFunc(a.PutBucketACLHandler).Queries("acl", "")
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketWebsiteHandler).Queries("website", "")
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketEncryptionHandler).Queries("encryption", "")
+	bucket.Methods("PUT").HandlerFunc(a.PutBucketReplicationHandler).Queries("replication", "")
 	bucket.Methods("PUT").HandlerFunc(a.PutBucketHandler)
 	bucket.Methods("HEAD").HandlerFunc(a.HeadBucketHandler)
 	bucket.Methods("POST").HandlerFunc(a.PostPolicyBucketHandler)
+	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketEncryptionHandler).Queries("encryption", "")
+	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketReplicationHandler).Queries("replication", "")
 	// Not supported
 	bucket.Methods("DELETE").HandlerFunc(a.DeleteBucketHandler)
 
@@ -86,20 +100,57 @@ func getNewAPI(anonymous bool) API {
 
 func getAPIHandler(anonymous bool, api API) http.Handler {
 	var mwHandlers = []MiddlewareHandler{
+		BandwidthLimitHandler,
+		MemoryLimitHandler,
 		TimeValidityHandler,
 		IgnoreResourcesHandler,
+		KeyLimitHandler,
+		RequestLimitHandler,
 		CorsHandler,
 	}
 	if !anonymous {
 		mwHandlers = append(mwHandlers, SignatureHandler)
+	} else {
+		mwHandlers = append(mwHandlers, AnonymousAuditHandler)
 	}
+	mwHandlers = append(mwHandlers, AnonymousLimitHandler)
+	// denied requests are counted as well
+	mwHandlers = append(mwHandlers, api.BucketMetricsHandler)
+	// spans cover signature verification as well
+	mwHandlers = append(mwHandlers, TracingHandler)
+	// outermost, every other handler sees paths without the prefix
+	mwHandlers = append(mwHandlers, PathPrefixHandler)
 	mux := router.NewRouter()
 	registerAPI(mux, api)
 	apiHandler := registerCustomMiddleware(mux, mwHandlers...)
 	return apiHandler
 }
 
-func getServerRPCHandler(anonymous bool) http.Handler {
+// getWebsiteHandler static website handler, serves buckets with a website configuration
+func getWebsiteHandler(api API) http.Handler {
+	var mwHandlers = []MiddlewareHandler{
+		BandwidthLimitHandler,
+		MemoryLimitHandler,
+		RequestLimitHandler,
+		CorsHandler,
+		AnonymousLimitHandler,
+		api.BucketMetricsHandler,
+	}
+	mux := router.NewRouter()
+	mux.Methods("GET", "HEAD").Path("/{bucket}").HandlerFunc(api.WebsiteHandler)
+	mux.Methods("GET", "HEAD").Path("/{bucket}/{object:.*}").HandlerFunc(api.WebsiteHandler)
+	websiteHandler := registerCustomMiddleware(mux, mwHandlers...)
+	return websiteHandler
+}
+
+// getMetricsHandler request counters of all buckets in the Prometheus text format, unauthenticated
+func getMetricsHandler() http.Handler {
+	mux := router.NewRouter()
+	mux.Methods("GET").Path("/metrics").HandlerFunc(MetricsHandler)
+	return mux
+}
+
+func getServerRPCHandler(anonymous bool, api API) http.Handler {
 	var mwHandlers = []MiddlewareHandler{
 		TimeValidityHandler,
 	}
@@ -109,7 +160,7 @@ func getServerRPCHandler(anonymous bool) http.Handler {
 
 	s := jsonrpc.NewServer()
 	s.RegisterCodec(json.NewCodec(), "application/json")
-	s.RegisterService(new(serverRPCService), "Server")
+	s.RegisterService(&serverRPCService{XL: api.XL, anonymous: anonymous, fence: new(controllerFence)}, "Server")
 	s.RegisterService(new(xlRPCService), "XL")
 	mux := router.NewRouter()
 	mux.Handle("/rpc", s)
@@ -119,7 +170,7 @@ func getServerRPCHandler(anonymous bool) http.Handler {
 }
 
 // getControllerRPCHandler rpc handler for controller
-func getControllerRPCHandler(anonymous bool) http.Handler {
+func getControllerRPCHandler(anonymous bool, controller *controllerRPCService) http.Handler {
 	var mwHandlers = []MiddlewareHandler{
 		TimeValidityHandler,
 	}
@@ -131,7 +182,7 @@ func getControllerRPCHandler(anonymous bool) http.Handler {
 	codec := json.NewCodec()
 	s.RegisterCodec(codec, "application/json")
 	s.RegisterCodec(codec, "application/json; charset=UTF-8")
-	s.RegisterService(new(controllerRPCService), "Controller")
+	s.RegisterService(controller, "Controller")
 	mux := router.NewRouter()
 	// Add new RPC services here
 	mux.Handle("/rpc", s)
@@ -0,0 +1,127 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// globalRPCConnTracker is set by startServer, tracks connections of the server RPC
+var globalRPCConnTracker *rpcConnTracker
+
+// globalRPCTransport is set by startController, used for all requests to servers
+var globalRPCTransport http.RoundTripper
+
+// rpcConn state of a connection and since when it is in that state
+type rpcConn struct {
+	state http.ConnState
+	since time.Time
+}
+
+// rpcConnTracker tracks open connections of an RPC server, connections idle for longer
+// than idleTimeout are closed by its reaper, active connections are never closed
+type rpcConnTracker struct {
+	lock        *sync.Mutex
+	idleTimeout time.Duration
+	conns       map[net.Conn]rpcConn
+}
+
+// newRPCConnTracker - instantiate a new tracker, '0' idleTimeout never reaps connections
+func newRPCConnTracker(idleTimeout time.Duration) *rpcConnTracker {
+	return &rpcConnTracker{
+		lock:        &sync.Mutex{},
+		idleTimeout: idleTimeout,
+		conns:       make(map[net.Conn]rpcConn),
+	}
+}
+
+// connState - http.Server ConnState hook
+func (t *rpcConnTracker) connState(conn net.Conn, state http.ConnState) {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	switch state {
+	case http.StateNew, http.StateActive, http.StateIdle:
+		t.conns[conn] = rpcConn{state: state, since: time.Now()}
+	case http.StateHijacked, http.StateClosed:
+		delete(t.conns, conn)
+	}
+}
+
+// reap - close connections which have not been active since idleTimeout, returns number of connections closed
+func (t *rpcConnTracker) reap(now time.Time) int {
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	if t.idleTimeout <= 0 {
+		return 0
+	}
+	var reaped int
+	for conn, c := range t.conns {
+		if c.state == http.StateActive || now.Sub(c.since) < t.idleTimeout {
+			continue
+		}
+		conn.Close()
+		delete(t.conns, conn)
+		reaped++
+	}
+	return reaped
+}
+
+// reaper - reap idle connections periodically, never returns
+func (t *rpcConnTracker) reaper() {
+	if t.idleTimeout <= 0 {
+		return
+	}
+	ticker := time.NewTicker(t.idleTimeout / 2)
+	defer ticker.Stop()
+	for now := range ticker.C {
+		t.reap(now)
+	}
+}
+
+// getOpenConns - number of connections currently open
+func (t *rpcConnTracker) getOpenConns() int {
+	if t == nil {
+		return 0
+	}
+	t.lock.Lock()
+	defer t.lock.Unlock()
+	return len(t.conns)
+}
+
+// trackRPCServer - track connections of an RPC server and start reaping idle ones
+func trackRPCServer(server *http.Server, idleTimeout time.Duration) *rpcConnTracker {
+	tracker := newRPCConnTracker(idleTimeout)
+	server.ConnState = tracker.connState
+	go tracker.reaper()
+	return tracker
+}
+
+// newRPCTransport - transport for RPC requests, idle connections are closed after idleTimeout
+func newRPCTransport(idleTimeout time.Duration) http.RoundTripper {
+	return &http.Transport{
+		Proxy: http.ProxyFromEnvironment,
+		Dial: (&net.Dialer{
+			Timeout:   30 * time.Second,
+			KeepAlive: 30 * time.Second,
+		}).Dial,
+		TLSHandshakeTimeout: 10 * time.Second,
+		IdleConnTimeout:     idleTimeout,
+	}
+}
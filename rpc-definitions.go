@@ -16,6 +16,12 @@
 
 package main
 
+import (
+	"time"
+
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
 //// In memory metadata
 
 //// RPC params
@@ -28,8 +34,11 @@ type AuthArgs struct {
 // XLArg xl params
 type XLArg struct{}
 
-// ServerArg server params
-type ServerArg struct{}
+// ServerArg server params, controller and term of its lease are empty for controllers without peers
+type ServerArg struct {
+	Controller string `json:"controller"`
+	Term       uint64 `json:"term"`
+}
 
 // ControllerArgs controller params
 type ControllerArgs struct {
@@ -38,12 +47,28 @@ type ControllerArgs struct {
 	ID   string `json:"id"`
 }
 
+// LeaseArgs controller asking a peer for its vote, servers it manages are handed over on failover
+type LeaseArgs struct {
+	Holder  string        `json:"holder"`
+	Term    uint64        `json:"term"`
+	TTL     time.Duration `json:"ttl"`
+	Servers []ServerRep   `json:"servers"`
+}
+
 //// RPC replies
 
+// LeaseRep vote of a controller : Holder and Term of the vote are returned whether granted or not
+type LeaseRep struct {
+	Granted bool   `json:"granted"`
+	Holder  string `json:"holder"`
+	Term    uint64 `json:"term"`
+}
+
 // ServerRep server reply container for Server.List
 type ServerRep struct {
 	Host string `json:"host"`
 	ID   string `json:"id"`
+	SSL  bool   `json:"ssl"`
 }
 
 // ControllerNetInfoRep array of ip/mask in the form: 172.17.42.1/16
@@ -69,8 +94,9 @@ type DiskStatsRep struct {
 
 // MemStatsRep memory statistics of a server
 type MemStatsRep struct {
-	Total uint64 `json:"total"`
-	Free  uint64 `json:"free"`
+	Total    uint64 `json:"total"`
+	Free     uint64 `json:"free"`
+	InFlight uint64 `json:"inFlight"`
 }
 
 // Network metadata of a server
@@ -94,6 +120,7 @@ type SysInfoRep struct {
 	SysCPUS   int    `json:"sysNcpus"`
 	Routines  int    `json:"goRoutines"`
 	GOVersion string `json:"goVersion"`
+	RPCConns  int    `json:"rpcConnections"`
 }
 
 // ListRep all servers list
@@ -140,6 +167,53 @@ type BucketStats struct {
 	Used uint64 `json:"used"`
 }
 
+// BucketStatsArgs bucket to report object counts of, all buckets if empty
+type BucketStatsArgs struct {
+	Bucket string `json:"bucket"`
+}
+
+// BucketStatsRep number of objects and their total size per bucket
+type BucketStatsRep struct {
+	Buckets []xl.BucketStats `json:"buckets"`
+}
+
+// DeleteByQueryArgs objects of a bucket to delete by user metadata key and value
+type DeleteByQueryArgs struct {
+	Bucket string `json:"bucket"`
+	Key    string `json:"key"`
+	Value  string `json:"value"`
+	DryRun bool   `json:"dryRun"`
+}
+
+// DeleteFailure object matched which could not be deleted
+type DeleteFailure struct {
+	Object string `json:"object"`
+	Error  string `json:"error"`
+}
+
+// DeleteByQueryRep objects matched and how many of them were deleted, none on a dry run
+type DeleteByQueryRep struct {
+	Bucket   string          `json:"bucket"`
+	Key      string          `json:"key"`
+	Value    string          `json:"value"`
+	DryRun   bool            `json:"dryRun"`
+	Matched  []string        `json:"matched"`
+	Deleted  int             `json:"deleted"`
+	Failures []DeleteFailure `json:"failures"`
+}
+
+// DefaultContentTypeArgs default content type of a bucket, removed if empty
+type DefaultContentTypeArgs struct {
+	Bucket      string `json:"bucket"`
+	ContentType string `json:"contentType"`
+}
+
+// DefaultContentTypeRep default content type a bucket has from now on
+type DefaultContentTypeRep struct {
+	Bucket      string `json:"bucket"`
+	ContentType string `json:"contentType"`
+}
+
 // StorageStatsRep array of BucketStats
 type StorageStatsRep struct {
 	Buckets []BucketStats `json:"bucketStats"`
@@ -165,3 +239,61 @@ type XLVersionRep struct {
 	Architecture    string `json:"arch"`
 	OperatingSystem string `json:"os"`
 }
+
+// DiskUsageRep usage of a disk in bytes and how its health was probed, "stat" or "write"
+type DiskUsageRep struct {
+	Path   string `json:"path"`
+	Total  int64  `json:"total"`
+	Free   int64  `json:"free"`
+	Usable bool   `json:"usable"`
+	Probe  string `json:"probe"`
+}
+
+// BucketRequestStats requests served for a bucket by method and bytes transferred since the server started
+type BucketRequestStats struct {
+	Bucket        string           `json:"bucket"`
+	Requests      map[string]int64 `json:"requests"`
+	Errors        int64            `json:"errors"`
+	BytesReceived int64            `json:"bytesReceived"`
+	BytesSent     int64            `json:"bytesSent"`
+}
+
+// ServerStatusRep disk usage, object counts and health of a server
+type ServerStatusRep struct {
+	Disks            []DiskUsageRep       `json:"disks"`
+	Buckets          int                  `json:"buckets"`
+	Objects          int64                `json:"objects"`
+	MultipartUploads int                  `json:"multipartUploads"`
+	Orphans          xl.OrphanStats       `json:"orphans"`
+	DiskCache        xl.DiskCacheStats    `json:"diskCache"`
+	DiskQuorum       xl.DiskQuorumStats   `json:"diskQuorum"`
+	BucketRequests   []BucketRequestStats `json:"bucketRequests"`
+	Healthy          bool                 `json:"healthy"`
+	Error            string               `json:"error"`
+}
+
+// ServerStatusEntry status of a registered server : Error is "" if the server is reachable
+type ServerStatusEntry struct {
+	Host   string          `json:"host"`
+	ID     string          `json:"id"`
+	Status ServerStatusRep `json:"status"`
+	Error  string          `json:"error"`
+}
+
+// ClusterStatusRep status of all registered servers and their aggregated summary
+type ClusterStatusRep struct {
+	Servers          []ServerStatusEntry  `json:"servers"`
+	TotalServers     int                  `json:"totalServers"`
+	OnlineServers    int                  `json:"onlineServers"`
+	HealthyServers   int                  `json:"healthyServers"`
+	DegradedServers  int                  `json:"degradedServers"`
+	Disks            int                  `json:"disks"`
+	Total            int64                `json:"total"`
+	Free             int64                `json:"free"`
+	Objects          int64                `json:"objects"`
+	MultipartUploads int                  `json:"multipartUploads"`
+	OrphansRemoved   int64                `json:"orphansRemoved"`
+	DiskCacheHits    int64                `json:"diskCacheHits"`
+	DiskCacheMisses  int64                `json:"diskCacheMisses"`
+	BucketRequests   []BucketRequestStats `json:"bucketRequests"`
+}
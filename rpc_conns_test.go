@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type RPCConnsSuite struct{}
+
+var _ = Suite(&RPCConnsSuite{})
+
+func (s *RPCConnsSuite) TestRPCConnReaper(c *C) {
+	tracker := newRPCConnTracker(time.Minute)
+	started := make(chan struct{})
+	proceed := make(chan struct{})
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/slow" {
+			started <- struct{}{}
+			<-proceed
+		}
+		w.Write([]byte("ok"))
+	}))
+	server.Config.ConnState = tracker.connState
+	server.Start()
+	defer server.Close()
+
+	// keep-alive connection stays open once the request is done
+	client := http.Client{Transport: &http.Transport{}}
+	response, err := client.Get(server.URL + "/fast")
+	c.Assert(err, IsNil)
+	_, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	response.Body.Close()
+
+	// second client holds an active request
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		slowClient := http.Client{Transport: &http.Transport{}}
+		if response, err := slowClient.Get(server.URL + "/slow"); err == nil {
+			response.Body.Close()
+		}
+	}()
+	<-started
+	c.Assert(tracker.getOpenConns(), Equals, 2)
+
+	// nothing is idle long enough yet
+	c.Assert(tracker.reap(time.Now()), Equals, 0)
+	// only the idle connection is reaped, the active one is left alone
+	c.Assert(tracker.reap(time.Now().Add(time.Hour)), Equals, 1)
+	c.Assert(tracker.getOpenConns(), Equals, 1)
+
+	close(proceed)
+	<-done
+
+	// reaping disabled
+	c.Assert(newRPCConnTracker(0).reap(time.Now().Add(time.Hour)), Equals, 0)
+	var nilTracker *rpcConnTracker
+	c.Assert(nilTracker.getOpenConns(), Equals, 0)
+}
@@ -0,0 +1,92 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+)
+
+// anonymousRetryAfter is the Retry-After, in seconds, of rejected unsigned requests
+const anonymousRetryAfter = 1
+
+// anonymousLimiter bounds the number of unsigned requests served concurrently
+type anonymousLimiter struct {
+	inFlight chan struct{}
+}
+
+// globalAnonymousLimiter is set by startServer with --anonymous-ratelimit, unlimited by default
+var globalAnonymousLimiter = newAnonymousLimiter(0)
+
+// newAnonymousLimiter - new limiter, '0' max means unsigned requests are never rejected
+func newAnonymousLimiter(max int) *anonymousLimiter {
+	if max <= 0 {
+		return &anonymousLimiter{}
+	}
+	return &anonymousLimiter{inFlight: make(chan struct{}, max)}
+}
+
+// acquire - account an unsigned request, false if max of them are already in flight
+func (l *anonymousLimiter) acquire() bool {
+	if l.inFlight == nil {
+		return true
+	}
+	select {
+	case l.inFlight <- struct{}{}:
+		return true
+	default:
+		return false
+	}
+}
+
+// release - release a request accounted with acquire
+func (l *anonymousLimiter) release() {
+	if l.inFlight != nil {
+		<-l.inFlight
+	}
+}
+
+// isRequestSigned - request carries a signature, whether it is valid or not is up to the
+// signature handler
+func isRequestSigned(r *http.Request) bool {
+	return isRequestSignatureV4(r) || isRequestPresignedSignatureV4(r) || (isRequestPostPolicySignatureV4(r) && r.Method == "POST")
+}
+
+type anonymousLimitHandler struct {
+	handler http.Handler
+	limiter *anonymousLimiter
+}
+
+// AnonymousLimitHandler rejects unsigned requests with 503 SlowDown while --anonymous-ratelimit
+// of them are in flight, signed requests are only bound by --ratelimit
+func AnonymousLimitHandler(h http.Handler) http.Handler {
+	return anonymousLimitHandler{h, globalAnonymousLimiter}
+}
+
+func (h anonymousLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if isRequestSigned(r) {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	if !h.limiter.acquire() {
+		w.Header().Set("Retry-After", strconv.Itoa(anonymousRetryAfter))
+		writeErrorResponse(w, r, SlowDown, r.URL.Path)
+		return
+	}
+	defer h.limiter.release()
+	h.handler.ServeHTTP(w, r)
+}
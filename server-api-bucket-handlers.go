@@ -17,7 +17,13 @@
 package main
 
 import (
+	"encoding/xml"
+	"io"
+	"io/ioutil"
 	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
 
 	"github.com/gorilla/mux"
 	"github.com/minio/minio-xl/pkg/probe"
@@ -50,6 +56,10 @@ func (api API) ListMultipartUploadsHandler(w http.ResponseWriter, req *http.Requ
 	if resources.MaxUploads == 0 {
 		resources.MaxUploads = maxObjectList
 	}
+	if !isValidEncodingType(resources.EncodingType) {
+		writeErrorResponse(w, req, InvalidEncodingType, req.URL.Path)
+		return
+	}
 
 	vars := mux.Vars(req)
 	bucket := vars["bucket"]
@@ -68,10 +78,8 @@ func (api API) ListMultipartUploadsHandler(w http.ResponseWriter, req *http.Requ
 	// generate response
 	response := generateListMultipartUploadsResponse(bucket, resources)
 	encodedSuccessResponse := encodeSuccessResponse(response)
-	// write headers
-	setCommonHeaders(w, len(encodedSuccessResponse))
-	// write body
-	w.Write(encodedSuccessResponse)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
 }
 
 // ListObjectsHandler - GET Bucket (List Objects)
@@ -80,6 +88,11 @@ func (api API) ListMultipartUploadsHandler(w http.ResponseWriter, req *http.Requ
 // of the objects in a bucket. You can use the request parameters as selection
 // criteria to return a subset of the objects in a bucket.
 //
+// As a minio extension 'depth' along with 'delimiter' lists up to depth levels
+// in one call, objects nested fewer than depth delimiters deep are returned and
+// common prefixes are returned for every level. Without it the listing is the
+// standard single level one.
+//
 func (api API) ListObjectsHandler(w http.ResponseWriter, req *http.Request) {
 	// Ticket master block
 	{
@@ -91,28 +104,103 @@ func (api API) ListObjectsHandler(w http.ResponseWriter, req *http.Request) {
 	}
 
 	resources := getBucketResources(req.URL.Query())
-	if resources.Maxkeys < 0 {
-		writeErrorResponse(w, req, InvalidMaxKeys, req.URL.Path)
+	if !checkListObjectsResources(w, req, &resources) {
 		return
 	}
-	if resources.Maxkeys == 0 {
-		resources.Maxkeys = maxObjectList
-	}
 
 	vars := mux.Vars(req)
 	bucket := vars["bucket"]
 
-	objects, resources, err := api.XL.ListObjects(bucket, resources)
+	objects, resources, err := api.getStorage(req).ListObjects(bucket, resources)
 	if err == nil {
 		// generate response
 		response := generateListObjectsResponse(bucket, objects, resources)
 		encodedSuccessResponse := encodeSuccessResponse(response)
-		// write headers
-		setCommonHeaders(w, len(encodedSuccessResponse))
-		// write body
-		w.Write(encodedSuccessResponse)
+		// write headers and body
+		writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
 		return
 	}
+	writeListObjectsErrorResponse(w, req, err)
+}
+
+// ListObjectsV2Handler - GET Bucket (List Objects) Version 2
+// -------------------------
+// Same listing as ListObjectsHandler, paged with 'continuation-token' instead of
+// 'marker'. Objects are listed after 'start-after' or after the token, whichever
+// comes later. Objects carry their owner only if 'fetch-owner' is true.
+//
+func (api API) ListObjectsV2Handler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	values := req.URL.Query()
+	resources := getBucketResources(values)
+	if !checkListObjectsResources(w, req, &resources) {
+		return
+	}
+	startAfter := values.Get("start-after")
+	continuationToken := values.Get("continuation-token")
+	resources.Marker = startAfter
+	if continuationToken != "" {
+		marker, ok := decodeContinuationToken(continuationToken)
+		if !ok {
+			writeErrorResponse(w, req, InvalidContinuationToken, req.URL.Path)
+			return
+		}
+		if marker > resources.Marker {
+			resources.Marker = marker
+		}
+	}
+	var owner *Owner
+	if fetchOwner, _ := strconv.ParseBool(values.Get("fetch-owner")); fetchOwner {
+		owner = getRequestOwner(req)
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	objects, resources, err := api.getStorage(req).ListObjects(bucket, resources)
+	if err == nil {
+		// generate response
+		response := generateListObjectsV2Response(bucket, objects, resources, startAfter, continuationToken, owner)
+		encodedSuccessResponse := encodeSuccessResponse(response)
+		// write headers and body
+		writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
+		return
+	}
+	writeListObjectsErrorResponse(w, req, err)
+}
+
+// checkListObjectsResources - validate max-keys, depth and encoding-type of a listing, larger
+// max-keys are clamped. Returns false if an error response was written.
+func checkListObjectsResources(w http.ResponseWriter, req *http.Request, resources *xl.BucketResourcesMetadata) bool {
+	if resources.Maxkeys < 0 {
+		writeErrorResponse(w, req, InvalidMaxKeys, req.URL.Path)
+		return false
+	}
+	// larger requests are clamped rather than refused, clients page through the rest
+	if resources.Maxkeys == 0 || resources.Maxkeys > maxObjectList {
+		resources.Maxkeys = maxObjectList
+	}
+	if resources.Depth < 0 || resources.Depth > maxListDepth {
+		writeErrorResponse(w, req, InvalidListDepth, req.URL.Path)
+		return false
+	}
+	if !isValidEncodingType(resources.EncodingType) {
+		writeErrorResponse(w, req, InvalidEncodingType, req.URL.Path)
+		return false
+	}
+	return true
+}
+
+// writeListObjectsErrorResponse - error response of a failed listing
+func writeListObjectsErrorResponse(w http.ResponseWriter, req *http.Request, err *probe.Error) {
 	switch err.ToGoError().(type) {
 	case xl.BucketNameInvalid:
 		writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
@@ -154,10 +242,8 @@ func (api API) ListBucketsHandler(w http.ResponseWriter, req *http.Request) {
 		// generate response
 		response := generateListBucketsResponse(buckets)
 		encodedSuccessResponse := encodeSuccessResponse(response)
-		// write headers
-		setCommonHeaders(w, len(encodedSuccessResponse))
-		// write response
-		w.Write(encodedSuccessResponse)
+		// write headers and body
+		writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
 		return
 	}
 	errorIf(err.Trace(), "ListBuckets failed.", nil)
@@ -234,7 +320,7 @@ func (api API) PutBucketHandler(w http.ResponseWriter, req *http.Request) {
 		return
 	}
 	// Make sure to add Location information here only for bucket
-	w.Header().Set("Location", "/"+bucket)
+	w.Header().Set("Location", getPrefixedPath("/"+bucket))
 	writeSuccessResponse(w)
 }
 
@@ -272,7 +358,7 @@ func (api API) PostPolicyBucketHandler(w http.ResponseWriter, req *http.Request)
 		return
 	}
 
-	fileBody, formValues, perr := extractHTTPFormValues(reader)
+	filePart, formValues, perr := extractHTTPFormValues(reader)
 	if perr != nil {
 		errorIf(perr.Trace(), "Unable to parse form values.", nil)
 		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
@@ -281,9 +367,11 @@ func (api API) PostPolicyBucketHandler(w http.ResponseWriter, req *http.Request)
 	bucket := mux.Vars(req)["bucket"]
 	formValues["Bucket"] = bucket
 	object := formValues["Key"]
+	setAuditAccessKey(w, strings.Split(formValues["X-Amz-Credential"], "/")[0])
 	signature, perr := initPostPresignedPolicyV4(formValues)
 	if perr != nil {
 		errorIf(perr.Trace(), "Unable to initialize post policy presigned.", nil)
+		auditDeferredDenied(w, MalformedPOSTRequest)
 		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
 		return
 	}
@@ -297,12 +385,25 @@ func (api API) PostPolicyBucketHandler(w http.ResponseWriter, req *http.Request)
 		writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
 		return
 	}
-	if perr = applyPolicy(formValues); perr != nil {
+	fileBody, fileSize, perr := applyPolicy(formValues, filePart)
+	if perr != nil {
 		errorIf(perr.Trace(), "Invalid request, policy doesn't match with the endpoint.", nil)
-		writeErrorResponse(w, req, MalformedPOSTRequest, req.URL.Path)
+		var errorCode int
+		switch perr.ToGoError() {
+		case errPolicyEntityTooSmall:
+			errorCode = EntityTooSmall
+		case errPolicyEntityTooLarge:
+			errorCode = EntityTooLarge
+		case errPolicyAlreadyExpired:
+			errorCode = AccessDenied
+		default:
+			errorCode = MalformedPOSTRequest
+		}
+		auditDeferredDenied(w, errorCode)
+		writeErrorResponse(w, req, errorCode, req.URL.Path)
 		return
 	}
-	metadata, perr := api.XL.CreateObject(bucket, object, "", 0, fileBody, nil, nil)
+	metadata, perr := api.getStorage(req).CreateObject(bucket, object, "", fileSize, fileBody, map[string]string{"contentType": formValues["Content-Type"]}, nil)
 	if perr != nil {
 		errorIf(perr.Trace(), "CreateObject failed.", nil)
 		switch perr.ToGoError().(type) {
@@ -310,6 +411,8 @@ func (api API) PostPolicyBucketHandler(w http.ResponseWriter, req *http.Request)
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		case xl.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 		case xl.ObjectExists:
 			writeErrorResponse(w, req, MutableWriteNotAllowed, req.URL.Path)
 		case xl.BadDigest:
@@ -327,8 +430,45 @@ func (api API) PostPolicyBucketHandler(w http.ResponseWriter, req *http.Request)
 		}
 		return
 	}
-	w.Header().Set("ETag", metadata.MD5Sum)
-	writeSuccessResponse(w)
+	w.Header().Set("ETag", "\""+metadata.MD5Sum+"\"")
+	runPostUploadHooks(api.XL, bucket, object, formValues["Content-Type"])
+	globalReplicator.objectCreated(bucket, object)
+	writePostObjectResponse(w, req, formValues, metadata)
+}
+
+// writePostObjectResponse - write the response configured by success_action_redirect or
+// success_action_status form fields, defaults to '204 No Content'
+func writePostObjectResponse(w http.ResponseWriter, req *http.Request, formValues map[string]string, metadata xl.ObjectMetadata) {
+	if redirect := formValues[http.CanonicalHeaderKey("success_action_redirect")]; redirect != "" {
+		redirectURL, err := url.Parse(redirect)
+		if err == nil && redirectURL.IsAbs() {
+			query := redirectURL.Query()
+			query.Set("bucket", metadata.Bucket)
+			query.Set("key", metadata.Object)
+			query.Set("etag", "\""+metadata.MD5Sum+"\"")
+			redirectURL.RawQuery = query.Encode()
+			setCommonHeaders(w, 0)
+			w.Header().Set("Location", redirectURL.String())
+			w.WriteHeader(http.StatusSeeOther)
+			return
+		}
+	}
+	switch formValues[http.CanonicalHeaderKey("success_action_status")] {
+	case "200":
+		writeSuccessResponse(w)
+	case "201":
+		scheme := "http"
+		if req.TLS != nil {
+			scheme = "https"
+		}
+		location := scheme + "://" + req.Host + getPrefixedPath("/"+metadata.Bucket+"/"+metadata.Object)
+		encodedSuccessResponse := encodeSuccessResponse(generatePostResponse(metadata.Bucket, metadata.Object, location, metadata.MD5Sum))
+		w.Header().Set("Location", location)
+		writeXMLResponse(w, req, http.StatusCreated, encodedSuccessResponse)
+	default:
+		setCommonHeaders(w, 0)
+		w.WriteHeader(http.StatusNoContent)
+	}
 }
 
 // PutBucketACLHandler - PUT Bucket ACL
@@ -405,10 +545,341 @@ func (api API) GetBucketACLHandler(w http.ResponseWriter, req *http.Request) {
 	// generate response
 	response := generateAccessControlPolicyResponse(bucketMetadata.ACL)
 	encodedSuccessResponse := encodeSuccessResponse(response)
-	// write headers
-	setCommonHeaders(w, len(encodedSuccessResponse))
-	// write body
-	w.Write(encodedSuccessResponse)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
+}
+
+// PutBucketWebsiteHandler - PUT Bucket website
+// ----------
+// This implementation of the PUT operation sets the index and error documents
+// used when the bucket is served on the website address
+func (api API) PutBucketWebsiteHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	websiteBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxWebsiteConfigSize))
+	if e != nil {
+		errorIf(probe.NewError(e), "Unable to read website configuration.", nil)
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	if !api.Anonymous {
+		ok, err := doesBodySignatureMatch(req, websiteBytes)
+		if err != nil {
+			errorIf(err.Trace(), "Unable to verify signature.", nil)
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
+		}
+		if !ok {
+			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+			return
+		}
+	}
+
+	websiteConfig := WebsiteConfiguration{}
+	if e := xml.Unmarshal(websiteBytes, &websiteConfig); e != nil {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	if !isValidWebsiteConfig(websiteConfig) {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+
+	err := api.XL.SetBucketMetadata(bucket, getWebsiteMetadata(websiteConfig))
+	if err != nil {
+		errorIf(err.Trace(), "PutBucketWebsite failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	writeSuccessResponse(w)
+}
+
+// GetBucketWebsiteHandler - GET Bucket website
+// ----------
+// This operation uses website subresource to return the website configuration
+// of a bucket, responds with 404 if the bucket does not have one.
+func (api API) GetBucketWebsiteHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	bucketMetadata, err := api.XL.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	websiteConfig, ok := getWebsiteConfig(bucketMetadata)
+	if !ok {
+		writeErrorResponse(w, req, NoSuchWebsiteConfiguration, req.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeSuccessResponse(websiteConfig)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
+}
+
+// PutBucketReplicationHandler - PUT Bucket replication
+// ----------
+// This implementation of the PUT operation sets the remote bucket new objects
+// of the bucket are replicated to, along with deletes
+func (api API) PutBucketReplicationHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	replicationBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxReplicationConfigSize))
+	if e != nil {
+		errorIf(probe.NewError(e), "Unable to read replication configuration.", nil)
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	if !api.Anonymous {
+		ok, err := doesBodySignatureMatch(req, replicationBytes)
+		if err != nil {
+			errorIf(err.Trace(), "Unable to verify signature.", nil)
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
+		}
+		if !ok {
+			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+			return
+		}
+	}
+
+	replicationConfig := ReplicationConfiguration{}
+	if e := xml.Unmarshal(replicationBytes, &replicationConfig); e != nil {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	if hasReplicationFilter(replicationConfig) {
+		writeErrorResponse(w, req, NotImplemented, req.URL.Path)
+		return
+	}
+	if !isValidReplicationConfig(replicationConfig) {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+
+	err := api.XL.SetBucketMetadata(bucket, getReplicationMetadata(&replicationConfig))
+	if err != nil {
+		errorIf(err.Trace(), "PutBucketReplication failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	writeSuccessResponse(w)
+}
+
+// GetBucketReplicationHandler - GET Bucket replication
+// ----------
+// This operation uses replication subresource to return the replication configuration
+// of a bucket without the secret key, responds with 404 if the bucket does not have one.
+func (api API) GetBucketReplicationHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	bucketMetadata, err := api.XL.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	replicationConfig, ok := getReplicationConfig(bucketMetadata)
+	if !ok {
+		writeErrorResponse(w, req, NoSuchReplicationConfiguration, req.URL.Path)
+		return
+	}
+	encodedSuccessResponse := encodeSuccessResponse(replicationConfig)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
+}
+
+// DeleteBucketReplicationHandler - DELETE Bucket replication
+// ----------
+// Objects are no longer replicated, objects already replicated stay on the target.
+func (api API) DeleteBucketReplicationHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	err := api.XL.SetBucketMetadata(bucket, getReplicationMetadata(nil))
+	if err != nil {
+		errorIf(err.Trace(), "DeleteBucketReplication failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	setCommonHeaders(w, 0)
+	w.WriteHeader(http.StatusNoContent)
+}
+
+// PutBucketEncryptionHandler - PUT Bucket encryption
+// ----------
+// Objects are not encrypted at rest by this server, a default encryption
+// which would not be applied is refused rather than stored.
+func (api API) PutBucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	writeErrorResponse(w, req, NotImplemented, req.URL.Path)
+}
+
+// GetBucketEncryptionHandler - GET Bucket encryption
+// ----------
+// No bucket ever has a default encryption configured.
+func (api API) GetBucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if _, err := api.XL.GetBucketMetadata(bucket); err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	writeErrorResponse(w, req, NoSuchEncryptionConfiguration, req.URL.Path)
+}
+
+// DeleteBucketEncryptionHandler - DELETE Bucket encryption
+// ----------
+// There is never a default encryption to remove, succeeds for existing buckets.
+func (api API) DeleteBucketEncryptionHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+
+	if _, err := api.XL.GetBucketMetadata(bucket); err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	setCommonHeaders(w, 0)
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // HeadBucketHandler - HEAD Bucket
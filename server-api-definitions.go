@@ -20,7 +20,10 @@ import "encoding/xml"
 
 // Limit number of objects in a given response
 const (
+	// maximum objects listed by a single response, as in S3
 	maxObjectList = 1000
+	// maximum levels listed by a single depth listing
+	maxListDepth = 16
 )
 
 // AccessControlPolicyResponse - format for get bucket acl response
@@ -73,6 +76,30 @@ type ListObjectsResponse struct {
 	Prefix     string
 }
 
+// ListObjectsV2Response - format for list objects version 2 response
+type ListObjectsV2Response struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ ListBucketResult" json:"-"`
+
+	CommonPrefixes []*CommonPrefix
+	Contents       []*Object
+
+	Delimiter    string
+	EncodingType string
+	IsTruncated  bool
+
+	// Number of objects and common prefixes in the response.
+	KeyCount int
+	MaxKeys  int
+	Name     string
+	Prefix   string
+
+	StartAfter        string `xml:",omitempty" json:",omitempty"`
+	ContinuationToken string `xml:",omitempty" json:",omitempty"`
+
+	// Sent as continuation-token of the next request when the response is truncated.
+	NextContinuationToken string `xml:",omitempty" json:",omitempty"`
+}
+
 // Part container for part metadata
 type Part struct {
 	PartNumber   int
@@ -160,7 +187,8 @@ type Object struct {
 	LastModified string
 	Size         int64
 
-	Owner Owner
+	// Version 2 listings only include the owner if asked to.
+	Owner *Owner `xml:",omitempty" json:",omitempty"`
 
 	// The class of storage used to store the object.
 	StorageClass string
@@ -194,6 +222,88 @@ type CompleteMultipartUploadResponse struct {
 	ETag     string
 }
 
+// CopyObjectPartResponse container for upload part copy response
+type CopyObjectPartResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyPartResult" json:"-"`
+
+	LastModified string
+	ETag         string
+}
+
+// CopyObjectResponse container returns ETag and LastModified of the copied object
+type CopyObjectResponse struct {
+	XMLName xml.Name `xml:"http://s3.amazonaws.com/doc/2006-03-01/ CopyObjectResult" json:"-"`
+
+	LastModified string
+	ETag         string
+}
+
+// PostResponse container for POST object response, returned when success_action_status is 201
+type PostResponse struct {
+	XMLName xml.Name `xml:"PostResponse" json:"-"`
+
+	Location string
+	Bucket   string
+	Key      string
+	ETag     string
+}
+
+// WebsiteConfiguration container for bucket website configuration, request and response
+type WebsiteConfiguration struct {
+	XMLName xml.Name `xml:"WebsiteConfiguration" json:"-"`
+
+	IndexDocument WebsiteIndexDocument
+	ErrorDocument *WebsiteErrorDocument `xml:",omitempty"`
+}
+
+// WebsiteIndexDocument container for index document suffix appended to requests for a "directory"
+type WebsiteIndexDocument struct {
+	Suffix string
+}
+
+// WebsiteErrorDocument container for object key returned when the requested object is missing
+type WebsiteErrorDocument struct {
+	Key string
+}
+
+// ReplicationConfiguration container for bucket replication configuration, request and response
+type ReplicationConfiguration struct {
+	XMLName xml.Name `xml:"ReplicationConfiguration" json:"-"`
+
+	Role string `xml:",omitempty"`
+	Rule []ReplicationRule
+}
+
+// ReplicationRule container for a replication rule, only whole buckets are replicated
+type ReplicationRule struct {
+	ID          string `xml:",omitempty"`
+	Status      string
+	Prefix      string             `xml:",omitempty"`
+	Filter      *ReplicationFilter `xml:",omitempty"`
+	Destination ReplicationDestination
+}
+
+// ReplicationFilter container for the objects a rule applies to
+type ReplicationFilter struct {
+	Prefix string `xml:",omitempty"`
+}
+
+// ReplicationDestination container for the target of a rule, there are no roles to assume so
+// the endpoint and credentials of the target are part of the rule, the secret key is never returned
+type ReplicationDestination struct {
+	Bucket          string
+	Endpoint        string
+	AccessKeyID     string `xml:",omitempty"`
+	SecretAccessKey string `xml:",omitempty"`
+}
+
+// ObjectLegalHold container for object legal hold, request and response
+type ObjectLegalHold struct {
+	XMLName xml.Name `xml:"LegalHold" json:"-"`
+
+	Status string
+}
+
 // List of not implemented bucket queries
 var notimplementedBucketResourceNames = map[string]bool{
 	"policy":         true,
@@ -202,12 +312,10 @@ var notimplementedBucketResourceNames = map[string]bool{
 	"location":       true,
 	"logging":        true,
 	"notification":   true,
-	"replication":    true,
 	"tagging":        true,
 	"versions":       true,
 	"requestPayment": true,
 	"versioning":     true,
-	"website":        true,
 }
 
 // List of not implemented object queries
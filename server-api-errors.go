@@ -30,12 +30,13 @@ type APIError struct {
 
 // APIErrorResponse - error response format
 type APIErrorResponse struct {
-	XMLName   xml.Name `xml:"Error" json:"-"`
-	Code      string
-	Message   string
-	Resource  string
-	RequestID string `xml:"RequestId"`
-	HostID    string `xml:"HostId"`
+	XMLName    xml.Name `xml:"Error" json:"-"`
+	Code       string
+	Message    string
+	Resource   string
+	PartNumber int    `xml:",omitempty" json:",omitempty"`
+	RequestID  string `xml:"RequestId"`
+	HostID     string `xml:"HostId"`
 }
 
 // Error codes, non exhaustive list - http://docs.aws.amazon.com/AmazonS3/latest/API/ErrorResponses.html
@@ -55,7 +56,10 @@ const (
 	InvalidMaxKeys
 	InvalidMaxUploads
 	InvalidMaxParts
+	InvalidListDepth
 	InvalidPartNumberMarker
+	InvalidContinuationToken
+	InvalidEncodingType
 	MalformedXML
 	MissingContentLength
 	MissingRequestBodyError
@@ -71,7 +75,22 @@ const (
 	InvalidPart
 	InvalidPartOrder
 	AuthorizationHeaderMalformed
+	AuthorizationQueryParametersError
+	ExpiredPresignRequest
 	MalformedPOSTRequest
+	PreconditionFailed
+	SlowDown
+	NoSuchWebsiteConfiguration
+	KeyTooLong
+	InvalidObjectName
+	ServiceUnavailable
+	NoSuchEncryptionConfiguration
+	NoSuchReplicationConfiguration
+	InvalidArchive
+	IdempotencyKeyMismatch
+	RequestURITooLong
+	CorruptPart
+	OperationAborted
 )
 
 // APIError code to Error structure map
@@ -91,11 +110,26 @@ var errorCodeResponse = map[int]APIError{
 		Description:    "Argument maxParts must be an integer between 1 and 10000.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	InvalidListDepth: {
+		Code:           "InvalidArgument",
+		Description:    "Argument depth must be an integer between 0 and 16.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	InvalidPartNumberMarker: {
 		Code:           "InvalidArgument",
 		Description:    "Argument partNumberMarker must be an integer.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	InvalidContinuationToken: {
+		Code:           "InvalidArgument",
+		Description:    "The continuation token provided is incorrect.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidEncodingType: {
+		Code:           "InvalidArgument",
+		Description:    "Invalid Encoding Method specified in Request.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	AccessDenied: {
 		Code:           "AccessDenied",
 		Description:    "Access Denied.",
@@ -151,6 +185,11 @@ var errorCodeResponse = map[int]APIError{
 		Description:    "The requested range cannot be satisfied.",
 		HTTPStatusCode: http.StatusRequestedRangeNotSatisfiable,
 	},
+	InvalidRequest: {
+		Code:           "InvalidRequest",
+		Description:    "The request is not valid, please check the request headers.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
 	MalformedXML: {
 		Code:           "MalformedXML",
 		Description:    "The XML you provided was not well-formed or did not validate against our published schema.",
@@ -226,11 +265,86 @@ var errorCodeResponse = map[int]APIError{
 		Description:    "The authorization header is malformed; the region is wrong; expecting 'us-east-1'.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	AuthorizationQueryParametersError: {
+		Code:           "AuthorizationQueryParametersError",
+		Description:    "X-Amz-Expires must be between 1 and 604800 seconds.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ExpiredPresignRequest: {
+		Code:           "AccessDenied",
+		Description:    "Request has expired.",
+		HTTPStatusCode: http.StatusForbidden,
+	},
 	MalformedPOSTRequest: {
 		Code:           "MalformedPOSTRequest",
 		Description:    "The body of your POST request is not well-formed multipart/form-data.",
 		HTTPStatusCode: http.StatusBadRequest,
 	},
+	PreconditionFailed: {
+		Code:           "PreconditionFailed",
+		Description:    "At least one of the preconditions you specified did not hold.",
+		HTTPStatusCode: http.StatusPreconditionFailed,
+	},
+	SlowDown: {
+		Code:           "SlowDown",
+		Description:    "Please reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	NoSuchWebsiteConfiguration: {
+		Code:           "NoSuchWebsiteConfiguration",
+		Description:    "The specified bucket does not have a website configuration.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	KeyTooLong: {
+		Code:           "KeyTooLongError",
+		Description:    "Your key is too long.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	InvalidObjectName: {
+		Code:           "InvalidObjectName",
+		Description:    "Your key has too many path segments.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	ServiceUnavailable: {
+		Code:           "ServiceUnavailable",
+		Description:    "Please reduce your request rate.",
+		HTTPStatusCode: http.StatusServiceUnavailable,
+	},
+	NoSuchReplicationConfiguration: {
+		Code:           "ReplicationConfigurationNotFoundError",
+		Description:    "The replication configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
+	InvalidArchive: {
+		Code:           "InvalidArchive",
+		Description:    "The archive you provided is not a valid tar or gzipped tar archive.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	IdempotencyKeyMismatch: {
+		Code:           "IdempotencyKeyMismatch",
+		Description:    "The idempotency key you provided was used by an earlier request with different content.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	RequestURITooLong: {
+		Code:           "RequestURITooLong",
+		Description:    "The query string of your request is too long.",
+		HTTPStatusCode: http.StatusRequestURITooLong,
+	},
+	CorruptPart: {
+		Code:           "InvalidPart",
+		Description:    "A staged part of the upload is corrupt, upload the part again and complete the upload.",
+		HTTPStatusCode: http.StatusBadRequest,
+	},
+	OperationAborted: {
+		Code:           "OperationAborted",
+		Description:    "A conflicting conditional operation is currently in progress against this resource. Please try again.",
+		HTTPStatusCode: http.StatusConflict,
+	},
+	NoSuchEncryptionConfiguration: {
+		Code:           "ServerSideEncryptionConfigurationNotFoundError",
+		Description:    "The server side encryption configuration was not found.",
+		HTTPStatusCode: http.StatusNotFound,
+	},
 }
 
 // errorCodeError provides errorCode to Error. It returns empty if the code provided is unknown
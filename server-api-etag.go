@@ -0,0 +1,192 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// entityTag - an ETag as defined by RFC 7232 section 2.3
+type entityTag struct {
+	opaque string
+	weak   bool
+}
+
+// getObjectETag - ETag of an object as served, weak if the bytes served are not the bytes stored
+func getObjectETag(metadata xl.ObjectMetadata, weak bool) entityTag {
+	return entityTag{opaque: metadata.MD5Sum, weak: weak}
+}
+
+func (e entityTag) String() string {
+	if e.weak {
+		return "W/\"" + e.opaque + "\""
+	}
+	return "\"" + e.opaque + "\""
+}
+
+// strongMatch - both tags are strong and identical, RFC 7232 section 2.3.2
+func (e entityTag) strongMatch(o entityTag) bool {
+	return !e.weak && !o.weak && e.opaque == o.opaque
+}
+
+// weakMatch - tags are identical regardless of either being weak, RFC 7232 section 2.3.2
+func (e entityTag) weakMatch(o entityTag) bool {
+	return e.opaque == o.opaque
+}
+
+// parseEntityTags - parse the list of tags in an If-Match or If-None-Match header, returns
+// true instead if the header is ‘*’. Unquoted tags sent by some S3 clients are accepted.
+func parseEntityTags(header string) ([]entityTag, bool) {
+	header = strings.TrimSpace(header)
+	if header == "*" {
+		return nil, true
+	}
+	var tags []entityTag
+	for header != "" {
+		header = strings.TrimLeft(header, " \t,")
+		if header == "" {
+			break
+		}
+		var tag entityTag
+		if strings.HasPrefix(header, "W/") {
+			tag.weak = true
+			header = header[len("W/"):]
+		}
+		var end int
+		if strings.HasPrefix(header, "\"") {
+			header = header[1:]
+			if end = strings.Index(header, "\""); end < 0 {
+				end = len(header)
+			}
+			tag.opaque = header[:end]
+			header = header[end:]
+			header = strings.TrimPrefix(header, "\"")
+		} else {
+			if end = strings.IndexAny(header, " \t,"); end < 0 {
+				end = len(header)
+			}
+			tag.opaque = header[:end]
+			header = header[end:]
+		}
+		tags = append(tags, tag)
+	}
+	return tags, false
+}
+
+// matchEntityTags - header lists a tag matching etag, compared weakly or strongly
+func matchEntityTags(header string, etag entityTag, weak bool) bool {
+	tags, wildcard := parseEntityTags(header)
+	if wildcard {
+		return true
+	}
+	for _, tag := range tags {
+		if weak && tag.weakMatch(etag) {
+			return true
+		}
+		if !weak && tag.strongMatch(etag) {
+			return true
+		}
+	}
+	return false
+}
+
+// checkPreconditions - evaluate conditional headers of a GET or HEAD request against the object
+// as served, in the order of RFC 7232 section 6. Returns true if the response was written.
+func checkPreconditions(w http.ResponseWriter, req *http.Request, metadata xl.ObjectMetadata, etag entityTag) bool {
+	// Last-Modified is only precise to the second
+	lastModified := metadata.Created.UTC().Truncate(time.Second)
+	if ifMatch := req.Header.Get("If-Match"); ifMatch != "" {
+		if !matchEntityTags(ifMatch, etag, false) {
+			writeErrorResponse(w, req, PreconditionFailed, req.URL.Path)
+			return true
+		}
+	} else if ifUnmodifiedSince := req.Header.Get("If-Unmodified-Since"); ifUnmodifiedSince != "" {
+		if t, err := http.ParseTime(ifUnmodifiedSince); err == nil && lastModified.After(t) {
+			writeErrorResponse(w, req, PreconditionFailed, req.URL.Path)
+			return true
+		}
+	}
+	notModified := false
+	if ifNoneMatch := req.Header.Get("If-None-Match"); ifNoneMatch != "" {
+		notModified = matchEntityTags(ifNoneMatch, etag, true)
+	} else if ifModifiedSince := req.Header.Get("If-Modified-Since"); ifModifiedSince != "" {
+		if t, err := http.ParseTime(ifModifiedSince); err == nil && !lastModified.After(t) {
+			notModified = true
+		}
+	}
+	if notModified {
+		// headers a cache refreshes its stored response with, RFC 7232 section 4.1, no body
+		w.Header().Set("ETag", etag.String())
+		w.Header().Set("Last-Modified", lastModified.Format(http.TimeFormat))
+		setCacheControlHeader(w)
+		w.WriteHeader(http.StatusNotModified)
+		return true
+	}
+	return false
+}
+
+// isIfRangeMatch - the Range of a request applies only if its If-Range still names the object
+// as served, RFC 7233 section 3.2. Only a strong ETag or the exact Last-Modified date matches, a
+// changed object is then sent whole instead of bytes which do not belong to what the client has.
+func isIfRangeMatch(req *http.Request, metadata xl.ObjectMetadata) bool {
+	ifRange := strings.TrimSpace(req.Header.Get("If-Range"))
+	if ifRange == "" {
+		return true
+	}
+	if t, err := http.ParseTime(ifRange); err == nil {
+		return metadata.Created.UTC().Truncate(time.Second).Equal(t)
+	}
+	tags, wildcard := parseEntityTags(ifRange)
+	if wildcard || len(tags) != 1 {
+		return false
+	}
+	return tags[0].strongMatch(getObjectETag(metadata, false))
+}
+
+// getExpectedETag - ETag an object must still have for a conditional write, given its If-Match
+// header. Only strong tags ever match, ‘*’ matches any existing object and is returned as empty.
+// Of several tags the one matching the current object is returned, the write itself verifies it.
+func getExpectedETag(storage xl.Interface, bucket, object, ifMatch string) (string, bool) {
+	tags, wildcard := parseEntityTags(ifMatch)
+	if wildcard {
+		return "", true
+	}
+	var strongTags []entityTag
+	for _, tag := range tags {
+		if !tag.weak && tag.opaque != "" {
+			strongTags = append(strongTags, tag)
+		}
+	}
+	switch len(strongTags) {
+	case 0:
+		return "", false
+	case 1:
+		return strongTags[0].opaque, true
+	}
+	if metadata, err := storage.GetObjectMetadata(bucket, object); err == nil {
+		for _, tag := range strongTags {
+			if tag.strongMatch(getObjectETag(metadata, false)) {
+				return tag.opaque, true
+			}
+		}
+	}
+	return strongTags[0].opaque, true
+}
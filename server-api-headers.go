@@ -42,11 +42,27 @@ func generateRequestID() []byte {
 	return alpha
 }
 
+// globalSanitizeHeaders is set by startServer when --sanitize-headers is given
+var globalSanitizeHeaders bool
+
+// globalCacheControl is set by startServer with --cache-control, not sent by default
+var globalCacheControl string
+
+// setCacheControlHeader - caches keep objects served as told by --cache-control
+func setCacheControlHeader(w http.ResponseWriter) {
+	if globalCacheControl != "" {
+		w.Header().Set("Cache-Control", globalCacheControl)
+	}
+}
+
 // Write http common headers
 func setCommonHeaders(w http.ResponseWriter, contentLength int) {
 	// set unique request ID for each reply
 	w.Header().Set("X-Amz-Request-Id", string(generateRequestID()))
-	w.Header().Set("Server", ("Minio/" + minioXLReleaseTag + " (" + runtime.GOOS + ";" + runtime.GOARCH + ")"))
+	// release and platform are not disclosed to internet facing clients
+	if !globalSanitizeHeaders {
+		w.Header().Set("Server", ("Minio/" + minioXLReleaseTag + " (" + runtime.GOOS + ";" + runtime.GOARCH + ")"))
+	}
 	w.Header().Set("Accept-Ranges", "bytes")
 	w.Header().Set("Connection", "close")
 	// should be set to '0' by default
@@ -74,12 +90,28 @@ func setObjectHeaders(w http.ResponseWriter, metadata xl.ObjectMetadata, content
 	} else {
 		setCommonHeaders(w, int(metadata.Size))
 	}
-	// set object headers
-	lastModified := metadata.Created.Format(http.TimeFormat)
+	// set object headers, http dates are always in GMT
+	lastModified := metadata.Created.UTC().Format(http.TimeFormat)
 	// object related headers
 	w.Header().Set("Content-Type", metadata.Metadata["contentType"])
 	w.Header().Set("ETag", "\""+metadata.MD5Sum+"\"")
 	w.Header().Set("Last-Modified", lastModified)
+	setCacheControlHeader(w)
+	// preserved modification time is returned as unix time in seconds
+	if _, ok := metadata.Metadata["mtime"]; ok {
+		w.Header().Set("X-Amz-Meta-Mtime", strconv.FormatFloat(float64(metadata.Created.UnixNano())/1e9, 'f', -1, 64))
+	}
+	setChecksumHeader(w, metadata)
+	setUserMetadataHeaders(w, metadata)
+	if legalHold, ok := metadata.Metadata["legalHold"]; ok {
+		w.Header().Set("X-Amz-Object-Lock-Legal-Hold", legalHold)
+	}
+	if location, ok := metadata.Metadata[websiteRedirectLocationKey]; ok {
+		w.Header().Set("X-Amz-Website-Redirect-Location", location)
+	}
+	if status, ok := metadata.Metadata[replicationStatusKey]; ok {
+		w.Header().Set("X-Amz-Replication-Status", status)
+	}
 
 	// set content range
 	if contentRange != nil {
@@ -90,6 +122,31 @@ func setObjectHeaders(w http.ResponseWriter, metadata xl.ObjectMetadata, content
 	}
 }
 
+// Write additional checksum of the object if any
+func setChecksumHeader(w http.ResponseWriter, metadata xl.ObjectMetadata) {
+	if checksumHeader, ok := checksumHeaders[metadata.Metadata["checksumAlgorithm"]]; ok {
+		w.Header().Set(checksumHeader, metadata.Metadata["checksum"])
+	}
+}
+
+// Write user metadata of the object, keys are set directly to keep the case they were sent in
+func setUserMetadataHeaders(w http.ResponseWriter, metadata xl.ObjectMetadata) {
+	for key, value := range metadata.Metadata {
+		if xl.IsUserMetadata(key) {
+			w.Header()[key] = []string{value}
+		}
+	}
+}
+
+// Write headers announcing the checksum trailer, sent after a body of unknown length
+func setChecksumTrailerHeaders(w http.ResponseWriter, algorithm string) {
+	for _, checksumHeader := range checksumHeaders {
+		w.Header().Del(checksumHeader)
+	}
+	w.Header().Del("Content-Length")
+	w.Header().Set("Trailer", checksumHeaders[algorithm])
+}
+
 func encodeSuccessResponse(response interface{}) []byte {
 	var bytesBuffer bytes.Buffer
 	e := xml.NewEncoder(&bytesBuffer)
@@ -19,6 +19,7 @@ package main
 import (
 	"errors"
 	"fmt"
+	"net/http"
 	"strconv"
 	"strings"
 
@@ -57,17 +58,26 @@ func getRequestedRange(hrange string, size int64) (*httpRange, *probe.Error) {
 	return r, nil
 }
 
+// parsePosition - first or last byte position of a range, signs are not allowed
+func parsePosition(position string) (int64, error) {
+	i, err := strconv.ParseUint(position, 10, 63)
+	return int64(i), err
+}
+
+// parse - ‘first-last’, ‘first-’ to the end or ‘-length’ of the end of the object. Ranges starting
+// beyond the end, reversed ranges and ranges of empty objects can never be satisfied, ranges
+// ending beyond the end are cut short.
 func (r *httpRange) parse(ra string) *probe.Error {
-	i := strings.Index(ra, "-")
-	if i < 0 {
+	dash := strings.Index(ra, "-")
+	if dash < 0 {
 		return probe.NewError(xl.InvalidRange{})
 	}
-	start, end := strings.TrimSpace(ra[:i]), strings.TrimSpace(ra[i+1:])
+	start, end := strings.TrimSpace(ra[:dash]), strings.TrimSpace(ra[dash+1:])
 	if start == "" {
 		// If no start is specified, end specifies the
 		// range start relative to the end of the file.
-		i, err := strconv.ParseInt(end, 10, 64)
-		if err != nil {
+		i, err := parsePosition(end)
+		if err != nil || i == 0 || r.size == 0 {
 			return probe.NewError(xl.InvalidRange{})
 		}
 		if i > r.size {
@@ -75,26 +85,26 @@ func (r *httpRange) parse(ra string) *probe.Error {
 		}
 		r.start = r.size - i
 		r.length = r.size - r.start
-	} else {
-		i, err := strconv.ParseInt(start, 10, 64)
-		if err != nil || i > r.size || i < 0 {
-			return probe.NewError(xl.InvalidRange{})
-		}
-		r.start = i
-		if end == "" {
-			// If no end is specified, range extends to end of the file.
-			r.length = r.size - r.start
-		} else {
-			i, err := strconv.ParseInt(end, 10, 64)
-			if err != nil || r.start > i {
-				return probe.NewError(xl.InvalidRange{})
-			}
-			if i >= r.size {
-				i = r.size - 1
-			}
-			r.length = i - r.start + 1
-		}
+		return nil
+	}
+	i, err := parsePosition(start)
+	if err != nil || i >= r.size {
+		return probe.NewError(xl.InvalidRange{})
+	}
+	r.start = i
+	if end == "" {
+		// If no end is specified, range extends to end of the file.
+		r.length = r.size - r.start
+		return nil
+	}
+	i, err = parsePosition(end)
+	if err != nil || r.start > i {
+		return probe.NewError(xl.InvalidRange{})
 	}
+	if i >= r.size {
+		i = r.size - 1
+	}
+	r.length = i - r.start + 1
 	return nil
 }
 
@@ -122,3 +132,38 @@ func (r *httpRange) parseRange(s string) *probe.Error {
 	}
 	return r.parse(ra)
 }
+
+// writeRangeNotSatisfiable - InvalidRange error, along with the size of the object the range
+// was checked against
+func writeRangeNotSatisfiable(w http.ResponseWriter, req *http.Request, size int64) {
+	w.Header().Set("Content-Range", "bytes */"+strconv.FormatInt(size, 10))
+	writeErrorResponse(w, req, InvalidRange, req.URL.Path)
+}
+
+// getCopySourceRange - range of a part copied from an object, x-amz-copy-source-range is
+// ‘bytes=first-last’ with both positions mandatory and within the object. The whole
+// object is copied when the header is empty.
+func getCopySourceRange(hrange string, size int64) (*httpRange, *probe.Error) {
+	r := &httpRange{start: 0, length: size, size: size}
+	if hrange == "" {
+		return r, nil
+	}
+	if !strings.HasPrefix(hrange, b) {
+		return nil, probe.NewError(xl.InvalidRange{})
+	}
+	ra := strings.SplitN(hrange[len(b):], "-", 2)
+	if len(ra) != 2 {
+		return nil, probe.NewError(xl.InvalidRange{})
+	}
+	start, err := strconv.ParseInt(strings.TrimSpace(ra[0]), 10, 64)
+	if err != nil {
+		return nil, probe.NewError(xl.InvalidRange{})
+	}
+	end, err := strconv.ParseInt(strings.TrimSpace(ra[1]), 10, 64)
+	if err != nil || start < 0 || start > end || end >= size {
+		return nil, probe.NewError(xl.InvalidRange{Start: start, Length: end - start + 1})
+	}
+	r.start = start
+	r.length = end - start + 1
+	return r, nil
+}
@@ -17,10 +17,18 @@
 package main
 
 import (
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/xml"
+	"io"
+	"io/ioutil"
 	"net/http"
 	"strconv"
+	"strings"
+	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
 	"github.com/minio/minio-xl/pkg/probe"
 	signv4 "github.com/minio/minio-xl/pkg/signature"
 	"github.com/minio/minio-xl/pkg/xl"
@@ -28,6 +36,8 @@ import (
 
 const (
 	maxPartsList = 1000
+	// maximum size of legal hold accepted
+	maxLegalHoldSize = 1024
 )
 
 // GetObjectHandler - GET Object
@@ -49,7 +59,7 @@ func (api API) GetObjectHandler(w http.ResponseWriter, req *http.Request) {
 	bucket = vars["bucket"]
 	object = vars["object"]
 
-	metadata, err := api.XL.GetObjectMetadata(bucket, object)
+	metadata, err := api.getStorage(req).GetObjectMetadata(bucket, object)
 	if err != nil {
 		errorIf(err.Trace(), "GetObject failed.", nil)
 		switch err.ToGoError().(type) {
@@ -66,14 +76,49 @@ func (api API) GetObjectHandler(w http.ResponseWriter, req *http.Request) {
 		}
 		return
 	}
+	// a stale If-Range turns the request into a fresh download of the whole object, still sent
+	// uncompressed with a strong ETag so that it can be resumed in turn
+	requestedRange := req.Header.Get("Range")
+	if !isIfRangeMatch(req, metadata) {
+		requestedRange = ""
+	}
 	var hrange *httpRange
-	hrange, err = getRequestedRange(req.Header.Get("Range"), metadata.Size)
+	hrange, err = getRequestedRange(requestedRange, metadata.Size)
 	if err != nil {
-		writeErrorResponse(w, req, InvalidRange, req.URL.Path)
+		writeRangeNotSatisfiable(w, req, metadata.Size)
+		return
+	}
+	// integrity of the bytes sent is preferred over saving bandwidth
+	trailer, hasTrailer := getChecksumTrailer(req, metadata)
+	compressed := !hasTrailer && isCompressedResponse(req, metadata)
+	setVaryHeader(w, metadata)
+	if checkPreconditions(w, req, metadata, getObjectETag(metadata, compressed)) {
 		return
 	}
 	setObjectHeaders(w, metadata, hrange)
-	if _, err = api.XL.GetObject(w, bucket, object, hrange.start, hrange.length); err != nil {
+	if hasTrailer {
+		setChecksumTrailerHeaders(w, trailer)
+		checksum := xl.NewChecksumHash(trailer)
+		if _, err = api.getStorage(req).GetObject(io.MultiWriter(w, checksum), bucket, object, 0, 0); err != nil {
+			errorIf(err.Trace(), "GetObject failed.", nil)
+			return
+		}
+		// checksum of the bytes as read from disks, a mismatch is reported to the client as is
+		sum := base64.StdEncoding.EncodeToString(checksum.Sum(nil))
+		if stored, ok := metadata.Metadata["checksum"]; ok && stored != sum {
+			errorIf(probe.NewError(xl.BadDigest{}), "GetObject checksum mismatch.", map[string]interface{}{"object": bucket + "/" + object})
+		}
+		w.Header().Set(checksumHeaders[trailer], sum)
+		return
+	}
+	if compressed {
+		setCompressedHeaders(w, metadata)
+		if err = getCompressedObject(api.XL, w, bucket, object); err != nil {
+			errorIf(err.Trace(), "GetObject failed.", nil)
+		}
+		return
+	}
+	if _, err = api.getStorage(req).GetObject(w, bucket, object, hrange.start, hrange.length); err != nil {
 		errorIf(err.Trace(), "GetObject failed.", nil)
 		return
 	}
@@ -97,7 +142,7 @@ func (api API) HeadObjectHandler(w http.ResponseWriter, req *http.Request) {
 	bucket = vars["bucket"]
 	object = vars["object"]
 
-	metadata, err := api.XL.GetObjectMetadata(bucket, object)
+	metadata, err := api.getStorage(req).GetObjectMetadata(bucket, object)
 	if err != nil {
 		errorIf(err.Trace(), "GetObjectMetadata failed.", nil)
 		switch err.ToGoError().(type) {
@@ -114,7 +159,15 @@ func (api API) HeadObjectHandler(w http.ResponseWriter, req *http.Request) {
 		}
 		return
 	}
+	compressed := isCompressedResponse(req, metadata)
+	setVaryHeader(w, metadata)
+	if checkPreconditions(w, req, metadata, getObjectETag(metadata, compressed)) {
+		return
+	}
 	setObjectHeaders(w, metadata, nil)
+	if compressed {
+		setCompressedHeaders(w, metadata)
+	}
 	w.WriteHeader(http.StatusOK)
 }
 
@@ -187,7 +240,96 @@ func (api API) PutObjectHandler(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	metadata, err := api.XL.CreateObject(bucket, object, md5, sizeInt64, req.Body, nil, signature)
+	// archive is exploded into objects under the key instead of being stored as is
+	if isExtractRequest(req) {
+		api.extractObject(w, req, bucket, object, md5, sizeInt64, signature)
+		return
+	}
+
+	// preserve modification time of the object if requested, useful for backup and sync tools
+	var objectMetadata map[string]string
+	if mtime := req.Header.Get("X-Amz-Meta-Mtime"); mtime != "" {
+		modTime, ok := parseModTime(mtime)
+		if !ok {
+			writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+			return
+		}
+		objectMetadata = map[string]string{"mtime": modTime.Format(time.RFC3339Nano)}
+	}
+	// user metadata keeps the case of its keys as sent
+	if userMetadata := getUserMetadata(req); len(userMetadata) > 0 {
+		if objectMetadata == nil {
+			objectMetadata = make(map[string]string)
+		}
+		for key, value := range userMetadata {
+			objectMetadata[key] = value
+		}
+	}
+	// additional checksum requested by newer sdks, verified while the object is written
+	algorithm, checksum, ok := getChecksum(req.Header)
+	if !ok {
+		writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+		return
+	}
+	if algorithm != "" {
+		if objectMetadata == nil {
+			objectMetadata = make(map[string]string)
+		}
+		objectMetadata["checksumAlgorithm"] = algorithm
+		objectMetadata["checksum"] = checksum
+	}
+	// object under legal hold cannot be deleted or overwritten until it is released
+	if legalHold := req.Header.Get("X-Amz-Object-Lock-Legal-Hold"); legalHold != "" {
+		if !xl.IsValidLegalHold(legalHold) {
+			writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+			return
+		}
+		if legalHold == xl.LegalHoldOn {
+			if objectMetadata == nil {
+				objectMetadata = make(map[string]string)
+			}
+			objectMetadata["legalHold"] = legalHold
+		}
+	}
+
+	// objects served by the website endpoint can redirect to another page of the site or elsewhere
+	if location := req.Header.Get("X-Amz-Website-Redirect-Location"); location != "" {
+		if !isValidWebsiteRedirectLocation(location) {
+			writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+			return
+		}
+		if objectMetadata == nil {
+			objectMetadata = make(map[string]string)
+		}
+		objectMetadata[websiteRedirectLocationKey] = location
+	}
+
+	// content type sent by the client is stored, it wins over the default of the bucket
+	if contentType := req.Header.Get("Content-Type"); contentType != "" {
+		if objectMetadata == nil {
+			objectMetadata = make(map[string]string)
+		}
+		objectMetadata["contentType"] = contentType
+	}
+
+	// retries of a PUT which already succeeded are answered with its result, nothing is written
+	idempotencyKey, ok := getIdempotencyKey(req, bucket, object)
+	if !ok {
+		writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+		return
+	}
+	body, finish, original, err := globalIdempotencyCache.start(idempotencyKey, req.Body)
+	if err != nil {
+		errorIf(err.Trace(), "Retried PUT still in progress.", nil)
+		writeErrorResponse(w, req, OperationAborted, req.URL.Path)
+		return
+	}
+	if original != nil {
+		writeIdempotentPutResponse(w, req, original, signature)
+		return
+	}
+	metadata, err := api.getStorage(req).CreateObject(bucket, object, md5, sizeInt64, body, objectMetadata, signature)
+	finish(metadata, err)
 	if err != nil {
 		errorIf(err.Trace(), "CreateObject failed.", nil)
 		switch err.ToGoError().(type) {
@@ -195,6 +337,8 @@ func (api API) PutObjectHandler(w http.ResponseWriter, req *http.Request) {
 			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
 		case xl.BucketNameInvalid:
 			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
 		case xl.ObjectExists:
 			writeErrorResponse(w, req, MutableWriteNotAllowed, req.URL.Path)
 		case xl.BadDigest:
@@ -209,12 +353,17 @@ func (api API) PutObjectHandler(w http.ResponseWriter, req *http.Request) {
 			writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
 		case xl.InvalidDigest:
 			writeErrorResponse(w, req, InvalidDigest, req.URL.Path)
+		case xl.InvalidChecksum:
+			writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
 		default:
 			writeErrorResponse(w, req, InternalError, req.URL.Path)
 		}
 		return
 	}
 	w.Header().Set("ETag", metadata.MD5Sum)
+	setChecksumHeader(w, metadata)
+	runPostUploadHooks(api.XL, bucket, object, req.Header.Get("Content-Type"))
+	globalReplicator.objectCreated(bucket, object)
 	writeSuccessResponse(w)
 }
 
@@ -242,6 +391,8 @@ func (api API) NewMultipartUploadHandler(w http.ResponseWriter, req *http.Reques
 		switch err.ToGoError().(type) {
 		case xl.ObjectExists:
 			writeErrorResponse(w, req, MutableWriteNotAllowed, req.URL.Path)
+		case xl.TooManyMultipartUploads:
+			writeErrorResponse(w, req, ServiceUnavailable, req.URL.Path)
 		default:
 			writeErrorResponse(w, req, InternalError, req.URL.Path)
 		}
@@ -250,10 +401,8 @@ func (api API) NewMultipartUploadHandler(w http.ResponseWriter, req *http.Reques
 
 	response := generateInitiateMultipartUploadResponse(bucket, object, uploadID)
 	encodedSuccessResponse := encodeSuccessResponse(response)
-	// write headers
-	setCommonHeaders(w, len(encodedSuccessResponse))
-	// write body
-	w.Write(encodedSuccessResponse)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
 }
 
 // PutObjectPartHandler - Upload part
@@ -314,6 +463,14 @@ func (api API) PutObjectPartHandler(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
+	// parts of the same upload beyond --max-parts-in-flight wait for others to finish
+	if !globalPartLimiter.acquire(uploadID, partWaitTimeout) {
+		w.Header().Set("Retry-After", strconv.Itoa(partRetryAfter))
+		writeErrorResponse(w, req, SlowDown, req.URL.Path)
+		return
+	}
+	defer globalPartLimiter.release(uploadID)
+
 	var signature *signv4.Signature
 	if !api.Anonymous {
 		if _, ok := req.Header["Authorization"]; ok {
@@ -328,7 +485,7 @@ func (api API) PutObjectPartHandler(w http.ResponseWriter, req *http.Request) {
 		}
 	}
 
-	calculatedMD5, err := api.XL.CreateObjectPart(bucket, object, uploadID, partID, "", md5, sizeInt64, req.Body, signature)
+	calculatedMD5, err := api.getStorage(req).CreateObjectPart(bucket, object, uploadID, partID, "", md5, sizeInt64, req.Body, signature)
 	if err != nil {
 		errorIf(err.Trace(), "CreateObjectPart failed.", nil)
 		switch err.ToGoError().(type) {
@@ -355,6 +512,123 @@ func (api API) PutObjectPartHandler(w http.ResponseWriter, req *http.Request) {
 	writeSuccessResponse(w)
 }
 
+// CopyObjectPartHandler - Upload part copy
+// -----------
+// Creates a part of a multipart upload from a byte range of an existing object given with
+// x-amz-copy-source and x-amz-copy-source-range, the data never leaves the server.
+func (api API) CopyObjectPartHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	uploadID := req.URL.Query().Get("uploadId")
+	partID, e := strconv.Atoi(req.URL.Query().Get("partNumber"))
+	if e != nil {
+		writeErrorResponse(w, req, InvalidPart, req.URL.Path)
+		return
+	}
+
+	sourceBucket, sourceObject, ok := getMoveSource(req.Header.Get("X-Amz-Copy-Source"))
+	if !ok {
+		writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+		return
+	}
+
+	// request has no payload, verify signature here
+	if !api.Anonymous {
+		if _, ok := req.Header["Authorization"]; ok {
+			signature, err := initSignatureV4(req)
+			if err != nil {
+				errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
+				writeErrorResponse(w, req, InternalError, req.URL.Path)
+				return
+			}
+			ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sha256.Sum256([]byte(""))))
+			if err != nil {
+				errorIf(err.Trace(), "Unable to verify signature.", nil)
+				writeErrorResponse(w, req, InternalError, req.URL.Path)
+				return
+			}
+			if !ok {
+				writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+				return
+			}
+		}
+	}
+
+	metadata, err := api.getStorage(req).GetObjectMetadata(sourceBucket, sourceObject)
+	if err != nil {
+		errorIf(err.Trace(), "GetObjectMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.ObjectNotFound, xl.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	hrange, err := getCopySourceRange(req.Header.Get("X-Amz-Copy-Source-Range"), metadata.Size)
+	if err != nil {
+		writeErrorResponse(w, req, InvalidRange, req.URL.Path)
+		return
+	}
+	if isMaxObjectSize(strconv.FormatInt(hrange.length, 10)) {
+		writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
+		return
+	}
+
+	// parts of the same upload beyond --max-parts-in-flight wait for others to finish
+	if !globalPartLimiter.acquire(uploadID, partWaitTimeout) {
+		w.Header().Set("Retry-After", strconv.Itoa(partRetryAfter))
+		writeErrorResponse(w, req, SlowDown, req.URL.Path)
+		return
+	}
+	defer globalPartLimiter.release(uploadID)
+
+	// long copies keep the connection busy with whitespace until they are done
+	var etag string
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		etag, err = api.getStorage(req).CopyObjectPart(bucket, object, uploadID, partID, sourceBucket, sourceObject, hrange.start, hrange.length)
+	}()
+	keepAlive := &keepAliveResponse{w: w}
+	keepAlive.wait(done)
+	if err != nil {
+		errorIf(err.Trace(), "CopyObjectPart failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.InvalidUploadID:
+			keepAlive.writeErrorResponse(req, NoSuchUpload, req.URL.Path)
+		case xl.ObjectNotFound:
+			keepAlive.writeErrorResponse(req, NoSuchKey, req.URL.Path)
+		case xl.InvalidRange:
+			keepAlive.writeErrorResponse(req, InvalidRange, req.URL.Path)
+		case xl.BucketNotFound:
+			keepAlive.writeErrorResponse(req, NoSuchBucket, req.URL.Path)
+		default:
+			keepAlive.writeErrorResponse(req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	response := generateCopyObjectPartResponse(etag, time.Now().UTC())
+	encodedSuccessResponse := encodeSuccessResponse(response)
+	// write headers and body
+	keepAlive.writeSuccessResponse(req, encodedSuccessResponse)
+}
+
 // AbortMultipartUploadHandler - Abort multipart upload
 func (api API) AbortMultipartUploadHandler(w http.ResponseWriter, req *http.Request) {
 	// Ticket master block
@@ -428,10 +702,8 @@ func (api API) ListObjectPartsHandler(w http.ResponseWriter, req *http.Request)
 	}
 	response := generateListPartsResponse(objectResourcesMetadata)
 	encodedSuccessResponse := encodeSuccessResponse(response)
-	// write headers
-	setCommonHeaders(w, len(encodedSuccessResponse))
-	// write body
-	w.Write(encodedSuccessResponse)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
 }
 
 // CompleteMultipartUploadHandler - Complete multipart upload
@@ -465,14 +737,16 @@ func (api API) CompleteMultipartUploadHandler(w http.ResponseWriter, req *http.R
 		}
 	}
 
-	metadata, err := api.XL.CompleteMultipartUpload(bucket, object, objectResourcesMetadata.UploadID, req.Body, signature)
+	metadata, err := api.getStorage(req).CompleteMultipartUpload(bucket, object, objectResourcesMetadata.UploadID, req.Body, signature)
 	if err != nil {
 		errorIf(err.Trace(), "CompleteMultipartUpload failed.", nil)
-		switch err.ToGoError().(type) {
+		switch e := err.ToGoError().(type) {
 		case xl.InvalidUploadID:
 			writeErrorResponse(w, req, NoSuchUpload, req.URL.Path)
 		case xl.InvalidPart:
 			writeErrorResponse(w, req, InvalidPart, req.URL.Path)
+		case xl.CorruptPart:
+			writePartErrorResponse(w, req, CorruptPart, e.PartNumber, req.URL.Path)
 		case xl.InvalidPartOrder:
 			writeErrorResponse(w, req, InvalidPartOrder, req.URL.Path)
 		case signv4.MissingDateHeader:
@@ -488,12 +762,11 @@ func (api API) CompleteMultipartUploadHandler(w http.ResponseWriter, req *http.R
 		}
 		return
 	}
+	globalReplicator.objectCreated(bucket, object)
 	response := generateCompleteMultpartUploadResponse(bucket, object, "", metadata.MD5Sum)
 	encodedSuccessResponse := encodeSuccessResponse(response)
-	// write headers
-	setCommonHeaders(w, len(encodedSuccessResponse))
-	// write body
-	w.Write(encodedSuccessResponse)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
 }
 
 /// Delete API
@@ -503,7 +776,323 @@ func (api API) DeleteBucketHandler(w http.ResponseWriter, req *http.Request) {
 	writeErrorResponse(w, req, DeleteNotAllowed, req.URL.Path)
 }
 
+// PutObjectLegalHoldHandler - PUT Object legal hold
+// -----------
+// Places or releases a legal hold on an object, objects under legal hold
+// cannot be deleted or overwritten until the hold is released.
+func (api API) PutObjectLegalHoldHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	/// if Content-Length missing, deny the request
+	if req.Header.Get("Content-Length") == "" {
+		writeErrorResponse(w, req, MissingContentLength, req.URL.Path)
+		return
+	}
+
+	var object, bucket string
+	vars := mux.Vars(req)
+	bucket = vars["bucket"]
+	object = vars["object"]
+
+	legalHoldBytes, e := ioutil.ReadAll(io.LimitReader(req.Body, maxLegalHoldSize))
+	if e != nil {
+		errorIf(probe.NewError(e), "Unable to read legal hold.", nil)
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+	if !api.Anonymous {
+		ok, err := doesBodySignatureMatch(req, legalHoldBytes)
+		if err != nil {
+			errorIf(err.Trace(), "Unable to verify signature.", nil)
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+			return
+		}
+		if !ok {
+			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+			return
+		}
+	}
+	legalHold := ObjectLegalHold{}
+	if e := xml.Unmarshal(legalHoldBytes, &legalHold); e != nil || !xl.IsValidLegalHold(legalHold.Status) {
+		writeErrorResponse(w, req, MalformedXML, req.URL.Path)
+		return
+	}
+	// released hold is removed from object metadata
+	status := legalHold.Status
+	if status == xl.LegalHoldOff {
+		status = ""
+	}
+
+	_, err := api.XL.SetObjectMetadata(bucket, object, map[string]string{"legalHold": status})
+	if err != nil {
+		errorIf(err.Trace(), "PutObjectLegalHold failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.ObjectNotFound:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	writeSuccessResponse(w)
+}
+
+// GetObjectLegalHoldHandler - GET Object legal hold
+// -----------
+// Returns legal hold status of an object, OFF if it was never placed.
+func (api API) GetObjectLegalHoldHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	var object, bucket string
+	vars := mux.Vars(req)
+	bucket = vars["bucket"]
+	object = vars["object"]
+
+	metadata, err := api.getStorage(req).GetObjectMetadata(bucket, object)
+	if err != nil {
+		errorIf(err.Trace(), "GetObjectMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.ObjectNotFound:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	legalHold := ObjectLegalHold{Status: xl.LegalHoldOff}
+	if status, ok := metadata.Metadata["legalHold"]; ok {
+		legalHold.Status = status
+	}
+	encodedSuccessResponse := encodeSuccessResponse(legalHold)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
+}
+
+// isEmptyPayloadSignatureMatch - verify signature of a PUT without payload, SignatureHandler
+// leaves every PUT to its handler. Writes the error response if it does not match
+func (api API) isEmptyPayloadSignatureMatch(w http.ResponseWriter, req *http.Request) bool {
+	if api.Anonymous {
+		return true
+	}
+	if _, ok := req.Header["Authorization"]; !ok {
+		return true
+	}
+	signature, err := initSignatureV4(req)
+	if err != nil {
+		errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return false
+	}
+	ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sha256.Sum256([]byte(""))))
+	if err != nil {
+		errorIf(err.Trace(), "Unable to verify signature.", nil)
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return false
+	}
+	if !ok {
+		writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		return false
+	}
+	return true
+}
+
+// MoveObjectHandler - PUT Object with X-Minio-Move-Source
+// -----------
+// Moves an object to a new name in the same bucket, data blocks are not rewritten
+// and the object is never visible under both names.
+func (api API) MoveObjectHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	var object, bucket string
+	vars := mux.Vars(req)
+	bucket = vars["bucket"]
+	object = vars["object"]
+
+	sourceBucket, sourceObject, ok := getMoveSource(req.Header.Get("X-Minio-Move-Source"))
+	if !ok {
+		writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+		return
+	}
+	// block map lives with the bucket, objects cannot move across buckets
+	if sourceBucket != bucket {
+		writeErrorResponse(w, req, NotImplemented, req.URL.Path)
+		return
+	}
+
+	// request has no payload, verify signature here
+	if !api.isEmptyPayloadSignatureMatch(w, req) {
+		return
+	}
+
+	metadata, err := api.getStorage(req).MoveObject(bucket, sourceObject, object)
+	if err != nil {
+		errorIf(err.Trace(), "MoveObject failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.ObjectNotFound:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		case xl.ObjectExists:
+			writeErrorResponse(w, req, MutableWriteNotAllowed, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	globalReplicator.objectDeleted(bucket, sourceObject, metadata.MD5Sum)
+	globalReplicator.objectCreated(bucket, object)
+	w.Header().Set("ETag", "\""+metadata.MD5Sum+"\"")
+	writeSuccessResponse(w)
+}
+
+// TouchObjectHandler - PUT Object copying an object onto itself with X-Minio-Touch: true
+// -----------
+// Sets the modification time of the object to now, data, ETag and metadata stay the same.
+// A copy from any other object is not supported.
+func (api API) TouchObjectHandler(w http.ResponseWriter, req *http.Request) {
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	var object, bucket string
+	vars := mux.Vars(req)
+	bucket = vars["bucket"]
+	object = vars["object"]
+
+	sourceBucket, sourceObject, ok := getMoveSource(req.Header.Get("X-Amz-Copy-Source"))
+	if !ok {
+		writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+		return
+	}
+	if sourceBucket != bucket || sourceObject != object {
+		writeErrorResponse(w, req, NotImplemented, req.URL.Path)
+		return
+	}
+
+	// request has no payload, verify signature here
+	if !api.isEmptyPayloadSignatureMatch(w, req) {
+		return
+	}
+
+	metadata, err := api.getStorage(req).TouchObject(bucket, object)
+	if err != nil {
+		errorIf(err.Trace(), "TouchObject failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.ObjectNotFound:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	response := generateCopyObjectResponse(metadata.MD5Sum, metadata.Created)
+	encodedSuccessResponse := encodeSuccessResponse(response)
+	// write headers and body
+	writeXMLResponse(w, req, http.StatusOK, encodedSuccessResponse)
+}
+
 // DeleteObjectHandler - Delete object
+// -----------
+// Objects are immutable, a delete is only allowed with an If-Match header carrying the
+// current ETag of the object. Object is not removed if it was overwritten in the meantime.
 func (api API) DeleteObjectHandler(w http.ResponseWriter, req *http.Request) {
-	writeErrorResponse(w, req, DeleteNotAllowed, req.URL.Path)
+	// Ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	ifMatch := strings.TrimSpace(req.Header.Get("If-Match"))
+	if ifMatch == "" {
+		writeErrorResponse(w, req, DeleteNotAllowed, req.URL.Path)
+		return
+	}
+
+	var object, bucket string
+	vars := mux.Vars(req)
+	bucket = vars["bucket"]
+	object = vars["object"]
+
+	expectedETag, ok := getExpectedETag(api.XL, bucket, object, ifMatch)
+	if !ok {
+		writeErrorResponse(w, req, PreconditionFailed, req.URL.Path)
+		return
+	}
+	err := api.getStorage(req).DeleteObject(bucket, object, expectedETag)
+	if err != nil {
+		errorIf(err.Trace(), "DeleteObject failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.ObjectNotFound:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.ObjectNameInvalid:
+			writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+		case xl.PreconditionFailed:
+			writeErrorResponse(w, req, PreconditionFailed, req.URL.Path)
+		case xl.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	globalReplicator.objectDeleted(bucket, object, expectedETag)
+	setCommonHeaders(w, 0)
+	w.WriteHeader(http.StatusNoContent)
 }
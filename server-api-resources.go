@@ -17,8 +17,13 @@
 package main
 
 import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"net/http"
 	"net/url"
 	"strconv"
+	"strings"
 
 	"github.com/minio/minio-xl/pkg/xl"
 )
@@ -29,10 +34,76 @@ func getBucketResources(values url.Values) (v xl.BucketResourcesMetadata) {
 	v.Marker = values.Get("marker")
 	v.Maxkeys, _ = strconv.Atoi(values.Get("max-keys"))
 	v.Delimiter = values.Get("delimiter")
+	v.Depth, _ = strconv.Atoi(values.Get("depth"))
 	v.EncodingType = values.Get("encoding-type")
 	return
 }
 
+// listing keys are url encoded in responses to requests with this encoding-type
+const urlEncodingType = "url"
+
+// isValidEncodingType - listings support url encoding of keys or none at all
+func isValidEncodingType(encodingType string) bool {
+	return encodingType == "" || strings.EqualFold(encodingType, urlEncodingType)
+}
+
+// getEncodingType - encoding-type of a listing response, valid encodings in any case are sent
+// back in lower case
+func getEncodingType(encodingType string) string {
+	if strings.EqualFold(encodingType, urlEncodingType) {
+		return urlEncodingType
+	}
+	return encodingType
+}
+
+// encodeListingName - key, prefix, marker or delimiter of a listing as it is sent in the response,
+// url encoding escapes every byte except unreserved characters and '/'
+func encodeListingName(name, encodingType string) string {
+	if !strings.EqualFold(encodingType, urlEncodingType) {
+		return name
+	}
+	var encoded bytes.Buffer
+	for i := 0; i < len(name); i++ {
+		c := name[i]
+		switch {
+		case 'a' <= c && c <= 'z', 'A' <= c && c <= 'Z', '0' <= c && c <= '9':
+			encoded.WriteByte(c)
+		case c == '-' || c == '_' || c == '.' || c == '~' || c == '/':
+			encoded.WriteByte(c)
+		default:
+			fmt.Fprintf(&encoded, "%%%02X", c)
+		}
+	}
+	return encoded.String()
+}
+
+// encodeContinuationToken - opaque continuation token of a version 2 listing, the key
+// listed after
+func encodeContinuationToken(marker string) string {
+	return base64.StdEncoding.EncodeToString([]byte(marker))
+}
+
+// decodeContinuationToken - key a continuation token lists after, false if the token was
+// not generated by encodeContinuationToken
+func decodeContinuationToken(token string) (string, bool) {
+	marker, err := base64.StdEncoding.DecodeString(token)
+	if err != nil {
+		return "", false
+	}
+	return string(marker), true
+}
+
+// getRequestOwner - owner of the listed objects as seen by a request, named after the
+// access key it is signed with, anonymous requests see the server as owner
+func getRequestOwner(req *http.Request) *Owner {
+	owner := &Owner{ID: "minio-xl", DisplayName: "minio-xl"}
+	if accessKey := getAuditAccessKey(req); accessKey != "" {
+		owner.ID = accessKey
+		owner.DisplayName = accessKey
+	}
+	return owner
+}
+
 // part bucket url queries for ?uploads
 func getBucketMultipartResources(values url.Values) (v xl.BucketMultipartResourcesMetadata) {
 	v.Prefix = values.Get("prefix")
@@ -18,6 +18,7 @@ package main
 
 import (
 	"net/http"
+	"time"
 
 	"github.com/minio/minio-xl/pkg/xl"
 )
@@ -106,32 +107,65 @@ func generateListObjectsResponse(bucket string, objects []xl.ObjectMetadata, buc
 		if object.Object == "" {
 			continue
 		}
-		content.Key = object.Object
+		content.Key = encodeListingName(object.Object, bucketResources.EncodingType)
 		content.LastModified = object.Created.Format(rfcFormat)
 		content.ETag = "\"" + object.MD5Sum + "\""
 		content.Size = object.Size
 		content.StorageClass = "STANDARD"
-		content.Owner = owner
+		content.Owner = &owner
 		contents = append(contents, content)
 	}
-	// TODO - support EncodingType in xml decoding
 	data.Name = bucket
 	data.Contents = contents
 	data.MaxKeys = bucketResources.Maxkeys
-	data.Prefix = bucketResources.Prefix
-	data.Delimiter = bucketResources.Delimiter
-	data.Marker = bucketResources.Marker
-	data.NextMarker = bucketResources.NextMarker
+	data.Prefix = encodeListingName(bucketResources.Prefix, bucketResources.EncodingType)
+	data.Delimiter = encodeListingName(bucketResources.Delimiter, bucketResources.EncodingType)
+	data.Marker = encodeListingName(bucketResources.Marker, bucketResources.EncodingType)
+	data.NextMarker = encodeListingName(bucketResources.NextMarker, bucketResources.EncodingType)
+	data.EncodingType = getEncodingType(bucketResources.EncodingType)
 	data.IsTruncated = bucketResources.IsTruncated
 	for _, prefix := range bucketResources.CommonPrefixes {
 		var prefixItem = &CommonPrefix{}
-		prefixItem.Prefix = prefix
+		prefixItem.Prefix = encodeListingName(prefix, bucketResources.EncodingType)
 		prefixes = append(prefixes, prefixItem)
 	}
 	data.CommonPrefixes = prefixes
 	return data
 }
 
+// generates a ListObjectsV2 response for the said bucket, objects carry owner unless it is nil.
+func generateListObjectsV2Response(bucket string, objects []xl.ObjectMetadata, bucketResources xl.BucketResourcesMetadata, startAfter, continuationToken string, owner *Owner) ListObjectsV2Response {
+	listObjectsResponse := generateListObjectsResponse(bucket, objects, bucketResources)
+	for _, content := range listObjectsResponse.Contents {
+		content.Owner = owner
+	}
+	var data = ListObjectsV2Response{}
+	data.Name = bucket
+	data.Contents = listObjectsResponse.Contents
+	data.CommonPrefixes = listObjectsResponse.CommonPrefixes
+	data.KeyCount = len(data.Contents) + len(data.CommonPrefixes)
+	data.MaxKeys = bucketResources.Maxkeys
+	data.Prefix = listObjectsResponse.Prefix
+	data.Delimiter = listObjectsResponse.Delimiter
+	data.EncodingType = listObjectsResponse.EncodingType
+	data.StartAfter = encodeListingName(startAfter, bucketResources.EncodingType)
+	data.ContinuationToken = continuationToken
+	data.IsTruncated = bucketResources.IsTruncated
+	if data.IsTruncated {
+		// the next page starts where a version 1 listing would place its marker, tokens carry
+		// keys as they are, never encoded
+		nextMarker := bucketResources.NextMarker
+		if nextMarker == "" && len(objects) > 0 {
+			nextMarker = objects[len(objects)-1].Object
+		}
+		if nextMarker == "" && len(bucketResources.CommonPrefixes) > 0 {
+			nextMarker = bucketResources.CommonPrefixes[len(bucketResources.CommonPrefixes)-1]
+		}
+		data.NextContinuationToken = encodeContinuationToken(nextMarker)
+	}
+	return data
+}
+
 // generateInitiateMultipartUploadResponse
 func generateInitiateMultipartUploadResponse(bucket, key, uploadID string) InitiateMultipartUploadResponse {
 	return InitiateMultipartUploadResponse{
@@ -147,7 +181,33 @@ func generateCompleteMultpartUploadResponse(bucket, key, location, etag string)
 		Location: location,
 		Bucket:   bucket,
 		Key:      key,
-		ETag:     etag,
+		ETag:     "\"" + etag + "\"",
+	}
+}
+
+// generateCopyObjectPartResponse
+func generateCopyObjectPartResponse(etag string, lastModified time.Time) CopyObjectPartResponse {
+	return CopyObjectPartResponse{
+		LastModified: lastModified.Format(rfcFormat),
+		ETag:         "\"" + etag + "\"",
+	}
+}
+
+// generateCopyObjectResponse
+func generateCopyObjectResponse(etag string, lastModified time.Time) CopyObjectResponse {
+	return CopyObjectResponse{
+		LastModified: lastModified.Format(rfcFormat),
+		ETag:         "\"" + etag + "\"",
+	}
+}
+
+// generatePostResponse
+func generatePostResponse(bucket, key, location, etag string) PostResponse {
+	return PostResponse{
+		Location: location,
+		Bucket:   bucket,
+		Key:      key,
+		ETag:     "\"" + etag + "\"",
 	}
 }
 
@@ -185,12 +245,12 @@ func generateListPartsResponse(objectMetadata xl.ObjectResourcesMetadata) ListPa
 func generateListMultipartUploadsResponse(bucket string, metadata xl.BucketMultipartResourcesMetadata) ListMultipartUploadsResponse {
 	listMultipartUploadsResponse := ListMultipartUploadsResponse{}
 	listMultipartUploadsResponse.Bucket = bucket
-	listMultipartUploadsResponse.Delimiter = metadata.Delimiter
+	listMultipartUploadsResponse.Delimiter = encodeListingName(metadata.Delimiter, metadata.EncodingType)
 	listMultipartUploadsResponse.IsTruncated = metadata.IsTruncated
-	listMultipartUploadsResponse.EncodingType = metadata.EncodingType
-	listMultipartUploadsResponse.Prefix = metadata.Prefix
-	listMultipartUploadsResponse.KeyMarker = metadata.KeyMarker
-	listMultipartUploadsResponse.NextKeyMarker = metadata.NextKeyMarker
+	listMultipartUploadsResponse.EncodingType = getEncodingType(metadata.EncodingType)
+	listMultipartUploadsResponse.Prefix = encodeListingName(metadata.Prefix, metadata.EncodingType)
+	listMultipartUploadsResponse.KeyMarker = encodeListingName(metadata.KeyMarker, metadata.EncodingType)
+	listMultipartUploadsResponse.NextKeyMarker = encodeListingName(metadata.NextKeyMarker, metadata.EncodingType)
 	listMultipartUploadsResponse.MaxUploads = metadata.MaxUploads
 	listMultipartUploadsResponse.NextUploadIDMarker = metadata.NextUploadIDMarker
 	listMultipartUploadsResponse.UploadIDMarker = metadata.UploadIDMarker
@@ -199,7 +259,7 @@ func generateListMultipartUploadsResponse(bucket string, metadata xl.BucketMulti
 	for _, upload := range metadata.Upload {
 		newUpload := &Upload{}
 		newUpload.UploadID = upload.UploadID
-		newUpload.Key = upload.Key
+		newUpload.Key = encodeListingName(upload.Key, metadata.EncodingType)
 		newUpload.Initiated = upload.Initiated.Format(rfcFormat)
 		listMultipartUploadsResponse.Upload = append(listMultipartUploadsResponse.Upload, newUpload)
 	}
@@ -214,17 +274,21 @@ func writeSuccessResponse(w http.ResponseWriter) {
 
 // writeErrorRespone write error headers
 func writeErrorResponse(w http.ResponseWriter, req *http.Request, errorType int, resource string) {
+	auditErrorResponse(w, errorType)
 	error := getErrorCode(errorType)
 	// generate error response
 	errorResponse := getErrorResponse(error, resource)
 	encodedErrorResponse := encodeErrorResponse(errorResponse)
-	// set common headers
-	setCommonHeaders(w, len(encodedErrorResponse))
-	// write Header
-	w.WriteHeader(error.HTTPStatusCode)
-	// HEAD should have no body, do not attempt to write to it
-	if req.Method != "HEAD" {
-		// write error body
-		w.Write(encodedErrorResponse)
-	}
+	// write headers and body
+	writeXMLResponse(w, req, error.HTTPStatusCode, encodedErrorResponse)
+}
+
+// writePartErrorResponse write error headers, the error names the part of the upload it is about
+func writePartErrorResponse(w http.ResponseWriter, req *http.Request, errorType int, partNumber int, resource string) {
+	auditErrorResponse(w, errorType)
+	error := getErrorCode(errorType)
+	errorResponse := getErrorResponse(error, resource)
+	errorResponse.PartNumber = partNumber
+	encodedErrorResponse := encodeErrorResponse(errorResponse)
+	writeXMLResponse(w, req, error.HTTPStatusCode, encodedErrorResponse)
 }
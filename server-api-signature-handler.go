@@ -58,9 +58,15 @@ func isRequestPostPolicySignatureV4(req *http.Request) bool {
 	return false
 }
 
+// deny - reject a request before it reaches the handler, the decision is audited
+func (s signatureHandler) deny(w http.ResponseWriter, r *http.Request, errorCode int) {
+	auditDenied(r, getAuditAccessKey(r), errorCode)
+	writeErrorResponse(w, r, errorCode, r.URL.Path)
+}
+
 func (s signatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 	if isRequestPostPolicySignatureV4(r) && r.Method == "POST" {
-		s.handler.ServeHTTP(w, r)
+		s.handler.ServeHTTP(newAuditWriter(w, r, auditPostPolicySignatureMatch), r)
 		return
 	}
 
@@ -76,30 +82,33 @@ func (s signatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 				switch err.ToGoError() {
 				case errInvalidRegion:
 					errorIf(err.Trace(), "Unknown region in authorization header.", nil)
-					writeErrorResponse(w, r, AuthorizationHeaderMalformed, r.URL.Path)
+					s.deny(w, r, AuthorizationHeaderMalformed)
 					return
 				case errAccessKeyIDInvalid:
 					errorIf(err.Trace(), "Invalid access key id.", nil)
-					writeErrorResponse(w, r, InvalidAccessKeyID, r.URL.Path)
+					s.deny(w, r, InvalidAccessKeyID)
 					return
 				default:
 					errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
-					writeErrorResponse(w, r, InternalError, r.URL.Path)
+					s.deny(w, r, InternalError)
 					return
 				}
 			}
 			ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sha256.Sum256([]byte(""))))
 			if err != nil {
 				errorIf(err.Trace(), "Unable to verify signature.", nil)
-				writeErrorResponse(w, r, InternalError, r.URL.Path)
+				s.deny(w, r, InternalError)
 				return
 			}
 			if !ok {
-				writeErrorResponse(w, r, SignatureDoesNotMatch, r.URL.Path)
+				s.deny(w, r, SignatureDoesNotMatch)
 				return
 			}
+			auditAllowed(r, signature.AccessKeyID, auditSignatureMatch)
+			s.handler.ServeHTTP(w, r)
+			return
 		}
-		s.handler.ServeHTTP(w, r)
+		s.handler.ServeHTTP(newAuditWriter(w, r, auditSignatureMatch), r)
 		return
 	}
 	if isRequestPresignedSignatureV4(r) {
@@ -109,26 +118,34 @@ func (s signatureHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
 			switch err.ToGoError() {
 			case errAccessKeyIDInvalid:
 				errorIf(err.Trace(), "Invalid access key id requested.", nil)
-				writeErrorResponse(w, r, InvalidAccessKeyID, r.URL.Path)
+				s.deny(w, r, InvalidAccessKeyID)
 				return
 			default:
 				errorIf(err.Trace(), "Initializing signature v4 failed.", nil)
-				writeErrorResponse(w, r, InternalError, r.URL.Path)
+				s.deny(w, r, InternalError)
 				return
 			}
 		}
 		ok, err := signature.DoesPresignedSignatureMatch()
 		if err != nil {
-			errorIf(err.Trace(), "Unable to verify signature.", nil)
-			writeErrorResponse(w, r, InternalError, r.URL.Path)
+			switch err.ToGoError().(type) {
+			case signv4.ExpiredPresignedRequest:
+				s.deny(w, r, ExpiredPresignRequest)
+			case signv4.MissingExpiresQuery, signv4.InvalidExpiresQuery:
+				s.deny(w, r, AuthorizationQueryParametersError)
+			default:
+				errorIf(err.Trace(), "Unable to verify signature.", nil)
+				s.deny(w, r, InternalError)
+			}
 			return
 		}
 		if !ok {
-			writeErrorResponse(w, r, SignatureDoesNotMatch, r.URL.Path)
+			s.deny(w, r, SignatureDoesNotMatch)
 			return
 		}
+		auditAllowed(r, signature.AccessKeyID, auditPresignedSignatureMatch)
 		s.handler.ServeHTTP(w, r)
 		return
 	}
-	writeErrorResponse(w, r, AccessDenied, r.URL.Path)
+	s.deny(w, r, AccessDenied)
 }
@@ -19,6 +19,7 @@ package main
 import (
 	"bytes"
 	"encoding/base64"
+	"encoding/hex"
 	"io"
 	"io/ioutil"
 	"mime/multipart"
@@ -106,21 +107,21 @@ func initSignatureV4(req *http.Request) (*signv4.Signature, *probe.Error) {
 	if err != nil {
 		return nil, err.Trace()
 	}
-	authConfig, err := LoadConfig()
+	users, err := getAuthUsers()
 	if err != nil {
 		return nil, err.Trace()
 	}
 	authFields := strings.Split(strings.TrimSpace(authHeaderValue), ",")
 	signedHeaders := strings.Split(strings.Split(strings.TrimSpace(authFields[1]), "=")[1], ";")
 	signature := strings.Split(strings.TrimSpace(authFields[2]), "=")[1]
-	for _, user := range authConfig.Users {
+	for _, user := range users {
 		if user.AccessKeyID == accessKeyID {
 			signature := &signv4.Signature{
 				AccessKeyID:     user.AccessKeyID,
 				SecretAccessKey: user.SecretAccessKey,
 				Signature:       signature,
 				SignedHeaders:   signedHeaders,
-				Request:         req,
+				Request:         getSignedRequest(req),
 			}
 			return signature, nil
 		}
@@ -128,79 +129,129 @@ func initSignatureV4(req *http.Request) (*signv4.Signature, *probe.Error) {
 	return nil, probe.NewError(errAccessKeyIDInvalid)
 }
 
+// doesBodySignatureMatch verify signature v4 of a request whose body is already read,
+// requests without an Authorization header are not verified
+func doesBodySignatureMatch(req *http.Request, body []byte) (bool, *probe.Error) {
+	if _, ok := req.Header["Authorization"]; !ok {
+		return true, nil
+	}
+	signature, err := initSignatureV4(req)
+	if err != nil {
+		return false, err.Trace()
+	}
+	ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sum256(body)))
+	if err != nil {
+		return false, err.Trace()
+	}
+	return ok, nil
+}
+
+// extractHTTPFormValues reads all the HTML form values up to the form field 'file', whose part
+// is returned unread. S3 requires every other field before 'file', any following it are ignored
 func extractHTTPFormValues(reader *multipart.Reader) (io.Reader, map[string]string, *probe.Error) {
 	/// HTML Form values
 	formValues := make(map[string]string)
-	filePart := new(bytes.Buffer)
-	var err error
-	for err == nil {
-		var part *multipart.Part
-		part, err = reader.NextPart()
-		if part != nil {
-			if part.FileName() == "" {
-				buffer, err := ioutil.ReadAll(part)
-				if err != nil {
-					return nil, nil, probe.NewError(err)
-				}
-				formValues[http.CanonicalHeaderKey(part.FormName())] = string(buffer)
-			} else {
-				_, err := io.Copy(filePart, part)
-				if err != nil {
-					return nil, nil, probe.NewError(err)
-				}
-			}
+	for {
+		part, err := reader.NextPart()
+		if err == io.EOF {
+			// no file was sent, the object is empty
+			return bytes.NewReader(nil), formValues, nil
+		}
+		if err != nil {
+			return nil, nil, probe.NewError(err)
+		}
+		if part.FormName() == "file" {
+			return part, formValues, nil
+		}
+		buffer, err := ioutil.ReadAll(part)
+		if err != nil {
+			return nil, nil, probe.NewError(err)
 		}
+		formValues[http.CanonicalHeaderKey(part.FormName())] = string(buffer)
 	}
-	return filePart, formValues, nil
 }
 
-func applyPolicy(formValues map[string]string) *probe.Error {
+// applyPolicy verifies the form values against the policy before the file is read, the file
+// is then read up to the maximum of its content-length-range, maxPostPolicyFileSize at most
+func applyPolicy(formValues map[string]string, file io.Reader) (io.Reader, int64, *probe.Error) {
 	if formValues["X-Amz-Algorithm"] != "AWS4-HMAC-SHA256" {
-		return probe.NewError(errUnsupportedAlgorithm)
+		return nil, 0, probe.NewError(errUnsupportedAlgorithm)
 	}
 	/// Decoding policy
 	policyBytes, err := base64.StdEncoding.DecodeString(formValues["Policy"])
 	if err != nil {
-		return probe.NewError(err)
+		return nil, 0, probe.NewError(err)
 	}
 	postPolicyForm, perr := signv4.ParsePostPolicyForm(string(policyBytes))
 	if perr != nil {
-		return perr.Trace()
+		return nil, 0, perr.Trace()
 	}
 	if !postPolicyForm.Expiration.After(time.Now().UTC()) {
-		return probe.NewError(errPolicyAlreadyExpired)
+		return nil, 0, probe.NewError(errPolicyAlreadyExpired)
 	}
 	if postPolicyForm.Conditions.Policies["$bucket"].Operator == "eq" {
 		if formValues["Bucket"] != postPolicyForm.Conditions.Policies["$bucket"].Value {
-			return probe.NewError(errPolicyMissingFields)
+			return nil, 0, probe.NewError(errPolicyMissingFields)
 		}
 	}
 	if postPolicyForm.Conditions.Policies["$x-amz-date"].Operator == "eq" {
 		if formValues["X-Amz-Date"] != postPolicyForm.Conditions.Policies["$x-amz-date"].Value {
-			return probe.NewError(errPolicyMissingFields)
+			return nil, 0, probe.NewError(errPolicyMissingFields)
 		}
 	}
 	if postPolicyForm.Conditions.Policies["$Content-Type"].Operator == "starts-with" {
 		if !strings.HasPrefix(formValues["Content-Type"], postPolicyForm.Conditions.Policies["$Content-Type"].Value) {
-			return probe.NewError(errPolicyMissingFields)
+			return nil, 0, probe.NewError(errPolicyMissingFields)
 		}
 	}
 	if postPolicyForm.Conditions.Policies["$Content-Type"].Operator == "eq" {
 		if formValues["Content-Type"] != postPolicyForm.Conditions.Policies["$Content-Type"].Value {
-			return probe.NewError(errPolicyMissingFields)
+			return nil, 0, probe.NewError(errPolicyMissingFields)
 		}
 	}
 	if postPolicyForm.Conditions.Policies["$key"].Operator == "starts-with" {
 		if !strings.HasPrefix(formValues["Key"], postPolicyForm.Conditions.Policies["$key"].Value) {
-			return probe.NewError(errPolicyMissingFields)
+			return nil, 0, probe.NewError(errPolicyMissingFields)
 		}
 	}
 	if postPolicyForm.Conditions.Policies["$key"].Operator == "eq" {
 		if formValues["Key"] != postPolicyForm.Conditions.Policies["$key"].Value {
-			return probe.NewError(errPolicyMissingFields)
+			return nil, 0, probe.NewError(errPolicyMissingFields)
 		}
 	}
-	return nil
+	if postPolicyForm.Conditions.Policies["$success_action_status"].Operator == "eq" {
+		if formValues[http.CanonicalHeaderKey("success_action_status")] != postPolicyForm.Conditions.Policies["$success_action_status"].Value {
+			return nil, 0, probe.NewError(errPolicyMissingFields)
+		}
+	}
+	if postPolicyForm.Conditions.Policies["$success_action_redirect"].Operator == "starts-with" {
+		if !strings.HasPrefix(formValues[http.CanonicalHeaderKey("success_action_redirect")], postPolicyForm.Conditions.Policies["$success_action_redirect"].Value) {
+			return nil, 0, probe.NewError(errPolicyMissingFields)
+		}
+	}
+	if postPolicyForm.Conditions.Policies["$success_action_redirect"].Operator == "eq" {
+		if formValues[http.CanonicalHeaderKey("success_action_redirect")] != postPolicyForm.Conditions.Policies["$success_action_redirect"].Value {
+			return nil, 0, probe.NewError(errPolicyMissingFields)
+		}
+	}
+	contentLengthRange := postPolicyForm.Conditions.ContentLengthRange
+	maxSize := int64(maxPostPolicyFileSize)
+	if contentLengthRange.Max > 0 && int64(contentLengthRange.Max) < maxSize {
+		maxSize = int64(contentLengthRange.Max)
+	}
+	// one byte more than allowed is read to tell files which are too large
+	fileBody := new(bytes.Buffer)
+	if _, err := io.Copy(fileBody, io.LimitReader(file, maxSize+1)); err != nil {
+		return nil, 0, probe.NewError(err)
+	}
+	fileSize := int64(fileBody.Len())
+	if fileSize > maxSize {
+		return nil, 0, probe.NewError(errPolicyEntityTooLarge)
+	}
+	if contentLengthRange.Min > 0 && fileSize < int64(contentLengthRange.Min) {
+		return nil, 0, probe.NewError(errPolicyEntityTooSmall)
+	}
+	return fileBody, fileSize, nil
 }
 
 // initPostPresignedPolicyV4 initializing post policy signature verification
@@ -213,11 +264,11 @@ func initPostPresignedPolicyV4(formValues map[string]string) (*signv4.Signature,
 	if !IsValidAccessKey(accessKeyID) {
 		return nil, probe.NewError(errAccessKeyIDInvalid)
 	}
-	authConfig, perr := LoadConfig()
+	users, perr := getAuthUsers()
 	if perr != nil {
 		return nil, perr.Trace()
 	}
-	for _, user := range authConfig.Users {
+	for _, user := range users {
 		if user.AccessKeyID == accessKeyID {
 			signature := &signv4.Signature{
 				AccessKeyID:     user.AccessKeyID,
@@ -241,13 +292,13 @@ func initPresignedSignatureV4(req *http.Request) (*signv4.Signature, *probe.Erro
 	if !IsValidAccessKey(accessKeyID) {
 		return nil, probe.NewError(errAccessKeyIDInvalid)
 	}
-	authConfig, err := LoadConfig()
+	users, err := getAuthUsers()
 	if err != nil {
 		return nil, err.Trace()
 	}
 	signedHeaders := strings.Split(strings.TrimSpace(req.URL.Query().Get("X-Amz-SignedHeaders")), ";")
 	signature := strings.TrimSpace(req.URL.Query().Get("X-Amz-Signature"))
-	for _, user := range authConfig.Users {
+	for _, user := range users {
 		if user.AccessKeyID == accessKeyID {
 			signature := &signv4.Signature{
 				AccessKeyID:     user.AccessKeyID,
@@ -255,7 +306,7 @@ func initPresignedSignatureV4(req *http.Request) (*signv4.Signature, *probe.Erro
 				Signature:       signature,
 				SignedHeaders:   signedHeaders,
 				Presigned:       true,
-				Request:         req,
+				Request:         getSignedRequest(req),
 			}
 			return signature, nil
 		}
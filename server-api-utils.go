@@ -18,8 +18,14 @@ package main
 
 import (
 	"encoding/base64"
+	"net/http"
+	"net/url"
 	"strconv"
 	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/minhttp"
+	"github.com/minio/minio-xl/pkg/xl"
 )
 
 // isValidMD5 - verify if valid md5
@@ -38,6 +44,8 @@ func isValidMD5(md5 string) bool {
 const (
 	// maximum object size per PUT request is 5GB
 	maxObjectSize = 1024 * 1024 * 1024 * 5
+	// maximum file size per browser POST upload is 64MB, the file is held in memory
+	maxPostPolicyFileSize = 1024 * 1024 * 64
 	// mimimum object size per Multipart PUT request is 5MB
 	minMultiPartObjectSize = 1024 * 1024 * 5
 	// minimum object size per PUT request is 1B
@@ -79,3 +87,100 @@ func isMinMultipartObjectSize(size string) bool {
 	}
 	return false
 }
+
+// parseModTime - parse object modification time requested through 'x-amz-meta-mtime', accepts
+// unix time in seconds with optional fraction (as set by rclone, s3cmd), RFC3339 and HTTP date formats
+func parseModTime(mtime string) (time.Time, bool) {
+	mtime = strings.TrimSpace(mtime)
+	if seconds, err := strconv.ParseFloat(mtime, 64); err == nil {
+		sec := int64(seconds)
+		nsec := int64((seconds - float64(sec)) * 1e9)
+		return time.Unix(sec, nsec).UTC(), true
+	}
+	if t, err := time.Parse(time.RFC3339Nano, mtime); err == nil {
+		return t.UTC(), true
+	}
+	if t, err := time.Parse(http.TimeFormat, mtime); err == nil {
+		return t.UTC(), true
+	}
+	return time.Time{}, false
+}
+
+// getUserMetadata - x-amz-meta-* headers of a request by their keys as sent by the client, keys
+// differing only in case are joined like repeated headers. 'x-amz-meta-mtime' is not user metadata.
+func getUserMetadata(req *http.Request) map[string]string {
+	headerCase := minhttp.GetHeaderCase(req)
+	userMetadata := make(map[string]string)
+	for key, values := range req.Header {
+		if !xl.IsUserMetadata(key) || http.CanonicalHeaderKey(key) == "X-Amz-Meta-Mtime" {
+			continue
+		}
+		if sentKey, ok := headerCase[http.CanonicalHeaderKey(key)]; ok {
+			key = sentKey
+		}
+		userMetadata[key] = strings.Join(values, ",")
+	}
+	return userMetadata
+}
+
+// checksumHeaders - headers carrying the additional checksum of an object for each supported algorithm
+var checksumHeaders = map[string]string{
+	xl.ChecksumCRC32C: "X-Amz-Checksum-Crc32c",
+	xl.ChecksumSHA256: "X-Amz-Checksum-Sha256",
+}
+
+// getChecksum - additional checksum requested through 'x-amz-checksum-algorithm' and expected
+// value through 'x-amz-checksum-<algorithm>', algorithm is empty if no checksum is requested
+func getChecksum(header http.Header) (algorithm, checksum string, ok bool) {
+	algorithm = strings.ToUpper(strings.TrimSpace(header.Get("X-Amz-Checksum-Algorithm")))
+	for checksumAlgorithm, checksumHeader := range checksumHeaders {
+		value := strings.TrimSpace(header.Get(checksumHeader))
+		if value == "" {
+			continue
+		}
+		// only one checksum per request, matching the algorithm if it is specified
+		if checksum != "" || (algorithm != "" && algorithm != checksumAlgorithm) {
+			return "", "", false
+		}
+		algorithm, checksum = checksumAlgorithm, value
+	}
+	if algorithm == "" {
+		return "", "", true
+	}
+	return algorithm, checksum, xl.IsValidChecksum(algorithm, checksum)
+}
+
+// getChecksumTrailer - algorithm of the checksum trailer requested by a GET with
+// 'x-amz-checksum-mode: ENABLED', the algorithm of the stored checksum of the object if any,
+// otherwise 'x-amz-checksum-algorithm' or CRC32C. Trailers cover the whole object and need a
+// chunked body, ranges and HTTP/1.0 clients never get one.
+func getChecksumTrailer(req *http.Request, metadata xl.ObjectMetadata) (string, bool) {
+	if !strings.EqualFold(strings.TrimSpace(req.Header.Get("X-Amz-Checksum-Mode")), "ENABLED") {
+		return "", false
+	}
+	if req.Header.Get("Range") != "" || !req.ProtoAtLeast(1, 1) {
+		return "", false
+	}
+	if algorithm, ok := metadata.Metadata["checksumAlgorithm"]; ok {
+		return algorithm, true
+	}
+	algorithm := strings.ToUpper(strings.TrimSpace(req.Header.Get("X-Amz-Checksum-Algorithm")))
+	if algorithm == "" {
+		return xl.ChecksumCRC32C, true
+	}
+	return algorithm, xl.IsValidChecksum(algorithm, "")
+}
+
+// getMoveSource - bucket and object from 'x-minio-move-source' of the form '/bucket/object',
+// object may be url encoded
+func getMoveSource(moveSource string) (bucket, object string, ok bool) {
+	moveSource, err := url.QueryUnescape(moveSource)
+	if err != nil {
+		return "", "", false
+	}
+	splits := strings.SplitN(strings.TrimPrefix(moveSource, "/"), "/", 2)
+	if len(splits) != 2 || splits[0] == "" || splits[1] == "" {
+		return "", "", false
+	}
+	return splits[0], splits[1], true
+}
@@ -0,0 +1,229 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// globalAuditLogger is set by startServer when --audit-log is given, nil disables auditing
+var globalAuditLogger *auditLogger
+
+// reasons requests are allowed for, denied requests carry the code of the error returned instead
+const (
+	auditSignatureMatch           = "SignatureMatch"
+	auditPresignedSignatureMatch  = "PresignedSignatureMatch"
+	auditPostPolicySignatureMatch = "PostPolicySignatureMatch"
+	auditAnonymous                = "Anonymous"
+)
+
+// maxAuditAccessKeyLength - access keys are at most 20 characters, longer claims are truncated
+const maxAuditAccessKeyLength = 128
+
+// auditEntry - an authentication decision
+type auditEntry struct {
+	Time       time.Time `json:"time"`
+	RemoteAddr string    `json:"remoteAddr"`
+	AccessKey  string    `json:"accessKey,omitempty"`
+	Method     string    `json:"method"`
+	Bucket     string    `json:"bucket,omitempty"`
+	Object     string    `json:"object,omitempty"`
+	Allowed    bool      `json:"allowed"`
+	Reason     string    `json:"reason"`
+	Message    string    `json:"message,omitempty"`
+}
+
+// auditLogger - appends json encoded entries to the audit log, one per line
+type auditLogger struct {
+	lock    *sync.Mutex
+	file    *os.File
+	encoder *json.Encoder
+}
+
+// newAuditLogger - open the audit log for appending, it is readable only by the server user
+func newAuditLogger(path string) (*auditLogger, *probe.Error) {
+	file, err := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0600)
+	if err != nil {
+		return nil, probe.NewError(err)
+	}
+	return &auditLogger{
+		lock:    &sync.Mutex{},
+		file:    file,
+		encoder: json.NewEncoder(file),
+	}, nil
+}
+
+// log - write an entry, nothing is written if auditing is disabled
+func (l *auditLogger) log(entry auditEntry) {
+	if l == nil {
+		return
+	}
+	l.lock.Lock()
+	defer l.lock.Unlock()
+	errorIf(probe.NewError(l.encoder.Encode(entry)), "Unable to write audit log.", nil)
+}
+
+// getAuditAccessKey - access key a request claims to be signed with, even if it is unknown
+func getAuditAccessKey(r *http.Request) string {
+	credential := r.URL.Query().Get("X-Amz-Credential")
+	if auth := r.Header.Get("Authorization"); auth != "" {
+		if i := strings.Index(auth, "Credential="); i >= 0 {
+			credential = auth[i+len("Credential="):]
+		}
+	}
+	if i := strings.IndexAny(credential, "/,"); i >= 0 {
+		credential = credential[:i]
+	}
+	accessKey := strings.TrimSpace(credential)
+	if len(accessKey) > maxAuditAccessKeyLength {
+		accessKey = accessKey[:maxAuditAccessKeyLength]
+	}
+	return accessKey
+}
+
+// newAuditEntry - entry for a request, bucket and object are taken from the path
+func newAuditEntry(r *http.Request, accessKey string) auditEntry {
+	entry := auditEntry{
+		Time:       time.Now().UTC(),
+		RemoteAddr: r.RemoteAddr,
+		AccessKey:  accessKey,
+		Method:     r.Method,
+	}
+	resource := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	entry.Bucket = resource[0]
+	if len(resource) > 1 {
+		entry.Object = resource[1]
+	}
+	return entry
+}
+
+// auditAllowed - record a request allowed for reason
+func auditAllowed(r *http.Request, accessKey, reason string) {
+	if globalAuditLogger == nil {
+		return
+	}
+	entry := newAuditEntry(r, accessKey)
+	entry.Allowed = true
+	entry.Reason = reason
+	globalAuditLogger.log(entry)
+}
+
+// auditDenied - record a request denied with errorCode
+func auditDenied(r *http.Request, accessKey string, errorCode int) {
+	if globalAuditLogger == nil {
+		return
+	}
+	apiError := getErrorCode(errorCode)
+	entry := newAuditEntry(r, accessKey)
+	entry.Reason = apiError.Code
+	entry.Message = apiError.Description
+	globalAuditLogger.log(entry)
+}
+
+// isAuthErrorCode - errors returned when verifying a request signature
+func isAuthErrorCode(errorCode int) bool {
+	switch errorCode {
+	case SignatureDoesNotMatch, InvalidAccessKeyID, AuthorizationHeaderMalformed,
+		AuthorizationQueryParametersError, ExpiredPresignRequest, RequestTimeTooSkewed:
+		return true
+	}
+	return false
+}
+
+// auditWriter - records the decision on requests whose signature is verified along with
+// their body, by the handler. Requests failing for other reasons before their signature
+// is verified are not recorded since no decision was made.
+type auditWriter struct {
+	http.ResponseWriter
+	req       *http.Request
+	accessKey string
+	reason    string
+	decided   bool
+}
+
+// newAuditWriter - writer recording the decision on req once the handler responds
+func newAuditWriter(w http.ResponseWriter, req *http.Request, reason string) http.ResponseWriter {
+	if globalAuditLogger == nil {
+		return w
+	}
+	return &auditWriter{ResponseWriter: w, req: req, accessKey: getAuditAccessKey(req), reason: reason}
+}
+
+func (a *auditWriter) WriteHeader(status int) {
+	if !a.decided {
+		a.decided = true
+		if status < http.StatusBadRequest {
+			auditAllowed(a.req, a.accessKey, a.reason)
+		}
+	}
+	a.ResponseWriter.WriteHeader(status)
+}
+
+func (a *auditWriter) Write(p []byte) (int, error) {
+	if !a.decided {
+		a.WriteHeader(http.StatusOK)
+	}
+	return a.ResponseWriter.Write(p)
+}
+
+func (a *auditWriter) Flush() {
+	flushResponse(a.ResponseWriter)
+}
+
+// auditErrorResponse - deny a deferred decision when the handler fails signature verification
+func auditErrorResponse(w http.ResponseWriter, errorCode int) {
+	if isAuthErrorCode(errorCode) {
+		auditDeferredDenied(w, errorCode)
+	}
+}
+
+// auditDeferredDenied - deny a deferred decision for a reason only the handler knows of,
+// such as an upload violating its POST policy
+func auditDeferredDenied(w http.ResponseWriter, errorCode int) {
+	if a, ok := w.(*auditWriter); ok && !a.decided {
+		a.decided = true
+		auditDenied(a.req, a.accessKey, errorCode)
+	}
+}
+
+// setAuditAccessKey - access key known only to the handler, such as of browser POST uploads
+func setAuditAccessKey(w http.ResponseWriter, accessKey string) {
+	if a, ok := w.(*auditWriter); ok {
+		a.accessKey = accessKey
+	}
+}
+
+type anonymousAuditHandler struct {
+	handler http.Handler
+}
+
+// AnonymousAuditHandler - record requests to a server running without authentication
+func AnonymousAuditHandler(h http.Handler) http.Handler {
+	return anonymousAuditHandler{h}
+}
+
+func (a anonymousAuditHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	auditAllowed(r, "", auditAnonymous)
+	a.handler.ServeHTTP(w, r)
+}
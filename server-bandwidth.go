@@ -0,0 +1,178 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io"
+	"net/http"
+	"sync"
+	"time"
+)
+
+// bandwidthChunkSize is the most a request body read or a response write moves before it
+// waits for the limiter, large writes are spread out rather than sent in bursts
+const bandwidthChunkSize = 32 * 1024
+
+// bandwidthLimiter is a token bucket of bytes refilled at rate bytes per second, holding at
+// most a tenth of a second worth of them
+type bandwidthLimiter struct {
+	mutex  *sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// newBandwidthLimiter - new limiter, '0' rate means unlimited
+func newBandwidthLimiter(rate int64) *bandwidthLimiter {
+	burst := float64(rate) / 10
+	if burst < bandwidthChunkSize {
+		burst = bandwidthChunkSize
+	}
+	return &bandwidthLimiter{
+		mutex:  &sync.Mutex{},
+		rate:   float64(rate),
+		burst:  burst,
+		tokens: burst,
+		last:   time.Now(),
+	}
+}
+
+// isLimited - rate is not unlimited
+func (l *bandwidthLimiter) isLimited() bool {
+	return l.rate > 0
+}
+
+// wait - take n bytes, sleeps until the bucket refilled what is missing. Tokens are taken
+// right away, so concurrent requests queue up behind each other instead of racing.
+func (l *bandwidthLimiter) wait(n int) {
+	if !l.isLimited() || n <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	now := time.Now()
+	l.tokens += now.Sub(l.last).Seconds() * l.rate
+	if l.tokens > l.burst {
+		l.tokens = l.burst
+	}
+	l.last = now
+	l.tokens -= float64(n)
+	missing := -l.tokens
+	l.mutex.Unlock()
+	if missing > 0 {
+		time.Sleep(time.Duration(missing / l.rate * float64(time.Second)))
+	}
+}
+
+// bandwidthLimits - aggregate limit shared by uploads and downloads, and one for each direction
+type bandwidthLimits struct {
+	total   *bandwidthLimiter
+	ingress *bandwidthLimiter
+	egress  *bandwidthLimiter
+}
+
+// globalBandwidthLimits is set by startServer with --max-bandwidth, --max-ingress-bandwidth and
+// --max-egress-bandwidth, unlimited by default
+var globalBandwidthLimits = newBandwidthLimits(0, 0, 0)
+
+// newBandwidthLimits - new limits in bytes per second, '0' means unlimited
+func newBandwidthLimits(total, ingress, egress int64) bandwidthLimits {
+	return bandwidthLimits{
+		total:   newBandwidthLimiter(total),
+		ingress: newBandwidthLimiter(ingress),
+		egress:  newBandwidthLimiter(egress),
+	}
+}
+
+// getLimiters - limiters in effect for one direction
+func (b bandwidthLimits) getLimiters(direction *bandwidthLimiter) []*bandwidthLimiter {
+	var limiters []*bandwidthLimiter
+	for _, limiter := range []*bandwidthLimiter{b.total, direction} {
+		if limiter.isLimited() {
+			limiters = append(limiters, limiter)
+		}
+	}
+	return limiters
+}
+
+// throttledReader - request body read no faster than its limiters allow
+type throttledReader struct {
+	io.ReadCloser
+	limiters []*bandwidthLimiter
+}
+
+func (r *throttledReader) Read(p []byte) (int, error) {
+	if len(p) > bandwidthChunkSize {
+		p = p[:bandwidthChunkSize]
+	}
+	n, err := r.ReadCloser.Read(p)
+	for _, limiter := range r.limiters {
+		limiter.wait(n)
+	}
+	return n, err
+}
+
+// throttledWriter - response written no faster than its limiters allow
+type throttledWriter struct {
+	http.ResponseWriter
+	limiters []*bandwidthLimiter
+}
+
+func (w *throttledWriter) Write(p []byte) (int, error) {
+	var written int
+	for len(p) > 0 {
+		chunk := p
+		if len(chunk) > bandwidthChunkSize {
+			chunk = chunk[:bandwidthChunkSize]
+		}
+		for _, limiter := range w.limiters {
+			limiter.wait(len(chunk))
+		}
+		n, err := w.ResponseWriter.Write(chunk)
+		written += n
+		if err != nil {
+			return written, err
+		}
+		p = p[n:]
+	}
+	return written, nil
+}
+
+func (w *throttledWriter) Flush() {
+	flushResponse(w.ResponseWriter)
+}
+
+type bandwidthLimitHandler struct {
+	handler http.Handler
+	limits  bandwidthLimits
+}
+
+// BandwidthLimitHandler slows down request bodies and responses to stay under --max-bandwidth,
+// --max-ingress-bandwidth and --max-egress-bandwidth, requests are never rejected
+func BandwidthLimitHandler(h http.Handler) http.Handler {
+	return bandwidthLimitHandler{h, globalBandwidthLimits}
+}
+
+func (h bandwidthLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if limiters := h.limits.getLimiters(h.limits.ingress); len(limiters) > 0 && r.Body != nil {
+		r.Body = &throttledReader{ReadCloser: r.Body, limiters: limiters}
+	}
+	if limiters := h.limits.getLimiters(h.limits.egress); len(limiters) > 0 {
+		w = &throttledWriter{ResponseWriter: w, limiters: limiters}
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -0,0 +1,150 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"compress/gzip"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// globalCompress is set by startServer when --compress is given
+var globalCompress bool
+
+// globalCompressXML is cleared by startServer when --disable-xml-compression is given
+var globalCompressXML = true
+
+// compressibleTypes - content types, or prefixes of, served gzip compressed to clients accepting it
+var compressibleTypes = []string{
+	"text/",
+	"application/json",
+	"application/xml",
+	"application/javascript",
+	"image/svg+xml",
+}
+
+// isCompressible - object is served compressed to clients accepting gzip
+func isCompressible(metadata xl.ObjectMetadata) bool {
+	if !globalCompress || metadata.Size == 0 {
+		return false
+	}
+	contentType := strings.ToLower(metadata.Metadata["contentType"])
+	if i := strings.Index(contentType, ";"); i >= 0 {
+		contentType = contentType[:i]
+	}
+	contentType = strings.TrimSpace(contentType)
+	for _, compressibleType := range compressibleTypes {
+		if strings.HasSuffix(compressibleType, "/") && strings.HasPrefix(contentType, compressibleType) {
+			return true
+		}
+		if contentType == compressibleType {
+			return true
+		}
+	}
+	return false
+}
+
+// acceptsGzip - Accept-Encoding of the request allows gzip, codings with ‘q=0’ are refused
+func acceptsGzip(req *http.Request) bool {
+	accepted := false
+	for _, coding := range strings.Split(req.Header.Get("Accept-Encoding"), ",") {
+		params := strings.Split(coding, ";")
+		name := strings.ToLower(strings.TrimSpace(params[0]))
+		if name != "gzip" && name != "*" {
+			continue
+		}
+		quality := 1.0
+		for _, param := range params[1:] {
+			param = strings.TrimSpace(param)
+			if strings.HasPrefix(param, "q=") {
+				if q, err := strconv.ParseFloat(param[len("q="):], 64); err == nil {
+					quality = q
+				}
+			}
+		}
+		// an explicit gzip preference overrides the wildcard
+		if name == "gzip" {
+			return quality > 0
+		}
+		accepted = quality > 0
+	}
+	return accepted
+}
+
+// isCompressedResponse - object is served compressed in response to req, ranges address the
+// stored bytes and are always served uncompressed. Compressed bodies have no known length, HTTP/1.0
+// clients get the stored bytes with a Content-Length rather than a body ended by closing the connection.
+func isCompressedResponse(req *http.Request, metadata xl.ObjectMetadata) bool {
+	return isCompressible(metadata) && acceptsGzip(req) && req.Header.Get("Range") == "" && req.ProtoAtLeast(1, 1)
+}
+
+// setVaryHeader - the encoding of compressible objects depends on the request, caches must
+// never serve a compressed body to a client which did not ask for one
+func setVaryHeader(w http.ResponseWriter, metadata xl.ObjectMetadata) {
+	if isCompressible(metadata) {
+		w.Header().Add("Vary", "Accept-Encoding")
+	}
+}
+
+// setCompressedHeaders - compressed bodies are not the stored bytes, they have a weak ETag
+// and no known length
+func setCompressedHeaders(w http.ResponseWriter, metadata xl.ObjectMetadata) {
+	w.Header().Set("Content-Encoding", "gzip")
+	w.Header().Set("ETag", getObjectETag(metadata, true).String())
+	w.Header().Del("Content-Length")
+	w.Header().Del("Accept-Ranges")
+}
+
+// getCompressedObject - write object gzip compressed
+func getCompressedObject(storage xl.Interface, w http.ResponseWriter, bucket, object string) *probe.Error {
+	writer := gzip.NewWriter(w)
+	if _, err := storage.GetObject(writer, bucket, object, 0, 0); err != nil {
+		return err.Trace()
+	}
+	if err := writer.Close(); err != nil {
+		return probe.NewError(err)
+	}
+	return nil
+}
+
+// writeXMLResponse - write headers and an XML body of listings, ACLs or errors, gzip compressed
+// to clients accepting it. Bodies are small enough to be compressed up front, compressed
+// responses keep a Content-Length. HEAD requests get the headers of the uncompressed body.
+func writeXMLResponse(w http.ResponseWriter, req *http.Request, status int, body []byte) {
+	if globalCompressXML {
+		w.Header().Add("Vary", "Accept-Encoding")
+		if req.Method != "HEAD" && acceptsGzip(req) {
+			var buffer bytes.Buffer
+			writer := gzip.NewWriter(&buffer)
+			writer.Write(body)
+			writer.Close()
+			body = buffer.Bytes()
+			w.Header().Set("Content-Encoding", "gzip")
+		}
+	}
+	setCommonHeaders(w, len(body))
+	w.WriteHeader(status)
+	// HEAD should have no body, do not attempt to write to it
+	if req.Method != "HEAD" {
+		w.Write(body)
+	}
+}
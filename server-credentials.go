@@ -0,0 +1,139 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"os"
+	"os/exec"
+	"os/signal"
+	"strings"
+	"sync"
+	"syscall"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// credentialsCmdOutput - json expected on stdout of --credentials-cmd
+type credentialsCmdOutput struct {
+	AccessKey string `json:"accessKey"`
+	SecretKey string `json:"secretKey"`
+}
+
+// credentialsProvider caches credentials returned by --credentials-cmd
+type credentialsProvider struct {
+	cmd  string
+	lock *sync.RWMutex
+	user *AuthUser
+}
+
+// globalCredentialsProvider is set only when server is started with --credentials-cmd
+var globalCredentialsProvider *credentialsProvider
+
+// runCredentialsCmd runs the command and parses credentials from its output, command
+// is run directly without a shell, arguments are separated by white space
+func runCredentialsCmd(cmd string) (*AuthUser, *probe.Error) {
+	args := strings.Fields(cmd)
+	if len(args) == 0 {
+		return nil, probe.NewError(errInvalidCredentialsCmd)
+	}
+	var stdout bytes.Buffer
+	command := exec.Command(args[0], args[1:]...)
+	command.Stdout = &stdout
+	command.Stderr = os.Stderr
+	if err := command.Run(); err != nil {
+		return nil, probe.NewError(err).Trace(args[0])
+	}
+	output := credentialsCmdOutput{}
+	if err := json.Unmarshal(stdout.Bytes(), &output); err != nil {
+		return nil, probe.NewError(errMalformedCredentials).Trace(args[0])
+	}
+	if output.AccessKey == "" || output.SecretKey == "" {
+		return nil, probe.NewError(errMalformedCredentials).Trace(args[0])
+	}
+	if !IsValidAccessKey(output.AccessKey) || !IsValidSecretKey(output.SecretKey) {
+		return nil, probe.NewError(errMalformedCredentials).Trace(args[0])
+	}
+	return &AuthUser{
+		Name:            "admin",
+		AccessKeyID:     output.AccessKey,
+		SecretAccessKey: output.SecretKey,
+	}, nil
+}
+
+// newCredentialsProvider runs the command once, fails if no valid credentials are returned
+func newCredentialsProvider(cmd string) (*credentialsProvider, *probe.Error) {
+	p := &credentialsProvider{
+		cmd:  cmd,
+		lock: &sync.RWMutex{},
+	}
+	if err := p.Reload(); err != nil {
+		return nil, err.Trace()
+	}
+	return p, nil
+}
+
+// Reload re-runs the command, on failure cached credentials are dropped so that
+// all signed requests are rejected until a subsequent reload succeeds
+func (p *credentialsProvider) Reload() *probe.Error {
+	user, err := runCredentialsCmd(p.cmd)
+	p.lock.Lock()
+	defer p.lock.Unlock()
+	p.user = user
+	if err != nil {
+		return err.Trace()
+	}
+	return nil
+}
+
+// Get returns cached credentials
+func (p *credentialsProvider) Get() (*AuthUser, *probe.Error) {
+	p.lock.RLock()
+	defer p.lock.RUnlock()
+	if p.user == nil {
+		return nil, probe.NewError(errCredentialsUnavailable)
+	}
+	return p.user, nil
+}
+
+// reloadOnSIGHUP reloads credentials on every SIGHUP, this keeps the running process
+// in sync with the process started by the graceful restart which SIGHUP also triggers
+func (p *credentialsProvider) reloadOnSIGHUP() {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, syscall.SIGHUP)
+	for range ch {
+		err := p.Reload()
+		errorIf(err.Trace(), "Unable to reload credentials, all signed requests will be rejected.", nil)
+	}
+}
+
+// getAuthUsers returns users allowed to sign requests, from --credentials-cmd if set otherwise from users config
+func getAuthUsers() (map[string]*AuthUser, *probe.Error) {
+	if globalCredentialsProvider != nil {
+		user, err := globalCredentialsProvider.Get()
+		if err != nil {
+			return nil, err.Trace()
+		}
+		return map[string]*AuthUser{user.Name: user}, nil
+	}
+	authConfig, err := LoadConfig()
+	if err != nil {
+		return nil, err.Trace()
+	}
+	return authConfig.Users, nil
+}
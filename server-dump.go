@@ -0,0 +1,115 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"os/signal"
+	"path/filepath"
+	"runtime"
+	"runtime/pprof"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// dumpTimeFormat - dumps are named after the time they were taken, sorting by name sorts by time
+const dumpTimeFormat = "20060102T150405.000000000Z"
+
+// parseDumpSignal - signal triggering dumps by name, e.g. SIGQUIT
+func parseDumpSignal(name string) (os.Signal, bool) {
+	sig, ok := dumpSignals[strings.ToUpper(name)]
+	return sig, ok
+}
+
+// dumpMessage - files a dump was written to
+type dumpMessage struct {
+	Goroutines string `json:"goroutines"`
+	Heap       string `json:"heap,omitempty"`
+}
+
+func (m dumpMessage) String() string {
+	if m.Heap == "" {
+		return fmt.Sprintf("Wrote goroutine dump to ‘%s’.", m.Goroutines)
+	}
+	return fmt.Sprintf("Wrote goroutine dump to ‘%s’, heap profile to ‘%s’.", m.Goroutines, m.Heap)
+}
+
+// JSON - json formatted output
+func (m dumpMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// writeDumpFile - new file at path holding what write writes, removed if writing fails
+func writeDumpFile(path string, write func(io.Writer) error) *probe.Error {
+	file, e := os.OpenFile(path, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0600)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if e := write(file); e != nil {
+		file.Close()
+		os.Remove(path)
+		return probe.NewError(e)
+	}
+	if e := file.Close(); e != nil {
+		os.Remove(path)
+		return probe.NewError(e)
+	}
+	return nil
+}
+
+// writeDump - stack traces of all goroutines, and a heap profile if asked for, under dir
+func writeDump(dir string, heap bool, now time.Time) (dumpMessage, *probe.Error) {
+	if e := os.MkdirAll(dir, 0700); e != nil {
+		return dumpMessage{}, probe.NewError(e)
+	}
+	stamp := now.UTC().Format(dumpTimeFormat)
+	message := dumpMessage{Goroutines: filepath.Join(dir, "goroutines-"+stamp+".txt")}
+	err := writeDumpFile(message.Goroutines, func(w io.Writer) error {
+		return pprof.Lookup("goroutine").WriteTo(w, 2)
+	})
+	if err != nil {
+		return dumpMessage{}, err.Trace(message.Goroutines)
+	}
+	if heap {
+		message.Heap = filepath.Join(dir, "heap-"+stamp+".pprof")
+		// profile reflects the heap as of the last collection, make that now
+		runtime.GC()
+		if err := writeDumpFile(message.Heap, pprof.WriteHeapProfile); err != nil {
+			return dumpMessage{}, err.Trace(message.Heap)
+		}
+	}
+	return message, nil
+}
+
+// dumpOnSignal - write a dump under dir on every sig for as long as the server runs. Unlike Go's
+// own SIGQUIT handler, which dumps to stderr, the process is not terminated
+func dumpOnSignal(sig os.Signal, dir string, heap bool) {
+	ch := make(chan os.Signal, 1)
+	signal.Notify(ch, sig)
+	for range ch {
+		message, err := writeDump(dir, heap, time.Now())
+		if err != nil {
+			errorIf(err.Trace(dir), "Unable to write dump.", nil)
+			continue
+		}
+		printMessage(message)
+	}
+}
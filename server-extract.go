@@ -0,0 +1,260 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"archive/tar"
+	"bytes"
+	"compress/gzip"
+	"crypto/md5"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"io"
+	"io/ioutil"
+	"mime"
+	"net/http"
+	"path"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/probe"
+	signv4 "github.com/minio/minio-xl/pkg/signature"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// maxExtractEntries - most objects created from a single archive
+const maxExtractEntries = 10000
+
+// extractContentTypes - archives which can be extracted on upload, true for gzipped ones
+var extractContentTypes = map[string]bool{
+	"application/x-tar":            false,
+	"application/tar":              false,
+	"application/gzip":             true,
+	"application/x-gzip":           true,
+	"application/x-gtar":           true,
+	"application/x-compressed-tar": true,
+}
+
+var (
+	errInvalidArchive         = errors.New("Archive is not a valid tar or gzipped tar archive")
+	errInvalidArchiveEntry    = errors.New("Archive entry name is not a valid object name")
+	errTooManyArchiveEntries  = errors.New("Archive has too many entries")
+	errIncompleteArchiveBody  = errors.New("Archive is shorter than its Content-Length")
+	errArchiveDigestMismatch  = errors.New("Archive does not match its Content-MD5")
+	errArchiveSignatureFailed = errors.New("Archive does not match its signature")
+)
+
+// isExtractRequest - upload asks for its archive to be extracted with 'x-minio-extract: true'
+func isExtractRequest(req *http.Request) bool {
+	return strings.EqualFold(strings.TrimSpace(req.Header.Get("X-Minio-Extract")), "true")
+}
+
+// getExtractFormat - whether an archive of contentType is gzipped, false if it cannot be extracted
+func getExtractFormat(contentType string) (gzipped bool, ok bool) {
+	mediaType, _, e := mime.ParseMediaType(contentType)
+	if e != nil {
+		return false, false
+	}
+	gzipped, ok = extractContentTypes[strings.ToLower(mediaType)]
+	return gzipped, ok
+}
+
+// getExtractObjectName - object of an archive entry under prefix, entries leaving the
+// prefix with '..' are not valid
+func getExtractObjectName(prefix, name string) (string, bool) {
+	name = strings.TrimLeft(strings.TrimPrefix(name, "./"), "/")
+	for _, segment := range strings.Split(name, "/") {
+		if segment == ".." {
+			return "", false
+		}
+	}
+	object := prefix + path.Clean(name)
+	if name == "" || !xl.IsValidObjectName(object) {
+		return "", false
+	}
+	if _, ok := globalKeyLimits.check(object); ok {
+		return "", false
+	}
+	return object, true
+}
+
+// extractArchive - create an object under prefix for every regular file of archive, other
+// entries such as directories and links are skipped. Objects already created are removed if
+// the archive turns out invalid
+func extractArchive(storage xl.Interface, bucket, prefix string, archive io.Reader, gzipped bool) ([]xl.ObjectMetadata, *probe.Error) {
+	var created []xl.ObjectMetadata
+	fail := func(err *probe.Error) ([]xl.ObjectMetadata, *probe.Error) {
+		removeExtracted(storage, created)
+		return nil, err
+	}
+	if gzipped {
+		gzipReader, e := gzip.NewReader(archive)
+		if e != nil {
+			return fail(probe.NewError(errInvalidArchive))
+		}
+		defer gzipReader.Close()
+		archive = gzipReader
+	}
+	tarReader := tar.NewReader(archive)
+	for {
+		header, e := tarReader.Next()
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return fail(probe.NewError(errInvalidArchive))
+		}
+		if header.Typeflag != tar.TypeReg && header.Typeflag != tar.TypeRegA {
+			continue
+		}
+		object, ok := getExtractObjectName(prefix, header.Name)
+		if !ok {
+			return fail(probe.NewError(errInvalidArchiveEntry).Trace(header.Name))
+		}
+		if len(created) == maxExtractEntries {
+			return fail(probe.NewError(errTooManyArchiveEntries))
+		}
+		metadata := map[string]string{"contentType": mime.TypeByExtension(path.Ext(object))}
+		objectMetadata, err := storage.CreateObject(bucket, object, "", header.Size, tarReader, metadata, nil)
+		if err != nil {
+			if _, ok := err.ToGoError().(xl.IncompleteBody); ok {
+				return fail(probe.NewError(errInvalidArchive).Trace(header.Name))
+			}
+			return fail(err.Trace(header.Name))
+		}
+		created = append(created, objectMetadata)
+	}
+	return created, nil
+}
+
+// removeExtracted - remove objects of an archive which failed, failures are only logged
+func removeExtracted(storage xl.Interface, created []xl.ObjectMetadata) {
+	for _, metadata := range created {
+		if err := storage.DeleteObject(metadata.Bucket, metadata.Object, metadata.MD5Sum); err != nil {
+			errorIf(err.Trace(metadata.Bucket, metadata.Object), "Unable to remove object of an invalid archive.", nil)
+		}
+	}
+}
+
+// extractObject - extract the uploaded archive into objects under object as prefix. Archive
+// is verified against Content-MD5 and the signature once it is read in full, its objects are
+// removed if it does not match
+func (api API) extractObject(w http.ResponseWriter, req *http.Request, bucket, object, md5Base64 string, size int64, signature *signv4.Signature) {
+	gzipped, ok := getExtractFormat(req.Header.Get("Content-Type"))
+	if !ok {
+		writeErrorResponse(w, req, InvalidRequest, req.URL.Path)
+		return
+	}
+	prefix := object
+	if !strings.HasSuffix(prefix, "/") {
+		prefix = prefix + "/"
+	}
+
+	md5Hash := md5.New()
+	sha256Hash := sha256.New()
+	body := &countingReader{reader: io.TeeReader(io.LimitReader(req.Body, size), io.MultiWriter(md5Hash, sha256Hash))}
+	storage := api.getStorage(req)
+	created, err := extractArchive(storage, bucket, prefix, body, gzipped)
+	if err == nil {
+		// padding after the end of the archive is part of the payload
+		if _, e := io.Copy(ioutil.Discard, body); e != nil {
+			err = probe.NewError(e)
+		}
+	}
+	if err == nil {
+		err = verifyArchive(body.n, size, md5Base64, md5Hash.Sum(nil), hex.EncodeToString(sha256Hash.Sum(nil)), signature)
+	}
+	if err != nil {
+		// objects are only kept once the whole archive is read and verified
+		removeExtracted(storage, created)
+		errorIf(err.Trace(), "Extracting archive failed.", nil)
+		switch err.ToGoError() {
+		case errInvalidArchive, errInvalidArchiveEntry, errTooManyArchiveEntries:
+			writeErrorResponse(w, req, InvalidArchive, req.URL.Path)
+			return
+		case errIncompleteArchiveBody:
+			writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
+			return
+		case errArchiveDigestMismatch:
+			writeErrorResponse(w, req, BadDigest, req.URL.Path)
+			return
+		case errArchiveSignatureFailed:
+			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+			return
+		}
+		switch err.ToGoError().(type) {
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		case xl.ObjectLocked:
+			writeErrorResponse(w, req, AccessDenied, req.URL.Path)
+		case xl.ObjectExists:
+			writeErrorResponse(w, req, MutableWriteNotAllowed, req.URL.Path)
+		case xl.EntityTooLarge:
+			writeErrorResponse(w, req, EntityTooLarge, req.URL.Path)
+		case signv4.MissingDateHeader:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	for _, metadata := range created {
+		runPostUploadHooks(api.XL, bucket, metadata.Object, metadata.Metadata["contentType"])
+		globalReplicator.objectCreated(bucket, metadata.Object)
+	}
+	w.Header().Set("X-Minio-Extracted-Objects", strconv.Itoa(len(created)))
+	writeSuccessResponse(w)
+}
+
+// verifyArchive - archive read in full matches its Content-MD5 and signature
+func verifyArchive(n, size int64, md5Base64 string, md5Sum []byte, hashedPayload string, signature *signv4.Signature) *probe.Error {
+	if n != size {
+		return probe.NewError(errIncompleteArchiveBody)
+	}
+	if md5Base64 != "" {
+		expected, e := base64.StdEncoding.DecodeString(strings.TrimSpace(md5Base64))
+		if e != nil || !bytes.Equal(expected, md5Sum) {
+			return probe.NewError(errArchiveDigestMismatch)
+		}
+	}
+	if signature != nil {
+		ok, err := signature.DoesSignatureMatch(hashedPayload)
+		if err != nil {
+			return err.Trace()
+		}
+		if !ok {
+			return probe.NewError(errArchiveSignatureFailed)
+		}
+	}
+	return nil
+}
+
+// countingReader - number of bytes read so far
+type countingReader struct {
+	reader io.Reader
+	n      int64
+}
+
+func (r *countingReader) Read(p []byte) (int, error) {
+	n, e := r.reader.Read(p)
+	r.n += int64(n)
+	return n, e
+}
@@ -0,0 +1,51 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// globalPostUploadHooks is set by startServer, hooks run for every object uploaded successfully
+var globalPostUploadHooks []postUploadHook
+
+// postUploadHook - transformation of an uploaded object, such as storing a derived object
+// alongside it
+type postUploadHook interface {
+	// Name - used while logging failures
+	Name() string
+	// Matches - whether the hook applies to an uploaded object
+	Matches(object, contentType string) bool
+	// Run - process an uploaded object
+	Run(storage xl.Interface, bucket, object, contentType string) *probe.Error
+}
+
+// runPostUploadHooks - run matching hooks in the background, failures are only logged
+// since the upload itself has already succeeded
+func runPostUploadHooks(storage xl.Interface, bucket, object, contentType string) {
+	for _, hook := range globalPostUploadHooks {
+		if !hook.Matches(object, contentType) {
+			continue
+		}
+		go func(hook postUploadHook) {
+			if err := hook.Run(storage, bucket, object, contentType); err != nil {
+				errorIf(err.Trace(bucket, object), "Post upload hook "+hook.Name()+" failed.", nil)
+			}
+		}(hook)
+	}
+}
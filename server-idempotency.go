@@ -0,0 +1,221 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/sha256"
+	"crypto/sha512"
+	"encoding/hex"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	signv4 "github.com/minio/minio-xl/pkg/signature"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+const (
+	// idempotencyKeyHeader - PUTs retried with the same key are answered with the result of the
+	// first one instead of being written again
+	idempotencyKeyHeader = "X-Minio-Idempotency-Key"
+	// maxIdempotencyKeyLength - longest key accepted, in bytes
+	maxIdempotencyKeyLength = 256
+	// maxIdempotencyEntries - bounds memory used by clients sending a new key with every request
+	maxIdempotencyEntries = 10000
+	// idempotentReplayHeader - set on responses answered with the result of an earlier PUT
+	idempotentReplayHeader = "X-Minio-Idempotent-Replay"
+	// idempotentPutWait - longest a retry waits for the PUT in progress with its key, a PUT stuck
+	// on a slow client would otherwise hold every retry along with it
+	idempotentPutWait = 10 * time.Second
+)
+
+// globalIdempotencyCache is set by startServer with --idempotency-ttl, nil if disabled
+var globalIdempotencyCache *idempotencyCache
+
+// idempotentPut - a PUT in progress or completed with a key
+type idempotentPut struct {
+	// done is closed once the PUT completed, ok if it succeeded
+	done     chan struct{}
+	ok       bool
+	metadata xl.ObjectMetadata
+	// sha512Sum of the body, retries must send the same
+	sha512Sum string
+	expires   time.Time
+}
+
+// idempotencyCache - PUTs by access key, bucket, object and idempotency key
+type idempotencyCache struct {
+	lock sync.Mutex
+	ttl  time.Duration
+	wait time.Duration
+	puts map[string]*idempotentPut
+}
+
+func newIdempotencyCache(ttl time.Duration) *idempotencyCache {
+	return &idempotencyCache{ttl: ttl, wait: idempotentPutWait, puts: make(map[string]*idempotentPut)}
+}
+
+// getIdempotencyKey - key of a PUT in the cache, empty if the request has no idempotency key.
+// Returns false for keys longer than maxIdempotencyKeyLength.
+func getIdempotencyKey(req *http.Request, bucket, object string) (string, bool) {
+	key := strings.TrimSpace(req.Header.Get(idempotencyKeyHeader))
+	if key == "" {
+		return "", true
+	}
+	if len(key) > maxIdempotencyKeyLength {
+		return "", false
+	}
+	return strings.Join([]string{getAuditAccessKey(req), bucket, object, key}, "\x00"), true
+}
+
+// begin - PUT with key completed within ttl, or in progress in which case begin waits for it to
+// complete, for wait at most. Returns nil if there is none, the caller then writes the object and
+// calls finish. Nothing is cached once the cache is full, such PUTs are written as if they had no key.
+func (c *idempotencyCache) begin(key string) (*idempotentPut, bool, *probe.Error) {
+	for {
+		c.lock.Lock()
+		put, ok := c.puts[key]
+		if ok && put.ok && time.Now().After(put.expires) {
+			delete(c.puts, key)
+			ok = false
+		}
+		if !ok {
+			if len(c.puts) >= maxIdempotencyEntries {
+				c.expire()
+			}
+			if len(c.puts) >= maxIdempotencyEntries {
+				c.lock.Unlock()
+				return nil, false, nil
+			}
+			c.puts[key] = &idempotentPut{done: make(chan struct{})}
+			c.lock.Unlock()
+			return nil, true, nil
+		}
+		c.lock.Unlock()
+		select {
+		case <-put.done:
+		case <-time.After(c.wait):
+			return nil, false, probe.NewError(errIdempotentPutInProgress)
+		}
+		// a failed PUT is dropped by finish, the retry is written instead
+		if put.ok {
+			return put, false, nil
+		}
+	}
+}
+
+// finish - complete a PUT started with begin, failed ones are forgotten
+func (c *idempotencyCache) finish(key string, metadata xl.ObjectMetadata, sha512Sum string, err *probe.Error) {
+	c.lock.Lock()
+	defer c.lock.Unlock()
+	put, ok := c.puts[key]
+	if !ok {
+		return
+	}
+	if err != nil {
+		delete(c.puts, key)
+	} else {
+		put.ok = true
+		put.metadata = metadata
+		put.sha512Sum = sha512Sum
+		put.expires = time.Now().Add(c.ttl)
+	}
+	close(put.done)
+}
+
+// start - body to write for a PUT and the function completing it, or the completed PUT the
+// request is a retry of. PUTs without a key, or with the cache disabled, are written as they are.
+// Fails with errIdempotentPutInProgress if the PUT retried is still in progress after wait.
+func (c *idempotencyCache) start(key string, body io.Reader) (io.Reader, func(xl.ObjectMetadata, *probe.Error), *idempotentPut, *probe.Error) {
+	finish := func(xl.ObjectMetadata, *probe.Error) {}
+	if c == nil || key == "" {
+		return body, finish, nil, nil
+	}
+	put, started, err := c.begin(key)
+	if err != nil {
+		return nil, nil, nil, err.Trace()
+	}
+	if put != nil {
+		return nil, nil, put, nil
+	}
+	if !started {
+		return body, finish, nil, nil
+	}
+	sum512 := sha512.New()
+	finish = func(metadata xl.ObjectMetadata, err *probe.Error) {
+		c.finish(key, metadata, hex.EncodeToString(sum512.Sum(nil)), err)
+	}
+	return io.TeeReader(body, sum512), finish, nil, nil
+}
+
+// expire - drop completed PUTs older than ttl, PUTs in progress are kept
+func (c *idempotencyCache) expire() {
+	now := time.Now()
+	for key, put := range c.puts {
+		if put.ok && now.After(put.expires) {
+			delete(c.puts, key)
+		}
+	}
+}
+
+// isIdenticalPut - does a retried PUT carry the data of the original one. The body is read in
+// full, retries are verified against their signature as any PUT would be.
+func isIdenticalPut(req *http.Request, original *idempotentPut, signature *signv4.Signature) (bool, *probe.Error) {
+	sum512 := sha512.New()
+	sum256 := sha256.New()
+	if _, e := io.Copy(io.MultiWriter(sum512, sum256), req.Body); e != nil {
+		return false, probe.NewError(e)
+	}
+	if signature != nil {
+		ok, err := signature.DoesSignatureMatch(hex.EncodeToString(sum256.Sum(nil)))
+		if err != nil {
+			return false, err.Trace()
+		}
+		if !ok {
+			return false, probe.NewError(signv4.DoesNotMatch{})
+		}
+	}
+	return hex.EncodeToString(sum512.Sum(nil)) == original.sha512Sum, nil
+}
+
+// writeIdempotentPutResponse - answer a retried PUT with the result of the original one
+func writeIdempotentPutResponse(w http.ResponseWriter, req *http.Request, original *idempotentPut, signature *signv4.Signature) {
+	identical, err := isIdenticalPut(req, original, signature)
+	if err != nil {
+		errorIf(err.Trace(), "Reading retried PUT failed.", nil)
+		switch err.ToGoError().(type) {
+		case signv4.MissingDateHeader:
+			writeErrorResponse(w, req, RequestTimeTooSkewed, req.URL.Path)
+		case signv4.DoesNotMatch:
+			writeErrorResponse(w, req, SignatureDoesNotMatch, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, IncompleteBody, req.URL.Path)
+		}
+		return
+	}
+	if !identical {
+		writeErrorResponse(w, req, IdempotencyKeyMismatch, req.URL.Path)
+		return
+	}
+	w.Header().Set("ETag", original.metadata.MD5Sum)
+	w.Header().Set(idempotentReplayHeader, "true")
+	setChecksumHeader(w, original.metadata)
+	writeSuccessResponse(w)
+}
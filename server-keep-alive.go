@@ -0,0 +1,80 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// copyKeepAliveInterval - whitespace is sent this often while a server side copy runs, well
+// below the idle timeout of common proxies and load balancers
+var copyKeepAliveInterval = 10 * time.Second
+
+// flushResponse - send what was written so far to the client
+func flushResponse(w http.ResponseWriter) {
+	if f, ok := w.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// keepAliveResponse - response of a server side copy. Copies running longer than
+// copyKeepAliveInterval get a 200 OK status and whitespace until they are done, like S3 does,
+// the result or error follows as the XML body. Clients skip leading whitespace of the body.
+type keepAliveResponse struct {
+	w       http.ResponseWriter
+	started bool
+}
+
+// wait - block until done is closed, keeping the connection busy meanwhile
+func (k *keepAliveResponse) wait(done <-chan struct{}) {
+	ticker := time.NewTicker(copyKeepAliveInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-done:
+			return
+		case <-ticker.C:
+			if !k.started {
+				k.started = true
+				k.w.Header().Set("Content-Type", "application/xml")
+				k.w.WriteHeader(http.StatusOK)
+			}
+			k.w.Write([]byte(" "))
+			flushResponse(k.w)
+		}
+	}
+}
+
+// writeSuccessResponse - write the result, its status and headers unless whitespace went out
+func (k *keepAliveResponse) writeSuccessResponse(req *http.Request, body []byte) {
+	if !k.started {
+		writeXMLResponse(k.w, req, http.StatusOK, body)
+		return
+	}
+	k.w.Write(body)
+}
+
+// writeErrorResponse - write the error, its status and headers unless whitespace went out
+func (k *keepAliveResponse) writeErrorResponse(req *http.Request, errorType int, resource string) {
+	if !k.started {
+		writeErrorResponse(k.w, req, errorType, resource)
+		return
+	}
+	errorResponse := getErrorResponse(getErrorCode(errorType), resource)
+	k.w.Write(encodeErrorResponse(errorResponse))
+}
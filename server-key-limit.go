@@ -0,0 +1,82 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+)
+
+// keyLimits bounds object keys of requests before any disk is looked at, pathological keys
+// otherwise cost a lot of metadata path work
+type keyLimits struct {
+	// maxLength longest key in bytes
+	maxLength int
+	// maxDepth most ‘/’ separated segments of a key, '0' means unlimited
+	maxDepth int
+}
+
+// globalKeyLimits is set by startServer with --max-key-length and --max-key-depth
+var globalKeyLimits = newKeyLimits(0, 0)
+
+// newKeyLimits - new limits, a maxLength smaller than 1 means the S3 limit of 1024 bytes
+func newKeyLimits(maxLength, maxDepth int) keyLimits {
+	if maxLength < 1 {
+		maxLength = 1024
+	}
+	if maxDepth < 0 {
+		maxDepth = 0
+	}
+	return keyLimits{maxLength: maxLength, maxDepth: maxDepth}
+}
+
+// check - error code of a key over the limits, returns false for keys within them
+func (l keyLimits) check(key string) (int, bool) {
+	if len(key) > l.maxLength {
+		return KeyTooLong, true
+	}
+	if l.maxDepth > 0 && strings.Count(key, "/")+1 > l.maxDepth {
+		return InvalidObjectName, true
+	}
+	return 0, false
+}
+
+type keyLimitHandler struct {
+	handler http.Handler
+}
+
+// KeyLimitHandler rejects requests for object keys, or copying from them, over the limits
+func KeyLimitHandler(h http.Handler) http.Handler {
+	return keyLimitHandler{h}
+}
+
+func (h keyLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	limits := globalKeyLimits
+	if splits := strings.SplitN(r.URL.Path, "/", 3); len(splits) == 3 && splits[2] != "" {
+		if code, ok := limits.check(splits[2]); ok {
+			writeErrorResponse(w, r, code, r.URL.Path)
+			return
+		}
+	}
+	if _, object, ok := getMoveSource(r.Header.Get("X-Amz-Copy-Source")); ok {
+		if code, ok := limits.check(object); ok {
+			writeErrorResponse(w, r, code, r.URL.Path)
+			return
+		}
+	}
+	h.handler.ServeHTTP(w, r)
+}
@@ -17,15 +17,18 @@
 package main
 
 import (
-	"crypto/tls"
 	"net"
 	"net/http"
 	"os"
 	"strings"
 
+	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
 	"github.com/minio/minio-xl/pkg/minhttp"
 	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/tracing"
+	"github.com/minio/minio-xl/pkg/xl"
+	"github.com/minio/minio-xl/pkg/xl/disk"
 )
 
 var serverCmd = cli.Command{
@@ -51,18 +54,28 @@ func configureAPIServer(conf minioConfig, apiHandler http.Handler) (*http.Server
 	apiServer := &http.Server{
 		Addr:           conf.Address,
 		Handler:        apiHandler,
-		MaxHeaderBytes: 1 << 20,
+		MaxHeaderBytes: conf.MaxHeaderBytes,
 	}
 
 	if conf.TLS {
-		var err error
-		apiServer.TLSConfig = &tls.Config{}
-		apiServer.TLSConfig.Certificates = make([]tls.Certificate, 1)
-		apiServer.TLSConfig.Certificates[0], err = tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		var err *probe.Error
+		apiServer.TLSConfig, err = getTLSConfig(conf)
 		if err != nil {
-			return nil, probe.NewError(err)
+			return nil, err.Trace()
 		}
 	}
+	// load balancer in front sends the client address in a PROXY protocol header
+	if conf.ProxyProtocol {
+		minhttp.EnableProxyProtocol(apiServer)
+	}
+	// user metadata keys are returned in the case they were sent
+	minhttp.EnableHeaderCase(apiServer)
+
+	// local only deployments, nothing is exposed on the network
+	if strings.HasPrefix(conf.Address, minhttp.UnixAddressPrefix) {
+		printMessage(startMessage{Service: "server", Address: conf.Address, PID: os.Getpid()})
+		return apiServer, nil
+	}
 
 	host, port, err := net.SplitHostPort(conf.Address)
 	if err != nil {
@@ -89,11 +102,11 @@ func configureAPIServer(conf minioConfig, apiHandler http.Handler) (*http.Server
 	}
 
 	for _, host := range hosts {
+		scheme := "http://"
 		if conf.TLS {
-			Printf("Starting minio server on: https://%s:%s, PID: %d\n", host, port, os.Getpid())
-		} else {
-			Printf("Starting minio server on: http://%s:%s, PID: %d\n", host, port, os.Getpid())
+			scheme = "https://"
 		}
+		printMessage(startMessage{Service: "server", Address: scheme + net.JoinHostPort(host, port) + normalizePathPrefix(conf.PathPrefix), PID: os.Getpid()})
 	}
 	return apiServer, nil
 }
@@ -104,21 +117,69 @@ func configureServerRPC(conf minioConfig, rpcHandler http.Handler) (*http.Server
 	rpcServer := &http.Server{
 		Addr:           conf.RPCAddress,
 		Handler:        rpcHandler,
-		MaxHeaderBytes: 1 << 20,
+		MaxHeaderBytes: conf.MaxHeaderBytes,
 	}
 
 	if conf.TLS {
-		var err error
-		rpcServer.TLSConfig = &tls.Config{}
-		rpcServer.TLSConfig.Certificates = make([]tls.Certificate, 1)
-		rpcServer.TLSConfig.Certificates[0], err = tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+		var err *probe.Error
+		rpcServer.TLSConfig, err = getTLSConfig(conf)
 		if err != nil {
-			return nil, probe.NewError(err)
+			return nil, err.Trace()
 		}
 	}
 	return rpcServer, nil
 }
 
+// configureWebsiteServer configure static website server
+func configureWebsiteServer(conf minioConfig, websiteHandler http.Handler) (*http.Server, *probe.Error) {
+	websiteServer := &http.Server{
+		Addr:           conf.WebsiteAddress,
+		Handler:        websiteHandler,
+		MaxHeaderBytes: conf.MaxHeaderBytes,
+	}
+
+	if conf.TLS {
+		var err *probe.Error
+		websiteServer.TLSConfig, err = getTLSConfig(conf)
+		if err != nil {
+			return nil, err.Trace()
+		}
+	}
+	// load balancer in front sends the client address in a PROXY protocol header
+	if conf.ProxyProtocol {
+		minhttp.EnableProxyProtocol(websiteServer)
+	}
+	scheme := "http://"
+	if conf.TLS {
+		scheme = "https://"
+	}
+	printMessage(startMessage{Service: "website", Address: scheme + conf.WebsiteAddress, PID: os.Getpid()})
+	return websiteServer, nil
+}
+
+// configureMetricsServer configure a new server instance serving bucket metrics to Prometheus
+func configureMetricsServer(conf minioConfig, metricsHandler http.Handler) (*http.Server, *probe.Error) {
+	metricsServer := &http.Server{
+		Addr:           conf.MetricsAddress,
+		Handler:        metricsHandler,
+		MaxHeaderBytes: conf.MaxHeaderBytes,
+	}
+
+	if conf.TLS {
+		var err *probe.Error
+		metricsServer.TLSConfig, err = getTLSConfig(conf)
+		if err != nil {
+			return nil, err.Trace()
+		}
+	}
+	scheme := "http://"
+	if conf.TLS {
+		scheme = "https://"
+	}
+	printMessage(startMessage{Service: "metrics", Address: scheme + conf.MetricsAddress + "/metrics", PID: os.Getpid()})
+	return metricsServer, nil
+}
+
 // Start ticket master
 func startTM(api API) {
 	for {
@@ -128,19 +189,169 @@ func startTM(api API) {
 	}
 }
 
+// defaultBucketMessage - default bucket of a single bucket appliance was created or found
+type defaultBucketMessage struct {
+	Bucket  string `json:"bucket"`
+	Created bool   `json:"created"`
+}
+
+func (d defaultBucketMessage) String() string {
+	if d.Created {
+		return "Created default bucket: " + d.Bucket
+	}
+	return "Default bucket " + d.Bucket + " is already present."
+}
+
+// JSON - json formatted output
+func (d defaultBucketMessage) JSON() string {
+	return jsonMessage(d)
+}
+
 // startServer starts an s3 compatible cloud storage server
+// makeDefaultBucket - create the bucket of single bucket appliances on first run, nothing
+// is done if it exists already
+func makeDefaultBucket(storage xl.Interface, bucket string) *probe.Error {
+	err := storage.MakeBucket(bucket, "private", nil, nil)
+	if err == nil {
+		printMessage(defaultBucketMessage{Bucket: bucket, Created: true})
+		return nil
+	}
+	if _, ok := err.ToGoError().(xl.BucketExists); ok {
+		printMessage(defaultBucketMessage{Bucket: bucket})
+		return nil
+	}
+	return err.Trace(bucket)
+}
+
 func startServer(conf minioConfig) *probe.Error {
+	if conf.CredentialsCmd != "" {
+		provider, err := newCredentialsProvider(conf.CredentialsCmd)
+		if err != nil {
+			return err.Trace(conf.CredentialsCmd)
+		}
+		globalCredentialsProvider = provider
+		go provider.reloadOnSIGHUP()
+	}
+	if conf.OCSPStaple {
+		stapler, err := newOCSPStapler(conf.CertFile, conf.KeyFile)
+		if err != nil {
+			return err.Trace(conf.CertFile)
+		}
+		// clients are served without a staple until a fetch succeeds
+		refreshIn, err := stapler.refresh()
+		errorIf(err.Trace(), "Unable to fetch OCSP response, serving without a staple.", nil)
+		globalOCSPStapler = stapler
+		go stapler.refreshPeriodically(refreshIn)
+	}
+	xl.SetReadAhead(conf.ReadAhead)
+	xl.SetDiskOpenWorkers(conf.DiskOpenWorkers)
+	xl.SetMinOnlineDisks(conf.MinOnlineDisks)
+	xl.SetListWorkers(conf.ListWorkers)
+	disk.SetPermissions(conf.FileMode, conf.DirMode)
+	disk.SetFsyncPolicy(conf.FsyncPolicy)
+	xl.SetWriteOrder(conf.WriteOrder)
+	xl.SetDiskTimeout(conf.DiskTimeout)
+	xl.SetDedup(conf.Dedup)
+	xl.SetReadRepair(conf.ReadRepair)
+	xl.SetDisableMD5(conf.DisableMD5)
+	xl.SetETagAlgorithm(conf.ETagAlgorithm)
+	xl.SetBufferPool(!conf.NoBufferPool)
+	xl.SetWriteRetry(conf.WriteRetries, conf.WriteRetryBackoff)
+	xl.SetNotFoundCacheTTL(conf.NotFoundCacheTTL)
+	xl.SetOrphanExpiry(conf.OrphanExpiry)
+	xl.SetMultipartTTL(conf.MultipartTTL)
+	xl.SetDiskCache(conf.CacheDir, conf.CacheSize, conf.CacheWrites)
+	xl.SetErasureRatioRules(conf.ErasureRatios)
+	xl.SetLocalDisks(conf.LocalDisks)
+	xl.SetMetadataIndexSize(int64(conf.MetadataIndexSize))
+	xl.SetAccessTimeInterval(conf.AccessInterval)
+	globalDiskProbe = conf.DiskProbe
+	if conf.Thumbnail != "" {
+		hook, err := newThumbnailHook(conf.Thumbnail)
+		if err != nil {
+			return err.Trace(conf.Thumbnail)
+		}
+		globalPostUploadHooks = append(globalPostUploadHooks, hook)
+	}
+	globalCompress = conf.Compress
+	globalCompressXML = !conf.NoXMLCompression
+	globalSanitizeHeaders = conf.SanitizeHeaders
+	globalCacheControl = conf.CacheControl
+	if conf.IdempotencyTTL > 0 {
+		globalIdempotencyCache = newIdempotencyCache(conf.IdempotencyTTL)
+	}
+	if conf.AuditLog != "" {
+		logger, err := newAuditLogger(conf.AuditLog)
+		if err != nil {
+			return err.Trace(conf.AuditLog)
+		}
+		globalAuditLogger = logger
+	}
+	if conf.DumpDir != "" {
+		go dumpOnSignal(conf.DumpSignal, conf.DumpDir, conf.DumpHeap)
+	}
+	if conf.OTelEndpoint != "" {
+		if err := tracing.Enable(conf.OTelEndpoint, "minio-xl"); err != nil {
+			return err.Trace(conf.OTelEndpoint)
+		}
+	}
+	globalMemoryAccountant = newMemoryAccountant(conf.MaxMemory)
+	globalBandwidthLimits = newBandwidthLimits(conf.MaxBandwidth, conf.MaxIngress, conf.MaxEgress)
+	globalPartLimiter = newPartLimiter(conf.MaxPartsInFlight)
+	globalAnonymousLimiter = newAnonymousLimiter(conf.AnonRateLimit)
+	xl.SetMaxMultipartUploads(conf.MaxUploads)
+	globalPathPrefix = normalizePathPrefix(conf.PathPrefix)
+	globalKeyLimits = newKeyLimits(conf.MaxKeyLength, conf.MaxKeyDepth)
+	xl.SetMaxObjectNameLength(globalKeyLimits.maxLength)
 	minioAPI := getNewAPI(conf.Anonymous)
+	globalReplicator = newReplicator(minioAPI.XL)
+	globalReplicator.start()
+	if conf.DefaultBucket != "" {
+		if err := makeDefaultBucket(minioAPI.XL, conf.DefaultBucket); err != nil {
+			return err.Trace()
+		}
+	}
 	apiHandler := getAPIHandler(conf.Anonymous, minioAPI)
 	apiServer, err := configureAPIServer(conf, apiHandler)
 	if err != nil {
 		return err.Trace()
 	}
-	rpcServer, err := configureServerRPC(conf, getServerRPCHandler(conf.Anonymous))
+	rpcServer, err := configureServerRPC(conf, getServerRPCHandler(conf.Anonymous, minioAPI))
+	if err != nil {
+		return err.Trace()
+	}
+	globalRPCConnTracker = trackRPCServer(rpcServer, conf.RPCIdleTimeout)
+	servers := []*http.Server{apiServer, rpcServer}
+	if conf.WebsiteAddress != "" {
+		websiteServer, err := configureWebsiteServer(conf, getWebsiteHandler(minioAPI))
+		if err != nil {
+			return err.Trace()
+		}
+		servers = append(servers, websiteServer)
+	}
+	if conf.MetricsAddress != "" {
+		metricsServer, err := configureMetricsServer(conf, getMetricsHandler())
+		if err != nil {
+			return err.Trace()
+		}
+		servers = append(servers, metricsServer)
+	}
+
+	if conf.Controller != "" {
+		host, err := getAdvertisedAddress(conf.RPCAddress)
+		if err != nil {
+			return err.Trace()
+		}
+		go func() {
+			registration := newControllerRegistration(conf.Controller, host, conf.TLS, conf.RegisterTimeout)
+			errorIf(registration.register(), "Unable to register with the controller.", nil)
+		}()
+	}
 
 	// start ticket master
 	go startTM(minioAPI)
-	if err := minhttp.ListenAndServe(apiServer, rpcServer); err != nil {
+	minhttp.SetStopTimeout(conf.ShutdownTimeout)
+	if err := minhttp.ListenAndServe(servers...); err != nil {
 		return err.Trace()
 	}
 	return nil
@@ -153,14 +364,127 @@ func getServerConfig(c *cli.Context) minioConfig {
 		Fatalln("Both certificate and key are required to enable https.")
 	}
 	tls := (certFile != "" && keyFile != "")
+	if c.GlobalBool("ocsp-staple") && !tls {
+		Fatalln("--ocsp-staple requires --cert and --key.")
+	}
+	var maxMemory uint64
+	if max := c.GlobalString("max-memory"); max != "" {
+		var e error
+		maxMemory, e = humanize.ParseBytes(max)
+		fatalIf(probe.NewError(e), "Invalid max memory "+max+".", nil)
+	}
+	bandwidth := make(map[string]int64)
+	for _, name := range []string{"max-bandwidth", "max-ingress-bandwidth", "max-egress-bandwidth"} {
+		if max := c.GlobalString(name); max != "" {
+			rate, e := humanize.ParseBytes(max)
+			fatalIf(probe.NewError(e), "Invalid --"+name+" "+max+".", nil)
+			bandwidth[name] = int64(rate)
+		}
+	}
+	fileMode, err := disk.ParseMode(c.GlobalString("file-mode"), 0600)
+	fatalIf(err.Trace(), "Invalid --file-mode.", nil)
+	dirMode, err := disk.ParseMode(c.GlobalString("dir-mode"), 0700)
+	fatalIf(err.Trace(), "Invalid --dir-mode.", nil)
+	fsyncPolicy, err := disk.ParseFsyncPolicy(c.GlobalString("fsync"))
+	fatalIf(err.Trace(), "Invalid --fsync.", nil)
+	writeOrder, err := xl.ParseWriteOrder(c.GlobalString("write-order"))
+	fatalIf(err.Trace(), "Invalid --write-order.", nil)
+	etagAlgorithm, err := xl.ParseETagAlgorithm(c.GlobalString("etag-algorithm"))
+	fatalIf(err.Trace(), "Invalid --etag-algorithm.", nil)
+	var cacheSize uint64
+	cacheDir := c.GlobalString("cache-dir")
+	if cacheDir != "" {
+		_, err := disk.New(cacheDir)
+		fatalIf(err.Trace(cacheDir), "Invalid --cache-dir "+cacheDir+".", nil)
+		var e error
+		cacheSize, e = humanize.ParseBytes(c.GlobalString("cache-size"))
+		fatalIf(probe.NewError(e), "Invalid --cache-size "+c.GlobalString("cache-size")+".", nil)
+	}
+	erasureRatios, err := xl.ParseErasureRatioRules(c.GlobalString("erasure-ratio"))
+	fatalIf(err.Trace(), "Invalid --erasure-ratio.", nil)
+	var localDisks []string
+	for _, path := range strings.Split(c.GlobalString("local-disks"), ",") {
+		if path = strings.TrimSpace(path); path != "" {
+			localDisks = append(localDisks, path)
+		}
+	}
+	diskProbe := c.GlobalString("disk-probe")
+	if diskProbe != diskProbeStat && diskProbe != diskProbeWrite {
+		Fatalln("Invalid --disk-probe " + diskProbe + ", must be ‘stat’ or ‘write’.")
+	}
+	dumpSignal, ok := parseDumpSignal(c.GlobalString("dump-signal"))
+	if !ok {
+		Fatalln("Invalid --dump-signal " + c.GlobalString("dump-signal") + ", must be one of ‘SIGQUIT’ or ‘SIGUSR1’.")
+	}
 	return minioConfig{
-		Address:    c.GlobalString("address"),
-		RPCAddress: c.GlobalString("address-server-rpc"),
-		Anonymous:  c.GlobalBool("anonymous"),
-		TLS:        tls,
-		CertFile:   certFile,
-		KeyFile:    keyFile,
-		RateLimit:  c.GlobalInt("ratelimit"),
+		Address:           c.GlobalString("address"),
+		RPCAddress:        c.GlobalString("address-server-rpc"),
+		WebsiteAddress:    c.GlobalString("website-address"),
+		MetricsAddress:    c.GlobalString("metrics-address"),
+		Anonymous:         c.GlobalBool("anonymous"),
+		TLS:               tls,
+		TLSMinVersion:     c.GlobalString("tls-min-version"),
+		TLSCiphers:        c.GlobalString("tls-ciphers"),
+		OCSPStaple:        c.GlobalBool("ocsp-staple"),
+		ProxyProtocol:     c.GlobalBool("proxy-protocol"),
+		CertFile:          certFile,
+		KeyFile:           keyFile,
+		RateLimit:         c.GlobalInt("ratelimit"),
+		AnonRateLimit:     c.GlobalInt("anonymous-ratelimit"),
+		CredentialsCmd:    c.GlobalString("credentials-cmd"),
+		ReadAhead:         c.GlobalInt("read-ahead"),
+		MaxMemory:         int64(maxMemory),
+		MaxBandwidth:      bandwidth["max-bandwidth"],
+		MaxIngress:        bandwidth["max-ingress-bandwidth"],
+		MaxEgress:         bandwidth["max-egress-bandwidth"],
+		MaxPartsInFlight:  c.GlobalInt("max-parts-in-flight"),
+		MaxUploads:        c.GlobalInt("max-multipart-uploads"),
+		PathPrefix:        c.GlobalString("path-prefix"),
+		MaxKeyLength:      c.GlobalInt("max-key-length"),
+		MaxKeyDepth:       c.GlobalInt("max-key-depth"),
+		DiskOpenWorkers:   c.GlobalInt("disk-open-workers"),
+		MinOnlineDisks:    c.GlobalInt("min-online-disks"),
+		ListWorkers:       c.GlobalInt("list-workers"),
+		FileMode:          fileMode,
+		DirMode:           dirMode,
+		FsyncPolicy:       fsyncPolicy,
+		WriteOrder:        writeOrder,
+		DiskTimeout:       c.GlobalDuration("disk-timeout"),
+		Dedup:             c.GlobalBool("dedup"),
+		ReadRepair:        c.GlobalBool("read-repair"),
+		DisableMD5:        c.GlobalBool("disable-md5"),
+		ETagAlgorithm:     etagAlgorithm,
+		NoBufferPool:      c.GlobalBool("disable-buffer-pool"),
+		RPCIdleTimeout:    c.GlobalDuration("rpc-idle-timeout"),
+		Controller:        c.GlobalString("register-controller"),
+		RegisterTimeout:   c.GlobalDuration("register-timeout"),
+		ShutdownTimeout:   c.GlobalDuration("shutdown-timeout"),
+		MaxHeaderBytes:    c.GlobalInt("max-header-bytes"),
+		IdempotencyTTL:    c.GlobalDuration("idempotency-ttl"),
+		Thumbnail:         c.GlobalString("thumbnail"),
+		Compress:          c.GlobalBool("compress"),
+		NoXMLCompression:  c.GlobalBool("disable-xml-compression"),
+		SanitizeHeaders:   c.GlobalBool("sanitize-headers"),
+		CacheControl:      c.GlobalString("cache-control"),
+		WriteRetries:      c.GlobalInt("write-retries"),
+		WriteRetryBackoff: c.GlobalDuration("write-retry-backoff"),
+		NotFoundCacheTTL:  c.GlobalDuration("not-found-cache-ttl"),
+		OrphanExpiry:      c.GlobalDuration("orphan-expiry"),
+		MultipartTTL:      c.GlobalDuration("multipart-ttl"),
+		CacheDir:          cacheDir,
+		CacheSize:         int64(cacheSize),
+		CacheWrites:       c.GlobalBool("cache-writes"),
+		ErasureRatios:     erasureRatios,
+		LocalDisks:        localDisks,
+		MetadataIndexSize: c.GlobalInt("metadata-index-size"),
+		AccessInterval:    c.GlobalDuration("access-time-interval"),
+		DiskProbe:         diskProbe,
+		DefaultBucket:     c.GlobalString("default-bucket"),
+		AuditLog:          c.GlobalString("audit-log"),
+		OTelEndpoint:      c.GlobalString("otel-endpoint"),
+		DumpDir:           c.GlobalString("dump-dir"),
+		DumpSignal:        dumpSignal,
+		DumpHeap:          c.GlobalBool("dump-heap"),
 	}
 }
 
@@ -0,0 +1,177 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const (
+	// requestBufferSize is the most a single request is accounted for, xl buffers
+	// at most one erasure coded block of 10MB per request
+	requestBufferSize = 10 * 1024 * 1024
+	// memoryWaitTimeout uploads wait this long for in-flight memory to drop under
+	// --max-memory before they are rejected with 503 SlowDown
+	memoryWaitTimeout = 5 * time.Second
+)
+
+// memoryAccountant tracks bytes buffered by in-flight uploads and downloads
+type memoryAccountant struct {
+	mutex    *sync.Mutex
+	max      int64
+	inFlight int64
+	// closed and replaced on every release, wakes up waiting uploads
+	released chan struct{}
+}
+
+// globalMemoryAccountant is set by startServer with --max-memory, unlimited by default
+var globalMemoryAccountant = newMemoryAccountant(0)
+
+// newMemoryAccountant - new accountant, '0' max means uploads are only accounted never delayed
+func newMemoryAccountant(max int64) *memoryAccountant {
+	return &memoryAccountant{
+		mutex:    &sync.Mutex{},
+		max:      max,
+		released: make(chan struct{}),
+	}
+}
+
+// reserve - account size bytes, waits for at most timeout if max would be exceeded. A request
+// which alone is larger than max is let through when nothing else is in flight.
+func (m *memoryAccountant) reserve(size int64, timeout time.Duration) bool {
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		m.mutex.Lock()
+		if m.max <= 0 || m.inFlight == 0 || m.inFlight+size <= m.max {
+			m.inFlight += size
+			m.mutex.Unlock()
+			return true
+		}
+		released := m.released
+		m.mutex.Unlock()
+		select {
+		case <-released:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// add - account size bytes without waiting, used for downloads which are never delayed
+func (m *memoryAccountant) add(size int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.inFlight += size
+}
+
+// release - release size bytes accounted with reserve or add
+func (m *memoryAccountant) release(size int64) {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	m.inFlight -= size
+	close(m.released)
+	m.released = make(chan struct{})
+}
+
+// getInFlight - total bytes currently accounted
+func (m *memoryAccountant) getInFlight() int64 {
+	m.mutex.Lock()
+	defer m.mutex.Unlock()
+	return m.inFlight
+}
+
+// getRequestBufferSize - bytes accounted for a request or response of given content length
+func getRequestBufferSize(contentLength int64) int64 {
+	if contentLength > requestBufferSize {
+		return requestBufferSize
+	}
+	return contentLength
+}
+
+type memoryLimitHandler struct {
+	handler    http.Handler
+	accountant *memoryAccountant
+	timeout    time.Duration
+}
+
+// memoryAccountingWriter accounts a download once its Content-Length is known
+type memoryAccountingWriter struct {
+	http.ResponseWriter
+	accountant *memoryAccountant
+	size       int64
+	accounted  bool
+}
+
+func (w *memoryAccountingWriter) account() {
+	if w.accounted {
+		return
+	}
+	w.accounted = true
+	contentLength, err := strconv.ParseInt(w.Header().Get("Content-Length"), 10, 64)
+	if err != nil || contentLength <= 0 {
+		return
+	}
+	w.size = getRequestBufferSize(contentLength)
+	w.accountant.add(w.size)
+}
+
+func (w *memoryAccountingWriter) WriteHeader(code int) {
+	w.account()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *memoryAccountingWriter) Write(p []byte) (int, error) {
+	w.account()
+	return w.ResponseWriter.Write(p)
+}
+
+func (w *memoryAccountingWriter) Flush() {
+	flushResponse(w.ResponseWriter)
+}
+
+// MemoryLimitHandler accounts memory buffered by uploads and downloads, new uploads are
+// delayed and eventually rejected while in-flight memory is above --max-memory
+func MemoryLimitHandler(h http.Handler) http.Handler {
+	return memoryLimitHandler{h, globalMemoryAccountant, memoryWaitTimeout}
+}
+
+func (h memoryLimitHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	switch {
+	case (r.Method == "PUT" || r.Method == "POST") && r.ContentLength > 0:
+		size := getRequestBufferSize(r.ContentLength)
+		if !h.accountant.reserve(size, h.timeout) {
+			writeErrorResponse(w, r, SlowDown, r.URL.Path)
+			return
+		}
+		defer h.accountant.release(size)
+		h.handler.ServeHTTP(w, r)
+	case r.Method == "GET":
+		mw := &memoryAccountingWriter{ResponseWriter: w, accountant: h.accountant}
+		defer func() {
+			if mw.size > 0 {
+				h.accountant.release(mw.size)
+			}
+		}()
+		h.handler.ServeHTTP(mw, r)
+	default:
+		h.handler.ServeHTTP(w, r)
+	}
+}
@@ -0,0 +1,256 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// bucketMetricsRefreshInterval - requests for unknown buckets look up the buckets of the
+// storage at most this often, bogus bucket names never get counters of their own
+const bucketMetricsRefreshInterval = 10 * time.Second
+
+// bucketMetricsMethods - methods counted by name, any other is counted as ‘OTHER’
+var bucketMetricsMethods = map[string]bool{"GET": true, "HEAD": true, "PUT": true, "POST": true, "DELETE": true}
+
+// globalBucketMetrics - requests served per bucket since the server started
+var globalBucketMetrics = newBucketMetrics()
+
+// bucketCounters - requests served for a bucket by method, failed requests and bytes transferred
+type bucketCounters struct {
+	requests      map[string]int64
+	errors        int64
+	bytesReceived int64
+	bytesSent     int64
+}
+
+// bucketMetrics - counters of buckets known to exist, counters of removed buckets are kept
+type bucketMetrics struct {
+	lock      *sync.Mutex
+	buckets   map[string]*bucketCounters
+	refreshed time.Time
+}
+
+func newBucketMetrics() *bucketMetrics {
+	return &bucketMetrics{
+		lock:    &sync.Mutex{},
+		buckets: make(map[string]*bucketCounters),
+	}
+}
+
+// add - bucket is known to exist
+func (m *bucketMetrics) add(bucket string) {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	if _, ok := m.buckets[bucket]; !ok {
+		m.buckets[bucket] = &bucketCounters{requests: make(map[string]int64)}
+	}
+}
+
+// refresh - look up the buckets of storage unless done recently
+func (m *bucketMetrics) refresh(storage xl.Interface) {
+	m.lock.Lock()
+	if time.Since(m.refreshed) < bucketMetricsRefreshInterval {
+		m.lock.Unlock()
+		return
+	}
+	m.refreshed = time.Now()
+	m.lock.Unlock()
+	buckets, err := storage.ListBuckets()
+	if err != nil {
+		return
+	}
+	for _, bucket := range buckets {
+		m.add(bucket.Name)
+	}
+}
+
+// record - count a request for bucket, requests for buckets not known to exist are not counted
+func (m *bucketMetrics) record(storage xl.Interface, bucket, method string, status int, received, sent int64) {
+	m.lock.Lock()
+	_, ok := m.buckets[bucket]
+	m.lock.Unlock()
+	if !ok {
+		m.refresh(storage)
+	}
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	counters, ok := m.buckets[bucket]
+	if !ok {
+		return
+	}
+	if !bucketMetricsMethods[method] {
+		method = "OTHER"
+	}
+	counters.requests[method]++
+	if status >= http.StatusBadRequest {
+		counters.errors++
+	}
+	counters.bytesReceived += received
+	counters.bytesSent += sent
+}
+
+// stats - counters of all buckets sorted by bucket name
+func (m *bucketMetrics) stats() []BucketRequestStats {
+	m.lock.Lock()
+	defer m.lock.Unlock()
+	var stats []BucketRequestStats
+	for bucket, counters := range m.buckets {
+		requests := make(map[string]int64)
+		for method, count := range counters.requests {
+			requests[method] = count
+		}
+		stats = append(stats, BucketRequestStats{
+			Bucket:        bucket,
+			Requests:      requests,
+			Errors:        counters.errors,
+			BytesReceived: counters.bytesReceived,
+			BytesSent:     counters.bytesSent,
+		})
+	}
+	sort.Sort(bucketRequestStatsByName(stats))
+	return stats
+}
+
+type bucketRequestStatsByName []BucketRequestStats
+
+func (s bucketRequestStatsByName) Len() int           { return len(s) }
+func (s bucketRequestStatsByName) Swap(i, j int)      { s[i], s[j] = s[j], s[i] }
+func (s bucketRequestStatsByName) Less(i, j int) bool { return s[i].Bucket < s[j].Bucket }
+
+// bucketMetricsHandler - count requests per bucket, bytes of request bodies read by handlers
+// and of responses written
+type bucketMetricsHandler struct {
+	handler http.Handler
+	storage xl.Interface
+}
+
+// BucketMetricsHandler - count requests for the buckets of api
+func (api API) BucketMetricsHandler(h http.Handler) http.Handler {
+	return bucketMetricsHandler{handler: h, storage: api.XL}
+}
+
+// metricsReader - counts bytes of the request body read
+type metricsReader struct {
+	io.ReadCloser
+	read int64
+}
+
+func (m *metricsReader) Read(p []byte) (int, error) {
+	n, err := m.ReadCloser.Read(p)
+	m.read += int64(n)
+	return n, err
+}
+
+// metricsWriter - records the status and counts bytes of the response
+type metricsWriter struct {
+	http.ResponseWriter
+	status  int
+	written int64
+}
+
+func (m *metricsWriter) WriteHeader(status int) {
+	if m.status == 0 {
+		m.status = status
+	}
+	m.ResponseWriter.WriteHeader(status)
+}
+
+func (m *metricsWriter) Write(p []byte) (int, error) {
+	if m.status == 0 {
+		m.status = http.StatusOK
+	}
+	n, err := m.ResponseWriter.Write(p)
+	m.written += int64(n)
+	return n, err
+}
+
+func (m *metricsWriter) Flush() {
+	flushResponse(m.ResponseWriter)
+}
+
+func (h bucketMetricsHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	resource := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	bucket := resource[0]
+	if bucket == "" {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	reader := &metricsReader{ReadCloser: r.Body}
+	if r.Body != nil {
+		r.Body = reader
+	}
+	mw := &metricsWriter{ResponseWriter: w}
+	h.handler.ServeHTTP(mw, r)
+	if mw.status == 0 {
+		mw.status = http.StatusOK
+	}
+	// counted from its first request on, without waiting for the next refresh
+	if r.Method == "PUT" && len(resource) == 1 && len(r.URL.Query()) == 0 && mw.status == http.StatusOK {
+		globalBucketMetrics.add(bucket)
+	}
+	globalBucketMetrics.record(h.storage, bucket, r.Method, mw.status, reader.read, mw.written)
+}
+
+// writeBucketMetrics - counters of all buckets in the Prometheus text format
+func writeBucketMetrics(w io.Writer, stats []BucketRequestStats) {
+	label := func(bucket string) string {
+		return "bucket=" + strconv.Quote(bucket)
+	}
+	fmt.Fprintln(w, "# HELP minio_bucket_requests_total Requests served for a bucket by method.")
+	fmt.Fprintln(w, "# TYPE minio_bucket_requests_total counter")
+	for _, s := range stats {
+		var methods []string
+		for method := range s.Requests {
+			methods = append(methods, method)
+		}
+		sort.Strings(methods)
+		for _, method := range methods {
+			fmt.Fprintf(w, "minio_bucket_requests_total{%s,method=%q} %d\n", label(s.Bucket), method, s.Requests[method])
+		}
+	}
+	counters := []struct {
+		name, help string
+		value      func(BucketRequestStats) int64
+	}{
+		{"minio_bucket_errors_total", "Requests for a bucket failed with a 4xx or 5xx status.", func(s BucketRequestStats) int64 { return s.Errors }},
+		{"minio_bucket_received_bytes_total", "Bytes of request bodies received for a bucket.", func(s BucketRequestStats) int64 { return s.BytesReceived }},
+		{"minio_bucket_sent_bytes_total", "Bytes of responses sent for a bucket.", func(s BucketRequestStats) int64 { return s.BytesSent }},
+	}
+	for _, counter := range counters {
+		fmt.Fprintf(w, "# HELP %s %s\n", counter.name, counter.help)
+		fmt.Fprintf(w, "# TYPE %s counter\n", counter.name)
+		for _, s := range stats {
+			fmt.Fprintf(w, "%s{%s} %d\n", counter.name, label(s.Bucket), counter.value(s))
+		}
+	}
+}
+
+// MetricsHandler - serve the counters of all buckets to Prometheus
+func MetricsHandler(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+	writeBucketMetrics(w, globalBucketMetrics.stats())
+}
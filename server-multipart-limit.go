@@ -0,0 +1,98 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+const (
+	// partWaitTimeout parts wait this long for another part of the same upload to finish
+	// before they are rejected with 503 SlowDown
+	partWaitTimeout = 5 * time.Second
+	// partRetryAfter is the Retry-After, in seconds, of rejected parts
+	partRetryAfter = 1
+)
+
+// partLimiter bounds the number of parts uploaded concurrently for each upload id
+type partLimiter struct {
+	mutex    *sync.Mutex
+	max      int
+	inFlight map[string]int
+	// closed and replaced on every release, wakes up waiting parts
+	released chan struct{}
+}
+
+// globalPartLimiter is set by startServer with --max-parts-in-flight, unlimited by default
+var globalPartLimiter = newPartLimiter(0)
+
+// newPartLimiter - new limiter, '0' max means parts are never delayed
+func newPartLimiter(max int) *partLimiter {
+	return &partLimiter{
+		mutex:    &sync.Mutex{},
+		max:      max,
+		inFlight: make(map[string]int),
+		released: make(chan struct{}),
+	}
+}
+
+// acquire - account a part of uploadID, waits for at most timeout if max parts of it are
+// already in flight
+func (l *partLimiter) acquire(uploadID string, timeout time.Duration) bool {
+	if l.max <= 0 {
+		return true
+	}
+	timer := time.NewTimer(timeout)
+	defer timer.Stop()
+	for {
+		l.mutex.Lock()
+		if l.inFlight[uploadID] < l.max {
+			l.inFlight[uploadID]++
+			l.mutex.Unlock()
+			return true
+		}
+		released := l.released
+		l.mutex.Unlock()
+		select {
+		case <-released:
+		case <-timer.C:
+			return false
+		}
+	}
+}
+
+// release - release a part accounted with acquire
+func (l *partLimiter) release(uploadID string) {
+	if l.max <= 0 {
+		return
+	}
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	if l.inFlight[uploadID]--; l.inFlight[uploadID] <= 0 {
+		delete(l.inFlight, uploadID)
+	}
+	close(l.released)
+	l.released = make(chan struct{})
+}
+
+// getInFlight - parts of uploadID currently in flight
+func (l *partLimiter) getInFlight(uploadID string) int {
+	l.mutex.Lock()
+	defer l.mutex.Unlock()
+	return l.inFlight[uploadID]
+}
@@ -0,0 +1,289 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"crypto/sha1"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"io"
+	"io/ioutil"
+	"math/big"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// ocspRefreshInterval - responses without a next update are fetched again this often
+	ocspRefreshInterval = time.Hour
+	// ocspRetryInterval - failed fetches are retried this often
+	ocspRetryInterval = 5 * time.Minute
+	// ocspTimeout - a fetch from the responder must finish within this long
+	ocspTimeout = 10 * time.Second
+	// maxOCSPResponseSize - longest response read from the responder
+	maxOCSPResponseSize = 1024 * 1024
+)
+
+var (
+	// ocspSHA1OID - issuer name and key are hashed with sha1 in requests, as all responders support
+	ocspSHA1OID = asn1.ObjectIdentifier{1, 3, 14, 3, 2, 26}
+	// ocspBasicResponseOID - the only response type defined
+	ocspBasicResponseOID = asn1.ObjectIdentifier{1, 3, 6, 1, 5, 5, 7, 48, 1, 1}
+)
+
+// ocsp asn.1 structures as of RFC 6960, only the fields the server needs
+type ocspCertID struct {
+	HashAlgorithm  pkix.AlgorithmIdentifier
+	IssuerNameHash []byte
+	IssuerKeyHash  []byte
+	SerialNumber   *big.Int
+}
+
+type ocspRequestEntry struct {
+	CertID ocspCertID
+}
+
+type ocspTBSRequest struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	RequestList []ocspRequestEntry
+}
+
+type ocspRequest struct {
+	TBSRequest ocspTBSRequest
+}
+
+type ocspResponseBytes struct {
+	ResponseType asn1.ObjectIdentifier
+	Response     []byte
+}
+
+type ocspResponse struct {
+	Status        asn1.Enumerated
+	ResponseBytes ocspResponseBytes `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspRevokedInfo struct {
+	RevocationTime time.Time       `asn1:"generalized"`
+	Reason         asn1.Enumerated `asn1:"explicit,tag:0,optional"`
+}
+
+type ocspSingleResponse struct {
+	CertID     ocspCertID
+	Good       asn1.Flag        `asn1:"tag:0,optional"`
+	Revoked    ocspRevokedInfo  `asn1:"tag:1,optional"`
+	Unknown    asn1.Flag        `asn1:"tag:2,optional"`
+	ThisUpdate time.Time        `asn1:"generalized"`
+	NextUpdate time.Time        `asn1:"generalized,explicit,tag:0,optional"`
+	Extensions []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspResponseData struct {
+	Version     int `asn1:"explicit,tag:0,default:0,optional"`
+	ResponderID asn1.RawValue
+	ProducedAt  time.Time `asn1:"generalized"`
+	Responses   []ocspSingleResponse
+	Extensions  []pkix.Extension `asn1:"explicit,tag:1,optional"`
+}
+
+type ocspBasicResponse struct {
+	TBSResponseData    ocspResponseData
+	SignatureAlgorithm pkix.AlgorithmIdentifier
+	Signature          asn1.BitString
+	Certificates       []asn1.RawValue `asn1:"explicit,tag:0,optional"`
+}
+
+// ocspStapler - certificate handed to clients with the OCSP response of its responder
+// stapled, fetched again before the response expires
+type ocspStapler struct {
+	lock   *sync.RWMutex
+	cert   tls.Certificate
+	leaf   *x509.Certificate
+	issuer *x509.Certificate
+	client *http.Client
+	// nextUpdate of the stapled response, it is dropped once expired
+	nextUpdate time.Time
+}
+
+// globalOCSPStapler is set only when server is started with --ocsp-staple
+var globalOCSPStapler *ocspStapler
+
+// newOCSPStapler - the certificate must name an OCSP responder and be followed by the
+// certificate of its issuer in certFile, nothing is fetched yet
+func newOCSPStapler(certFile, keyFile string) (*ocspStapler, *probe.Error) {
+	cert, e := tls.LoadX509KeyPair(certFile, keyFile)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	if len(cert.Certificate) < 2 {
+		return nil, probe.NewError(errNoIssuerCertificate)
+	}
+	leaf, e := x509.ParseCertificate(cert.Certificate[0])
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	if len(leaf.OCSPServer) == 0 {
+		return nil, probe.NewError(errNoOCSPServer)
+	}
+	issuer, e := x509.ParseCertificate(cert.Certificate[1])
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return &ocspStapler{
+		lock:   &sync.RWMutex{},
+		cert:   cert,
+		leaf:   leaf,
+		issuer: issuer,
+		client: &http.Client{Timeout: ocspTimeout},
+	}, nil
+}
+
+// getCertificate - tls.Config GetCertificate, the staple is left out once it expired
+func (s *ocspStapler) getCertificate(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+	s.lock.RLock()
+	defer s.lock.RUnlock()
+	cert := s.cert
+	if !s.nextUpdate.IsZero() && time.Now().After(s.nextUpdate) {
+		cert.OCSPStaple = nil
+	}
+	return &cert, nil
+}
+
+// getCertID - id of the certificate in requests and responses
+func (s *ocspStapler) getCertID() (ocspCertID, *probe.Error) {
+	var publicKeyInfo struct {
+		Algorithm pkix.AlgorithmIdentifier
+		PublicKey asn1.BitString
+	}
+	if _, e := asn1.Unmarshal(s.issuer.RawSubjectPublicKeyInfo, &publicKeyInfo); e != nil {
+		return ocspCertID{}, probe.NewError(e)
+	}
+	nameHash := sha1.Sum(s.issuer.RawSubject)
+	keyHash := sha1.Sum(publicKeyInfo.PublicKey.RightAlign())
+	return ocspCertID{
+		HashAlgorithm:  pkix.AlgorithmIdentifier{Algorithm: ocspSHA1OID, Parameters: asn1.RawValue{Tag: asn1.TagNull}},
+		IssuerNameHash: nameHash[:],
+		IssuerKeyHash:  keyHash[:],
+		SerialNumber:   s.leaf.SerialNumber,
+	}, nil
+}
+
+// fetch - post a request to the first responder of the certificate, returns the raw
+// response along with its single response for the certificate
+func (s *ocspStapler) fetch() ([]byte, ocspSingleResponse, *probe.Error) {
+	certID, err := s.getCertID()
+	if err != nil {
+		return nil, ocspSingleResponse{}, err.Trace()
+	}
+	request, e := asn1.Marshal(ocspRequest{TBSRequest: ocspTBSRequest{RequestList: []ocspRequestEntry{{CertID: certID}}}})
+	if e != nil {
+		return nil, ocspSingleResponse{}, probe.NewError(e)
+	}
+	req, e := http.NewRequest("POST", s.leaf.OCSPServer[0], bytes.NewReader(request))
+	if e != nil {
+		return nil, ocspSingleResponse{}, probe.NewError(e)
+	}
+	req.Header.Set("Content-Type", "application/ocsp-request")
+	req.Header.Set("Accept", "application/ocsp-response")
+	resp, e := s.client.Do(req)
+	if e != nil {
+		return nil, ocspSingleResponse{}, probe.NewError(e).Trace(s.leaf.OCSPServer[0])
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, ocspSingleResponse{}, probe.NewError(errInvalidOCSPResponse).Trace(s.leaf.OCSPServer[0], resp.Status)
+	}
+	raw, e := ioutil.ReadAll(io.LimitReader(resp.Body, maxOCSPResponseSize))
+	if e != nil {
+		return nil, ocspSingleResponse{}, probe.NewError(e).Trace(s.leaf.OCSPServer[0])
+	}
+	single, err := parseOCSPResponse(raw, certID, time.Now())
+	if err != nil {
+		return nil, ocspSingleResponse{}, err.Trace(s.leaf.OCSPServer[0])
+	}
+	return raw, single, nil
+}
+
+// parseOCSPResponse - single response for certID of a successful basic response which is
+// current at now, revoked certificates are stapled as well. Signatures are checked by clients
+// against the issuer, as for any staple
+func parseOCSPResponse(raw []byte, certID ocspCertID, now time.Time) (ocspSingleResponse, *probe.Error) {
+	var response ocspResponse
+	if rest, e := asn1.Unmarshal(raw, &response); e != nil || len(rest) > 0 {
+		return ocspSingleResponse{}, probe.NewError(errInvalidOCSPResponse)
+	}
+	// 0 - successful, anything else has no response bytes
+	if response.Status != 0 || !response.ResponseBytes.ResponseType.Equal(ocspBasicResponseOID) {
+		return ocspSingleResponse{}, probe.NewError(errInvalidOCSPResponse)
+	}
+	var basic ocspBasicResponse
+	if rest, e := asn1.Unmarshal(response.ResponseBytes.Response, &basic); e != nil || len(rest) > 0 {
+		return ocspSingleResponse{}, probe.NewError(errInvalidOCSPResponse)
+	}
+	for _, single := range basic.TBSResponseData.Responses {
+		if single.CertID.SerialNumber == nil || single.CertID.SerialNumber.Cmp(certID.SerialNumber) != 0 ||
+			!single.CertID.HashAlgorithm.Algorithm.Equal(certID.HashAlgorithm.Algorithm) ||
+			!bytes.Equal(single.CertID.IssuerNameHash, certID.IssuerNameHash) ||
+			!bytes.Equal(single.CertID.IssuerKeyHash, certID.IssuerKeyHash) {
+			continue
+		}
+		// unknown status tells clients nothing, responses from the future or expired ones are refused
+		if bool(single.Unknown) || single.ThisUpdate.After(now) || (!single.NextUpdate.IsZero() && single.NextUpdate.Before(now)) {
+			return ocspSingleResponse{}, probe.NewError(errInvalidOCSPResponse)
+		}
+		return single, nil
+	}
+	return ocspSingleResponse{}, probe.NewError(errInvalidOCSPResponse)
+}
+
+// refresh - fetch and staple a new response, returns when to refresh next. A failed fetch
+// keeps the stapled response until it expires, the certificate is then served without one
+func (s *ocspStapler) refresh() (time.Duration, *probe.Error) {
+	raw, single, err := s.fetch()
+	if err != nil {
+		return ocspRetryInterval, err.Trace()
+	}
+	s.lock.Lock()
+	s.cert.OCSPStaple = raw
+	s.nextUpdate = single.NextUpdate
+	s.lock.Unlock()
+	if single.NextUpdate.IsZero() {
+		return ocspRefreshInterval, nil
+	}
+	// halfway to the next update leaves time for retries before the response expires
+	refreshIn := single.NextUpdate.Sub(time.Now()) / 2
+	if refreshIn < ocspRetryInterval {
+		refreshIn = ocspRetryInterval
+	}
+	return refreshIn, nil
+}
+
+// refreshPeriodically - keep the staple fresh for as long as the server runs, starting after
+// the first refresh
+func (s *ocspStapler) refreshPeriodically(refreshIn time.Duration) {
+	for {
+		time.Sleep(refreshIn)
+		var err *probe.Error
+		refreshIn, err = s.refresh()
+		errorIf(err.Trace(), "Unable to fetch OCSP response, the stapled response is served until it expires.", nil)
+	}
+}
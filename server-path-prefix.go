@@ -0,0 +1,84 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/url"
+	"strings"
+)
+
+// globalPathPrefix is set by startServer with --path-prefix, requests are served at the root
+// by default
+var globalPathPrefix string
+
+// normalizePathPrefix - prefix starting with a slash and without a trailing one, empty for the root
+func normalizePathPrefix(prefix string) string {
+	prefix = strings.Trim(prefix, "/")
+	if prefix == "" {
+		return ""
+	}
+	return "/" + prefix
+}
+
+// getPrefixedPath - path of a request as sent by clients, before the prefix was stripped
+func getPrefixedPath(path string) string {
+	return globalPathPrefix + path
+}
+
+// getSignedRequest - request whose path is the one clients signed, the prefix included
+func getSignedRequest(req *http.Request) *http.Request {
+	if globalPathPrefix == "" {
+		return req
+	}
+	signedReq := new(http.Request)
+	*signedReq = *req
+	signedURL := new(url.URL)
+	*signedURL = *req.URL
+	signedURL.Path = getPrefixedPath(req.URL.Path)
+	signedURL.RawPath = ""
+	signedReq.URL = signedURL
+	return signedReq
+}
+
+type pathPrefixHandler struct {
+	handler http.Handler
+}
+
+// PathPrefixHandler strips --path-prefix off request paths before routing, requests outside
+// of it are not found
+func PathPrefixHandler(h http.Handler) http.Handler {
+	return pathPrefixHandler{h}
+}
+
+func (h pathPrefixHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	prefix := globalPathPrefix
+	if prefix == "" {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	if r.URL.Path != prefix && !strings.HasPrefix(r.URL.Path, prefix+"/") {
+		http.NotFound(w, r)
+		return
+	}
+	r.URL.Path = strings.TrimPrefix(r.URL.Path, prefix)
+	if r.URL.Path == "" {
+		r.URL.Path = "/"
+	}
+	r.URL.RawPath = ""
+	h.handler.ServeHTTP(w, r)
+}
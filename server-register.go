@@ -0,0 +1,112 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"fmt"
+	"net"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+const (
+	// registerBackoff - wait before the first retry, doubled for every further retry
+	registerBackoff = time.Second
+	// registerMaxBackoff - longest wait between retries
+	registerMaxBackoff = 30 * time.Second
+)
+
+// registerMessage - attempt of a server to register with its controller
+type registerMessage struct {
+	Controller string `json:"controller"`
+	Attempt    int    `json:"attempt"`
+	Registered bool   `json:"registered"`
+	Error      string `json:"error,omitempty"`
+	RetryIn    string `json:"retryIn,omitempty"`
+}
+
+func (r registerMessage) String() string {
+	switch {
+	case r.Registered:
+		return fmt.Sprintf("Registered with controller %s, attempt %d.", r.Controller, r.Attempt)
+	case r.RetryIn != "":
+		return fmt.Sprintf("Unable to register with controller %s, attempt %d: %s. Retrying in %s.", r.Controller, r.Attempt, r.Error, r.RetryIn)
+	}
+	return fmt.Sprintf("Unable to register with controller %s, attempt %d: %s. Giving up.", r.Controller, r.Attempt, r.Error)
+}
+
+// JSON - json formatted output
+func (r registerMessage) JSON() string {
+	return jsonMessage(r)
+}
+
+// controllerRegistration - server registering itself with a controller once it started, retried
+// with backoff so servers started before their controller still join it
+type controllerRegistration struct {
+	controller string
+	host       string
+	ssl        bool
+	// window - give up once retrying would take longer than this, '0' retries until registered
+	window     time.Duration
+	backoff    time.Duration
+	maxBackoff time.Duration
+}
+
+// newControllerRegistration - register the server reachable at host with controller, port 9001
+// is used for a controller without one
+func newControllerRegistration(controller, host string, ssl bool, window time.Duration) controllerRegistration {
+	if _, _, err := net.SplitHostPort(controller); err != nil {
+		controller = net.JoinHostPort(controller, "9001")
+	}
+	return controllerRegistration{
+		controller: controller,
+		host:       host,
+		ssl:        ssl,
+		window:     window,
+		backoff:    registerBackoff,
+		maxBackoff: registerMaxBackoff,
+	}
+}
+
+// register - register until the controller accepts the server or the window is over, every
+// attempt is logged
+func (r controllerRegistration) register() *probe.Error {
+	deadline := time.Now().Add(r.window)
+	backoff := r.backoff
+	for attempt := 1; ; attempt++ {
+		var rep ServerRep
+		err := callRPC("Controller.AddServer", r.controller, r.ssl, ControllerArgs{Host: r.host, SSL: r.ssl}, &rep)
+		msg := registerMessage{Controller: r.controller, Attempt: attempt}
+		if err == nil {
+			msg.Registered = true
+			printMessage(msg)
+			return nil
+		}
+		msg.Error = err.ToGoError().Error()
+		if r.window > 0 && time.Now().Add(backoff).After(deadline) {
+			printMessage(msg)
+			return err.Trace(r.controller)
+		}
+		msg.RetryIn = backoff.String()
+		printMessage(msg)
+		time.Sleep(backoff)
+		if backoff *= 2; backoff > r.maxBackoff {
+			backoff = r.maxBackoff
+		}
+	}
+}
@@ -0,0 +1,576 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"hash/fnv"
+	"io"
+	"net/http"
+	"net/url"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/minio/minio-xl/pkg/crypto/sha256"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// maximum size of replication configuration accepted
+const maxReplicationConfigSize = 64 * 1024
+
+// replication configuration is saved as bucket metadata under these keys
+const (
+	replicationRuleIDKey     = "replicationRuleID"
+	replicationRuleStatusKey = "replicationRuleStatus"
+	replicationEndpointKey   = "replicationEndpoint"
+	replicationBucketKey     = "replicationBucket"
+	replicationAccessKeyKey  = "replicationAccessKey"
+	replicationSecretKeyKey  = "replicationSecretKey"
+)
+
+// replication status of an object is saved as object metadata under this key
+const replicationStatusKey = "replicationStatus"
+
+// replication status of an object, returned in 'x-amz-replication-status'
+const (
+	replicationPending   = "PENDING"
+	replicationCompleted = "COMPLETED"
+	replicationFailed    = "FAILED"
+)
+
+const (
+	// replicationWorkers - objects replicated concurrently, operations on the same object
+	// are always handled by the same worker in the order they happened
+	replicationWorkers = 4
+	// replicationQueueSize - operations waiting for every worker
+	replicationQueueSize = 1000
+	// replicationMaxAttempts - attempts before an object is marked failed
+	replicationMaxAttempts = 5
+	// replicationResyncInterval - pending and failed objects are queued again this often
+	replicationResyncInterval = time.Hour
+)
+
+// replicationRetryDelay - delay before the first retry, doubled on every further attempt
+var replicationRetryDelay = time.Second
+
+// replicationARNPrefix - destination bucket may be given as an S3 bucket ARN
+const replicationARNPrefix = "arn:aws:s3:::"
+
+// emptySHA256 - hex encoded sha256 of an empty payload
+const emptySHA256 = "e3b0c44298fc1c149afbf4c8996fb92427ae41e4649b934ca495991b7852b855"
+
+var errReplicationQueueFull = errors.New("Replication queue is full")
+
+// hasReplicationFilter - rule is limited to a prefix, which is not supported yet
+func hasReplicationFilter(replicationConfig ReplicationConfiguration) bool {
+	for _, rule := range replicationConfig.Rule {
+		if rule.Prefix != "" || (rule.Filter != nil && rule.Filter.Prefix != "") {
+			return true
+		}
+	}
+	return false
+}
+
+// isValidReplicationConfig - a single rule to a bucket of an http(s) endpoint, with both
+// credentials or none for targets accepting anonymous writes
+func isValidReplicationConfig(replicationConfig ReplicationConfiguration) bool {
+	if len(replicationConfig.Rule) != 1 {
+		return false
+	}
+	rule := replicationConfig.Rule[0]
+	if rule.Status != "Enabled" && rule.Status != "Disabled" {
+		return false
+	}
+	destination := rule.Destination
+	if !xl.IsValidBucket(strings.TrimPrefix(destination.Bucket, replicationARNPrefix)) {
+		return false
+	}
+	endpoint, e := url.Parse(destination.Endpoint)
+	if e != nil || (endpoint.Scheme != "http" && endpoint.Scheme != "https") || endpoint.Host == "" || endpoint.RawQuery != "" {
+		return false
+	}
+	return (destination.AccessKeyID == "") == (destination.SecretAccessKey == "")
+}
+
+// getReplicationMetadata - bucket metadata for a replication configuration, nil configuration
+// removes it
+func getReplicationMetadata(replicationConfig *ReplicationConfiguration) map[string]string {
+	metadata := map[string]string{
+		replicationRuleIDKey:     "",
+		replicationRuleStatusKey: "",
+		replicationEndpointKey:   "",
+		replicationBucketKey:     "",
+		replicationAccessKeyKey:  "",
+		replicationSecretKeyKey:  "",
+	}
+	if replicationConfig == nil {
+		return metadata
+	}
+	rule := replicationConfig.Rule[0]
+	metadata[replicationRuleIDKey] = rule.ID
+	metadata[replicationRuleStatusKey] = rule.Status
+	metadata[replicationEndpointKey] = strings.TrimSuffix(rule.Destination.Endpoint, "/")
+	metadata[replicationBucketKey] = strings.TrimPrefix(rule.Destination.Bucket, replicationARNPrefix)
+	metadata[replicationAccessKeyKey] = rule.Destination.AccessKeyID
+	metadata[replicationSecretKeyKey] = rule.Destination.SecretAccessKey
+	return metadata
+}
+
+// getReplicationConfig - replication configuration from bucket metadata without the secret key,
+// returns false if bucket has none
+func getReplicationConfig(bucketMetadata xl.BucketMetadata) (ReplicationConfiguration, bool) {
+	endpoint, ok := bucketMetadata.Metadata[replicationEndpointKey]
+	if !ok {
+		return ReplicationConfiguration{}, false
+	}
+	rule := ReplicationRule{
+		ID:     bucketMetadata.Metadata[replicationRuleIDKey],
+		Status: bucketMetadata.Metadata[replicationRuleStatusKey],
+		Destination: ReplicationDestination{
+			Bucket:      replicationARNPrefix + bucketMetadata.Metadata[replicationBucketKey],
+			Endpoint:    endpoint,
+			AccessKeyID: bucketMetadata.Metadata[replicationAccessKeyKey],
+		},
+	}
+	return ReplicationConfiguration{Rule: []ReplicationRule{rule}}, true
+}
+
+// replicationTarget - bucket objects are replicated to
+type replicationTarget struct {
+	endpoint        string
+	bucket          string
+	accessKeyID     string
+	secretAccessKey string
+}
+
+// getReplicationTarget - target of a bucket with an enabled replication rule
+func getReplicationTarget(bucketMetadata xl.BucketMetadata) (replicationTarget, bool) {
+	endpoint, ok := bucketMetadata.Metadata[replicationEndpointKey]
+	if !ok || bucketMetadata.Metadata[replicationRuleStatusKey] != "Enabled" {
+		return replicationTarget{}, false
+	}
+	return replicationTarget{
+		endpoint:        endpoint,
+		bucket:          bucketMetadata.Metadata[replicationBucketKey],
+		accessKeyID:     bucketMetadata.Metadata[replicationAccessKeyKey],
+		secretAccessKey: bucketMetadata.Metadata[replicationSecretKeyKey],
+	}, true
+}
+
+// newRequest - path style request for object of the target bucket, signature v4 of hashedPayload
+// unless the target accepts anonymous requests
+func (t replicationTarget) newRequest(method, object string, body io.Reader, hashedPayload string) (*http.Request, *probe.Error) {
+	req, e := http.NewRequest(method, t.endpoint, body)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	req.URL.Path = strings.TrimSuffix(req.URL.Path, "/") + "/" + t.bucket + "/" + object
+	req.URL.RawPath = strings.Replace(getURLEncodedName(req.URL.Path), "+", "%20", -1)
+	if t.accessKeyID == "" {
+		return req, nil
+	}
+
+	date := time.Now().UTC()
+	req.Header.Set("X-Amz-Date", date.Format(iso8601Format))
+	req.Header.Set("X-Amz-Content-Sha256", hashedPayload)
+	headers := []string{"host"}
+	for key := range req.Header {
+		if !ignoredHeaders[http.CanonicalHeaderKey(key)] {
+			headers = append(headers, strings.ToLower(key))
+		}
+	}
+	sort.Strings(headers)
+	var canonicalHeaders bytes.Buffer
+	for _, key := range headers {
+		value := req.URL.Host
+		if key != "host" {
+			value = strings.Join(req.Header[http.CanonicalHeaderKey(key)], ",")
+		}
+		canonicalHeaders.WriteString(key + ":" + strings.TrimSpace(value) + "\n")
+	}
+	signedHeaders := strings.Join(headers, ";")
+	canonicalRequest := strings.Join([]string{
+		method,
+		req.URL.RawPath,
+		"",
+		canonicalHeaders.String(),
+		signedHeaders,
+		hashedPayload,
+	}, "\n")
+
+	scope := strings.Join([]string{date.Format(yyyymmdd), "us-east-1", "s3", "aws4_request"}, "/")
+	stringToSign := authHeaderPrefix + "\n" + date.Format(iso8601Format) + "\n" + scope + "\n" +
+		hex.EncodeToString(sum256([]byte(canonicalRequest)))
+	signingKey := sumHMAC([]byte("AWS4"+t.secretAccessKey), []byte(date.Format(yyyymmdd)))
+	signingKey = sumHMAC(signingKey, []byte("us-east-1"))
+	signingKey = sumHMAC(signingKey, []byte("s3"))
+	signingKey = sumHMAC(signingKey, []byte("aws4_request"))
+	signature := hex.EncodeToString(sumHMAC(signingKey, []byte(stringToSign)))
+	req.Header.Set("Authorization", authHeaderPrefix+" Credential="+t.accessKeyID+"/"+scope+", SignedHeaders="+signedHeaders+
+		", Signature="+signature)
+	return req, nil
+}
+
+// replicationOp - object to replicate, or to delete on the target as long as it has etag there
+type replicationOp struct {
+	bucket   string
+	object   string
+	delete   bool
+	etag     string
+	attempts int
+}
+
+// key - operations already queued are not queued again
+func (op replicationOp) key() string {
+	if op.delete {
+		return "delete/" + op.bucket + "/" + op.object
+	}
+	return "put/" + op.bucket + "/" + op.object
+}
+
+// replicator - replicates objects of buckets with a replication configuration in the background
+type replicator struct {
+	storage xl.Interface
+	client  *http.Client
+	queues  []chan replicationOp
+	done    chan struct{}
+
+	// ops already queued
+	lock   sync.Mutex
+	queued map[string]bool
+}
+
+// globalReplicator is set by startServer, no object is replicated while it is nil
+var globalReplicator *replicator
+
+// newReplicator - new replicator of objects of storage, nothing is replicated until it is started
+func newReplicator(storage xl.Interface) *replicator {
+	r := &replicator{
+		storage: storage,
+		client:  &http.Client{},
+		done:    make(chan struct{}),
+		queued:  make(map[string]bool),
+	}
+	for i := 0; i < replicationWorkers; i++ {
+		r.queues = append(r.queues, make(chan replicationOp, replicationQueueSize))
+	}
+	return r
+}
+
+// start - start workers, objects still pending or failed are queued again right away and
+// then every replicationResyncInterval
+func (r *replicator) start() {
+	for _, queue := range r.queues {
+		go r.worker(queue)
+	}
+	go func() {
+		ticker := time.NewTicker(replicationResyncInterval)
+		defer ticker.Stop()
+		for {
+			r.resync()
+			select {
+			case <-ticker.C:
+			case <-r.done:
+				return
+			}
+		}
+	}()
+}
+
+// stop - stop workers, queued operations are dropped
+func (r *replicator) stop() {
+	close(r.done)
+}
+
+// objectCreated - replicate a new object if its bucket is replicated, the object is pending
+// until it is on the target
+func (r *replicator) objectCreated(bucket, object string) {
+	if r == nil {
+		return
+	}
+	if _, ok := r.getTarget(bucket); !ok {
+		return
+	}
+	if _, err := r.storage.SetObjectMetadata(bucket, object, map[string]string{replicationStatusKey: replicationPending}); err != nil {
+		errorIf(err.Trace(bucket, object), "Unable to set replication status.", nil)
+		return
+	}
+	r.enqueue(replicationOp{bucket: bucket, object: object})
+}
+
+// objectDeleted - delete an object with etag on the target if its bucket is replicated, an
+// empty etag deletes whatever the target has
+func (r *replicator) objectDeleted(bucket, object, etag string) {
+	if r == nil {
+		return
+	}
+	if _, ok := r.getTarget(bucket); !ok {
+		return
+	}
+	r.enqueue(replicationOp{bucket: bucket, object: object, delete: true, etag: etag})
+}
+
+// getTarget - target of a replicated bucket
+func (r *replicator) getTarget(bucket string) (replicationTarget, bool) {
+	bucketMetadata, err := r.storage.GetBucketMetadata(bucket)
+	if err != nil {
+		return replicationTarget{}, false
+	}
+	return getReplicationTarget(bucketMetadata)
+}
+
+// queue - worker handling all operations on an object
+func (r *replicator) queue(op replicationOp) chan replicationOp {
+	h := fnv.New32a()
+	h.Write([]byte(op.bucket + "/" + op.object))
+	return r.queues[h.Sum32()%uint32(len(r.queues))]
+}
+
+// enqueue - queue an operation unless it is already, never blocks. Objects which do not fit
+// stay pending and are queued again by the next resync
+func (r *replicator) enqueue(op replicationOp) {
+	r.lock.Lock()
+	if r.queued[op.key()] {
+		r.lock.Unlock()
+		return
+	}
+	r.queued[op.key()] = true
+	r.lock.Unlock()
+	select {
+	case r.queue(op) <- op:
+	default:
+		r.dequeue(op)
+		errorIf(probe.NewError(errReplicationQueueFull).Trace(op.bucket, op.object), "Unable to queue replication.", nil)
+	}
+}
+
+// dequeue - operation is done, it can be queued again
+func (r *replicator) dequeue(op replicationOp) {
+	r.lock.Lock()
+	defer r.lock.Unlock()
+	delete(r.queued, op.key())
+}
+
+func (r *replicator) worker(queue chan replicationOp) {
+	for {
+		select {
+		case op := <-queue:
+			r.process(op)
+		case <-r.done:
+			return
+		}
+	}
+}
+
+// process - replicate op, failed attempts are retried with a growing delay before the object
+// is marked failed
+func (r *replicator) process(op replicationOp) {
+	target, ok := r.getTarget(op.bucket)
+	if !ok {
+		// replication was disabled meanwhile
+		r.dequeue(op)
+		return
+	}
+	var err *probe.Error
+	if op.delete {
+		err = r.replicateDelete(target, op)
+	} else {
+		err = r.replicatePut(target, op)
+	}
+	if err == nil {
+		r.dequeue(op)
+		return
+	}
+	op.attempts++
+	if op.attempts < replicationMaxAttempts {
+		time.AfterFunc(replicationRetryDelay<<uint(op.attempts-1), func() {
+			select {
+			case r.queue(op) <- op:
+			case <-r.done:
+			}
+		})
+		return
+	}
+	r.dequeue(op)
+	errorIf(err.Trace(op.bucket, op.object, target.endpoint), "Replication failed.", nil)
+	if op.delete {
+		return
+	}
+	if _, err := r.storage.SetObjectMetadata(op.bucket, op.object, map[string]string{replicationStatusKey: replicationFailed}); err != nil {
+		errorIf(err.Trace(op.bucket, op.object), "Unable to set replication status.", nil)
+	}
+}
+
+// replicatePut - PUT the object to the target, objects the target already has are not sent again
+func (r *replicator) replicatePut(target replicationTarget, op replicationOp) *probe.Error {
+	metadata, err := r.storage.GetObjectMetadata(op.bucket, op.object)
+	if err != nil {
+		if _, ok := err.ToGoError().(xl.ObjectNotFound); ok {
+			// deleted meanwhile, the delete is replicated on its own
+			return nil
+		}
+		return err.Trace()
+	}
+	if metadata.Metadata[replicationStatusKey] == replicationCompleted {
+		return nil
+	}
+	etag, err := r.headTarget(target, op.object)
+	if err != nil {
+		return err.Trace()
+	}
+	if etag != metadata.MD5Sum {
+		if err := r.putTarget(target, metadata); err != nil {
+			return err.Trace()
+		}
+	}
+	if _, err := r.storage.SetObjectMetadata(op.bucket, op.object, map[string]string{replicationStatusKey: replicationCompleted}); err != nil {
+		return err.Trace()
+	}
+	return nil
+}
+
+// headTarget - etag of object on the target, empty if the target does not have it
+func (r *replicator) headTarget(target replicationTarget, object string) (string, *probe.Error) {
+	req, err := target.newRequest("HEAD", object, nil, emptySHA256)
+	if err != nil {
+		return "", err.Trace()
+	}
+	resp, e := r.client.Do(req)
+	if e != nil {
+		return "", probe.NewError(e)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK:
+		return strings.Trim(resp.Header.Get("ETag"), "\""), nil
+	case http.StatusNotFound:
+		return "", nil
+	}
+	return "", probe.NewError(fmt.Errorf("Replication target answered %s", resp.Status))
+}
+
+// putTarget - send the object, the payload is signed so the object is read once to hash it and
+// once more to send it
+func (r *replicator) putTarget(target replicationTarget, metadata xl.ObjectMetadata) *probe.Error {
+	hash := sha256.New()
+	if _, err := r.storage.GetObject(hash, metadata.Bucket, metadata.Object, 0, 0); err != nil {
+		return err.Trace()
+	}
+	reader, writer := io.Pipe()
+	defer reader.Close()
+	go func() {
+		if _, err := r.storage.GetObject(writer, metadata.Bucket, metadata.Object, 0, 0); err != nil {
+			writer.CloseWithError(err.ToGoError())
+			return
+		}
+		writer.Close()
+	}()
+	req, err := target.newRequest("PUT", metadata.Object, reader, hex.EncodeToString(hash.Sum(nil)))
+	if err != nil {
+		return err.Trace()
+	}
+	req.ContentLength = metadata.Size
+	if contentType := metadata.Metadata["contentType"]; contentType != "" {
+		req.Header.Set("Content-Type", contentType)
+	}
+	// md5 of an object uploaded in parts is unknown
+	if md5Sum, e := hex.DecodeString(metadata.MD5Sum); e == nil && len(md5Sum) == 16 {
+		req.Header.Set("Content-Md5", base64.StdEncoding.EncodeToString(md5Sum))
+	}
+	if _, ok := metadata.Metadata["mtime"]; ok {
+		req.Header.Set("X-Amz-Meta-Mtime", strconv.FormatFloat(float64(metadata.Created.UnixNano())/1e9, 'f', -1, 64))
+	}
+	for key, value := range metadata.Metadata {
+		if xl.IsUserMetadata(key) {
+			req.Header[key] = []string{value}
+		}
+	}
+	resp, e := r.client.Do(req)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return probe.NewError(fmt.Errorf("Replication target answered %s", resp.Status))
+	}
+	return nil
+}
+
+// replicateDelete - DELETE the object on the target, objects the target does not have or
+// has with another etag are left alone
+func (r *replicator) replicateDelete(target replicationTarget, op replicationOp) *probe.Error {
+	req, err := target.newRequest("DELETE", op.object, nil, emptySHA256)
+	if err != nil {
+		return err.Trace()
+	}
+	if op.etag == "" {
+		req.Header.Set("If-Match", "*")
+	} else {
+		req.Header.Set("If-Match", "\""+op.etag+"\"")
+	}
+	resp, e := r.client.Do(req)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	resp.Body.Close()
+	switch resp.StatusCode {
+	case http.StatusOK, http.StatusNoContent, http.StatusNotFound, http.StatusPreconditionFailed:
+		return nil
+	}
+	return probe.NewError(fmt.Errorf("Replication target answered %s", resp.Status))
+}
+
+// resync - queue objects of replicated buckets which are still pending or failed, objects
+// created before their bucket was replicated are not replicated
+func (r *replicator) resync() {
+	buckets, err := r.storage.ListBuckets()
+	if err != nil {
+		errorIf(err.Trace(), "Unable to list buckets for replication.", nil)
+		return
+	}
+	for _, bucket := range buckets {
+		if _, ok := getReplicationTarget(bucket); !ok {
+			continue
+		}
+		resources := xl.BucketResourcesMetadata{Maxkeys: 1000}
+		for {
+			results, nextResources, err := r.storage.ListObjects(bucket.Name, resources)
+			if err != nil {
+				errorIf(err.Trace(bucket.Name), "Unable to list objects for replication.", nil)
+				break
+			}
+			for _, object := range results {
+				switch object.Metadata[replicationStatusKey] {
+				case replicationPending, replicationFailed:
+					r.enqueue(replicationOp{bucket: bucket.Name, object: object.Object})
+				}
+			}
+			if !nextResources.IsTruncated || len(results) == 0 {
+				break
+			}
+			resources.Marker = results[len(results)-1].Object
+		}
+	}
+}
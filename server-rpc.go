@@ -17,38 +17,63 @@
 package main
 
 import (
+	"mime"
 	"net/http"
 	"os"
 	"runtime"
+	"sort"
+	"strconv"
 
 	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+	"github.com/minio/minio-xl/pkg/xl/disk"
 )
 
-type serverRPCService struct{}
+type serverRPCService struct {
+	XL xl.Interface
+	// anonymous servers take unsigned requests, admin only methods are refused
+	anonymous bool
+	// refuses controllers which lost their lease to a peer
+	fence *controllerFence
+}
 
 func (s *serverRPCService) Add(r *http.Request, arg *ServerArg, rep *ServerRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
 	rep.Host = "192.168.1.1:9002"
 	rep.ID = "6F27CB16-493D-40FA-B035-2A2E5646066A"
 	return nil
 }
 
 func (s *serverRPCService) MemStats(r *http.Request, arg *ServerArg, rep *MemStatsRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
 	rep.Total = 64 * 1024 * 1024 * 1024
 	rep.Free = 9 * 1024 * 1024 * 1024
+	rep.InFlight = uint64(globalMemoryAccountant.getInFlight())
 	return nil
 }
 
 func (s *serverRPCService) DiskStats(r *http.Request, arg *ServerArg, rep *DiskStatsRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
 	rep.Disks = []string{"/mnt/disk1", "/mnt/disk2", "/mnt/disk3", "/mnt/disk4", "/mnt/disk5", "/mnt/disk6"}
 	return nil
 }
 
 func (s *serverRPCService) SysInfo(r *http.Request, arg *ServerArg, rep *SysInfoRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
 	rep.SysARCH = runtime.GOARCH
 	rep.SysOS = runtime.GOOS
 	rep.SysCPUS = runtime.NumCPU()
 	rep.Routines = runtime.NumGoroutine()
 	rep.GOVersion = runtime.Version()
+	rep.RPCConns = globalRPCConnTracker.getOpenConns()
 	var err error
 	rep.Hostname, err = os.Hostname()
 	if err != nil {
@@ -58,15 +83,174 @@ func (s *serverRPCService) SysInfo(r *http.Request, arg *ServerArg, rep *SysInfo
 }
 
 func (s *serverRPCService) NetStats(r *http.Request, arg *ServerArg, rep *NetStatsRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
 	rep.Interfaces = make([]Network, 0)
 	rep.Interfaces = append(rep.Interfaces, Network{Address: "192.168.1.1", NetMask: "255.255.255.0", Hostname: "hostname1", Ethernet: "eth0"})
 	return nil
 }
 
 func (s *serverRPCService) Version(r *http.Request, arg *ServerArg, rep *VersionRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
 	rep.Version = "0.0.1"
 	rep.BuildDate = minioXLVersion
 	rep.Architecture = runtime.GOARCH
 	rep.OperatingSystem = runtime.GOOS
 	return nil
 }
+
+const (
+	// diskProbeStat - disks are only stat'ed
+	diskProbeStat = "stat"
+	// diskProbeWrite - disks are stat'ed and a test file is written, except on read-only mounts
+	diskProbeWrite = "write"
+)
+
+// globalDiskProbe is set by startServer from --disk-probe
+var globalDiskProbe = diskProbeWrite
+
+// getDiskUsage usage of a disk, disks which cannot be stat'ed or fail the test write are
+// reported as not usable. Disks mounted read-only are never written to.
+func getDiskUsage(diskPath string) DiskUsageRep {
+	usage := DiskUsageRep{Path: diskPath, Probe: diskProbeStat}
+	d, err := disk.New(diskPath)
+	if err != nil || !d.IsUsable() {
+		return usage
+	}
+	if globalDiskProbe == diskProbeWrite && !d.IsReadOnly() {
+		usage.Probe = diskProbeWrite
+		if _, err := isUsable(diskPath); err != nil {
+			return usage
+		}
+	}
+	fsInfo := d.GetFSInfo()
+	if fsInfo == nil {
+		return usage
+	}
+	usage.Total, _ = strconv.ParseInt(fsInfo["TotalB"], 10, 64)
+	usage.Free, _ = strconv.ParseInt(fsInfo["FreeB"], 10, 64)
+	usage.Usable = true
+	return usage
+}
+
+// countObjects total number of buckets and objects stored
+func countObjects(storage xl.Interface) (int, int64, *probe.Error) {
+	buckets, err := storage.ListBuckets()
+	if err != nil {
+		return 0, 0, err.Trace()
+	}
+	var objects int64
+	for _, bucket := range buckets {
+		resources := xl.BucketResourcesMetadata{Maxkeys: 1000}
+		for {
+			var results []xl.ObjectMetadata
+			results, resources, err = storage.ListObjects(bucket.Name, resources)
+			if err != nil {
+				return 0, 0, err.Trace(bucket.Name)
+			}
+			objects += int64(len(results))
+			if !resources.IsTruncated || len(results) == 0 {
+				break
+			}
+			resources.Marker = results[len(results)-1].Object
+		}
+	}
+	return len(buckets), objects, nil
+}
+
+// BucketStats reports number of objects and their total size of a bucket, or of all buckets
+func (s *serverRPCService) BucketStats(r *http.Request, arg *BucketStatsArgs, rep *BucketStatsRep) error {
+	buckets := []string{arg.Bucket}
+	if arg.Bucket == "" {
+		metadata, err := s.XL.ListBuckets()
+		if err != nil {
+			return probe.WrapError(err.Trace())
+		}
+		buckets = nil
+		for _, bucket := range metadata {
+			buckets = append(buckets, bucket.Name)
+		}
+	}
+	rep.Buckets = []xl.BucketStats{}
+	for _, bucket := range buckets {
+		stats, err := s.XL.GetBucketStats(bucket)
+		if err != nil {
+			return probe.WrapError(err.Trace(bucket))
+		}
+		rep.Buckets = append(rep.Buckets, stats)
+	}
+	return nil
+}
+
+// DeleteByQuery deletes all objects of a bucket whose user metadata matches, only for requests
+// signed with admin credentials
+func (s *serverRPCService) DeleteByQuery(r *http.Request, arg *DeleteByQueryArgs, rep *DeleteByQueryRep) error {
+	if s.anonymous {
+		return probe.WrapError(probe.NewError(errAdminCredentialsRequired))
+	}
+	reply, err := deleteByQuery(s.XL, *arg)
+	if err != nil {
+		return probe.WrapError(err.Trace())
+	}
+	*rep = reply
+	return nil
+}
+
+// SetDefaultContentType sets the default content type of a bucket on this server, applied to
+// uploads from now on without a restart, only for requests signed with admin credentials
+func (s *serverRPCService) SetDefaultContentType(r *http.Request, arg *DefaultContentTypeArgs, rep *DefaultContentTypeRep) error {
+	if s.anonymous {
+		return probe.WrapError(probe.NewError(errAdminCredentialsRequired))
+	}
+	if arg.ContentType != "" {
+		if _, _, e := mime.ParseMediaType(arg.ContentType); e != nil {
+			return probe.WrapError(probe.NewError(e))
+		}
+	}
+	if err := s.XL.SetBucketMetadata(arg.Bucket, map[string]string{xl.DefaultContentTypeKey: arg.ContentType}); err != nil {
+		return probe.WrapError(err.Trace(arg.Bucket))
+	}
+	rep.Bucket = arg.Bucket
+	rep.ContentType = arg.ContentType
+	return nil
+}
+
+// Status reports disk usage, object counts and health of this server
+func (s *serverRPCService) Status(r *http.Request, arg *ServerArg, rep *ServerStatusRep) error {
+	if err := s.fence.accept(arg); err != nil {
+		return err
+	}
+	nodeDiskMap, err := s.XL.Info()
+	if err != nil {
+		return probe.WrapError(err.Trace())
+	}
+	var nodes []string
+	for node := range nodeDiskMap {
+		nodes = append(nodes, node)
+	}
+	sort.Strings(nodes)
+	rep.Healthy = true
+	for _, node := range nodes {
+		for _, diskPath := range nodeDiskMap[node] {
+			usage := getDiskUsage(diskPath)
+			if !usage.Usable {
+				rep.Healthy = false
+			}
+			rep.Disks = append(rep.Disks, usage)
+		}
+	}
+	rep.MultipartUploads = s.XL.CountMultipartUploads()
+	rep.Orphans = s.XL.GetOrphanStats()
+	rep.DiskCache = s.XL.GetDiskCacheStats()
+	rep.DiskQuorum = s.XL.GetDiskQuorumStats()
+	rep.BucketRequests = globalBucketMetrics.stats()
+	rep.Buckets, rep.Objects, err = countObjects(s.XL)
+	if err != nil {
+		rep.Healthy = false
+		rep.Error = err.ToGoError().Error()
+	}
+	return nil
+}
@@ -0,0 +1,201 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"errors"
+	"image"
+	"image/color"
+	"image/gif"
+	"image/jpeg"
+	"image/png"
+	"strconv"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// thumbnailSuffix - thumbnail of an object is stored alongside it as ‘<object>?thumb’
+const thumbnailSuffix = "?thumb"
+
+const (
+	// maxThumbnailSourceSize - larger images are not thumbnailed
+	maxThumbnailSourceSize = 32 * 1024 * 1024
+	// maxThumbnailSourcePixels - guards against decoding images which expand enormously in memory
+	maxThumbnailSourcePixels = 64 * 1024 * 1024
+)
+
+// thumbnailContentTypes - formats decoded and encoded back by the thumbnailer
+var thumbnailContentTypes = map[string]bool{
+	"image/jpeg": true,
+	"image/png":  true,
+	"image/gif":  true,
+}
+
+var errInvalidThumbnailSize = errors.New("Thumbnail size should be of the form WIDTHxHEIGHT e.g. 256x256")
+
+// thumbnailHook - stores a downscaled copy of uploaded images
+type thumbnailHook struct {
+	width  int
+	height int
+}
+
+// newThumbnailHook - instantiate a thumbnailer, size is of the form ‘256x256’
+func newThumbnailHook(size string) (thumbnailHook, *probe.Error) {
+	parts := strings.Split(strings.ToLower(size), "x")
+	if len(parts) != 2 {
+		return thumbnailHook{}, probe.NewError(errInvalidThumbnailSize)
+	}
+	width, err := strconv.Atoi(parts[0])
+	if err != nil || width <= 0 {
+		return thumbnailHook{}, probe.NewError(errInvalidThumbnailSize)
+	}
+	height, err := strconv.Atoi(parts[1])
+	if err != nil || height <= 0 {
+		return thumbnailHook{}, probe.NewError(errInvalidThumbnailSize)
+	}
+	return thumbnailHook{width: width, height: height}, nil
+}
+
+// Name - name of the hook
+func (t thumbnailHook) Name() string {
+	return "thumbnail"
+}
+
+// Matches - images of supported formats, except thumbnails themselves
+func (t thumbnailHook) Matches(object, contentType string) bool {
+	return thumbnailContentTypes[getMediaType(contentType)] && !strings.HasSuffix(object, thumbnailSuffix)
+}
+
+// Run - read back the uploaded image and store its thumbnail
+func (t thumbnailHook) Run(storage xl.Interface, bucket, object, contentType string) *probe.Error {
+	metadata, err := storage.GetObjectMetadata(bucket, object)
+	if err != nil {
+		return err.Trace()
+	}
+	if metadata.Size > maxThumbnailSourceSize {
+		return nil
+	}
+	var source bytes.Buffer
+	if _, err := storage.GetObject(&source, bucket, object, 0, 0); err != nil {
+		return err.Trace()
+	}
+	config, _, e := image.DecodeConfig(bytes.NewReader(source.Bytes()))
+	if e != nil {
+		return probe.NewError(e)
+	}
+	if config.Width*config.Height > maxThumbnailSourcePixels {
+		return nil
+	}
+	img, _, e := image.Decode(&source)
+	if e != nil {
+		return probe.NewError(e)
+	}
+	var thumbnail bytes.Buffer
+	mediaType := getMediaType(contentType)
+	if e := encodeImage(&thumbnail, resizeImage(img, t.width, t.height), mediaType); e != nil {
+		return probe.NewError(e)
+	}
+
+	// objects are immutable, a thumbnail left behind by a deleted object is replaced
+	thumbnailObject := object + thumbnailSuffix
+	size := int64(thumbnail.Len())
+	thumbnailMetadata := map[string]string{"contentType": mediaType}
+	_, err = storage.CreateObject(bucket, thumbnailObject, "", size, bytes.NewReader(thumbnail.Bytes()), thumbnailMetadata, nil)
+	if err != nil {
+		if _, ok := err.ToGoError().(xl.ObjectExists); !ok {
+			return err.Trace(thumbnailObject)
+		}
+		if err := storage.DeleteObject(bucket, thumbnailObject, ""); err != nil {
+			return err.Trace(thumbnailObject)
+		}
+		_, err = storage.CreateObject(bucket, thumbnailObject, "", size, bytes.NewReader(thumbnail.Bytes()), thumbnailMetadata, nil)
+		if err != nil {
+			return err.Trace(thumbnailObject)
+		}
+	}
+	return nil
+}
+
+// getMediaType - content type without its parameters
+func getMediaType(contentType string) string {
+	return strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+}
+
+// encodeImage - encode in the format of the original image
+func encodeImage(buffer *bytes.Buffer, img image.Image, mediaType string) error {
+	switch mediaType {
+	case "image/png":
+		return png.Encode(buffer, img)
+	case "image/gif":
+		return gif.Encode(buffer, img, nil)
+	default:
+		return jpeg.Encode(buffer, img, &jpeg.Options{Quality: 85})
+	}
+}
+
+// resizeImage - downscale to fit within width x height preserving the aspect ratio, every
+// pixel of the thumbnail averages the source pixels it covers, smaller images are not enlarged
+func resizeImage(img image.Image, width, height int) image.Image {
+	bounds := img.Bounds()
+	srcWidth, srcHeight := bounds.Dx(), bounds.Dy()
+	if srcWidth <= width && srcHeight <= height {
+		return img
+	}
+	// scale by the dimension which overflows the most
+	if srcWidth*height > srcHeight*width {
+		height = srcHeight * width / srcWidth
+	} else {
+		width = srcWidth * height / srcHeight
+	}
+	if width == 0 {
+		width = 1
+	}
+	if height == 0 {
+		height = 1
+	}
+	thumbnail := image.NewRGBA(image.Rect(0, 0, width, height))
+	for y := 0; y < height; y++ {
+		y0, y1 := bounds.Min.Y+y*srcHeight/height, bounds.Min.Y+(y+1)*srcHeight/height
+		if y1 == y0 {
+			y1++
+		}
+		for x := 0; x < width; x++ {
+			x0, x1 := bounds.Min.X+x*srcWidth/width, bounds.Min.X+(x+1)*srcWidth/width
+			if x1 == x0 {
+				x1++
+			}
+			var r, g, b, a, n uint64
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					sr, sg, sb, sa := img.At(sx, sy).RGBA()
+					r, g, b, a = r+uint64(sr), g+uint64(sg), b+uint64(sb), a+uint64(sa)
+					n++
+				}
+			}
+			thumbnail.SetRGBA(x, y, color.RGBA{
+				R: uint8(r / n >> 8),
+				G: uint8(g / n >> 8),
+				B: uint8(b / n >> 8),
+				A: uint8(a / n >> 8),
+			})
+		}
+	}
+	return thumbnail
+}
@@ -0,0 +1,120 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/tls"
+	"fmt"
+	"sort"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/probe"
+)
+
+// tlsMinVersions supported values for --tls-min-version
+var tlsMinVersions = map[string]uint16{
+	"1.0": tls.VersionTLS10,
+	"1.1": tls.VersionTLS11,
+	"1.2": tls.VersionTLS12,
+	"1.3": tls.VersionTLS13,
+}
+
+// defaultTLSCipherSuites used when --tls-ciphers is not provided, only forward secret AEAD ciphers.
+// TLS 1.3 cipher suites are not configurable and always enabled.
+var defaultTLSCipherSuites = []uint16{
+	tls.TLS_ECDHE_ECDSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384,
+	tls.TLS_ECDHE_ECDSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256,
+	tls.TLS_ECDHE_ECDSA_WITH_CHACHA20_POLY1305_SHA256,
+	tls.TLS_ECDHE_RSA_WITH_CHACHA20_POLY1305_SHA256,
+}
+
+// sortedKeys returns keys of the map in sorted order, used for error messages
+func sortedKeys(m map[string]uint16) []string {
+	var keys []string
+	for k := range m {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	return keys
+}
+
+// getTLSMinVersion parses --tls-min-version value
+func getTLSMinVersion(version string) (uint16, *probe.Error) {
+	if version == "" {
+		return tls.VersionTLS12, nil
+	}
+	v, ok := tlsMinVersions[version]
+	if !ok {
+		return 0, probe.NewError(fmt.Errorf("Invalid TLS minimum version '%s', valid options are: %s", version,
+			strings.Join(sortedKeys(tlsMinVersions), ", ")))
+	}
+	return v, nil
+}
+
+// getTLSCipherSuites parses comma separated --tls-ciphers value, cipher names are as defined by crypto/tls
+func getTLSCipherSuites(ciphers string) ([]uint16, *probe.Error) {
+	if strings.TrimSpace(ciphers) == "" {
+		return defaultTLSCipherSuites, nil
+	}
+	supportedCiphers := make(map[string]uint16)
+	for _, suite := range tls.CipherSuites() {
+		supportedCiphers[suite.Name] = suite.ID
+	}
+	var cipherSuites []uint16
+	for _, cipher := range strings.Split(ciphers, ",") {
+		cipher = strings.TrimSpace(cipher)
+		id, ok := supportedCiphers[cipher]
+		if !ok {
+			return nil, probe.NewError(fmt.Errorf("Invalid TLS cipher suite '%s', valid options are: %s", cipher,
+				strings.Join(sortedKeys(supportedCiphers), ", ")))
+		}
+		cipherSuites = append(cipherSuites, id)
+	}
+	return cipherSuites, nil
+}
+
+// getTLSConfig returns a new tls config with certificates, minimum version and cipher suites set,
+// with --ocsp-staple certificates are taken from the stapler
+func getTLSConfig(conf minioConfig) (*tls.Config, *probe.Error) {
+	minVersion, err := getTLSMinVersion(conf.TLSMinVersion)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	cipherSuites, err := getTLSCipherSuites(conf.TLSCiphers)
+	if err != nil {
+		return nil, err.Trace()
+	}
+	tlsConfig := &tls.Config{
+		MinVersion:               minVersion,
+		CipherSuites:             cipherSuites,
+		PreferServerCipherSuites: true,
+	}
+	// certificate is handed out with the current staple
+	if globalOCSPStapler != nil {
+		tlsConfig.GetCertificate = globalOCSPStapler.getCertificate
+		return tlsConfig, nil
+	}
+	tlsConfig.Certificates = make([]tls.Certificate, 1)
+	var e error
+	tlsConfig.Certificates[0], e = tls.LoadX509KeyPair(conf.CertFile, conf.KeyFile)
+	if e != nil {
+		return nil, probe.NewError(e)
+	}
+	return tlsConfig, nil
+}
@@ -0,0 +1,94 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/tracing"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// tracingHandler - span for every request, disk operations serving it are its children
+type tracingHandler struct {
+	handler http.Handler
+}
+
+// TracingHandler - trace requests once --otel-endpoint is given, requests are passed
+// through untouched otherwise
+func TracingHandler(h http.Handler) http.Handler {
+	return tracingHandler{h}
+}
+
+// tracingWriter - records the status of the response
+type tracingWriter struct {
+	http.ResponseWriter
+	status int
+}
+
+func (t *tracingWriter) WriteHeader(status int) {
+	if t.status == 0 {
+		t.status = status
+	}
+	t.ResponseWriter.WriteHeader(status)
+}
+
+func (t *tracingWriter) Write(p []byte) (int, error) {
+	if t.status == 0 {
+		t.status = http.StatusOK
+	}
+	return t.ResponseWriter.Write(p)
+}
+
+func (t *tracingWriter) Flush() {
+	flushResponse(t.ResponseWriter)
+}
+
+func (h tracingHandler) ServeHTTP(w http.ResponseWriter, r *http.Request) {
+	if !tracing.Enabled() {
+		h.handler.ServeHTTP(w, r)
+		return
+	}
+	ctx, span := tracing.StartServer(r.Context(), r.Method, r.Header)
+	defer span.End()
+	span.SetAttribute("http.request.method", r.Method)
+	resource := strings.SplitN(strings.TrimPrefix(r.URL.Path, "/"), "/", 2)
+	if resource[0] != "" {
+		span.SetAttribute("xl.bucket", resource[0])
+	}
+	if len(resource) > 1 && resource[1] != "" {
+		span.SetAttribute("xl.object", resource[1])
+	}
+	tw := &tracingWriter{ResponseWriter: w}
+	h.handler.ServeHTTP(tw, r.WithContext(ctx))
+	if tw.status == 0 {
+		tw.status = http.StatusOK
+	}
+	span.SetAttribute("http.response.status_code", tw.status)
+	if tw.status >= http.StatusInternalServerError {
+		span.SetError(http.StatusText(tw.status))
+	}
+}
+
+// getStorage - storage tracing its disk operations as part of req while tracing is enabled
+func (api API) getStorage(req *http.Request) xl.Interface {
+	if !tracing.Enabled() {
+		return api.XL
+	}
+	return api.XL.WithContext(req.Context())
+}
@@ -0,0 +1,205 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/minio/minio-xl/pkg/probe"
+	"github.com/minio/minio-xl/pkg/xl"
+)
+
+// maximum size of website configuration accepted
+const maxWebsiteConfigSize = 64 * 1024
+
+// website configuration is saved as bucket metadata under these keys
+const (
+	websiteIndexDocumentKey = "websiteIndexDocument"
+	websiteErrorDocumentKey = "websiteErrorDocument"
+)
+
+// redirect location of an object is saved as object metadata under this key
+const websiteRedirectLocationKey = "websiteRedirectLocation"
+
+// maximum length of a redirect location accepted
+const maxWebsiteRedirectLocationLength = 2 * 1024
+
+// isValidWebsiteRedirectLocation - redirect location is either an absolute path of the same
+// site or an http(s) URL
+func isValidWebsiteRedirectLocation(location string) bool {
+	if len(location) > maxWebsiteRedirectLocationLength {
+		return false
+	}
+	return strings.HasPrefix(location, "/") || strings.HasPrefix(location, "http://") || strings.HasPrefix(location, "https://")
+}
+
+// isValidWebsiteConfig - index document suffix is mandatory and cannot have slashes
+func isValidWebsiteConfig(websiteConfig WebsiteConfiguration) bool {
+	suffix := websiteConfig.IndexDocument.Suffix
+	if suffix == "" || strings.Contains(suffix, "/") {
+		return false
+	}
+	if websiteConfig.ErrorDocument != nil {
+		return xl.IsValidObjectName(websiteConfig.ErrorDocument.Key)
+	}
+	return true
+}
+
+// getWebsiteMetadata - bucket metadata for a website configuration, missing error document is removed
+func getWebsiteMetadata(websiteConfig WebsiteConfiguration) map[string]string {
+	metadata := map[string]string{
+		websiteIndexDocumentKey: websiteConfig.IndexDocument.Suffix,
+		websiteErrorDocumentKey: "",
+	}
+	if websiteConfig.ErrorDocument != nil {
+		metadata[websiteErrorDocumentKey] = websiteConfig.ErrorDocument.Key
+	}
+	return metadata
+}
+
+// getWebsiteConfig - website configuration from bucket metadata, returns false if bucket has none
+func getWebsiteConfig(bucketMetadata xl.BucketMetadata) (WebsiteConfiguration, bool) {
+	suffix, ok := bucketMetadata.Metadata[websiteIndexDocumentKey]
+	if !ok {
+		return WebsiteConfiguration{}, false
+	}
+	websiteConfig := WebsiteConfiguration{}
+	websiteConfig.IndexDocument.Suffix = suffix
+	if key, ok := bucketMetadata.Metadata[websiteErrorDocumentKey]; ok {
+		websiteConfig.ErrorDocument = &WebsiteErrorDocument{Key: key}
+	}
+	return websiteConfig, true
+}
+
+// WebsiteHandler - GET/HEAD on website address
+// ----------
+// Serves objects of buckets with a website configuration, requests for a "directory"
+// return its index document and missing objects return the error document with 404.
+func (api API) WebsiteHandler(w http.ResponseWriter, req *http.Request) {
+	// ticket master block
+	{
+		op := APIOperation{}
+		op.ProceedCh = make(chan struct{})
+		api.OP <- op
+		// block until Ticket master gives us a go
+		<-op.ProceedCh
+	}
+
+	vars := mux.Vars(req)
+	bucket := vars["bucket"]
+	object := vars["object"]
+
+	bucketMetadata, err := api.XL.GetBucketMetadata(bucket)
+	if err != nil {
+		errorIf(err.Trace(), "GetBucketMetadata failed.", nil)
+		switch err.ToGoError().(type) {
+		case xl.BucketNotFound:
+			writeErrorResponse(w, req, NoSuchBucket, req.URL.Path)
+		case xl.BucketNameInvalid:
+			writeErrorResponse(w, req, InvalidBucketName, req.URL.Path)
+		default:
+			writeErrorResponse(w, req, InternalError, req.URL.Path)
+		}
+		return
+	}
+	websiteConfig, ok := getWebsiteConfig(bucketMetadata)
+	if !ok {
+		writeErrorResponse(w, req, NoSuchWebsiteConfiguration, req.URL.Path)
+		return
+	}
+
+	suffix := websiteConfig.IndexDocument.Suffix
+	key := object
+	if key == "" || strings.HasSuffix(key, "/") {
+		key = key + suffix
+	}
+	metadata, err := api.XL.GetObjectMetadata(bucket, key)
+	if err == nil {
+		serveWebsiteObject(api, w, req, metadata, http.StatusOK)
+		return
+	}
+	switch err.ToGoError().(type) {
+	case xl.ObjectNotFound, xl.ObjectNameInvalid:
+	default:
+		errorIf(err.Trace(), "GetObjectMetadata failed.", nil)
+		writeErrorResponse(w, req, InternalError, req.URL.Path)
+		return
+	}
+
+	// request for a "directory" without trailing slash, redirect if it has an index document
+	if key == object {
+		if _, err := api.XL.GetObjectMetadata(bucket, object+"/"+suffix); err == nil {
+			setCommonHeaders(w, 0)
+			w.Header().Set("Location", "/"+bucket+"/"+object+"/")
+			w.WriteHeader(http.StatusFound)
+			return
+		}
+	}
+	if websiteConfig.ErrorDocument != nil {
+		if metadata, err := api.XL.GetObjectMetadata(bucket, websiteConfig.ErrorDocument.Key); err == nil {
+			serveWebsiteObject(api, w, req, metadata, http.StatusNotFound)
+			return
+		}
+	}
+	writeErrorResponse(w, req, NoSuchKey, req.URL.Path)
+}
+
+// serveWebsiteObject - write object with the given status, ranges are honored only for 200
+func serveWebsiteObject(api API, w http.ResponseWriter, req *http.Request, metadata xl.ObjectMetadata, status int) {
+	if location, ok := metadata.Metadata[websiteRedirectLocationKey]; ok && status == http.StatusOK {
+		setCommonHeaders(w, 0)
+		w.Header().Set("Location", location)
+		w.WriteHeader(http.StatusMovedPermanently)
+		return
+	}
+	hrange := &httpRange{start: 0, length: 0, size: metadata.Size}
+	if status == http.StatusOK {
+		var err *probe.Error
+		hrange, err = getRequestedRange(req.Header.Get("Range"), metadata.Size)
+		if err != nil {
+			writeRangeNotSatisfiable(w, req, metadata.Size)
+			return
+		}
+	}
+	compressed := isCompressedResponse(req, metadata)
+	setVaryHeader(w, metadata)
+	if status == http.StatusOK && checkPreconditions(w, req, metadata, getObjectETag(metadata, compressed)) {
+		return
+	}
+	setObjectHeaders(w, metadata, hrange)
+	if compressed {
+		setCompressedHeaders(w, metadata)
+	}
+	if hrange.start == 0 && hrange.length == 0 {
+		w.WriteHeader(status)
+	}
+	if req.Method == "HEAD" {
+		return
+	}
+	if compressed {
+		if err := getCompressedObject(api.XL, w, metadata.Bucket, metadata.Object); err != nil {
+			errorIf(err.Trace(), "GetObject failed.", nil)
+		}
+		return
+	}
+	if _, err := api.XL.GetObject(w, metadata.Bucket, metadata.Object, hrange.start, hrange.length); err != nil {
+		errorIf(err.Trace(), "GetObject failed.", nil)
+		return
+	}
+}
@@ -0,0 +1,82 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"sync"
+
+	. "gopkg.in/check.v1"
+)
+
+type AnonymousLimiterSuite struct{}
+
+var _ = Suite(&AnonymousLimiterSuite{})
+
+func (s *AnonymousLimiterSuite) TestAnonymousLimitHandler(c *C) {
+	started := make(chan struct{})
+	finish := make(chan struct{})
+	blocking := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Query().Get("block") != "" {
+			started <- struct{}{}
+			<-finish
+		}
+		w.WriteHeader(http.StatusOK)
+	})
+	handler := anonymousLimitHandler{blocking, newAnonymousLimiter(2)}
+	serve := func(url string, header http.Header) *httptest.ResponseRecorder {
+		req, err := http.NewRequest("GET", url, nil)
+		c.Assert(err, IsNil)
+		for key, values := range header {
+			req.Header[key] = values
+		}
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		return w
+	}
+
+	var wg sync.WaitGroup
+	for i := 0; i < 2; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			c.Check(serve("http://localhost/bucket/object?block=1", nil).Code, Equals, http.StatusOK)
+		}()
+		<-started
+	}
+
+	// unsigned requests over the limit are rejected right away
+	w := serve("http://localhost/bucket/object", nil)
+	c.Assert(w.Code, Equals, http.StatusServiceUnavailable)
+	c.Assert(w.Header().Get("Retry-After"), Equals, "1")
+	// signed requests are not counted, their signature is verified further on
+	w = serve("http://localhost/bucket/object", http.Header{"Authorization": {"AWS4-HMAC-SHA256 Credential=access/..."}})
+	c.Assert(w.Code, Equals, http.StatusOK)
+	w = serve("http://localhost/bucket/object?X-Amz-Credential=access", nil)
+	c.Assert(w.Code, Equals, http.StatusOK)
+
+	close(finish)
+	wg.Wait()
+	c.Assert(serve("http://localhost/bucket/object", nil).Code, Equals, http.StatusOK)
+
+	// unlimited
+	handler = anonymousLimitHandler{blocking, newAnonymousLimiter(0)}
+	for i := 0; i < 10; i++ {
+		c.Assert(serve("http://localhost/bucket/object", nil).Code, Equals, http.StatusOK)
+	}
+}
@@ -0,0 +1,108 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"io/ioutil"
+	"net/http"
+	"net/http/httptest"
+	"sync"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type BandwidthSuite struct{}
+
+var _ = Suite(&BandwidthSuite{})
+
+// assertUnderRate - bytes moved in elapsed stay under rate, allowing for the initial burst
+func assertUnderRate(c *C, bytes int, elapsed time.Duration, limiter *bandwidthLimiter) {
+	c.Assert(float64(bytes) <= limiter.rate*elapsed.Seconds()+limiter.burst, Equals, true,
+		Commentf("%d bytes in %v over %v bytes/sec", bytes, elapsed, limiter.rate))
+}
+
+func (s *BandwidthSuite) TestBandwidthLimiter(c *C) {
+	limiter := newBandwidthLimiter(1024 * 1024)
+	start := time.Now()
+	var written int
+	for written < 512*1024 {
+		limiter.wait(bandwidthChunkSize)
+		written += bandwidthChunkSize
+	}
+	elapsed := time.Since(start)
+	assertUnderRate(c, written, elapsed, limiter)
+	c.Assert(elapsed < time.Second, Equals, true)
+
+	// unlimited
+	limiter = newBandwidthLimiter(0)
+	start = time.Now()
+	for i := 0; i < 1000; i++ {
+		limiter.wait(bandwidthChunkSize)
+	}
+	c.Assert(time.Since(start) < 100*time.Millisecond, Equals, true)
+}
+
+func (s *BandwidthSuite) TestBandwidthLimitHandler(c *C) {
+	data := bytes.Repeat([]byte("a"), 128*1024)
+	echo := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == "PUT" {
+			body, e := ioutil.ReadAll(r.Body)
+			c.Check(e, IsNil)
+			c.Check(len(body), Equals, len(data))
+			return
+		}
+		w.Write(data)
+	})
+	serve := func(handler http.Handler, method string) {
+		req, e := http.NewRequest(method, "http://localhost/bucket/object", bytes.NewReader(data))
+		c.Assert(e, IsNil)
+		w := httptest.NewRecorder()
+		handler.ServeHTTP(w, req)
+		c.Assert(w.Code, Equals, http.StatusOK)
+	}
+
+	// uploads and downloads share the aggregate limit, slowed down but never rejected
+	limits := newBandwidthLimits(512*1024, 0, 0)
+	handler := bandwidthLimitHandler{echo, limits}
+	start := time.Now()
+	var wg sync.WaitGroup
+	for _, method := range []string{"PUT", "GET", "PUT", "GET"} {
+		wg.Add(1)
+		go func(method string) {
+			defer wg.Done()
+			serve(handler, method)
+		}(method)
+	}
+	wg.Wait()
+	elapsed := time.Since(start)
+	assertUnderRate(c, 4*len(data), elapsed, limits.total)
+	c.Assert(elapsed > 500*time.Millisecond, Equals, true)
+
+	// egress only, uploads are not slowed down
+	limits = newBandwidthLimits(0, 0, 256*1024)
+	handler = bandwidthLimitHandler{echo, limits}
+	start = time.Now()
+	serve(handler, "PUT")
+	c.Assert(time.Since(start) < 100*time.Millisecond, Equals, true)
+	start = time.Now()
+	serve(handler, "GET")
+	elapsed = time.Since(start)
+	assertUnderRate(c, len(data), elapsed, limits.egress)
+	c.Assert(elapsed > 250*time.Millisecond, Equals, true)
+}
@@ -0,0 +1,103 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	. "gopkg.in/check.v1"
+)
+
+type CredentialsSuite struct {
+	root string
+}
+
+var _ = Suite(&CredentialsSuite{})
+
+func (s *CredentialsSuite) SetUpSuite(c *C) {
+	root, err := ioutil.TempDir(os.TempDir(), "credentials-")
+	c.Assert(err, IsNil)
+	s.root = root
+}
+
+func (s *CredentialsSuite) TearDownSuite(c *C) {
+	os.RemoveAll(s.root)
+}
+
+// writeCredentials writes output for a 'cat' based credentials command
+func (s *CredentialsSuite) writeCredentials(c *C, name, content string) string {
+	file := filepath.Join(s.root, name)
+	c.Assert(ioutil.WriteFile(file, []byte(content), 0600), IsNil)
+	return "cat " + file
+}
+
+func (s *CredentialsSuite) TestCredentialsCmd(c *C) {
+	_, err := runCredentialsCmd(s.writeCredentials(c, "long.json", `{"accessKey": "AC5NH40NQLTL4D2W92PM", "secretKey": "H+AVh8q5G7hEH2r3WxFP135+Q19Aw8yXWel8IGh/HhEjnFvdZwt5ooA9gFkyl+Gl"}`))
+	// secret key has to be 40 characters long
+	c.Assert(err, NotNil)
+	c.Assert(err.ToGoError(), Equals, errMalformedCredentials)
+
+	cmd := s.writeCredentials(c, "valid.json", `{"accessKey": "AC5NH40NQLTL4D2W92PM", "secretKey": "H+AVh8q5G7hEH2r3WxFP135+Q19Aw8yXWel8IGh/"}`)
+	user, err := runCredentialsCmd(cmd)
+	c.Assert(err, IsNil)
+	c.Assert(user.AccessKeyID, Equals, "AC5NH40NQLTL4D2W92PM")
+	c.Assert(user.SecretAccessKey, Equals, "H+AVh8q5G7hEH2r3WxFP135+Q19Aw8yXWel8IGh/")
+
+	_, err = runCredentialsCmd("")
+	c.Assert(err.ToGoError(), Equals, errInvalidCredentialsCmd)
+
+	_, err = runCredentialsCmd("false")
+	c.Assert(err, NotNil)
+
+	_, err = runCredentialsCmd(s.writeCredentials(c, "invalid.json", `accessKey=AC5NH40NQLTL4D2W92PM`))
+	c.Assert(err.ToGoError(), Equals, errMalformedCredentials)
+
+	_, err = runCredentialsCmd(s.writeCredentials(c, "empty.json", `{"accessKey": "AC5NH40NQLTL4D2W92PM"}`))
+	c.Assert(err.ToGoError(), Equals, errMalformedCredentials)
+}
+
+func (s *CredentialsSuite) TestCredentialsProvider(c *C) {
+	cmd := s.writeCredentials(c, "rotate.json", `{"accessKey": "AC5NH40NQLTL4D2W92PM", "secretKey": "H+AVh8q5G7hEH2r3WxFP135+Q19Aw8yXWel8IGh/"}`)
+	_, err := newCredentialsProvider("false")
+	c.Assert(err, NotNil)
+
+	provider, err := newCredentialsProvider(cmd)
+	c.Assert(err, IsNil)
+	globalCredentialsProvider = provider
+	defer func() { globalCredentialsProvider = nil }()
+
+	users, err := getAuthUsers()
+	c.Assert(err, IsNil)
+	c.Assert(users["admin"].AccessKeyID, Equals, "AC5NH40NQLTL4D2W92PM")
+
+	// rotate
+	s.writeCredentials(c, "rotate.json", `{"accessKey": "BC5NH40NQLTL4D2W92PM", "secretKey": "J+AVh8q5G7hEH2r3WxFP135+Q19Aw8yXWel8IGh/"}`)
+	c.Assert(provider.Reload(), IsNil)
+	users, err = getAuthUsers()
+	c.Assert(err, IsNil)
+	c.Assert(users["admin"].AccessKeyID, Equals, "BC5NH40NQLTL4D2W92PM")
+	c.Assert(users["admin"].SecretAccessKey, Equals, "J+AVh8q5G7hEH2r3WxFP135+Q19Aw8yXWel8IGh/")
+
+	// failed reload must not leave the old credentials around
+	s.writeCredentials(c, "rotate.json", `{}`)
+	c.Assert(provider.Reload(), NotNil)
+	_, err = getAuthUsers()
+	c.Assert(err, NotNil)
+	c.Assert(err.ToGoError(), Equals, errCredentialsUnavailable)
+}
@@ -0,0 +1,61 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"io/ioutil"
+	"path/filepath"
+	"strings"
+	"syscall"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type DumpSuite struct{}
+
+var _ = Suite(&DumpSuite{})
+
+func (s *DumpSuite) TestWriteDump(c *C) {
+	dir := filepath.Join(c.MkDir(), "dumps")
+	now := time.Date(2015, 11, 20, 10, 30, 0, 0, time.UTC)
+	message, err := writeDump(dir, false, now)
+	c.Assert(err, IsNil)
+	c.Assert(message, DeepEquals, dumpMessage{Goroutines: filepath.Join(dir, "goroutines-20151120T103000.000000000Z.txt")})
+	goroutines, e := ioutil.ReadFile(message.Goroutines)
+	c.Assert(e, IsNil)
+	c.Assert(strings.Contains(string(goroutines), ".writeDump("), Equals, true)
+
+	// existing dumps are never overwritten
+	_, err = writeDump(dir, false, now)
+	c.Assert(err, Not(IsNil))
+
+	message, err = writeDump(dir, true, now.Add(time.Second))
+	c.Assert(err, IsNil)
+	c.Assert(message.Heap, Equals, filepath.Join(dir, "heap-20151120T103001.000000000Z.pprof"))
+	heap, e := ioutil.ReadFile(message.Heap)
+	c.Assert(e, IsNil)
+	c.Assert(len(heap) > 0, Equals, true)
+}
+
+func (s *DumpSuite) TestParseDumpSignal(c *C) {
+	sig, ok := parseDumpSignal("sigquit")
+	c.Assert(ok, Equals, true)
+	c.Assert(sig, Equals, syscall.SIGQUIT)
+	_, ok = parseDumpSignal("SIGTERM")
+	c.Assert(ok, Equals, false)
+}
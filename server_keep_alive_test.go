@@ -0,0 +1,84 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"encoding/xml"
+	"net/http"
+	"net/http/httptest"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type KeepAliveSuite struct{}
+
+var _ = Suite(&KeepAliveSuite{})
+
+func (s *KeepAliveSuite) TestKeepAliveResponse(c *C) {
+	copyKeepAliveInterval = time.Millisecond
+	defer func() { copyKeepAliveInterval = 10 * time.Second }()
+	req, err := http.NewRequest("PUT", "/bucket/object?uploadId=id&partNumber=1", nil)
+	c.Assert(err, IsNil)
+	req.Header.Set("Accept-Encoding", "gzip")
+
+	copyDone := func(after time.Duration) <-chan struct{} {
+		done := make(chan struct{})
+		go func() {
+			time.Sleep(after)
+			close(done)
+		}()
+		return done
+	}
+
+	// long copies get whitespace, flushed through the writers wrapping the response
+	recorder := httptest.NewRecorder()
+	keepAlive := &keepAliveResponse{w: &tracingWriter{ResponseWriter: recorder}}
+	keepAlive.wait(copyDone(20 * time.Millisecond))
+	c.Assert(keepAlive.started, Equals, true)
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	c.Assert(recorder.Flushed, Equals, true)
+	c.Assert(recorder.Body.Len() > 0, Equals, true)
+	c.Assert(len(bytes.TrimLeft(recorder.Body.Bytes(), " ")), Equals, 0)
+	response := generateCopyObjectPartResponse("etag", time.Now().UTC())
+	keepAlive.writeSuccessResponse(req, encodeSuccessResponse(response))
+	c.Assert(recorder.Header().Get("Content-Type"), Equals, "application/xml")
+	c.Assert(recorder.Header().Get("Content-Encoding"), Equals, "")
+	copyResponse := CopyObjectPartResponse{}
+	c.Assert(xml.Unmarshal(recorder.Body.Bytes(), &copyResponse), IsNil)
+	c.Assert(copyResponse.ETag, Equals, "\"etag\"")
+
+	// once the status went out errors are only in the body
+	recorder = httptest.NewRecorder()
+	keepAlive = &keepAliveResponse{w: recorder}
+	keepAlive.wait(copyDone(20 * time.Millisecond))
+	keepAlive.writeErrorResponse(req, NoSuchUpload, req.URL.Path)
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	errorResponse := APIErrorResponse{}
+	c.Assert(xml.Unmarshal(recorder.Body.Bytes(), &errorResponse), IsNil)
+	c.Assert(errorResponse.Code, Equals, "NoSuchUpload")
+
+	// copies done within the interval are answered as usual
+	copyKeepAliveInterval = time.Hour
+	recorder = httptest.NewRecorder()
+	keepAlive = &keepAliveResponse{w: recorder}
+	keepAlive.wait(copyDone(0))
+	keepAlive.writeErrorResponse(req, NoSuchUpload, req.URL.Path)
+	c.Assert(recorder.Code, Equals, http.StatusNotFound)
+	c.Assert(recorder.Header().Get("Content-Encoding"), Equals, "gzip")
+}
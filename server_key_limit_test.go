@@ -0,0 +1,56 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"strings"
+
+	"github.com/minio/minio-xl/pkg/xl"
+	. "gopkg.in/check.v1"
+)
+
+type KeyLimitsSuite struct{}
+
+var _ = Suite(&KeyLimitsSuite{})
+
+func (s *KeyLimitsSuite) TestKeyLimits(c *C) {
+	limits := newKeyLimits(0, 0)
+	_, ok := limits.check(strings.Repeat("a", 1024))
+	c.Assert(ok, Equals, false)
+	code, ok := limits.check(strings.Repeat("a", 1025))
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, KeyTooLong)
+	_, ok = limits.check(strings.Repeat("a/", 500))
+	c.Assert(ok, Equals, false)
+
+	limits = newKeyLimits(10, 3)
+	_, ok = limits.check("a/b/c")
+	c.Assert(ok, Equals, false)
+	code, ok = limits.check("a/b/c/d")
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, InvalidObjectName)
+	code, ok = limits.check("abcdefghijk")
+	c.Assert(ok, Equals, true)
+	c.Assert(code, Equals, KeyTooLong)
+
+	// longer keys than S3 allows are accepted by xl as well
+	xl.SetMaxObjectNameLength(2048)
+	defer xl.SetMaxObjectNameLength(0)
+	c.Assert(xl.IsValidObjectName(strings.Repeat("a", 2048)), Equals, true)
+	xl.SetMaxObjectNameLength(0)
+	c.Assert(xl.IsValidObjectName(strings.Repeat("a", 1025)), Equals, false)
+}
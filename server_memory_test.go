@@ -0,0 +1,118 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"bytes"
+	"net/http"
+	"net/http/httptest"
+	"strconv"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type MemorySuite struct{}
+
+var _ = Suite(&MemorySuite{})
+
+func (s *MemorySuite) TestMemoryAccountant(c *C) {
+	accountant := newMemoryAccountant(100)
+	c.Assert(accountant.reserve(60, time.Second), Equals, true)
+	c.Assert(accountant.getInFlight(), Equals, int64(60))
+
+	// over the limit, times out
+	c.Assert(accountant.reserve(60, 10*time.Millisecond), Equals, false)
+	c.Assert(accountant.getInFlight(), Equals, int64(60))
+
+	// over the limit, proceeds as soon as memory is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		accountant.release(60)
+	}()
+	c.Assert(accountant.reserve(60, time.Second), Equals, true)
+	c.Assert(accountant.getInFlight(), Equals, int64(60))
+	accountant.release(60)
+
+	// a single request larger than the limit proceeds when nothing else is in flight
+	c.Assert(accountant.reserve(200, 10*time.Millisecond), Equals, true)
+	accountant.add(10)
+	c.Assert(accountant.getInFlight(), Equals, int64(210))
+	accountant.release(200)
+	accountant.release(10)
+	c.Assert(accountant.getInFlight(), Equals, int64(0))
+
+	// unlimited
+	accountant = newMemoryAccountant(0)
+	c.Assert(accountant.reserve(requestBufferSize, 10*time.Millisecond), Equals, true)
+	c.Assert(accountant.reserve(requestBufferSize, 10*time.Millisecond), Equals, true)
+	c.Assert(accountant.getInFlight(), Equals, int64(2*requestBufferSize))
+}
+
+func (s *MemorySuite) TestMemoryLimitHandler(c *C) {
+	accountant := newMemoryAccountant(100)
+	proceed := make(chan struct{})
+	started := make(chan struct{})
+	handler := memoryLimitHandler{
+		handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			started <- struct{}{}
+			<-proceed
+			w.Write(make([]byte, 80))
+		}),
+		accountant: accountant,
+		timeout:    10 * time.Millisecond,
+	}
+
+	// first upload holds its buffer until it finishes
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		request, _ := http.NewRequest("PUT", "http://localhost/bucket/object1", bytes.NewReader(make([]byte, 80)))
+		handler.ServeHTTP(httptest.NewRecorder(), request)
+	}()
+	<-started
+	c.Assert(accountant.getInFlight(), Equals, int64(80))
+
+	// second upload is rejected while first one is in flight
+	request, err := http.NewRequest("PUT", "http://localhost/bucket/object2", bytes.NewReader(make([]byte, 80)))
+	c.Assert(err, IsNil)
+	response := httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	c.Assert(response.Code, Equals, http.StatusServiceUnavailable)
+
+	proceed <- struct{}{}
+	<-done
+	c.Assert(accountant.getInFlight(), Equals, int64(0))
+
+	// downloads are accounted once content length is known, never rejected
+	var inFlight int64
+	handler.handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Length", strconv.Itoa(80))
+		w.Write(make([]byte, 40))
+		inFlight = accountant.getInFlight()
+		w.Write(make([]byte, 40))
+	})
+	c.Assert(accountant.reserve(80, time.Second), Equals, true)
+	request, err = http.NewRequest("GET", "http://localhost/bucket/object1", nil)
+	c.Assert(err, IsNil)
+	response = httptest.NewRecorder()
+	handler.ServeHTTP(response, request)
+	c.Assert(response.Code, Equals, http.StatusOK)
+	c.Assert(inFlight, Equals, int64(160))
+	accountant.release(80)
+	c.Assert(accountant.getInFlight(), Equals, int64(0))
+}
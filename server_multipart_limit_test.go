@@ -0,0 +1,89 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type PartLimiterSuite struct{}
+
+var _ = Suite(&PartLimiterSuite{})
+
+func (s *PartLimiterSuite) TestPartLimiter(c *C) {
+	limiter := newPartLimiter(2)
+	c.Assert(limiter.acquire("upload1", time.Second), Equals, true)
+	c.Assert(limiter.acquire("upload1", time.Second), Equals, true)
+	c.Assert(limiter.getInFlight("upload1"), Equals, 2)
+
+	// over the limit, times out
+	c.Assert(limiter.acquire("upload1", 10*time.Millisecond), Equals, false)
+	// other uploads are not affected
+	c.Assert(limiter.acquire("upload2", 10*time.Millisecond), Equals, true)
+	limiter.release("upload2")
+	c.Assert(limiter.getInFlight("upload2"), Equals, 0)
+
+	// over the limit, proceeds as soon as a part is released
+	go func() {
+		time.Sleep(10 * time.Millisecond)
+		limiter.release("upload1")
+	}()
+	c.Assert(limiter.acquire("upload1", time.Second), Equals, true)
+	limiter.release("upload1")
+	limiter.release("upload1")
+	c.Assert(limiter.getInFlight("upload1"), Equals, 0)
+	c.Assert(len(limiter.inFlight), Equals, 0)
+
+	// unlimited
+	limiter = newPartLimiter(0)
+	for i := 0; i < 10; i++ {
+		c.Assert(limiter.acquire("upload1", 10*time.Millisecond), Equals, true)
+	}
+}
+
+func (s *PartLimiterSuite) TestPartLimiterConcurrent(c *C) {
+	limiter := newPartLimiter(3)
+	var inFlight, peak int64
+	var wg sync.WaitGroup
+	for i := 0; i < 100; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			if !limiter.acquire("upload", 10*time.Second) {
+				c.Error("part was rejected")
+				return
+			}
+			defer limiter.release("upload")
+			current := atomic.AddInt64(&inFlight, 1)
+			for {
+				max := atomic.LoadInt64(&peak)
+				if current <= max || atomic.CompareAndSwapInt64(&peak, max, current) {
+					break
+				}
+			}
+			time.Sleep(time.Millisecond)
+			atomic.AddInt64(&inFlight, -1)
+		}()
+	}
+	wg.Wait()
+	c.Assert(peak <= 3, Equals, true)
+	c.Assert(limiter.getInFlight("upload"), Equals, 0)
+}
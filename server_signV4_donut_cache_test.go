@@ -17,20 +17,39 @@
 package main
 
 import (
+	"archive/tar"
+	"bufio"
 	"bytes"
+	"compress/gzip"
+	"image"
+	"image/png"
 	"io"
 	"io/ioutil"
 	"os"
 	"path/filepath"
 	"sort"
+	"strconv"
 	"strings"
+	"sync"
 	"time"
 
+	"crypto/md5"
+	"crypto/sha256"
+	"encoding/base64"
 	"encoding/hex"
+	"encoding/json"
 	"encoding/xml"
+	"fmt"
+	"hash/crc32"
+	"mime/multipart"
+	"net"
 	"net/http"
 	"net/http/httptest"
+	"net/url"
 
+	"github.com/minio/minio-xl/pkg/minhttp"
+	signv4 "github.com/minio/minio-xl/pkg/signature"
+	"github.com/minio/minio-xl/pkg/tracing"
 	"github.com/minio/minio-xl/pkg/xl"
 	. "gopkg.in/check.v1"
 )
@@ -41,11 +60,13 @@ type MyAPIXLCacheSuite struct {
 	body            io.ReadSeeker
 	accessKeyID     string
 	secretAccessKey string
+	storage         xl.Interface
 }
 
 var _ = Suite(&MyAPIXLCacheSuite{})
 
 var testAPIXLCacheServer *httptest.Server
+var testWebsiteXLCacheServer *httptest.Server
 
 func (s *MyAPIXLCacheSuite) SetUpSuite(c *C) {
 	root, err := ioutil.TempDir(os.TempDir(), "api-")
@@ -79,14 +100,17 @@ func (s *MyAPIXLCacheSuite) SetUpSuite(c *C) {
 	c.Assert(perr, IsNil)
 
 	minioAPI := getNewAPI(false)
+	s.storage = minioAPI.XL
 	httpHandler := getAPIHandler(false, minioAPI)
 	go startTM(minioAPI)
 	testAPIXLCacheServer = httptest.NewServer(httpHandler)
+	testWebsiteXLCacheServer = httptest.NewServer(getWebsiteHandler(minioAPI))
 }
 
 func (s *MyAPIXLCacheSuite) TearDownSuite(c *C) {
 	os.RemoveAll(s.root)
 	testAPIXLCacheServer.Close()
+	testWebsiteXLCacheServer.Close()
 }
 
 func (s *MyAPIXLCacheSuite) newRequest(method, urlStr string, contentLength int64, body io.ReadSeeker) (*http.Request, error) {
@@ -230,6 +254,1743 @@ func (s *MyAPIXLCacheSuite) TestDeleteObject(c *C) {
 	c.Assert(response.StatusCode, Equals, http.StatusMethodNotAllowed)
 }
 
+func (s *MyAPIXLCacheSuite) TestConditionalDeleteObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/conditional-delete", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := &http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/conditional-delete/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	etag := response.Header.Get("ETag")
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/conditional-delete/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "\"d41d8cd98f00b204e9800998ecf8427e\"")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "PreconditionFailed", "At least one of the preconditions you specified did not hold.", http.StatusPreconditionFailed)
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/conditional-delete/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "\""+etag+"\"")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/conditional-delete/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *MyAPIXLCacheSuite) TestMoveObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	for _, object := range []string{"old", "other"} {
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object/"+object, int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object/dir/new", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Minio-Move-Source", "/move-object/old")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, "\"5eb63bbbe01eeed093cb22bb8f5acdc3\"")
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/move-object/dir/new", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "hello world")
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/move-object/old", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	// existing objects are never overwritten, moves across buckets are not supported
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object/other", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Minio-Move-Source", "/move-object/dir/new")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "MutableWriteNotAllowed", "Mutable write method not allowed against this resource.", http.StatusMethodNotAllowed)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object/new", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Minio-Move-Source", "/bucket/old")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotImplemented)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object/new", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Minio-Move-Source", "/move-object/old")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+
+	// moves are signed like any PUT, a forged signature moves nothing
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/move-object/forged", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Minio-Move-Source", "/move-object/other")
+	authorization := request.Header.Get("Authorization")
+	request.Header.Set("Authorization", authorization[:len(authorization)-4]+"0000")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden)
+	_, perr := s.storage.GetObjectMetadata("move-object", "other")
+	c.Assert(perr, IsNil)
+}
+
+func (s *MyAPIXLCacheSuite) TestObjectLegalHold(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/legal-hold", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/legal-hold/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Object-Lock-Legal-Hold", "ON")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/legal-hold/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Object-Lock-Legal-Hold"), Equals, "ON")
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/legal-hold/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "*")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Access Denied.", http.StatusForbidden)
+
+	legalHold := bytes.NewReader([]byte("<LegalHold><Status>OFF</Status></LegalHold>"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/legal-hold/object?legal-hold", int64(legalHold.Len()), legalHold)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/legal-hold/object?legal-hold", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(responseBody), "<Status>OFF</Status>"), Equals, true)
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/legal-hold/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "*")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	legalHold = bytes.NewReader([]byte("<LegalHold><Status>MAYBE</Status></LegalHold>"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/legal-hold/object?legal-hold", int64(legalHold.Len()), legalHold)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest)
+}
+
+func (s *MyAPIXLCacheSuite) TestListObjectsDepth(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/list-depth", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	for _, object := range []string{"a/b/c", "a/d", "e"} {
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/list-depth/"+object, int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/list-depth?delimiter=/&depth=2", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	decoder := xml.NewDecoder(response.Body)
+	listResponse := &ListObjectsResponse{}
+	err = decoder.Decode(listResponse)
+	c.Assert(err, IsNil)
+	c.Assert(len(listResponse.Contents), Equals, 2)
+	c.Assert(listResponse.Contents[0].Key, Equals, "a/d")
+	c.Assert(listResponse.Contents[1].Key, Equals, "e")
+	c.Assert(len(listResponse.CommonPrefixes), Equals, 2)
+	c.Assert(listResponse.CommonPrefixes[0].Prefix, Equals, "a/")
+	c.Assert(listResponse.CommonPrefixes[1].Prefix, Equals, "a/b/")
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/list-depth?delimiter=/&depth=17", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Argument depth must be an integer between 0 and 16.", http.StatusBadRequest)
+}
+
+func (s *MyAPIXLCacheSuite) TestThumbnail(c *C) {
+	globalPostUploadHooks = []postUploadHook{thumbnailHook{width: 64, height: 64}}
+	defer func() { globalPostUploadHooks = nil }()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/thumbnail", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	var buffer bytes.Buffer
+	c.Assert(png.Encode(&buffer, image.NewRGBA(image.Rect(0, 0, 400, 200))), IsNil)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/thumbnail/image.png", int64(buffer.Len()), bytes.NewReader(buffer.Bytes()))
+	c.Assert(err, IsNil)
+	request.Header.Set("Content-Type", "image/png")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// thumbnail is generated in the background
+	for i := 0; i < 50; i++ {
+		request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/thumbnail/image.png%3Fthumb", 0, nil)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		if response.StatusCode == http.StatusOK {
+			break
+		}
+		response.Body.Close()
+		time.Sleep(100 * time.Millisecond)
+	}
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	thumbnail, err := png.Decode(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(thumbnail.Bounds().Dx(), Equals, 64)
+	c.Assert(thumbnail.Bounds().Dy(), Equals, 32)
+
+	// objects which are not images are left alone
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/thumbnail/text", int64(len("hello world")), bytes.NewReader([]byte("hello world")))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	_, perr := newThumbnailHook("256")
+	c.Assert(perr, Not(IsNil))
+	c.Assert(thumbnailHook{}.Matches("text", ""), Equals, false)
+}
+
+func (s *MyAPIXLCacheSuite) TestPresignedExpiry(c *C) {
+	// expiry is verified before the signature
+	presignedURL := func(date time.Time, expires string) string {
+		query := make(url.Values)
+		query.Set("X-Amz-Algorithm", "AWS4-HMAC-SHA256")
+		query.Set("X-Amz-Credential", s.accessKeyID+"/"+date.Format(yyyymmdd)+"/us-east-1/s3/aws4_request")
+		query.Set("X-Amz-Date", date.Format(iso8601Format))
+		query.Set("X-Amz-Expires", expires)
+		query.Set("X-Amz-SignedHeaders", "host")
+		query.Set("X-Amz-Signature", strings.Repeat("0", 64))
+		return testAPIXLCacheServer.URL + "/bucket/object?" + query.Encode()
+	}
+
+	client := http.Client{}
+	response, err := client.Get(presignedURL(time.Now().UTC().Add(-time.Hour), "60"))
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AccessDenied", "Request has expired.", http.StatusForbidden)
+
+	response, err = client.Get(presignedURL(time.Now().UTC(), "604801"))
+	c.Assert(err, IsNil)
+	verifyError(c, response, "AuthorizationQueryParametersError", "X-Amz-Expires must be between 1 and 604800 seconds.", http.StatusBadRequest)
+}
+
+func (s *MyAPIXLCacheSuite) TestDefaultBucket(c *C) {
+	storage, perr := xl.New()
+	c.Assert(perr, IsNil)
+	c.Assert(makeDefaultBucket(storage, "default-bucket"), IsNil)
+	// already present on the next start
+	c.Assert(makeDefaultBucket(storage, "default-bucket"), IsNil)
+	buckets, perr := storage.ListBuckets()
+	c.Assert(perr, IsNil)
+	c.Assert(len(buckets), Equals, 1)
+	c.Assert(buckets[0].Name, Equals, "default-bucket")
+
+	perr = makeDefaultBucket(storage, "Default_Bucket")
+	c.Assert(perr, Not(IsNil))
+	c.Assert(perr.ToGoError(), DeepEquals, xl.BucketNameInvalid{Bucket: "Default_Bucket"})
+}
+
+func (s *MyAPIXLCacheSuite) TestSetContentType(c *C) {
+	storage, perr := xl.New()
+	c.Assert(perr, IsNil)
+	c.Assert(storage.MakeBucket("content-type", "private", nil, nil), IsNil)
+	etags := make(map[string]string)
+	for _, key := range []string{"videos/a.mp4", "videos/b.mp4", "videos/c.mp4", "index.html"} {
+		objMetadata, perr := storage.CreateObject("content-type", key, "", 5, bytes.NewReader([]byte("hello")), nil, nil)
+		c.Assert(perr, IsNil)
+		etags[key] = objMetadata.MD5Sum
+	}
+	_, perr = storage.SetObjectMetadata("content-type", "videos/c.mp4", map[string]string{"contentType": "video/mp4"})
+	c.Assert(perr, IsNil)
+
+	// objects which already have the content type are not updated
+	message, perr := setContentType(storage, "content-type", "videos/", "video/mp4")
+	c.Assert(perr, IsNil)
+	c.Assert(message, DeepEquals, contentTypeMessage{Bucket: "content-type", Prefix: "videos/", ContentType: "video/mp4", Objects: 3, Updated: 2})
+	for key, etag := range etags {
+		objMetadata, perr := storage.GetObjectMetadata("content-type", key)
+		c.Assert(perr, IsNil)
+		c.Assert(objMetadata.MD5Sum, Equals, etag)
+		if key == "index.html" {
+			c.Assert(objMetadata.Metadata["contentType"], Equals, "application/octet-stream")
+			continue
+		}
+		c.Assert(objMetadata.Metadata["contentType"], Equals, "video/mp4")
+	}
+	var buffer bytes.Buffer
+	_, perr = storage.GetObject(&buffer, "content-type", "videos/a.mp4", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.String(), Equals, "hello")
+
+	// a full key updates a single object
+	message, perr = setContentType(storage, "content-type", "index.html", "text/html")
+	c.Assert(perr, IsNil)
+	c.Assert(message.Updated, Equals, 1)
+
+	_, perr = setContentType(storage, "no-such-bucket", "", "text/html")
+	c.Assert(perr.ToGoError(), DeepEquals, xl.BucketNotFound{Bucket: "no-such-bucket"})
+}
+
+func (s *MyAPIXLCacheSuite) TestTouchObject(c *C) {
+	c.Assert(s.storage.MakeBucket("touch", "private", nil, nil), IsNil)
+	created := make(map[string]xl.ObjectMetadata)
+	for _, key := range []string{"sync/a", "sync/b", "other"} {
+		objMetadata, perr := s.storage.CreateObject("touch", key, "", 5, bytes.NewReader([]byte("hello")), nil, nil)
+		c.Assert(perr, IsNil)
+		created[key] = objMetadata
+	}
+	time.Sleep(10 * time.Millisecond)
+
+	// copying an object onto itself with the header touches it
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/touch/other", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/touch/other")
+	request.Header.Set("X-Minio-Touch", "true")
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	var copyResponse CopyObjectResponse
+	c.Assert(xml.NewDecoder(response.Body).Decode(&copyResponse), IsNil)
+	c.Assert(copyResponse.ETag, Equals, "\""+created["other"].MD5Sum+"\"")
+	objMetadata, perr := s.storage.GetObjectMetadata("touch", "other")
+	c.Assert(perr, IsNil)
+	c.Assert(objMetadata.Created.After(created["other"].Created), Equals, true)
+	c.Assert(objMetadata.MD5Sum, Equals, created["other"].MD5Sum)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/touch/other", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/touch/sync/a")
+	request.Header.Set("X-Minio-Touch", "true")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented)
+
+	// touches are signed like any PUT, a forged signature leaves the object alone
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/touch/other", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/touch/other")
+	request.Header.Set("X-Minio-Touch", "true")
+	authorization := request.Header.Get("Authorization")
+	request.Header.Set("Authorization", authorization[:len(authorization)-4]+"0000")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "SignatureDoesNotMatch", "The request signature we calculated does not match the signature you provided.", http.StatusForbidden)
+	forged, perr := s.storage.GetObjectMetadata("touch", "other")
+	c.Assert(perr, IsNil)
+	c.Assert(forged.Created, Equals, objMetadata.Created)
+
+	message, perr := touchObjects(s.storage, "touch", "sync/", true)
+	c.Assert(perr, IsNil)
+	c.Assert(message, DeepEquals, touchMessage{Bucket: "touch", Key: "sync/", Prefix: true, Touched: 2})
+	for _, key := range []string{"sync/a", "sync/b"} {
+		objMetadata, perr := s.storage.GetObjectMetadata("touch", key)
+		c.Assert(perr, IsNil)
+		c.Assert(objMetadata.Created.After(created[key].Created), Equals, true)
+		c.Assert(objMetadata.MD5Sum, Equals, created[key].MD5Sum)
+	}
+	var buffer bytes.Buffer
+	_, perr = s.storage.GetObject(&buffer, "touch", "sync/a", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.String(), Equals, "hello")
+
+	// without the prefix only the exact object is touched
+	_, perr = touchObjects(s.storage, "touch", "sync/", false)
+	c.Assert(perr.ToGoError(), DeepEquals, xl.ObjectNotFound{Object: "sync/"})
+}
+
+func (s *MyAPIXLCacheSuite) TestListLocked(c *C) {
+	c.Assert(s.storage.MakeBucket("list-locked", "private", nil, nil), IsNil)
+	created := make(map[string]xl.ObjectMetadata)
+	for _, key := range []string{"a", "b", "dir/c"} {
+		var metadata map[string]string
+		if key != "b" {
+			metadata = map[string]string{"legalHold": xl.LegalHoldOn}
+		}
+		objMetadata, perr := s.storage.CreateObject("list-locked", key, "", 5, bytes.NewReader([]byte("hello")), metadata, nil)
+		c.Assert(perr, IsNil)
+		created[key] = objMetadata
+	}
+
+	message, perr := listLockedObjects(s.storage, "list-locked", "")
+	c.Assert(perr, IsNil)
+	c.Assert(message, DeepEquals, lockedMessage{Bucket: "list-locked", Objects: []lockedObject{
+		{Key: "a", LegalHold: xl.LegalHoldOn, LastModified: created["a"].Created},
+		{Key: "dir/c", LegalHold: xl.LegalHoldOn, LastModified: created["dir/c"].Created},
+	}})
+	message, perr = listLockedObjects(s.storage, "list-locked", "dir/")
+	c.Assert(perr, IsNil)
+	c.Assert(len(message.Objects), Equals, 1)
+	c.Assert(message.Objects[0].Key, Equals, "dir/c")
+
+	// released objects are no longer listed
+	_, perr = s.storage.SetObjectMetadata("list-locked", "a", map[string]string{"legalHold": ""})
+	c.Assert(perr, IsNil)
+	_, perr = s.storage.SetObjectMetadata("list-locked", "dir/c", map[string]string{"legalHold": ""})
+	c.Assert(perr, IsNil)
+	message, perr = listLockedObjects(s.storage, "list-locked", "")
+	c.Assert(perr, IsNil)
+	c.Assert(message.Objects, DeepEquals, []lockedObject{})
+	c.Assert(message.String(), Equals, "No locked objects under ‘list-locked/’.")
+
+	_, perr = listLockedObjects(s.storage, "no-such-bucket", "")
+	c.Assert(perr, Not(IsNil))
+}
+
+func (s *MyAPIXLCacheSuite) TestDiskUsage(c *C) {
+	c.Assert(s.storage.MakeBucket("disk-usage", "private", nil, nil), IsNil)
+	for key, size := range map[string]int{"a": 5, "photos/2015/x": 10, "photos/2015/y": 10, "photos/2016/z": 20, "videos/v": 100} {
+		_, perr := s.storage.CreateObject("disk-usage", key, "", int64(size), bytes.NewReader(bytes.Repeat([]byte("a"), size)), nil, nil)
+		c.Assert(perr, IsNil)
+	}
+
+	message, perr := diskUsage(s.storage, "disk-usage", "", 0)
+	c.Assert(perr, IsNil)
+	c.Assert(message, DeepEquals, duMessage{Bucket: "disk-usage", prefixUsage: prefixUsage{Size: 145, Objects: 5}})
+	c.Assert(message.String(), Equals, "      145B         5 objects  disk-usage/")
+
+	// largest first, objects directly under the prefix only count towards its total
+	message, perr = diskUsage(s.storage, "disk-usage", "", 1)
+	c.Assert(perr, IsNil)
+	c.Assert(message.prefixUsage, DeepEquals, prefixUsage{Size: 145, Objects: 5})
+	c.Assert(message.Prefixes, DeepEquals, []prefixUsage{{"videos/", 100, 1}, {"photos/", 40, 3}})
+	c.Assert(strings.Split(message.String(), "\n"), DeepEquals, []string{
+		"      100B         1 objects  disk-usage/videos/",
+		"       40B         3 objects  disk-usage/photos/",
+		"      145B         5 objects  disk-usage/",
+	})
+
+	message, perr = diskUsage(s.storage, "disk-usage", "", 2)
+	c.Assert(perr, IsNil)
+	c.Assert(message.Prefixes, DeepEquals, []prefixUsage{{"videos/", 100, 1}, {"photos/", 40, 3}, {"photos/2015/", 20, 2}, {"photos/2016/", 20, 1}})
+
+	// levels are counted below the prefix
+	message, perr = diskUsage(s.storage, "disk-usage", "photos/", 1)
+	c.Assert(perr, IsNil)
+	c.Assert(message.prefixUsage, DeepEquals, prefixUsage{Prefix: "photos/", Size: 40, Objects: 3})
+	c.Assert(message.Prefixes, DeepEquals, []prefixUsage{{"photos/2015/", 20, 2}, {"photos/2016/", 20, 1}})
+
+	message, perr = diskUsage(s.storage, "disk-usage", "none/", 1)
+	c.Assert(perr, IsNil)
+	c.Assert(message, DeepEquals, duMessage{Bucket: "disk-usage", prefixUsage: prefixUsage{Prefix: "none/"}})
+
+	_, perr = diskUsage(s.storage, "no-such-bucket", "", 0)
+	c.Assert(perr, Not(IsNil))
+}
+
+func (s *MyAPIXLCacheSuite) TestTracing(c *C) {
+	type testSpan struct {
+		TraceID      string `json:"traceId"`
+		ParentSpanID string `json:"parentSpanId"`
+		Name         string `json:"name"`
+		Attributes   []struct {
+			Key   string `json:"key"`
+			Value struct {
+				StringValue string `json:"stringValue"`
+				IntValue    string `json:"intValue"`
+			} `json:"value"`
+		} `json:"attributes"`
+	}
+	var lock sync.Mutex
+	var spans []testSpan
+	collector := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var request struct {
+			ResourceSpans []struct {
+				ScopeSpans []struct {
+					Spans []testSpan `json:"spans"`
+				} `json:"scopeSpans"`
+			} `json:"resourceSpans"`
+		}
+		c.Check(json.NewDecoder(r.Body).Decode(&request), IsNil)
+		lock.Lock()
+		defer lock.Unlock()
+		for _, resourceSpans := range request.ResourceSpans {
+			for _, scopeSpans := range resourceSpans.ScopeSpans {
+				spans = append(spans, scopeSpans.Spans...)
+			}
+		}
+	}))
+	defer collector.Close()
+	c.Assert(tracing.Enable(collector.URL, "minio-xl"), IsNil)
+	defer tracing.Disable()
+
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/tracing-bucket", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/tracing-bucket/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	request.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/tracing-bucket/missing", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	tracing.Disable()
+
+	requests := make(map[string]map[string]string)
+	for _, span := range spans {
+		if span.Name == "disk.write" || span.Name == "disk.read" {
+			continue
+		}
+		attributes := map[string]string{"traceId": span.TraceID, "parentSpanId": span.ParentSpanID}
+		for _, attribute := range span.Attributes {
+			attributes[attribute.Key] = attribute.Value.StringValue + attribute.Value.IntValue
+		}
+		requests[span.Name+" "+attributes["xl.object"]] = attributes
+	}
+	c.Assert(len(requests), Equals, 3)
+	c.Assert(requests["PUT "]["xl.bucket"], Equals, "tracing-bucket")
+	c.Assert(requests["PUT "]["parentSpanId"], Equals, "")
+	// continues the trace of the client
+	c.Assert(requests["PUT object"]["traceId"], Equals, "4bf92f3577b34da6a3ce929d0e0e4736")
+	c.Assert(requests["PUT object"]["parentSpanId"], Equals, "00f067aa0ba902b7")
+	c.Assert(requests["PUT object"]["http.response.status_code"], Equals, "200")
+	c.Assert(requests["GET missing"]["http.request.method"], Equals, "GET")
+	c.Assert(requests["GET missing"]["http.response.status_code"], Equals, "404")
+}
+
+func (s *MyAPIXLCacheSuite) TestXMLCompression(c *C) {
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/xml-compression", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	for i := 0; i < 20; i++ {
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/xml-compression/object"+strconv.Itoa(i), int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	do := func(method, url, acceptEncoding string) *http.Response {
+		request, err := s.newRequest(method, url, 0, nil)
+		c.Assert(err, IsNil)
+		// set explicitly, the client does not decompress on its own
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	response = do("GET", testAPIXLCacheServer.URL+"/xml-compression", "gzip")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "gzip")
+	c.Assert(response.Header["Vary"], DeepEquals, []string{"Origin", "Accept-Encoding"})
+	compressed, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(response.ContentLength, Equals, int64(len(compressed)))
+	reader, err := gzip.NewReader(bytes.NewReader(compressed))
+	c.Assert(err, IsNil)
+	var listing ListObjectsResponse
+	c.Assert(xml.NewDecoder(reader).Decode(&listing), IsNil)
+	c.Assert(len(listing.Contents), Equals, 20)
+
+	response = do("GET", testAPIXLCacheServer.URL+"/xml-compression", "identity")
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "")
+	uncompressed, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(len(uncompressed) > len(compressed), Equals, true)
+
+	// errors as well, HEAD responses have no body to compress
+	response = do("GET", testAPIXLCacheServer.URL+"/xml-compression/missing", "gzip")
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "gzip")
+	reader, err = gzip.NewReader(response.Body)
+	c.Assert(err, IsNil)
+	verifyError(c, &http.Response{StatusCode: response.StatusCode, Body: ioutil.NopCloser(reader)}, "NoSuchKey",
+		"The specified key does not exist.", http.StatusNotFound)
+	response = do("HEAD", testAPIXLCacheServer.URL+"/xml-compression/missing", "gzip")
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "")
+
+	globalCompressXML = false
+	defer func() { globalCompressXML = true }()
+	response = do("GET", testAPIXLCacheServer.URL+"/xml-compression", "gzip")
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "")
+	c.Assert(response.Header["Vary"], DeepEquals, []string{"Origin"})
+	body, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(body, DeepEquals, uncompressed)
+}
+
+func (s *MyAPIXLCacheSuite) TestBucketEncryption(c *C) {
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/encryption", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/encryption?encryption", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "ServerSideEncryptionConfigurationNotFoundError", "The server side encryption configuration was not found.", http.StatusNotFound)
+
+	// objects are not encrypted at rest, a default encryption is refused
+	buffer := bytes.NewReader([]byte(`<ServerSideEncryptionConfiguration><Rule><ApplyServerSideEncryptionByDefault><SSEAlgorithm>AES256</SSEAlgorithm></ApplyServerSideEncryptionByDefault></Rule></ServerSideEncryptionConfiguration>`))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/encryption?encryption", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented)
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/encryption?encryption", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/encryption-missing?encryption", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
+}
+
+func (s *MyAPIXLCacheSuite) TestListObjectsMaxKeysClamp(c *C) {
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/maxkeysclamp", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	for i := 0; i < maxObjectList+1; i++ {
+		_, perr := s.storage.CreateObject("maxkeysclamp", fmt.Sprintf("object%04d", i), "", 1, bytes.NewReader([]byte("x")), nil, nil)
+		c.Assert(perr, IsNil)
+	}
+
+	listObjects := func(query string) ListObjectsResponse {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/maxkeysclamp?"+query, 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		listResponse := ListObjectsResponse{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(&listResponse), IsNil)
+		return listResponse
+	}
+
+	// clamped rather than refused, the rest is listed by the next page
+	listResponse := listObjects("max-keys=1000000")
+	c.Assert(listResponse.MaxKeys, Equals, maxObjectList)
+	c.Assert(len(listResponse.Contents), Equals, maxObjectList)
+	c.Assert(listResponse.IsTruncated, Equals, true)
+	marker := listResponse.Contents[len(listResponse.Contents)-1].Key
+	c.Assert(marker, Equals, "object0999")
+
+	listResponse = listObjects("max-keys=1000000&marker=" + marker)
+	c.Assert(len(listResponse.Contents), Equals, 1)
+	c.Assert(listResponse.Contents[0].Key, Equals, "object1000")
+	c.Assert(listResponse.IsTruncated, Equals, false)
+
+	// smaller requests are honoured
+	listResponse = listObjects("max-keys=10")
+	c.Assert(listResponse.MaxKeys, Equals, 10)
+	c.Assert(len(listResponse.Contents), Equals, 10)
+	c.Assert(listResponse.IsTruncated, Equals, true)
+}
+
+func (s *MyAPIXLCacheSuite) TestListObjectsV2(c *C) {
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/listobjectsv2", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	for i := 0; i < 5; i++ {
+		_, perr := s.storage.CreateObject("listobjectsv2", fmt.Sprintf("object%d", i), "", 1, bytes.NewReader([]byte("x")), nil, nil)
+		c.Assert(perr, IsNil)
+	}
+
+	listObjects := func(query string) ListObjectsV2Response {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/listobjectsv2?list-type=2&"+query, 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		listResponse := ListObjectsV2Response{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(&listResponse), IsNil)
+		return listResponse
+	}
+
+	// owner is omitted unless asked for
+	listResponse := listObjects("max-keys=10")
+	c.Assert(listResponse.KeyCount, Equals, 5)
+	c.Assert(listResponse.IsTruncated, Equals, false)
+	c.Assert(listResponse.NextContinuationToken, Equals, "")
+	for _, content := range listResponse.Contents {
+		c.Assert(content.Owner, IsNil)
+	}
+	listResponse = listObjects("max-keys=10&fetch-owner=true")
+	c.Assert(len(listResponse.Contents), Equals, 5)
+	for _, content := range listResponse.Contents {
+		c.Assert(content.Owner, NotNil)
+		c.Assert(content.Owner.ID, Equals, s.accessKeyID)
+	}
+
+	// pages follow the continuation token
+	listResponse = listObjects("max-keys=2&start-after=object0")
+	c.Assert(listResponse.StartAfter, Equals, "object0")
+	c.Assert(len(listResponse.Contents), Equals, 2)
+	c.Assert(listResponse.Contents[0].Key, Equals, "object1")
+	c.Assert(listResponse.Contents[1].Key, Equals, "object2")
+	c.Assert(listResponse.IsTruncated, Equals, true)
+	token := listResponse.NextContinuationToken
+	c.Assert(token, Not(Equals), "")
+
+	listResponse = listObjects("max-keys=2&start-after=object0&continuation-token=" + url.QueryEscape(token))
+	c.Assert(listResponse.ContinuationToken, Equals, token)
+	c.Assert(len(listResponse.Contents), Equals, 2)
+	c.Assert(listResponse.Contents[0].Key, Equals, "object3")
+	c.Assert(listResponse.Contents[1].Key, Equals, "object4")
+
+	// start-after later than the token wins
+	listResponse = listObjects("start-after=object3&continuation-token=" + url.QueryEscape(token))
+	c.Assert(len(listResponse.Contents), Equals, 1)
+	c.Assert(listResponse.Contents[0].Key, Equals, "object4")
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/listobjectsv2?list-type=2&continuation-token=%25%25", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "The continuation token provided is incorrect.", http.StatusBadRequest)
+}
+
+func (s *MyAPIXLCacheSuite) TestBucketReplication(c *C) {
+	targetAPI := getNewAPI(false)
+	go startTM(targetAPI)
+	target := httptest.NewServer(getAPIHandler(false, targetAPI))
+	defer target.Close()
+	c.Assert(targetAPI.XL.MakeBucket("replica", "private", nil, nil), IsNil)
+
+	defer func(delay time.Duration) { replicationRetryDelay = delay }(replicationRetryDelay)
+	replicationRetryDelay = time.Millisecond
+	globalReplicator = newReplicator(s.storage)
+	globalReplicator.start()
+	defer func() {
+		globalReplicator.stop()
+		globalReplicator = nil
+	}()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/replicated", 0, nil)
+	c.Assert(err, IsNil)
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/replicated?replication", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "ReplicationConfigurationNotFoundError", "The replication configuration was not found.", http.StatusNotFound)
+
+	putReplication := func(rule string) *http.Response {
+		buffer := bytes.NewReader([]byte("<ReplicationConfiguration><Rule>" + rule + "</Rule></ReplicationConfiguration>"))
+		request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/replicated?replication", int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	destination := func(endpoint string) string {
+		return "<Destination><Bucket>arn:aws:s3:::replica</Bucket><Endpoint>" + endpoint + "</Endpoint><AccessKeyID>" + s.accessKeyID +
+			"</AccessKeyID><SecretAccessKey>" + s.secretAccessKey + "</SecretAccessKey></Destination>"
+	}
+	// prefix filters are not supported yet
+	response = putReplication("<Status>Enabled</Status><Filter><Prefix>logs/</Prefix></Filter>" + destination(target.URL))
+	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented)
+	response = putReplication("<Status>Enabled</Status>" + destination("ftp://localhost"))
+	verifyError(c, response, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest)
+	response = putReplication("<ID>dr</ID><Status>Enabled</Status>" + destination(target.URL))
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// secret key is never returned
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/replicated?replication", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	replicationConfig := ReplicationConfiguration{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&replicationConfig), IsNil)
+	c.Assert(len(replicationConfig.Rule), Equals, 1)
+	c.Assert(replicationConfig.Rule[0].ID, Equals, "dr")
+	c.Assert(replicationConfig.Rule[0].Destination, DeepEquals, ReplicationDestination{Bucket: "arn:aws:s3:::replica", Endpoint: target.URL,
+		AccessKeyID: s.accessKeyID})
+
+	// replicationStatus - poll object status until it is no longer pending
+	replicationStatus := func(object string) string {
+		for i := 0; i < 500; i++ {
+			request, err := s.newRequest("HEAD", testAPIXLCacheServer.URL+"/replicated/"+object, 0, nil)
+			c.Assert(err, IsNil)
+			response, err := client.Do(request)
+			c.Assert(err, IsNil)
+			c.Assert(response.StatusCode, Equals, http.StatusOK)
+			if status := response.Header.Get("X-Amz-Replication-Status"); status != replicationPending {
+				return status
+			}
+			time.Sleep(10 * time.Millisecond)
+		}
+		return replicationPending
+	}
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/replicated/dir/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Meta-Origin", "site-a")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(replicationStatus("dir/object"), Equals, replicationCompleted)
+
+	var replica bytes.Buffer
+	_, perr := targetAPI.XL.GetObject(&replica, "replica", "dir/object", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(replica.String(), Equals, "hello world")
+	replicaMetadata, perr := targetAPI.XL.GetObjectMetadata("replica", "dir/object")
+	c.Assert(perr, IsNil)
+	c.Assert(replicaMetadata.Metadata["X-Amz-Meta-Origin"], Equals, "site-a")
+	metadata, perr := s.storage.GetObjectMetadata("replicated", "dir/object")
+	c.Assert(perr, IsNil)
+	c.Assert(replicaMetadata.MD5Sum, Equals, metadata.MD5Sum)
+
+	// deletes are propagated
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/replicated/dir/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "\""+metadata.MD5Sum+"\"")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	for i := 0; i < 500; i++ {
+		if _, perr = targetAPI.XL.GetObjectMetadata("replica", "dir/object"); perr != nil {
+			break
+		}
+		time.Sleep(10 * time.Millisecond)
+	}
+	c.Assert(perr, NotNil)
+
+	// objects are marked failed once all attempts to an unreachable target failed
+	target.Close()
+	buffer = bytes.NewReader([]byte("unreachable"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/replicated/failed", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(replicationStatus("failed"), Equals, replicationFailed)
+
+	// objects of buckets which are no longer replicated have no status
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/replicated?replication", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	buffer = bytes.NewReader([]byte("local"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/replicated/local", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(replicationStatus("local"), Equals, "")
+}
+
+// newTestArchive - gzipped tar archive of files, in the order given as name and data pairs
+func newTestArchive(c *C, files ...string) []byte {
+	var buffer bytes.Buffer
+	gzipWriter := gzip.NewWriter(&buffer)
+	tarWriter := tar.NewWriter(gzipWriter)
+	c.Assert(tarWriter.WriteHeader(&tar.Header{Name: "dir/", Typeflag: tar.TypeDir, Mode: 0755}), IsNil)
+	c.Assert(tarWriter.WriteHeader(&tar.Header{Name: "link", Typeflag: tar.TypeSymlink, Linkname: "dir/", Mode: 0777}), IsNil)
+	for i := 0; i < len(files); i += 2 {
+		c.Assert(tarWriter.WriteHeader(&tar.Header{Name: files[i], Typeflag: tar.TypeReg, Mode: 0644, Size: int64(len(files[i+1]))}), IsNil)
+		_, err := tarWriter.Write([]byte(files[i+1]))
+		c.Assert(err, IsNil)
+	}
+	c.Assert(tarWriter.Close(), IsNil)
+	c.Assert(gzipWriter.Close(), IsNil)
+	return buffer.Bytes()
+}
+
+func (s *MyAPIXLCacheSuite) TestExtractOnUpload(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/extract", 0, nil)
+	c.Assert(err, IsNil)
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	putArchive := func(object, contentType string, archive []byte) *http.Response {
+		request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/extract/"+object, int64(len(archive)), bytes.NewReader(archive))
+		c.Assert(err, IsNil)
+		request.Header.Set("Content-Type", contentType)
+		request.Header.Set("X-Minio-Extract", "true")
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	listObjects := func() []string {
+		results, _, perr := s.storage.ListObjects("extract", xl.BucketResourcesMetadata{Maxkeys: 1000})
+		c.Assert(perr, IsNil)
+		var objects []string
+		for _, object := range results {
+			objects = append(objects, object.Object)
+		}
+		return objects
+	}
+
+	archive := newTestArchive(c, "index.html", "<html/>", "./dir/app.js", "app", "dir/sub/data.bin", "data")
+	response = putArchive("build-1", "application/x-tar", archive)
+	verifyError(c, response, "InvalidArchive", "The archive you provided is not a valid tar or gzipped tar archive.", http.StatusBadRequest)
+	c.Assert(len(listObjects()), Equals, 0)
+
+	response = putArchive("build-1", "application/gzip", archive)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Minio-Extracted-Objects"), Equals, "3")
+	c.Assert(listObjects(), DeepEquals, []string{"build-1/dir/app.js", "build-1/dir/sub/data.bin", "build-1/index.html"})
+	var buffer bytes.Buffer
+	_, perr := s.storage.GetObject(&buffer, "extract", "build-1/dir/app.js", 0, 0)
+	c.Assert(perr, IsNil)
+	c.Assert(buffer.String(), Equals, "app")
+	metadata, perr := s.storage.GetObjectMetadata("extract", "build-1/index.html")
+	c.Assert(perr, IsNil)
+	c.Assert(strings.HasPrefix(metadata.Metadata["contentType"], "text/html"), Equals, true)
+
+	// entries leaving the prefix fail the whole archive, objects already extracted are removed
+	response = putArchive("build-2/", "application/x-gzip", newTestArchive(c, "ok.txt", "ok", "../escape.txt", "escape"))
+	verifyError(c, response, "InvalidArchive", "The archive you provided is not a valid tar or gzipped tar archive.", http.StatusBadRequest)
+	// truncated archives as well
+	response = putArchive("build-2/", "application/x-gzip", archive[:len(archive)/2])
+	verifyError(c, response, "InvalidArchive", "The archive you provided is not a valid tar or gzipped tar archive.", http.StatusBadRequest)
+	// objects are immutable, an archive overwriting one fails
+	response = putArchive("build-1", "application/x-gzip", newTestArchive(c, "new.txt", "new", "index.html", "overwrite"))
+	verifyError(c, response, "MutableWriteNotAllowed", "Mutable write method not allowed against this resource.", http.StatusMethodNotAllowed)
+	c.Assert(listObjects(), DeepEquals, []string{"build-1/dir/app.js", "build-1/dir/sub/data.bin", "build-1/index.html"})
+
+	// only archives are extracted
+	response = putArchive("build-3", "text/plain", []byte("text"))
+	verifyError(c, response, "InvalidRequest", "The request is not valid, please check the request headers.", http.StatusBadRequest)
+
+	// a client disconnecting after the archive never gets its signature verified, nothing is kept
+	archive = newTestArchive(c, "evil.txt", "evil")
+	request, err = http.NewRequest("PUT", testAPIXLCacheServer.URL+"/extract/build-4", io.MultiReader(bytes.NewReader(archive), failingReader{}))
+	c.Assert(err, IsNil)
+	request.Header.Set("Content-Type", "application/gzip")
+	recorder := httptest.NewRecorder()
+	forged := &signv4.Signature{AccessKeyID: s.accessKeyID, Signature: strings.Repeat("0", 64)}
+	API{XL: s.storage}.extractObject(recorder, request, "extract", "build-4", "", int64(len(archive))+1024, forged)
+	c.Assert(recorder.Code, Not(Equals), http.StatusOK)
+	c.Assert(listObjects(), DeepEquals, []string{"build-1/dir/app.js", "build-1/dir/sub/data.bin", "build-1/index.html"})
+}
+
+// failingReader - reader of a body whose client disconnected
+type failingReader struct{}
+
+func (failingReader) Read(p []byte) (int, error) {
+	return 0, io.ErrUnexpectedEOF
+}
+
+func (s *MyAPIXLCacheSuite) TestBucketRequestMetrics(c *C) {
+	bucketStats := func(bucket string) (BucketRequestStats, bool) {
+		for _, stats := range globalBucketMetrics.stats() {
+			if stats.Bucket == bucket {
+				return stats, true
+			}
+		}
+		return BucketRequestStats{}, false
+	}
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/metered", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	data := "hello metrics"
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/metered/object", int64(len(data)), strings.NewReader(data))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/metered/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	body, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, data)
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/metered/missing", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	// bogus bucket names never get counters
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/bogus-bucket/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
+	_, ok := bucketStats("bogus-bucket")
+	c.Assert(ok, Equals, false)
+
+	stats, ok := bucketStats("metered")
+	c.Assert(ok, Equals, true)
+	c.Assert(stats.Requests, DeepEquals, map[string]int64{"PUT": 2, "GET": 1, "HEAD": 1})
+	c.Assert(stats.Errors, Equals, int64(1))
+	c.Assert(stats.BytesReceived, Equals, int64(len(data)))
+	c.Assert(stats.BytesSent, Equals, int64(len(data)))
+
+	recorder := httptest.NewRecorder()
+	request, err = http.NewRequest("GET", "/metrics", nil)
+	c.Assert(err, IsNil)
+	getMetricsHandler().ServeHTTP(recorder, request)
+	c.Assert(recorder.Code, Equals, http.StatusOK)
+	metrics := recorder.Body.String()
+	for _, line := range []string{
+		`minio_bucket_requests_total{bucket="metered",method="GET"} 1`,
+		`minio_bucket_requests_total{bucket="metered",method="PUT"} 2`,
+		`minio_bucket_errors_total{bucket="metered"} 1`,
+		`minio_bucket_received_bytes_total{bucket="metered"} 13`,
+		`minio_bucket_sent_bytes_total{bucket="metered"} 13`,
+	} {
+		c.Assert(strings.Contains(metrics, line+"\n"), Equals, true, Commentf("%s is missing in %s", line, metrics))
+	}
+	c.Assert(strings.Contains(metrics, "bogus-bucket"), Equals, false)
+}
+
+func (s *MyAPIXLCacheSuite) TestResumableDownload(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/resumable", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	putObject := func(data []byte) string {
+		request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/resumable/object", int64(len(data)), bytes.NewReader(data))
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		return "\"" + strings.Trim(response.Header.Get("ETag"), "\"") + "\""
+	}
+	resume := func(offset int, ifRange string) *http.Response {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/resumable/object", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set("Range", "bytes="+strconv.Itoa(offset)+"-")
+		request.Header.Set("If-Range", ifRange)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	original := bytes.Repeat([]byte("original"), 1024)
+	etag := putObject(original)
+
+	// download is interrupted after the first kilobyte
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/resumable/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, etag)
+	lastModified := response.Header.Get("Last-Modified")
+	partial := make([]byte, 1024)
+	_, err = io.ReadFull(response.Body, partial)
+	c.Assert(err, IsNil)
+	response.Body.Close()
+
+	// resumed while the object is unchanged, by ETag or by date
+	for _, ifRange := range []string{etag, lastModified} {
+		response = resume(len(partial), ifRange)
+		c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+		c.Assert(response.Header.Get("ETag"), Equals, etag)
+		rest, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		c.Assert(bytes.Equal(append(partial, rest...), original), Equals, true)
+	}
+	// weak tags never validate a range
+	response = resume(len(partial), "W/"+etag)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response.Body.Close()
+
+	// object is replaced before the download is resumed
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/resumable/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", etag)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	replaced := bytes.Repeat([]byte("replaced"), 1024)
+	newETag := putObject(replaced)
+	c.Assert(newETag, Not(Equals), etag)
+
+	// the whole new object is sent instead of its tail
+	response = resume(len(partial), etag)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, newETag)
+	c.Assert(response.Header.Get("Content-Range"), Equals, "")
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data, replaced), Equals, true)
+
+	response = resume(len(partial), newETag)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	c.Assert(response.Header.Get("Content-Range"), Equals, "bytes 1024-8191/8192")
+	data, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(bytes.Equal(data, replaced[1024:]), Equals, true)
+}
+
+func (s *MyAPIXLCacheSuite) TestSanitizeHeaders(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/sanitize-headers", 0, nil)
+	c.Assert(err, IsNil)
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(strings.HasPrefix(response.Header.Get("Server"), "Minio/"), Equals, true)
+
+	globalSanitizeHeaders = true
+	defer func() { globalSanitizeHeaders = false }()
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/sanitize-headers/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	_, ok := response.Header["Server"]
+	c.Assert(ok, Equals, false)
+
+	// functional headers are left alone, errors included
+	for _, object := range []string{"object", "missing"} {
+		request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/sanitize-headers/"+object, 0, nil)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		_, ok = response.Header["Server"]
+		c.Assert(ok, Equals, false)
+		c.Assert(response.Header.Get("X-Amz-Request-Id"), Not(Equals), "")
+		c.Assert(response.Header.Get("Accept-Ranges"), Equals, "bytes")
+	}
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *MyAPIXLCacheSuite) TestListObjectsEncodingType(c *C) {
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/listencoding", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	keys := []string{"dir/a&b", "dir/control\x01char", "dir/new\nline", "dir/ünïcode ключ+"}
+	for _, key := range keys {
+		_, perr := s.storage.CreateObject("listencoding", key, "", 1, bytes.NewReader([]byte("x")), nil, nil)
+		c.Assert(perr, IsNil)
+	}
+	_, perr := s.storage.NewMultipartUpload("listencoding", "dir/a&upload", "")
+	c.Assert(perr, IsNil)
+
+	list := func(query string, v interface{}) {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/listencoding?"+query, 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		// responses are valid xml whatever the keys are
+		body, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		decoder := xml.NewDecoder(bytes.NewReader(body))
+		decoder.Strict = true
+		c.Assert(decoder.Decode(v), IsNil)
+	}
+
+	var listResponse ListObjectsResponse
+	list("prefix=dir%2F&delimiter=%2F&encoding-type=url", &listResponse)
+	c.Assert(listResponse.EncodingType, Equals, "url")
+	c.Assert(listResponse.Prefix, Equals, "dir/")
+	c.Assert(listResponse.Delimiter, Equals, "/")
+	c.Assert(len(listResponse.Contents), Equals, len(keys))
+	for i, content := range listResponse.Contents {
+		c.Assert(strings.ContainsAny(content.Key, "&\n\x01 +"), Equals, false)
+		key, err := url.QueryUnescape(content.Key)
+		c.Assert(err, IsNil)
+		c.Assert(key, Equals, keys[i])
+	}
+	c.Assert(listResponse.Contents[2].Key, Equals, "dir/new%0Aline")
+	c.Assert(listResponse.Contents[3].Key, Equals, "dir/%C3%BCn%C3%AFcode%20%D0%BA%D0%BB%D1%8E%D1%87%2B")
+
+	// common prefixes are encoded as well
+	listResponse = ListObjectsResponse{}
+	list("delimiter=%26&encoding-type=URL", &listResponse)
+	c.Assert(listResponse.EncodingType, Equals, "url")
+	c.Assert(listResponse.Delimiter, Equals, "%26")
+	c.Assert(len(listResponse.CommonPrefixes), Equals, 1)
+	c.Assert(listResponse.CommonPrefixes[0].Prefix, Equals, "dir/a%26")
+
+	// keys are sent as they are without it
+	listResponse = ListObjectsResponse{}
+	list("prefix=dir%2Fa", &listResponse)
+	c.Assert(listResponse.EncodingType, Equals, "")
+	c.Assert(listResponse.Contents[0].Key, Equals, "dir/a&b")
+
+	// version 2 pages with tokens of keys as they are
+	var listV2Response ListObjectsV2Response
+	list("list-type=2&max-keys=2&start-after=dir%2Fa%26b&encoding-type=url", &listV2Response)
+	c.Assert(listV2Response.EncodingType, Equals, "url")
+	c.Assert(listV2Response.StartAfter, Equals, "dir/a%26b")
+	c.Assert(listV2Response.Contents[0].Key, Equals, "dir/control%01char")
+	c.Assert(listV2Response.IsTruncated, Equals, true)
+	token := listV2Response.NextContinuationToken
+	listV2Response = ListObjectsV2Response{}
+	list("list-type=2&encoding-type=url&continuation-token="+url.QueryEscape(token), &listV2Response)
+	c.Assert(len(listV2Response.Contents), Equals, 1)
+	c.Assert(listV2Response.Contents[0].Key, Equals, "dir/%C3%BCn%C3%AFcode%20%D0%BA%D0%BB%D1%8E%D1%87%2B")
+
+	var uploadsResponse ListMultipartUploadsResponse
+	list("uploads&prefix=dir%2Fa&encoding-type=url", &uploadsResponse)
+	c.Assert(uploadsResponse.EncodingType, Equals, "url")
+	c.Assert(len(uploadsResponse.Upload), Equals, 1)
+	c.Assert(uploadsResponse.Upload[0].Key, Equals, "dir/a%26upload")
+
+	for _, query := range []string{"encoding-type=base64", "list-type=2&encoding-type=base64", "uploads&encoding-type=base64"} {
+		request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/listencoding?"+query, 0, nil)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		verifyError(c, response, "InvalidArgument", "Invalid Encoding Method specified in Request.", http.StatusBadRequest)
+	}
+}
+
+func (s *MyAPIXLCacheSuite) TestIdempotentPut(c *C) {
+	globalIdempotencyCache = newIdempotencyCache(time.Hour)
+	defer func() { globalIdempotencyCache = nil }()
+
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/idempotent", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	put := func(object, key, data string) *http.Response {
+		request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/idempotent/"+object, int64(len(data)), bytes.NewReader([]byte(data)))
+		c.Assert(err, IsNil)
+		if key != "" {
+			request.Header.Set("X-Minio-Idempotency-Key", key)
+		}
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	get := func(object string) string {
+		var buffer bytes.Buffer
+		_, perr := s.storage.GetObject(&buffer, "idempotent", object, 0, 0)
+		c.Assert(perr, IsNil)
+		return buffer.String()
+	}
+
+	response = put("object", "retry-1", "first")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Minio-Idempotent-Replay"), Equals, "")
+	etag := response.Header.Get("ETag")
+	c.Assert(etag, Not(Equals), "")
+
+	// objects are never overwritten in cache mode, a retry written again would fail
+	response = put("object", "retry-1", "first")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Minio-Idempotent-Replay"), Equals, "true")
+	c.Assert(response.Header.Get("ETag"), Equals, etag)
+	c.Assert(get("object"), Equals, "first")
+	response = put("object", "", "first")
+	c.Assert(response.StatusCode, Equals, http.StatusMethodNotAllowed)
+
+	// keys are per object, a key reused with other content is refused
+	response = put("other", "retry-1", "other")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Minio-Idempotent-Replay"), Equals, "")
+	response = put("object", "retry-1", "second")
+	verifyError(c, response, "IdempotencyKeyMismatch", "The idempotency key you provided was used by an earlier request with different content.", http.StatusConflict)
+	c.Assert(get("object"), Equals, "first")
+
+	response = put("object", strings.Repeat("k", 257), "first")
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+
+	// results are kept for the ttl only
+	globalIdempotencyCache.ttl = 0
+	response = put("expiring", "retry-2", "first")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = put("expiring", "retry-2", "first")
+	c.Assert(response.StatusCode, Equals, http.StatusMethodNotAllowed)
+
+	// retries stop waiting for a PUT stuck in progress
+	cache := newIdempotencyCache(time.Hour)
+	cache.wait = 10 * time.Millisecond
+	_, finish, _, perr := cache.start("stuck", nil)
+	c.Assert(perr, IsNil)
+	_, _, _, perr = cache.start("stuck", nil)
+	c.Assert(perr, Not(IsNil))
+	c.Assert(perr.ToGoError(), Equals, errIdempotentPutInProgress)
+	finish(xl.ObjectMetadata{MD5Sum: "etag"}, nil)
+	_, _, original, perr := cache.start("stuck", nil)
+	c.Assert(perr, IsNil)
+	c.Assert(original.metadata.MD5Sum, Equals, "etag")
+}
+
+func (s *MyAPIXLCacheSuite) TestRequestLimits(c *C) {
+	apiServer, perr := configureAPIServer(minioConfig{Address: "127.0.0.1:0", MaxHeaderBytes: 8 * 1024}, testAPIXLCacheServer.Config.Handler)
+	c.Assert(perr, IsNil)
+	server := httptest.NewUnstartedServer(apiServer.Handler)
+	server.Config = apiServer
+	server.Start()
+	defer server.Close()
+
+	client := http.Client{}
+	request, err := s.newRequest("GET", server.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Meta-Padding", strings.Repeat("a", 2*1024))
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// the server allows for some slack over the limit, send well above it
+	request, err = s.newRequest("GET", server.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Meta-Padding", strings.Repeat("a", 64*1024))
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusRequestHeaderFieldsTooLarge)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/?prefix="+strings.Repeat("a", 16*1024), 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "RequestURITooLong", "The query string of your request is too long.", http.StatusRequestURITooLong)
+}
+
+func (s *MyAPIXLCacheSuite) TestAuditLog(c *C) {
+	auditLog := filepath.Join(s.root, "audit.log")
+	logger, perr := newAuditLogger(auditLog)
+	c.Assert(perr, IsNil)
+	globalAuditLogger = logger
+	defer func() { globalAuditLogger = nil }()
+
+	client := http.Client{}
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/audit-bucket", 0, nil)
+	c.Assert(err, IsNil)
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/audit-bucket", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// rejected before the body is read
+	response, err = client.Get(testAPIXLCacheServer.URL + "/audit-bucket/object")
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// rejected once the body is verified
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/audit-bucket/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	authorization := request.Header.Get("Authorization")
+	request.Header.Set("Authorization", authorization[:len(authorization)-4]+"0000")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	data, err := ioutil.ReadFile(auditLog)
+	c.Assert(err, IsNil)
+	var entries []auditEntry
+	for _, line := range strings.Split(strings.TrimSpace(string(data)), "\n") {
+		var entry auditEntry
+		c.Assert(json.Unmarshal([]byte(line), &entry), IsNil)
+		entries = append(entries, entry)
+	}
+	c.Assert(len(entries), Equals, 4)
+	c.Assert(entries[0].AccessKey, Equals, s.accessKeyID)
+	c.Assert(entries[0].Method, Equals, "PUT")
+	c.Assert(entries[0].Bucket, Equals, "audit-bucket")
+	c.Assert(entries[0].Allowed, Equals, true)
+	c.Assert(entries[0].Reason, Equals, "SignatureMatch")
+	c.Assert(entries[1].Method, Equals, "GET")
+	c.Assert(entries[1].Allowed, Equals, true)
+	c.Assert(entries[2].AccessKey, Equals, "")
+	c.Assert(entries[2].Object, Equals, "object")
+	c.Assert(entries[2].Allowed, Equals, false)
+	c.Assert(entries[2].Reason, Equals, "AccessDenied")
+	c.Assert(entries[3].AccessKey, Equals, s.accessKeyID)
+	c.Assert(entries[3].Allowed, Equals, false)
+	c.Assert(entries[3].Reason, Equals, "SignatureDoesNotMatch")
+}
+
+func (s *MyAPIXLCacheSuite) TestConditionalGet(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/conditional", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/conditional/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	etag := "\"5eb63bbbe01eeed093cb22bb8f5acdc3\""
+
+	conditionalGet := func(header, value string) *http.Response {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/conditional/object", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set(header, value)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	// If-None-Match compares weakly
+	response = conditionalGet("If-None-Match", etag)
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+	c.Assert(response.Header.Get("ETag"), Equals, etag)
+	response = conditionalGet("If-None-Match", "\"other\", W/"+etag)
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+	response = conditionalGet("If-None-Match", "\"other\"")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// If-Match compares strongly
+	response = conditionalGet("If-Match", etag)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	response = conditionalGet("If-Match", "W/"+etag)
+	verifyError(c, response, "PreconditionFailed", "At least one of the preconditions you specified did not hold.", http.StatusPreconditionFailed)
+
+	response = conditionalGet("If-Modified-Since", time.Now().UTC().Add(time.Hour).Format(http.TimeFormat))
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+	response = conditionalGet("If-Unmodified-Since", time.Now().UTC().Add(-time.Hour).Format(http.TimeFormat))
+	c.Assert(response.StatusCode, Equals, http.StatusPreconditionFailed)
+
+	// a weak tag never matches the object to be deleted
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/conditional/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "W/"+etag)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPreconditionFailed)
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/conditional/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("If-Match", "\"other\", "+etag)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+}
+
+func (s *MyAPIXLCacheSuite) TestConditionalGetBoundary(c *C) {
+	cacheControl := globalCacheControl
+	globalCacheControl = "public, max-age=300"
+	defer func() { globalCacheControl = cacheControl }()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/conditional-boundary", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/conditional-boundary/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	etag := "\"5eb63bbbe01eeed093cb22bb8f5acdc3\""
+
+	conditionalRequest := func(method, header, value string) (*http.Response, []byte) {
+		request, err := s.newRequest(method, testAPIXLCacheServer.URL+"/conditional-boundary/object", 0, nil)
+		c.Assert(err, IsNil)
+		if header != "" {
+			request.Header.Set(header, value)
+		}
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		body, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		response.Body.Close()
+		return response, body
+	}
+
+	response, _ = conditionalRequest("GET", "", "")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Cache-Control"), Equals, "public, max-age=300")
+	lastModified := response.Header.Get("Last-Modified")
+	c.Assert(strings.HasSuffix(lastModified, " GMT"), Equals, true)
+	modified, e := http.ParseTime(lastModified)
+	c.Assert(e, IsNil)
+
+	for _, method := range []string{"GET", "HEAD"} {
+		// modification time has sub-second precision, Last-Modified is truncated to seconds
+		for _, condition := range [][2]string{{"If-Modified-Since", lastModified}, {"If-None-Match", etag}, {"If-None-Match", "W/" + etag}} {
+			response, body := conditionalRequest(method, condition[0], condition[1])
+			c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+			c.Assert(len(body), Equals, 0)
+			c.Assert(response.Header.Get("ETag"), Equals, etag)
+			c.Assert(response.Header.Get("Last-Modified"), Equals, lastModified)
+			c.Assert(response.Header.Get("Cache-Control"), Equals, "public, max-age=300")
+		}
+
+		response, body := conditionalRequest(method, "If-Modified-Since", modified.Add(-time.Second).Format(http.TimeFormat))
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		if method == "GET" {
+			c.Assert(string(body), Equals, "hello world")
+		}
+		response, _ = conditionalRequest(method, "If-Unmodified-Since", lastModified)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		response, _ = conditionalRequest(method, "If-Unmodified-Since", modified.Add(-time.Second).Format(http.TimeFormat))
+		c.Assert(response.StatusCode, Equals, http.StatusPreconditionFailed)
+	}
+
+	// not sent unless configured
+	globalCacheControl = ""
+	response, _ = conditionalRequest("GET", "If-Modified-Since", lastModified)
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+	c.Assert(response.Header.Get("Cache-Control"), Equals, "")
+}
+
+// doRawRequest - send request over a new connection speaking proto, the response is read
+// along with whether the server closed the connection after it
+func doRawRequest(c *C, request *http.Request, proto string) (*http.Response, []byte, bool) {
+	conn, err := net.Dial("tcp", request.URL.Host)
+	c.Assert(err, IsNil)
+	defer conn.Close()
+	var buffer bytes.Buffer
+	c.Assert(request.Write(&buffer), IsNil)
+	raw := bytes.Replace(buffer.Bytes(), []byte(" HTTP/1.1\r\n"), []byte(" "+proto+"\r\n"), 1)
+	_, err = conn.Write(raw)
+	c.Assert(err, IsNil)
+
+	conn.SetReadDeadline(time.Now().Add(5 * time.Second))
+	reader := bufio.NewReader(conn)
+	response, err := http.ReadResponse(reader, request)
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	_, err = reader.ReadByte()
+	return response, body, err == io.EOF
+}
+
+func (s *MyAPIXLCacheSuite) TestHTTP10(c *C) {
+	globalCompress = true
+	defer func() { globalCompress = false }()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/http10", 0, nil)
+	c.Assert(err, IsNil)
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	data := "hello world hello world"
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/http10", "post.txt", []byte(data),
+		map[string]string{"Content-Type": "text/plain"})
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	// compressible objects are sent with their length, never chunked or ended by a close only
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/http10/post.txt", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	request.Header.Set("Connection", "keep-alive")
+	response, body, closed := doRawRequest(c, request, "HTTP/1.0")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Proto, Equals, "HTTP/1.0")
+	c.Assert(response.TransferEncoding, IsNil)
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "")
+	c.Assert(response.ContentLength, Equals, int64(len(data)))
+	c.Assert(string(body), Equals, data)
+	c.Assert(closed, Equals, true)
+
+	// errors too
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/http10/missing", 0, nil)
+	c.Assert(err, IsNil)
+	response, body, closed = doRawRequest(c, request, "HTTP/1.0")
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(response.TransferEncoding, IsNil)
+	c.Assert(response.ContentLength, Equals, int64(len(body)))
+	c.Assert(closed, Equals, true)
+
+	// Connection: close of HTTP/1.1 clients is honored
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/http10", 0, nil)
+	c.Assert(err, IsNil)
+	request.Close = true
+	response, body, closed = doRawRequest(c, request, "HTTP/1.1")
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Close, Equals, true)
+	c.Assert(response.ContentLength, Equals, int64(len(body)))
+	c.Assert(closed, Equals, true)
+}
+
+func (s *MyAPIXLCacheSuite) TestCompression(c *C) {
+	globalCompress = true
+	defer func() { globalCompress = false }()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/compression", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// content type is only kept for browser uploads
+	data := "hello world hello world"
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/compression", "post.txt", []byte(data),
+		map[string]string{"Content-Type": "text/plain; charset=utf-8"})
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	md5Sum := md5.Sum([]byte(data))
+	etag := "\"" + hex.EncodeToString(md5Sum[:]) + "\""
+
+	get := func(acceptEncoding string) *http.Response {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/compression/post.txt", 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set("Accept-Encoding", acceptEncoding)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header["Vary"], DeepEquals, []string{"Origin", "Accept-Encoding"})
+		return response
+	}
+
+	response = get("gzip, deflate")
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "gzip")
+	c.Assert(response.Header.Get("ETag"), Equals, "W/"+etag)
+	reader, err := gzip.NewReader(response.Body)
+	c.Assert(err, IsNil)
+	body, err := ioutil.ReadAll(reader)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, data)
+
+	response = get("gzip;q=0, *")
+	c.Assert(response.Header.Get("Content-Encoding"), Equals, "")
+	c.Assert(response.Header.Get("ETag"), Equals, etag)
+	body, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, data)
+
+	// a cached compressed body is still fresh for the same request
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/compression/post.txt", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("Accept-Encoding", "gzip")
+	request.Header.Set("If-None-Match", "W/"+etag)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotModified)
+	c.Assert(response.Header.Get("ETag"), Equals, "W/"+etag)
+	c.Assert(response.Header["Vary"], DeepEquals, []string{"Origin", "Accept-Encoding"})
+}
+
 func (s *MyAPIXLCacheSuite) TestNonExistantBucket(c *C) {
 	request, err := s.newRequest("HEAD", testAPIXLCacheServer.URL+"/nonexistantbucket", 0, nil)
 	c.Assert(err, IsNil)
@@ -237,92 +1998,349 @@ func (s *MyAPIXLCacheSuite) TestNonExistantBucket(c *C) {
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+}
+
+func (s *MyAPIXLCacheSuite) TestEmptyObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/emptyobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/emptyobject/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/emptyobject/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	var buffer bytes.Buffer
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, buffer.Bytes()))
+}
+
+func (s *MyAPIXLCacheSuite) TestBucket(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucket", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/bucket", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIXLCacheSuite) TestObject(c *C) {
+	buffer := bytes.NewReader([]byte("hello world"))
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/testobject", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/testobject/object", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/testobject/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+}
+
+func (s *MyAPIXLCacheSuite) TestMultipleObjects(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+
+	//// test object 1
+
+	// get object
+	buffer1 := bytes.NewReader([]byte("hello one"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects/object1", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object1", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// verify response data
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello one")))
+
+	buffer2 := bytes.NewReader([]byte("hello two"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects/object2", int64(buffer2.Len()), buffer2)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object2", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// verify response data
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello two")))
+
+	buffer3 := bytes.NewReader([]byte("hello three"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects/object3", int64(buffer3.Len()), buffer3)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object3", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// verify object
+	responseBody, err = ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello three")))
+}
+
+func (s *MyAPIXLCacheSuite) TestNotImplemented(c *C) {
+	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/bucket/object?policy", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotImplemented)
+
+}
+
+func (s *MyAPIXLCacheSuite) TestHeader(c *C) {
+	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/bucket/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+}
+
+func (s *MyAPIXLCacheSuite) TestPutBucket(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-bucket", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-bucket-slash/", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIXLCacheSuite) TestPutObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-object", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-object/object", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
+
+func (s *MyAPIXLCacheSuite) TestListBuckets(c *C) {
+	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	var results ListBucketsResponse
+	decoder := xml.NewDecoder(response.Body)
+	err = decoder.Decode(&results)
+	c.Assert(err, IsNil)
+}
+
+func (s *MyAPIXLCacheSuite) TestNotBeAbleToCreateObjectInNonexistantBucket(c *C) {
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/innonexistantbucket/object", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
 }
 
-func (s *MyAPIXLCacheSuite) TestEmptyObject(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/emptyobject", 0, nil)
+func (s *MyAPIXLCacheSuite) TestHeadOnObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/headonobject", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/emptyobject/object", 0, nil)
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/headonobject/object1", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/emptyobject/object", 0, nil)
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/headonobject/object1", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-
-	var buffer bytes.Buffer
-	responseBody, err := ioutil.ReadAll(response.Body)
-	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, buffer.Bytes()))
 }
 
-func (s *MyAPIXLCacheSuite) TestBucket(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucket", 0, nil)
+func (s *MyAPIXLCacheSuite) TestHeadOnBucket(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/headonbucket", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/bucket", 0, nil)
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/headonbucket", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 }
 
-func (s *MyAPIXLCacheSuite) TestObject(c *C) {
-	buffer := bytes.NewReader([]byte("hello world"))
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/testobject", 0, nil)
+func (s *MyAPIXLCacheSuite) TestXMLNameNotInBucketListJson(c *C) {
+	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("Accept", "application/json")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/testobject/object", int64(buffer.Len()), buffer)
+	byteResults, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
+	c.Assert(strings.Contains(string(byteResults), "XML"), Equals, false)
+}
 
-	client = http.Client{}
-	response, err = client.Do(request)
+func (s *MyAPIXLCacheSuite) TestXMLNameNotInObjectListJson(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/xmlnamenotinobjectlistjson", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("Accept", "application/json")
+
+	client := http.Client{}
+	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/testobject/object", 0, nil)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/xmlnamenotinobjectlistjson", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("Accept", "application/json")
 
 	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	responseBody, err := ioutil.ReadAll(response.Body)
+	byteResults, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
-	c.Assert(responseBody, DeepEquals, []byte("hello world"))
-
+	c.Assert(strings.Contains(string(byteResults), "XML"), Equals, false)
 }
 
-func (s *MyAPIXLCacheSuite) TestMultipleObjects(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects", 0, nil)
+func (s *MyAPIXLCacheSuite) TestContentTypePersists(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/contenttype-persists", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -330,256 +2348,510 @@ func (s *MyAPIXLCacheSuite) TestMultipleObjects(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object", 0, nil)
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/contenttype-persists/one", int64(buffer1.Len()), buffer1)
+	delete(request.Header, "Content-Type")
 	c.Assert(err, IsNil)
 
 	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	//// test object 1
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/contenttype-persists/one", 0, nil)
+	c.Assert(err, IsNil)
 
-	// get object
-	buffer1 := bytes.NewReader([]byte("hello one"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects/object1", int64(buffer1.Len()), buffer1)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/contenttype-persists/one", 0, nil)
 	c.Assert(err, IsNil)
 
 	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object1", 0, nil)
+	buffer2 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/contenttype-persists/two", int64(buffer2.Len()), buffer2)
+	delete(request.Header, "Content-Type")
+	request.Header.Add("Content-Type", "application/json")
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	// verify response data
-	responseBody, err := ioutil.ReadAll(response.Body)
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/contenttype-persists/two", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello one")))
 
-	buffer2 := bytes.NewReader([]byte("hello two"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects/object2", int64(buffer2.Len()), buffer2)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "application/json")
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/contenttype-persists/two", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("Content-Type"), Equals, "application/json")
+}
+
+func (s *MyAPIXLCacheSuite) TestModTimePersists(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/modtime-persists", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object2", 0, nil)
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/modtime-persists/one", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Meta-Mtime", "1445412480.5")
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	// verify response data
-	responseBody, err = ioutil.ReadAll(response.Body)
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/modtime-persists/one", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello two")))
 
-	buffer3 := bytes.NewReader([]byte("hello three"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/multipleobjects/object3", int64(buffer3.Len()), buffer3)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.Header.Get("Last-Modified"), Equals, "Wed, 21 Oct 2015 07:28:00 GMT")
+	c.Assert(response.Header.Get("X-Amz-Meta-Mtime"), Equals, "1445412480.5")
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/modtime-persists", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/multipleobjects/object3", 0, nil)
+	listResponse := ListObjectsResponse{}
+	decoder := xml.NewDecoder(response.Body)
+	err = decoder.Decode(&listResponse)
 	c.Assert(err, IsNil)
+	c.Assert(len(listResponse.Contents), Equals, 1)
+	c.Assert(listResponse.Contents[0].LastModified, Equals, "2015-10-21T07:28:00.500Z")
 
-	client = http.Client{}
-	response, err = client.Do(request)
+	buffer2 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/modtime-persists/two", int64(buffer2.Len()), buffer2)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	request.Header.Set("X-Amz-Meta-Mtime", "yesterday")
 
-	// verify object
-	responseBody, err = ioutil.ReadAll(response.Body)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(true, Equals, bytes.Equal(responseBody, []byte("hello three")))
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
 }
 
-func (s *MyAPIXLCacheSuite) TestNotImplemented(c *C) {
-	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/bucket/object?policy", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectChecksum(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-checksum", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusNotImplemented)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	crc32c := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	crc32c.Write([]byte("hello world"))
+	crc32cSum := base64.StdEncoding.EncodeToString(crc32c.Sum(nil))
+	sha256Sum := sha256.Sum256([]byte("hello world"))
+
+	// verified checksum is returned on PUT, GET and HEAD
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-checksum/crc32c", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Algorithm", "CRC32C")
+	request.Header.Set("X-Amz-Checksum-Crc32c", crc32cSum)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Checksum-Crc32c"), Equals, crc32cSum)
+
+	for _, method := range []string{"HEAD", "GET"} {
+		request, err = s.newRequest(method, testAPIXLCacheServer.URL+"/object-checksum/crc32c", 0, nil)
+		c.Assert(err, IsNil)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		c.Assert(response.Header.Get("X-Amz-Checksum-Crc32c"), Equals, crc32cSum)
+	}
+
+	// algorithm alone computes the checksum without verification
+	buffer2 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-checksum/sha256", int64(buffer2.Len()), buffer2)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Algorithm", "SHA256")
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Checksum-Sha256"), Equals, base64.StdEncoding.EncodeToString(sha256Sum[:]))
+
+	// mismatching checksum is rejected and the object is not created
+	wrongSum := sha256.Sum256([]byte("hello world!"))
+	buffer3 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-checksum/mismatch", int64(buffer3.Len()), buffer3)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Sha256", base64.StdEncoding.EncodeToString(wrongSum[:]))
 
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "BadDigest", "The Content-MD5 you specified did not match what we received.", http.StatusBadRequest)
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/object-checksum/mismatch", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+
+	// unsupported algorithm, malformed checksum and checksum not matching the algorithm are invalid
+	for header, value := range map[string]string{
+		"X-Amz-Checksum-Algorithm": "MD4",
+		"X-Amz-Checksum-Crc32c":    "invalid",
+	} {
+		buffer4 := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-checksum/invalid", int64(buffer4.Len()), buffer4)
+		c.Assert(err, IsNil)
+		request.Header.Set(header, value)
+
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
+	}
+	buffer5 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-checksum/invalid", int64(buffer5.Len()), buffer5)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Algorithm", "SHA256")
+	request.Header.Set("X-Amz-Checksum-Crc32c", crc32cSum)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusBadRequest)
 }
 
-func (s *MyAPIXLCacheSuite) TestHeader(c *C) {
-	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/bucket/object", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectChecksumTrailer(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/checksum-trailer", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+	crc32c := crc32.New(crc32.MakeTable(crc32.Castagnoli))
+	crc32c.Write([]byte("hello world"))
+	crc32cSum := base64.StdEncoding.EncodeToString(crc32c.Sum(nil))
+	sha256Sum := sha256.Sum256([]byte("hello world"))
+
+	for _, object := range []string{"stored", "computed"} {
+		buffer := bytes.NewReader([]byte("hello world"))
+		request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/checksum-trailer/"+object, int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		if object == "stored" {
+			request.Header.Set("X-Amz-Checksum-Algorithm", "CRC32C")
+		}
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
+
+	getTrailer := func(object, algorithm, checksumHeader string) string {
+		request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/checksum-trailer/"+object, 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set("X-Amz-Checksum-Mode", "ENABLED")
+		if algorithm != "" {
+			request.Header.Set("X-Amz-Checksum-Algorithm", algorithm)
+		}
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		// checksum is announced up front and only sent after the body
+		c.Assert(response.TransferEncoding, DeepEquals, []string{"chunked"})
+		_, ok := response.Trailer[checksumHeader]
+		c.Assert(ok, Equals, true)
+		c.Assert(response.Trailer.Get(checksumHeader), Equals, "")
+		c.Assert(response.Header.Get(checksumHeader), Equals, "")
+		body, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		c.Assert(string(body), Equals, "hello world")
+		return response.Trailer.Get(checksumHeader)
+	}
+
+	// stored algorithm wins over the requested one
+	c.Assert(getTrailer("stored", "SHA256", "X-Amz-Checksum-Crc32c"), Equals, crc32cSum)
+	// computed on the fly for objects without a stored checksum
+	c.Assert(getTrailer("computed", "SHA256", "X-Amz-Checksum-Sha256"), Equals, base64.StdEncoding.EncodeToString(sha256Sum[:]))
+	c.Assert(getTrailer("computed", "", "X-Amz-Checksum-Crc32c"), Equals, crc32cSum)
+
+	// ranges do not cover the object, the stored checksum header is sent as usual
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/checksum-trailer/stored", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Checksum-Mode", "ENABLED")
+	request.Header.Set("Range", "bytes=0-4")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	c.Assert(response.Header.Get("X-Amz-Checksum-Crc32c"), Equals, crc32cSum)
+	c.Assert(len(response.Trailer), Equals, 0)
+	body, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(body), Equals, "hello")
 }
 
-func (s *MyAPIXLCacheSuite) TestPutBucket(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-bucket", 0, nil)
+func (s *MyAPIXLCacheSuite) TestPartialContent(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/partial-content", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-bucket-slash/", 0, nil)
+	buffer1 := bytes.NewReader([]byte("Hello World"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/partial-content/bar", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
 	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-}
 
-func (s *MyAPIXLCacheSuite) TestPutObject(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-object", 0, nil)
+	// prepare request
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/partial-content/bar", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
+	request.Header.Add("Accept", "application/json")
+	request.Header.Add("Range", "bytes=6-7")
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
+	partialObject, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+
+	c.Assert(string(partialObject), Equals, "Wo")
+}
 
+func (s *MyAPIXLCacheSuite) TestGetObjectRanges(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-ranges", 0, nil)
+	c.Assert(err, IsNil)
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/put-object/object", int64(buffer1.Len()), buffer1)
+	buffer := bytes.NewReader([]byte("Hello World"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/object-ranges/bar", int64(buffer.Len()), buffer)
 	c.Assert(err, IsNil)
-
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	_, perr := s.storage.CreateObject("object-ranges", "empty", "", 0, bytes.NewReader(nil), nil, nil)
+	c.Assert(perr, IsNil)
+
+	testCases := []struct {
+		object       string
+		hrange       string
+		status       int
+		body         string
+		contentRange string
+	}{
+		{"bar", "", http.StatusOK, "Hello World", ""},
+		{"bar", "bytes=0-4", http.StatusPartialContent, "Hello", "bytes 0-4/11"},
+		{"bar", "bytes=6-6", http.StatusPartialContent, "W", "bytes 6-6/11"},
+		{"bar", "bytes=10-10", http.StatusPartialContent, "d", "bytes 10-10/11"},
+		// open-ended ranges serve to the end
+		{"bar", "bytes=6-", http.StatusPartialContent, "World", "bytes 6-10/11"},
+		{"bar", "bytes=0-", http.StatusPartialContent, "Hello World", "bytes 0-10/11"},
+		// ranges ending beyond the end are cut short
+		{"bar", "bytes=6-100", http.StatusPartialContent, "World", "bytes 6-10/11"},
+		// suffix ranges, longer than the object are the whole object
+		{"bar", "bytes=-5", http.StatusPartialContent, "World", "bytes 6-10/11"},
+		{"bar", "bytes=-1", http.StatusPartialContent, "d", "bytes 10-10/11"},
+		{"bar", "bytes=-500", http.StatusPartialContent, "Hello World", "bytes 0-10/11"},
+		// reversed, out of range and malformed ranges
+		{"bar", "bytes=7-6", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=100-50", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=11-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=11-20", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=-0", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=--5", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=+1-5", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=a-5", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "bytes=0-1,3-4", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		{"bar", "items=0-4", http.StatusRequestedRangeNotSatisfiable, "", "bytes */11"},
+		// no range of an empty object can be satisfied
+		{"empty", "", http.StatusOK, "", ""},
+		{"empty", "bytes=0-", http.StatusRequestedRangeNotSatisfiable, "", "bytes */0"},
+		{"empty", "bytes=-5", http.StatusRequestedRangeNotSatisfiable, "", "bytes */0"},
+	}
+	for _, testCase := range testCases {
+		comment := Commentf("%s %q", testCase.object, testCase.hrange)
+		request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/object-ranges/"+testCase.object, 0, nil)
+		c.Assert(err, IsNil)
+		if testCase.hrange != "" {
+			request.Header.Set("Range", testCase.hrange)
+		}
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.Header.Get("Content-Range"), Equals, testCase.contentRange, comment)
+		if testCase.status == http.StatusRequestedRangeNotSatisfiable {
+			verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
+			continue
+		}
+		c.Assert(response.StatusCode, Equals, testCase.status, comment)
+		body, err := ioutil.ReadAll(response.Body)
+		response.Body.Close()
+		c.Assert(err, IsNil)
+		c.Assert(string(body), Equals, testCase.body, comment)
+		c.Assert(response.ContentLength, Equals, int64(len(testCase.body)), comment)
+	}
 }
 
-func (s *MyAPIXLCacheSuite) TestListBuckets(c *C) {
-	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/", 0, nil)
+func (s *MyAPIXLCacheSuite) TestListObjectsHandlerErrors(c *C) {
+	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/objecthandlererrors-.", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objecthandlererrors", 0, nil)
+	c.Assert(err, IsNil)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objecthandlererrors", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	var results ListBucketsResponse
-	decoder := xml.NewDecoder(response.Body)
-	err = decoder.Decode(&results)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objecthandlererrors?max-keys=-2", 0, nil)
+	c.Assert(err, IsNil)
+	client = http.Client{}
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidArgument", "Argument maxKeys must be an integer between 0 and 2147483647.", http.StatusBadRequest)
 }
 
-func (s *MyAPIXLCacheSuite) TestNotBeAbleToCreateObjectInNonexistantBucket(c *C) {
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/innonexistantbucket/object", int64(buffer1.Len()), buffer1)
+func (s *MyAPIXLCacheSuite) TestPutBucketErrors(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket-.", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
-}
+	verifyError(c, response, "InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest)
 
-func (s *MyAPIXLCacheSuite) TestHeadOnObject(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/headonobject", 0, nil)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket", 0, nil)
 	c.Assert(err, IsNil)
 	request.Header.Add("x-amz-acl", "private")
 
-	client := http.Client{}
-	response, err := client.Do(request)
+	client = http.Client{}
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/headonobject/object1", int64(buffer1.Len()), buffer1)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "private")
 
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	verifyError(c, response, "BucketAlreadyExists", "The requested bucket name is not available.", http.StatusConflict)
 
-	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/headonobject/object1", 0, nil)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket?acl", 0, nil)
 	c.Assert(err, IsNil)
+	request.Header.Add("x-amz-acl", "unknown")
 
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented)
 }
 
-func (s *MyAPIXLCacheSuite) TestHeadOnBucket(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/headonbucket", 0, nil)
+func (s *MyAPIXLCacheSuite) TestGetObjectErrors(c *C) {
+	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjecterrors", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
 
-	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/headonbucket", 0, nil)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/getobjecterrors", 0, nil)
 	c.Assert(err, IsNil)
 
+	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-}
 
-func (s *MyAPIXLCacheSuite) TestXMLNameNotInBucketListJson(c *C) {
-	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/", 0, nil)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjecterrors/bar", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("Accept", "application/json")
 
-	client := http.Client{}
-	response, err := client.Do(request)
+	client = http.Client{}
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
 
-	byteResults, err := ioutil.ReadAll(response.Body)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjecterrors-./bar", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(strings.Contains(string(byteResults), "XML"), Equals, false)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest)
+
 }
 
-func (s *MyAPIXLCacheSuite) TestXMLNameNotInObjectListJson(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/xmlnamenotinobjectlistjson", 0, nil)
+func (s *MyAPIXLCacheSuite) TestGetObjectRangeErrors(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/getobjectrangeerrors", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("Accept", "application/json")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/xmlnamenotinobjectlistjson", 0, nil)
+	buffer1 := bytes.NewReader([]byte("Hello World"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/getobjectrangeerrors/bar", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
-	request.Header.Add("Accept", "application/json")
 
 	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	byteResults, err := ioutil.ReadAll(response.Body)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjectrangeerrors/bar", 0, nil)
+	request.Header.Add("Range", "bytes=7-6")
 	c.Assert(err, IsNil)
-	c.Assert(strings.Contains(string(byteResults), "XML"), Equals, false)
+
+	client = http.Client{}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
 }
 
-func (s *MyAPIXLCacheSuite) TestContentTypePersists(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/contenttype-persists", 0, nil)
+func (s *MyAPIXLCacheSuite) TestBucketWebsite(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/website", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -587,59 +2859,82 @@ func (s *MyAPIXLCacheSuite) TestContentTypePersists(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/contenttype-persists/one", int64(buffer1.Len()), buffer1)
-	delete(request.Header, "Content-Type")
-	c.Assert(err, IsNil)
+	for object, data := range map[string]string{"index.html": "home", "docs/index.html": "docs", "error.html": "oops"} {
+		buffer := bytes.NewReader([]byte(data))
+		request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/website/"+object, int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+	}
 
-	client = http.Client{}
+	// bucket is not served until it has a website configuration
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/website?website", 0, nil)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	verifyError(c, response, "NoSuchWebsiteConfiguration", "The specified bucket does not have a website configuration.", http.StatusNotFound)
 
-	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/contenttype-persists/one", 0, nil)
+	response, err = http.Get(testWebsiteXLCacheServer.URL + "/website/")
 	c.Assert(err, IsNil)
+	verifyError(c, response, "NoSuchWebsiteConfiguration", "The specified bucket does not have a website configuration.", http.StatusNotFound)
 
+	// index document must not have slashes
+	buffer1 := bytes.NewReader([]byte("<WebsiteConfiguration><IndexDocument><Suffix>docs/index.html</Suffix></IndexDocument></WebsiteConfiguration>"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/website?website", int64(buffer1.Len()), buffer1)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
+	verifyError(c, response, "MalformedXML", "The XML you provided was not well-formed or did not validate against our published schema.", http.StatusBadRequest)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/contenttype-persists/one", 0, nil)
+	websiteConfig := "<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument><ErrorDocument><Key>error.html</Key></ErrorDocument></WebsiteConfiguration>"
+	buffer2 := bytes.NewReader([]byte(websiteConfig))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/website?website", int64(buffer2.Len()), buffer2)
 	c.Assert(err, IsNil)
-
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
 
-	buffer2 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/contenttype-persists/two", int64(buffer2.Len()), buffer2)
-	delete(request.Header, "Content-Type")
-	request.Header.Add("Content-Type", "application/json")
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/website?website", 0, nil)
 	c.Assert(err, IsNil)
-
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	getWebsiteConfig := WebsiteConfiguration{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(&getWebsiteConfig), IsNil)
+	c.Assert(getWebsiteConfig.IndexDocument.Suffix, Equals, "index.html")
+	c.Assert(getWebsiteConfig.ErrorDocument, NotNil)
+	c.Assert(getWebsiteConfig.ErrorDocument.Key, Equals, "error.html")
+
+	// "directories" serve their index document, misses serve the error document
+	for path, expected := range map[string]string{"/website": "home", "/website/": "home", "/website/docs/": "docs", "/website/docs/index.html": "docs"} {
+		response, err = http.Get(testWebsiteXLCacheServer.URL + path)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		responseBody, err := ioutil.ReadAll(response.Body)
+		c.Assert(err, IsNil)
+		c.Assert(string(responseBody), Equals, expected)
+	}
 
-	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/contenttype-persists/two", 0, nil)
+	response, err = http.Get(testWebsiteXLCacheServer.URL + "/website/missing.html")
 	c.Assert(err, IsNil)
-
-	response, err = client.Do(request)
+	c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	responseBody, err := ioutil.ReadAll(response.Body)
 	c.Assert(err, IsNil)
-	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
+	c.Assert(string(responseBody), Equals, "oops")
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/contenttype-persists/two", 0, nil)
+	// "directory" without trailing slash is redirected
+	transport := http.Transport{}
+	request, err = http.NewRequest("GET", testWebsiteXLCacheServer.URL+"/website/docs", nil)
 	c.Assert(err, IsNil)
-
-	response, err = client.Do(request)
+	response, err = transport.RoundTrip(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.Header.Get("Content-Type"), Equals, "application/octet-stream")
+	c.Assert(response.StatusCode, Equals, http.StatusFound)
+	c.Assert(response.Header.Get("Location"), Equals, "/website/docs/")
 }
 
-func (s *MyAPIXLCacheSuite) TestPartialContent(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/partial-content", 0, nil)
+func (s *MyAPIXLCacheSuite) TestWebsiteRedirectLocation(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/websiteredirect", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -647,212 +2942,383 @@ func (s *MyAPIXLCacheSuite) TestPartialContent(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	buffer1 := bytes.NewReader([]byte("Hello World"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/partial-content/bar", int64(buffer1.Len()), buffer1)
+	buffer1 := bytes.NewReader([]byte("<WebsiteConfiguration><IndexDocument><Suffix>index.html</Suffix></IndexDocument></WebsiteConfiguration>"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/websiteredirect?website", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
-
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	// prepare request
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/partial-content/bar", 0, nil)
+	buffer2 := bytes.NewReader([]byte("moved"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/websiteredirect/old.html", int64(buffer2.Len()), buffer2)
 	c.Assert(err, IsNil)
-	request.Header.Add("Accept", "application/json")
-	request.Header.Add("Range", "bytes=6-7")
+	request.Header.Set("X-Amz-Website-Redirect-Location", "ftp://example.com/new.html")
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidRequest", "The request is not valid, please check the request headers.", http.StatusBadRequest)
 
-	client = http.Client{}
+	buffer2 = bytes.NewReader([]byte("moved"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/websiteredirect/old.html", int64(buffer2.Len()), buffer2)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Website-Redirect-Location", "/websiteredirect/new.html")
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusPartialContent)
-	partialObject, err := ioutil.ReadAll(response.Body)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// outside website mode the object is served, the location is only metadata
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/websiteredirect/old.html", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Website-Redirect-Location"), Equals, "/websiteredirect/new.html")
 
-	c.Assert(string(partialObject), Equals, "Wo")
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/websiteredirect/old.html", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Website-Redirect-Location"), Equals, "/websiteredirect/new.html")
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "moved")
+
+	// website endpoint redirects instead of serving the object
+	transport := http.Transport{}
+	for _, method := range []string{"GET", "HEAD"} {
+		request, err = http.NewRequest(method, testWebsiteXLCacheServer.URL+"/websiteredirect/old.html", nil)
+		c.Assert(err, IsNil)
+		response, err = transport.RoundTrip(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusMovedPermanently)
+		c.Assert(response.Header.Get("Location"), Equals, "/websiteredirect/new.html")
+	}
 }
 
-func (s *MyAPIXLCacheSuite) TestListObjectsHandlerErrors(c *C) {
-	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/objecthandlererrors-.", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectMultipartAbort(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartabort", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest)
+	c.Assert(response.StatusCode, Equals, 200)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objecthandlererrors", 0, nil)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploads", 0, nil)
 	c.Assert(err, IsNil)
 
-	client = http.Client{}
 	response, err = client.Do(request)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	decoder := xml.NewDecoder(response.Body)
+	newResponse := &InitiateMultipartUploadResponse{}
+
+	err = decoder.Decode(newResponse)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
+	c.Assert(len(newResponse.UploadID) > 0, Equals, true)
+	uploadID := newResponse.UploadID
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objecthandlererrors", 0, nil)
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploadId="+uploadID+"&partNumber=1", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
-	client = http.Client{}
-	response, err = client.Do(request)
+	response1, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response1.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objecthandlererrors?max-keys=-2", 0, nil)
+	buffer2 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploadId="+uploadID+"&partNumber=2", int64(buffer2.Len()), buffer2)
 	c.Assert(err, IsNil)
-	client = http.Client{}
-	response, err = client.Do(request)
+
+	response2, err := client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "InvalidArgument", "Argument maxKeys must be an integer between 0 and 2147483647.", http.StatusBadRequest)
+	c.Assert(response2.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploadId="+uploadID, 0, nil)
+	c.Assert(err, IsNil)
+
+	response3, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response3.StatusCode, Equals, http.StatusNoContent)
 }
 
-func (s *MyAPIXLCacheSuite) TestPutBucketErrors(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket-.", 0, nil)
+func (s *MyAPIXLCacheSuite) TestBucketMultipartList(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucketmultipartlist", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest)
+	c.Assert(response.StatusCode, Equals, 200)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket", 0, nil)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/bucketmultipartlist/object?uploads", 0, nil)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
 
-	client = http.Client{}
 	response, err = client.Do(request)
-	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket", 0, nil)
+	decoder := xml.NewDecoder(response.Body)
+	newResponse := &InitiateMultipartUploadResponse{}
+
+	err = decoder.Decode(newResponse)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "private")
+	c.Assert(len(newResponse.UploadID) > 0, Equals, true)
+	uploadID := newResponse.UploadID
 
-	response, err = client.Do(request)
+	buffer1 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucketmultipartlist/object?uploadId="+uploadID+"&partNumber=1", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "BucketAlreadyExists", "The requested bucket name is not available.", http.StatusConflict)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/putbucket?acl", 0, nil)
+	response1, err := client.Do(request)
 	c.Assert(err, IsNil)
-	request.Header.Add("x-amz-acl", "unknown")
+	c.Assert(response1.StatusCode, Equals, http.StatusOK)
 
-	response, err = client.Do(request)
+	buffer2 := bytes.NewReader([]byte("hello world"))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucketmultipartlist/object?uploadId="+uploadID+"&partNumber=2", int64(buffer2.Len()), buffer2)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NotImplemented", "A header you provided implies functionality that is not implemented.", http.StatusNotImplemented)
+
+	response2, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response2.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/bucketmultipartlist?uploads", 0, nil)
+	c.Assert(err, IsNil)
+
+	response3, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response3.StatusCode, Equals, http.StatusOK)
+
+	decoder = xml.NewDecoder(response3.Body)
+	newResponse3 := &ListMultipartUploadsResponse{}
+	err = decoder.Decode(newResponse3)
+	c.Assert(err, IsNil)
+	c.Assert(newResponse3.Bucket, Equals, "bucketmultipartlist")
 }
 
-func (s *MyAPIXLCacheSuite) TestGetObjectErrors(c *C) {
-	request, err := s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjecterrors", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectKeyLimits(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchBucket", "The specified bucket does not exist.", http.StatusNotFound)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/getobjecterrors", 0, nil)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits/"+strings.Repeat("a", 1024), 5, bytes.NewReader([]byte("hello")))
 	c.Assert(err, IsNil)
-
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjecterrors/bar", 0, nil)
+	longKey := strings.Repeat("a", 1025)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits/"+longKey, 5, bytes.NewReader([]byte("hello")))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	verifyError(c, response, "KeyTooLongError", "Your key is too long.", http.StatusBadRequest)
 
-	client = http.Client{}
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/keylimits/"+longKey, 0, nil)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+	verifyError(c, response, "KeyTooLongError", "Your key is too long.", http.StatusBadRequest)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjecterrors-./bar", 0, nil)
+	// depth is unlimited by default
+	deepKey := strings.Repeat("a/", 100) + "object"
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits/"+deepKey, 5, bytes.NewReader([]byte("hello")))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	globalKeyLimits = newKeyLimits(1024, 16)
+	defer func() { globalKeyLimits = newKeyLimits(0, 0) }()
+
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits/"+deepKey, 5, bytes.NewReader([]byte("hello")))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	verifyError(c, response, "InvalidObjectName", "Your key has too many path segments.", http.StatusBadRequest)
 
+	// copying from a key over the limits is rejected just the same
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits/copy", 0, nil)
+	c.Assert(err, IsNil)
+	request.Header.Set("X-Amz-Copy-Source", "/keylimits/"+deepKey)
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "InvalidBucketName", "The specified bucket is not valid.", http.StatusBadRequest)
+	verifyError(c, response, "InvalidObjectName", "Your key has too many path segments.", http.StatusBadRequest)
 
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/keylimits/"+strings.Repeat("a/", 15)+"object", 5, bytes.NewReader([]byte("hello")))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 }
 
-func (s *MyAPIXLCacheSuite) TestGetObjectRangeErrors(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/getobjectrangeerrors", 0, nil)
+func (s *MyAPIXLCacheSuite) TestPathPrefix(c *C) {
+	c.Assert(normalizePathPrefix("storage/"), Equals, "/storage")
+	c.Assert(normalizePathPrefix("/"), Equals, "")
+	globalPathPrefix = "/storage"
+	defer func() { globalPathPrefix = "" }()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/storage/pathprefix", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("Location"), Equals, "/storage/pathprefix")
 
-	buffer1 := bytes.NewReader([]byte("Hello World"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/getobjectrangeerrors/bar", int64(buffer1.Len()), buffer1)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/storage/pathprefix/object", 5, bytes.NewReader([]byte("hello")))
 	c.Assert(err, IsNil)
-
-	client = http.Client{}
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/getobjectrangeerrors/bar", 0, nil)
-	request.Header.Add("Range", "bytes=7-6")
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/storage/pathprefix/object", 0, nil)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	data, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello")
 
-	client = http.Client{}
+	// clients sign the path they sent, prefix included
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/pathprefix/object", 0, nil)
+	c.Assert(err, IsNil)
+	request.URL.Path = "/storage/pathprefix/object"
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
-	verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
+	c.Assert(response.StatusCode, Equals, http.StatusForbidden)
+
+	// nothing is served outside of the prefix
+	for _, path := range []string{"/pathprefix/object", "/storagepathprefix/object"} {
+		request, err = s.newRequest("GET", testAPIXLCacheServer.URL+path, 0, nil)
+		c.Assert(err, IsNil)
+		response, err = client.Do(request)
+		c.Assert(err, IsNil)
+		c.Assert(response.StatusCode, Equals, http.StatusNotFound)
+	}
 }
 
-func (s *MyAPIXLCacheSuite) TestObjectMultipartAbort(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartabort", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectMultipartMaxUploads(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartmax", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, 200)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploads", 0, nil)
-	c.Assert(err, IsNil)
+	// uploads left open by other tests count as well
+	max := s.storage.CountMultipartUploads() + 2
+	xl.SetMaxMultipartUploads(max)
+	defer xl.SetMaxMultipartUploads(0)
+
+	newUpload := func(object string) *http.Response {
+		request, err := s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartmax/"+object+"?uploads", 0, nil)
+		c.Assert(err, IsNil)
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+	var uploadIDs []string
+	for _, object := range []string{"object1", "object2"} {
+		response = newUpload(object)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		newResponse := &InitiateMultipartUploadResponse{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(newResponse), IsNil)
+		uploadIDs = append(uploadIDs, newResponse.UploadID)
+	}
+	c.Assert(s.storage.CountMultipartUploads(), Equals, max)
 
+	response = newUpload("object3")
+	verifyError(c, response, "ServiceUnavailable", "Please reduce your request rate.", http.StatusServiceUnavailable)
+
+	// aborting an upload makes room for another one
+	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/objectmultipartmax/object1?uploadId="+uploadIDs[0], 0, nil)
+	c.Assert(err, IsNil)
 	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+	response = newUpload("object3")
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+}
 
-	decoder := xml.NewDecoder(response.Body)
-	newResponse := &InitiateMultipartUploadResponse{}
+func (s *MyAPIXLCacheSuite) TestUserMetadataCase(c *C) {
+	server := httptest.NewUnstartedServer(testAPIXLCacheServer.Config.Handler)
+	server.Listener = minhttp.NewHeaderCaseListener(server.Listener)
+	minhttp.EnableHeaderCase(server.Config)
+	server.Start()
+	defer server.Close()
 
-	err = decoder.Decode(newResponse)
+	client := http.Client{}
+	request, err := s.newRequest("PUT", server.URL+"/user-metadata", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(len(newResponse.UploadID) > 0, Equals, true)
-	uploadID := newResponse.UploadID
-
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploadId="+uploadID+"&partNumber=1", int64(buffer1.Len()), buffer1)
+	response, err := client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	response1, err := client.Do(request)
+	// header keys are written the way they are set, read the raw response as well
+	send := func(request *http.Request) string {
+		conn, err := net.Dial("tcp", server.Listener.Addr().String())
+		c.Assert(err, IsNil)
+		defer conn.Close()
+		c.Assert(request.Write(conn), IsNil)
+		raw, err := ioutil.ReadAll(conn)
+		c.Assert(err, IsNil)
+		return string(raw)
+	}
+	request, err = s.newRequest("PUT", server.URL+"/user-metadata/object", int64(len("hello")), bytes.NewReader([]byte("hello")))
 	c.Assert(err, IsNil)
-	c.Assert(response1.StatusCode, Equals, http.StatusOK)
+	request.Header["x-amz-meta-MyKey"] = []string{"value"}
+	request.Header["X-AMZ-META-UPPER"] = []string{"upper"}
+	request.Header.Set("X-Amz-Meta-Mtime", "1445412480.5")
+	c.Assert(strings.HasPrefix(send(request), "HTTP/1.1 200 OK"), Equals, true)
 
-	buffer2 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploadId="+uploadID+"&partNumber=2", int64(buffer2.Len()), buffer2)
+	request, err = s.newRequest("HEAD", server.URL+"/user-metadata/object", 0, nil)
 	c.Assert(err, IsNil)
+	raw := send(request)
+	c.Assert(strings.Contains(raw, "\r\nx-amz-meta-MyKey: value\r\n"), Equals, true)
+	c.Assert(strings.Contains(raw, "\r\nX-AMZ-META-UPPER: upper\r\n"), Equals, true)
+	metadata, perr := s.storage.GetObjectMetadata("user-metadata", "object")
+	c.Assert(perr, IsNil)
+	c.Assert(metadata.Metadata["x-amz-meta-MyKey"], Equals, "value")
+	c.Assert(metadata.Metadata["mtime"], Not(Equals), "")
+	_, ok := metadata.Metadata["X-Amz-Meta-Mtime"]
+	c.Assert(ok, Equals, false)
 
-	response2, err := client.Do(request)
+	// clients canonicalizing header keys still find them
+	request, err = s.newRequest("GET", server.URL+"/user-metadata/object", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(response2.StatusCode, Equals, http.StatusOK)
-
-	request, err = s.newRequest("DELETE", testAPIXLCacheServer.URL+"/objectmultipartabort/object?uploadId="+uploadID, 0, nil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("X-Amz-Meta-Mykey"), Equals, "value")
 
-	response3, err := client.Do(request)
+	// keys differing only in case name the same metadata
+	metadata, perr = s.storage.SetObjectMetadata("user-metadata", "object", map[string]string{"X-Amz-Meta-Mykey": "replaced"})
+	c.Assert(perr, IsNil)
+	c.Assert(metadata.Metadata["X-Amz-Meta-Mykey"], Equals, "replaced")
+	_, ok = metadata.Metadata["x-amz-meta-MyKey"]
+	c.Assert(ok, Equals, false)
+
+	// without the listener keys are only known in their canonical form
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/user-metadata/canonical", int64(len("hello")), bytes.NewReader([]byte("hello")))
 	c.Assert(err, IsNil)
-	c.Assert(response3.StatusCode, Equals, http.StatusNoContent)
+	request.Header["x-amz-meta-MyKey"] = []string{"value"}
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	metadata, perr = s.storage.GetObjectMetadata("user-metadata", "canonical")
+	c.Assert(perr, IsNil)
+	c.Assert(metadata.Metadata["X-Amz-Meta-Mykey"], Equals, "value")
 }
 
-func (s *MyAPIXLCacheSuite) TestBucketMultipartList(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucketmultipartlist", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectMultipartList(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartlist", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
@@ -860,7 +3326,7 @@ func (s *MyAPIXLCacheSuite) TestBucketMultipartList(c *C) {
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, 200)
 
-	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/bucketmultipartlist/object?uploads", 0, nil)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploads", 0, nil)
 	c.Assert(err, IsNil)
 
 	response, err = client.Do(request)
@@ -875,7 +3341,7 @@ func (s *MyAPIXLCacheSuite) TestBucketMultipartList(c *C) {
 	uploadID := newResponse.UploadID
 
 	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucketmultipartlist/object?uploadId="+uploadID+"&partNumber=1", int64(buffer1.Len()), buffer1)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploadId="+uploadID+"&partNumber=1", int64(buffer1.Len()), buffer1)
 	c.Assert(err, IsNil)
 
 	response1, err := client.Do(request)
@@ -883,79 +3349,176 @@ func (s *MyAPIXLCacheSuite) TestBucketMultipartList(c *C) {
 	c.Assert(response1.StatusCode, Equals, http.StatusOK)
 
 	buffer2 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/bucketmultipartlist/object?uploadId="+uploadID+"&partNumber=2", int64(buffer2.Len()), buffer2)
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploadId="+uploadID+"&partNumber=2", int64(buffer2.Len()), buffer2)
 	c.Assert(err, IsNil)
 
 	response2, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response2.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/bucketmultipartlist?uploads", 0, nil)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploadId="+uploadID, 0, nil)
 	c.Assert(err, IsNil)
 
 	response3, err := client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response3.StatusCode, Equals, http.StatusOK)
 
-	decoder = xml.NewDecoder(response3.Body)
-	newResponse3 := &ListMultipartUploadsResponse{}
-	err = decoder.Decode(newResponse3)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultipartlist/object?max-parts=-2&uploadId="+uploadID, 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(newResponse3.Bucket, Equals, "bucketmultipartlist")
+
+	response4, err := client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response4, "InvalidArgument", "Argument maxParts must be an integer between 1 and 10000.", http.StatusBadRequest)
 }
 
-func (s *MyAPIXLCacheSuite) TestObjectMultipartList(c *C) {
-	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartlist", 0, nil)
+func (s *MyAPIXLCacheSuite) TestObjectMultipartConcurrentParts(c *C) {
+	globalPartLimiter = newPartLimiter(2)
+	defer func() { globalPartLimiter = newPartLimiter(0) }()
+
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartconcurrent", 0, nil)
 	c.Assert(err, IsNil)
 
 	client := http.Client{}
 	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response.StatusCode, Equals, 200)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploads", 0, nil)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartconcurrent/object?uploads", 0, nil)
 	c.Assert(err, IsNil)
-
 	response, err = client.Do(request)
+	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
-
-	decoder := xml.NewDecoder(response.Body)
 	newResponse := &InitiateMultipartUploadResponse{}
-
-	err = decoder.Decode(newResponse)
-	c.Assert(err, IsNil)
-	c.Assert(len(newResponse.UploadID) > 0, Equals, true)
+	c.Assert(xml.NewDecoder(response.Body).Decode(newResponse), IsNil)
 	uploadID := newResponse.UploadID
 
-	buffer1 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploadId="+uploadID+"&partNumber=1", int64(buffer1.Len()), buffer1)
+	// all parts are fired at once, at most two are uploaded at a time and none is lost
+	parts := 20
+	requests := make([]*http.Request, parts)
+	for i := range requests {
+		buffer := bytes.NewReader([]byte("part" + strconv.Itoa(i+1) + ","))
+		requests[i], err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartconcurrent/object?uploadId="+uploadID+"&partNumber="+strconv.Itoa(i+1), int64(buffer.Len()), buffer)
+		c.Assert(err, IsNil)
+	}
+	etags := make([]string, parts)
+	var wg sync.WaitGroup
+	for i := range requests {
+		wg.Add(1)
+		go func(i int) {
+			defer wg.Done()
+			response, err := client.Do(requests[i])
+			if err != nil || response.StatusCode != http.StatusOK {
+				c.Errorf("part %d failed: %v", i+1, err)
+				return
+			}
+			etags[i] = response.Header.Get("ETag")
+		}(i)
+	}
+	wg.Wait()
+	c.Assert(globalPartLimiter.getInFlight(uploadID), Equals, 0)
+
+	completeUploads := &xl.CompleteMultipartUpload{}
+	expected := ""
+	for i := range etags {
+		completeUploads.Part = append(completeUploads.Part, xl.CompletePart{PartNumber: i + 1, ETag: etags[i]})
+		expected += "part" + strconv.Itoa(i+1) + ","
+	}
+	completeBytes, err := xml.Marshal(completeUploads)
+	c.Assert(err, IsNil)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartconcurrent/object?uploadId="+uploadID, int64(len(completeBytes)), bytes.NewReader(completeBytes))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	response1, err := client.Do(request)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultipartconcurrent/object", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(response1.StatusCode, Equals, http.StatusOK)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, expected)
+}
 
-	buffer2 := bytes.NewReader([]byte("hello world"))
-	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploadId="+uploadID+"&partNumber=2", int64(buffer2.Len()), buffer2)
+func (s *MyAPIXLCacheSuite) TestObjectMultipartCopyPart(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartcopy", 0, nil)
 	c.Assert(err, IsNil)
 
-	response2, err := client.Do(request)
+	client := http.Client{}
+	response, err := client.Do(request)
 	c.Assert(err, IsNil)
-	c.Assert(response2.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultipartlist/object?uploadId="+uploadID, 0, nil)
+	source := "0123456789abcdefghijklmnopqrstuvwxyz"
+	buffer := bytes.NewReader([]byte(source))
+	request, err = s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartcopy/source", int64(buffer.Len()), buffer)
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	response3, err := client.Do(request)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartcopy/object?uploads", 0, nil)
 	c.Assert(err, IsNil)
-	c.Assert(response3.StatusCode, Equals, http.StatusOK)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	newResponse := &InitiateMultipartUploadResponse{}
+	c.Assert(xml.NewDecoder(response.Body).Decode(newResponse), IsNil)
+	uploadID := newResponse.UploadID
 
-	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultipartlist/object?max-parts=-2&uploadId="+uploadID, 0, nil)
+	copyPart := func(partNumber int, copySource, copySourceRange string) *http.Response {
+		request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/objectmultipartcopy/object?uploadId="+uploadID+"&partNumber="+strconv.Itoa(partNumber), 0, nil)
+		c.Assert(err, IsNil)
+		request.Header.Set("X-Amz-Copy-Source", copySource)
+		if copySourceRange != "" {
+			request.Header.Set("X-Amz-Copy-Source-Range", copySourceRange)
+		}
+		response, err := client.Do(request)
+		c.Assert(err, IsNil)
+		return response
+	}
+
+	// ranges outside of the source are refused
+	for _, copySourceRange := range []string{"bytes=10-36", "bytes=20-10", "bytes=-10", "bytes=10-", "10-20"} {
+		response = copyPart(1, "/objectmultipartcopy/source", copySourceRange)
+		verifyError(c, response, "InvalidRange", "The requested range cannot be satisfied.", http.StatusRequestedRangeNotSatisfiable)
+	}
+	response = copyPart(1, "/objectmultipartcopy/missing", "bytes=0-1")
+	verifyError(c, response, "NoSuchKey", "The specified key does not exist.", http.StatusNotFound)
+
+	var etags []string
+	for i, copySourceRange := range []string{"bytes=26-35", "bytes=0-9", ""} {
+		response = copyPart(i+1, "objectmultipartcopy%2Fsource", copySourceRange)
+		c.Assert(response.StatusCode, Equals, http.StatusOK)
+		copyResponse := &CopyObjectPartResponse{}
+		c.Assert(xml.NewDecoder(response.Body).Decode(copyResponse), IsNil)
+		c.Assert(copyResponse.LastModified, Not(Equals), "")
+		etags = append(etags, strings.Trim(copyResponse.ETag, "\""))
+	}
+	partMD5Sum := md5.Sum([]byte("0123456789"))
+	c.Assert(etags[1], Equals, hex.EncodeToString(partMD5Sum[:]))
+
+	completeUploads := &xl.CompleteMultipartUpload{}
+	for i, etag := range etags {
+		completeUploads.Part = append(completeUploads.Part, xl.CompletePart{PartNumber: i + 1, ETag: etag})
+	}
+	completeBytes, err := xml.Marshal(completeUploads)
+	c.Assert(err, IsNil)
+	request, err = s.newRequest("POST", testAPIXLCacheServer.URL+"/objectmultipartcopy/object?uploadId="+uploadID, int64(len(completeBytes)), bytes.NewReader(completeBytes))
+	c.Assert(err, IsNil)
+	response, err = client.Do(request)
 	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
 
-	response4, err := client.Do(request)
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultipartcopy/object", 0, nil)
 	c.Assert(err, IsNil)
-	verifyError(c, response4, "InvalidArgument", "Argument maxParts must be an integer between 1 and 10000.", http.StatusBadRequest)
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(string(responseBody), Equals, "qrstuvwxyz0123456789"+source)
 }
 
 func (s *MyAPIXLCacheSuite) TestObjectMultipart(c *C) {
@@ -1024,6 +3587,205 @@ func (s *MyAPIXLCacheSuite) TestObjectMultipart(c *C) {
 	response, err = client.Do(request)
 	c.Assert(err, IsNil)
 	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	// multipart ETag is md5sum of the concatenated part md5sums suffixed with number of parts
+	partMD5Sum := md5.Sum([]byte("hello world"))
+	multipartMD5Sum := md5.Sum(append(partMD5Sum[:], partMD5Sum[:]...))
+	expectedETag := "\"" + hex.EncodeToString(multipartMD5Sum[:]) + "-2\""
+
+	completeResponse := &CompleteMultipartUploadResponse{}
+	err = xml.NewDecoder(response.Body).Decode(completeResponse)
+	c.Assert(err, IsNil)
+	c.Assert(completeResponse.ETag, Equals, expectedETag)
+
+	request, err = s.newRequest("HEAD", testAPIXLCacheServer.URL+"/objectmultiparts/object", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	c.Assert(response.Header.Get("ETag"), Equals, expectedETag)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/objectmultiparts", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	listResponse := ListObjectsResponse{}
+	err = xml.NewDecoder(response.Body).Decode(&listResponse)
+	c.Assert(err, IsNil)
+	c.Assert(len(listResponse.Contents), Equals, 1)
+	c.Assert(listResponse.Contents[0].ETag, Equals, expectedETag)
+}
+
+func (s *MyAPIXLCacheSuite) newPostPolicyRequest(urlStr, key string, data []byte, formFields map[string]string) (*http.Request, error) {
+	t := time.Now().UTC()
+	scope := strings.Join([]string{
+		t.Format(yyyymmdd),
+		"us-east-1",
+		"s3",
+		"aws4_request",
+	}, "/")
+	policy := `{"expiration": "` + t.Add(10*time.Minute).Format(time.RFC3339Nano) + `", "conditions": [` +
+		`["starts-with", "$key", "post"], ["content-length-range", 1, 32]]}`
+	encodedPolicy := base64.StdEncoding.EncodeToString([]byte(policy))
+
+	date := sumHMAC([]byte("AWS4"+s.secretAccessKey), []byte(t.Format(yyyymmdd)))
+	region := sumHMAC(date, []byte("us-east-1"))
+	service := sumHMAC(region, []byte("s3"))
+	signingKey := sumHMAC(service, []byte("aws4_request"))
+
+	fields := map[string]string{
+		"key":              key,
+		"policy":           encodedPolicy,
+		"x-amz-algorithm":  authHeaderPrefix,
+		"x-amz-credential": s.accessKeyID + "/" + scope,
+		"x-amz-date":       t.Format(iso8601Format),
+		"x-amz-signature":  hex.EncodeToString(sumHMAC(signingKey, []byte(encodedPolicy))),
+	}
+	for k, v := range formFields {
+		fields[k] = v
+	}
+
+	var body bytes.Buffer
+	writer := multipart.NewWriter(&body)
+	for k, v := range fields {
+		if err := writer.WriteField(k, v); err != nil {
+			return nil, err
+		}
+	}
+	file, err := writer.CreateFormFile("file", "upload.txt")
+	if err != nil {
+		return nil, err
+	}
+	if _, err = file.Write(data); err != nil {
+		return nil, err
+	}
+	if err = writer.Close(); err != nil {
+		return nil, err
+	}
+	req, err := http.NewRequest("POST", urlStr, &body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Content-Type", writer.FormDataContentType())
+	return req, nil
+}
+
+func (s *MyAPIXLCacheSuite) TestPostPolicyObject(c *C) {
+	request, err := s.newRequest("PUT", testAPIXLCacheServer.URL+"/postpolicy", 0, nil)
+	c.Assert(err, IsNil)
+
+	client := http.Client{}
+	response, err := client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/postpolicy", "postobject1", []byte("hello world"), nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusNoContent)
+
+	request, err = s.newRequest("GET", testAPIXLCacheServer.URL+"/postpolicy/postobject1", 0, nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusOK)
+	responseBody, err := ioutil.ReadAll(response.Body)
+	c.Assert(err, IsNil)
+	c.Assert(responseBody, DeepEquals, []byte("hello world"))
+
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/postpolicy", "postobject2", []byte("hello world"),
+		map[string]string{"success_action_status": "201"})
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusCreated)
+	postResponse := PostResponse{}
+	decoder := xml.NewDecoder(response.Body)
+	err = decoder.Decode(&postResponse)
+	c.Assert(err, IsNil)
+	c.Assert(postResponse.Bucket, Equals, "postpolicy")
+	c.Assert(postResponse.Key, Equals, "postobject2")
+
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/postpolicy", "postobject3", []byte("hello world"),
+		map[string]string{"success_action_redirect": "http://example.com/done"})
+	c.Assert(err, IsNil)
+
+	response, err = http.DefaultTransport.RoundTrip(request)
+	c.Assert(err, IsNil)
+	c.Assert(response.StatusCode, Equals, http.StatusSeeOther)
+	c.Assert(strings.HasPrefix(response.Header.Get("Location"), "http://example.com/done?"), Equals, true)
+
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/postpolicy", "postobject4", bytes.Repeat([]byte("a"), 64), nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "EntityTooLarge", "Your proposed upload exceeds the maximum allowed object size.", http.StatusBadRequest)
+
+	request, err = s.newPostPolicyRequest(testAPIXLCacheServer.URL+"/postpolicy", "invalidprefix", []byte("hello world"), nil)
+	c.Assert(err, IsNil)
+
+	response, err = client.Do(request)
+	c.Assert(err, IsNil)
+	verifyError(c, response, "MalformedPOSTRequest", "The body of your POST request is not well-formed multipart/form-data.", http.StatusBadRequest)
+}
+
+// endlessReader - endless reader counting the bytes read from it
+type endlessReader struct {
+	read int64
+}
+
+func (r *endlessReader) Read(p []byte) (int, error) {
+	r.read += int64(len(p))
+	return len(p), nil
+}
+
+func (s *MyAPIXLCacheSuite) TestPostPolicyFileSizeLimit(c *C) {
+	policy := `{"expiration": "` + time.Now().UTC().Add(10*time.Minute).Format(time.RFC3339Nano) + `", "conditions": [` +
+		`["content-length-range", 1, 32]]}`
+	formValues := map[string]string{
+		"X-Amz-Algorithm": authHeaderPrefix,
+		"Policy":          base64.StdEncoding.EncodeToString([]byte(policy)),
+	}
+	fileBody, fileSize, perr := applyPolicy(formValues, strings.NewReader("hello world"))
+	c.Assert(perr, IsNil)
+	c.Assert(fileSize, Equals, int64(len("hello world")))
+	data, err := ioutil.ReadAll(fileBody)
+	c.Assert(err, IsNil)
+	c.Assert(string(data), Equals, "hello world")
+
+	// files larger than the policy allows are refused without being read to the end
+	file := new(endlessReader)
+	_, _, perr = applyPolicy(formValues, file)
+	c.Assert(perr, Not(IsNil))
+	c.Assert(perr.ToGoError(), Equals, errPolicyEntityTooLarge)
+	c.Assert(file.read <= 32+bytes.MinRead, Equals, true)
+
+	// files are held in memory, without content-length-range they are bounded all the same
+	noRange := `{"expiration": "` + time.Now().UTC().Add(10*time.Minute).Format(time.RFC3339Nano) + `", "conditions": []}`
+	file = new(endlessReader)
+	_, _, perr = applyPolicy(map[string]string{
+		"X-Amz-Algorithm": authHeaderPrefix,
+		"Policy":          base64.StdEncoding.EncodeToString([]byte(noRange)),
+	}, file)
+	c.Assert(perr, Not(IsNil))
+	c.Assert(perr.ToGoError(), Equals, errPolicyEntityTooLarge)
+	c.Assert(file.read <= maxPostPolicyFileSize+bytes.MinRead, Equals, true)
+
+	// the policy is verified before the file is read
+	formValues["X-Amz-Algorithm"] = "AWS4-HMAC-SHA1"
+	file = new(endlessReader)
+	_, _, perr = applyPolicy(formValues, file)
+	c.Assert(perr, Not(IsNil))
+	c.Assert(file.read, Equals, int64(0))
 }
 
 func verifyError(c *C, response *http.Response, code, description string, statusCode int) {
@@ -0,0 +1,205 @@
+/*
+ * Minio Cloud Storage, (C) 2015 Minio, Inc.
+ *
+ * Licensed under the Apache License, Version 2.0 (the "License");
+ * you may not use this file except in compliance with the License.
+ * You may obtain a copy of the License at
+ *
+ *     http://www.apache.org/licenses/LICENSE-2.0
+ *
+ * Unless required by applicable law or agreed to in writing, software
+ * distributed under the License is distributed on an "AS IS" BASIS,
+ * WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+ * See the License for the specific language governing permissions and
+ * limitations under the License.
+ */
+
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/pem"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	. "gopkg.in/check.v1"
+)
+
+type TLSSuite struct{}
+
+var _ = Suite(&TLSSuite{})
+
+func (s *TLSSuite) TestTLSMinVersion(c *C) {
+	version, err := getTLSMinVersion("")
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint16(tls.VersionTLS12))
+
+	version, err = getTLSMinVersion("1.3")
+	c.Assert(err, IsNil)
+	c.Assert(version, Equals, uint16(tls.VersionTLS13))
+
+	_, err = getTLSMinVersion("1.4")
+	c.Assert(err, NotNil)
+	c.Assert(strings.Contains(err.ToGoError().Error(), "1.0, 1.1, 1.2, 1.3"), Equals, true)
+}
+
+func (s *TLSSuite) TestTLSCipherSuites(c *C) {
+	ciphers, err := getTLSCipherSuites("")
+	c.Assert(err, IsNil)
+	c.Assert(ciphers, DeepEquals, defaultTLSCipherSuites)
+
+	ciphers, err = getTLSCipherSuites("TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384")
+	c.Assert(err, IsNil)
+	c.Assert(ciphers, DeepEquals, []uint16{tls.TLS_ECDHE_RSA_WITH_AES_128_GCM_SHA256, tls.TLS_ECDHE_RSA_WITH_AES_256_GCM_SHA384})
+
+	_, err = getTLSCipherSuites("TLS_UNKNOWN_CIPHER")
+	c.Assert(err, NotNil)
+}
+
+// writeTestCertificate - write a certificate issued by a test CA naming responder, followed by
+// the CA certificate if withIssuer, returns the CA certificate
+func writeTestCertificate(c *C, certFile, keyFile, responder string, withIssuer bool) *x509.Certificate {
+	caKey, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(e, IsNil)
+	caTemplate := &x509.Certificate{
+		SerialNumber:          big.NewInt(1),
+		Subject:               pkix.Name{CommonName: "minio test CA"},
+		NotBefore:             time.Now().Add(-time.Hour),
+		NotAfter:              time.Now().Add(time.Hour),
+		IsCA:                  true,
+		BasicConstraintsValid: true,
+		KeyUsage:              x509.KeyUsageCertSign,
+	}
+	caDER, e := x509.CreateCertificate(rand.Reader, caTemplate, caTemplate, &caKey.PublicKey, caKey)
+	c.Assert(e, IsNil)
+	ca, e := x509.ParseCertificate(caDER)
+	c.Assert(e, IsNil)
+	key, e := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	c.Assert(e, IsNil)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(42),
+		Subject:      pkix.Name{CommonName: "localhost"},
+		DNSNames:     []string{"localhost"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth},
+		OCSPServer:   []string{responder},
+	}
+	certDER, e := x509.CreateCertificate(rand.Reader, template, ca, &key.PublicKey, caKey)
+	c.Assert(e, IsNil)
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: certDER})
+	if withIssuer {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: caDER})...)
+	}
+	c.Assert(ioutil.WriteFile(certFile, certPEM, 0600), IsNil)
+	keyDER, e := x509.MarshalECPrivateKey(key)
+	c.Assert(e, IsNil)
+	c.Assert(ioutil.WriteFile(keyFile, pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER}), 0600), IsNil)
+	return ca
+}
+
+// newTestOCSPResponse - successful response for certID, signatures are not checked by the server
+func newTestOCSPResponse(c *C, certID ocspCertID, nextUpdate time.Time) []byte {
+	basic, e := asn1.Marshal(ocspBasicResponse{
+		TBSResponseData: ocspResponseData{
+			ResponderID: asn1.RawValue{Class: asn1.ClassContextSpecific, Tag: 2, IsCompound: true, Bytes: []byte{0x04, 0x00}},
+			ProducedAt:  time.Now().UTC().Truncate(time.Second),
+			Responses: []ocspSingleResponse{{
+				CertID:     certID,
+				Good:       true,
+				ThisUpdate: time.Now().Add(-time.Minute).UTC().Truncate(time.Second),
+				NextUpdate: nextUpdate.UTC().Truncate(time.Second),
+			}},
+		},
+		SignatureAlgorithm: pkix.AlgorithmIdentifier{Algorithm: asn1.ObjectIdentifier{1, 2, 840, 10045, 4, 3, 2}},
+		Signature:          asn1.BitString{Bytes: []byte{0}, BitLength: 8},
+	})
+	c.Assert(e, IsNil)
+	response, e := asn1.Marshal(ocspResponse{ResponseBytes: ocspResponseBytes{ResponseType: ocspBasicResponseOID, Response: basic}})
+	c.Assert(e, IsNil)
+	return response
+}
+
+func (s *TLSSuite) TestOCSPStaple(c *C) {
+	root, e := ioutil.TempDir(os.TempDir(), "minio-ocsp-")
+	c.Assert(e, IsNil)
+	defer os.RemoveAll(root)
+
+	var status int
+	var response []byte
+	var serial int64
+	responder := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, e := ioutil.ReadAll(r.Body)
+		c.Assert(e, IsNil)
+		var request ocspRequest
+		_, e = asn1.Unmarshal(body, &request)
+		c.Assert(e, IsNil)
+		certID := request.TBSRequest.RequestList[0].CertID
+		if serial != 0 {
+			certID.SerialNumber = big.NewInt(serial)
+		}
+		response = newTestOCSPResponse(c, certID, time.Now().Add(time.Hour))
+		w.WriteHeader(status)
+		w.Write(response)
+	}))
+	defer responder.Close()
+
+	certFile, keyFile := filepath.Join(root, "public.crt"), filepath.Join(root, "private.key")
+	writeTestCertificate(c, certFile, keyFile, responder.URL, false)
+	_, err := newOCSPStapler(certFile, keyFile)
+	c.Assert(err, NotNil)
+	c.Assert(err.ToGoError(), Equals, errNoIssuerCertificate)
+
+	ca := writeTestCertificate(c, certFile, keyFile, responder.URL, true)
+	stapler, err := newOCSPStapler(certFile, keyFile)
+	c.Assert(err, IsNil)
+	cert, e := stapler.getCertificate(nil)
+	c.Assert(e, IsNil)
+	c.Assert(cert.OCSPStaple, IsNil)
+
+	status = http.StatusOK
+	refreshIn, err := stapler.refresh()
+	c.Assert(err, IsNil)
+	c.Assert(refreshIn > 29*time.Minute && refreshIn <= 30*time.Minute, Equals, true)
+	staple := response
+	roots := x509.NewCertPool()
+	roots.AddCert(ca)
+	serverConn, clientConn := net.Pipe()
+	go tls.Server(serverConn, &tls.Config{GetCertificate: stapler.getCertificate}).Handshake()
+	client := tls.Client(clientConn, &tls.Config{RootCAs: roots, ServerName: "localhost"})
+	c.Assert(client.Handshake(), IsNil)
+	c.Assert(client.ConnectionState().OCSPResponse, DeepEquals, staple)
+	client.Close()
+
+	// failures keep the staple until it expires
+	status = http.StatusInternalServerError
+	refreshIn, err = stapler.refresh()
+	c.Assert(err, NotNil)
+	c.Assert(refreshIn, Equals, ocspRetryInterval)
+	cert, _ = stapler.getCertificate(nil)
+	c.Assert(cert.OCSPStaple, DeepEquals, staple)
+	stapler.nextUpdate = time.Now().Add(-time.Second)
+	cert, _ = stapler.getCertificate(nil)
+	c.Assert(cert.OCSPStaple, IsNil)
+
+	// responses for other certificates are never stapled
+	status, serial = http.StatusOK, 43
+	_, err = stapler.refresh()
+	c.Assert(err, NotNil)
+	c.Assert(err.ToGoError(), Equals, errInvalidOCSPResponse)
+	cert, _ = stapler.getCertificate(nil)
+	c.Assert(cert.OCSPStaple, IsNil)
+}
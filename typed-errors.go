@@ -69,5 +69,44 @@ var errPolicyAlreadyExpired = errors.New("Policy already expired")
 // errPolicyMissingFields means that form values and policy header have some fields missing.
 var errPolicyMissingFields = errors.New("Some fields are missing or do not match in policy")
 
+// errPolicyEntityTooSmall means that the uploaded file is smaller than the
+// minimum allowed by the policy content-length-range condition.
+var errPolicyEntityTooSmall = errors.New("Uploaded file is smaller than the policy content-length-range")
+
+// errPolicyEntityTooLarge means that the uploaded file is larger than the
+// maximum allowed by the policy content-length-range condition.
+var errPolicyEntityTooLarge = errors.New("Uploaded file is larger than the policy content-length-range")
+
+// errInvalidCredentialsCmd means that --credentials-cmd value is empty.
+var errInvalidCredentialsCmd = errors.New("Invalid credentials command")
+
+// errMalformedCredentials means that output of the credentials command
+// is not a json with valid accessKey and secretKey.
+var errMalformedCredentials = errors.New("Malformed credentials returned by credentials command")
+
+// errCredentialsUnavailable means that the last run of the credentials
+// command failed, all signed requests are rejected.
+var errCredentialsUnavailable = errors.New("Credentials unavailable")
+
 // errMissingDateHeader means that date header is missing
 var errMissingDateHeader = errors.New("Missing date header on the request")
+
+// errNoOCSPServer means that --ocsp-staple is set for a certificate
+// which names no OCSP responder.
+var errNoOCSPServer = errors.New("Certificate names no OCSP responder")
+
+// errNoIssuerCertificate means that --ocsp-staple is set but the
+// certificate file does not hold the certificate of its issuer.
+var errNoIssuerCertificate = errors.New("Certificate file holds no issuer certificate")
+
+// errInvalidOCSPResponse means that the OCSP responder sent a response
+// which is malformed, unsuccessful, expired or not for the certificate.
+var errInvalidOCSPResponse = errors.New("Invalid OCSP response")
+
+// errAdminCredentialsRequired means that an admin only RPC was called on
+// a server started with --anonymous, which takes unsigned requests.
+var errAdminCredentialsRequired = errors.New("Admin credentials required, server takes anonymous requests")
+
+// errIdempotentPutInProgress means that a PUT retried with an idempotency
+// key was still in progress when the retry stopped waiting for it.
+var errIdempotentPutInProgress = errors.New("PUT with the same idempotency key still in progress")
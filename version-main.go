@@ -16,7 +16,17 @@
 
 package main
 
-import "github.com/minio/cli"
+import (
+	"sort"
+	"strings"
+
+	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/erasure"
+)
+
+// minioXLDependencies - comma separated ‘path=version’ of bundled libraries, set at build
+// time by ‘make’ through -ldflags generated by buildscripts/gen-ldflags.go
+var minioXLDependencies = ""
 
 var versionCmd = cli.Command{
 	Name:   "version",
@@ -33,8 +43,62 @@ EXAMPLES:
 `,
 }
 
+// versionMessage - version and build information
+type versionMessage struct {
+	Version      string            `json:"version"`
+	ReleaseTag   string            `json:"releaseTag"`
+	CommitID     string            `json:"commitID"`
+	Dependencies map[string]string `json:"dependencies"`
+	Codec        string            `json:"codec"`
+}
+
+// getDependencies - parse bundled library versions, empty if the binary was not built with them
+func getDependencies(dependencies string) map[string]string {
+	versions := make(map[string]string)
+	for _, dependency := range strings.Split(dependencies, ",") {
+		parts := strings.SplitN(strings.TrimSpace(dependency), "=", 2)
+		if len(parts) != 2 || parts[0] == "" {
+			continue
+		}
+		versions[parts[0]] = parts[1]
+	}
+	return versions
+}
+
+func (v versionMessage) String() string {
+	message := "Version: " + v.Version + "\n"
+	message += "Release-Tag: " + v.ReleaseTag + "\n"
+	message += "Commit-ID: " + v.CommitID
+	if v.Codec != "" {
+		message += "\nErasure-Codec: " + v.Codec
+	}
+	if len(v.Dependencies) == 0 {
+		return message
+	}
+	var paths []string
+	for path := range v.Dependencies {
+		paths = append(paths, path)
+	}
+	sort.Strings(paths)
+	message += "\nDependencies:"
+	for _, path := range paths {
+		message += "\n  " + path + ": " + v.Dependencies[path]
+	}
+	return message
+}
+
+// JSON - json formatted output
+func (v versionMessage) JSON() string {
+	return jsonMessage(v)
+}
+
 func mainVersion(ctxx *cli.Context) {
-	Println("Version: " + minioXLVersion)
-	Println("Release-Tag: " + minioXLReleaseTag)
-	Println("Commit-ID: " + minioXLCommitID)
+	message := versionMessage{
+		Version:      minioXLVersion,
+		ReleaseTag:   minioXLReleaseTag,
+		CommitID:     minioXLCommitID,
+		Dependencies: getDependencies(minioXLDependencies),
+		Codec:        erasure.GetCodec(),
+	}
+	printMessage(message)
 }
@@ -17,10 +17,23 @@
 package main
 
 import (
+	"bufio"
+	"bytes"
+	"crypto/rand"
+	"encoding/json"
+	"fmt"
+	"io"
+	"mime"
 	"os"
 	"path/filepath"
+	"reflect"
+	"sort"
+	"strings"
+	"time"
 
+	"github.com/dustin/go-humanize"
 	"github.com/minio/cli"
+	"github.com/minio/minio-xl/pkg/erasure"
 	"github.com/minio/minio-xl/pkg/probe"
 	"github.com/minio/minio-xl/pkg/xl"
 )
@@ -45,6 +58,372 @@ EXAMPLES:
       $ minio-xl xl {{.Name}} operational-data /mnt/export1 /mnt/export2 /mnt/export3 /mnt/export4 /mnt/export5 \
        /mnt/export6 /mnt/export7 /mnt/export8 /mnt/export9 /mnt/export10 /mnt/export11 \
        /mnt/export12 /mnt/export13 /mnt/export14 /mnt/export15 /mnt/export16
+`,
+		},
+		{
+			Name:        "rebalance",
+			Description: "redistribute objects of a xl across all its disks",
+			Action:      rebalanceXLMain,
+			Flags: []cli.Flag{
+				cli.StringFlag{
+					Name:  "bandwidth",
+					Usage: "Limit rebalance to BANDWIDTH bytes per second, e.g. 10MB [DEFAULT: unlimited].",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Report objects which would be moved, nothing is written.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--bandwidth BANDWIDTH] [--dry-run] XL-NAME
+
+  Old blocks of moved objects are kept for servers which may still be reading them, they are
+  removed as orphans by the next ‘minio-xl xl fsck --fix’ or by --orphan-expiry once expired.
+
+EXAMPLES:
+  1. Rebalance a xl after adding new disks to its configuration
+      $ minio-xl xl {{.Name}} mongodb-backup
+
+  2. Rebalance a xl moving at most 10MB per second, progress is reported in json
+      $ minio-xl --json xl {{.Name}} --bandwidth 10MB operational-data
+
+  3. See how much data a rebalance would move, nothing is written
+      $ minio-xl xl {{.Name}} --dry-run mongodb-backup
+`,
+		},
+		{
+			Name:        "fsck",
+			Description: "check a xl for orphaned blocks and objects with missing blocks",
+			Action:      fsckXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "fix",
+					Usage: "Remove orphaned blocks and metadata, objects with missing blocks are only reported.",
+				},
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "With --fix report orphans which would be removed, nothing is modified.",
+				},
+				cli.BoolFlag{
+					Name:  "verify",
+					Usage: "Read every block and compare it with the checksums in the integrity file of its object.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--fix [--dry-run]] [--verify] XL-NAME
+
+  With --fix damaged object metadata is restored and corrupt blocks found by --verify are rebuilt.
+
+EXAMPLES:
+  1. Check a xl after a crash, nothing is modified
+      $ minio-xl xl {{.Name}} mongodb-backup
+
+  2. Reclaim space of orphaned blocks, problems and the summary are reported in json
+      $ minio-xl --json xl {{.Name}} --fix operational-data
+
+  3. See which orphans --fix would remove, reported exactly as a real run, nothing is modified
+      $ minio-xl xl {{.Name}} --fix --dry-run operational-data
+
+  4. Scrub a xl, blocks which do not match their checksums are rebuilt from parity
+      $ minio-xl xl {{.Name}} --verify --fix mongodb-backup
+`,
+		},
+		{
+			Name:        "repair-metadata",
+			Description: "rebuild lost object metadata from surviving copies, integrity files and blocks",
+			Action:      repairMetadataXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "Report objects whose metadata would be rebuilt, nothing is written.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--dry-run] XL-NAME [BUCKET[/OBJECT]]
+
+  Objects with no metadata left are rebuilt only once enough blocks match the checksums in their
+  integrity file, others are reported. Run it while the server is stopped.
+
+EXAMPLES:
+  1. Repair metadata of all objects of a xl after disks were damaged
+      $ minio-xl xl {{.Name}} mongodb-backup
+
+  2. See which objects of a bucket could be repaired, reported in json, nothing is written
+      $ minio-xl --json xl {{.Name}} --dry-run operational-data backups
+
+  3. Repair a single object
+      $ minio-xl xl {{.Name}} mongodb-backup backups/mongodb.tar.gz
+`,
+		},
+		{
+			Name:        "export-metadata",
+			Description: "export metadata of all buckets and objects of a xl as a json stream",
+			Action:      exportMetadataXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "resume",
+					Usage: "Continue an interrupted export to FILE after the last record written in full.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--resume] XL-NAME FILE
+
+  One json record per line, each bucket followed by its objects. FILE ‘-’ writes to stdout.
+
+EXAMPLES:
+  1. Back up metadata of a xl, the summary is reported in json
+      $ minio-xl --json xl {{.Name}} mongodb-backup /backup/mongodb-backup.json
+
+  2. Continue an export which was interrupted
+      $ minio-xl xl {{.Name}} --resume mongodb-backup /backup/mongodb-backup.json
+`,
+		},
+		{
+			Name:        "import-metadata",
+			Description: "import metadata exported by export-metadata, only objects whose data blocks are on disks",
+			Action:      importMetadataXLMain,
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} XL-NAME FILE
+
+  Buckets and objects which already exist are left alone. FILE ‘-’ reads from stdin.
+
+EXAMPLES:
+  1. Restore metadata of a xl after bucket metadata was lost
+      $ minio-xl xl {{.Name}} mongodb-backup /backup/mongodb-backup.json
+
+  2. Restore from a compressed export, records which are not imported are reported in json
+      $ zcat /backup/operational-data.json.gz | minio-xl --json xl {{.Name}} operational-data -
+`,
+		},
+		{
+			Name:        "bucket-stats",
+			Description: "number of objects of a bucket and their total size",
+			Action:      bucketStatsXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "rescan",
+					Usage: "Count objects by reading metadata of all of them, correcting the stored counters.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--rescan] BUCKET
+
+EXAMPLES:
+  1. Objects and bytes stored in a bucket, for billing
+      $ minio-xl --json xl {{.Name}} mongodb-backup
+
+  2. Recount a bucket, reports how far off the stored counters were
+      $ minio-xl xl {{.Name}} --rescan operational-data
+`,
+		},
+		{
+			Name:        "set-content-type",
+			Description: "set content type of objects without rewriting their data",
+			Action:      setContentTypeXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "default",
+					Usage: "Set the content type of objects uploaded to BUCKET without one from now on, removed if none is given.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} BUCKET[/PREFIX] CONTENT-TYPE
+  minio-xl xl {{.Name}} --default BUCKET [CONTENT-TYPE]
+
+EXAMPLES:
+  1. Fix the content type of a single object uploaded without one
+      $ minio-xl xl {{.Name}} website/index.html text/html
+
+  2. Serve all uploaded videos as mp4, number of objects updated is reported in json
+      $ minio-xl --json xl {{.Name}} media/videos/ video/mp4
+
+  3. Store photos uploaded without a content type as jpeg
+      $ minio-xl xl {{.Name}} --default photos image/jpeg
+`,
+		},
+		{
+			Name:        "touch",
+			Description: "set modification time of objects to now without rewriting them",
+			Action:      touchXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "prefix",
+					Usage: "Touch every object whose name starts with PREFIX.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} BUCKET/OBJECT
+  minio-xl xl {{.Name}} --prefix BUCKET[/PREFIX]
+
+EXAMPLES:
+  1. Make a sync tool pick up an object again
+      $ minio-xl xl {{.Name}} website/index.html
+
+  2. Touch all objects under a prefix, number of objects touched is reported in json
+      $ minio-xl --json xl {{.Name}} --prefix media/videos/
+`,
+		},
+		{
+			Name:        "list-locked",
+			Description: "list objects under legal hold, which cannot be deleted or overwritten",
+			Action:      listLockedXLMain,
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} BUCKET[/PREFIX]
+
+EXAMPLES:
+  1. Audit which objects of a bucket are locked
+      $ minio-xl xl {{.Name}} mongodb-backup
+
+  2. Locked objects under a prefix along with their modification time, in json
+      $ minio-xl --json xl {{.Name}} operational-data/2015/
+`,
+		},
+		{
+			Name:        "du",
+			Description: "report size and number of objects under a prefix",
+			Action:      duXLMain,
+			Flags: []cli.Flag{
+				cli.IntFlag{
+					Name:  "max-depth",
+					Usage: "Also report every prefix up to this many ‘/’ levels below PREFIX, largest first.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--max-depth N] BUCKET[/PREFIX]
+
+EXAMPLES:
+  1. Space used by a bucket
+      $ minio-xl xl {{.Name}} mongodb-backup
+
+  2. Which prefixes directly under ‘photos/’ use the most space
+      $ minio-xl xl {{.Name}} --max-depth 1 media/photos/
+
+  3. Same in json, sizes are in bytes
+      $ minio-xl --json xl {{.Name}} --max-depth 1 media/photos/
+`,
+		},
+		{
+			Name:        "delete-by-query",
+			Description: "delete all objects of a bucket with a user metadata value, found through its search index",
+			Action:      deleteByQueryXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "dry-run",
+					Usage: "List objects which would be deleted, nothing is deleted.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} [--dry-run] BUCKET KEY=VALUE
+
+  KEY is a user metadata key with or without the ‘x-amz-meta-’ prefix, in any case. Objects are
+  matched as by ‘minio-xl xl search’, objects under legal hold are reported and kept.
+
+EXAMPLES:
+  1. See which objects of a user would be deleted
+      $ minio-xl xl {{.Name}} --dry-run uploads user-id=1234
+
+  2. Delete all objects of a user, objects deleted and failures are reported in json
+      $ minio-xl --json xl {{.Name}} uploads user-id=1234
+`,
+		},
+		{
+			Name:        "search",
+			Description: "find objects of a bucket by user metadata, enabled per bucket",
+			Action:      searchXLMain,
+			Flags: []cli.Flag{
+				cli.BoolFlag{
+					Name:  "enable",
+					Usage: "Index user metadata of objects in BUCKET, servers keep the index up to date on every write.",
+				},
+				cli.BoolFlag{
+					Name:  "disable",
+					Usage: "Stop indexing BUCKET, its index is dropped.",
+				},
+			},
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} BUCKET KEY=VALUE
+  minio-xl xl {{.Name}} --enable|--disable BUCKET
+
+  KEY is a user metadata key with or without the ‘x-amz-meta-’ prefix, in any case.
+
+EXAMPLES:
+  1. Allow searching a bucket by metadata
+      $ minio-xl xl {{.Name}} --enable projects
+
+  2. Objects uploaded with ‘x-amz-meta-project: foo’, reported in json
+      $ minio-xl --json xl {{.Name}} projects project=foo
+`,
+		},
+		{
+			Name:        "info",
+			Description: "show checksums of an object kept in its integrity file",
+			Action:      infoXLMain,
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}} BUCKET/OBJECT
+
+EXAMPLES:
+  1. Checksums of every block of an object
+      $ minio-xl xl {{.Name}} backups/2015/mongodb.tar.gz
+
+  2. Checksums of an object reported in json
+      $ minio-xl --json xl {{.Name}} backups/2015/mongodb.tar.gz
+`,
+		},
+		{
+			Name:        "selftest",
+			Description: "check erasure coding on this machine and measure its speed",
+			Action:      selftestXLMain,
+			CustomHelpTemplate: `NAME:
+  minio-xl xl {{.Name}} - {{.Description}}
+
+USAGE:
+  minio-xl xl {{.Name}}
+
+EXAMPLES:
+  1. Codec in use, how fast it encodes and decodes 8 data and 8 parity blocks
+      $ minio-xl xl {{.Name}}
+
+  2. Compare with the portable codec
+      $ minio-xl --codec go xl {{.Name}}
 `,
 		},
 	}
@@ -96,5 +475,1135 @@ func makeXLMain(c *cli.Context) {
 		Fatalln(err.Trace())
 	}
 
-	Infoln("Success!")
+	printMessage(infoMessage{"Success!"})
+}
+
+// rebalanceProgress - printable rebalance progress, json output of a dry run is the same as of a real run
+type rebalanceProgress struct {
+	xl.RebalanceProgress
+	dryRun bool
+}
+
+func (r rebalanceProgress) String() string {
+	if !r.Moved {
+		return fmt.Sprintf("Skipped ‘%s/%s’, already balanced. Moved: %s", r.Bucket, r.Object, humanize.IBytes(uint64(r.MovedBytes)))
+	}
+	if r.dryRun {
+		return fmt.Sprintf("Would rebalance ‘%s/%s’ (%s). Moved: %s", r.Bucket, r.Object, humanize.IBytes(uint64(r.Size)),
+			humanize.IBytes(uint64(r.MovedBytes)))
+	}
+	return fmt.Sprintf("Rebalanced ‘%s/%s’ (%s). Moved: %s", r.Bucket, r.Object, humanize.IBytes(uint64(r.Size)),
+		humanize.IBytes(uint64(r.MovedBytes)))
+}
+
+// JSON - json formatted output
+func (r rebalanceProgress) JSON() string {
+	return jsonMessage(r)
+}
+
+func rebalanceXLMain(c *cli.Context) {
+	if !c.Args().Present() || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "rebalance", 1)
+	}
+	xlName := c.Args().First()
+	conf, err := xl.LoadConfig()
+	fatalIf(err.Trace(), "Unable to load xl config.", nil)
+	if conf.XLName != xlName {
+		Fatalf("Unknown xlname %s\n", xlName)
+	}
+
+	var rateLimit uint64
+	if bandwidth := c.String("bandwidth"); bandwidth != "" {
+		var e error
+		rateLimit, e = humanize.ParseBytes(bandwidth)
+		fatalIf(probe.NewError(e), "Invalid bandwidth "+bandwidth+".", nil)
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	dryRun := c.Bool("dry-run")
+	err = xlAPI.Rebalance(xl.RebalanceConfig{
+		RateLimit: int64(rateLimit),
+		DryRun:    dryRun,
+		Progress: func(progress xl.RebalanceProgress) {
+			printMessage(rebalanceProgress{progress, dryRun})
+		},
+	})
+	fatalIf(err.Trace(xlName), "Unable to rebalance xl.", nil)
+
+	if dryRun {
+		printMessage(infoMessage{"Dry run, nothing was written."})
+		return
+	}
+	printMessage(infoMessage{"Success!"})
+}
+
+// fsckProblem - printable fsck problem, json output of a dry run is the same as of a real run
+type fsckProblem struct {
+	xl.FsckProblem
+	dryRun bool
+}
+
+func (f fsckProblem) String() string {
+	switch f.Kind {
+	case xl.FsckCorrupt:
+		if !f.Recoverable {
+			return fmt.Sprintf("Unrecoverable ‘%s/%s’, %d blocks do not match their checksums.", f.Bucket, f.Object, f.CorruptBlocks)
+		}
+		if f.Fixed && f.dryRun {
+			return fmt.Sprintf("Would rebuild %d corrupt blocks of ‘%s/%s’.", f.CorruptBlocks, f.Bucket, f.Object)
+		}
+		if f.Fixed {
+			return fmt.Sprintf("Rebuilt %d corrupt blocks of ‘%s/%s’.", f.CorruptBlocks, f.Bucket, f.Object)
+		}
+		return fmt.Sprintf("Corrupt ‘%s/%s’, %d blocks do not match their checksums.", f.Bucket, f.Object, f.CorruptBlocks)
+	case xl.FsckMetadata:
+		if f.Mismatch {
+			return fmt.Sprintf("Metadata of ‘%s/%s’ does not match its integrity file.", f.Bucket, f.Object)
+		}
+		if f.Fixed && f.dryRun {
+			return fmt.Sprintf("Would restore metadata of ‘%s/%s’ on %d disks.", f.Bucket, f.Object, f.DamagedCopies)
+		}
+		if f.Fixed {
+			return fmt.Sprintf("Restored metadata of ‘%s/%s’ on %d disks.", f.Bucket, f.Object, f.DamagedCopies)
+		}
+		return fmt.Sprintf("Damaged metadata of ‘%s/%s’ on %d disks.", f.Bucket, f.Object, f.DamagedCopies)
+	case xl.FsckOrphan:
+		if f.Fixed && f.dryRun {
+			return fmt.Sprintf("Would remove orphan ‘%s’ (%s).", f.Path, humanize.IBytes(uint64(f.Size)))
+		}
+		if f.Fixed {
+			return fmt.Sprintf("Removed orphan ‘%s’ (%s).", f.Path, humanize.IBytes(uint64(f.Size)))
+		}
+		return fmt.Sprintf("Orphan ‘%s’ (%s).", f.Path, humanize.IBytes(uint64(f.Size)))
+	}
+	if !f.Recoverable {
+		return fmt.Sprintf("Unrecoverable ‘%s/%s’, too many blocks are missing.", f.Bucket, f.Object)
+	}
+	return fmt.Sprintf("Degraded ‘%s/%s’, %d blocks are missing.", f.Bucket, f.Object, f.MissingBlocks)
+}
+
+// JSON - json formatted output
+func (f fsckProblem) JSON() string {
+	return jsonMessage(f)
+}
+
+// fsckSummary - printable fsck summary
+type fsckSummary struct {
+	xl.FsckSummary
+	dryRun bool
+}
+
+func (f fsckSummary) String() string {
+	removed, repaired := "removed", "repaired"
+	if f.dryRun {
+		removed, repaired = "would be removed", "would be repaired"
+	}
+	return fmt.Sprintf("Checked %d objects in %d buckets. Orphans: %d (%s), %s: %d (%s). Dangling: %d, unrecoverable: %d. "+
+		"Corrupt: %d, damaged metadata: %d, %s: %d.",
+		f.Objects, f.Buckets, f.Orphans, humanize.IBytes(uint64(f.OrphanedBytes)), removed, f.Fixed, humanize.IBytes(uint64(f.ReclaimedBytes)),
+		f.Dangling, f.Unrecoverable, f.Corrupt, f.DamagedMetadata, repaired, f.Repaired)
+}
+
+// JSON - json formatted output
+func (f fsckSummary) JSON() string {
+	return jsonMessage(f)
+}
+
+func fsckXLMain(c *cli.Context) {
+	if !c.Args().Present() || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "fsck", 1)
+	}
+	xlName := c.Args().First()
+	conf, err := xl.LoadConfig()
+	fatalIf(err.Trace(), "Unable to load xl config.", nil)
+	if conf.XLName != xlName {
+		Fatalf("Unknown xlname %s\n", xlName)
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	dryRun := c.Bool("dry-run")
+	summary, err := xlAPI.Fsck(xl.FsckConfig{
+		Fix:    c.Bool("fix"),
+		DryRun: dryRun,
+		Verify: c.Bool("verify"),
+		Progress: func(problem xl.FsckProblem) {
+			printMessage(fsckProblem{problem, dryRun})
+		},
+	})
+	fatalIf(err.Trace(xlName), "Unable to check xl.", nil)
+
+	printMessage(fsckSummary{summary, dryRun})
+}
+
+// metadataRepairMessage - printable object with damaged metadata, json output of a dry run is
+// the same as of a real run
+type metadataRepairMessage struct {
+	xl.MetadataRepair
+	dryRun bool
+}
+
+func (m metadataRepairMessage) String() string {
+	if !m.Repaired {
+		return fmt.Sprintf("Unable to rebuild metadata of ‘%s/%s’, %s.", m.Bucket, m.Object, m.Reason)
+	}
+	from := "a readable copy"
+	if m.FromIntegrity {
+		from = fmt.Sprintf("its integrity file and %d verified blocks, user metadata is lost", m.VerifiedBlocks)
+	}
+	if m.dryRun {
+		return fmt.Sprintf("Would rebuild metadata of ‘%s/%s’ from %s.", m.Bucket, m.Object, from)
+	}
+	return fmt.Sprintf("Rebuilt metadata of ‘%s/%s’ from %s.", m.Bucket, m.Object, from)
+}
+
+// JSON - json formatted output
+func (m metadataRepairMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// metadataRepairSummary - printable metadata repair summary
+type metadataRepairSummary struct {
+	xl.RepairMetadataSummary
+	dryRun bool
+}
+
+func (m metadataRepairSummary) String() string {
+	repaired := "repaired"
+	if m.dryRun {
+		repaired = "would be repaired"
+	}
+	return fmt.Sprintf("Checked %d objects. Damaged metadata: %d, %s: %d, unrecoverable: %d.",
+		m.Objects, m.Damaged, repaired, m.Repaired, m.Unrecoverable)
+}
+
+// JSON - json formatted output
+func (m metadataRepairSummary) JSON() string {
+	return jsonMessage(m)
+}
+
+func repairMetadataXLMain(c *cli.Context) {
+	if len(c.Args()) < 1 || len(c.Args()) > 2 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "repair-metadata", 1)
+	}
+	xlName := c.Args().First()
+	bucket, object := strings.TrimPrefix(c.Args().Get(1), "/"), ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, object = bucket[:i], bucket[i+1:]
+	}
+	conf, err := xl.LoadConfig()
+	fatalIf(err.Trace(), "Unable to load xl config.", nil)
+	if conf.XLName != xlName {
+		Fatalf("Unknown xlname %s\n", xlName)
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	dryRun := c.Bool("dry-run")
+	summary, err := xlAPI.RepairMetadata(xl.RepairMetadataConfig{
+		Bucket: bucket,
+		Object: object,
+		DryRun: dryRun,
+		Progress: func(repair xl.MetadataRepair) {
+			printMessage(metadataRepairMessage{repair, dryRun})
+		},
+	})
+	fatalIf(err.Trace(xlName, bucket, object), "Unable to repair metadata.", nil)
+
+	printMessage(metadataRepairSummary{summary, dryRun})
+}
+
+// metadataExportMessage - printable summary of an export, a resumed export only counts
+// records written after StartAfter
+type metadataExportMessage struct {
+	File       string `json:"file"`
+	StartAfter string `json:"startAfter,omitempty"`
+	Buckets    int    `json:"buckets"`
+	Objects    int    `json:"objects"`
+}
+
+func (m metadataExportMessage) String() string {
+	if m.StartAfter != "" {
+		return fmt.Sprintf("Exported %d buckets and %d objects to ‘%s’ after ‘%s’.", m.Buckets, m.Objects, m.File, m.StartAfter)
+	}
+	return fmt.Sprintf("Exported %d buckets and %d objects to ‘%s’.", m.Buckets, m.Objects, m.File)
+}
+
+// JSON - json formatted output
+func (m metadataExportMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// exportMetadata - write a record per line of all buckets and objects after startAfter
+func exportMetadata(storage xl.Interface, w io.Writer, startAfter string) (metadataExportMessage, *probe.Error) {
+	message := metadataExportMessage{StartAfter: startAfter}
+	encoder := json.NewEncoder(w)
+	err := storage.ExportMetadata(startAfter, func(record xl.MetadataRecord) *probe.Error {
+		if e := encoder.Encode(record); e != nil {
+			return probe.NewError(e)
+		}
+		if record.Object == "" {
+			message.Buckets++
+		} else {
+			message.Objects++
+		}
+		return nil
+	})
+	if err != nil {
+		return message, err.Trace()
+	}
+	return message, nil
+}
+
+// resumeMetadataExport - key of the last record written in full to file, a partially
+// written record after it is truncated and file is positioned to append
+func resumeMetadataExport(file *os.File) (string, *probe.Error) {
+	reader := bufio.NewReader(file)
+	var offset int64
+	var lastKey string
+	for {
+		line, e := reader.ReadBytes('\n')
+		if e == io.EOF {
+			break
+		}
+		if e != nil {
+			return "", probe.NewError(e)
+		}
+		var record xl.MetadataRecord
+		if json.Unmarshal(line, &record) != nil {
+			break
+		}
+		offset += int64(len(line))
+		lastKey = record.Key()
+	}
+	if e := file.Truncate(offset); e != nil {
+		return "", probe.NewError(e)
+	}
+	if _, e := file.Seek(offset, os.SEEK_SET); e != nil {
+		return "", probe.NewError(e)
+	}
+	return lastKey, nil
+}
+
+func exportMetadataXLMain(c *cli.Context) {
+	if len(c.Args()) != 2 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "export-metadata", 1)
+	}
+	xlName, fileName := c.Args().First(), c.Args().Get(1)
+	conf, err := xl.LoadConfig()
+	fatalIf(err.Trace(), "Unable to load xl config.", nil)
+	if conf.XLName != xlName {
+		Fatalf("Unknown xlname %s\n", xlName)
+	}
+
+	var startAfter string
+	var w io.Writer = os.Stdout
+	if fileName != "-" {
+		flags := os.O_WRONLY | os.O_CREATE | os.O_TRUNC
+		if c.Bool("resume") {
+			flags = os.O_RDWR | os.O_CREATE
+		}
+		file, e := os.OpenFile(fileName, flags, 0600)
+		fatalIf(probe.NewError(e), "Unable to open export file.", nil)
+		defer file.Close()
+		if c.Bool("resume") {
+			startAfter, err = resumeMetadataExport(file)
+			fatalIf(err.Trace(fileName), "Unable to resume export.", nil)
+		}
+		w = file
+	} else if c.Bool("resume") {
+		Fatalln("Only exports to a file can be resumed.")
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	message, err := exportMetadata(xlAPI, w, startAfter)
+	fatalIf(err.Trace(xlName), "Unable to export metadata.", nil)
+	if fileName == "-" {
+		// records are on stdout, the summary must not be mixed with them
+		return
+	}
+	message.File = fileName
+	printMessage(message)
+}
+
+// metadataImportResult - printable record which was not imported
+type metadataImportResult struct {
+	xl.MetadataImportResult
+}
+
+func (r metadataImportResult) String() string {
+	if r.Object == "" {
+		return fmt.Sprintf("Skipped bucket ‘%s’, %s.", r.Bucket, r.Reason)
+	}
+	return fmt.Sprintf("Skipped ‘%s/%s’, %s.", r.Bucket, r.Object, r.Reason)
+}
+
+// JSON - json formatted output
+func (r metadataImportResult) JSON() string {
+	return jsonMessage(r)
+}
+
+// metadataImportMessage - printable summary of an import
+type metadataImportMessage struct {
+	Buckets int `json:"buckets"`
+	Objects int `json:"objects"`
+	Skipped int `json:"skipped"`
+}
+
+func (m metadataImportMessage) String() string {
+	return fmt.Sprintf("Imported %d buckets and %d objects, skipped %d records.", m.Buckets, m.Objects, m.Skipped)
+}
+
+// JSON - json formatted output
+func (m metadataImportMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// metadataImportBatch - records imported at once, bucket metadata is written once per batch
+const metadataImportBatch = 1000
+
+// importMetadata - import records read from r in batches, skipped is called for every
+// record which was not imported
+func importMetadata(storage xl.Interface, r io.Reader, skipped func(xl.MetadataImportResult)) (metadataImportMessage, *probe.Error) {
+	var message metadataImportMessage
+	decoder := json.NewDecoder(r)
+	for done := false; !done; {
+		var records []xl.MetadataRecord
+		for len(records) < metadataImportBatch {
+			var record xl.MetadataRecord
+			if e := decoder.Decode(&record); e != nil {
+				if e != io.EOF {
+					return message, probe.NewError(e)
+				}
+				done = true
+				break
+			}
+			records = append(records, record)
+		}
+		if len(records) == 0 {
+			break
+		}
+		results, err := storage.ImportMetadata(records)
+		if err != nil {
+			return message, err.Trace()
+		}
+		for _, result := range results {
+			switch {
+			case !result.Imported:
+				message.Skipped++
+				skipped(result)
+			case result.Object == "":
+				message.Buckets++
+			default:
+				message.Objects++
+			}
+		}
+	}
+	return message, nil
+}
+
+func importMetadataXLMain(c *cli.Context) {
+	if len(c.Args()) != 2 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "import-metadata", 1)
+	}
+	xlName, fileName := c.Args().First(), c.Args().Get(1)
+	conf, err := xl.LoadConfig()
+	fatalIf(err.Trace(), "Unable to load xl config.", nil)
+	if conf.XLName != xlName {
+		Fatalf("Unknown xlname %s\n", xlName)
+	}
+
+	var r io.Reader = os.Stdin
+	if fileName != "-" {
+		file, e := os.Open(fileName)
+		fatalIf(probe.NewError(e), "Unable to open import file.", nil)
+		defer file.Close()
+		r = file
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	message, err := importMetadata(xlAPI, r, func(result xl.MetadataImportResult) {
+		printMessage(metadataImportResult{result})
+	})
+	fatalIf(err.Trace(xlName, fileName), "Unable to import metadata.", nil)
+	printMessage(message)
+}
+
+// bucketStatsMessage - printable bucket statistics, after a rescan along with how far off
+// the counters it replaced were
+type bucketStatsMessage struct {
+	xl.BucketStats
+	Rescanned bool `json:"rescanned"`
+	// ObjectsDiff, SizeDiff counters minus the rescan, zero unless the counters were off
+	ObjectsDiff int64 `json:"objectsDiff"`
+	SizeDiff    int64 `json:"sizeDiff"`
+}
+
+func (b bucketStatsMessage) String() string {
+	message := fmt.Sprintf("Bucket ‘%s’: %d objects, %s.", b.Bucket, b.Objects, humanize.IBytes(uint64(b.Size)))
+	if b.ObjectsDiff != 0 || b.SizeDiff != 0 {
+		message += fmt.Sprintf(" Counters were off by %+d objects, %+d bytes, corrected.", b.ObjectsDiff, b.SizeDiff)
+	}
+	return message
+}
+
+// JSON - json formatted output
+func (b bucketStatsMessage) JSON() string {
+	return jsonMessage(b)
+}
+
+func bucketStatsXLMain(c *cli.Context) {
+	if !c.Args().Present() || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "bucket-stats", 1)
+	}
+	bucket := c.Args().First()
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	stats, err := xlAPI.GetBucketStats(bucket)
+	fatalIf(err.Trace(bucket), "Unable to get bucket stats.", nil)
+	if !c.Bool("rescan") {
+		printMessage(bucketStatsMessage{BucketStats: stats})
+		return
+	}
+	rescanned, err := xlAPI.RescanBucketStats(bucket)
+	fatalIf(err.Trace(bucket), "Unable to rescan bucket.", nil)
+	printMessage(bucketStatsMessage{
+		BucketStats: rescanned,
+		Rescanned:   true,
+		ObjectsDiff: stats.Objects - rescanned.Objects,
+		SizeDiff:    stats.Size - rescanned.Size,
+	})
+}
+
+// contentTypeMessage - printable number of objects whose content type was set, objects
+// which already had it are not updated
+type contentTypeMessage struct {
+	Bucket      string `json:"bucket"`
+	Prefix      string `json:"prefix"`
+	ContentType string `json:"contentType"`
+	Objects     int    `json:"objects"`
+	Updated     int    `json:"updated"`
+}
+
+func (m contentTypeMessage) String() string {
+	return fmt.Sprintf("Content type of %d out of %d objects under ‘%s/%s’ set to ‘%s’.", m.Updated, m.Objects, m.Bucket, m.Prefix, m.ContentType)
+}
+
+// JSON - json formatted output
+func (m contentTypeMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// setContentType - set content type of all objects under prefix, only their metadata is
+// rewritten so data blocks and ETags stay the same
+func setContentType(storage xl.Interface, bucket, prefix, contentType string) (contentTypeMessage, *probe.Error) {
+	message := contentTypeMessage{Bucket: bucket, Prefix: prefix, ContentType: contentType}
+	resources := xl.BucketResourcesMetadata{Prefix: prefix, Maxkeys: 1000}
+	for {
+		results, nextResources, err := storage.ListObjects(bucket, resources)
+		if err != nil {
+			return message, err.Trace(bucket, prefix)
+		}
+		for _, object := range results {
+			message.Objects++
+			if object.Metadata["contentType"] == contentType {
+				continue
+			}
+			if _, err := storage.SetObjectMetadata(bucket, object.Object, map[string]string{"contentType": contentType}); err != nil {
+				return message, err.Trace(bucket, object.Object)
+			}
+			message.Updated++
+		}
+		if !nextResources.IsTruncated || len(results) == 0 {
+			break
+		}
+		resources.Marker = results[len(results)-1].Object
+	}
+	return message, nil
+}
+
+func setContentTypeXLMain(c *cli.Context) {
+	if c.Bool("default") {
+		setDefaultContentTypeXLMain(c)
+		return
+	}
+	if len(c.Args()) != 2 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "set-content-type", 1)
+	}
+	bucket, prefix := c.Args().First(), ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, prefix = bucket[:i], bucket[i+1:]
+	}
+	contentType := c.Args().Get(1)
+	if _, _, e := mime.ParseMediaType(contentType); e != nil {
+		Fatalf("Invalid content type %s\n", contentType)
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	message, err := setContentType(xlAPI, bucket, prefix, contentType)
+	fatalIf(err.Trace(bucket, prefix), "Unable to set content type.", nil)
+	printMessage(message)
+}
+
+// setDefaultContentTypeXLMain - default content type is saved in bucket metadata, servers apply it
+// to uploads without a content type of their own once they are restarted
+func setDefaultContentTypeXLMain(c *cli.Context) {
+	if !c.Args().Present() || len(c.Args()) > 2 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "set-content-type", 1)
+	}
+	bucket, contentType := c.Args().First(), c.Args().Get(1)
+	if contentType != "" {
+		if _, _, e := mime.ParseMediaType(contentType); e != nil {
+			Fatalf("Invalid content type %s\n", contentType)
+		}
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	err = xlAPI.SetBucketMetadata(bucket, map[string]string{xl.DefaultContentTypeKey: contentType})
+	fatalIf(err.Trace(bucket), "Unable to set bucket metadata.", nil)
+	message := "Default content type of ‘" + bucket + "’ removed."
+	if contentType != "" {
+		message = "Default content type of ‘" + bucket + "’ set to ‘" + contentType + "’."
+	}
+	// running servers loaded bucket metadata when they started
+	message += " Servers already running keep their default until restarted, Server.SetDefaultContentType changes it on a running server."
+	printMessage(infoMessage{message})
+}
+
+// touchMessage - printable number of objects whose modification time was set to now
+type touchMessage struct {
+	Bucket  string `json:"bucket"`
+	Key     string `json:"key"`
+	Prefix  bool   `json:"prefix"`
+	Touched int    `json:"touched"`
+}
+
+func (m touchMessage) String() string {
+	if !m.Prefix {
+		return fmt.Sprintf("Touched ‘%s/%s’.", m.Bucket, m.Key)
+	}
+	return fmt.Sprintf("Touched %d objects under ‘%s/%s’.", m.Touched, m.Bucket, m.Key)
+}
+
+// JSON - json formatted output
+func (m touchMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// touchObjects - set modification time of an object, or of all objects under key with prefix,
+// to now. Only their metadata is rewritten so data blocks and ETags stay the same
+func touchObjects(storage xl.Interface, bucket, key string, prefix bool) (touchMessage, *probe.Error) {
+	message := touchMessage{Bucket: bucket, Key: key, Prefix: prefix}
+	if !prefix {
+		if _, err := storage.TouchObject(bucket, key); err != nil {
+			return message, err.Trace(bucket, key)
+		}
+		message.Touched++
+		return message, nil
+	}
+	resources := xl.BucketResourcesMetadata{Prefix: key, Maxkeys: 1000}
+	for {
+		results, nextResources, err := storage.ListObjects(bucket, resources)
+		if err != nil {
+			return message, err.Trace(bucket, key)
+		}
+		for _, object := range results {
+			if _, err := storage.TouchObject(bucket, object.Object); err != nil {
+				return message, err.Trace(bucket, object.Object)
+			}
+			message.Touched++
+		}
+		if !nextResources.IsTruncated || len(results) == 0 {
+			break
+		}
+		resources.Marker = results[len(results)-1].Object
+	}
+	return message, nil
+}
+
+func touchXLMain(c *cli.Context) {
+	if len(c.Args()) != 1 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "touch", 1)
+	}
+	bucket, key := strings.TrimPrefix(c.Args().First(), "/"), ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, key = bucket[:i], bucket[i+1:]
+	}
+	if bucket == "" || (key == "" && !c.Bool("prefix")) {
+		Fatalf("Invalid object %s, BUCKET/OBJECT expected\n", c.Args().First())
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	message, err := touchObjects(xlAPI, bucket, key, c.Bool("prefix"))
+	fatalIf(err.Trace(bucket, key), "Unable to touch objects.", nil)
+	printMessage(message)
+}
+
+// lockedObject - object under legal hold
+type lockedObject struct {
+	Key          string    `json:"key"`
+	LegalHold    string    `json:"legalHold"`
+	LastModified time.Time `json:"lastModified"`
+}
+
+// lockedMessage - printable objects under legal hold
+type lockedMessage struct {
+	Bucket  string         `json:"bucket"`
+	Prefix  string         `json:"prefix"`
+	Objects []lockedObject `json:"objects"`
+}
+
+func (m lockedMessage) String() string {
+	if len(m.Objects) == 0 {
+		return fmt.Sprintf("No locked objects under ‘%s/%s’.", m.Bucket, m.Prefix)
+	}
+	var lines []string
+	for _, object := range m.Objects {
+		lines = append(lines, fmt.Sprintf("%s  %s  legal hold", object.LastModified.Format(time.RFC1123), object.Key))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON - json formatted output
+func (m lockedMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// listLockedObjects - objects under prefix with legal hold set, their status is part of the
+// metadata listings read anyway so no object is looked up on its own
+func listLockedObjects(storage xl.Interface, bucket, prefix string) (lockedMessage, *probe.Error) {
+	message := lockedMessage{Bucket: bucket, Prefix: prefix, Objects: []lockedObject{}}
+	resources := xl.BucketResourcesMetadata{Prefix: prefix, Maxkeys: 1000}
+	for {
+		results, nextResources, err := storage.ListObjects(bucket, resources)
+		if err != nil {
+			return message, err.Trace(bucket, prefix)
+		}
+		for _, object := range results {
+			if object.Metadata["legalHold"] == xl.LegalHoldOn {
+				message.Objects = append(message.Objects, lockedObject{
+					Key:          object.Object,
+					LegalHold:    xl.LegalHoldOn,
+					LastModified: object.Created,
+				})
+			}
+		}
+		if !nextResources.IsTruncated || len(results) == 0 {
+			break
+		}
+		resources.Marker = results[len(results)-1].Object
+	}
+	return message, nil
+}
+
+func listLockedXLMain(c *cli.Context) {
+	if len(c.Args()) != 1 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "list-locked", 1)
+	}
+	bucket, prefix := strings.TrimPrefix(c.Args().First(), "/"), ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, prefix = bucket[:i], bucket[i+1:]
+	}
+	if bucket == "" {
+		Fatalf("Invalid bucket %s, BUCKET[/PREFIX] expected\n", c.Args().First())
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	message, err := listLockedObjects(xlAPI, bucket, prefix)
+	fatalIf(err.Trace(bucket, prefix), "Unable to list locked objects.", nil)
+	printMessage(message)
+}
+
+// prefixUsage - size and number of objects under a prefix
+type prefixUsage struct {
+	Prefix  string `json:"prefix"`
+	Size    int64  `json:"size"`
+	Objects int    `json:"objects"`
+}
+
+// duMessage - printable usage of a prefix along with prefixes below it
+type duMessage struct {
+	Bucket string `json:"bucket"`
+	prefixUsage
+	Prefixes []prefixUsage `json:"prefixes,omitempty"`
+}
+
+func (m duMessage) String() string {
+	var lines []string
+	for _, usage := range append(m.Prefixes, m.prefixUsage) {
+		lines = append(lines, fmt.Sprintf("%10s  %8d objects  %s/%s", humanize.IBytes(uint64(usage.Size)), usage.Objects, m.Bucket, usage.Prefix))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON - json formatted output
+func (m duMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// diskUsage - total size and number of objects under prefix, along with every prefix up to
+// maxDepth ‘/’ levels below it. Sizes come from the metadata listings, no object is read
+func diskUsage(storage xl.Interface, bucket, prefix string, maxDepth int) (duMessage, *probe.Error) {
+	message := duMessage{Bucket: bucket, prefixUsage: prefixUsage{Prefix: prefix}}
+	prefixes := make(map[string]*prefixUsage)
+	resources := xl.BucketResourcesMetadata{Prefix: prefix, Maxkeys: 1000}
+	for {
+		results, nextResources, err := storage.ListObjects(bucket, resources)
+		if err != nil {
+			return message, err.Trace(bucket, prefix)
+		}
+		for _, object := range results {
+			message.Size += object.Size
+			message.Objects++
+			rest := strings.TrimPrefix(object.Object, prefix)
+			for depth, end := 0, 0; depth < maxDepth; depth++ {
+				i := strings.Index(rest[end:], "/")
+				if i < 0 {
+					break
+				}
+				end += i + 1
+				usage, ok := prefixes[prefix+rest[:end]]
+				if !ok {
+					usage = &prefixUsage{Prefix: prefix + rest[:end]}
+					prefixes[usage.Prefix] = usage
+				}
+				usage.Size += object.Size
+				usage.Objects++
+			}
+		}
+		if !nextResources.IsTruncated || len(results) == 0 {
+			break
+		}
+		resources.Marker = results[len(results)-1].Object
+	}
+	for _, usage := range prefixes {
+		message.Prefixes = append(message.Prefixes, *usage)
+	}
+	sort.Sort(prefixUsageBySize(message.Prefixes))
+	return message, nil
+}
+
+// prefixUsageBySize - largest first, prefixes of the same size by name
+type prefixUsageBySize []prefixUsage
+
+func (s prefixUsageBySize) Len() int      { return len(s) }
+func (s prefixUsageBySize) Swap(i, j int) { s[i], s[j] = s[j], s[i] }
+func (s prefixUsageBySize) Less(i, j int) bool {
+	if s[i].Size != s[j].Size {
+		return s[i].Size > s[j].Size
+	}
+	return s[i].Prefix < s[j].Prefix
+}
+
+func duXLMain(c *cli.Context) {
+	if len(c.Args()) != 1 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "du", 1)
+	}
+	bucket, prefix := strings.TrimPrefix(c.Args().First(), "/"), ""
+	if i := strings.Index(bucket, "/"); i >= 0 {
+		bucket, prefix = bucket[:i], bucket[i+1:]
+	}
+	if bucket == "" {
+		Fatalf("Invalid bucket %s, BUCKET[/PREFIX] expected\n", c.Args().First())
+	}
+	if c.Int("max-depth") < 0 {
+		Fatalf("Invalid --max-depth %d, must not be negative\n", c.Int("max-depth"))
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	message, err := diskUsage(xlAPI, bucket, prefix, c.Int("max-depth"))
+	fatalIf(err.Trace(bucket, prefix), "Unable to report disk usage.", nil)
+	printMessage(message)
+}
+
+// searchMessage - printable objects whose user metadata matched
+type searchMessage struct {
+	Bucket  string   `json:"bucket"`
+	Key     string   `json:"key"`
+	Value   string   `json:"value"`
+	Objects []string `json:"objects"`
+}
+
+func (m searchMessage) String() string {
+	if len(m.Objects) == 0 {
+		return fmt.Sprintf("No objects in ‘%s’ with %s=%s.", m.Bucket, m.Key, m.Value)
+	}
+	return strings.Join(m.Objects, "\n")
+}
+
+// JSON - json formatted output
+func (m searchMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// deleteByQueryMessage - printable objects deleted by user metadata
+type deleteByQueryMessage struct {
+	DeleteByQueryRep
+}
+
+func (m deleteByQueryMessage) String() string {
+	var lines []string
+	failed := make(map[string]string)
+	for _, failure := range m.Failures {
+		failed[failure.Object] = failure.Error
+	}
+	for _, object := range m.Matched {
+		switch e, ok := failed[object]; {
+		case m.DryRun:
+			lines = append(lines, fmt.Sprintf("Would delete ‘%s/%s’.", m.Bucket, object))
+		case ok:
+			lines = append(lines, fmt.Sprintf("Unable to delete ‘%s/%s’, %s.", m.Bucket, object, e))
+		default:
+			lines = append(lines, fmt.Sprintf("Deleted ‘%s/%s’.", m.Bucket, object))
+		}
+	}
+	if m.DryRun {
+		lines = append(lines, fmt.Sprintf("%d objects in ‘%s’ with %s=%s would be deleted.", len(m.Matched), m.Bucket, m.Key, m.Value))
+	} else {
+		lines = append(lines, fmt.Sprintf("Deleted %d of %d objects in ‘%s’ with %s=%s, %d failed.", m.Deleted, len(m.Matched), m.Bucket, m.Key, m.Value, len(m.Failures)))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON - json formatted output
+func (m deleteByQueryMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// deleteByQuery - delete all objects of a bucket whose user metadata key has value as a DELETE
+// of each would, found through the metadata index. Objects changed since they were found, or
+// under legal hold, are reported as failures and left alone
+func deleteByQuery(storage xl.Interface, args DeleteByQueryArgs) (DeleteByQueryRep, *probe.Error) {
+	rep := DeleteByQueryRep{Bucket: args.Bucket, Key: args.Key, Value: args.Value, DryRun: args.DryRun, Matched: []string{}, Failures: []DeleteFailure{}}
+	objects, err := storage.SearchMetadata(args.Bucket, args.Key, args.Value)
+	if err != nil {
+		return rep, err.Trace(args.Bucket, args.Key)
+	}
+	if objects != nil {
+		rep.Matched = objects
+	}
+	if args.DryRun {
+		return rep, nil
+	}
+	for _, object := range rep.Matched {
+		objMetadata, err := storage.GetObjectMetadata(args.Bucket, object)
+		if err == nil {
+			err = storage.DeleteObject(args.Bucket, object, objMetadata.MD5Sum)
+		}
+		if err != nil {
+			rep.Failures = append(rep.Failures, DeleteFailure{Object: object, Error: err.ToGoError().Error()})
+			continue
+		}
+		globalReplicator.objectDeleted(args.Bucket, object, objMetadata.MD5Sum)
+		rep.Deleted++
+	}
+	return rep, nil
+}
+
+func deleteByQueryXLMain(c *cli.Context) {
+	if len(c.Args()) != 2 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "delete-by-query", 1)
+	}
+	bucket, query := c.Args().First(), c.Args().Get(1)
+	i := strings.Index(query, "=")
+	if i <= 0 {
+		Fatalf("Invalid query %s, KEY=VALUE expected\n", query)
+	}
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	rep, err := deleteByQuery(xlAPI, DeleteByQueryArgs{Bucket: bucket, Key: query[:i], Value: query[i+1:], DryRun: c.Bool("dry-run")})
+	fatalIf(err.Trace(bucket, query), "Unable to delete objects.", nil)
+	printMessage(deleteByQueryMessage{rep})
+}
+
+// integrityMessage - printable integrity file of an object
+type integrityMessage struct {
+	Bucket string `json:"bucket"`
+	Object string `json:"object"`
+	xl.ObjectIntegrity
+}
+
+func (m integrityMessage) String() string {
+	lines := []string{
+		fmt.Sprintf("Object:  %s/%s", m.Bucket, m.Object),
+		fmt.Sprintf("Created: %s", m.Created.Format(time.RFC1123)),
+		fmt.Sprintf("Size:    %s", humanize.IBytes(uint64(m.Size))),
+	}
+	if m.DataDisks > 0 {
+		lines = append(lines, fmt.Sprintf("Erasure: %d data, %d parity blocks", m.DataDisks, m.ParityDisks))
+	}
+	lines = append(lines, fmt.Sprintf("MD5:     %s", m.MD5Sum), fmt.Sprintf("SHA512:  %s", m.SHA512Sum))
+	var orders []int
+	for order := range m.Blocks {
+		orders = append(orders, order)
+	}
+	sort.Ints(orders)
+	for _, order := range orders {
+		lines = append(lines, fmt.Sprintf("Block %d: %s", order, m.Blocks[order]))
+	}
+	return strings.Join(lines, "\n")
+}
+
+// JSON - json formatted output
+func (m integrityMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+func infoXLMain(c *cli.Context) {
+	if len(c.Args()) != 1 || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "info", 1)
+	}
+	path := strings.TrimPrefix(c.Args().First(), "/")
+	i := strings.Index(path, "/")
+	if i <= 0 || i == len(path)-1 {
+		Fatalf("Invalid object %s, BUCKET/OBJECT expected\n", path)
+	}
+	bucket, object := path[:i], path[i+1:]
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	integrity, err := xlAPI.GetObjectIntegrity(bucket, object)
+	fatalIf(err.Trace(bucket, object), "Unable to read integrity of object.", nil)
+	printMessage(integrityMessage{Bucket: bucket, Object: object, ObjectIntegrity: integrity})
+}
+
+func searchXLMain(c *cli.Context) {
+	if !c.Args().Present() || c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "search", 1)
+	}
+	bucket := c.Args().First()
+	if c.Bool("enable") || c.Bool("disable") {
+		if len(c.Args()) != 1 || (c.Bool("enable") && c.Bool("disable")) {
+			cli.ShowCommandHelpAndExit(c, "search", 1)
+		}
+		xlAPI, err := xl.New()
+		fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+		value, message := "", "Metadata search disabled for ‘"+bucket+"’."
+		if c.Bool("enable") {
+			value, message = xl.MetadataIndexEnabled, "Metadata search enabled for ‘"+bucket+"’."
+		}
+		err = xlAPI.SetBucketMetadata(bucket, map[string]string{xl.MetadataIndexKey: value})
+		fatalIf(err.Trace(bucket), "Unable to set bucket metadata.", nil)
+		printMessage(infoMessage{message})
+		return
+	}
+	if len(c.Args()) != 2 {
+		cli.ShowCommandHelpAndExit(c, "search", 1)
+	}
+	query := c.Args().Get(1)
+	i := strings.Index(query, "=")
+	if i <= 0 {
+		Fatalf("Invalid search %s, KEY=VALUE expected\n", query)
+	}
+	key, value := query[:i], query[i+1:]
+
+	xlAPI, err := xl.New()
+	fatalIf(err.Trace(), "Unable to initialize xl.", nil)
+
+	objects, err := xlAPI.SearchMetadata(bucket, key, value)
+	fatalIf(err.Trace(bucket, key), "Unable to search bucket.", nil)
+	if objects == nil {
+		objects = []string{}
+	}
+	printMessage(searchMessage{Bucket: bucket, Key: key, Value: value, Objects: objects})
+}
+
+// selftestMessage - codec checked and its speed, with as many blocks lost as there is parity
+type selftestMessage struct {
+	Codec        string `json:"codec"`
+	DataBlocks   int    `json:"dataBlocks"`
+	ParityBlocks int    `json:"parityBlocks"`
+	EncodeSpeed  int64  `json:"encodeBytesPerSecond"`
+	DecodeSpeed  int64  `json:"decodeBytesPerSecond"`
+}
+
+func (m selftestMessage) String() string {
+	return fmt.Sprintf("Erasure codec: %s, %d data and %d parity blocks. Encode: %s/s, Decode: %s/s", m.Codec, m.DataBlocks,
+		m.ParityBlocks, humanize.IBytes(uint64(m.EncodeSpeed)), humanize.IBytes(uint64(m.DecodeSpeed)))
+}
+
+// JSON - json formatted output
+func (m selftestMessage) JSON() string {
+	return jsonMessage(m)
+}
+
+// selftest - encode and decode rounds of random data of size with the codec in use, blocks
+// must be the same as those of the other codec since either one reads blocks on disks
+func selftest(k, m uint8, size, rounds int) (selftestMessage, *probe.Error) {
+	message := selftestMessage{Codec: erasure.GetCodec(), DataBlocks: int(k), ParityBlocks: int(m)}
+	data := make([]byte, size)
+	if _, e := rand.Read(data); e != nil {
+		return selftestMessage{}, probe.NewError(e)
+	}
+	encoder := erasure.NewErasure(&erasure.Params{K: k, M: m})
+	var blocks [][]byte
+	start := time.Now()
+	for i := 0; i < rounds; i++ {
+		var e error
+		if blocks, e = encoder.Encode(data); e != nil {
+			return selftestMessage{}, probe.NewError(e)
+		}
+	}
+	message.EncodeSpeed = int64(float64(size*rounds) / time.Since(start).Seconds())
+
+	other := erasure.CodecGo
+	if strings.HasPrefix(message.Codec, erasure.CodecGo) {
+		other = erasure.CodecISAL
+	}
+	expected, e := erasure.NewErasure(&erasure.Params{K: k, M: m, Codec: other}).Encode(data)
+	if e != nil {
+		return selftestMessage{}, probe.NewError(e)
+	}
+	if !reflect.DeepEqual(blocks, expected) {
+		return selftestMessage{}, probe.NewError(fmt.Errorf("Blocks encoded by %s differ from those of %s", message.Codec, other))
+	}
+
+	// data blocks are lost first, decoding them is the most work
+	start = time.Now()
+	for i := 0; i < rounds; i++ {
+		lost := make([][]byte, len(blocks))
+		copy(lost[m:], blocks[m:])
+		decoded, e := encoder.Decode(lost, size)
+		if e != nil {
+			return selftestMessage{}, probe.NewError(e)
+		}
+		if !bytes.Equal(decoded, data) {
+			return selftestMessage{}, probe.NewError(fmt.Errorf("Data decoded by %s differs from the original", message.Codec))
+		}
+	}
+	message.DecodeSpeed = int64(float64(size*rounds) / time.Since(start).Seconds())
+	return message, nil
+}
+
+func selftestXLMain(c *cli.Context) {
+	if c.Args().First() == "help" {
+		cli.ShowCommandHelpAndExit(c, "selftest", 1)
+	}
+	// chunks of 10MiB in 8 data and 8 parity blocks, as objects are written on 16 disks
+	message, err := selftest(8, 8, 10*1024*1024, 8)
+	fatalIf(err.Trace(), "Erasure coding selftest failed.", nil)
+	printMessage(message)
 }